@@ -0,0 +1,204 @@
+package m3u8
+
+/*
+ Part of M3U8 parser & generator library.
+ This file defines the alignment check of renditions.
+
+ Copyright 2013-2019 The Project Developers.
+ See the AUTHORS and LICENSE files at the top-level directory of this distribution
+ and at https://github.com/grafov/m3u8/
+
+ ॐ तारे तुत्तारे तुरे स्व
+*/
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// CheckAlignment verifies that media playlists of renditions of the
+// same presentation (video variants, audio, subtitles) are aligned:
+// they have the same media sequence numbers and segment counts,
+// durations of corresponding segments differ by no more than the
+// tolerance (in seconds), discontinuities are at the same positions
+// and program date times of corresponding segments, where both are
+// set, differ by no more than the tolerance. Misaligned renditions
+// are a common cause of player stalls on switching. The first
+// playlist is the reference one, the error describes the first
+// mismatch found and refers to playlists by their positions in the
+// arguments.
+func CheckAlignment(tolerance float64, playlists ...*MediaPlaylist) error {
+	if len(playlists) < 2 {
+		return nil
+	}
+	ref := playlists[0]
+	for n, p := range playlists[1:] {
+		n++
+		if p.SeqNo != ref.SeqNo {
+			return fmt.Errorf("playlist %d: media sequence %d differs from %d", n, p.SeqNo, ref.SeqNo)
+		}
+		if count, refCount := len(p.segments()), len(ref.segments()); count != refCount {
+			return fmt.Errorf("playlist %d: %d segments differ from %d", n, count, refCount)
+		}
+		var err error
+		compareRenditions(ref, p, tolerance, func(rule string, seqID uint64, e error) {
+			if err == nil {
+				err = fmt.Errorf("playlist %d: %s", n, e)
+			}
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// compareRenditions compares the corresponding segments (by media
+// sequence numbers) of the playlist with the reference one and
+// reports each mismatch with its rule.
+func compareRenditions(ref, p *MediaPlaylist, tolerance float64, report func(rule string, seqID uint64, err error)) {
+	refSegs, segs := ref.segments(), p.segments()
+	first, end := ref.SeqNo, ref.SeqNo+uint64(len(refSegs))
+	if p.SeqNo > first {
+		first = p.SeqNo
+	}
+	if e := p.SeqNo + uint64(len(segs)); e < end {
+		end = e
+	}
+	for seqID := first; seqID < end; seqID++ {
+		refSeg, seg := refSegs[seqID-ref.SeqNo], segs[seqID-p.SeqNo]
+		if math.Abs(seg.Duration-refSeg.Duration) > tolerance {
+			report("alignment-duration", seqID, fmt.Errorf("segment %d duration %g differs from %g", seqID, seg.Duration, refSeg.Duration))
+		}
+		if seg.Discontinuity != refSeg.Discontinuity {
+			report("alignment-discontinuity", seqID, fmt.Errorf("segment %d discontinuity is misaligned", seqID))
+		}
+		if !seg.ProgramDateTime.IsZero() && !refSeg.ProgramDateTime.IsZero() &&
+			math.Abs(seg.ProgramDateTime.Sub(refSeg.ProgramDateTime).Seconds()) > tolerance {
+			report("alignment-program-date-time", seqID, fmt.Errorf("segment %d program date time %s differs from %s", seqID,
+				seg.ProgramDateTime.Format(DATETIME), refSeg.ProgramDateTime.Format(DATETIME)))
+		}
+	}
+}
+
+// GapCoverage describes segments of the rendition marked with
+// EXT-X-GAP.
+type GapCoverage struct {
+	URI      string   // URI of the rendition
+	Segments []uint64 // media sequence numbers of gap segments
+	Duration float64  // total duration of gap segments in seconds
+}
+
+// AlignmentReport is the result of MasterPlaylist.AnalyzeAlignment.
+type AlignmentReport struct {
+	// Violations lists all misalignments of the renditions with the
+	// reference one, items refer to renditions by URI.
+	Violations []Violation
+	// Gaps lists renditions with gap segments.
+	Gaps []GapCoverage
+	// Uncovered lists media sequence numbers of segments which are
+	// gaps in all renditions of the same type having them (video
+	// variants or alternatives of the same TYPE and GROUP-ID), so
+	// players can't switch to another rendition to fill the gap.
+	Uncovered []uint64
+}
+
+// AnalyzeAlignment checks alignment of media playlists of the
+// renditions of the master playlist as CheckAlignment does and
+// reports all misalignments together with EXT-X-GAP coverage.
+// Renditions are the variants with assigned Chunklist (see
+// ResolveChunklists) and the playlists of renditions (alternatives
+// or variants) passed by their URIs. I-frame and image variants are
+// not analyzed. Renditions are compared by media sequence numbers, so
+// live playlists loaded at slightly different times are compared by
+// their common segments and the difference is reported. The first
+// analyzed rendition is the reference one.
+func (p *MasterPlaylist) AnalyzeAlignment(tolerance float64, renditions map[string]*MediaPlaylist) AlignmentReport {
+	var (
+		uris      []string
+		groups    []string // renditions of the same group may replace each other
+		playlists []*MediaPlaylist
+		seen      = make(map[string]bool)
+	)
+	add := func(uri, group string, pl *MediaPlaylist) {
+		if pl == nil || seen[uri] {
+			return
+		}
+		seen[uri] = true
+		uris = append(uris, uri)
+		groups = append(groups, group)
+		playlists = append(playlists, pl)
+	}
+	for _, v := range p.Variants {
+		if v == nil || v.Iframe || v.Images {
+			continue
+		}
+		if v.Chunklist != nil {
+			add(v.URI, "", v.Chunklist)
+		} else {
+			add(v.URI, "", renditions[v.URI])
+		}
+		for _, alt := range v.Alternatives {
+			if alt != nil && alt.URI != "" {
+				add(alt.URI, alt.Type+"/"+alt.GroupId, renditions[alt.URI])
+			}
+		}
+	}
+
+	var report AlignmentReport
+	var vs violations
+	var gapGroups []string // groups of report.Gaps
+	for i, pl := range playlists {
+		if i > 0 {
+			ref, uri := playlists[0], uris[i]
+			if pl.SeqNo != ref.SeqNo {
+				vs.add("alignment-sequence", uri, fmt.Errorf("media sequence %d differs from %d", pl.SeqNo, ref.SeqNo))
+			}
+			if n, refN := len(pl.segments()), len(ref.segments()); n != refN {
+				vs.add("alignment-count", uri, fmt.Errorf("%d segments differ from %d", n, refN))
+			}
+			compareRenditions(ref, pl, tolerance, func(rule string, seqID uint64, err error) {
+				vs.add(rule, uri, err)
+			})
+		}
+		gaps := GapCoverage{URI: uris[i]}
+		for j, seg := range pl.segments() {
+			if seg.Gap {
+				gaps.Segments = append(gaps.Segments, pl.SeqNo+uint64(j))
+				gaps.Duration += seg.Duration
+			}
+		}
+		if len(gaps.Segments) > 0 {
+			report.Gaps = append(report.Gaps, gaps)
+			gapGroups = append(gapGroups, groups[i])
+		}
+	}
+	report.Violations = vs
+
+	// a gap is uncovered when no rendition of the group has media
+	// for the segment
+	type groupSegment struct {
+		group string
+		seqID uint64
+	}
+	covered := make(map[groupSegment]bool)
+	for i, pl := range playlists {
+		for j, seg := range pl.segments() {
+			if !seg.Gap {
+				covered[groupSegment{groups[i], pl.SeqNo + uint64(j)}] = true
+			}
+		}
+	}
+	uncovered := make(map[uint64]bool)
+	for i, gaps := range report.Gaps {
+		for _, seqID := range gaps.Segments {
+			if !covered[groupSegment{gapGroups[i], seqID}] && !uncovered[seqID] {
+				uncovered[seqID] = true
+				report.Uncovered = append(report.Uncovered, seqID)
+			}
+		}
+	}
+	sort.Slice(report.Uncovered, func(i, j int) bool { return report.Uncovered[i] < report.Uncovered[j] })
+	return report
+}
@@ -0,0 +1,111 @@
+/*
+Rendition alignment tests.
+
+Copyright 2013-2019 The Project Developers.
+See the AUTHORS and LICENSE files at the top-level directory of this distribution
+and at https://github.com/grafov/m3u8/
+
+ॐ तारे तुत्तारे तुरे स्व
+*/
+package m3u8
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCheckAlignment(t *testing.T) {
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	build := func(durations ...float64) *MediaPlaylist {
+		p, err := NewMediaPlaylist(5, 5)
+		if err != nil {
+			t.Fatal(err)
+		}
+		at := start
+		for i, d := range durations {
+			if err = p.Append("seg.ts", d, ""); err != nil {
+				t.Fatal(err)
+			}
+			p.SetProgramDateTime(at)
+			if i == 2 {
+				p.SetDiscontinuity()
+			}
+			at = at.Add(time.Duration(d * float64(time.Second)))
+		}
+		return p
+	}
+	video := build(6, 6, 6)
+	audio := build(6.02, 5.98, 6)
+	if err := CheckAlignment(0.05, video, audio); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := CheckAlignment(0.01, video, audio); err == nil || !strings.Contains(err.Error(), "duration") {
+		t.Errorf("expected duration mismatch, got %v", err)
+	}
+	if err := CheckAlignment(0.05, video, build(6, 6)); err == nil || !strings.Contains(err.Error(), "segments") {
+		t.Errorf("expected count mismatch, got %v", err)
+	}
+	subs := build(6, 6, 6)
+	subs.Segments[2].Discontinuity = false
+	subs.Segments[1].Discontinuity = true
+	if err := CheckAlignment(0.05, video, subs); err == nil || !strings.Contains(err.Error(), "discontinuity") {
+		t.Errorf("expected discontinuity mismatch, got %v", err)
+	}
+	shifted := build(6, 6, 6)
+	shifted.Segments[1].ProgramDateTime = shifted.Segments[1].ProgramDateTime.Add(time.Second)
+	if err := CheckAlignment(0.05, video, shifted); err == nil || !strings.Contains(err.Error(), "program date time") {
+		t.Errorf("expected program date time mismatch, got %v", err)
+	}
+	late := build(6, 6, 6)
+	late.SeqNo = 1
+	if err := CheckAlignment(0.05, video, late); err == nil || !strings.Contains(err.Error(), "media sequence") {
+		t.Errorf("expected media sequence mismatch, got %v", err)
+	}
+}
+
+func TestAnalyzeAlignment(t *testing.T) {
+	build := func(seqNo uint64, durations ...float64) *MediaPlaylist {
+		p, err := NewMediaPlaylist(0, uint(len(durations)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		p.SeqNo = seqNo
+		for _, d := range durations {
+			if err = p.Append("seg.ts", d, ""); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return p
+	}
+	high := build(10, 6, 6, 6, 6)
+	low := build(11, 6, 6, 4)
+	audio := build(10, 6, 6, 6, 6)
+	high.Segments[1].Gap = true
+	high.Segments[3].Gap = true
+	audio.Segments[2].Gap = true
+	audio.Segments[3].Gap = true
+	low.Segments[2].Gap = true
+
+	m := NewMasterPlaylist()
+	m.Append("high.m3u8", high, VariantParams{Bandwidth: 2000, Alternatives: []*Alternative{{Type: "AUDIO", GroupId: "aac", URI: "audio.m3u8"}}})
+	m.Append("low.m3u8", nil, VariantParams{Bandwidth: 1000, Alternatives: []*Alternative{{Type: "AUDIO", GroupId: "aac", URI: "audio.m3u8"}}})
+	m.Append("iframe.m3u8", nil, VariantParams{Bandwidth: 100, Iframe: true})
+	report := m.AnalyzeAlignment(0.05, map[string]*MediaPlaylist{"low.m3u8": low, "audio.m3u8": audio})
+
+	var rules []string
+	for _, v := range report.Violations {
+		rules = append(rules, v.Rule+" "+v.Item)
+	}
+	expected := "alignment-sequence low.m3u8,alignment-count low.m3u8,alignment-duration low.m3u8"
+	if strings.Join(rules, ",") != expected {
+		t.Errorf("unexpected violations %v", report.Violations)
+	}
+	if len(report.Gaps) != 3 || report.Gaps[0].URI != "high.m3u8" || len(report.Gaps[0].Segments) != 2 || report.Gaps[0].Duration != 12 {
+		t.Errorf("unexpected gaps %+v", report.Gaps)
+	}
+	// video variants don't cover the audio gap of segment 12
+	if len(report.Uncovered) != 2 || report.Uncovered[0] != 12 || report.Uncovered[1] != 13 {
+		t.Errorf("unexpected uncovered gaps %v", report.Uncovered)
+	}
+}
@@ -0,0 +1,98 @@
+package m3u8
+
+/*
+ Part of M3U8 parser & generator library.
+ This file defines encoding of playlists with pooled buffers.
+
+ Copyright 2013-2019 The Project Developers.
+ See the AUTHORS and LICENSE files at the top-level directory of this distribution
+ and at https://github.com/grafov/m3u8/
+
+ ॐ तारे तुत्तारे तुरे स्व
+*/
+
+import (
+	"bytes"
+	"sync"
+	"time"
+)
+
+// maxPooledBuffer is the capacity of buffers which are not returned
+// to the pool, so a single huge playlist doesn't pin the memory.
+const maxPooledBuffer = 1 << 20
+
+var bufferPool = sync.Pool{
+	New: func() interface{} {
+		return new(bytes.Buffer)
+	},
+}
+
+// getBuffer returns an empty buffer from the pool with at least size
+// bytes available.
+func getBuffer(size int) *bytes.Buffer {
+	buf := bufferPool.Get().(*bytes.Buffer)
+	buf.Grow(size)
+	return buf
+}
+
+// putBuffer returns the buffer to the pool.
+func putBuffer(buf *bytes.Buffer) {
+	if buf.Cap() > maxPooledBuffer {
+		return
+	}
+	buf.Reset()
+	bufferPool.Put(buf)
+}
+
+// AppendTo appends the playlist in M3U8 format to dst and returns the
+// extended slice. The cached output is appended if the playlist wasn't
+// changed since the last encoding. Otherwise the playlist is encoded
+// with a pooled buffer pre-sized for its variants and the cache isn't
+// filled, so callers keeping the output in own buffers don't keep
+// another copy of it for each playlist.
+func (p *MasterPlaylist) AppendTo(dst []byte) []byte {
+	if p.buf.Len() > 0 {
+		return append(dst, p.buf.Bytes()...)
+	}
+	defer reportEncoded(MASTER, len(p.Variants), time.Now())
+	buf := getBuffer(p.encodedSize())
+	defer putBuffer(buf)
+	p.encode(buf)
+	return append(dst, buf.Bytes()...)
+}
+
+// encodedSize estimates the size of the encoded playlist.
+func (p *MasterPlaylist) encodedSize() int {
+	return 256 + 256*len(p.Variants)
+}
+
+// AppendTo appends the playlist in M3U8 format to dst and returns the
+// extended slice, see MasterPlaylist.AppendTo. Segments appended since
+// the last encoding are appended to the cached output as by Encode.
+func (p *MediaPlaylist) AppendTo(dst []byte) []byte {
+	if p.buf.Len() > 0 {
+		return append(dst, p.Encode().Bytes()...)
+	}
+	start := time.Now()
+	buf := getBuffer(p.encodedSize())
+	defer putBuffer(buf)
+	q := p.transformed()
+	q.encode(buf, 0, q.winsize)
+	p.source.restore(buf)
+	reportEncoded(MEDIA, int(q.windowCount(0, q.winsize)), start)
+	return append(dst, buf.Bytes()...)
+}
+
+// encodedSize estimates the size of the encoded playlist from the
+// number of written segments and the length of the last URI.
+func (p *MediaPlaylist) encodedSize() int {
+	n := p.windowCount(0, p.winsize)
+	if n == 0 {
+		return 256
+	}
+	uri := 0
+	if seg := p.At(p.count - 1); seg != nil {
+		uri = len(seg.URI) + len(seg.Args) + len(p.Args)
+	}
+	return 256 + int(n)*(uri+32)
+}
@@ -0,0 +1,62 @@
+/*
+Pooled encoding tests.
+
+Copyright 2013-2019 The Project Developers.
+See the AUTHORS and LICENSE files at the top-level directory of this distribution
+and at https://github.com/grafov/m3u8/
+
+ॐ तारे तुत्तारे तुरे स्व
+*/
+package m3u8
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestMediaPlaylistAppendTo(t *testing.T) {
+	p, _ := NewMediaPlaylist(3, 5)
+	for i := 0; i < 5; i++ {
+		_ = p.Append(fmt.Sprintf("t%02d.ts", i), 6, "")
+	}
+	out := string(p.AppendTo([]byte("prefix\n")))
+	if p.buf.Len() != 0 {
+		t.Error("AppendTo must not fill the cache")
+	}
+	if want := "prefix\n" + p.String(); out != want {
+		t.Errorf("Unexpected output:\n%s\nwant:\n%s", out, want)
+	}
+	// the cached output is appended without allocations
+	dst := make([]byte, 0, 1024)
+	if allocs := testing.AllocsPerRun(10, func() { p.AppendTo(dst) }); allocs != 0 {
+		t.Errorf("AppendTo of cached playlist allocates %v times", allocs)
+	}
+}
+
+func TestMasterPlaylistAppendTo(t *testing.T) {
+	m := NewMasterPlaylist()
+	m.Append("low.m3u8", nil, VariantParams{Bandwidth: 100000})
+	m.Append("high.m3u8", nil, VariantParams{Bandwidth: 200000})
+	out := string(m.AppendTo(nil))
+	if m.buf.Len() != 0 {
+		t.Error("AppendTo must not fill the cache")
+	}
+	if want := m.String(); out != want {
+		t.Errorf("Unexpected output:\n%s\nwant:\n%s", out, want)
+	}
+	if out = string(m.AppendTo(nil)); out != m.String() {
+		t.Errorf("Unexpected output of cached playlist:\n%s", out)
+	}
+}
+
+func BenchmarkMediaPlaylistAppendTo(b *testing.B) {
+	p, _ := NewMediaPlaylist(0, 1000)
+	for i := 0; i < 1000; i++ {
+		_ = p.Append(fmt.Sprintf("segment%04d.ts", i), 6, "")
+	}
+	dst := make([]byte, 0, 64*1024)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		dst = p.AppendTo(dst[:0])
+	}
+}
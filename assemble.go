@@ -0,0 +1,221 @@
+package m3u8
+
+/*
+ Part of M3U8 parser & generator library.
+ This file defines helpers assembling master playlists.
+
+ Copyright 2013-2019 The Project Developers.
+ See the AUTHORS and LICENSE files at the top-level directory of this distribution
+ and at https://github.com/grafov/m3u8/
+
+ ॐ तारे तुत्तारे तुरे स्व
+*/
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+)
+
+// AudioRendition describes an audio media playlist in one language
+// for AddAudioGroup.
+type AudioRendition struct {
+	URI      string
+	Language string // RFC5646 language tag
+	Name     string // defaults to Language
+	Channels string
+	Default  bool // at most one rendition of the group may be default
+}
+
+// AddAudioGroup adds the audio renditions to the master playlist as
+// EXT-X-MEDIA tags of TYPE=AUDIO with the group ID and refers to the
+// group in the AUDIO attribute of all EXT-X-STREAM-INF variants.
+// Renditions are autoselected and exactly one of them is default: the
+// one marked as default or the first one. It returns error if the
+// group ID is empty, there are no renditions, more than one rendition
+// is marked as default or names of renditions are missing or not
+// unique. This operation does reset playlist cache.
+func (p *MasterPlaylist) AddAudioGroup(groupID string, renditions []AudioRendition) error {
+	if groupID == "" {
+		return errors.New("empty audio group ID")
+	}
+	if len(renditions) == 0 {
+		return errors.New("no audio renditions")
+	}
+	def := -1
+	names := make(map[string]bool, len(renditions))
+	alts := make([]*Alternative, len(renditions))
+	for i, r := range renditions {
+		if r.Default {
+			if def >= 0 {
+				return fmt.Errorf("audio group %q: more than one default rendition", groupID)
+			}
+			def = i
+		}
+		name := r.Name
+		if name == "" {
+			name = r.Language
+		}
+		if name == "" {
+			return fmt.Errorf("audio group %q: rendition %q has neither name nor language", groupID, r.URI)
+		}
+		if names[name] {
+			return fmt.Errorf("audio group %q: duplicate rendition name %q", groupID, name)
+		}
+		names[name] = true
+		alts[i] = &Alternative{
+			GroupId:    groupID,
+			URI:        r.URI,
+			Type:       "AUDIO",
+			Language:   r.Language,
+			Name:       name,
+			Autoselect: "YES",
+			Channels:   r.Channels,
+		}
+	}
+	if def < 0 {
+		def = 0
+	}
+	alts[def].Default = true
+
+	for _, v := range p.Variants {
+		if v == nil || v.Iframe || v.Images {
+			continue
+		}
+		v.Audio = groupID
+		v.Alternatives = append(v.Alternatives, alts...)
+	}
+	version(&p.ver, 4) // see Append
+	p.buf.Reset()
+	return nil
+}
+
+// IframeFunc returns the URI and the media playlist (optional) of the
+// I-frame counterpart of the variant or empty URI if the variant has
+// no I-frame playlist.
+type IframeFunc func(v *Variant) (uri string, chunklist *MediaPlaylist)
+
+// AddIframeVariants appends EXT-X-I-FRAME-STREAM-INF variants for the
+// EXT-X-STREAM-INF variants which have I-frame playlists returned by
+// the function. BANDWIDTH, CODECS (without audio codecs), RESOLUTION,
+// VIDEO, VIDEO-RANGE, HDCP-LEVEL and PROGRAM-ID are taken from the
+// parent variant. Variants whose I-frame playlist is already in the
+// master playlist are skipped. It returns the appended variants. This
+// operation does reset playlist cache.
+func (p *MasterPlaylist) AddIframeVariants(iframe IframeFunc) []*Variant {
+	existing := make(map[string]bool)
+	for _, v := range p.Variants {
+		if v != nil && v.Iframe {
+			existing[v.URI] = true
+		}
+	}
+	var added []*Variant
+	for _, v := range p.Variants {
+		if v == nil || v.Iframe || v.Images {
+			continue
+		}
+		uri, chunklist := iframe(v)
+		if uri == "" || existing[uri] {
+			continue
+		}
+		existing[uri] = true
+		added = append(added, &Variant{URI: uri, Chunklist: chunklist, VariantParams: iframeParams(v)})
+	}
+	p.Variants = append(p.Variants, added...)
+	p.buf.Reset()
+	return added
+}
+
+// iframeParams returns attributes of EXT-X-I-FRAME-STREAM-INF taken
+// from the parent variant.
+func iframeParams(v *Variant) VariantParams {
+	return VariantParams{
+		ProgramId:    v.ProgramId,
+		Bandwidth:    v.Bandwidth,
+		Codecs:       videoCodecs(v.Codecs),
+		Resolution:   v.Resolution,
+		Width:        v.Width,
+		Height:       v.Height,
+		Video:        v.Video,
+		Iframe:       true,
+		VideoRange:   v.VideoRange,
+		HDCPLevel:    v.HDCPLevel,
+		programIdSet: v.programIdSet,
+	}
+}
+
+// VariantProbe returns attributes of the variant which can't be
+// inferred from its media playlist, for example CODECS, RESOLUTION and
+// FRAME-RATE found by probing the media segments. Zero BANDWIDTH and
+// AVERAGE-BANDWIDTH are inferred by ProbeMaster.
+type VariantProbe func(uri string, p *MediaPlaylist) (VariantParams, error)
+
+// ProbeMaster fetches the media playlists with the fetcher and
+// assembles the master playlist of them, for example to wrap output
+// of a packager which produces only media playlists. Relative URIs
+// are resolved against the base URI and kept relative in the master
+// playlist. Attributes of the variants are returned by the probe
+// (optional). Missing BANDWIDTH and AVERAGE-BANDWIDTH are computed from
+// sizes of segments (ByteSize, EXT-X-BYTERANGE) or EXT-X-BITRATE, I-frame
+// media playlists become EXT-X-I-FRAME-STREAM-INF variants. Media
+// playlists are stored in Chunklist fields of the variants. It returns
+// error if BANDWIDTH of a variant can't be inferred.
+func ProbeMaster(ctx context.Context, f Fetcher, base string, uris []string, probe VariantProbe) (*MasterPlaylist, error) {
+	baseURL, err := url.Parse(base)
+	if err != nil {
+		return nil, err
+	}
+	m := NewMasterPlaylist()
+	for _, uri := range uris {
+		ref, err := url.Parse(uri)
+		if err != nil {
+			return nil, fmt.Errorf("variant %q: %s", uri, err)
+		}
+		chunklist, err := fetchMediaPlaylist(ctx, f, baseURL.ResolveReference(ref).String(), false)
+		if err != nil {
+			return nil, fmt.Errorf("variant %q: %s", uri, err)
+		}
+		var params VariantParams
+		if probe != nil {
+			if params, err = probe(uri, chunklist); err != nil {
+				return nil, fmt.Errorf("variant %q: %s", uri, err)
+			}
+		}
+		params.Iframe = params.Iframe || chunklist.Iframe
+		params.Images = params.Images || chunklist.ImagesOnly
+		if params.Bandwidth == 0 {
+			params.Bandwidth, _ = chunklist.PeakBandwidth(probedSize)
+		}
+		if params.AverageBandwidth == 0 && !params.Iframe && !params.Images {
+			params.AverageBandwidth, _ = chunklist.AverageBandwidth(probedSize)
+		}
+		if params.Bandwidth == 0 {
+			return nil, fmt.Errorf("variant %q: can't infer BANDWIDTH", uri)
+		}
+		m.Append(uri, chunklist, params)
+	}
+	return m, nil
+}
+
+// probedSize returns the known size of the segment in bytes or the
+// size estimated from EXT-X-BITRATE of the segment.
+func probedSize(seg *MediaSegment) (int64, error) {
+	if n := segmentSize(seg); n > 0 {
+		return n, nil
+	}
+	return int64(math.Ceil(float64(seg.Bitrate) * 1000 / 8 * seg.Duration)), nil
+}
+
+// videoCodecs removes audio and text codecs from the CODECS attribute
+// as they are not applicable to I-frame playlists.
+func videoCodecs(codecs string) string {
+	var video Codecs
+	for _, c := range ParseCodecs(codecs) {
+		if !c.IsAudio() && !c.IsText() {
+			video = append(video, c)
+		}
+	}
+	return video.String()
+}
@@ -0,0 +1,155 @@
+/*
+Master playlist assembly tests.
+
+Copyright 2013-2019 The Project Developers.
+See the AUTHORS and LICENSE files at the top-level directory of this distribution
+and at https://github.com/grafov/m3u8/
+
+ॐ तारे तुत्तारे तुरे स्व
+*/
+package m3u8
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestAddAudioGroup(t *testing.T) {
+	m := NewMasterPlaylist()
+	m.Append("low.m3u8", nil, VariantParams{Bandwidth: 500000})
+	m.Append("high.m3u8", nil, VariantParams{Bandwidth: 1500000})
+	m.Append("iframe.m3u8", nil, VariantParams{Bandwidth: 100000, Iframe: true})
+
+	err := m.AddAudioGroup("aac", []AudioRendition{
+		{URI: "en.m3u8", Language: "en", Name: "English"},
+		{URI: "de.m3u8", Language: "de", Default: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range m.Variants[:2] {
+		if v.Audio != "aac" || len(v.Alternatives) != 2 {
+			t.Errorf("variant %s is not wired to the audio group: %+v", v.URI, v.VariantParams)
+		}
+	}
+	if m.Variants[2].Audio != "" || len(m.Variants[2].Alternatives) != 0 {
+		t.Error("I-frame variant must not refer to the audio group")
+	}
+	out := m.String()
+	expected := []string{
+		`#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID="aac",NAME="English",DEFAULT=NO,AUTOSELECT=YES,LANGUAGE="en",URI="en.m3u8"`,
+		`#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID="aac",NAME="de",DEFAULT=YES,AUTOSELECT=YES,LANGUAGE="de",URI="de.m3u8"`,
+		`AUDIO="aac"`,
+	}
+	for _, e := range expected {
+		if !strings.Contains(out, e) {
+			t.Errorf("expected %s in:\n%s", e, out)
+		}
+	}
+	if strings.Count(out, "#EXT-X-MEDIA:") != 2 {
+		t.Errorf("renditions must be written once:\n%s", out)
+	}
+
+	if err = m.AddAudioGroup("aac2", []AudioRendition{{URI: "en.m3u8", Language: "en"}}); err != nil {
+		t.Fatal(err)
+	}
+	if !m.Variants[0].Alternatives[2].Default {
+		t.Error("expected the first rendition to be default")
+	}
+
+	bad := [][]AudioRendition{
+		nil,
+		{{URI: "x.m3u8"}},
+		{{URI: "a.m3u8", Language: "en"}, {URI: "b.m3u8", Language: "en"}},
+		{{URI: "a.m3u8", Language: "en", Default: true}, {URI: "b.m3u8", Language: "de", Default: true}},
+	}
+	for i, renditions := range bad {
+		if err = m.AddAudioGroup("bad", renditions); err == nil {
+			t.Errorf("case %d: expected error", i)
+		}
+	}
+	if err = m.AddAudioGroup("", []AudioRendition{{URI: "en.m3u8", Language: "en"}}); err == nil {
+		t.Error("expected error for empty group ID")
+	}
+}
+
+func TestAddIframeVariants(t *testing.T) {
+	m := NewMasterPlaylist()
+	m.Append("low.m3u8", nil, VariantParams{Bandwidth: 500000, Codecs: "avc1.42c015,mp4a.40.2", Resolution: "640x360"})
+	m.Append("high.m3u8", nil, VariantParams{Bandwidth: 1500000, Codecs: "mp4a.40.2, avc1.640028", Resolution: "1280x720", VideoRange: VideoRangeSDR})
+	m.Append("audio.m3u8", nil, VariantParams{Bandwidth: 64000, Codecs: "mp4a.40.2"})
+
+	iframe := func(v *Variant) (string, *MediaPlaylist) {
+		if v.Resolution == "" {
+			return "", nil
+		}
+		return strings.Replace(v.URI, ".m3u8", "-iframe.m3u8", 1), nil
+	}
+	added := m.AddIframeVariants(iframe)
+	if len(added) != 2 || len(m.Variants) != 5 {
+		t.Fatalf("expected 2 I-frame variants, got %d", len(added))
+	}
+	if v := added[1]; !v.Iframe || v.URI != "high-iframe.m3u8" || v.Bandwidth != 1500000 ||
+		v.Codecs != "avc1.640028" || v.Resolution != "1280x720" || v.VideoRange != VideoRangeSDR {
+		t.Errorf("unexpected I-frame variant: %+v", v)
+	}
+	if !strings.Contains(m.String(), `#EXT-X-I-FRAME-STREAM-INF:BANDWIDTH=500000,CODECS="avc1.42c015",RESOLUTION=640x360,URI="low-iframe.m3u8"`) {
+		t.Errorf("I-frame variant is not written:\n%s", m.String())
+	}
+	if added = m.AddIframeVariants(iframe); len(added) != 0 {
+		t.Errorf("expected existing I-frame variants to be skipped, got %d", len(added))
+	}
+}
+
+func TestProbeMaster(t *testing.T) {
+	playlists := map[string]string{
+		"http://example.com/live/low.m3u8":    "#EXTM3U\n#EXT-X-TARGETDURATION:4\n#EXT-X-BITRATE:800\n#EXTINF:4,\nlow0.ts\n#EXT-X-BITRATE:1200\n#EXTINF:4,\nlow1.ts\n#EXT-X-ENDLIST\n",
+		"http://example.com/live/high.m3u8":   "#EXTM3U\n#EXT-X-TARGETDURATION:4\n#EXT-X-BYTERANGE:1000000@0\n#EXTINF:4,\nhigh.ts\n#EXT-X-BYTERANGE:500000\n#EXTINF:4,\nhigh.ts\n#EXT-X-ENDLIST\n",
+		"http://example.com/live/iframe.m3u8": "#EXTM3U\n#EXT-X-VERSION:4\n#EXT-X-TARGETDURATION:4\n#EXT-X-I-FRAMES-ONLY\n#EXT-X-BYTERANGE:50000@0\n#EXTINF:4,\nhigh.ts\n#EXT-X-ENDLIST\n",
+		"http://example.com/live/empty.m3u8":  "#EXTM3U\n#EXT-X-TARGETDURATION:4\n#EXTINF:4,\nx.ts\n#EXT-X-ENDLIST\n",
+	}
+	f := FetcherFunc(func(ctx context.Context, uri string) (io.ReadCloser, error) {
+		src, ok := playlists[uri]
+		if !ok {
+			return nil, os.ErrNotExist
+		}
+		return ioutil.NopCloser(strings.NewReader(src)), nil
+	})
+	probe := func(uri string, p *MediaPlaylist) (VariantParams, error) {
+		if uri == "low.m3u8" {
+			return VariantParams{Codecs: "avc1.4d401e,mp4a.40.2", Resolution: "640x360"}, nil
+		}
+		return VariantParams{Codecs: "avc1.640028", Resolution: "1920x1080"}, nil
+	}
+	m, err := ProbeMaster(context.Background(), f, "http://example.com/live/", []string{"low.m3u8", "high.m3u8", "iframe.m3u8"}, probe)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Variants) != 3 {
+		t.Fatalf("expected 3 variants, got %d", len(m.Variants))
+	}
+	low, high, iframe := m.Variants[0], m.Variants[1], m.Variants[2]
+	if low.Bandwidth != 1200000 || low.AverageBandwidth != 1000000 || low.Resolution != "640x360" || low.Chunklist == nil {
+		t.Errorf("unexpected low variant %+v", low.VariantParams)
+	}
+	if high.Bandwidth != 2000000 || high.AverageBandwidth != 1500000 || high.Codecs != "avc1.640028" {
+		t.Errorf("unexpected high variant %+v", high.VariantParams)
+	}
+	if !iframe.Iframe || iframe.Bandwidth != 100000 || iframe.AverageBandwidth != 0 {
+		t.Errorf("unexpected I-frame variant %+v", iframe.VariantParams)
+	}
+	if !strings.Contains(m.String(), "#EXT-X-I-FRAME-STREAM-INF:BANDWIDTH=100000,") {
+		t.Errorf("I-frame variant expected:\n%s", m)
+	}
+
+	if _, err = ProbeMaster(context.Background(), f, "http://example.com/live/", []string{"empty.m3u8"}, nil); err == nil {
+		t.Error("expected error for variant without known sizes")
+	}
+	if _, err = ProbeMaster(context.Background(), f, "http://example.com/live/", []string{"missing.m3u8"}, nil); err == nil {
+		t.Error("expected error for missing playlist")
+	}
+}
@@ -0,0 +1,182 @@
+package m3u8
+
+/*
+ Part of M3U8 parser & generator library.
+ This file defines parsing and writing of attribute lists for custom
+ tags.
+
+ Copyright 2013-2019 The Project Developers.
+ See the AUTHORS and LICENSE files at the top-level directory of this distribution
+ and at https://github.com/grafov/m3u8/
+
+ ॐ तारे तुत्तारे तुरे स्व
+*/
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// AttrType is the type of the value of an attribute (section 4.2).
+type AttrType uint
+
+const (
+	AttrString     AttrType = iota // quoted-string
+	AttrEnum                       // enumerated-string, unquoted
+	AttrInteger                    // decimal-integer
+	AttrFloat                      // decimal-floating-point or signed-decimal-floating-point
+	AttrHex                        // hexadecimal-sequence
+	AttrResolution                 // decimal-resolution, e.g. 1280x720
+)
+
+// Attribute is the NAME=VALUE pair of an attribute list. Value is the
+// textual value of the attribute without quotes.
+type Attribute struct {
+	Name  string
+	Type  AttrType
+	Value string
+}
+
+// AttributeList is the attribute list of a tag in order of the tag,
+// e.g. for implementations of CustomDecoder and CustomTag.
+type AttributeList []Attribute
+
+// ParseAttributeList parses the attribute list, the tag and ':' must
+// be trimmed. Types of the values are derived from their syntax:
+// quoted strings, hexadecimal sequences, decimal integers, decimal
+// floating points and resolutions are recognized and other unquoted
+// values are enumerated strings. Malformed attributes are skipped as
+// by the playlist decoder.
+func ParseAttributeList(line string) AttributeList {
+	var list AttributeList
+	scanAttributes(line, func(name, raw string) {
+		list = append(list, parseAttribute(name, raw))
+	})
+	return list
+}
+
+// parseAttribute derives the type of the attribute from the syntax of
+// its raw value.
+func parseAttribute(name, raw string) Attribute {
+	if strings.HasPrefix(raw, `"`) {
+		return Attribute{Name: name, Type: AttrString, Value: strings.Trim(raw, `"`)}
+	}
+	raw = strings.TrimSpace(raw)
+	a := Attribute{Name: name, Type: AttrEnum, Value: raw}
+	switch {
+	case isHexSequence(raw):
+		a.Type = AttrHex
+	case isDecimalInteger(raw):
+		a.Type = AttrInteger
+	case isDecimalFloat(raw):
+		a.Type = AttrFloat
+	default:
+		if _, _, err := parseResolution(raw); err == nil {
+			a.Type = AttrResolution
+		}
+	}
+	return a
+}
+
+// isDecimalInteger reports whether the value is the decimal-integer
+// of attribute lists.
+func isDecimalInteger(v string) bool {
+	if v == "" {
+		return false
+	}
+	for _, r := range v {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// Get returns the first attribute with the name.
+func (l AttributeList) Get(name string) (Attribute, bool) {
+	for _, a := range l {
+		if a.Name == name {
+			return a, true
+		}
+	}
+	return Attribute{}, false
+}
+
+// Value returns the value of the first attribute with the name or
+// empty string.
+func (l AttributeList) Value(name string) string {
+	a, _ := l.Get(name)
+	return a.Value
+}
+
+// Int returns the value of the decimal-integer attribute.
+func (l AttributeList) Int(name string) (uint64, error) {
+	a, ok := l.Get(name)
+	if !ok {
+		return 0, fmt.Errorf("attribute %s not found", name)
+	}
+	return strconv.ParseUint(a.Value, 10, 64)
+}
+
+// Float returns the value of the decimal-floating-point attribute.
+func (l AttributeList) Float(name string) (float64, error) {
+	a, ok := l.Get(name)
+	if !ok {
+		return 0, fmt.Errorf("attribute %s not found", name)
+	}
+	return strconv.ParseFloat(a.Value, 64)
+}
+
+// Set replaces the value of the first attribute with the name or
+// appends the attribute.
+func (l *AttributeList) Set(name string, typ AttrType, value string) {
+	for i := range *l {
+		if (*l)[i].Name == name {
+			(*l)[i].Type, (*l)[i].Value = typ, value
+			return
+		}
+	}
+	*l = append(*l, Attribute{Name: name, Type: typ, Value: value})
+}
+
+// Validate checks the attributes can be written: names must consist
+// of upper case letters, digits and '-', quoted strings must not
+// contain double quotes and line breaks and other values must not be
+// empty or contain commas, quotes or whitespace.
+func (l AttributeList) Validate() error {
+	for _, a := range l {
+		if a.Name == "" {
+			return errors.New("attribute without name")
+		}
+		for i := 0; i < len(a.Name); i++ {
+			if c := a.Name[i]; !('A' <= c && c <= 'Z' || '0' <= c && c <= '9' || c == '-') {
+				return fmt.Errorf("invalid attribute name %q", a.Name)
+			}
+		}
+	}
+	return l.attrs().check()
+}
+
+// String returns the attribute list, values of AttrString type are
+// quoted. See Validate for values which can't be written correctly.
+func (l AttributeList) String() string {
+	var buf bytes.Buffer
+	l.attrs().writeTo(&buf, nil)
+	return buf.String()
+}
+
+// attrs converts the attribute list for writing.
+func (l AttributeList) attrs() attrList {
+	attrs := make(attrList, 0, len(l))
+	for _, a := range l {
+		if a.Type == AttrString {
+			attrs.quoted(a.Name, a.Value)
+		} else {
+			attrs.add(a.Name, a.Value)
+		}
+	}
+	return attrs
+}
@@ -0,0 +1,69 @@
+/*
+Attribute list tests.
+
+Copyright 2013-2019 The Project Developers.
+See the AUTHORS and LICENSE files at the top-level directory of this distribution
+and at https://github.com/grafov/m3u8/
+
+ॐ तारे तुत्तारे तुरे स्व
+*/
+package m3u8
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseAttributeList(t *testing.T) {
+	line := `URI="ad,1.ts",BANDWIDTH=1280000,DURATION=-1.5,IV=0x1A2B,RESOLUTION=1280x720,TYPE=AUDIO,BROKEN,EMPTY=""`
+	list := ParseAttributeList(line)
+	expected := AttributeList{
+		{Name: "URI", Type: AttrString, Value: "ad,1.ts"},
+		{Name: "BANDWIDTH", Type: AttrInteger, Value: "1280000"},
+		{Name: "DURATION", Type: AttrFloat, Value: "-1.5"},
+		{Name: "IV", Type: AttrHex, Value: "0x1A2B"},
+		{Name: "RESOLUTION", Type: AttrResolution, Value: "1280x720"},
+		{Name: "TYPE", Type: AttrEnum, Value: "AUDIO"},
+	}
+	if !reflect.DeepEqual(list, expected) {
+		t.Fatalf("expected %+v, got %+v", expected, list)
+	}
+	if bw, err := list.Int("BANDWIDTH"); err != nil || bw != 1280000 {
+		t.Errorf("unexpected BANDWIDTH %d: %v", bw, err)
+	}
+	if d, err := list.Float("DURATION"); err != nil || d != -1.5 {
+		t.Errorf("unexpected DURATION %v: %v", d, err)
+	}
+	if _, err := list.Int("MISSING"); err == nil {
+		t.Error("expected error for missing attribute")
+	}
+	if list.Value("TYPE") != "AUDIO" || list.Value("MISSING") != "" {
+		t.Error("unexpected values")
+	}
+	list.Set("TYPE", AttrEnum, "VIDEO")
+	list.Set("NAME", AttrString, "English")
+	if err := list.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if s := list.String(); s != `URI="ad,1.ts",BANDWIDTH=1280000,DURATION=-1.5,IV=0x1A2B,RESOLUTION=1280x720,TYPE=VIDEO,NAME="English"` {
+		t.Errorf("unexpected attribute list %s", s)
+	}
+	if !reflect.DeepEqual(ParseAttributeList(list.String()), list) {
+		t.Error("written attribute list is parsed differently")
+	}
+}
+
+func TestAttributeListValidate(t *testing.T) {
+	for _, l := range []AttributeList{
+		{{Name: "lower", Type: AttrEnum, Value: "A"}},
+		{{Name: "NAME", Type: AttrString, Value: `say "hi"`}},
+		{{Name: "NAME", Type: AttrString, Value: "a\nb"}},
+		{{Name: "TYPE", Type: AttrEnum, Value: "A,B"}},
+		{{Name: "TYPE", Type: AttrEnum, Value: ""}},
+		{{Name: "", Type: AttrEnum, Value: "A"}},
+	} {
+		if err := l.Validate(); err == nil {
+			t.Errorf("expected error for %+v", l)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package m3u8
+
+/*
+ Part of M3U8 parser & generator library.
+ This file defines helpers for calculation of variant bandwidth.
+
+ Copyright 2013-2019 The Project Developers.
+ See the AUTHORS and LICENSE files at the top-level directory of this distribution
+ and at https://github.com/grafov/m3u8/
+
+ ॐ तारे तुत्तारे तुरे स्व
+*/
+
+import (
+	"fmt"
+	"math"
+)
+
+// SegmentSizeFunc returns the size in bytes of the segment of the
+// variant media playlist.
+type SegmentSizeFunc func(v *Variant, seg *MediaSegment) (int64, error)
+
+// AverageBandwidth returns the average bitrate of the media playlist
+// in bits per second computed from the segment sizes returned by the
+// size function. Nil size function means the sizes are taken from
+// ByteSize or, if it is not set, from byte ranges (EXT-X-BYTERANGE)
+// of the segments. Zero is returned for the playlist without
+// duration.
+func (p *MediaPlaylist) AverageBandwidth(size func(seg *MediaSegment) (int64, error)) (uint32, error) {
+	var bytes int64
+	var duration float64
+	for _, seg := range p.segments() {
+		n := segmentSize(seg)
+		if size != nil {
+			var err error
+			if n, err = size(seg); err != nil {
+				return 0, err
+			}
+		}
+		bytes += n
+		duration += seg.Duration
+	}
+	if duration <= 0 {
+		return 0, nil
+	}
+	return uint32(math.Ceil(float64(bytes) * 8 / duration)), nil
+}
+
+// SetAverageBandwidth computes AVERAGE-BANDWIDTH of the variants with
+// media playlists (Chunklist) from the sizes of their segments
+// returned by the size function (see MediaPlaylist.AverageBandwidth)
+// and sets it to the variants. Variants without media playlists are
+// left intact. It returns error if BANDWIDTH of a variant is set and
+// it is lower than the computed average bandwidth as BANDWIDTH is the
+// peak bitrate of the variant. This operation does reset playlist
+// cache.
+func (p *MasterPlaylist) SetAverageBandwidth(size SegmentSizeFunc) error {
+	p.buf.Reset()
+	for _, v := range p.Variants {
+		if v == nil || v.Chunklist == nil {
+			continue
+		}
+		var vsize func(seg *MediaSegment) (int64, error)
+		if size != nil {
+			vsize = func(seg *MediaSegment) (int64, error) { return size(v, seg) }
+		}
+		avg, err := v.Chunklist.AverageBandwidth(vsize)
+		if err != nil {
+			return err
+		}
+		v.AverageBandwidth = avg
+		if v.Bandwidth > 0 && v.Bandwidth < avg {
+			return fmt.Errorf("variant %q: BANDWIDTH %d is lower than AVERAGE-BANDWIDTH %d", v.URI, v.Bandwidth, avg)
+		}
+	}
+	return nil
+}
+
+// PeakBandwidth returns the peak segment bitrate of the media
+// playlist in bits per second (the value of BANDWIDTH attribute of the
+// variant) computed from the segment sizes the same way as
+// AverageBandwidth.
+func (p *MediaPlaylist) PeakBandwidth(size func(seg *MediaSegment) (int64, error)) (uint32, error) {
+	var peak float64
+	for _, seg := range p.segments() {
+		if seg.Duration <= 0 {
+			continue
+		}
+		n := segmentSize(seg)
+		if size != nil {
+			var err error
+			if n, err = size(seg); err != nil {
+				return 0, err
+			}
+		}
+		if bw := float64(n) * 8 / seg.Duration; bw > peak {
+			peak = bw
+		}
+	}
+	return uint32(math.Ceil(peak)), nil
+}
+
+// FillBitrates sets Bitrate (EXT-X-BITRATE) of the segments with
+// known ByteSize from their sizes and durations. Segments with byte
+// ranges are skipped as EXT-X-BITRATE must not be applied to them.
+// This operation does reset playlist cache.
+func (p *MediaPlaylist) FillBitrates() {
+	p.eachSegment(func(seg *MediaSegment) {
+		if seg.ByteSize > 0 && seg.Duration > 0 && seg.Limit == 0 {
+			seg.Bitrate = int64(math.Ceil(float64(seg.ByteSize) * 8 / seg.Duration / 1000))
+		}
+	})
+	p.buf.Reset()
+}
+
+// segmentSize returns the known size of the segment in bytes.
+func segmentSize(seg *MediaSegment) int64 {
+	if seg.ByteSize > 0 {
+		return seg.ByteSize
+	}
+	return seg.Limit
+}
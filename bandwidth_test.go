@@ -0,0 +1,112 @@
+/*
+Bandwidth calculation tests.
+
+Copyright 2013-2019 The Project Developers.
+See the AUTHORS and LICENSE files at the top-level directory of this distribution
+and at https://github.com/grafov/m3u8/
+
+ॐ तारे तुत्तारे तुरे स्व
+*/
+package m3u8
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestSetAverageBandwidth(t *testing.T) {
+	m := NewMasterPlaylist()
+	for _, bw := range []uint32{2000000, 500000} {
+		p, err := NewMediaPlaylist(3, 3)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, uri := range []string{"a.ts", "b.ts"} {
+			if err = p.Append(uri, 4, ""); err != nil {
+				t.Fatal(err)
+			}
+		}
+		m.Append("chunklist.m3u8", p, VariantParams{Bandwidth: bw})
+	}
+	m.Append("other.m3u8", nil, VariantParams{Bandwidth: 100000})
+
+	// 500000 bytes per 4 seconds is 1 Mbps
+	size := func(v *Variant, seg *MediaSegment) (int64, error) { return 500000, nil }
+	if err := m.SetAverageBandwidth(size); err == nil {
+		t.Error("expected error for BANDWIDTH lower than AVERAGE-BANDWIDTH")
+	}
+	m.Variants[1].Bandwidth = 0
+	if err := m.SetAverageBandwidth(size); err != nil {
+		t.Fatal(err)
+	}
+	for i, v := range m.Variants[:2] {
+		if v.AverageBandwidth != 1000000 {
+			t.Errorf("variant %d: expected AVERAGE-BANDWIDTH 1000000, got %d", i, v.AverageBandwidth)
+		}
+	}
+	if m.Variants[2].AverageBandwidth != 0 {
+		t.Error("variant without media playlist must be left intact")
+	}
+
+	failure := errors.New("no size")
+	if err := m.SetAverageBandwidth(func(*Variant, *MediaSegment) (int64, error) { return 0, failure }); err != failure {
+		t.Errorf("expected size error, got %v", err)
+	}
+}
+
+func TestMediaPlaylistAverageBandwidthByteRange(t *testing.T) {
+	p, err := NewMediaPlaylist(2, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bw, _ := p.AverageBandwidth(nil); bw != 0 {
+		t.Errorf("expected zero bandwidth of empty playlist, got %d", bw)
+	}
+	p.Append("a.ts", 2, "")
+	p.SetRange(1000, 0)
+	p.Append("a.ts", 2, "")
+	p.SetRange(3000, 1000)
+	if bw, _ := p.AverageBandwidth(nil); bw != 8000 {
+		t.Errorf("expected 8000, got %d", bw)
+	}
+}
+
+func TestFillBitrates(t *testing.T) {
+	p, err := NewMediaPlaylist(3, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, size := range []int64{500000, 500000, 1000000} {
+		if err = p.Append("seg.ts", 4, ""); err != nil {
+			t.Fatal(err)
+		}
+		p.Segments[p.last()].ByteSize = size
+	}
+	p.FillBitrates()
+	if p.Segments[0].Bitrate != 1000 || p.Segments[2].Bitrate != 2000 {
+		t.Errorf("unexpected bitrates: %d, %d", p.Segments[0].Bitrate, p.Segments[2].Bitrate)
+	}
+	out := p.String()
+	if strings.Count(out, "#EXT-X-BITRATE:") != 2 || !strings.Contains(out, "#EXT-X-BITRATE:2000\n#EXTINF:4.000,") {
+		t.Errorf("EXT-X-BITRATE must be written on change only:\n%s", out)
+	}
+	if bw, _ := p.PeakBandwidth(nil); bw != 2000000 {
+		t.Errorf("expected peak bandwidth 2000000, got %d", bw)
+	}
+	if bw, _ := p.AverageBandwidth(nil); bw != 1333334 {
+		t.Errorf("expected average bandwidth 1333334, got %d", bw)
+	}
+
+	pl, _, err := DecodeFrom(strings.NewReader(out), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded := pl.(*MediaPlaylist)
+	if decoded.Segments[1].Bitrate != 1000 || decoded.Segments[2].Bitrate != 2000 {
+		t.Errorf("EXT-X-BITRATE is not applied to following segments")
+	}
+	if decoded.String() != out {
+		t.Errorf("EXT-X-BITRATE is not round-tripped:\n%s", decoded.String())
+	}
+}
@@ -0,0 +1,98 @@
+package m3u8
+
+/*
+ Part of M3U8 parser & generator library.
+ This file defines canonicalization of master playlists.
+
+ Copyright 2013-2019 The Project Developers.
+ See the AUTHORS and LICENSE files at the top-level directory of this distribution
+ and at https://github.com/grafov/m3u8/
+
+ ॐ तारे तुत्तारे तुरे स्व
+*/
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Canonicalize normalizes the master playlist so playlists produced
+// by different packagers could be compared. It uppercases enumerated
+// attribute values, strips spaces from CODECS lists, removes exact
+// duplicates of variants, orders renditions of each variant by type,
+// group, language and name and fills AVERAGE-BANDWIDTH of variants
+// from BANDWIDTH when missing. Order of variants themselves preserved
+// because it is meaningful for clients. This operation does reset
+// playlist cache.
+func (p *MasterPlaylist) Canonicalize() {
+	variants := p.Variants[:0]
+	for _, v := range p.Variants {
+		if v == nil {
+			continue
+		}
+		canonicalizeVariant(v)
+		if !containsVariant(variants, v) {
+			variants = append(variants, v)
+		}
+	}
+	for i := len(variants); i < len(p.Variants); i++ {
+		p.Variants[i] = nil
+	}
+	p.Variants = variants
+	p.buf.Reset()
+}
+
+func canonicalizeVariant(v *Variant) {
+	v.VideoRange = VideoRange(strings.ToUpper(string(v.VideoRange)))
+	v.HDCPLevel = strings.ToUpper(v.HDCPLevel)
+	v.Resolution = strings.ToLower(v.Resolution)
+	if v.Codecs != "" {
+		codecs := strings.Split(v.Codecs, ",")
+		for i := range codecs {
+			codecs[i] = strings.TrimSpace(codecs[i])
+		}
+		v.Codecs = strings.Join(codecs, ",")
+	}
+	if v.AverageBandwidth == 0 && !v.Iframe && !v.Images {
+		v.AverageBandwidth = v.Bandwidth
+	}
+	for _, alt := range v.Alternatives {
+		if alt == nil {
+			continue
+		}
+		alt.Type = strings.ToUpper(alt.Type)
+		alt.Autoselect = strings.ToUpper(alt.Autoselect)
+		alt.Forced = strings.ToUpper(alt.Forced)
+	}
+	sort.SliceStable(v.Alternatives, func(i, j int) bool {
+		a, b := v.Alternatives[i], v.Alternatives[j]
+		if a == nil || b == nil {
+			return b == nil && a != nil
+		}
+		if a.Type != b.Type {
+			return a.Type < b.Type
+		}
+		if a.GroupId != b.GroupId {
+			return a.GroupId < b.GroupId
+		}
+		if a.Language != b.Language {
+			return a.Language < b.Language
+		}
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return a.URI < b.URI
+	})
+}
+
+// containsVariant reports whether the list contains the variant with
+// the same URI and parameters. Chunklists are not compared.
+func containsVariant(variants []*Variant, v *Variant) bool {
+	for _, other := range variants {
+		if other.URI == v.URI && reflect.DeepEqual(other.VariantParams, v.VariantParams) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,53 @@
+/*
+Canonicalization tests.
+
+Copyright 2013-2019 The Project Developers.
+See the AUTHORS and LICENSE files at the top-level directory of this distribution
+and at https://github.com/grafov/m3u8/
+
+ॐ तारे तुत्तारे तुरे स्व
+*/
+package m3u8
+
+import (
+	"testing"
+)
+
+func TestCanonicalize(t *testing.T) {
+	alts := func() []*Alternative {
+		return []*Alternative{
+			{Type: "subtitles", GroupId: "subs", Language: "en", Name: "English", Autoselect: "yes"},
+			{Type: "AUDIO", GroupId: "aud", Language: "fr", Name: "French"},
+			{Type: "AUDIO", GroupId: "aud", Language: "en", Name: "English"},
+		}
+	}
+	p := NewMasterPlaylist()
+	p.Append("low.m3u8", nil, VariantParams{Bandwidth: 1000, Codecs: "avc1.4d401f, mp4a.40.2", VideoRange: "sdr", Alternatives: alts()})
+	p.Append("high.m3u8", nil, VariantParams{Bandwidth: 2000, AverageBandwidth: 1800, Resolution: "1280X720", Alternatives: alts()})
+	p.Append("low.m3u8", nil, VariantParams{Bandwidth: 1000, Codecs: "avc1.4d401f,mp4a.40.2", VideoRange: "SDR", Alternatives: alts()})
+	p.Canonicalize()
+
+	if len(p.Variants) != 2 {
+		t.Fatalf("Expected duplicate variant removed, got %d variants", len(p.Variants))
+	}
+	low, high := p.Variants[0], p.Variants[1]
+	if low.URI != "low.m3u8" || high.URI != "high.m3u8" {
+		t.Errorf("Variant order changed: %s, %s", low.URI, high.URI)
+	}
+	if low.Codecs != "avc1.4d401f,mp4a.40.2" || low.VideoRange != VideoRangeSDR || low.AverageBandwidth != 1000 {
+		t.Errorf("Variant not normalized: %+v", low.VariantParams)
+	}
+	if high.AverageBandwidth != 1800 || high.Resolution != "1280x720" {
+		t.Errorf("Variant not normalized: %+v", high.VariantParams)
+	}
+	var order []string
+	for _, alt := range low.Alternatives {
+		order = append(order, alt.Type+"/"+alt.Language)
+	}
+	if got := order[0] + " " + order[1] + " " + order[2]; got != "AUDIO/en AUDIO/fr SUBTITLES/en" {
+		t.Errorf("Unexpected renditions order: %s", got)
+	}
+	if low.Alternatives[2].Autoselect != "YES" {
+		t.Errorf("AUTOSELECT not normalized: %s", low.Alternatives[2].Autoselect)
+	}
+}
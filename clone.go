@@ -0,0 +1,376 @@
+package m3u8
+
+/*
+ Part of M3U8 parser & generator library.
+ This file defines deep copying of playlists.
+
+ Copyright 2013-2019 The Project Developers.
+ See the AUTHORS and LICENSE files at the top-level directory of this distribution
+ and at https://github.com/grafov/m3u8/
+
+ ॐ तारे तुत्तारे तुरे स्व
+*/
+
+import "bytes"
+
+// Clone returns a deep copy of the segment. Keys, maps, dateranges,
+// SCTE-35 cue, partial segments, tiles, unknown and custom tags and
+// metadata are copied so changes of the copy don't affect the
+// original segment. Values of custom tags are shared unless they have
+// a Clone() CustomTag method which is used to copy them. Values of
+// metadata are shared.
+func (seg *MediaSegment) Clone() *MediaSegment {
+	return new(cloner).segment(seg)
+}
+
+// Clone returns a deep copy of the variant with its alternatives and
+// chunklist, see MediaPlaylist.Clone.
+func (v *Variant) Clone() *Variant {
+	return new(cloner).variant(v)
+}
+
+// Clone returns a deep copy of the playlist. Segments and all the
+// values of the header are copied, see MediaSegment.Clone. Values
+// shared by several segments of the playlist, e.g. the key set by
+// SetDefaultKey, are shared by the segments of the copy as well. The
+// copy keeps custom decoders, hooks, transforms and the segment pool
+// of the playlist, and the state recorded by decoding (source order
+// of attributes, source map and the source of the fidelity mode) is
+// related to the copied values.
+func (p *MediaPlaylist) Clone() *MediaPlaylist {
+	return (&cloner{orders: p.attrOrder}).mediaPlaylist(p)
+}
+
+// Clone returns a deep copy of the playlist with its variants, see
+// Variant.Clone. Alternatives shared by several variants are shared by
+// the variants of the copy as well.
+func (p *MasterPlaylist) Clone() *MasterPlaylist {
+	return (&cloner{orders: p.attrOrder}).masterPlaylist(p)
+}
+
+// cloner deep-copies values of a playlist. It remembers copies of
+// the values so values shared in the playlist are shared in the copy
+// and the state keyed by the values can be related to the copies.
+type cloner struct {
+	copies map[interface{}]interface{}
+	orders attrOrders // source order of attributes of the original values
+	copied attrOrders // source order of attributes of the copies
+}
+
+// remember relates the copy to the original value.
+func (c *cloner) remember(src, dst interface{}) {
+	if c.copies == nil {
+		c.copies = make(map[interface{}]interface{})
+	}
+	c.copies[src] = dst
+	if order, ok := c.orders[src]; ok {
+		if c.copied == nil {
+			c.copied = make(attrOrders)
+		}
+		c.copied[dst] = order
+	}
+}
+
+func (c *cloner) mediaPlaylist(p *MediaPlaylist) *MediaPlaylist {
+	if p == nil {
+		return nil
+	}
+	if q, ok := c.copies[p]; ok {
+		return q.(*MediaPlaylist)
+	}
+	q := new(MediaPlaylist)
+	*q = *p
+	q.buf = bytes.Buffer{}
+	q.enc = nil
+	c.remember(p, q)
+	if p.Segments != nil {
+		q.Segments = make([]*MediaSegment, len(p.Segments))
+		for i, seg := range p.Segments {
+			q.Segments[i] = c.segment(seg)
+		}
+	}
+	q.Key = c.key(p.Key)
+	q.Map = c.xmap(p.Map)
+	if p.WV != nil {
+		wv := *p.WV
+		q.WV = &wv
+	}
+	q.Custom = cloneCustom(p.Custom)
+	q.Defines = cloneDefines(p.Defines)
+	if p.ServerControl != nil {
+		sc := *p.ServerControl
+		q.ServerControl = &sc
+	}
+	q.PendingPartials = clonePartials(p.PendingPartials)
+	if p.PreloadHints != nil {
+		q.PreloadHints = make([]*PreloadHint, len(p.PreloadHints))
+		for i, hint := range p.PreloadHints {
+			if hint != nil {
+				h := *hint
+				q.PreloadHints[i] = &h
+			}
+		}
+	}
+	if p.RenditionReports != nil {
+		q.RenditionReports = make([]*RenditionReport, len(p.RenditionReports))
+		for i, report := range p.RenditionReports {
+			if report != nil {
+				r := *report
+				q.RenditionReports[i] = &r
+			}
+		}
+	}
+	if p.Skip != nil {
+		skip := *p.Skip
+		skip.RecentlyRemovedDateRanges = cloneStrings(p.Skip.RecentlyRemovedDateRanges)
+		q.Skip = &skip
+	}
+	q.UnknownTags = cloneStrings(p.UnknownTags)
+	q.TrailingUnknownTags = cloneStrings(p.TrailingUnknownTags)
+	q.Metadata = cloneMetadata(p.Metadata)
+	q.customDecoders = append([]CustomDecoder(nil), p.customDecoders...)
+	q.transforms = append([]Transform(nil), p.transforms...)
+	q.removedDateRanges = append([]removedDateRange(nil), p.removedDateRanges...)
+	q.attrOrder = c.copied
+	if p.sourceMap != nil {
+		q.sourceMap = c.sourceMap(p.sourceMap)
+	}
+	if p.durationText != nil {
+		q.durationText = make(map[*MediaSegment]string, len(p.durationText))
+		for seg, text := range p.durationText {
+			if s, ok := c.copies[seg]; ok {
+				q.durationText[s.(*MediaSegment)] = text
+			}
+		}
+	}
+	return q
+}
+
+func (c *cloner) masterPlaylist(p *MasterPlaylist) *MasterPlaylist {
+	if p == nil {
+		return nil
+	}
+	q := new(MasterPlaylist)
+	*q = *p
+	q.buf = bytes.Buffer{}
+	if p.Variants != nil {
+		q.Variants = make([]*Variant, len(p.Variants))
+		for i, v := range p.Variants {
+			q.Variants[i] = c.variant(v)
+		}
+	}
+	if p.SessionData != nil {
+		q.SessionData = make([]*SessionData, len(p.SessionData))
+		for i, sd := range p.SessionData {
+			if sd != nil {
+				d := *sd
+				c.remember(sd, &d)
+				q.SessionData[i] = &d
+			}
+		}
+	}
+	q.Custom = cloneCustom(p.Custom)
+	q.Defines = cloneDefines(p.Defines)
+	if p.ContentSteering != nil {
+		cs := *p.ContentSteering
+		q.ContentSteering = &cs
+	}
+	q.UnknownTags = cloneStrings(p.UnknownTags)
+	q.TrailingUnknownTags = cloneStrings(p.TrailingUnknownTags)
+	q.customDecoders = append([]CustomDecoder(nil), p.customDecoders...)
+	q.attrOrder = c.copied
+	if p.sourceMap != nil {
+		q.sourceMap = c.sourceMap(p.sourceMap)
+	}
+	return q
+}
+
+func (c *cloner) variant(v *Variant) *Variant {
+	if v == nil {
+		return nil
+	}
+	if q, ok := c.copies[v]; ok {
+		return q.(*Variant)
+	}
+	q := new(Variant)
+	*q = *v
+	c.remember(v, q)
+	if v.Chunklist != nil {
+		// the chunklist has own attribute orders
+		list := &cloner{copies: c.copies, orders: v.Chunklist.attrOrder}
+		q.Chunklist = list.mediaPlaylist(v.Chunklist)
+	}
+	q.Metadata = cloneMetadata(v.Metadata)
+	q.UnknownTags = cloneStrings(v.UnknownTags)
+	if v.Alternatives != nil {
+		q.Alternatives = make([]*Alternative, len(v.Alternatives))
+		for i, alt := range v.Alternatives {
+			q.Alternatives[i] = c.alternative(alt)
+		}
+	}
+	return q
+}
+
+func (c *cloner) alternative(alt *Alternative) *Alternative {
+	if alt == nil {
+		return nil
+	}
+	if q, ok := c.copies[alt]; ok {
+		return q.(*Alternative)
+	}
+	q := *alt
+	c.remember(alt, &q)
+	return &q
+}
+
+func (c *cloner) segment(seg *MediaSegment) *MediaSegment {
+	if seg == nil {
+		return nil
+	}
+	if q, ok := c.copies[seg]; ok {
+		return q.(*MediaSegment)
+	}
+	q := new(MediaSegment)
+	*q = *seg
+	c.remember(seg, q)
+	q.Key = c.key(seg.Key)
+	q.Map = c.xmap(seg.Map)
+	if seg.DateRange != nil {
+		q.DateRange = make([]*DateRange, len(seg.DateRange))
+		for i, dr := range seg.DateRange {
+			q.DateRange[i] = c.dateRange(dr)
+		}
+	}
+	if seg.SCTE != nil {
+		scte := *seg.SCTE
+		q.SCTE = &scte
+	}
+	q.Custom = cloneCustom(seg.Custom)
+	q.Partials = clonePartials(seg.Partials)
+	if seg.Tiles != nil {
+		tiles := *seg.Tiles
+		q.Tiles = &tiles
+	}
+	q.UnknownTags = cloneStrings(seg.UnknownTags)
+	q.Metadata = cloneMetadata(seg.Metadata)
+	return q
+}
+
+func (c *cloner) key(key *Key) *Key {
+	if key == nil {
+		return nil
+	}
+	if q, ok := c.copies[key]; ok {
+		return q.(*Key)
+	}
+	q := *key
+	c.remember(key, &q)
+	return &q
+}
+
+func (c *cloner) xmap(m *Map) *Map {
+	if m == nil {
+		return nil
+	}
+	if q, ok := c.copies[m]; ok {
+		return q.(*Map)
+	}
+	q := *m
+	c.remember(m, &q)
+	return &q
+}
+
+func (c *cloner) dateRange(dr *DateRange) *DateRange {
+	if dr == nil {
+		return nil
+	}
+	if q, ok := c.copies[dr]; ok {
+		return q.(*DateRange)
+	}
+	q := *dr
+	q.X = append(AttributeList(nil), dr.X...)
+	c.remember(dr, &q)
+	return &q
+}
+
+// sourceMap returns the source map related to the copied segments and
+// variants.
+func (c *cloner) sourceMap(m *SourceMap) *SourceMap {
+	q := newSourceMap()
+	for seg, line := range m.Segments {
+		if s, ok := c.copies[seg]; ok {
+			q.Segments[s.(*MediaSegment)] = line
+		}
+	}
+	for v, line := range m.Variants {
+		if cp, ok := c.copies[v]; ok {
+			q.Variants[cp.(*Variant)] = line
+		}
+	}
+	for tag, line := range m.Tags {
+		q.Tags[tag] = line
+	}
+	return q
+}
+
+// cloneCustom copies the custom tags using Clone() CustomTag method of
+// tags having it.
+func cloneCustom(custom CustomTags) CustomTags {
+	if custom == nil {
+		return nil
+	}
+	q := make(CustomTags, len(custom))
+	for i, tag := range custom {
+		if cl, ok := tag.(interface{ Clone() CustomTag }); ok {
+			tag = cl.Clone()
+		}
+		q[i] = tag
+	}
+	return q
+}
+
+func cloneDefines(defines []*Define) []*Define {
+	if defines == nil {
+		return nil
+	}
+	q := make([]*Define, len(defines))
+	for i, d := range defines {
+		if d != nil {
+			def := *d
+			q[i] = &def
+		}
+	}
+	return q
+}
+
+func clonePartials(parts []*PartialSegment) []*PartialSegment {
+	if parts == nil {
+		return nil
+	}
+	q := make([]*PartialSegment, len(parts))
+	for i, part := range parts {
+		if part != nil {
+			ps := *part
+			q[i] = &ps
+		}
+	}
+	return q
+}
+
+func cloneMetadata(m Metadata) Metadata {
+	if m == nil {
+		return nil
+	}
+	q := make(Metadata, len(m))
+	for k, v := range m {
+		q[k] = v
+	}
+	return q
+}
+
+func cloneStrings(s []string) []string {
+	if s == nil {
+		return nil
+	}
+	return append([]string{}, s...)
+}
@@ -0,0 +1,126 @@
+/*
+Playlist cloning tests.
+
+Copyright 2013-2019 The Project Developers.
+See the AUTHORS and LICENSE files at the top-level directory of this distribution
+and at https://github.com/grafov/m3u8/
+
+ॐ तारे तुत्तारे तुरे स्व
+*/
+package m3u8
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMediaSegmentClone(t *testing.T) {
+	seg := &MediaSegment{
+		URI:       "seg.ts",
+		Duration:  6,
+		Key:       &Key{Method: "AES-128", URI: "key"},
+		Map:       &Map{URI: "init.mp4"},
+		SCTE:      &SCTE{Syntax: SCTE35_OATCLS, Cue: "/DAlAAA="},
+		DateRange: []*DateRange{{ID: "ad", X: AttributeList{{Name: "X-COM-AD", Type: AttrString, Value: "a"}}}},
+		Partials:  []*PartialSegment{{URI: "part.ts", Duration: 1}},
+		Custom:    CustomTags{&MockCustomTag{name: "#CUSTOM"}},
+		Metadata:  Metadata{"viewer": 1},
+	}
+	c := seg.Clone()
+	c.Key.URI = "other"
+	c.Map.URI = "other.mp4"
+	c.SCTE.Cue = "other"
+	c.DateRange[0].ID = "other"
+	c.DateRange[0].X[0].Value = "other"
+	c.Partials[0].URI = "other.ts"
+	c.Custom.Remove("#CUSTOM")
+	c.Metadata["viewer"] = 2
+	if seg.Key.URI != "key" || seg.Map.URI != "init.mp4" || seg.SCTE.Cue != "/DAlAAA=" {
+		t.Errorf("Changes of the clone affect the segment: %+v", seg)
+	}
+	if seg.DateRange[0].ID != "ad" || seg.DateRange[0].X[0].Value != "a" {
+		t.Errorf("Changes of the clone affect daterange: %+v", seg.DateRange[0])
+	}
+	if seg.Partials[0].URI != "part.ts" || len(seg.Custom) != 1 || seg.Metadata["viewer"] != 1 {
+		t.Errorf("Changes of the clone affect the segment: %+v", seg)
+	}
+	if (*MediaSegment)(nil).Clone() != nil {
+		t.Error("Clone of nil segment must be nil")
+	}
+}
+
+func TestMediaPlaylistClone(t *testing.T) {
+	p, _ := NewMediaPlaylist(3, 5)
+	p.SetDefaultKey("AES-128", "key", "", "", "")
+	for _, uri := range []string{"a.ts", "b.ts", "c.ts"} {
+		p.Append(uri, 6, "")
+	}
+	p.SetKey("AES-128", "key2", "", "", "")
+	want := p.String()
+	c := p.Clone()
+	if c.String() != want {
+		t.Fatalf("Clone encodes differently:\n%s\nwant:\n%s", c, want)
+	}
+	c.Key.URI = "other"
+	c.Segments[2].Key.URI = "other2"
+	c.Segments[0].URI = "z.ts"
+	c.Append("d.ts", 6, "")
+	if p.String() != want {
+		t.Errorf("Changes of the clone affect the playlist:\n%s\nwant:\n%s", p, want)
+	}
+	if p.Count() != 3 || c.Count() != 4 {
+		t.Errorf("Counts are %d and %d, expected 3 and 4", p.Count(), c.Count())
+	}
+}
+
+func TestMediaPlaylistCloneKeepsDecodedState(t *testing.T) {
+	src := `#EXTM3U
+#EXT-X-VERSION:3
+#EXT-X-TARGETDURATION:6
+#EXT-X-KEY:URI="key",METHOD=AES-128
+#EXTINF:6.000,
+a.ts
+#EXTINF:6.000,
+b.ts
+#EXT-X-ENDLIST
+`
+	p, _ := NewMediaPlaylist(0, 2)
+	opts := DecodeOptions{PreserveAttributeOrder: true, SourceMap: true}
+	if err := p.DecodeWithOptions(strings.NewReader(src), opts); err != nil {
+		t.Fatal(err)
+	}
+	c := p.Clone()
+	if !strings.Contains(c.String(), `#EXT-X-KEY:URI="key",METHOD=AES-128`) {
+		t.Errorf("Clone loses the order of attributes:\n%s", c)
+	}
+	if line := c.SourceMap().Segments[c.Segments[1]]; line != 7 {
+		t.Errorf("Line of the cloned segment is %d, expected 7", line)
+	}
+}
+
+func TestMasterPlaylistClone(t *testing.T) {
+	m := NewMasterPlaylist()
+	alt := &Alternative{GroupId: "aac", Type: "AUDIO", Name: "en", URI: "en.m3u8"}
+	chunklist, _ := NewMediaPlaylist(1, 1)
+	chunklist.Append("a.ts", 6, "")
+	m.Append("low.m3u8", chunklist, VariantParams{Bandwidth: 100000, Audio: "aac", Alternatives: []*Alternative{alt}})
+	m.Append("high.m3u8", nil, VariantParams{Bandwidth: 200000, Audio: "aac", Alternatives: []*Alternative{alt}})
+	want := m.String()
+	c := m.Clone()
+	if c.String() != want {
+		t.Fatalf("Clone encodes differently:\n%s\nwant:\n%s", c, want)
+	}
+	if c.Variants[0].Alternatives[0] != c.Variants[1].Alternatives[0] {
+		t.Error("Alternative shared by variants isn't shared by the clones")
+	}
+	c.Variants[0].Alternatives[0].Name = "de"
+	c.Variants[0].Bandwidth = 1
+	c.Variants[0].Chunklist.Segments[0].URI = "b.ts"
+	if m.String() != want || alt.Name != "en" || chunklist.Segments[0].URI != "a.ts" {
+		t.Errorf("Changes of the clone affect the playlist:\n%s", m)
+	}
+	v := m.Variants[0].Clone()
+	if v.Alternatives[0] == alt || v.Chunklist == chunklist {
+		t.Error("Variant clone shares alternatives or chunklist")
+	}
+}
@@ -0,0 +1,265 @@
+package m3u8
+
+/*
+ Part of M3U8 parser & generator library.
+ This file defines parsing and completion of CODECS attribute of variants.
+
+ Copyright 2013-2019 The Project Developers.
+ See the AUTHORS and LICENSE files at the top-level directory of this distribution
+ and at https://github.com/grafov/m3u8/
+
+ ॐ तारे तुत्तारे तुरे स्व
+*/
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// AudioCodecResolver returns the CODECS value of the audio rendition or
+// empty string when the codec is unknown.
+type AudioCodecResolver func(alt *Alternative) string
+
+// audioCodecHints maps substrings of GROUP-ID and NAME of audio
+// renditions to their codecs, the longer hints are checked first.
+var audioCodecHints = []struct {
+	hint  string
+	codec string
+}{
+	{"he-aac", "mp4a.40.5"},
+	{"heaac", "mp4a.40.5"},
+	{"e-ac-3", "ec-3"},
+	{"eac3", "ec-3"},
+	{"ec-3", "ec-3"},
+	{"ec3", "ec-3"},
+	{"atmos", "ec-3"},
+	{"ac-3", "ac-3"},
+	{"ac3", "ac-3"},
+	{"ac-4", "ac-4"},
+	{"ac4", "ac-4"},
+	{"aac", "mp4a.40.2"},
+	{"mp4a", "mp4a.40.2"},
+	{"opus", "Opus"},
+	{"flac", "fLaC"},
+	{"alac", "alac"},
+}
+
+// InferAudioCodec is the default AudioCodecResolver. It derives the
+// codec of the audio rendition from the hints in its GROUP-ID and NAME
+// (e.g. "aac-stereo" or "ec3-5.1"), then from CHANNELS: joint object
+// coding (e.g. "16/JOC") means Dolby Atmos in E-AC-3 and up to two
+// channels mean AAC-LC. Multichannel renditions without hints are
+// unknown.
+func InferAudioCodec(alt *Alternative) string {
+	if alt == nil || alt.Type != "AUDIO" {
+		return ""
+	}
+	for _, s := range []string{alt.GroupId, alt.Name} {
+		s = strings.ToLower(s)
+		for _, h := range audioCodecHints {
+			if strings.Contains(s, h.hint) {
+				return h.codec
+			}
+		}
+	}
+	if alt.Channels == "" {
+		return ""
+	}
+	params := strings.Split(alt.Channels, "/")
+	if len(params) > 1 && strings.Contains(params[1], "JOC") {
+		return "ec-3"
+	}
+	if n, err := strconv.Atoi(params[0]); err == nil && n > 0 && n <= 2 {
+		return "mp4a.40.2"
+	}
+	return ""
+}
+
+// Codec is an entry of CODECS attribute in RFC 6381 format, e.g.
+// "avc1.640029" or "mp4a.40.2". Profile and Level hold the values as
+// written in the entry for the known families: the profile_idc and
+// level_idc hex digits for AVC ("64" and "29"), the general_profile_idc
+// and the tier with general_level_idc for HEVC ("2" and "L123"), the
+// seq_profile and seq_level_idx with the tier for AV1 ("0" and "04M"),
+// the profile and the level for VP9 and Dolby Vision and the audio
+// object type for MPEG-4 audio ("2" for AAC-LC, "5" for HE-AAC). They
+// are empty for other codecs.
+type Codec struct {
+	Value   string // the entry as written
+	Family  string // the sample entry (four-character code), e.g. "avc1", "ec-3"
+	Profile string
+	Level   string
+}
+
+// codecKinds classifies the known codec families (lower-cased).
+var codecKinds = map[string]byte{
+	"avc1": 'v', "avc3": 'v', "hvc1": 'v', "hev1": 'v', "dvh1": 'v', "dvhe": 'v',
+	"dva1": 'v', "dvav": 'v', "dav1": 'v', "av01": 'v', "vp08": 'v', "vp09": 'v',
+	"vvc1": 'v', "vvi1": 'v',
+	"mp4a": 'a', "ac-3": 'a', "ec-3": 'a', "ac-4": 'a', "opus": 'a', "flac": 'a',
+	"alac": 'a', "mha1": 'a', "mhm1": 'a',
+	"stpp": 't', "wvtt": 't',
+}
+
+// ParseCodec parses the entry of CODECS attribute.
+func ParseCodec(value string) Codec {
+	value = strings.TrimSpace(value)
+	parts := strings.Split(value, ".")
+	c := Codec{Value: value, Family: parts[0]}
+	at := func(i int) string {
+		if i < len(parts) {
+			return parts[i]
+		}
+		return ""
+	}
+	switch c.Family {
+	case "avc1", "avc3":
+		if p := at(1); len(p) == 6 {
+			c.Profile, c.Level = p[:2], p[4:]
+		}
+	case "hvc1", "hev1":
+		c.Profile = strings.TrimLeft(at(1), "ABC")
+		c.Level = at(3)
+	case "av01", "vp09", "dvh1", "dvhe", "dva1", "dvav", "dav1":
+		c.Profile, c.Level = at(1), at(2)
+	case "mp4a":
+		if at(1) == "40" {
+			c.Profile = at(2)
+		}
+	}
+	return c
+}
+
+// String returns the entry as written.
+func (c Codec) String() string {
+	return c.Value
+}
+
+// IsVideo reports whether the codec is a known video codec.
+func (c Codec) IsVideo() bool {
+	return codecKinds[strings.ToLower(c.Family)] == 'v'
+}
+
+// IsAudio reports whether the codec is a known audio codec.
+func (c Codec) IsAudio() bool {
+	return codecKinds[strings.ToLower(c.Family)] == 'a'
+}
+
+// IsText reports whether the codec is a known subtitles codec (IMSC or
+// WebVTT in fragmented MP4).
+func (c Codec) IsText() bool {
+	return codecKinds[strings.ToLower(c.Family)] == 't'
+}
+
+// IsAVC reports whether the codec is H.264.
+func (c Codec) IsAVC() bool {
+	return c.Family == "avc1" || c.Family == "avc3"
+}
+
+// IsHEVC reports whether the codec is H.265 including Dolby Vision
+// profiles based on it.
+func (c Codec) IsHEVC() bool {
+	switch c.Family {
+	case "hvc1", "hev1", "dvh1", "dvhe":
+		return true
+	}
+	return false
+}
+
+// IsDolbyVision reports whether the codec is a Dolby Vision profile.
+func (c Codec) IsDolbyVision() bool {
+	switch c.Family {
+	case "dvh1", "dvhe", "dva1", "dvav", "dav1":
+		return true
+	}
+	return false
+}
+
+// Codecs is the parsed CODECS attribute.
+type Codecs []Codec
+
+// ParseCodecs splits the CODECS attribute into the codecs. Empty
+// entries are skipped.
+func ParseCodecs(codecs string) Codecs {
+	var list Codecs
+	for _, value := range strings.Split(codecs, ",") {
+		if strings.TrimSpace(value) != "" {
+			list = append(list, ParseCodec(value))
+		}
+	}
+	return list
+}
+
+// String returns the CODECS attribute.
+func (list Codecs) String() string {
+	values := make([]string, len(list))
+	for i, c := range list {
+		values[i] = c.Value
+	}
+	return strings.Join(values, ",")
+}
+
+// HasVideo reports whether the list has a video codec.
+func (list Codecs) HasVideo() bool {
+	return list.has(Codec.IsVideo)
+}
+
+// HasAudio reports whether the list has an audio codec.
+func (list Codecs) HasAudio() bool {
+	return list.has(Codec.IsAudio)
+}
+
+// HasText reports whether the list has a subtitles codec.
+func (list Codecs) HasText() bool {
+	return list.has(Codec.IsText)
+}
+
+// HasHEVC reports whether the list has an H.265 codec.
+func (list Codecs) HasHEVC() bool {
+	return list.has(Codec.IsHEVC)
+}
+
+// has reports whether the function returns true for a codec.
+func (list Codecs) has(fn func(Codec) bool) bool {
+	for _, c := range list {
+		if fn(c) {
+			return true
+		}
+	}
+	return false
+}
+
+// CompleteAudioCodecs appends the codecs of the renditions of the
+// audio group (AUDIO attribute) to CODECS of the variants which list
+// no audio codec, as CODECS must list every codec of the variant
+// (section 4.4.6.2). Codecs of the renditions are resolved with the
+// function, InferAudioCodec if nil. Variants without CODECS or AUDIO
+// and I-frame variants are left intact. It returns error if a codec
+// of the group can't be resolved, variants completed before the error
+// keep their CODECS. This operation does reset playlist cache.
+func (p *MasterPlaylist) CompleteAudioCodecs(resolve AudioCodecResolver) error {
+	if resolve == nil {
+		resolve = InferAudioCodec
+	}
+	p.buf.Reset()
+	for _, v := range p.Variants {
+		if v == nil || v.Iframe || v.Images || v.Codecs == "" || v.Audio == "" || ParseCodecs(v.Codecs).HasAudio() {
+			continue
+		}
+		codecs := strings.Split(v.Codecs, ",")
+		seen := make(map[string]bool)
+		for _, alt := range p.renditionGroups(v.Audio)["AUDIO"] {
+			codec := resolve(alt)
+			if codec == "" {
+				return fmt.Errorf("variant %q: can't resolve codec of audio rendition %q in group %q", v.URI, alt.Name, v.Audio)
+			}
+			if !seen[codec] {
+				seen[codec] = true
+				codecs = append(codecs, codec)
+			}
+		}
+		v.Codecs = strings.Join(codecs, ",")
+	}
+	return nil
+}
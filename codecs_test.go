@@ -0,0 +1,100 @@
+/*
+CODECS completion tests.
+
+Copyright 2013-2019 The Project Developers.
+See the AUTHORS and LICENSE files at the top-level directory of this distribution
+and at https://github.com/grafov/m3u8/
+
+ॐ तारे तुत्तारे तुरे स्व
+*/
+package m3u8
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestInferAudioCodec(t *testing.T) {
+	cases := []struct {
+		alt  Alternative
+		want string
+	}{
+		{Alternative{Type: "AUDIO", GroupId: "aac-stereo"}, "mp4a.40.2"},
+		{Alternative{Type: "AUDIO", GroupId: "audio", Name: "English HE-AAC"}, "mp4a.40.5"},
+		{Alternative{Type: "AUDIO", GroupId: "ec3-surround"}, "ec-3"},
+		{Alternative{Type: "AUDIO", GroupId: "a1", Channels: "16/JOC"}, "ec-3"},
+		{Alternative{Type: "AUDIO", GroupId: "a1", Channels: "2"}, "mp4a.40.2"},
+		{Alternative{Type: "AUDIO", GroupId: "a1", Channels: "6"}, ""},
+		{Alternative{Type: "SUBTITLES", GroupId: "aac"}, ""},
+	}
+	for _, c := range cases {
+		if got := InferAudioCodec(&c.alt); got != c.want {
+			t.Errorf("%+v: expected %q, got %q", c.alt, c.want, got)
+		}
+	}
+}
+
+func TestCompleteAudioCodecs(t *testing.T) {
+	stereo := &Alternative{Type: "AUDIO", GroupId: "aud", Name: "English", Channels: "2"}
+	atmos := &Alternative{Type: "AUDIO", GroupId: "aud", Name: "English Atmos", Channels: "16/JOC"}
+	m := NewMasterPlaylist()
+	m.Append("low.m3u8", nil, VariantParams{Bandwidth: 800000, Codecs: "avc1.4d401e", Audio: "aud", Alternatives: []*Alternative{stereo, atmos}})
+	m.Append("high.m3u8", nil, VariantParams{Bandwidth: 2500000, Codecs: "avc1.640028,mp4a.40.2", Audio: "aud", Alternatives: []*Alternative{stereo, atmos}})
+	m.Append("iframe.m3u8", nil, VariantParams{Bandwidth: 200000, Codecs: "avc1.4d401e", Iframe: true})
+	if err := m.CompleteAudioCodecs(nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := m.Variants[0].Codecs; got != "avc1.4d401e,mp4a.40.2,ec-3" {
+		t.Errorf("unexpected completed CODECS: %s", got)
+	}
+	if got := m.Variants[1].Codecs; got != "avc1.640028,mp4a.40.2" {
+		t.Errorf("CODECS with audio codec changed: %s", got)
+	}
+	if got := m.Variants[2].Codecs; got != "avc1.4d401e" {
+		t.Errorf("CODECS of I-frame variant changed: %s", got)
+	}
+
+	surround := &Alternative{Type: "AUDIO", GroupId: "surround", Name: "English 5.1", Channels: "6"}
+	m = NewMasterPlaylist()
+	m.Append("low.m3u8", nil, VariantParams{Bandwidth: 800000, Codecs: "avc1.4d401e", Audio: "surround", Alternatives: []*Alternative{surround}})
+	if err := m.CompleteAudioCodecs(nil); err == nil {
+		t.Error("expected error for unresolved codec")
+	}
+	err := m.CompleteAudioCodecs(func(alt *Alternative) string { return "ac-3" })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := m.Variants[0].Codecs; got != "avc1.4d401e,ac-3" {
+		t.Errorf("unexpected CODECS with custom resolver: %s", got)
+	}
+}
+
+func TestParseCodecs(t *testing.T) {
+	list := ParseCodecs("avc1.640029, hvc1.2.4.L123.B0,mp4a.40.2,ec-3,,av01.0.04M.10,wvtt")
+	want := Codecs{
+		{Value: "avc1.640029", Family: "avc1", Profile: "64", Level: "29"},
+		{Value: "hvc1.2.4.L123.B0", Family: "hvc1", Profile: "2", Level: "L123"},
+		{Value: "mp4a.40.2", Family: "mp4a", Profile: "2"},
+		{Value: "ec-3", Family: "ec-3"},
+		{Value: "av01.0.04M.10", Family: "av01", Profile: "0", Level: "04M"},
+		{Value: "wvtt", Family: "wvtt"},
+	}
+	if !reflect.DeepEqual(list, want) {
+		t.Fatalf("expected %+v, got %+v", want, list)
+	}
+	if s := list.String(); s != "avc1.640029,hvc1.2.4.L123.B0,mp4a.40.2,ec-3,av01.0.04M.10,wvtt" {
+		t.Errorf("unexpected CODECS %s", s)
+	}
+	if !list.HasVideo() || !list.HasAudio() || !list.HasText() || !list.HasHEVC() {
+		t.Error("expected video, audio, text and HEVC codecs")
+	}
+	if !list[0].IsAVC() || list[0].IsHEVC() || !list[3].IsAudio() || list[3].IsVideo() {
+		t.Error("unexpected codec kinds")
+	}
+	if ParseCodecs("avc1.4d401e,Opus").HasHEVC() || !ParseCodecs("Opus").HasAudio() {
+		t.Error("unexpected codec kinds of AVC and Opus")
+	}
+	if !ParseCodec("dvh1.05.06").IsDolbyVision() || !ParseCodec("dvh1.05.06").IsHEVC() {
+		t.Error("dvh1 is expected to be Dolby Vision HEVC")
+	}
+}
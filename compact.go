@@ -0,0 +1,212 @@
+package m3u8
+
+/*
+ Part of M3U8 parser & generator library.
+ This file defines the compact representation of huge media playlists.
+
+ Copyright 2013-2019 The Project Developers.
+ See the AUTHORS and LICENSE files at the top-level directory of this distribution
+ and at https://github.com/grafov/m3u8/
+
+ ॐ तारे तुत्तारे तुरे स्व
+*/
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// CompactMediaPlaylist keeps a decoded media playlist in compact
+// columnar form: the source data with offsets of segments and their
+// durations. MediaSegment structures are materialized on demand. It
+// cuts memory for VOD playlists with millions of segments when only a
+// few segments are needed at once. Use DecodeCompact to create it.
+type CompactMediaPlaylist struct {
+	TargetDuration   float64
+	SeqNo            uint64 // EXT-X-MEDIA-SEQUENCE
+	DiscontinuitySeq uint64 // EXT-X-DISCONTINUITY-SEQUENCE
+	MediaType        MediaType
+	Closed           bool
+	Key              *Key // default key of the playlist
+	Map              *Map // default map of the playlist
+	ver              uint8
+	strict           bool
+	data             []byte
+	starts           []uint32  // offset of the first line of each segment
+	uris             []uint32  // offset of the URI line of each segment
+	durations        []float64 // EXTINF durations
+}
+
+// segmentTags are tags of media segments. Other tags preceding the
+// first segment belong to the header, including EXT-X-KEY and
+// EXT-X-MAP which are the defaults of the playlist (Key and Map).
+var segmentTags = map[string]bool{
+	"#EXTINF":                  true,
+	"#EXT-X-BYTERANGE":         true,
+	"#EXT-X-DISCONTINUITY":     true,
+	"#EXT-X-GAP":               true,
+	"#EXT-X-PROGRAM-DATE-TIME": true,
+	"#EXT-X-BITRATE":           true,
+	"#EXT-X-DATERANGE":         true,
+	"#EXT-X-PART":              true,
+	"#EXT-X-TILES":             true,
+	"#EXT-SCTE35":              true,
+	"#EXT-OATCLS-SCTE35":       true,
+	"#EXT-X-CUE":               true,
+	"#EXT-X-CUE-OUT":           true,
+	"#EXT-X-CUE-OUT-CONT":      true,
+	"#EXT-X-CUE-IN":            true,
+}
+
+// isSegmentTag returns true for the line of the media segment tag.
+func isSegmentTag(line []byte) bool {
+	name := line
+	if i := bytes.IndexByte(line, ':'); i >= 0 {
+		name = line[:i]
+	}
+	return segmentTags[string(name)]
+}
+
+// DecodeCompact decodes media playlist into the compact form. Header
+// tags are decoded immediately, segments are only indexed. Strict
+// mode applies to the header and to the segments materialized later
+// as for DecodeFrom. Master playlists are not supported.
+func DecodeCompact(reader io.Reader, strict bool) (*CompactMediaPlaylist, error) {
+	buf := new(bytes.Buffer)
+	if _, err := buf.ReadFrom(reader); err != nil {
+		return nil, err
+	}
+	data := buf.Bytes()
+	if len(data) > math.MaxUint32 {
+		return nil, errors.New("playlist is too large")
+	}
+	c := &CompactMediaPlaylist{data: data, strict: strict}
+
+	var (
+		start    int  // offset of the first line of the next segment
+		tags     bool // the first segment tag is found
+		duration float64
+		inf      bool
+	)
+	header, _ := NewMediaPlaylist(0, 1)
+	headerState := new(decodingState)
+	headerWV := new(WV)
+	for pos := 0; pos < len(data); {
+		end := bytes.IndexByte(data[pos:], '\n')
+		if end < 0 {
+			end = len(data)
+		} else {
+			end += pos
+		}
+		line := bytes.TrimSpace(data[pos:end])
+		switch {
+		case len(line) == 0:
+		case bytes.HasPrefix(line, []byte("#EXTINF:")):
+			inf = true
+			value := line[8:]
+			if i := bytes.IndexByte(value, ','); i >= 0 {
+				value = value[:i]
+			}
+			duration, _ = strconv.ParseFloat(string(value), 64)
+		case bytes.Equal(line, []byte("#EXT-X-ENDLIST")):
+			c.Closed = true
+		case line[0] != '#':
+			if inf {
+				c.starts = append(c.starts, uint32(start))
+				c.uris = append(c.uris, uint32(pos))
+				c.durations = append(c.durations, duration)
+				inf = false
+			}
+			start = end + 1
+		case len(c.starts) == 0:
+			// header tags appear before the first segment, the first
+			// segment starts with its first tag
+			if err := decodeLineOfMediaPlaylist(header, headerWV, headerState, string(line), strict); headerState.violation(err, strict) {
+				return nil, err
+			}
+			tags = tags || isSegmentTag(line)
+			if !tags {
+				start = end + 1
+			}
+		}
+		pos = end + 1
+	}
+	if !headerState.m3u {
+		return nil, errors.New("#EXTM3U absent")
+	}
+	c.TargetDuration = header.TargetDuration
+	c.SeqNo = header.SeqNo
+	c.DiscontinuitySeq = header.DiscontinuitySeq
+	c.MediaType = header.MediaType
+	c.Key = headerState.xkey
+	c.Map = headerState.xmap
+	c.ver = header.ver
+	return c, nil
+}
+
+// Count returns the number of segments.
+func (c *CompactMediaPlaylist) Count() int {
+	return len(c.durations)
+}
+
+// Duration returns the duration of the i-th segment.
+func (c *CompactMediaPlaylist) Duration(i int) float64 {
+	return c.durations[i]
+}
+
+// URI returns the URI of the i-th segment.
+func (c *CompactMediaPlaylist) URI(i int) string {
+	line := c.data[c.uris[i]:]
+	if end := bytes.IndexByte(line, '\n'); end >= 0 {
+		line = line[:end]
+	}
+	return strings.TrimSpace(string(line))
+}
+
+// Version returns the playlist version number.
+func (c *CompactMediaPlaylist) Version() uint8 {
+	return c.ver
+}
+
+// Segment materializes the i-th segment with all its tags. Each call
+// returns a new MediaSegment.
+func (c *CompactMediaPlaylist) Segment(i int) (*MediaSegment, error) {
+	if i < 0 || i >= len(c.starts) {
+		return nil, errors.New("segment index out of range")
+	}
+	p, _ := NewMediaPlaylist(0, 1)
+	state := new(decodingState)
+	wv := new(WV)
+	end := len(c.data)
+	if i+1 < len(c.starts) {
+		end = int(c.starts[i+1])
+	}
+	for _, line := range strings.Split(string(c.data[c.starts[i]:end]), "\n") {
+		if err := decodeLineOfMediaPlaylist(p, wv, state, line, c.strict); state.violation(err, c.strict) {
+			return nil, err
+		}
+	}
+	if p.count == 0 {
+		return nil, errors.New("segment not found")
+	}
+	seg := p.Segments[p.head]
+	seg.SeqId = c.SeqNo + uint64(i)
+	return seg, nil
+}
+
+// MediaPlaylist materializes all segments into a regular media
+// playlist. The window of the playlist includes all segments.
+func (c *CompactMediaPlaylist) MediaPlaylist() (*MediaPlaylist, error) {
+	p, err := NewMediaPlaylist(0, uint(len(c.durations))+1)
+	if err != nil {
+		return nil, err
+	}
+	if err = p.decode(bytes.NewBuffer(c.data), DecodeOptions{Strict: c.strict}); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
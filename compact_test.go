@@ -0,0 +1,128 @@
+/*
+Compact media playlist tests.
+
+Copyright 2013-2019 The Project Developers.
+See the AUTHORS and LICENSE files at the top-level directory of this distribution
+and at https://github.com/grafov/m3u8/
+
+ॐ तारे तुत्तारे तुरे स्व
+*/
+package m3u8
+
+import (
+	"bytes"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDecodeCompact(t *testing.T) {
+	for _, name := range []string{
+		"sample-playlists/media-playlist-large.m3u8",
+		"sample-playlists/media-playlist-with-byterange.m3u8",
+		"sample-playlists/media-playlist-with-discontinuity.m3u8",
+		"sample-playlists/media-playlist-with-program-date-time.m3u8",
+		"sample-playlists/wowza-vod-chunklist.m3u8",
+	} {
+		data, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c, err := DecodeCompact(bytes.NewReader(data), true)
+		if err != nil {
+			t.Fatalf("%s: %s", name, err)
+		}
+		p, _, err := DecodeFrom(bytes.NewReader(data), true)
+		if err != nil {
+			t.Fatalf("%s: %s", name, err)
+		}
+		full := p.(*MediaPlaylist)
+		if uint(c.Count()) != full.Count() {
+			t.Fatalf("%s: %d segments, want %d", name, c.Count(), full.Count())
+		}
+		if c.TargetDuration != full.TargetDuration || c.SeqNo != full.SeqNo || c.Closed != full.Closed {
+			t.Errorf("%s: header mismatch", name)
+		}
+		for i := 0; i < c.Count(); i++ {
+			want := full.Segments[i]
+			if c.URI(i) != want.URI || c.Duration(i) != want.Duration {
+				t.Errorf("%s: segment %d is %s/%v, want %s/%v", name, i, c.URI(i), c.Duration(i), want.URI, want.Duration)
+			}
+			seg, err := c.Segment(i)
+			if err != nil {
+				t.Fatalf("%s: segment %d: %s", name, i, err)
+			}
+			if !reflect.DeepEqual(seg, want) {
+				t.Errorf("%s: segment %d is %+v, want %+v", name, i, seg, want)
+			}
+		}
+		materialized, err := c.MediaPlaylist()
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = full.SetWinSize(0)
+		if materialized.String() != full.String() {
+			t.Errorf("%s: materialized playlist differs", name)
+		}
+	}
+}
+
+func TestDecodeCompactHeader(t *testing.T) {
+	const playlist = `#EXTM3U
+#EXT-X-VERSION:6
+#EXT-X-TARGETDURATION:10
+#EXT-X-KEY:METHOD=AES-128,URI="key"
+#EXT-X-MAP:URI="init.mp4"
+#EXT-X-PROGRAM-DATE-TIME:2020-01-01T00:00:00Z
+#EXTINF:10.000,
+s0.ts
+#EXT-X-BYTERANGE:bad
+#EXTINF:10.000,
+s1.ts
+`
+	c, err := DecodeCompact(strings.NewReader(playlist), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Key == nil || c.Key.URI != "key" || c.Map == nil || c.Map.URI != "init.mp4" {
+		t.Fatalf("unexpected header key %+v and map %+v", c.Key, c.Map)
+	}
+	seg, err := c.Segment(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if seg.Key != nil || seg.Map != nil {
+		t.Errorf("header key and map must not be linked to the first segment: %+v %+v", seg.Key, seg.Map)
+	}
+	if seg.ProgramDateTime.IsZero() || seg.URI != "s0.ts" {
+		t.Errorf("unexpected first segment %+v", seg)
+	}
+	if _, err = c.Segment(1); err == nil {
+		t.Error("expected error for invalid byte range in strict mode")
+	}
+
+	c, err = DecodeCompact(strings.NewReader(strings.Replace(playlist, "TARGETDURATION:10", "TARGETDURATION:x", 1)), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = c.Segment(1); err != nil {
+		t.Errorf("unexpected error in lenient mode: %s", err)
+	}
+	if _, err = DecodeCompact(strings.NewReader(strings.Replace(playlist, "TARGETDURATION:10", "TARGETDURATION:x", 1)), true); err == nil {
+		t.Error("expected error for invalid header in strict mode")
+	}
+}
+
+func BenchmarkDecodeCompact(b *testing.B) {
+	data, err := os.ReadFile("sample-playlists/media-playlist-large.m3u8")
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := DecodeCompact(bytes.NewReader(data), true); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
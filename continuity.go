@@ -0,0 +1,137 @@
+package m3u8
+
+/*
+ Part of M3U8 parser & generator library.
+ This file defines the continuity checker of live media playlists.
+
+ Copyright 2013-2019 The Project Developers.
+ See the AUTHORS and LICENSE files at the top-level directory of this distribution
+ and at https://github.com/grafov/m3u8/
+
+ ॐ तारे तुत्तारे तुरे स्व
+*/
+
+import "fmt"
+
+// ContinuityRule is a rule for successive refreshes of a live media
+// playlist (section 6.2.2).
+type ContinuityRule string
+
+const (
+	RuleSequenceMonotonic       ContinuityRule = "EXT-X-MEDIA-SEQUENCE must not decrease"
+	RuleSegmentImmutable        ContinuityRule = "segment with the same sequence number must not change"
+	RuleTargetDurationStable    ContinuityRule = "EXT-X-TARGETDURATION must not change"
+	RuleDiscontinuitySeqAdvance ContinuityRule = "EXT-X-DISCONTINUITY-SEQUENCE must advance by removed discontinuities"
+)
+
+// ContinuityViolation describes a violation of the continuity rule
+// found by ContinuityChecker. SeqId is the media sequence number of
+// the changed segment for RuleSegmentImmutable and the media sequence
+// number of the playlist for other rules.
+type ContinuityViolation struct {
+	Rule    ContinuityRule
+	SeqId   uint64
+	Message string
+}
+
+func (v ContinuityViolation) Error() string {
+	return fmt.Sprintf("%s: %s", v.Rule, v.Message)
+}
+
+// retainedSegment keeps properties of the segment which must not
+// change between refreshes.
+type retainedSegment struct {
+	uri           string
+	duration      float64
+	discontinuity bool
+}
+
+// ContinuityChecker consumes successive refreshes of the same live
+// media playlist and validates the rules of continuity between them:
+// the media sequence number doesn't decrease, segments with the same
+// media sequence number keep their URIs and durations, the target
+// duration doesn't change and the discontinuity sequence advances
+// exactly by the number of discontinuities removed with segments. The
+// last rule is checked only loosely (the discontinuity sequence grows
+// by at most the number of removed segments) when some removed
+// segments were never seen by the checker. It is not safe for
+// concurrent use.
+type ContinuityChecker struct {
+	started        bool
+	seqNo          uint64
+	targetDuration float64
+	dseq           uint64
+	segments       map[uint64]retainedSegment
+}
+
+// NewContinuityChecker creates a checker without a history.
+func NewContinuityChecker() *ContinuityChecker {
+	return new(ContinuityChecker)
+}
+
+// Check compares the refresh of the playlist with the previous one and
+// returns violations of the continuity rules. The first refresh only
+// initializes the checker.
+func (c *ContinuityChecker) Check(p *MediaPlaylist) []ContinuityViolation {
+	current := make(map[uint64]retainedSegment, p.count)
+	p.eachSegment(func(seg *MediaSegment) {
+		current[seg.SeqId] = retainedSegment{seg.URI, seg.Duration, seg.Discontinuity}
+	})
+	defer func() {
+		c.started = true
+		c.seqNo, c.targetDuration, c.dseq = p.SeqNo, p.TargetDuration, p.DiscontinuitySeq
+		c.segments = current
+	}()
+	if !c.started {
+		return nil
+	}
+
+	var violations []ContinuityViolation
+	add := func(rule ContinuityRule, seqID uint64, format string, args ...interface{}) {
+		violations = append(violations, ContinuityViolation{rule, seqID, fmt.Sprintf(format, args...)})
+	}
+	if p.SeqNo < c.seqNo {
+		add(RuleSequenceMonotonic, p.SeqNo, "decreased from %d to %d", c.seqNo, p.SeqNo)
+	}
+	if p.TargetDuration != c.targetDuration {
+		add(RuleTargetDurationStable, p.SeqNo, "changed from %v to %v", c.targetDuration, p.TargetDuration)
+	}
+	p.eachSegment(func(seg *MediaSegment) {
+		prev, ok := c.segments[seg.SeqId]
+		if !ok {
+			return
+		}
+		if prev.uri != seg.URI {
+			add(RuleSegmentImmutable, seg.SeqId, "URI changed from %q to %q", prev.uri, seg.URI)
+		}
+		if prev.duration != seg.Duration {
+			add(RuleSegmentImmutable, seg.SeqId, "duration changed from %v to %v", prev.duration, seg.Duration)
+		}
+	})
+
+	if p.SeqNo < c.seqNo {
+		return violations
+	}
+	removed := p.SeqNo - c.seqNo
+	var expected uint64
+	seen := true
+	for seqID := c.seqNo; seqID < p.SeqNo; seqID++ {
+		seg, ok := c.segments[seqID]
+		if !ok {
+			seen = false
+			break
+		}
+		if seg.discontinuity {
+			expected++
+		}
+	}
+	switch {
+	case p.DiscontinuitySeq < c.dseq:
+		add(RuleDiscontinuitySeqAdvance, p.SeqNo, "decreased from %d to %d", c.dseq, p.DiscontinuitySeq)
+	case seen && p.DiscontinuitySeq-c.dseq != expected:
+		add(RuleDiscontinuitySeqAdvance, p.SeqNo, "advanced from %d to %d, expected %d", c.dseq, p.DiscontinuitySeq, c.dseq+expected)
+	case !seen && p.DiscontinuitySeq-c.dseq > removed:
+		add(RuleDiscontinuitySeqAdvance, p.SeqNo, "advanced from %d to %d after removal of %d segments", c.dseq, p.DiscontinuitySeq, removed)
+	}
+	return violations
+}
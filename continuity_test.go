@@ -0,0 +1,71 @@
+/*
+Continuity checker tests.
+
+Copyright 2013-2019 The Project Developers.
+See the AUTHORS and LICENSE files at the top-level directory of this distribution
+and at https://github.com/grafov/m3u8/
+
+ॐ तारे तुत्तारे तुरे स्व
+*/
+package m3u8
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestContinuityChecker(t *testing.T) {
+	p, e := NewMediaPlaylist(3, 3)
+	if e != nil {
+		t.Fatalf("Create media playlist failed: %s", e)
+	}
+	c := NewContinuityChecker()
+	for i := 0; i < 6; i++ {
+		p.Slide(fmt.Sprintf("s%d.ts", i), 4, "")
+		if i == 1 {
+			p.SetDiscontinuity()
+		}
+		if v := c.Check(p); len(v) > 0 {
+			t.Fatalf("refresh %d: unexpected violations %v", i, v)
+		}
+	}
+	if p.DiscontinuitySeq != 1 {
+		t.Fatalf("expected discontinuity sequence 1, got %d", p.DiscontinuitySeq)
+	}
+
+	check := func(rule ContinuityRule) {
+		t.Helper()
+		v := c.Check(p)
+		if len(v) != 1 || v[0].Rule != rule {
+			t.Errorf("expected violation of %q, got %v", rule, v)
+		}
+	}
+	p.At(1).URI = "changed.ts"
+	check(RuleSegmentImmutable)
+	p.At(1).Duration = 3
+	check(RuleSegmentImmutable)
+	p.TargetDuration = 6
+	check(RuleTargetDurationStable)
+	p.SeqNo--
+	check(RuleSequenceMonotonic)
+	p.SeqNo++
+	c.Check(p)
+	p.Slide("s6.ts", 4, "")
+	p.DiscontinuitySeq++
+	check(RuleDiscontinuitySeqAdvance)
+
+	// segments removed unseen allow the discontinuity sequence to
+	// grow by the number of removed segments at most
+	for i := 7; i < 12; i++ {
+		p.Slide(fmt.Sprintf("s%d.ts", i), 4, "")
+	}
+	p.DiscontinuitySeq += 5
+	if v := c.Check(p); len(v) != 0 {
+		t.Errorf("unexpected violations %v", v)
+	}
+	for i := 12; i < 17; i++ {
+		p.Slide(fmt.Sprintf("s%d.ts", i), 4, "")
+	}
+	p.DiscontinuitySeq += 6
+	check(RuleDiscontinuitySeqAdvance)
+}
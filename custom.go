@@ -0,0 +1,170 @@
+package m3u8
+
+/*
+ Part of M3U8 parser & generator library.
+ This file defines lists of custom tags and their placement.
+
+ Copyright 2013-2019 The Project Developers.
+ See the AUTHORS and LICENSE files at the top-level directory of this distribution
+ and at https://github.com/grafov/m3u8/
+
+ ॐ तारे तुत्तारे तुरे स्व
+*/
+
+import "bytes"
+
+// CustomTags is the list of custom tags of a playlist or a segment
+// written in order of the list. A tag name may appear several times.
+type CustomTags []CustomTag
+
+// Get returns the first tag with the name or nil.
+func (tags CustomTags) Get(name string) CustomTag {
+	for _, tag := range tags {
+		if tag.TagName() == name {
+			return tag
+		}
+	}
+	return nil
+}
+
+// GetAll returns the tags with the name in order of the list.
+func (tags CustomTags) GetAll(name string) []CustomTag {
+	var list []CustomTag
+	for _, tag := range tags {
+		if tag.TagName() == name {
+			list = append(list, tag)
+		}
+	}
+	return list
+}
+
+// Add appends the tag to the list.
+func (tags *CustomTags) Add(tag CustomTag) {
+	*tags = append(*tags, tag)
+}
+
+// Set replaces the tags with the name of the tag by the tag placed at
+// the position of the first of them or appends the tag to the list.
+func (tags *CustomTags) Set(tag CustomTag) {
+	name := tag.TagName()
+	set := false
+	list := (*tags)[:0]
+	for _, t := range *tags {
+		if t.TagName() != name {
+			list = append(list, t)
+		} else if !set {
+			list = append(list, tag)
+			set = true
+		}
+	}
+	for i := len(list); i < len(*tags); i++ {
+		(*tags)[i] = nil
+	}
+	if !set {
+		list = append(list, tag)
+	}
+	*tags = list
+}
+
+// Remove removes the tags with the name from the list and returns the
+// number of removed tags.
+func (tags *CustomTags) Remove(name string) int {
+	list := (*tags)[:0]
+	for _, t := range *tags {
+		if t.TagName() != name {
+			list = append(list, t)
+		}
+	}
+	removed := len(*tags) - len(list)
+	for i := len(list); i < len(*tags); i++ {
+		(*tags)[i] = nil
+	}
+	*tags = list
+	return removed
+}
+
+// CustomTagPlacement is the position of a custom tag in the encoded
+// playlist, see PlacedTag.
+type CustomTagPlacement int
+
+const (
+	// CustomTagDefault places playlist tags after EXT-X-VERSION,
+	// EXT-X-INDEPENDENT-SEGMENTS and EXT-X-DEFINE (and EXT-X-START
+	// and EXT-X-CONTENT-STEERING of master playlists) and segment
+	// tags before EXTINF of the segment.
+	CustomTagDefault CustomTagPlacement = iota
+	// CustomTagBeforeHeader places playlist tags right after EXTM3U
+	// and segment tags before all the other tags of the segment.
+	CustomTagBeforeHeader
+	// CustomTagBeforeSegments places playlist tags after all the
+	// tags of the header before the first segment (the first
+	// EXT-X-MEDIA or variant of master playlists) and segment tags
+	// as CustomTagDefault.
+	CustomTagBeforeSegments
+	// CustomTagAfterSegments places playlist tags after the last
+	// segment (variant) before EXT-X-ENDLIST and segment tags after
+	// URI of the segment.
+	CustomTagAfterSegments
+)
+
+// PlacedTag is the custom tag written at the placement.
+type PlacedTag struct {
+	Tag       CustomTag
+	Placement CustomTagPlacement
+}
+
+// PlaceCustomTag returns the tag written at the placement, e.g. for
+// MediaPlaylist.AddCustomTag.
+func PlaceCustomTag(tag CustomTag, placement CustomTagPlacement) *PlacedTag {
+	return &PlacedTag{Tag: tag, Placement: placement}
+}
+
+// TagName implements CustomTag.
+func (t *PlacedTag) TagName() string {
+	return t.Tag.TagName()
+}
+
+// Encode implements CustomTag.
+func (t *PlacedTag) Encode() *bytes.Buffer {
+	return t.Tag.Encode()
+}
+
+// String implements CustomTag.
+func (t *PlacedTag) String() string {
+	return t.Tag.String()
+}
+
+// Clone returns a copy of the placed tag copying the tag with its
+// Clone() CustomTag method if it has one.
+func (t *PlacedTag) Clone() CustomTag {
+	tag := t.Tag
+	if cl, ok := tag.(interface{ Clone() CustomTag }); ok {
+		tag = cl.Clone()
+	}
+	return &PlacedTag{Tag: tag, Placement: t.Placement}
+}
+
+// placement returns the placement of the custom tag.
+func placement(tag CustomTag) CustomTagPlacement {
+	if t, ok := tag.(*PlacedTag); ok {
+		return t.Placement
+	}
+	return CustomTagDefault
+}
+
+// writeCustomTags writes the custom tags with one of the placements.
+func writeCustomTags(buf *bytes.Buffer, tags CustomTags, placements ...CustomTagPlacement) {
+	for _, tag := range tags {
+		at := placement(tag)
+		for _, pl := range placements {
+			if at != pl {
+				continue
+			}
+			if customBuf := tag.Encode(); customBuf != nil {
+				buf.WriteString(customBuf.String())
+				buf.WriteRune('\n')
+			}
+			break
+		}
+	}
+}
@@ -0,0 +1,112 @@
+/*
+Custom tags tests.
+
+Copyright 2013-2019 The Project Developers.
+See the AUTHORS and LICENSE files at the top-level directory of this distribution
+and at https://github.com/grafov/m3u8/
+
+ॐ तारे तुत्तारे तुरे स्व
+*/
+package m3u8
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func mockTag(name, value string) *MockCustomTag {
+	return &MockCustomTag{name: name, encodedString: name + ":" + value}
+}
+
+func TestCustomTags(t *testing.T) {
+	var tags CustomTags
+	tags.Add(mockTag("#X-A", "1"))
+	tags.Add(mockTag("#X-B", "1"))
+	tags.Add(mockTag("#X-A", "2"))
+	if len(tags.GetAll("#X-A")) != 2 || tags.Get("#X-A").String() != "#X-A:1" || tags.Get("#X-C") != nil {
+		t.Fatalf("unexpected tags %v", tags)
+	}
+	tags.Set(mockTag("#X-A", "3"))
+	if len(tags) != 2 || tags[0].String() != "#X-A:3" || tags[1].String() != "#X-B:1" {
+		t.Errorf("unexpected tags after Set %v", tags)
+	}
+	tags.Set(mockTag("#X-C", "1"))
+	if n := tags.Remove("#X-B"); n != 1 || len(tags) != 2 || tags[1].String() != "#X-C:1" {
+		t.Errorf("unexpected tags after Remove %v", tags)
+	}
+}
+
+func TestEncodeCustomTagsPlacement(t *testing.T) {
+	p, err := NewMediaPlaylist(0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.AddCustomTag(mockTag("#X-DEFAULT", "1"))
+	p.AddCustomTag(mockTag("#X-DEFAULT", "2"))
+	p.AddCustomTag(PlaceCustomTag(mockTag("#X-FIRST", "1"), CustomTagBeforeHeader))
+	p.AddCustomTag(PlaceCustomTag(mockTag("#X-HEAD-END", "1"), CustomTagBeforeSegments))
+	p.AddCustomTag(PlaceCustomTag(mockTag("#X-LAST", "1"), CustomTagAfterSegments))
+	p.Append("a.ts", 4, "")
+	p.SetDiscontinuity()
+	p.AddCustomSegmentTag(PlaceCustomTag(mockTag("#X-SEG-FIRST", "1"), CustomTagBeforeHeader))
+	p.AddCustomSegmentTag(mockTag("#X-SEG", "1"))
+	p.AddCustomSegmentTag(mockTag("#X-SEG", "2"))
+	p.AddCustomSegmentTag(PlaceCustomTag(mockTag("#X-SEG-AFTER", "1"), CustomTagAfterSegments))
+	p.Close()
+	expected := `#EXTM3U
+#X-FIRST:1
+#EXT-X-VERSION:3
+#X-DEFAULT:1
+#X-DEFAULT:2
+#EXT-X-MEDIA-SEQUENCE:0
+#EXT-X-TARGETDURATION:4
+#X-HEAD-END:1
+#X-SEG-FIRST:1
+#EXT-X-DISCONTINUITY
+#X-SEG:1
+#X-SEG:2
+#EXTINF:4.000,
+a.ts
+#X-SEG-AFTER:1
+#X-LAST:1
+#EXT-X-ENDLIST
+`
+	if s := p.String(); s != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, s)
+	}
+	if c := p.Clone(); c.String() != expected {
+		t.Errorf("clone is encoded differently:\n%s", c)
+	}
+
+	m := NewMasterPlaylist()
+	m.Append("a.m3u8", nil, VariantParams{Bandwidth: 1000})
+	m.AddCustomTag(PlaceCustomTag(mockTag("#X-LAST", "1"), CustomTagAfterSegments))
+	m.AddCustomTag(PlaceCustomTag(mockTag("#X-FIRST", "1"), CustomTagBeforeHeader))
+	if s := m.String(); !strings.HasPrefix(s, "#EXTM3U\n#X-FIRST:1\n") || !strings.HasSuffix(s, "a.m3u8\n#X-LAST:1\n") {
+		t.Errorf("unexpected master playlist:\n%s", s)
+	}
+}
+
+func TestDecodeRepeatedCustomTags(t *testing.T) {
+	const playlist = `#EXTM3U
+#X-PLAYLIST:1
+#X-PLAYLIST:2
+#EXT-X-TARGETDURATION:4
+#X-SEG:1
+#X-SEG:2
+#EXTINF:4,
+a.ts
+`
+	p, _, err := DecodeWith(bytes.NewBufferString(playlist), true, []CustomDecoder{
+		&MockCustomTag{name: "#X-PLAYLIST"},
+		&MockCustomTag{name: "#X-SEG", segment: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pp := p.(*MediaPlaylist)
+	if len(pp.Custom) != 2 || len(pp.Segments[0].Custom) != 2 {
+		t.Errorf("expected repeated tags, got %d playlist tags and %d segment tags", len(pp.Custom), len(pp.Segments[0].Custom))
+	}
+}
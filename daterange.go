@@ -0,0 +1,298 @@
+package m3u8
+
+/*
+ Part of M3U8 parser & generator library.
+ This file defines functions related to EXT-X-DATERANGE tags.
+
+ Copyright 2013-2019 The Project Developers.
+ See the AUTHORS and LICENSE files at the top-level directory of this distribution
+ and at https://github.com/grafov/m3u8/
+
+ ॐ तारे तुत्तारे तुरे स्व
+*/
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Values of X-TIMELINE-OCCUPIES and X-TIMELINE-STYLE attributes of
+// interstitial dateranges.
+const (
+	TimelineOccupiesPoint  = "POINT"
+	TimelineOccupiesRange  = "RANGE"
+	TimelineStyleHighlight = "HIGHLIGHT"
+	TimelineStylePrimary   = "PRIMARY"
+)
+
+// TimelineOccupies returns X-TIMELINE-OCCUPIES of the interstitial or
+// its default value POINT when the attribute is absent.
+func (dr *DateRange) TimelineOccupies() string {
+	if dr.XTimelineOccupies == "" {
+		return TimelineOccupiesPoint
+	}
+	return dr.XTimelineOccupies
+}
+
+// TimelineStyle returns X-TIMELINE-STYLE of the interstitial or its
+// default value HIGHLIGHT when the attribute is absent.
+func (dr *DateRange) TimelineStyle() string {
+	if dr.XTimelineStyle == "" {
+		return TimelineStyleHighlight
+	}
+	return dr.XTimelineStyle
+}
+
+// ContentMayVary reports X-CONTENT-MAY-VARY of the interstitial. The
+// attribute is YES by default.
+func (dr *DateRange) ContentMayVary() bool {
+	return dr.XContentMayVary != "NO"
+}
+
+// SetContentMayVary sets X-CONTENT-MAY-VARY of the interstitial.
+func (dr *DateRange) SetContentMayVary(yes bool) {
+	if yes {
+		dr.XContentMayVary = "YES"
+	} else {
+		dr.XContentMayVary = "NO"
+	}
+}
+
+// Values of X-SNAP and X-RESTRICT attributes of interstitial
+// dateranges.
+const (
+	SnapIn       = "IN"
+	SnapOut      = "OUT"
+	RestrictSkip = "SKIP"
+	RestrictJump = "JUMP"
+)
+
+// Values of CUE attribute of dateranges.
+const (
+	CuePre  = "PRE"  // trigger before playback of the primary asset
+	CuePost = "POST" // trigger after playback of the primary asset
+	CueOnce = "ONCE" // trigger no more than once
+)
+
+// CueList returns values of CUE attribute.
+func (dr *DateRange) CueList() []string {
+	return splitEnumList(dr.Cue)
+}
+
+// SetCue sets CUE attribute to the list of PRE, POST and ONCE values.
+// PRE and POST are mutually exclusive.
+func (dr *DateRange) SetCue(values ...string) error {
+	if err := checkCue(values); err != nil {
+		return err
+	}
+	dr.Cue = strings.Join(values, ",")
+	return nil
+}
+
+// checkCue verifies values of CUE attribute.
+func checkCue(values []string) error {
+	if err := checkEnumList("CUE", values, CuePre, CuePost, CueOnce); err != nil {
+		return err
+	}
+	var pre, post bool
+	for _, v := range values {
+		pre = pre || v == CuePre
+		post = post || v == CuePost
+	}
+	if pre && post {
+		return errors.New("CUE must not contain both PRE and POST")
+	}
+	return nil
+}
+
+// SnapList returns values of X-SNAP attribute.
+func (dr *DateRange) SnapList() []string {
+	return splitEnumList(dr.XSnap)
+}
+
+// SetXSnap sets X-SNAP attribute to the list of IN and OUT values.
+func (dr *DateRange) SetXSnap(values ...string) error {
+	if err := checkEnumList("X-SNAP", values, SnapIn, SnapOut); err != nil {
+		return err
+	}
+	dr.XSnap = strings.Join(values, ",")
+	return nil
+}
+
+// RestrictList returns values of X-RESTRICT attribute.
+func (dr *DateRange) RestrictList() []string {
+	return splitEnumList(dr.XRestrict)
+}
+
+// SetXRestrict sets X-RESTRICT attribute to the list of SKIP and JUMP
+// values.
+func (dr *DateRange) SetXRestrict(values ...string) error {
+	if err := checkEnumList("X-RESTRICT", values, RestrictSkip, RestrictJump); err != nil {
+		return err
+	}
+	dr.XRestrict = strings.Join(values, ",")
+	return nil
+}
+
+func splitEnumList(v string) []string {
+	if v == "" {
+		return nil
+	}
+	list := strings.Split(v, ",")
+	for i := range list {
+		list[i] = strings.TrimSpace(list[i])
+	}
+	return list
+}
+
+// checkEnumList verifies that the list consists of unique allowed
+// values.
+func checkEnumList(attr string, values []string, allowed ...string) error {
+	seen := make(map[string]bool)
+	for _, v := range values {
+		valid := false
+		for _, a := range allowed {
+			if v == a {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return fmt.Errorf("invalid %s value %q", attr, v)
+		}
+		if seen[v] {
+			return fmt.Errorf("duplicate %s value %q", attr, v)
+		}
+		seen[v] = true
+	}
+	return nil
+}
+
+// SCTE returns the SCTE-35 cue carried by the daterange in
+// SCTE35-OUT, SCTE35-IN or SCTE35-CMD attribute or nil if the
+// daterange has no cue. OUT is reported as a start cue, IN as an end
+// cue and CMD as a start cue. Time of the cue is the duration of the
+// daterange or its planned duration.
+func (dr *DateRange) SCTE() *SCTE {
+	scte := &SCTE{Syntax: SCTE35_DATERANGE, ID: dr.ID, Time: dr.Duration}
+	if scte.Time == 0 {
+		scte.Time = dr.PlannedDuration
+	}
+	switch {
+	case dr.SCTE35Out != "":
+		scte.Cue, scte.CueType = dr.SCTE35Out, SCTE35Cue_Start
+	case dr.SCTE35In != "":
+		scte.Cue, scte.CueType = dr.SCTE35In, SCTE35Cue_End
+	case dr.SCTE35Cmd != "":
+		scte.Cue, scte.CueType = dr.SCTE35Cmd, SCTE35Cue_Start
+	default:
+		return nil
+	}
+	return scte
+}
+
+// XAttribute returns the client-defined attribute with the name.
+func (dr *DateRange) XAttribute(name string) (Attribute, bool) {
+	return dr.X.Get(name)
+}
+
+// RemoveX removes the client-defined attribute with the name.
+func (dr *DateRange) RemoveX(name string) {
+	for i, a := range dr.X {
+		if a.Name == name {
+			dr.X = append(dr.X[:i:i], dr.X[i+1:]...)
+			return
+		}
+	}
+}
+
+// XString returns the value of the client-defined attribute of the
+// quoted-string type.
+func (dr *DateRange) XString(name string) (string, bool) {
+	a, ok := dr.XAttribute(name)
+	if !ok || a.Type != AttrString {
+		return "", false
+	}
+	return a.Value, true
+}
+
+// SetXString sets the client-defined attribute to the string. It is
+// written quoted whatever the string looks like.
+func (dr *DateRange) SetXString(name, value string) {
+	dr.X.Set(name, AttrString, value)
+}
+
+// XFloat returns the value of the client-defined attribute as a
+// decimal floating-point number.
+func (dr *DateRange) XFloat(name string) (float64, bool) {
+	a, ok := dr.XAttribute(name)
+	if !ok || a.Type != AttrFloat && a.Type != AttrInteger {
+		return 0, false
+	}
+	f, err := strconv.ParseFloat(a.Value, 64)
+	return f, err == nil
+}
+
+// SetXFloat sets the client-defined attribute to the decimal
+// floating-point number. It is written unquoted.
+func (dr *DateRange) SetXFloat(name string, value float64) {
+	dr.X.Set(name, AttrFloat, strconv.FormatFloat(value, 'f', -1, 64))
+}
+
+// XHex returns the value of the client-defined attribute as bytes of
+// the hexadecimal sequence.
+func (dr *DateRange) XHex(name string) ([]byte, bool) {
+	a, ok := dr.XAttribute(name)
+	if !ok || a.Type != AttrHex {
+		return nil, false
+	}
+	digits := a.Value[2:]
+	if len(digits)%2 == 1 {
+		digits = "0" + digits
+	}
+	b, err := hex.DecodeString(digits)
+	return b, err == nil
+}
+
+// SetXHex sets the client-defined attribute to the hexadecimal
+// sequence of the bytes. It is written unquoted.
+func (dr *DateRange) SetXHex(name string, value []byte) {
+	dr.X.Set(name, AttrHex, "0x"+strings.ToUpper(hex.EncodeToString(value)))
+}
+
+// isHexSequence reports whether the value is the hexadecimal-sequence
+// of attribute lists (section 4.2).
+func isHexSequence(v string) bool {
+	if len(v) < 3 || v[0] != '0' || (v[1] != 'x' && v[1] != 'X') {
+		return false
+	}
+	for _, r := range v[2:] {
+		if !(r >= '0' && r <= '9' || r >= 'a' && r <= 'f' || r >= 'A' && r <= 'F') {
+			return false
+		}
+	}
+	return true
+}
+
+// isDecimalFloat reports whether the value is the
+// decimal-floating-point of attribute lists (section 4.2).
+func isDecimalFloat(v string) bool {
+	if v == "" {
+		return false
+	}
+	dot := false
+	for i, r := range v {
+		switch {
+		case r >= '0' && r <= '9':
+		case r == '.' && !dot && i > 0:
+			dot = true
+		case r == '-' && i == 0 && len(v) > 1:
+		default:
+			return false
+		}
+	}
+	return v[len(v)-1] != '.'
+}
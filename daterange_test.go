@@ -0,0 +1,252 @@
+/*
+Daterange tests.
+
+Copyright 2013-2019 The Project Developers.
+See the AUTHORS and LICENSE files at the top-level directory of this distribution
+and at https://github.com/grafov/m3u8/
+
+ॐ तारे तुत्तारे तुरे स्व
+*/
+package m3u8
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDateRangeTypedClientAttributes(t *testing.T) {
+	playlist := `#EXTM3U
+#EXT-X-TARGETDURATION:10
+#EXT-X-DATERANGE:ID="ad",START-DATE="2019-01-01T00:00:00Z",X-COUNT=42,X-RATIO=-0.5,X-SIG=0xA1B2,X-NAME="0x12 text"
+#EXTINF:10.000,
+seg0.ts
+`
+	p, _, err := DecodeFrom(strings.NewReader(playlist), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dr := p.(*MediaPlaylist).Segments[0].DateRange[0]
+	if v, ok := dr.XFloat("X-COUNT"); !ok || v != 42 {
+		t.Errorf("X-COUNT = %v, %v", v, ok)
+	}
+	if v, ok := dr.XFloat("X-RATIO"); !ok || v != -0.5 {
+		t.Errorf("X-RATIO = %v, %v", v, ok)
+	}
+	if v, ok := dr.XHex("X-SIG"); !ok || !bytes.Equal(v, []byte{0xa1, 0xb2}) {
+		t.Errorf("X-SIG = %x, %v", v, ok)
+	}
+	if _, ok := dr.XHex("X-NAME"); ok {
+		t.Error("X-NAME must not be a hexadecimal sequence")
+	}
+	if _, ok := dr.XFloat("X-MISSING"); ok {
+		t.Error("X-MISSING must not be found")
+	}
+
+	out := p.String()
+	for _, attr := range []string{",X-COUNT=42", ",X-RATIO=-0.5", ",X-SIG=0xA1B2", `,X-NAME="0x12 text"`} {
+		if !strings.Contains(out, attr) {
+			t.Errorf("Encoded playlist lacks %s:\n%s", attr, out)
+		}
+	}
+
+	dr = &DateRange{ID: "new"}
+	dr.SetXHex("X-DATA", []byte{1, 255})
+	dr.SetXFloat("X-LEVEL", 1.25)
+	dr.SetXString("X-CODE", "1.5")
+	dr.SetXFloat("X-LEVEL", 1.5)
+	expected := AttributeList{{"X-DATA", AttrHex, "0x01FF"}, {"X-LEVEL", AttrFloat, "1.5"}, {"X-CODE", AttrString, "1.5"}}
+	if !reflect.DeepEqual(dr.X, expected) {
+		t.Errorf("Unexpected client attributes: %v", dr.X)
+	}
+	var buf bytes.Buffer
+	writeDateRange(&buf, dr, nil)
+	if buf.String() != "#EXT-X-DATERANGE:ID=\"new\",X-DATA=0x01FF,X-LEVEL=1.5,X-CODE=\"1.5\"\n" {
+		t.Errorf("Unexpected encoded daterange: %s", buf.String())
+	}
+	dr.RemoveX("X-LEVEL")
+	if _, ok := dr.XFloat("X-LEVEL"); ok || len(dr.X) != 2 {
+		t.Errorf("X-LEVEL is not removed: %v", dr.X)
+	}
+}
+
+func TestDateRangeEnumClientAttribute(t *testing.T) {
+	playlist := `#EXTM3U
+#EXT-X-TARGETDURATION:10
+#EXT-X-DATERANGE:ID="ad",START-DATE="2019-01-01T00:00:00Z",X-MODE=LIVE,X-SIZE=640x360
+#EXTINF:10.000,
+seg0.ts
+`
+	if _, _, err := DecodeFrom(strings.NewReader(playlist), true); err == nil {
+		t.Error("expected error for enumerated client attribute in strict mode")
+	}
+	p, _, err := DecodeFrom(strings.NewReader(playlist), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dr := p.(*MediaPlaylist).Segments[0].DateRange[0]
+	expected := AttributeList{{"X-MODE", AttrEnum, "LIVE"}, {"X-SIZE", AttrResolution, "640x360"}}
+	if !reflect.DeepEqual(dr.X, expected) {
+		t.Errorf("Unexpected client attributes: %v", dr.X)
+	}
+	if _, ok := dr.XFloat("X-MODE"); ok {
+		t.Error("X-MODE must not be a decimal floating point")
+	}
+}
+
+func TestDateRangeInterstitialTimelineAttributes(t *testing.T) {
+	playlist := `#EXTM3U
+#EXT-X-TARGETDURATION:10
+#EXT-X-DATERANGE:ID="ad",CLASS="com.apple.hls.interstitial",START-DATE="2019-01-01T00:00:00Z",X-RESUME-OFFSET=0.5,X-PLAYOUT-LIMIT=30,X-TIMELINE-OCCUPIES="RANGE",X-TIMELINE-STYLE="PRIMARY",X-CONTENT-MAY-VARY="NO"
+#EXTINF:10.000,
+seg0.ts
+`
+	p, _, err := DecodeFrom(strings.NewReader(playlist), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dr := p.(*MediaPlaylist).Segments[0].DateRange[0]
+	if dr.TimelineOccupies() != TimelineOccupiesRange || dr.TimelineStyle() != TimelineStylePrimary || dr.ContentMayVary() {
+		t.Errorf("Unexpected interstitial attributes: %+v", dr)
+	}
+	if !strings.Contains(p.String(), playlist[strings.Index(playlist, "#EXT-X-DATERANGE"):strings.Index(playlist, "#EXTINF")]) {
+		t.Errorf("Interstitial attributes not preserved:\n%s", p.String())
+	}
+
+	dr = &DateRange{ID: "defaults"}
+	if dr.TimelineOccupies() != TimelineOccupiesPoint || dr.TimelineStyle() != TimelineStyleHighlight || !dr.ContentMayVary() {
+		t.Errorf("Unexpected defaults: %s %s %v", dr.TimelineOccupies(), dr.TimelineStyle(), dr.ContentMayVary())
+	}
+	dr.SetContentMayVary(false)
+	if dr.XContentMayVary != "NO" {
+		t.Errorf("X-CONTENT-MAY-VARY = %s", dr.XContentMayVary)
+	}
+}
+
+func TestDateRangeSnapAndRestrict(t *testing.T) {
+	dr := new(DateRange)
+	if err := dr.SetXSnap(SnapOut, SnapIn); err != nil {
+		t.Fatal(err)
+	}
+	if err := dr.SetXRestrict(RestrictSkip, RestrictJump); err != nil {
+		t.Fatal(err)
+	}
+	if dr.XSnap != "OUT,IN" || dr.XRestrict != "SKIP,JUMP" {
+		t.Errorf("Unexpected values: %s %s", dr.XSnap, dr.XRestrict)
+	}
+	if list := dr.RestrictList(); len(list) != 2 || list[1] != RestrictJump {
+		t.Errorf("Unexpected restrict list: %v", list)
+	}
+	if err := dr.SetXSnap("OTU"); err == nil {
+		t.Error("Expected error for invalid X-SNAP value")
+	}
+	if err := dr.SetXRestrict(RestrictSkip, RestrictSkip); err == nil {
+		t.Error("Expected error for duplicate X-RESTRICT value")
+	}
+	if dr.XSnap != "OUT,IN" || dr.XRestrict != "SKIP,JUMP" {
+		t.Errorf("Invalid values must not be set: %s %s", dr.XSnap, dr.XRestrict)
+	}
+
+	playlist := `#EXTM3U
+#EXT-X-TARGETDURATION:10
+#EXT-X-DATERANGE:ID="ad",START-DATE="2019-01-01T00:00:00Z",X-RESTRICT="SKIP,JMUP"
+#EXTINF:10.000,
+seg0.ts
+`
+	if _, _, err := DecodeFrom(strings.NewReader(playlist), true); err == nil {
+		t.Error("Expected error for invalid X-RESTRICT in strict mode")
+	}
+	if _, _, err := DecodeFrom(strings.NewReader(playlist), false); err != nil {
+		t.Errorf("Unexpected error in non-strict mode: %s", err)
+	}
+}
+
+func TestDateRangeCue(t *testing.T) {
+	dr := &DateRange{ID: "pre", StartDate: time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)}
+	if err := dr.SetCue(CuePre, CueOnce); err != nil {
+		t.Fatal(err)
+	}
+	if err := dr.SetCue(CuePre, CuePost); err == nil {
+		t.Error("Expected error for CUE with PRE and POST")
+	}
+	if err := dr.SetCue("MID"); err == nil {
+		t.Error("Expected error for invalid CUE value")
+	}
+	if list := dr.CueList(); len(list) != 2 || list[0] != CuePre || list[1] != CueOnce {
+		t.Errorf("Unexpected cue list: %v", list)
+	}
+
+	p, _ := NewMediaPlaylist(1, 1)
+	p.Append("seg0.ts", 10, "")
+	p.SetDateRange([]*DateRange{dr})
+	out := p.String()
+	if !strings.Contains(out, `#EXT-X-DATERANGE:ID="pre",START-DATE="2019-01-01T00:00:00Z",CUE="PRE,ONCE"`) {
+		t.Fatalf("Unexpected playlist:\n%s", out)
+	}
+	decoded, _, err := DecodeFrom(strings.NewReader(out), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cue := decoded.(*MediaPlaylist).At(0).DateRange[0].Cue; cue != "PRE,ONCE" {
+		t.Errorf("Unexpected decoded CUE %q", cue)
+	}
+	invalid := strings.Replace(out, "PRE,ONCE", "PRE,POST", 1)
+	if _, _, err = DecodeFrom(strings.NewReader(invalid), true); err == nil {
+		t.Error("Expected error for CUE with PRE and POST in strict mode")
+	}
+}
+
+func TestDecodeLinkSCTE35DateRanges(t *testing.T) {
+	src := `#EXTM3U
+#EXT-X-VERSION:3
+#EXT-X-TARGETDURATION:10
+#EXT-X-MEDIA-SEQUENCE:0
+#EXT-X-DATERANGE:ID="ad1",START-DATE="2020-01-01T00:00:00Z",PLANNED-DURATION=30,SCTE35-OUT=0xFC002F
+#EXTINF:10,
+a.ts
+#EXT-X-DATERANGE:ID="ad1",START-DATE="2020-01-01T00:00:00Z",DURATION=30.5,SCTE35-IN=0xFC0030
+#EXTINF:10,
+b.ts
+#EXTINF:10,
+c.ts
+`
+	for _, link := range []bool{false, true} {
+		p, _, err := DecodeWithOptions(strings.NewReader(src), DecodeOptions{LinkSCTE35DateRanges: link})
+		if err != nil {
+			t.Fatal(err)
+		}
+		pp := p.(*MediaPlaylist)
+		if !link {
+			if pp.Segments[0].SCTE != nil {
+				t.Errorf("SCTE is linked without option: %+v", pp.Segments[0].SCTE)
+			}
+			continue
+		}
+		out := pp.Segments[0].SCTE
+		if out == nil || out.Syntax != SCTE35_DATERANGE || out.CueType != SCTE35Cue_Start ||
+			out.Cue != "0xFC002F" || out.ID != "ad1" || out.Time != 30 {
+			t.Errorf("unexpected out cue: %+v", out)
+		}
+		in := pp.Segments[1].SCTE
+		if in == nil || in.CueType != SCTE35Cue_End || in.Cue != "0xFC0030" || in.Time != 30.5 {
+			t.Errorf("unexpected in cue: %+v", in)
+		}
+		if pp.Segments[2].SCTE != nil {
+			t.Errorf("unexpected cue on segment without daterange: %+v", pp.Segments[2].SCTE)
+		}
+		if strings.Count(pp.String(), "SCTE35") != 2 {
+			t.Errorf("linked cues must not be written twice:\n%s", pp.String())
+		}
+	}
+}
+
+// A daterange before a URI line without EXTINF must not crash linking
+// of SCTE-35 cues (found by fuzzing).
+func TestDecodeLinkSCTE35DateRangeWithoutSegment(t *testing.T) {
+	src := "#EXTM3U\n#EXT-X-TARGETDURATION:10\n#EXT-X-DATERANGE:ID=\"a\",START-DATE=\"2020-01-01T00:00:00Z\",SCTE35-OUT=0xFC\nseg.ts\n"
+	if _, _, err := DecodeWithOptions(strings.NewReader(src), DecodeOptions{LinkSCTE35DateRanges: true}); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+}
@@ -0,0 +1,114 @@
+package m3u8
+
+/*
+ Part of M3U8 parser & generator library.
+ This file defines functions related to decryption of media segments.
+
+ Copyright 2013-2019 The Project Developers.
+ See the AUTHORS and LICENSE files at the top-level directory of this distribution
+ and at https://github.com/grafov/m3u8/
+
+ ॐ तारे तुत्तारे तुरे स्व
+*/
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// IVBytes returns the initialization vector for the segment with the
+// sequence number seqID. When the key has no IV attribute the media
+// sequence number is used as IV as described in section 5.2.
+func (k *Key) IVBytes(seqID uint64) ([]byte, error) {
+	if k.IV == "" {
+		iv := make([]byte, aes.BlockSize)
+		binary.BigEndian.PutUint64(iv[8:], seqID)
+		return iv, nil
+	}
+	value := k.IV
+	if strings.HasPrefix(value, "0x") || strings.HasPrefix(value, "0X") {
+		value = value[2:]
+	}
+	iv, err := hex.DecodeString(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid IV %s: %s", k.IV, err)
+	}
+	if len(iv) != aes.BlockSize {
+		return nil, fmt.Errorf("invalid IV %s: must be %d bytes", k.IV, aes.BlockSize)
+	}
+	return iv, nil
+}
+
+// DecryptAES128 decrypts data encrypted with AES-128 in CBC mode with
+// PKCS7 padding, the encryption of the AES-128 method of EXT-X-KEY.
+func DecryptAES128(data, key, iv []byte) ([]byte, error) {
+	if len(key) != 16 {
+		return nil, errors.New("AES-128 key must be 16 bytes")
+	}
+	if len(iv) != aes.BlockSize {
+		return nil, fmt.Errorf("IV must be %d bytes", aes.BlockSize)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 || len(data)%aes.BlockSize != 0 {
+		return nil, errors.New("encrypted data is not a multiple of the block size")
+	}
+	out := make([]byte, len(data))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(out, data)
+	padding := int(out[len(out)-1])
+	if padding == 0 || padding > aes.BlockSize || !bytes.Equal(out[len(out)-padding:], bytes.Repeat([]byte{byte(padding)}, padding)) {
+		return nil, errors.New("invalid PKCS7 padding")
+	}
+	return out[:len(out)-padding], nil
+}
+
+// DecryptSegment decrypts downloaded data of the media segment. The
+// key of the segment is the last EXT-X-KEY which appeared before the
+// segment in the playlist or the default key of the playlist. The
+// fetchKey callback receives the key URI and must return the key
+// bytes. Data of unencrypted segments returned unchanged. Only the
+// AES-128 method is supported.
+func (p *MediaPlaylist) DecryptSegment(seg *MediaSegment, data []byte, fetchKey func(uri string) ([]byte, error)) ([]byte, error) {
+	key := p.segmentKey(seg)
+	if key == nil || key.Method == "" || key.Method == "NONE" {
+		return data, nil
+	}
+	if key.Method != "AES-128" {
+		return nil, fmt.Errorf("unsupported encryption method %s", key.Method)
+	}
+	secret, err := fetchKey(key.URI)
+	if err != nil {
+		return nil, fmt.Errorf("fetch key %s: %s", key.URI, err)
+	}
+	iv, err := key.IVBytes(seg.SeqId)
+	if err != nil {
+		return nil, err
+	}
+	return DecryptAES128(data, secret, iv)
+}
+
+// segmentKey returns the key effective for the segment. The segment
+// not found in the playlist gets own key or the default playlist key.
+func (p *MediaPlaylist) segmentKey(seg *MediaSegment) *Key {
+	key := p.Key
+	for _, s := range p.segments() {
+		if s.Key != nil {
+			key = s.Key
+		}
+		if s == seg {
+			return key
+		}
+	}
+	if seg.Key != nil {
+		return seg.Key
+	}
+	return p.Key
+}
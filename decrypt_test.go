@@ -0,0 +1,93 @@
+/*
+Segment decryption tests.
+
+Copyright 2013-2019 The Project Developers.
+See the AUTHORS and LICENSE files at the top-level directory of this distribution
+and at https://github.com/grafov/m3u8/
+
+ॐ तारे तुत्तारे तुरे स्व
+*/
+package m3u8
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"errors"
+	"testing"
+)
+
+func encryptAES128(t *testing.T, data, key, iv []byte) []byte {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	padding := aes.BlockSize - len(data)%aes.BlockSize
+	plain := append(append([]byte{}, data...), bytes.Repeat([]byte{byte(padding)}, padding)...)
+	out := make([]byte, len(plain))
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(out, plain)
+	return out
+}
+
+func TestKeyIVBytes(t *testing.T) {
+	iv, err := (&Key{}).IVBytes(258)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(iv, []byte{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1, 2}) {
+		t.Errorf("Unexpected IV from sequence number: %x", iv)
+	}
+	iv, err = (&Key{IV: "0x000102030405060708090a0b0c0d0e0f"}).IVBytes(258)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if iv[15] != 0x0f {
+		t.Errorf("Unexpected explicit IV: %x", iv)
+	}
+	if _, err = (&Key{IV: "0x0102"}).IVBytes(0); err == nil {
+		t.Error("Expected error for short IV")
+	}
+}
+
+func TestDecryptSegment(t *testing.T) {
+	secret := []byte("0123456789abcdef")
+	p, _ := NewMediaPlaylist(3, 3)
+	_ = p.Append("plain.ts", 6, "")
+	_ = p.Append("enc1.ts", 6, "")
+	_ = p.SetKey("AES-128", "https://example.com/key", "", "", "")
+	_ = p.Append("enc2.ts", 6, "")
+
+	fetch := func(uri string) ([]byte, error) {
+		if uri != "https://example.com/key" {
+			return nil, errors.New("unknown key")
+		}
+		return secret, nil
+	}
+	data := []byte("media segment payload")
+
+	out, err := p.DecryptSegment(p.Segments[0], data, fetch)
+	if err != nil || !bytes.Equal(out, data) {
+		t.Errorf("Unencrypted segment must be returned as is: %q, %v", out, err)
+	}
+	for _, seg := range p.Segments[1:] {
+		iv, _ := (&Key{}).IVBytes(seg.SeqId)
+		out, err = p.DecryptSegment(seg, encryptAES128(t, data, secret, iv), fetch)
+		if err != nil {
+			t.Fatalf("Decrypt %s failed: %s", seg.URI, err)
+		}
+		if !bytes.Equal(out, data) {
+			t.Errorf("Decrypted %s = %q, want %q", seg.URI, out, data)
+		}
+	}
+}
+
+func TestDecryptAES128InvalidKey(t *testing.T) {
+	iv := make([]byte, aes.BlockSize)
+	data := encryptAES128(t, []byte("payload"), []byte("0123456789abcdef0123456789abcdef"), iv)
+	if _, err := DecryptAES128(data, []byte("0123456789abcdef0123456789abcdef"), iv); err == nil {
+		t.Error("Expected error for AES-256 key")
+	}
+	if _, err := DecryptAES128(data, []byte("0123456789abcdef"), iv[:8]); err == nil {
+		t.Error("Expected error for short IV")
+	}
+}
@@ -0,0 +1,312 @@
+package m3u8
+
+/*
+ Part of M3U8 parser & generator library.
+ This file defines variable substitution (EXT-X-DEFINE).
+
+ Copyright 2013-2019 The Project Developers.
+ See the AUTHORS and LICENSE files at the top-level directory of this distribution
+ and at https://github.com/grafov/m3u8/
+
+ ॐ तारे तुत्तारे तुरे स्व
+*/
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// DefineType is the kind of variable definition of EXT-X-DEFINE tag.
+type DefineType uint
+
+const (
+	// DefineValue defines the variable with NAME and VALUE attributes.
+	DefineValue DefineType = iota
+	// DefineImport imports the variable of the master playlist with
+	// IMPORT attribute (media playlists only).
+	DefineImport
+	// DefineQueryParam defines the variable from the query parameter
+	// of the playlist URI with QUERYPARAM attribute.
+	DefineQueryParam
+)
+
+// Define represents EXT-X-DEFINE tag. Name is the name of the variable
+// for all types of the definition, Value is used by DefineValue only.
+type Define struct {
+	Name  string
+	Type  DefineType
+	Value string
+}
+
+// ErrUndefinedVariable is returned by Expand for references to
+// variables which are not defined.
+var ErrUndefinedVariable = errors.New("undefined variable")
+
+// AppendDefine appends EXT-X-DEFINE tag to the master playlist. This
+// operation does reset playlist cache.
+func (p *MasterPlaylist) AppendDefine(d *Define) {
+	p.Defines = append(p.Defines, d)
+	p.buf.Reset()
+}
+
+// AppendDefine appends EXT-X-DEFINE tag to the media playlist. This
+// operation does reset playlist cache.
+func (p *MediaPlaylist) AppendDefine(d *Define) {
+	p.Defines = append(p.Defines, d)
+	p.buf.Reset()
+}
+
+// Variables returns values of the variables defined in the master
+// playlist. IMPORT definitions are not allowed in master playlists
+// and QUERYPARAM ones are not resolved by this function (see
+// ExpandQuery).
+func (p *MasterPlaylist) Variables() (map[string]string, error) {
+	return variables(p.Defines, nil, nil)
+}
+
+// Expand substitutes variable references ({$name}) in URIs of the
+// variants, renditions and session data of the master playlist with
+// values of the variables defined with EXT-X-DEFINE. It returns
+// ErrUndefinedVariable (wrapped with the variable name) for
+// references to variables which are not defined. This operation does
+// reset playlist cache.
+func (p *MasterPlaylist) Expand() error {
+	vars, err := p.Variables()
+	if err != nil {
+		return err
+	}
+	return p.expand(vars)
+}
+
+// ExpandQuery is the same as Expand but also resolves variables
+// defined with QUERYPARAM from the query of the URI the playlist was
+// requested with. It returns error if a query parameter is absent.
+func (p *MasterPlaylist) ExpandQuery(query url.Values) error {
+	vars, err := variables(p.Defines, nil, queryValues(query))
+	if err != nil {
+		return err
+	}
+	return p.expand(vars)
+}
+
+// expand substitutes the variables in the master playlist.
+func (p *MasterPlaylist) expand(vars map[string]string) error {
+	var err error
+	p.RewriteURIs(substituteFunc(vars, &err))
+	for _, sd := range p.SessionData {
+		if err == nil && sd != nil {
+			sd.Value, err = substitute(sd.Value, vars)
+		}
+	}
+	return err
+}
+
+// Variables returns values of the variables defined in the media
+// playlist. Variables imported with IMPORT definitions are looked up
+// in the parent master playlist, it returns error if the parent is
+// nil or it doesn't define an imported variable. QUERYPARAM
+// definitions are not resolved by this function (see ExpandQuery).
+func (p *MediaPlaylist) Variables(parent *MasterPlaylist) (map[string]string, error) {
+	return variables(p.Defines, parent, nil)
+}
+
+// Expand substitutes variable references ({$name}) in URIs of the
+// segments, keys and maps of the media playlist with values of the
+// variables defined with EXT-X-DEFINE. Imported variables are
+// resolved from definitions of the parent master playlist (see
+// Variables). It returns ErrUndefinedVariable (wrapped with the
+// variable name) for references to variables which are not defined.
+// This operation does reset playlist cache.
+func (p *MediaPlaylist) Expand(parent *MasterPlaylist) error {
+	vars, err := p.Variables(parent)
+	if err != nil {
+		return err
+	}
+	return p.expand(vars)
+}
+
+// ExpandQuery is the same as Expand but also resolves variables
+// defined with QUERYPARAM from the query of the URI the playlist was
+// requested with. It returns error if a query parameter is absent.
+func (p *MediaPlaylist) ExpandQuery(parent *MasterPlaylist, query url.Values) error {
+	vars, err := variables(p.Defines, parent, queryValues(query))
+	if err != nil {
+		return err
+	}
+	return p.expand(vars)
+}
+
+// expand substitutes the variables in the media playlist.
+func (p *MediaPlaylist) expand(vars map[string]string) error {
+	var err error
+	p.RewriteURIs(substituteFunc(vars, &err))
+	return err
+}
+
+// variables resolves the definitions. Imports are looked up in the
+// parent playlist and query parameters in the query values, nil query
+// means query parameters are not available.
+func variables(defines []*Define, parent *MasterPlaylist, query map[string][]string) (map[string]string, error) {
+	vars := make(map[string]string, len(defines))
+	var parentVars map[string]string
+	for _, d := range defines {
+		if d == nil {
+			continue
+		}
+		if _, ok := vars[d.Name]; ok {
+			return nil, fmt.Errorf("variable %q is defined twice", d.Name)
+		}
+		switch d.Type {
+		case DefineValue:
+			vars[d.Name] = d.Value
+		case DefineImport:
+			if parent == nil {
+				return nil, fmt.Errorf("variable %q is imported without master playlist", d.Name)
+			}
+			if parentVars == nil {
+				var err error
+				if parentVars, err = parent.Variables(); err != nil {
+					return nil, err
+				}
+			}
+			v, ok := parentVars[d.Name]
+			if !ok {
+				return nil, fmt.Errorf("imported variable %q is not defined in master playlist", d.Name)
+			}
+			vars[d.Name] = v
+		case DefineQueryParam:
+			if query == nil {
+				return nil, fmt.Errorf("variable %q is defined by query parameter", d.Name)
+			}
+			values, ok := query[d.Name]
+			if !ok || len(values) == 0 {
+				return nil, fmt.Errorf("query parameter %q is absent", d.Name)
+			}
+			vars[d.Name] = values[0]
+		}
+	}
+	return vars, nil
+}
+
+// queryValues returns non-nil query values, so variables reports
+// absent parameters for nil query.
+func queryValues(query url.Values) map[string][]string {
+	if query == nil {
+		return map[string][]string{}
+	}
+	return query
+}
+
+// substituteFunc returns the function substituting the variables in
+// strings, the first error is stored to err.
+func substituteFunc(vars map[string]string, err *error) func(string) string {
+	return func(s string) string {
+		if *err != nil {
+			return s
+		}
+		var out string
+		out, *err = substitute(s, vars)
+		return out
+	}
+}
+
+// substitute replaces variable references in the string with the
+// values of the variables.
+func substitute(s string, vars map[string]string) (string, error) {
+	if !strings.Contains(s, "{$") {
+		return s, nil
+	}
+	var out strings.Builder
+	for {
+		start := strings.Index(s, "{$")
+		if start < 0 {
+			break
+		}
+		end := strings.IndexByte(s[start:], '}')
+		if end < 0 {
+			break
+		}
+		name := s[start+2 : start+end]
+		if !validVariableName(name) {
+			out.WriteString(s[:start+2])
+			s = s[start+2:]
+			continue
+		}
+		value, ok := vars[name]
+		if !ok {
+			return "", fmt.Errorf("%s: %s", ErrUndefinedVariable, name)
+		}
+		out.WriteString(s[:start])
+		out.WriteString(value)
+		s = s[start+end+1:]
+	}
+	out.WriteString(s)
+	return out.String(), nil
+}
+
+// validVariableName reports whether the string is a valid variable
+// name consisting of [a-zA-Z0-9-_] characters.
+func validVariableName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, c := range name {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_') {
+			return false
+		}
+	}
+	return true
+}
+
+// decodeDefine parses attributes of EXT-X-DEFINE tag.
+func decodeDefine(line string) (*Define, error) {
+	d := new(Define)
+	params := decodeParamsLine(line)
+	switch {
+	case params["NAME"] != "":
+		d.Name = params["NAME"]
+		d.Value = params["VALUE"]
+	case params["IMPORT"] != "":
+		d.Type = DefineImport
+		d.Name = params["IMPORT"]
+	case params["QUERYPARAM"] != "":
+		d.Type = DefineQueryParam
+		d.Name = params["QUERYPARAM"]
+	default:
+		return nil, fmt.Errorf("EXT-X-DEFINE without NAME, IMPORT or QUERYPARAM: %s", line)
+	}
+	if !validVariableName(d.Name) {
+		return nil, fmt.Errorf("invalid variable name %q", d.Name)
+	}
+	return d, nil
+}
+
+// writeDefines writes EXT-X-DEFINE tags.
+func writeDefines(buf *bytes.Buffer, defines []*Define) {
+	for _, d := range defines {
+		if d == nil {
+			continue
+		}
+		buf.WriteString("#EXT-X-DEFINE:")
+		defineAttrs(d).writeTo(buf, nil)
+		buf.WriteRune('\n')
+	}
+}
+
+// defineAttrs returns attributes of EXT-X-DEFINE tag.
+func defineAttrs(d *Define) attrList {
+	var attrs attrList
+	switch d.Type {
+	case DefineImport:
+		attrs.quoted("IMPORT", d.Name)
+	case DefineQueryParam:
+		attrs.quoted("QUERYPARAM", d.Name)
+	default:
+		attrs.quoted("NAME", d.Name)
+		attrs.quoted("VALUE", d.Value)
+	}
+	return attrs
+}
@@ -0,0 +1,132 @@
+/*
+Variable substitution tests.
+
+Copyright 2013-2019 The Project Developers.
+See the AUTHORS and LICENSE files at the top-level directory of this distribution
+and at https://github.com/grafov/m3u8/
+
+ॐ तारे तुत्तारे तुरे स्व
+*/
+package m3u8
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestExpandImport(t *testing.T) {
+	masterSrc := `#EXTM3U
+#EXT-X-VERSION:8
+#EXT-X-DEFINE:NAME="cdn",VALUE="https://cdn.example.com"
+#EXT-X-STREAM-INF:BANDWIDTH=100
+{$cdn}/low.m3u8
+`
+	mediaSrc := `#EXTM3U
+#EXT-X-VERSION:8
+#EXT-X-DEFINE:IMPORT="cdn"
+#EXT-X-DEFINE:NAME="path",VALUE="video/1"
+#EXT-X-MEDIA-SEQUENCE:0
+#EXT-X-TARGETDURATION:10
+#EXTINF:10.000,
+{$cdn}/{$path}/a.ts
+#EXT-X-ENDLIST
+`
+	pl, _, err := DecodeFrom(strings.NewReader(masterSrc), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	master := pl.(*MasterPlaylist)
+	if len(master.Defines) != 1 || master.String() != masterSrc {
+		t.Errorf("EXT-X-DEFINE is not round-tripped:\n%s", master.String())
+	}
+	pl, _, err = DecodeFrom(strings.NewReader(mediaSrc), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	media := pl.(*MediaPlaylist)
+	if media.String() != mediaSrc {
+		t.Errorf("EXT-X-DEFINE is not round-tripped:\n%s", media.String())
+	}
+	if media.ComputeMinVersion() != 8 {
+		t.Errorf("expected version 8, got %d", media.ComputeMinVersion())
+	}
+
+	if err = media.Expand(nil); err == nil {
+		t.Error("expected error for import without master playlist")
+	}
+	if err = media.Expand(NewMasterPlaylist()); err == nil || !strings.Contains(err.Error(), "cdn") {
+		t.Errorf("expected missing import error, got %v", err)
+	}
+	if err = media.Expand(master); err != nil {
+		t.Fatal(err)
+	}
+	if uri := media.Segments[0].URI; uri != "https://cdn.example.com/video/1/a.ts" {
+		t.Errorf("unexpected segment URI %s", uri)
+	}
+	if err = master.Expand(); err != nil {
+		t.Fatal(err)
+	}
+	if uri := master.Variants[0].URI; uri != "https://cdn.example.com/low.m3u8" {
+		t.Errorf("unexpected variant URI %s", uri)
+	}
+}
+
+func TestSubstitute(t *testing.T) {
+	vars := map[string]string{"a": "1", "b-c": "2"}
+	tests := []struct {
+		in, out string
+		err     bool
+	}{
+		{"plain", "plain", false},
+		{"{$a}/{$b-c}.ts", "1/2.ts", false},
+		{"{$a", "{$a", false},
+		{"{$not valid}", "{$not valid}", false},
+		{"{$missing}", "", true},
+	}
+	for _, tt := range tests {
+		out, err := substitute(tt.in, vars)
+		if out != tt.out || (err != nil) != tt.err {
+			t.Errorf("%s: expected %q (error %v), got %q (%v)", tt.in, tt.out, tt.err, out, err)
+		}
+	}
+}
+
+func TestExpandQuery(t *testing.T) {
+	src := `#EXTM3U
+#EXT-X-DEFINE:QUERYPARAM="token"
+#EXT-X-TARGETDURATION:10
+#EXTINF:10,
+a.ts?token={$token}
+`
+	pl, _, err := DecodeFrom(strings.NewReader(src), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := pl.(*MediaPlaylist)
+	if d := p.Defines[0]; d.Type != DefineQueryParam || d.Name != "token" {
+		t.Errorf("unexpected definition: %+v", d)
+	}
+	if err = p.Expand(nil); err == nil {
+		t.Error("expected error for QUERYPARAM without query")
+	}
+	if err = p.ExpandQuery(nil, url.Values{"other": {"x"}}); err == nil || !strings.Contains(err.Error(), "token") {
+		t.Errorf("expected absent parameter error, got %v", err)
+	}
+	if err = p.ExpandQuery(nil, url.Values{"token": {"abc"}}); err != nil {
+		t.Fatal(err)
+	}
+	if uri := p.Segments[0].URI; uri != "a.ts?token=abc" {
+		t.Errorf("unexpected segment URI %s", uri)
+	}
+
+	m := NewMasterPlaylist()
+	m.AppendDefine(&Define{Name: "host", Type: DefineQueryParam})
+	m.Append("https://{$host}/low.m3u8", nil, VariantParams{Bandwidth: 1})
+	if err = m.ExpandQuery(url.Values{"host": {"cdn.example.com"}}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(m.String(), "#EXT-X-DEFINE:QUERYPARAM=\"host\"\n") || m.Variants[0].URI != "https://cdn.example.com/low.m3u8" {
+		t.Errorf("unexpected master playlist:\n%s", m.String())
+	}
+}
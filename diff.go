@@ -0,0 +1,206 @@
+package m3u8
+
+/*
+ Part of M3U8 parser & generator library.
+ This file defines comparison of master playlists.
+
+ Copyright 2013-2019 The Project Developers.
+ See the AUTHORS and LICENSE files at the top-level directory of this distribution
+ and at https://github.com/grafov/m3u8/
+
+ ॐ तारे तुत्तारे तुरे स्व
+*/
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DiffKind is the kind of the difference found by DiffMaster.
+type DiffKind uint
+
+const (
+	// use 0 for not defined kind
+	DiffAdded   DiffKind = iota + 1 // present only in the second playlist
+	DiffRemoved                     // present only in the first playlist
+	DiffChanged                     // attribute value differs
+)
+
+func (k DiffKind) String() string {
+	switch k {
+	case DiffAdded:
+		return "added"
+	case DiffRemoved:
+		return "removed"
+	case DiffChanged:
+		return "changed"
+	}
+	return "unknown"
+}
+
+// MasterChange describes a difference between variants or renditions
+// of two master playlists. Either Variant or Rendition is set: the
+// removed one from the first playlist, the added or changed one from
+// the second playlist. Attribute, Old and New (unquoted values, empty
+// for the missing attribute) are set for DiffChanged only.
+type MasterChange struct {
+	Kind      DiffKind
+	Variant   *Variant
+	Rendition *Alternative
+	Attribute string
+	Old       string
+	New       string
+}
+
+func (c MasterChange) String() string {
+	var subject string
+	if c.Variant != nil {
+		subject = fmt.Sprintf("variant %q", c.Variant.URI)
+	} else if c.Rendition != nil {
+		subject = fmt.Sprintf("rendition %s %q of group %q", c.Rendition.Type, c.Rendition.Name, c.Rendition.GroupId)
+	}
+	if c.Kind != DiffChanged {
+		return subject + " " + c.Kind.String()
+	}
+	return fmt.Sprintf("%s: %s changed from %q to %q", subject, c.Attribute, c.Old, c.New)
+}
+
+// DiffMaster compares the master playlists and returns added, removed
+// and changed variants and renditions, so changes of bitrate ladders
+// may be monitored (e.g. between versions of a packager). Variants are
+// matched by URI (I-frame and regular variants separately),
+// renditions by TYPE, GROUP-ID, NAME and LANGUAGE. Changes of every
+// attribute of matched variants and renditions are reported, so
+// rewiring of a variant to other rendition group is reported as the
+// change of its AUDIO, VIDEO, SUBTITLES or CLOSED-CAPTIONS attribute.
+// Removed variants and renditions are reported first in order of the
+// first playlist, then added and changed ones in order of the second
+// playlist.
+func DiffMaster(a, b *MasterPlaylist) []MasterChange {
+	var changes []MasterChange
+
+	// variants
+	variantKey := func(v *Variant) string {
+		return fmt.Sprintf("%t %t %s", v.Iframe, v.Images, v.URI)
+	}
+	matched := make(map[*Variant]*Variant)
+	candidates := make(map[string][]*Variant)
+	for _, v := range b.Variants {
+		if v != nil {
+			candidates[variantKey(v)] = append(candidates[variantKey(v)], v)
+		}
+	}
+	for _, v := range a.Variants {
+		if v == nil {
+			continue
+		}
+		key := variantKey(v)
+		if c := candidates[key]; len(c) > 0 {
+			matched[c[0]] = v
+			candidates[key] = c[1:]
+			continue
+		}
+		changes = append(changes, MasterChange{Kind: DiffRemoved, Variant: v})
+	}
+	var tail []MasterChange
+	for _, v := range b.Variants {
+		if v == nil {
+			continue
+		}
+		old, ok := matched[v]
+		if !ok {
+			tail = append(tail, MasterChange{Kind: DiffAdded, Variant: v})
+			continue
+		}
+		for _, d := range diffAttrs(variantAttrs(old), variantAttrs(v), "URI") {
+			d.Variant = v
+			tail = append(tail, d)
+		}
+	}
+
+	// renditions
+	oldAlts := make(map[string]*Alternative)
+	newAlts := make(map[string]bool)
+	for _, alt := range b.renditions() {
+		newAlts[renditionKey(alt)] = true
+	}
+	for _, alt := range a.renditions() {
+		key := renditionKey(alt)
+		oldAlts[key] = alt
+		if !newAlts[key] {
+			changes = append(changes, MasterChange{Kind: DiffRemoved, Rendition: alt})
+		}
+	}
+	for _, alt := range b.renditions() {
+		old, ok := oldAlts[renditionKey(alt)]
+		if !ok {
+			tail = append(tail, MasterChange{Kind: DiffAdded, Rendition: alt})
+			continue
+		}
+		for _, d := range diffAttrs(alternativeAttrs(old), alternativeAttrs(alt), "TYPE", "GROUP-ID", "NAME", "LANGUAGE") {
+			d.Rendition = alt
+			tail = append(tail, d)
+		}
+	}
+	return append(changes, tail...)
+}
+
+// renditions returns distinct renditions of the playlist in order of
+// the playlist. Renditions with the same TYPE, GROUP-ID, NAME and
+// LANGUAGE are written once, so only the first of them is returned.
+func (p *MasterPlaylist) renditions() []*Alternative {
+	var alts []*Alternative
+	seen := make(map[string]bool)
+	for _, v := range p.Variants {
+		if v == nil {
+			continue
+		}
+		for _, alt := range v.Alternatives {
+			if alt == nil {
+				continue
+			}
+			key := renditionKey(alt)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			alts = append(alts, alt)
+		}
+	}
+	return alts
+}
+
+// renditionKey identifies the rendition as the encoder does.
+func renditionKey(alt *Alternative) string {
+	return fmt.Sprintf("%s-%s-%s-%s", alt.Type, alt.GroupId, alt.Name, alt.Language)
+}
+
+// diffAttrs returns changes of the attributes except the ignored ones
+// in order of the old attributes followed by the new ones.
+func diffAttrs(old, cur attrList, ignore ...string) []MasterChange {
+	skip := make(map[string]bool)
+	for _, name := range ignore {
+		skip[name] = true
+	}
+	values := func(l attrList) map[string]string {
+		m := make(map[string]string, len(l))
+		for _, a := range l {
+			m[a.name] = strings.Trim(a.value, `"`)
+		}
+		return m
+	}
+	oldValues, curValues := values(old), values(cur)
+	var changes []MasterChange
+	for _, l := range []attrList{old, cur} {
+		for _, a := range l {
+			if skip[a.name] {
+				continue
+			}
+			skip[a.name] = true
+			if o, c := oldValues[a.name], curValues[a.name]; o != c {
+				changes = append(changes, MasterChange{Kind: DiffChanged, Attribute: a.name, Old: o, New: c})
+			}
+		}
+	}
+	return changes
+}
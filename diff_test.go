@@ -0,0 +1,83 @@
+/*
+Master playlist diff tests.
+
+Copyright 2013-2019 The Project Developers.
+See the AUTHORS and LICENSE files at the top-level directory of this distribution
+and at https://github.com/grafov/m3u8/
+
+ॐ तारे तुत्तारे तुरे स्व
+*/
+package m3u8
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDiffMaster(t *testing.T) {
+	decode := func(src string) (*MasterPlaylist, error) {
+		p := NewMasterPlaylist()
+		return p, p.DecodeFrom(strings.NewReader(src), true)
+	}
+	a, err := decode(`#EXTM3U
+#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID="aac",NAME="English",LANGUAGE="en",DEFAULT=YES,URI="en.m3u8"
+#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID="aac",NAME="Deutsch",LANGUAGE="de",URI="de.m3u8"
+#EXT-X-STREAM-INF:BANDWIDTH=800000,CODECS="avc1.4d401e,mp4a.40.2",AUDIO="aac"
+low.m3u8
+#EXT-X-STREAM-INF:BANDWIDTH=1500000,CODECS="avc1.4d401f,mp4a.40.2",AUDIO="aac"
+mid.m3u8
+#EXT-X-I-FRAME-STREAM-INF:BANDWIDTH=100000,URI="iframe.m3u8"
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := decode(`#EXTM3U
+#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID="aac",NAME="English",LANGUAGE="en",DEFAULT=YES,URI="en-v2.m3u8"
+#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID="ec3",NAME="English",LANGUAGE="en",DEFAULT=YES,URI="en-ec3.m3u8"
+#EXT-X-STREAM-INF:BANDWIDTH=900000,CODECS="avc1.4d401e,mp4a.40.2",AUDIO="aac"
+low.m3u8
+#EXT-X-STREAM-INF:BANDWIDTH=4000000,CODECS="avc1.640028,ec-3",AUDIO="ec3"
+high.m3u8
+#EXT-X-I-FRAME-STREAM-INF:BANDWIDTH=100000,URI="iframe.m3u8"
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, c := range DiffMaster(a, b) {
+		got = append(got, c.String())
+	}
+	expected := []string{
+		`variant "mid.m3u8" removed`,
+		`rendition AUDIO "Deutsch" of group "aac" removed`,
+		`variant "low.m3u8": BANDWIDTH changed from "800000" to "900000"`,
+		`variant "high.m3u8" added`,
+		`rendition AUDIO "English" of group "aac": URI changed from "en.m3u8" to "en-v2.m3u8"`,
+		`rendition AUDIO "English" of group "ec3" added`,
+	}
+	if strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("unexpected diff:\n%s\nexpected:\n%s", strings.Join(got, "\n"), strings.Join(expected, "\n"))
+	}
+
+	if changes := DiffMaster(a, a); len(changes) != 0 {
+		t.Errorf("expected no changes for the same playlist, got %v", changes)
+	}
+}
+
+func TestDiffMasterGroupRewiring(t *testing.T) {
+	a := NewMasterPlaylist()
+	a.Append("low.m3u8", nil, VariantParams{Bandwidth: 800000, Codecs: "avc1.4d401e", Audio: "aac"})
+	b := NewMasterPlaylist()
+	b.Append("low.m3u8", nil, VariantParams{Bandwidth: 800000, Codecs: "hvc1.1.6.L93.B0", Audio: "ec3"})
+	changes := DiffMaster(a, b)
+	if len(changes) != 2 {
+		t.Fatalf("expected 2 changes, got %v", changes)
+	}
+	if c := changes[0]; c.Kind != DiffChanged || c.Attribute != "CODECS" || c.Old != "avc1.4d401e" || c.Variant != b.Variants[0] {
+		t.Errorf("unexpected change: %+v", c)
+	}
+	if c := changes[1]; c.Attribute != "AUDIO" || c.Old != "aac" || c.New != "ec3" {
+		t.Errorf("unexpected change: %+v", c)
+	}
+}
@@ -0,0 +1,248 @@
+package m3u8
+
+/*
+ Part of M3U8 parser & generator library.
+ This file defines generation of start-over, DVR and time-shifted
+ playlists.
+
+ Copyright 2013-2019 The Project Developers.
+ See the AUTHORS and LICENSE files at the top-level directory of this distribution
+ and at https://github.com/grafov/m3u8/
+
+ ॐ तारे तुत्तारे तुरे स्व
+*/
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrProgramTimeNotFound returned when the requested program time is
+// outside of the segments of the playlist.
+var ErrProgramTimeNotFound = errors.New("program time is out of the playlist")
+
+// StartOver returns a new playlist beginning at the segment which
+// contains the program time from and continuing to the live edge.
+// The archive holds segments already evicted from the live playlist
+// (for example collected with SetOnEvict) in playlist order, so the
+// start-over may begin before the current window. The result keeps
+// media sequence numbers, discontinuity sequence, the effective key and
+// map of the first segment and EXT-X-PROGRAM-DATE-TIME of the first
+// segment. EXT-X-START points to the requested time inside the first
+// segment. The result of a live playlist has EVENT type so more
+// segments may be appended to it. Program times of segments are
+// computed from EXT-X-PROGRAM-DATE-TIME tags and durations, so at
+// least one segment must have the tag.
+func (p *MediaPlaylist) StartOver(from time.Time, archive ...*MediaSegment) (*MediaPlaylist, error) {
+	out, err := p.dvr(from, 0, archive)
+	if err != nil {
+		return nil, err
+	}
+	if !out.Closed {
+		out.MediaType = EVENT
+	}
+	return out, nil
+}
+
+// DVRWindow returns a new playlist as StartOver does but limited to
+// segments which start before from plus window. The playlist cut
+// before the live edge is closed. Zero window means no limit.
+func (p *MediaPlaylist) DVRWindow(from time.Time, window time.Duration, archive ...*MediaSegment) (*MediaPlaylist, error) {
+	return p.dvr(from, window, archive)
+}
+
+func (p *MediaPlaylist) dvr(from time.Time, window time.Duration, archive []*MediaSegment) (*MediaPlaylist, error) {
+	var segs []*MediaSegment
+	for _, seg := range archive {
+		if seg != nil {
+			segs = append(segs, seg)
+		}
+	}
+	live := len(segs)
+	segs = append(segs, p.segments()...)
+
+	times := programTimes(segs)
+	if times == nil {
+		return nil, ErrNoProgramDateTime
+	}
+	start := segmentAtTime(segs, times, from)
+	if start < 0 {
+		return nil, ErrProgramTimeNotFound
+	}
+	end := len(segs)
+	if window > 0 {
+		limit := from.Add(window)
+		for i := start + 1; i < len(segs); i++ {
+			if !times[i].Before(limit) {
+				end = i
+				break
+			}
+		}
+	}
+
+	out, err := p.excerpt(segs, start, end, live)
+	if err != nil {
+		return nil, err
+	}
+	out.Segments[0].ProgramDateTime = times[start]
+	if end < len(segs) || p.Closed {
+		out.Closed = true
+	}
+	if offset := from.Sub(times[start]).Seconds(); offset > 0 {
+		out.SetStartTime(offset, true)
+	}
+	return out, nil
+}
+
+// TimeShift returns a new live playlist delayed by delay relative
+// to the playlist: the newest segments ending within delay from the
+// live edge are dropped and the window of the playlist (or all
+// segments for playlists without a window) is kept before the delayed
+// edge. Media and discontinuity sequences follow the dropped segments
+// so the delayed playlist may be served instead of the source one,
+// for example for delayed broadcast. The result is never closed
+// unless delay is zero. ErrPlaylistEmpty returned when no segments
+// remain.
+func (p *MediaPlaylist) TimeShift(delay time.Duration) (*MediaPlaylist, error) {
+	segs := p.segments()
+	end := len(segs)
+	var dropped time.Duration
+	for end > 0 && dropped < delay {
+		end--
+		dropped += seconds(segs[end].Duration)
+	}
+	if end == 0 {
+		return nil, ErrPlaylistEmpty
+	}
+	start := 0
+	if p.winsize > 0 && uint(end) > p.winsize {
+		start = end - int(p.winsize)
+	}
+	out, err := p.excerpt(segs, start, end, 0)
+	if err != nil {
+		return nil, err
+	}
+	out.Closed = p.Closed && end == len(segs)
+	return out, nil
+}
+
+// excerpt returns a new open playlist with copies of segments
+// segs[start:end]. The live is the index of the first segment of the
+// playlist in segs, preceding segments are evicted ones. The first
+// segment gets the effective key and map if they differ from the
+// playlist ones.
+func (p *MediaPlaylist) excerpt(segs []*MediaSegment, start, end, live int) (*MediaPlaylist, error) {
+	// effective key, map and discontinuity sequence of the first segment
+	key, m := p.Key, p.Map
+	for _, seg := range segs[:start+1] {
+		if seg.Key != nil {
+			key = seg.Key
+		}
+		if seg.Map != nil {
+			m = seg.Map
+		}
+	}
+	dseq := p.DiscontinuitySeq
+	if start < live {
+		for _, seg := range segs[start:live] {
+			if seg.Discontinuity && dseq > 0 {
+				dseq--
+			}
+		}
+	} else {
+		for _, seg := range segs[live:start] {
+			if seg.Discontinuity {
+				dseq++
+			}
+		}
+	}
+
+	out, err := NewMediaPlaylist(0, uint(end-start))
+	if err != nil {
+		return nil, err
+	}
+	out.TargetDuration = p.TargetDuration
+	out.Args = p.Args
+	out.Iframe = p.Iframe
+	out.ImagesOnly = p.ImagesOnly
+	out.MediaType = p.MediaType
+	out.AllowCache = p.AllowCache
+	out.Key = p.Key
+	out.Map = p.Map
+	out.WV = p.WV
+	out.Defines = p.Defines
+	out.ServerControl = p.ServerControl
+	out.UnknownTags = p.UnknownTags
+	out.durationAsInt = p.durationAsInt
+	out.durationPrec = p.durationPrec
+	out.durationPrecSet = p.durationPrecSet
+	out.targetRounding = p.targetRounding
+	out.independentSegments = p.independentSegments
+	out.SeqNo = segs[start].SeqId
+	out.DiscontinuitySeq = dseq
+	out.dseqSet = dseq > 0 || p.dseqSet
+	for i, seg := range segs[start:end] {
+		s := *seg
+		if i == 0 {
+			if key != nil && s.Key == nil && (p.Key == nil || !sameKey(key, p.Key)) {
+				s.Key = key
+			}
+			if m != nil && s.Map == nil && m != p.Map {
+				s.Map = m
+			}
+		}
+		if text, ok := p.durationText[seg]; ok {
+			out.keepDurationText(&s, text)
+		}
+		out.Segments[i] = &s
+	}
+	out.tail = uint(end-start) % out.capacity
+	out.count = uint(end - start)
+	version(&out.ver, p.ver)
+	return out, nil
+}
+
+// seconds converts duration in seconds to time.Duration.
+func seconds(d float64) time.Duration {
+	return time.Duration(d * float64(time.Second))
+}
+
+// programTimes returns program times of the segments derived backward
+// and forward from the first segment with EXT-X-PROGRAM-DATE-TIME,
+// nil if no segment has it.
+func programTimes(segs []*MediaSegment) []time.Time {
+	first := -1
+	for i, seg := range segs {
+		if !seg.ProgramDateTime.IsZero() {
+			first = i
+			break
+		}
+	}
+	if first < 0 {
+		return nil
+	}
+	times := make([]time.Time, len(segs))
+	times[first] = segs[first].ProgramDateTime
+	for i := first - 1; i >= 0; i-- {
+		times[i] = times[i+1].Add(-seconds(segs[i].Duration))
+	}
+	for i := first + 1; i < len(segs); i++ {
+		if segs[i].ProgramDateTime.IsZero() {
+			times[i] = times[i-1].Add(seconds(segs[i-1].Duration))
+		} else {
+			times[i] = segs[i].ProgramDateTime
+		}
+	}
+	return times
+}
+
+// segmentAtTime returns the index of the segment containing the
+// program time t or -1 if no segment contains it.
+func segmentAtTime(segs []*MediaSegment, times []time.Time, t time.Time) int {
+	for i, seg := range segs {
+		if !t.Before(times[i]) && t.Before(times[i].Add(seconds(seg.Duration))) {
+			return i
+		}
+	}
+	return -1
+}
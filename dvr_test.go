@@ -0,0 +1,139 @@
+/*
+Start-over and DVR playlists tests.
+
+Copyright 2013-2019 The Project Developers.
+See the AUTHORS and LICENSE files at the top-level directory of this distribution
+and at https://github.com/grafov/m3u8/
+
+ॐ तारे तुत्तारे तुरे स्व
+*/
+package m3u8
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// dvrPlaylist returns a live playlist of 6 segments of 4 seconds
+// with 2 oldest segments evicted to the archive. The key changes
+// on the segment 1 and the discontinuity is on the segment 2.
+func dvrPlaylist(t *testing.T) (*MediaPlaylist, []*MediaSegment, time.Time) {
+	p, e := NewMediaPlaylist(4, 4)
+	if e != nil {
+		t.Fatalf("Create media playlist failed: %s", e)
+	}
+	var archive []*MediaSegment
+	p.SetOnEvict(func(seg *MediaSegment) { archive = append(archive, seg) })
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 6; i++ {
+		p.Slide(fmt.Sprintf("s%d.ts", i), 4, "")
+		switch i {
+		case 0:
+			p.SetProgramDateTime(start)
+		case 1:
+			p.SetKey("AES-128", "k1", "", "", "")
+		case 2:
+			p.SetDiscontinuity()
+		}
+	}
+	return p, archive, start
+}
+
+func TestStartOver(t *testing.T) {
+	p, archive, start := dvrPlaylist(t)
+	if len(archive) != 2 || p.SeqNo != 2 || p.DiscontinuitySeq != 0 {
+		t.Fatalf("unexpected live state: archive %d, seq %d, dseq %d", len(archive), p.SeqNo, p.DiscontinuitySeq)
+	}
+	out, err := p.StartOver(start.Add(6*time.Second), archive...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.SeqNo != 1 || out.Count() != 5 || out.MediaType != EVENT || out.Closed {
+		t.Errorf("unexpected start-over: seq %d, count %d, type %d, closed %v", out.SeqNo, out.Count(), out.MediaType, out.Closed)
+	}
+	text := out.String()
+	for _, expected := range []string{
+		"#EXT-X-MEDIA-SEQUENCE:1\n",
+		"#EXT-X-START:TIME-OFFSET=2,PRECISE=YES\n",
+		"#EXT-X-KEY:METHOD=AES-128,URI=\"k1\"\n",
+		"#EXT-X-PROGRAM-DATE-TIME:2020-01-01T00:00:04Z\n",
+		"#EXT-X-PLAYLIST-TYPE:EVENT\n",
+	} {
+		if !strings.Contains(text, expected) {
+			t.Errorf("expected %q in:\n%s", expected, text)
+		}
+	}
+	if !p.Segments[p.head].ProgramDateTime.IsZero() {
+		t.Error("source segments must not be modified")
+	}
+
+	// the start-over after the discontinuity counts it in the
+	// discontinuity sequence
+	out, err = p.StartOver(start.Add(13*time.Second), archive...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.SeqNo != 3 || out.DiscontinuitySeq != 1 {
+		t.Errorf("expected sequence 3 and discontinuity sequence 1, got %d and %d", out.SeqNo, out.DiscontinuitySeq)
+	}
+}
+
+func TestDVRWindow(t *testing.T) {
+	p, archive, start := dvrPlaylist(t)
+	out, err := p.DVRWindow(start, 8*time.Second, archive...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.Count() != 2 || !out.Closed || out.SeqNo != 0 || out.StartTime != 0 {
+		t.Errorf("unexpected window: count %d, closed %v, seq %d, start %v", out.Count(), out.Closed, out.SeqNo, out.StartTime)
+	}
+	if _, err = p.DVRWindow(start.Add(-time.Second), 0, archive...); err != ErrProgramTimeNotFound {
+		t.Errorf("expected %v, got %v", ErrProgramTimeNotFound, err)
+	}
+	if _, err = p.DVRWindow(start.Add(time.Minute), 0, archive...); err != ErrProgramTimeNotFound {
+		t.Errorf("expected %v, got %v", ErrProgramTimeNotFound, err)
+	}
+	vod, _ := NewMediaPlaylist(0, 1)
+	vod.Append("a.ts", 4, "")
+	if _, err = vod.StartOver(start); err != ErrNoProgramDateTime {
+		t.Errorf("expected %v, got %v", ErrNoProgramDateTime, err)
+	}
+}
+
+func TestTimeShift(t *testing.T) {
+	p, _, _ := dvrPlaylist(t)
+	p.winsize = 2
+	out, err := p.TimeShift(4 * time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// s5 is dropped, the window of 2 segments ends with s4 and the
+	// discontinuity of s2 is counted
+	if out.SeqNo != 3 || out.Count() != 2 || out.DiscontinuitySeq != 1 || out.Closed {
+		t.Fatalf("unexpected shifted playlist: seq %d, count %d, dseq %d, closed %v", out.SeqNo, out.Count(), out.DiscontinuitySeq, out.Closed)
+	}
+	if last := out.Segments[out.last()]; last.URI != "s4.ts" {
+		t.Errorf("expected s4.ts at the delayed edge, got %s", last.URI)
+	}
+
+	p.winsize = 0
+	out, err = p.TimeShift(5 * time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.SeqNo != 2 || out.Count() != 2 {
+		t.Errorf("expected sequence 2 and 2 segments, got %d and %d", out.SeqNo, out.Count())
+	}
+	out, err = p.TimeShift(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != p.String() {
+		t.Errorf("zero delay must keep the playlist:\n%s\nexpected:\n%s", out, p)
+	}
+	if _, err = p.TimeShift(time.Minute); err != ErrPlaylistEmpty {
+		t.Errorf("expected %v, got %v", ErrPlaylistEmpty, err)
+	}
+}
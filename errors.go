@@ -0,0 +1,47 @@
+package m3u8
+
+/*
+ Part of M3U8 parser & generator library.
+ This file defines errors of decoding.
+
+ Copyright 2013-2019 The Project Developers.
+ See the AUTHORS and LICENSE files at the top-level directory of this distribution
+ and at https://github.com/grafov/m3u8/
+
+ ॐ तारे तुत्तारे तुरे स्व
+*/
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrNoHeader returned by the strict decoder for the playlist without
+// #EXTM3U tag.
+var ErrNoHeader = errors.New("#EXTM3U absent")
+
+// ParseError is returned by the strict decoder for the malformed
+// line of the playlist. Err is the error of the line, it may be one
+// of the errors declared by the package (e.g. ErrSessionDataValueURI)
+// or the error of a custom decoder, use errors.Is and errors.As to
+// examine it.
+type ParseError struct {
+	Line int    // number of the line starting from 1
+	Text string // the line without trailing white space
+	Err  error
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("line %d: %s: %s", e.Line, lineTag(e.Text), e.Err)
+}
+
+// Unwrap returns the error of the line.
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// parseError returns ParseError of the current line.
+func (s *decodingState) parseError(line string, err error) error {
+	return &ParseError{Line: s.lineNo, Text: strings.TrimSpace(line), Err: err}
+}
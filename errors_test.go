@@ -0,0 +1,36 @@
+/*
+Decoding errors tests.
+
+Copyright 2013-2019 The Project Developers.
+See the AUTHORS and LICENSE files at the top-level directory of this distribution
+and at https://github.com/grafov/m3u8/
+
+ॐ तारे तुत्तारे तुरे स्व
+*/
+package m3u8
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseError(t *testing.T) {
+	src := "#EXTM3U\n#EXT-X-SESSION-DATA:DATA-ID=\"com.example\"\n#EXT-X-STREAM-INF:BANDWIDTH=1000\nlow.m3u8\n"
+	p := NewMasterPlaylist()
+	err := p.DecodeFrom(strings.NewReader(src), true)
+	perr, ok := err.(*ParseError)
+	if !ok {
+		t.Fatalf("expected ParseError, got %T: %v", err, err)
+	}
+	if perr.Line != 2 || perr.Text != `#EXT-X-SESSION-DATA:DATA-ID="com.example"` || perr.Unwrap() != ErrSessionDataValueURI {
+		t.Errorf("unexpected error: %+v", perr)
+	}
+	if !strings.HasPrefix(perr.Error(), "line 2: #EXT-X-SESSION-DATA: ") {
+		t.Errorf("unexpected message: %s", perr)
+	}
+
+	m, _ := NewMediaPlaylist(0, 1)
+	if err = m.DecodeFrom(strings.NewReader("#EXTINF:5,\na.ts\n"), true); err != ErrNoHeader {
+		t.Errorf("expected ErrNoHeader, got %v", err)
+	}
+}
@@ -0,0 +1,63 @@
+package m3u8
+
+/*
+ Part of M3U8 parser & generator library.
+ This file defines export of media playlists to segment lists.
+
+ Copyright 2013-2019 The Project Developers.
+ See the AUTHORS and LICENSE files at the top-level directory of this distribution
+ and at https://github.com/grafov/m3u8/
+
+ ॐ तारे तुत्तारे तुरे स्व
+*/
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// WriteFFConcat writes segments of the media playlist as ffmpeg
+// concat demuxer script (ffconcat version 1.0) with durations of the
+// segments. Byte ranges are expressed with ffmpeg subfile protocol.
+// URIs are written as is so relative URIs are resolved by ffmpeg
+// against the location of the script. Encryption of segments is not
+// exported.
+func (p *MediaPlaylist) WriteFFConcat(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	bw.WriteString("ffconcat version 1.0\n")
+	p.eachSegment(func(seg *MediaSegment) {
+		uri := seg.URI
+		if seg.Limit > 0 {
+			uri = "subfile,,start," + strconv.FormatInt(seg.Offset, 10) +
+				",end," + strconv.FormatInt(seg.Offset+seg.Limit, 10) + ",,:" + uri
+		}
+		bw.WriteString("file '")
+		bw.WriteString(strings.Replace(uri, "'", `'\''`, -1))
+		bw.WriteString("'\nduration ")
+		bw.WriteString(strconv.FormatFloat(seg.Duration, 'f', -1, 64))
+		bw.WriteRune('\n')
+	})
+	return bw.Flush()
+}
+
+// WriteSegmentList writes segments of the media playlist one per line
+// as tab separated URI, duration in seconds and byte range in
+// <length>@<offset> form (empty for segments without byte range).
+func (p *MediaPlaylist) WriteSegmentList(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	p.eachSegment(func(seg *MediaSegment) {
+		bw.WriteString(seg.URI)
+		bw.WriteRune('\t')
+		bw.WriteString(strconv.FormatFloat(seg.Duration, 'f', -1, 64))
+		bw.WriteRune('\t')
+		if seg.Limit > 0 {
+			bw.WriteString(strconv.FormatInt(seg.Limit, 10))
+			bw.WriteRune('@')
+			bw.WriteString(strconv.FormatInt(seg.Offset, 10))
+		}
+		bw.WriteRune('\n')
+	})
+	return bw.Flush()
+}
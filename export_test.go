@@ -0,0 +1,56 @@
+/*
+Segment list export tests.
+
+Copyright 2013-2019 The Project Developers.
+See the AUTHORS and LICENSE files at the top-level directory of this distribution
+and at https://github.com/grafov/m3u8/
+
+ॐ तारे तुत्तारे तुरे स्व
+*/
+package m3u8
+
+import (
+	"bytes"
+	"testing"
+)
+
+func exportPlaylist(t *testing.T) *MediaPlaylist {
+	p, err := NewMediaPlaylist(3, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.Append("a.ts", 10, "")
+	p.Append("it's.ts", 9.5, "")
+	p.Append("all.ts", 4, "")
+	p.SetRange(1000, 500)
+	return p
+}
+
+func TestWriteFFConcat(t *testing.T) {
+	var buf bytes.Buffer
+	if err := exportPlaylist(t).WriteFFConcat(&buf); err != nil {
+		t.Fatal(err)
+	}
+	expected := `ffconcat version 1.0
+file 'a.ts'
+duration 10
+file 'it'\''s.ts'
+duration 9.5
+file 'subfile,,start,500,end,1500,,:all.ts'
+duration 4
+`
+	if buf.String() != expected {
+		t.Errorf("unexpected script:\n%s", buf.String())
+	}
+}
+
+func TestWriteSegmentList(t *testing.T) {
+	var buf bytes.Buffer
+	if err := exportPlaylist(t).WriteSegmentList(&buf); err != nil {
+		t.Fatal(err)
+	}
+	expected := "a.ts\t10\t\nit's.ts\t9.5\t\nall.ts\t4\t1000@500\n"
+	if buf.String() != expected {
+		t.Errorf("unexpected list:\n%q", buf.String())
+	}
+}
@@ -0,0 +1,112 @@
+package m3u8
+
+/*
+ Part of M3U8 parser & generator library.
+ This file defines the interface for fetching nested resources.
+
+ Copyright 2013-2019 The Project Developers.
+ See the AUTHORS and LICENSE files at the top-level directory of this distribution
+ and at https://github.com/grafov/m3u8/
+
+ ॐ तारे तुत्तारे तुरे स्व
+*/
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+// Fetcher retrieves resources referenced by playlists (media
+// playlists of variants, keys etc.). The library doesn't dictate a
+// transport so any source (authenticated HTTP client, object
+// storage, files, test fakes) may be plugged in. A caller must close
+// the returned reader.
+type Fetcher interface {
+	Fetch(ctx context.Context, uri string) (io.ReadCloser, error)
+}
+
+// FetcherFunc is an adapter to allow the use of ordinary functions as
+// Fetcher.
+type FetcherFunc func(ctx context.Context, uri string) (io.ReadCloser, error)
+
+// Fetch calls f(ctx, uri).
+func (f FetcherFunc) Fetch(ctx context.Context, uri string) (io.ReadCloser, error) {
+	return f(ctx, uri)
+}
+
+// HTTPFetcher fetches resources over HTTP with the Client or with
+// http.DefaultClient when the Client is nil. Responses with status
+// other than 2xx are reported as errors.
+type HTTPFetcher struct {
+	Client *http.Client
+}
+
+// Fetch implements Fetcher interface.
+func (f *HTTPFetcher) Fetch(ctx context.Context, uri string) (io.ReadCloser, error) {
+	req, err := http.NewRequest(http.MethodGet, uri, nil)
+	if err != nil {
+		return nil, err
+	}
+	client := f.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		resp.Body.Close()
+		return nil, fmt.Errorf("fetch %s: %s", uri, resp.Status)
+	}
+	return resp.Body, nil
+}
+
+// ResolveChunklists fetches media playlists of all variants with the
+// fetcher and stores them in Chunklist field of each variant.
+// Relative variant URIs resolved against the base URI of the master
+// playlist. Variants with already assigned Chunklist are skipped.
+func (p *MasterPlaylist) ResolveChunklists(ctx context.Context, f Fetcher, base string, strict bool) error {
+	baseURL, err := url.Parse(base)
+	if err != nil {
+		return err
+	}
+	for _, v := range p.Variants {
+		if v == nil || v.Chunklist != nil || v.URI == "" {
+			continue
+		}
+		if err = ctx.Err(); err != nil {
+			return err
+		}
+		ref, err := url.Parse(v.URI)
+		if err != nil {
+			return fmt.Errorf("variant %q: %s", v.URI, err)
+		}
+		chunklist, err := fetchMediaPlaylist(ctx, f, baseURL.ResolveReference(ref).String(), strict)
+		if err != nil {
+			return fmt.Errorf("variant %q: %s", v.URI, err)
+		}
+		v.Chunklist = chunklist
+	}
+	return nil
+}
+
+// fetchMediaPlaylist fetches and decodes the media playlist.
+func fetchMediaPlaylist(ctx context.Context, f Fetcher, uri string, strict bool) (*MediaPlaylist, error) {
+	body, err := f.Fetch(ctx, uri)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	p, listType, err := DecodeContext(ctx, body, DecodeOptions{Strict: strict})
+	if err != nil {
+		return nil, err
+	}
+	if listType != MEDIA {
+		return nil, fmt.Errorf("%s is not a media playlist", uri)
+	}
+	return p.(*MediaPlaylist), nil
+}
@@ -0,0 +1,84 @@
+/*
+Fetcher tests.
+
+Copyright 2013-2019 The Project Developers.
+See the AUTHORS and LICENSE files at the top-level directory of this distribution
+and at https://github.com/grafov/m3u8/
+
+ॐ तारे तुत्तारे तुरे स्व
+*/
+package m3u8
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const fetchTestMedia = `#EXTM3U
+#EXT-X-TARGETDURATION:6
+#EXTINF:6.0,
+seg0.ts
+#EXT-X-ENDLIST
+`
+
+func TestResolveChunklists(t *testing.T) {
+	var requested []string
+	f := FetcherFunc(func(ctx context.Context, uri string) (io.ReadCloser, error) {
+		requested = append(requested, uri)
+		if strings.HasSuffix(uri, "missing.m3u8") {
+			return nil, errors.New("not found")
+		}
+		return ioutil.NopCloser(strings.NewReader(fetchTestMedia)), nil
+	})
+	p := NewMasterPlaylist()
+	p.Append("low/index.m3u8", nil, VariantParams{Bandwidth: 1000})
+	p.Append("https://cdn.example.com/high.m3u8", nil, VariantParams{Bandwidth: 2000})
+	if err := p.ResolveChunklists(context.Background(), f, "https://example.com/live/master.m3u8", true); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"https://example.com/live/low/index.m3u8", "https://cdn.example.com/high.m3u8"}
+	if strings.Join(requested, " ") != strings.Join(want, " ") {
+		t.Errorf("Requested %v, want %v", requested, want)
+	}
+	for _, v := range p.Variants {
+		if v.Chunklist == nil || v.Chunklist.Count() != 1 {
+			t.Errorf("Chunklist of %s is not resolved", v.URI)
+		}
+	}
+
+	p.Append("missing.m3u8", nil, VariantParams{Bandwidth: 3000})
+	if err := p.ResolveChunklists(context.Background(), f, "https://example.com/", true); err == nil {
+		t.Error("Expected error for missing chunklist")
+	}
+}
+
+func TestHTTPFetcher(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/index.m3u8" {
+			http.NotFound(w, r)
+			return
+		}
+		io.WriteString(w, fetchTestMedia)
+	}))
+	defer srv.Close()
+
+	f := &HTTPFetcher{}
+	body, err := f.Fetch(context.Background(), srv.URL+"/index.m3u8")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := ioutil.ReadAll(body)
+	body.Close()
+	if string(data) != fetchTestMedia {
+		t.Errorf("Unexpected body %q", data)
+	}
+	if _, err = f.Fetch(context.Background(), srv.URL+"/other.m3u8"); err == nil {
+		t.Error("Expected error for 404 response")
+	}
+}
@@ -0,0 +1,95 @@
+package m3u8
+
+/*
+ Part of M3U8 parser & generator library.
+ This file defines round-trip fidelity of decoded playlists.
+
+ Copyright 2013-2019 The Project Developers.
+ See the AUTHORS and LICENSE files at the top-level directory of this distribution
+ and at https://github.com/grafov/m3u8/
+
+ ॐ तारे तुत्तारे तुरे स्व
+*/
+
+import (
+	"bytes"
+	"strconv"
+)
+
+// fidelitySource is the source of the playlist decoded with
+// DecodeOptions.Fidelity and its encoding right after decoding. While
+// the encoding of the playlist doesn't change the source is written
+// instead.
+type fidelitySource struct {
+	text    string
+	encoded string
+}
+
+// withFidelity returns the options with options implied by Fidelity.
+func (o DecodeOptions) withFidelity() DecodeOptions {
+	if o.Fidelity {
+		o.PreserveAttributeOrder = true
+		o.PreserveUnknownTags = true
+	}
+	return o
+}
+
+// source returns the source text of the playlist to be decoded from
+// the buffer if Fidelity is set.
+func (o DecodeOptions) source(buf *bytes.Buffer) string {
+	if !o.Fidelity {
+		return ""
+	}
+	return buf.String()
+}
+
+// restore replaces the encoding of the unchanged playlist with the
+// source.
+func (s *fidelitySource) restore(buf *bytes.Buffer) {
+	if s != nil && buf.String() == s.encoded {
+		buf.Reset()
+		buf.WriteString(s.text)
+	}
+}
+
+// keepSource remembers the source of the decoded playlist.
+func (p *MasterPlaylist) keepSource(text string) {
+	p.source = nil
+	if text == "" {
+		return
+	}
+	p.buf.Reset()
+	p.source = &fidelitySource{text: text, encoded: p.Encode().String()}
+	p.buf.Reset()
+}
+
+// keepSource remembers the source of the decoded playlist.
+func (p *MediaPlaylist) keepSource(text string) {
+	p.source = nil
+	if text == "" {
+		return
+	}
+	p.buf.Reset()
+	p.source = &fidelitySource{text: text, encoded: p.Encode().String()}
+	p.buf.Reset()
+}
+
+// keepDurationText remembers the source text of EXTINF duration of
+// the segment.
+func (p *MediaPlaylist) keepDurationText(seg *MediaSegment, text string) {
+	if p.durationText == nil {
+		p.durationText = make(map[*MediaSegment]string)
+	}
+	p.durationText[seg] = text
+}
+
+// sourceDuration returns the source text of EXTINF duration of the
+// segment unless the duration was changed.
+func (p *MediaPlaylist) sourceDuration(seg *MediaSegment) (string, bool) {
+	text, ok := p.durationText[seg]
+	if !ok || p.durationAsInt || p.durationPrecSet {
+		return "", false
+	}
+	d, err := strconv.ParseFloat(text, 64)
+	return text, err == nil && d == seg.Duration
+}
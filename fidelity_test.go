@@ -0,0 +1,85 @@
+/*
+Round-trip fidelity tests.
+
+Copyright 2013-2019 The Project Developers.
+See the AUTHORS and LICENSE files at the top-level directory of this distribution
+and at https://github.com/grafov/m3u8/
+
+ॐ तारे तुत्तारे तुरे स्व
+*/
+package m3u8
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFidelityMedia(t *testing.T) {
+	const src = `#EXTM3U
+#EXT-X-TARGETDURATION:10
+#EXT-X-VERSION:3
+#EXT-X-MEDIA-SEQUENCE:1
+
+# first segment
+#EXT-X-KEY:URI="key.bin",METHOD=AES-128
+#EXTINF:9.5,
+a.ts
+
+#EXTINF:10,
+b.ts
+#EXT-X-ENDLIST
+`
+	p, _, err := DecodeWithOptions(strings.NewReader(src), DecodeOptions{Strict: true, Fidelity: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out := p.String(); out != src {
+		t.Errorf("unchanged playlist must be written as is:\n%s", out)
+	}
+
+	pp := p.(*MediaPlaylist)
+	pp.Segments[1].URI = "https://cdn.example.com/b.ts"
+	pp.ResetCache()
+	const expected = `#EXTM3U
+#EXT-X-VERSION:3
+#EXT-X-KEY:URI="key.bin",METHOD=AES-128
+#EXT-X-MEDIA-SEQUENCE:1
+#EXT-X-TARGETDURATION:10
+
+# first segment
+#EXTINF:9.5,
+a.ts
+
+#EXTINF:10,
+https://cdn.example.com/b.ts
+#EXT-X-ENDLIST
+`
+	if out := pp.String(); out != expected {
+		t.Errorf("unexpected output of the changed playlist:\n%s", out)
+	}
+
+	pp.Segments[0].Duration = 9
+	pp.ResetCache()
+	if out := pp.String(); !strings.Contains(out, "#EXTINF:9.000,\na.ts\n") {
+		t.Errorf("changed duration must be formatted:\n%s", out)
+	}
+}
+
+func TestFidelityMaster(t *testing.T) {
+	const src = "#EXTM3U\r\n" +
+		"#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID=\"aac\",NAME=\"en\",URI=\"en.m3u8\"\r\n" +
+		"#EXT-X-STREAM-INF:AUDIO=\"aac\",BANDWIDTH=300000\r\n" +
+		"low.m3u8\r\n"
+	p := NewMasterPlaylist()
+	if err := p.DecodeWithOptions(strings.NewReader(src), DecodeOptions{Strict: true, Fidelity: true}); err != nil {
+		t.Fatal(err)
+	}
+	if out := p.String(); out != src {
+		t.Errorf("unchanged playlist must be written as is:\n%q", out)
+	}
+	p.Variants[0].Bandwidth = 400000
+	p.ResetCache()
+	if out := p.String(); !strings.Contains(out, "#EXT-X-STREAM-INF:AUDIO=\"aac\",BANDWIDTH=400000\n") {
+		t.Errorf("unexpected output of the changed playlist:\n%s", out)
+	}
+}
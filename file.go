@@ -0,0 +1,89 @@
+package m3u8
+
+/*
+ Part of M3U8 parser & generator library.
+ This file defines atomic writing of playlists to files.
+
+ Copyright 2013-2019 The Project Developers.
+ See the AUTHORS and LICENSE files at the top-level directory of this distribution
+ and at https://github.com/grafov/m3u8/
+
+ ॐ तारे तुत्तारे तुरे स्व
+*/
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// PlaylistFileMode is the permission of files written by WriteFile.
+const PlaylistFileMode os.FileMode = 0644
+
+// WriteFile encodes the playlist and writes it to the file atomically:
+// the playlist is written to a temporary file in the same directory
+// which is renamed to the path, so readers (e.g. a web server) never
+// see a partially written playlist.
+func (p *MasterPlaylist) WriteFile(path string) error {
+	return writeFileAtomic(path, p.Encode().Bytes(), false)
+}
+
+// WriteFileSync writes the playlist as WriteFile and flushes the file
+// and its directory to the storage, so the playlist survives a crash.
+func (p *MasterPlaylist) WriteFileSync(path string) error {
+	return writeFileAtomic(path, p.Encode().Bytes(), true)
+}
+
+// WriteFile encodes the playlist and writes it to the file atomically,
+// see MasterPlaylist.WriteFile.
+func (p *MediaPlaylist) WriteFile(path string) error {
+	return writeFileAtomic(path, p.Encode().Bytes(), false)
+}
+
+// WriteFileSync writes the playlist as WriteFile and flushes the file
+// and its directory to the storage, see MasterPlaylist.WriteFileSync.
+func (p *MediaPlaylist) WriteFileSync(path string) error {
+	return writeFileAtomic(path, p.Encode().Bytes(), true)
+}
+
+// writeFileAtomic writes data to the temporary file and renames it to
+// the path. The temporary file is removed on failure.
+func writeFileAtomic(path string, data []byte, sync bool) (err error) {
+	dir := filepath.Dir(path)
+	f, err := ioutil.TempFile(dir, "."+filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			f.Close()
+			os.Remove(f.Name())
+		}
+	}()
+	if _, err = f.Write(data); err != nil {
+		return err
+	}
+	if sync {
+		if err = f.Sync(); err != nil {
+			return err
+		}
+	}
+	if err = f.Chmod(PlaylistFileMode); err != nil {
+		return err
+	}
+	if err = f.Close(); err != nil {
+		return err
+	}
+	if err = os.Rename(f.Name(), path); err != nil {
+		return err
+	}
+	if sync {
+		// the rename is durable after the directory is flushed, not
+		// all systems allow syncing of directories
+		if d, derr := os.Open(dir); derr == nil {
+			d.Sync()
+			d.Close()
+		}
+	}
+	return nil
+}
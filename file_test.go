@@ -0,0 +1,60 @@
+/*
+File output tests.
+
+Copyright 2013-2019 The Project Developers.
+See the AUTHORS and LICENSE files at the top-level directory of this distribution
+and at https://github.com/grafov/m3u8/
+
+ॐ तारे तुत्तारे तुरे स्व
+*/
+package m3u8
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "m3u8")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	p, _ := NewMediaPlaylist(3, 3)
+	p.Append("a.ts", 4, "")
+	path := filepath.Join(dir, "index.m3u8")
+	if err = p.WriteFile(path); err != nil {
+		t.Fatal(err)
+	}
+	p.Append("b.ts", 4, "")
+	if err = p.WriteFileSync(path); err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != p.String() {
+		t.Errorf("written playlist differs:\n%s\nexpected:\n%s", data, p)
+	}
+	if fi, _ := os.Stat(path); fi.Mode().Perm() != PlaylistFileMode {
+		t.Errorf("unexpected file mode %v", fi.Mode())
+	}
+
+	m := NewMasterPlaylist()
+	m.Append("index.m3u8", p, VariantParams{Bandwidth: 1000})
+	if err = m.WriteFile(filepath.Join(dir, "master.m3u8")); err != nil {
+		t.Fatal(err)
+	}
+	files, _ := ioutil.ReadDir(dir)
+	if len(files) != 2 {
+		t.Errorf("temporary files left: %d files in the directory", len(files))
+	}
+
+	if err = m.WriteFile(filepath.Join(dir, "missing", "master.m3u8")); err == nil {
+		t.Error("expected error for missing directory")
+	}
+}
@@ -0,0 +1,101 @@
+//go:build go1.16
+// +build go1.16
+
+package m3u8
+
+/*
+ Part of M3U8 parser & generator library.
+ This file defines loading of playlists from file systems (fs.FS).
+
+ Copyright 2013-2019 The Project Developers.
+ See the AUTHORS and LICENSE files at the top-level directory of this distribution
+ and at https://github.com/grafov/m3u8/
+
+ ॐ तारे तुत्तारे तुरे स्व
+*/
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// FSFetcher fetches resources from the file system, so playlists of a
+// local packager output may be processed with the same code as
+// playlists served over HTTP. URIs are slash-separated paths relative
+// to the root of the file system, query strings are ignored and
+// absolute URLs are rejected.
+type FSFetcher struct {
+	FS fs.FS
+}
+
+// Fetch implements Fetcher interface.
+func (f FSFetcher) Fetch(ctx context.Context, uri string) (io.ReadCloser, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "" || u.Host != "" {
+		return nil, fmt.Errorf("%s is not a path of the file system", uri)
+	}
+	name := path.Clean(strings.TrimPrefix(u.Path, "/"))
+	if !fs.ValidPath(name) {
+		return nil, fmt.Errorf("%s is outside of the file system", uri)
+	}
+	return f.FS.Open(name)
+}
+
+// LoadFS decodes the playlist from the file of the file system and
+// detects its type.
+func LoadFS(fsys fs.FS, name string, strict bool) (Playlist, ListType, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer f.Close()
+	return DecodeFrom(f, strict)
+}
+
+// LoadMasterFS decodes the master playlist from the file of the file
+// system and loads media playlists of its variants referenced with
+// paths relative to the master playlist (see ResolveChunklists).
+func LoadMasterFS(fsys fs.FS, name string, strict bool) (*MasterPlaylist, error) {
+	p, listType, err := LoadFS(fsys, name, strict)
+	if err != nil {
+		return nil, err
+	}
+	if listType != MASTER {
+		return nil, fmt.Errorf("%s is not a master playlist", name)
+	}
+	master := p.(*MasterPlaylist)
+	if err = master.ResolveChunklists(context.Background(), FSFetcher{fsys}, name, strict); err != nil {
+		return nil, err
+	}
+	return master, nil
+}
+
+// LoadGlobFS decodes playlists from the files of the file system
+// matching the pattern (see fs.Glob), for example "out/*/index.m3u8".
+// Playlists are returned by the names of the files.
+func LoadGlobFS(fsys fs.FS, pattern string, strict bool) (map[string]Playlist, error) {
+	names, err := fs.Glob(fsys, pattern)
+	if err != nil {
+		return nil, err
+	}
+	playlists := make(map[string]Playlist, len(names))
+	for _, name := range names {
+		p, _, err := LoadFS(fsys, name, strict)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", name, err)
+		}
+		playlists[name] = p
+	}
+	return playlists, nil
+}
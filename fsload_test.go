@@ -0,0 +1,81 @@
+//go:build go1.16
+// +build go1.16
+
+/*
+File system loading tests.
+
+Copyright 2013-2019 The Project Developers.
+See the AUTHORS and LICENSE files at the top-level directory of this distribution
+and at https://github.com/grafov/m3u8/
+
+ॐ तारे तुत्तारे तुरे स्व
+*/
+package m3u8
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+)
+
+const fsMedia = `#EXTM3U
+#EXT-X-TARGETDURATION:4
+#EXTINF:4,
+seg0.ts
+#EXT-X-ENDLIST
+`
+
+func testFS() fstest.MapFS {
+	return fstest.MapFS{
+		"out/master.m3u8": {Data: []byte(`#EXTM3U
+#EXT-X-STREAM-INF:BANDWIDTH=800000
+low/index.m3u8
+#EXT-X-STREAM-INF:BANDWIDTH=2500000
+high/index.m3u8?token=1
+`)},
+		"out/low/index.m3u8":  {Data: []byte(fsMedia)},
+		"out/high/index.m3u8": {Data: []byte(fsMedia)},
+	}
+}
+
+func TestLoadMasterFS(t *testing.T) {
+	m, err := LoadMasterFS(testFS(), "out/master.m3u8", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range m.Variants {
+		if v.Chunklist == nil || v.Chunklist.Count() != 1 || !v.Chunklist.Closed {
+			t.Errorf("variant %s: chunklist not loaded", v.URI)
+		}
+	}
+	if _, err = LoadMasterFS(testFS(), "out/low/index.m3u8", true); err == nil {
+		t.Error("expected error for media playlist")
+	}
+}
+
+func TestLoadGlobFS(t *testing.T) {
+	playlists, err := LoadGlobFS(testFS(), "out/*/index.m3u8", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(playlists) != 2 {
+		t.Fatalf("expected 2 playlists, got %d", len(playlists))
+	}
+	if p, ok := playlists["out/high/index.m3u8"].(*MediaPlaylist); !ok || p.Count() != 1 {
+		t.Errorf("unexpected playlist: %v", playlists["out/high/index.m3u8"])
+	}
+}
+
+func TestFSFetcher(t *testing.T) {
+	f := FSFetcher{testFS()}
+	for _, uri := range []string{"https://example.com/out/master.m3u8", "../etc/passwd", "out/missing.m3u8"} {
+		if _, err := f.Fetch(context.Background(), uri); err == nil {
+			t.Errorf("%s: expected error", uri)
+		}
+	}
+	body, err := f.Fetch(context.Background(), "/out/master.m3u8")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body.Close()
+}
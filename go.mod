@@ -1,5 +1,8 @@
 module github.com/jwplayer/m3u8
 
-go 1.12
+go 1.16
 
-require github.com/stretchr/testify v1.9.0
+require (
+	github.com/stretchr/testify v1.9.0
+	golang.org/x/text v0.3.8
+)
@@ -0,0 +1,149 @@
+package m3u8
+
+/*
+ Part of M3U8 parser & generator library.
+ This file defines generation of I-frame playlists.
+
+ Copyright 2013-2019 The Project Developers.
+ See the AUTHORS and LICENSE files at the top-level directory of this distribution
+ and at https://github.com/grafov/m3u8/
+
+ ॐ तारे तुत्तारे तुरे स्व
+*/
+
+import (
+	"fmt"
+	"time"
+)
+
+// Iframe is the location of an I-frame in the media segment.
+type Iframe struct {
+	Offset int64   // offset of the I-frame in the resource under URI of the segment
+	Length int64   // length of the I-frame in bytes
+	Time   float64 // presentation time of the I-frame relative to the start of the segment in seconds
+}
+
+// IframeLocator returns the I-frames of the media segment in order of
+// presentation, e.g. found by scanning the segment or recorded by the
+// packager.
+type IframeLocator func(seg *MediaSegment) ([]Iframe, error)
+
+// IframePlaylist generates the I-frame playlist (EXT-X-I-FRAMES-ONLY)
+// of the media playlist from the I-frames of its segments returned by
+// the locator. Every I-frame becomes a segment of the I-frame playlist
+// with EXT-X-BYTERANGE of the I-frame in the resource of its media
+// segment. The duration of an I-frame lasts until the next I-frame or
+// the end of the playlist. Keys, maps, discontinuities and program
+// date time of the segments are carried to their first I-frames, so
+// segments without I-frames pass them to the following I-frame. The
+// keys of the segments are written as in the media playlist. The
+// I-frame playlist starts with the media sequence number and the
+// discontinuity sequence number of the media playlist, it is closed
+// if the media playlist is closed and its window is not limited. It
+// returns error of the locator.
+func (p *MediaPlaylist) IframePlaylist(locate IframeLocator) (*MediaPlaylist, error) {
+	out, err := NewMediaPlaylist(0, 0)
+	if err != nil {
+		return nil, err
+	}
+	out.SetIframeOnly()
+	out.SeqNo = p.SeqNo
+	out.DiscontinuitySeq = p.DiscontinuitySeq
+	out.dseqSet = p.dseqSet
+	out.Key = p.Key
+	out.Map = p.Map
+	out.Args = p.Args
+	type iframe struct {
+		Iframe
+		seg           *MediaSegment
+		start         float64 // presentation time in the playlist
+		key           *Key
+		m             *Map
+		discontinuity bool
+		pdt           time.Time
+	}
+	var iframes []iframe
+	var key *Key
+	var m, written *Map
+	var discontinuity bool
+	var pdt time.Time // program date time of the segment
+	var pdtSet bool   // the segment or a skipped one has EXT-X-PROGRAM-DATE-TIME
+	var elapsed float64
+	for _, seg := range p.segments() {
+		list, err := locate(seg)
+		if err != nil {
+			return nil, fmt.Errorf("segment %d: %s", seg.SeqId, err)
+		}
+		if seg.Key != nil {
+			key = seg.Key
+		}
+		if seg.Map != nil {
+			m = seg.Map
+		}
+		discontinuity = discontinuity || seg.Discontinuity
+		if !seg.ProgramDateTime.IsZero() {
+			pdt = seg.ProgramDateTime
+			pdtSet = true
+		}
+		for i, f := range list {
+			iframe := iframe{Iframe: f, seg: seg, start: elapsed + f.Time, key: key}
+			if i == 0 {
+				if m != written {
+					iframe.m = m
+					written = m
+				}
+				iframe.discontinuity = discontinuity
+				discontinuity = false
+				if pdtSet {
+					iframe.pdt = pdt.Add(seconds(f.Time))
+					pdtSet = false
+				}
+			}
+			iframes = append(iframes, iframe)
+		}
+		if !pdt.IsZero() {
+			pdt = pdt.Add(seconds(seg.Duration))
+		}
+		elapsed += seg.Duration
+	}
+	for i, f := range iframes {
+		end := elapsed
+		if i+1 < len(iframes) {
+			end = iframes[i+1].start
+		}
+		seg := &MediaSegment{
+			URI:             f.seg.URI,
+			Args:            f.seg.Args,
+			Duration:        end - f.start,
+			Limit:           f.Length,
+			Offset:          f.Offset,
+			Key:             f.key,
+			Map:             f.m,
+			Discontinuity:   f.discontinuity,
+			ProgramDateTime: f.pdt,
+		}
+		if err = out.AppendSegment(seg); err != nil {
+			return nil, err
+		}
+	}
+	out.Closed = p.Closed && p.winsize == 0
+	version(&out.ver, out.requiredVersion())
+	return out, nil
+}
+
+// AddIframePlaylist appends EXT-X-I-FRAME-STREAM-INF variant with the
+// I-frame playlist (see MediaPlaylist.IframePlaylist) of the parent
+// EXT-X-STREAM-INF variant. Its attributes are taken from the parent
+// as by AddIframeVariants except BANDWIDTH and AVERAGE-BANDWIDTH which
+// are computed from byte ranges of the I-frames. It returns the
+// appended variant. This operation does reset playlist cache.
+func (p *MasterPlaylist) AddIframePlaylist(parent *Variant, uri string, iframes *MediaPlaylist) *Variant {
+	v := &Variant{URI: uri, Chunklist: iframes, VariantParams: iframeParams(parent)}
+	if bw, _ := iframes.PeakBandwidth(nil); bw > 0 {
+		v.Bandwidth = bw
+	}
+	v.AverageBandwidth, _ = iframes.AverageBandwidth(nil)
+	p.Variants = append(p.Variants, v)
+	p.buf.Reset()
+	return v
+}
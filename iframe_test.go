@@ -0,0 +1,88 @@
+/*
+I-frame playlist generation tests.
+
+Copyright 2013-2019 The Project Developers.
+See the AUTHORS and LICENSE files at the top-level directory of this distribution
+and at https://github.com/grafov/m3u8/
+
+ॐ तारे तुत्तारे तुरे स्व
+*/
+package m3u8
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestIframePlaylist(t *testing.T) {
+	p, err := NewMediaPlaylist(0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.SetDefaultMap("init.mp4", 0, 0)
+	for _, uri := range []string{"a.mp4", "b.mp4", "c.mp4"} {
+		if err = p.Append(uri, 4, ""); err != nil {
+			t.Fatal(err)
+		}
+		if uri == "a.mp4" {
+			p.SetProgramDateTime(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+		}
+		if uri == "b.mp4" {
+			p.SetDiscontinuity()
+		}
+	}
+	p.Close()
+	iframes := map[string][]Iframe{
+		"a.mp4": {{Offset: 100, Length: 1000, Time: 0}, {Offset: 5000, Length: 900, Time: 2}},
+		"b.mp4": nil,
+		"c.mp4": {{Offset: 100, Length: 1200, Time: 0.5}},
+	}
+	out, err := p.IframePlaylist(func(seg *MediaSegment) ([]Iframe, error) {
+		return iframes[seg.URI], nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.Count() != 3 || !out.Iframe || !out.Closed {
+		t.Fatalf("unexpected I-frame playlist:\n%s", out)
+	}
+	expected := `#EXTM3U
+#EXT-X-VERSION:5
+#EXT-X-MAP:URI="init.mp4"
+#EXT-X-MEDIA-SEQUENCE:0
+#EXT-X-TARGETDURATION:7
+#EXT-X-I-FRAMES-ONLY
+#EXT-X-PROGRAM-DATE-TIME:2020-01-01T00:00:00Z
+#EXT-X-BYTERANGE:1000@100
+#EXTINF:2.000,
+a.mp4
+#EXT-X-BYTERANGE:900@5000
+#EXTINF:6.500,
+a.mp4
+#EXT-X-DISCONTINUITY
+#EXT-X-BYTERANGE:1200@100
+#EXTINF:3.500,
+c.mp4
+#EXT-X-ENDLIST
+`
+	if s := out.String(); s != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, s)
+	}
+
+	m := NewMasterPlaylist()
+	m.Append("hd.m3u8", p, VariantParams{Bandwidth: 3000000, Codecs: "avc1.640028,mp4a.40.2", Width: 1280, Height: 720})
+	v := m.AddIframePlaylist(m.Variants[0], "hd-iframes.m3u8", out)
+	if !v.Iframe || v.Codecs != "avc1.640028" || v.Width != 1280 || v.Bandwidth != 4000 {
+		t.Errorf("unexpected I-frame variant %+v", v.VariantParams)
+	}
+	if !strings.Contains(m.String(), `#EXT-X-I-FRAME-STREAM-INF:BANDWIDTH=4000,`) {
+		t.Errorf("I-frame variant is not written:\n%s", m)
+	}
+
+	fail := errors.New("no access")
+	if _, err = p.IframePlaylist(func(*MediaSegment) ([]Iframe, error) { return nil, fail }); err == nil {
+		t.Error("expected error of the locator")
+	}
+}
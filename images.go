@@ -0,0 +1,82 @@
+package m3u8
+
+/*
+ Part of M3U8 parser & generator library.
+ This file defines image media playlists of trick play thumbnails.
+
+ Copyright 2013-2019 The Project Developers.
+ See the AUTHORS and LICENSE files at the top-level directory of this distribution
+ and at https://github.com/grafov/m3u8/
+
+ ॐ तारे तुत्तारे तुरे स्व
+*/
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// Tiles represents EXT-X-TILES tag of the segment of the image media
+// playlist (EXT-X-IMAGES-ONLY). The segment is an image of Layout
+// tiles of Resolution each showing the presentation for Duration
+// seconds, see the image media playlist specification by Roku.
+type Tiles struct {
+	Resolution string  // RESOLUTION of a tile, e.g. "320x180"
+	Layout     string  // LAYOUT, columns x rows of tiles, e.g. "5x4"
+	Duration   float64 // DURATION of a tile in seconds
+}
+
+// SetTiles sets EXT-X-TILES of the current media segment and marks
+// the playlist as the image media playlist. This operation does reset
+// playlist cache.
+func (p *MediaPlaylist) SetTiles(tiles *Tiles) error {
+	if p.count == 0 {
+		return errors.New("playlist is empty")
+	}
+	p.ImagesOnly = true
+	p.Segments[p.last()].Tiles = tiles
+	p.buf.Reset()
+	return nil
+}
+
+// decodeTiles parses attributes of EXT-X-TILES tag.
+func decodeTiles(line string, strict bool) (*Tiles, error) {
+	tiles := new(Tiles)
+	for k, v := range decodeParamsLine(line) {
+		switch k {
+		case "RESOLUTION":
+			tiles.Resolution = v
+		case "LAYOUT":
+			tiles.Layout = v
+		case "DURATION":
+			var err error
+			if tiles.Duration, err = strconv.ParseFloat(v, 64); err != nil {
+				return nil, fmt.Errorf("invalid DURATION: %s: %v", v, err)
+			}
+		}
+	}
+	if strict && (tiles.Resolution == "" || tiles.Layout == "") {
+		return nil, errors.New("EXT-X-TILES without RESOLUTION or LAYOUT")
+	}
+	return tiles, nil
+}
+
+// writeTiles writes EXT-X-TILES tag.
+func writeTiles(buf *bytes.Buffer, tiles *Tiles) {
+	buf.WriteString("#EXT-X-TILES:")
+	tilesAttrs(tiles).writeTo(buf, nil)
+	buf.WriteRune('\n')
+}
+
+// tilesAttrs returns attributes of EXT-X-TILES tag.
+func tilesAttrs(tiles *Tiles) attrList {
+	var attrs attrList
+	attrs.add("RESOLUTION", tiles.Resolution)
+	attrs.add("LAYOUT", tiles.Layout)
+	if tiles.Duration > 0 {
+		attrs.add("DURATION", strconv.FormatFloat(tiles.Duration, 'f', -1, 64))
+	}
+	return attrs
+}
@@ -0,0 +1,85 @@
+/*
+Image media playlists tests.
+
+Copyright 2013-2019 The Project Developers.
+See the AUTHORS and LICENSE files at the top-level directory of this distribution
+and at https://github.com/grafov/m3u8/
+
+ॐ तारे तुत्तारे तुरे स्व
+*/
+package m3u8
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestImageMasterPlaylist(t *testing.T) {
+	const src = `#EXTM3U
+#EXT-X-VERSION:3
+#EXT-X-STREAM-INF:BANDWIDTH=1280000,RESOLUTION=1280x720
+video.m3u8
+#EXT-X-IMAGE-STREAM-INF:BANDWIDTH=12000,CODECS="jpeg",RESOLUTION=320x180,URI="thumbs.m3u8"
+`
+	p := NewMasterPlaylist()
+	if err := p.DecodeFrom(bytes.NewBufferString(src), true); err != nil {
+		t.Fatal(err)
+	}
+	if len(p.Variants) != 2 {
+		t.Fatalf("expected 2 variants, got %d", len(p.Variants))
+	}
+	v := p.Variants[1]
+	if !v.Images || v.Iframe || v.URI != "thumbs.m3u8" || v.Resolution != "320x180" || v.Codecs != "jpeg" {
+		t.Errorf("unexpected image variant: %+v", v.VariantParams)
+	}
+	if out := p.String(); out != src {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+	if sel := p.SelectVariant(VariantConstraints{}); sel != p.Variants[0] {
+		t.Errorf("image variant must not be selected: %+v", sel)
+	}
+}
+
+func TestImageMediaPlaylist(t *testing.T) {
+	p, err := NewMediaPlaylist(2, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = p.Append("thumbs-0.jpg", 20, ""); err != nil {
+		t.Fatal(err)
+	}
+	if err = p.SetTiles(&Tiles{Resolution: "320x180", Layout: "5x4", Duration: 1}); err != nil {
+		t.Fatal(err)
+	}
+	p.Close()
+	const expected = `#EXTM3U
+#EXT-X-VERSION:3
+#EXT-X-MEDIA-SEQUENCE:0
+#EXT-X-TARGETDURATION:20
+#EXT-X-IMAGES-ONLY
+#EXT-X-TILES:RESOLUTION=320x180,LAYOUT=5x4,DURATION=1
+#EXTINF:20.000,
+thumbs-0.jpg
+#EXT-X-ENDLIST
+`
+	out := p.String()
+	if out != expected {
+		t.Fatalf("unexpected output:\n%s", out)
+	}
+	q, err := NewMediaPlaylist(0, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = q.DecodeFrom(bytes.NewBufferString(out), true); err != nil {
+		t.Fatal(err)
+	}
+	if !q.ImagesOnly {
+		t.Error("EXT-X-IMAGES-ONLY is not decoded")
+	}
+	if tiles := q.Segments[0].Tiles; tiles == nil || *tiles != (Tiles{"320x180", "5x4", 1}) {
+		t.Errorf("unexpected tiles: %+v", tiles)
+	}
+	if q.String() != expected {
+		t.Errorf("unexpected output:\n%s", q.String())
+	}
+}
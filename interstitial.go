@@ -0,0 +1,149 @@
+package m3u8
+
+/*
+ Part of M3U8 parser & generator library.
+ This file defines insertion of interstitials to media playlists.
+
+ Copyright 2013-2019 The Project Developers.
+ See the AUTHORS and LICENSE files at the top-level directory of this distribution
+ and at https://github.com/grafov/m3u8/
+
+ ॐ तारे तुत्तारे तुरे स्व
+*/
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// InterstitialClass is CLASS of interstitial dateranges (HLS
+// Interstitials, Appendix D of rfc8216bis).
+const InterstitialClass = "com.apple.hls.interstitial"
+
+// InterstitialOptions are optional attributes of the interstitial
+// inserted with InsertInterstitial. Zero values are not written.
+type InterstitialOptions struct {
+	Duration         float64  // DURATION of the interstitial
+	PlannedDuration  float64  // PLANNED-DURATION when DURATION is not known yet
+	ResumeOffset     float64  // X-RESUME-OFFSET, primary content skipped by the interstitial
+	PlayoutLimit     float64  // X-PLAYOUT-LIMIT
+	Snap             []string // X-SNAP, SnapIn and SnapOut
+	Restrict         []string // X-RESTRICT, RestrictSkip and RestrictJump
+	Cue              []string // CUE, CuePre or CuePost and CueOnce
+	TimelineOccupies string   // X-TIMELINE-OCCUPIES, TimelineOccupiesPoint or TimelineOccupiesRange
+	TimelineStyle    string   // X-TIMELINE-STYLE, TimelineStyleHighlight or TimelineStylePrimary
+	ContentMayVary   string   // X-CONTENT-MAY-VARY, YES or NO
+}
+
+// IsInterstitial reports whether the daterange is an interstitial.
+func (dr *DateRange) IsInterstitial() bool {
+	return dr.Class == InterstitialClass
+}
+
+// ValidateInterstitial returns error if the interstitial daterange
+// lacks ID or START-DATE, has none or both of X-ASSET-URI and
+// X-ASSET-LIST or has invalid values of enumerated attributes.
+func (dr *DateRange) ValidateInterstitial() error {
+	if !dr.IsInterstitial() {
+		return fmt.Errorf("daterange %q is not an interstitial", dr.ID)
+	}
+	if dr.ID == "" || dr.StartDate.IsZero() {
+		return errors.New("interstitial without ID or START-DATE")
+	}
+	if (dr.XAssetURI == "") == (dr.XAssetList == "") {
+		return fmt.Errorf("interstitial %q must have either X-ASSET-URI or X-ASSET-LIST", dr.ID)
+	}
+	if err := checkEnumList("X-SNAP", dr.SnapList(), SnapIn, SnapOut); err != nil {
+		return err
+	}
+	if err := checkEnumList("X-RESTRICT", dr.RestrictList(), RestrictSkip, RestrictJump); err != nil {
+		return err
+	}
+	if err := checkCue(dr.CueList()); err != nil {
+		return err
+	}
+	if dr.XTimelineOccupies != "" {
+		if err := checkEnumList("X-TIMELINE-OCCUPIES", []string{dr.XTimelineOccupies}, TimelineOccupiesPoint, TimelineOccupiesRange); err != nil {
+			return err
+		}
+	}
+	if dr.XTimelineStyle != "" {
+		if err := checkEnumList("X-TIMELINE-STYLE", []string{dr.XTimelineStyle}, TimelineStyleHighlight, TimelineStylePrimary); err != nil {
+			return err
+		}
+	}
+	if dr.XContentMayVary != "" {
+		if err := checkEnumList("X-CONTENT-MAY-VARY", []string{dr.XContentMayVary}, "YES", "NO"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// InsertInterstitial creates the interstitial daterange with the ID
+// starting at the time and playing either the asset (X-ASSET-URI) or
+// the assets of the asset list (X-ASSET-LIST), and attaches it to the
+// segment playing at the time according to EXT-X-PROGRAM-DATE-TIME
+// (the first segment with known time for earlier times, the last
+// segment for later times). It returns error if the playlist has no
+// segments with EXT-X-PROGRAM-DATE-TIME, the ID is used by another
+// daterange of the playlist or the interstitial is invalid (see
+// ValidateInterstitial). This operation does reset playlist cache.
+func (p *MediaPlaylist) InsertInterstitial(id string, start time.Time, assetURI, assetList string, opts InterstitialOptions) (*DateRange, error) {
+	dr := &DateRange{
+		ID:                id,
+		Class:             InterstitialClass,
+		StartDate:         start,
+		Duration:          opts.Duration,
+		PlannedDuration:   opts.PlannedDuration,
+		XAssetURI:         assetURI,
+		XAssetList:        assetList,
+		XResumeOfsset:     opts.ResumeOffset,
+		XPlayoutLimit:     opts.PlayoutLimit,
+		XTimelineOccupies: opts.TimelineOccupies,
+		XTimelineStyle:    opts.TimelineStyle,
+		XContentMayVary:   opts.ContentMayVary,
+	}
+	if err := dr.SetXSnap(opts.Snap...); err != nil {
+		return nil, err
+	}
+	if err := dr.SetXRestrict(opts.Restrict...); err != nil {
+		return nil, err
+	}
+	if err := dr.SetCue(opts.Cue...); err != nil {
+		return nil, err
+	}
+	if err := dr.ValidateInterstitial(); err != nil {
+		return nil, err
+	}
+	var (
+		target *MediaSegment
+		used   bool
+		base   time.Time
+		offset float64
+		baseAt float64
+	)
+	p.eachSegment(func(seg *MediaSegment) {
+		for _, other := range seg.DateRange {
+			used = used || other.ID == id
+		}
+		if !seg.ProgramDateTime.IsZero() {
+			base, baseAt = seg.ProgramDateTime, offset
+		}
+		at := base.Add(time.Duration((offset - baseAt) * float64(time.Second)))
+		offset += seg.Duration
+		if !base.IsZero() && (target == nil || !at.After(start)) {
+			target = seg
+		}
+	})
+	if used {
+		return nil, fmt.Errorf("daterange %q already exists", id)
+	}
+	if target == nil {
+		return nil, errors.New("playlist has no segments with EXT-X-PROGRAM-DATE-TIME")
+	}
+	target.DateRange = append(target.DateRange, dr)
+	p.buf.Reset()
+	return dr, nil
+}
@@ -0,0 +1,70 @@
+/*
+Interstitials tests.
+
+Copyright 2013-2019 The Project Developers.
+See the AUTHORS and LICENSE files at the top-level directory of this distribution
+and at https://github.com/grafov/m3u8/
+
+ॐ तारे तुत्तारे तुरे स्व
+*/
+package m3u8
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestInsertInterstitial(t *testing.T) {
+	p, err := NewMediaPlaylist(5, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 4; i++ {
+		_ = p.Append("seg.ts", 6, "")
+	}
+	if _, err = p.InsertInterstitial("ad1", start, "ad.m3u8", "", InterstitialOptions{}); err == nil {
+		t.Error("expected error for playlist without program date time")
+	}
+	p.Segments[0].ProgramDateTime = start
+
+	dr, err := p.InsertInterstitial("ad1", start.Add(13*time.Second), "https://ads.example.com/ad1.m3u8", "", InterstitialOptions{
+		Duration:       15,
+		ResumeOffset:   15,
+		Snap:           []string{SnapOut},
+		Restrict:       []string{RestrictSkip, RestrictJump},
+		ContentMayVary: "NO",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p.Segments[2].DateRange) != 1 || p.Segments[2].DateRange[0] != dr || !dr.IsInterstitial() {
+		t.Fatalf("interstitial is not attached to the third segment: %+v", p.Segments[2].DateRange)
+	}
+	expected := `#EXT-X-DATERANGE:ID="ad1",CLASS="com.apple.hls.interstitial",START-DATE="2024-05-01T12:00:13Z",DURATION=15,X-RESUME-OFFSET=15,X-SNAP="OUT",X-RESTRICT="SKIP,JUMP",X-ASSET-URI="https://ads.example.com/ad1.m3u8",X-CONTENT-MAY-VARY="NO"` + "\n"
+	if out := p.String(); !strings.Contains(out, expected) {
+		t.Errorf("expected %s in output:\n%s", expected, out)
+	}
+
+	if _, err = p.InsertInterstitial("pre", start.Add(-time.Minute), "", "list.json", InterstitialOptions{}); err != nil || len(p.Segments[0].DateRange) != 1 {
+		t.Errorf("preroll is not attached to the first segment: %v", err)
+	}
+	if _, err = p.InsertInterstitial("post", start.Add(time.Hour), "", "list.json", InterstitialOptions{}); err != nil || len(p.Segments[3].DateRange) != 1 {
+		t.Errorf("postroll is not attached to the last segment: %v", err)
+	}
+	for _, c := range []struct {
+		name, id, uri, list string
+		opts                InterstitialOptions
+	}{
+		{"duplicate ID", "ad1", "a.m3u8", "", InterstitialOptions{}},
+		{"both assets", "ad2", "a.m3u8", "list.json", InterstitialOptions{}},
+		{"no asset", "ad2", "", "", InterstitialOptions{}},
+		{"invalid snap", "ad2", "a.m3u8", "", InterstitialOptions{Snap: []string{"BOTH"}}},
+		{"invalid style", "ad2", "a.m3u8", "", InterstitialOptions{TimelineStyle: "BOLD"}},
+	} {
+		if _, err = p.InsertInterstitial(c.id, start, c.uri, c.list, c.opts); err == nil {
+			t.Errorf("%s: expected error", c.name)
+		}
+	}
+}
@@ -0,0 +1,223 @@
+package m3u8
+
+/*
+ Part of M3U8 parser & generator library.
+ This file defines journaling of live media playlist mutations.
+
+ Copyright 2013-2019 The Project Developers.
+ See the AUTHORS and LICENSE files at the top-level directory of this distribution
+ and at https://github.com/grafov/m3u8/
+
+ ॐ तारे तुत्तारे तुरे स्व
+*/
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// Journal applies mutations to the media playlist and records them to
+// an append-only log, so a live origin can recover the playlist state
+// after a restart with ReplayJournal. Each record is a line of JSON.
+// Custom tags of segments are not journaled. Journal is not safe for
+// concurrent use.
+type Journal struct {
+	p   *MediaPlaylist
+	enc *json.Encoder
+}
+
+// journalRecord is a record of the journal.
+type journalRecord struct {
+	Op      string         `json:"op"`
+	Header  *journalHeader `json:"header,omitempty"`
+	Segment *MediaSegment  `json:"segment,omitempty"`
+	Key     *Key           `json:"key,omitempty"`
+}
+
+// journalHeader is the playlist state recorded at the start of the
+// journal.
+type journalHeader struct {
+	WinSize          uint
+	Capacity         uint
+	TargetDuration   float64
+	SeqNo            uint64
+	DiscontinuitySeq uint64
+	ManualDSeq       bool
+	MediaType        MediaType
+	Closed           bool
+	Key              *Key
+	Map              *Map
+	Ver              uint8
+}
+
+// Journal operations.
+const (
+	journalInit   = "init"
+	journalAppend = "append"
+	journalSlide  = "slide"
+	journalRemove = "remove"
+	journalKey    = "key"
+	journalClose  = "close"
+)
+
+// NewJournal returns the journal of the playlist writing records to
+// the writer. The current state of the playlist (header and segments)
+// is recorded first.
+func NewJournal(p *MediaPlaylist, w io.Writer) (*Journal, error) {
+	j := &Journal{p: p, enc: json.NewEncoder(w)}
+	capacity := p.capacity
+	if p.unbounded {
+		capacity = 0 // replayed as unbounded playlist
+	}
+	header := &journalHeader{
+		WinSize:          p.winsize,
+		Capacity:         capacity,
+		TargetDuration:   p.TargetDuration,
+		SeqNo:            p.SeqNo,
+		DiscontinuitySeq: p.DiscontinuitySeq,
+		ManualDSeq:       p.manualDSeq,
+		MediaType:        p.MediaType,
+		Key:              p.Key,
+		Map:              p.Map,
+		Ver:              p.ver,
+	}
+	if err := j.enc.Encode(journalRecord{Op: journalInit, Header: header}); err != nil {
+		return nil, err
+	}
+	var err error
+	p.eachSegment(func(seg *MediaSegment) {
+		if err == nil {
+			err = j.record(journalAppend, seg, nil)
+		}
+	})
+	if err == nil && p.Closed {
+		err = j.record(journalClose, nil, nil)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return j, nil
+}
+
+// Playlist returns the journaled playlist.
+func (j *Journal) Playlist() *MediaPlaylist {
+	return j.p
+}
+
+// AppendSegment appends the segment to the playlist and records it.
+func (j *Journal) AppendSegment(seg *MediaSegment) error {
+	if err := j.p.AppendSegment(seg); err != nil {
+		return err
+	}
+	return j.record(journalAppend, seg, nil)
+}
+
+// SlideSegment slides the playlist with the segment (see
+// MediaPlaylist.SlideSegment) and records it.
+func (j *Journal) SlideSegment(seg *MediaSegment) error {
+	if err := j.p.SlideSegment(seg); err != nil {
+		return err
+	}
+	return j.record(journalSlide, seg, nil)
+}
+
+// Remove removes the first segment of the playlist and records it.
+func (j *Journal) Remove() error {
+	if err := j.p.Remove(); err != nil {
+		return err
+	}
+	return j.record(journalRemove, nil, nil)
+}
+
+// SetKey sets the key of the last segment of the playlist (see
+// MediaPlaylist.SetKey) and records it.
+func (j *Journal) SetKey(method, uri, iv, keyformat, keyformatversions string) error {
+	if err := j.p.SetKey(method, uri, iv, keyformat, keyformatversions); err != nil {
+		return err
+	}
+	return j.record(journalKey, nil, j.p.Segments[j.p.last()].Key)
+}
+
+// Close closes the playlist (see MediaPlaylist.Close) and records it.
+func (j *Journal) Close() error {
+	j.p.Close()
+	return j.record(journalClose, nil, nil)
+}
+
+// record writes the record of the operation.
+func (j *Journal) record(op string, seg *MediaSegment, key *Key) error {
+	if seg != nil && seg.Custom != nil {
+		s := *seg
+		s.Custom = nil
+		seg = &s
+	}
+	return j.enc.Encode(journalRecord{Op: op, Segment: seg, Key: key})
+}
+
+// ReplayJournal restores the media playlist from the journal written
+// by Journal. A truncated last record (e.g. after a crash in the
+// middle of writing) is ignored.
+func ReplayJournal(r io.Reader) (*MediaPlaylist, error) {
+	dec := json.NewDecoder(r)
+	var p *MediaPlaylist
+	for n := 1; ; n++ {
+		var rec journalRecord
+		err := dec.Decode(&rec)
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("journal record %d: %s", n, err)
+		}
+		if p == nil && rec.Op != journalInit {
+			return nil, fmt.Errorf("journal record %d: journal must start with %s record", n, journalInit)
+		}
+		switch rec.Op {
+		case journalInit:
+			if p != nil || rec.Header == nil {
+				return nil, fmt.Errorf("journal record %d: unexpected %s record", n, journalInit)
+			}
+			h := rec.Header
+			if p, err = NewMediaPlaylist(h.WinSize, h.Capacity); err != nil {
+				return nil, err
+			}
+			p.TargetDuration = h.TargetDuration
+			p.SeqNo = h.SeqNo
+			p.DiscontinuitySeq = h.DiscontinuitySeq
+			p.manualDSeq = h.ManualDSeq
+			p.MediaType = h.MediaType
+			p.Key = h.Key
+			p.Map = h.Map
+			p.ver = h.Ver
+		case journalAppend, journalSlide:
+			if rec.Segment == nil {
+				return nil, fmt.Errorf("journal record %d: %s without segment", n, rec.Op)
+			}
+			if rec.Op == journalAppend {
+				err = p.AppendSegment(rec.Segment)
+			} else {
+				err = p.SlideSegment(rec.Segment)
+			}
+		case journalRemove:
+			err = p.Remove()
+		case journalKey:
+			if rec.Key == nil {
+				return nil, fmt.Errorf("journal record %d: %s without key", n, rec.Op)
+			}
+			k := rec.Key
+			err = p.SetKey(k.Method, k.URI, k.IV, k.Keyformat, k.Keyformatversions)
+		case journalClose:
+			p.Close()
+		default:
+			return nil, fmt.Errorf("journal record %d: unknown operation %q", n, rec.Op)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("journal record %d: %s", n, err)
+		}
+	}
+	if p == nil {
+		return nil, ErrPlaylistEmpty
+	}
+	return p, nil
+}
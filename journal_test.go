@@ -0,0 +1,79 @@
+/*
+Playlist journal tests.
+
+Copyright 2013-2019 The Project Developers.
+See the AUTHORS and LICENSE files at the top-level directory of this distribution
+and at https://github.com/grafov/m3u8/
+
+ॐ तारे तुत्तारे तुरे स्व
+*/
+package m3u8
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestJournalReplay(t *testing.T) {
+	p, err := NewMediaPlaylist(3, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.SetDefaultKey("AES-128", "k0", "", "", "")
+	p.Append("old.ts", 6, "")
+
+	var log bytes.Buffer
+	j, err := NewJournal(p, &log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 6; i++ {
+		seg := &MediaSegment{URI: fmt.Sprintf("seg%d.ts", i), Duration: 6, ProgramDateTime: start.Add(time.Duration(i*6) * time.Second)}
+		if i == 3 {
+			seg.Discontinuity = true
+		}
+		if err = j.SlideSegment(seg); err != nil {
+			t.Fatal(err)
+		}
+		if i == 4 {
+			if err = j.SetKey("AES-128", "k1", "", "", ""); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err = j.Remove(); err != nil {
+		t.Fatal(err)
+	}
+	if err = j.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	restored, err := ReplayJournal(bytes.NewReader(log.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restored.String() != p.String() {
+		t.Errorf("restored playlist differs:\n%s\nexpected:\n%s", restored.String(), p.String())
+	}
+	if restored.SeqNo != p.SeqNo || restored.DiscontinuitySeq != p.DiscontinuitySeq {
+		t.Errorf("unexpected sequences %d/%d", restored.SeqNo, restored.DiscontinuitySeq)
+	}
+
+	// crash in the middle of the last record
+	truncated := log.Bytes()[:log.Len()-5]
+	restored, err = ReplayJournal(bytes.NewReader(truncated))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restored.Closed || restored.Count() != p.Count() {
+		t.Errorf("unexpected playlist restored from truncated journal:\n%s", restored.String())
+	}
+
+	if _, err = ReplayJournal(strings.NewReader(`{"op":"append","segment":{"URI":"a.ts"}}`)); err == nil {
+		t.Error("expected error for journal without init record")
+	}
+}
@@ -0,0 +1,110 @@
+package m3u8
+
+/*
+ Part of M3U8 parser & generator library.
+ This file defines inspection of encryption keys of media playlists.
+
+ Copyright 2013-2019 The Project Developers.
+ See the AUTHORS and LICENSE files at the top-level directory of this distribution
+ and at https://github.com/grafov/m3u8/
+
+ ॐ तारे तुत्तारे तुरे स्व
+*/
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// KeyPeriod is the range of segments of the media playlist encrypted
+// with the same key.
+type KeyPeriod struct {
+	Key        *Key // effective EXT-X-KEY, nil for unencrypted segments without any key
+	FirstSeqId uint64
+	LastSeqId  uint64
+	Segments   int
+}
+
+// EffectiveKey returns the EXT-X-KEY applied to the segment with the
+// sequence number: the last key declared at or before the segment or
+// the default key of the playlist. It returns false if there is no
+// such segment in the playlist. The key is nil for unencrypted
+// segments.
+func (p *MediaPlaylist) EffectiveKey(seqID uint64) (*Key, bool) {
+	seg, ok := p.GetSegment(seqID)
+	if !ok {
+		return nil, false
+	}
+	return p.segmentKey(seg), true
+}
+
+// KeyPeriods summarizes the key rotation of the media playlist: it
+// returns consecutive ranges of segments sharing the same effective
+// key (see EffectiveKey) in order of the playlist. Keys with equal
+// attributes are treated as the same key, so a repeated EXT-X-KEY
+// doesn't start a new period. Keys without IV use the sequence number
+// of each segment as IV (section 5.2).
+func (p *MediaPlaylist) KeyPeriods() []KeyPeriod {
+	var periods []KeyPeriod
+	key := p.Key
+	p.eachSegment(func(seg *MediaSegment) {
+		if seg.Key != nil {
+			key = seg.Key
+		}
+		if n := len(periods); n > 0 && sameKey(periods[n-1].Key, key) {
+			periods[n-1].LastSeqId = seg.SeqId
+			periods[n-1].Segments++
+			return
+		}
+		periods = append(periods, KeyPeriod{Key: key, FirstSeqId: seg.SeqId, LastSeqId: seg.SeqId, Segments: 1})
+	})
+	return periods
+}
+
+// KeyformatVersions parses KEYFORMATVERSIONS of the key, the
+// slash-separated list of versions. Keys without the attribute
+// support version 1 (section 4.4.4.4). The original string is kept in
+// Keyformatversions.
+func (k *Key) KeyformatVersions() ([]int, error) {
+	if k.Keyformatversions == "" {
+		return []int{1}, nil
+	}
+	parts := strings.Split(k.Keyformatversions, "/")
+	versions := make([]int, len(parts))
+	for i, s := range parts {
+		v, err := strconv.Atoi(strings.TrimSpace(s))
+		if err != nil || v <= 0 {
+			return nil, fmt.Errorf("invalid KEYFORMATVERSIONS: %s", k.Keyformatversions)
+		}
+		versions[i] = v
+	}
+	return versions, nil
+}
+
+// SupportsKeyformatVersion reports whether the version is listed in
+// KEYFORMATVERSIONS of the key. Keys with invalid KEYFORMATVERSIONS
+// support no version.
+func (k *Key) SupportsKeyformatVersion(version int) bool {
+	versions, err := k.KeyformatVersions()
+	if err != nil {
+		return false
+	}
+	for _, v := range versions {
+		if v == version {
+			return true
+		}
+	}
+	return false
+}
+
+// SetKeyformatVersions sets KEYFORMATVERSIONS of the key to the
+// slash-separated list of the versions. Empty list removes the
+// attribute.
+func (k *Key) SetKeyformatVersions(versions ...int) {
+	parts := make([]string, len(versions))
+	for i, v := range versions {
+		parts[i] = strconv.Itoa(v)
+	}
+	k.Keyformatversions = strings.Join(parts, "/")
+}
@@ -0,0 +1,103 @@
+/*
+Key inspection tests.
+
+Copyright 2013-2019 The Project Developers.
+See the AUTHORS and LICENSE files at the top-level directory of this distribution
+and at https://github.com/grafov/m3u8/
+
+ॐ तारे तुत्तारे तुरे स्व
+*/
+package m3u8
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestKeyPeriods(t *testing.T) {
+	src := `#EXTM3U
+#EXT-X-TARGETDURATION:10
+#EXT-X-MEDIA-SEQUENCE:10
+#EXT-X-KEY:METHOD=NONE
+#EXTINF:10,
+clear.ts
+#EXT-X-KEY:METHOD=AES-128,URI="k1",IV=0x00000000000000000000000000000001
+#EXTINF:10,
+a.ts
+#EXT-X-KEY:METHOD=AES-128,URI="k1",IV=0x00000000000000000000000000000001
+#EXTINF:10,
+b.ts
+#EXT-X-KEY:METHOD=AES-128,URI="k2"
+#EXTINF:10,
+c.ts
+#EXTINF:10,
+d.ts
+`
+	pl, _, err := DecodeFrom(strings.NewReader(src), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := pl.(*MediaPlaylist)
+	periods := p.KeyPeriods()
+	expected := []struct {
+		uri         string
+		first, last uint64
+	}{
+		{"NONE", 10, 10},
+		{"k1", 11, 12},
+		{"k2", 13, 14},
+	}
+	if len(periods) != len(expected) {
+		t.Fatalf("expected %d periods, got %+v", len(expected), periods)
+	}
+	for i, e := range expected {
+		period := periods[i]
+		uri := period.Key.URI
+		if uri == "" {
+			uri = period.Key.Method
+		}
+		if uri != e.uri || period.FirstSeqId != e.first || period.LastSeqId != e.last || period.Segments != int(e.last-e.first+1) {
+			t.Errorf("period %d: unexpected %+v", i, period)
+		}
+	}
+
+	if key, ok := p.EffectiveKey(14); !ok || key.URI != "k2" {
+		t.Errorf("unexpected effective key %+v", key)
+	}
+	if key, ok := p.EffectiveKey(10); !ok || key.Method != "NONE" {
+		t.Errorf("expected no encryption of the first segment, got %+v", key)
+	}
+	if _, ok := p.EffectiveKey(20); ok {
+		t.Error("expected no segment 20")
+	}
+}
+
+func TestKeyformatVersions(t *testing.T) {
+	key := &Key{Method: "SAMPLE-AES", URI: "skd://key", Keyformat: "com.apple.streamingkeydelivery", Keyformatversions: "1/2/5"}
+	versions, err := key.KeyformatVersions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions) != 3 || versions[0] != 1 || versions[1] != 2 || versions[2] != 5 {
+		t.Errorf("unexpected versions: %v", versions)
+	}
+	if !key.SupportsKeyformatVersion(5) || key.SupportsKeyformatVersion(3) {
+		t.Error("unexpected support of versions 5 and 3")
+	}
+
+	if !(&Key{Method: "AES-128"}).SupportsKeyformatVersion(1) {
+		t.Error("key without KEYFORMATVERSIONS must support version 1")
+	}
+	bad := &Key{Method: "AES-128", Keyformatversions: "1/x"}
+	if _, err := bad.KeyformatVersions(); err == nil {
+		t.Error("expected error for invalid KEYFORMATVERSIONS")
+	}
+	if bad.SupportsKeyformatVersion(1) {
+		t.Error("key with invalid KEYFORMATVERSIONS must support no version")
+	}
+
+	key.SetKeyformatVersions(1, 3)
+	if key.Keyformatversions != "1/3" {
+		t.Errorf("unexpected KEYFORMATVERSIONS: %s", key.Keyformatversions)
+	}
+}
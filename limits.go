@@ -0,0 +1,119 @@
+package m3u8
+
+/*
+ Part of M3U8 parser & generator library.
+ This file defines resource limits of decoding.
+
+ Copyright 2013-2019 The Project Developers.
+ See the AUTHORS and LICENSE files at the top-level directory of this distribution
+ and at https://github.com/grafov/m3u8/
+
+ ॐ तारे तुत्तारे तुरे स्व
+*/
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Limits restricts resources consumed by decoding of untrusted
+// playlists. Zero value of a limit means no limit. Limits are
+// enforced regardless of strict mode, decoding stops with LimitError
+// when a limit is exceeded.
+type Limits struct {
+	MaxSize       int64 // bytes of the input read by DecodeWithOptions
+	MaxLineLength int   // bytes of a line
+	MaxSegments   int   // URI lines of a media playlist
+	MaxVariants   int   // URI lines of a master playlist
+	MaxDateRanges int   // EXT-X-DATERANGE tags
+	MaxCustomTags int   // tags decoded by custom decoders
+}
+
+// LimitError is returned by decoder when the input exceeds a limit.
+type LimitError struct {
+	Limit string // name of the Limits field
+	Max   int64
+	Line  int // number of the line exceeding the limit, zero for MaxSize
+}
+
+func (e *LimitError) Error() string {
+	if e.Line == 0 {
+		return fmt.Sprintf("decode limit %s=%d exceeded", e.Limit, e.Max)
+	}
+	return fmt.Sprintf("line %d: decode limit %s=%d exceeded", e.Line, e.Limit, e.Max)
+}
+
+// limiter counts decoded items against the limits.
+type limiter struct {
+	Limits
+	decoders   []CustomDecoder
+	segments   int
+	variants   int
+	dateranges int
+	custom     int
+}
+
+// newLimiter returns the limiter or nil if there are no limits.
+func newLimiter(limits Limits, decoders []CustomDecoder) *limiter {
+	if limits == (Limits{}) {
+		return nil
+	}
+	return &limiter{Limits: limits, decoders: decoders}
+}
+
+// check accounts the line to be decoded and returns error if it
+// exceeds a limit.
+func (l *limiter) check(state *decodingState, line string) error {
+	if l == nil {
+		return nil
+	}
+	exceeded := func(limit string, max int) error {
+		return &LimitError{Limit: limit, Max: int64(max), Line: state.lineNo}
+	}
+	if l.MaxLineLength > 0 && len(strings.TrimRight(line, "\r\n")) > l.MaxLineLength {
+		return exceeded("MaxLineLength", l.MaxLineLength)
+	}
+	line = strings.TrimSpace(line)
+	switch {
+	case line == "":
+	case !strings.HasPrefix(line, "#"):
+		if state.listType == MASTER {
+			if l.variants++; l.MaxVariants > 0 && l.variants > l.MaxVariants {
+				return exceeded("MaxVariants", l.MaxVariants)
+			}
+		} else if l.segments++; l.MaxSegments > 0 && l.segments > l.MaxSegments {
+			return exceeded("MaxSegments", l.MaxSegments)
+		}
+	case strings.HasPrefix(line, "#EXT-X-DATERANGE:"):
+		if l.dateranges++; l.MaxDateRanges > 0 && l.dateranges > l.MaxDateRanges {
+			return exceeded("MaxDateRanges", l.MaxDateRanges)
+		}
+	default:
+		for _, d := range l.decoders {
+			if strings.HasPrefix(line, d.TagName()) {
+				if l.custom++; l.MaxCustomTags > 0 && l.custom > l.MaxCustomTags {
+					return exceeded("MaxCustomTags", l.MaxCustomTags)
+				}
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// readInput reads the input into the buffer respecting MaxSize limit.
+func readInput(reader io.Reader, limits Limits) (*bytes.Buffer, error) {
+	buf := new(bytes.Buffer)
+	if limits.MaxSize > 0 {
+		reader = io.LimitReader(reader, limits.MaxSize+1)
+	}
+	if _, err := buf.ReadFrom(reader); err != nil {
+		return nil, err
+	}
+	if limits.MaxSize > 0 && int64(buf.Len()) > limits.MaxSize {
+		return nil, &LimitError{Limit: "MaxSize", Max: limits.MaxSize}
+	}
+	return buf, nil
+}
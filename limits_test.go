@@ -0,0 +1,84 @@
+/*
+Decode limits tests.
+
+Copyright 2013-2019 The Project Developers.
+See the AUTHORS and LICENSE files at the top-level directory of this distribution
+and at https://github.com/grafov/m3u8/
+
+ॐ तारे तुत्तारे तुरे स्व
+*/
+package m3u8
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDecodeLimits(t *testing.T) {
+	media := `#EXTM3U
+#EXT-X-TARGETDURATION:10
+#EXT-X-DATERANGE:ID="a",START-DATE="2020-01-01T00:00:00Z"
+#EXTINF:10,
+a.ts
+#EXT-X-DATERANGE:ID="b",START-DATE="2020-01-01T00:00:10Z"
+#EXTINF:10,
+b.ts
+#EXTINF:10,
+c.ts
+`
+	master := `#EXTM3U
+#EXT-X-STREAM-INF:BANDWIDTH=100
+low.m3u8
+#EXT-X-STREAM-INF:BANDWIDTH=200
+high.m3u8
+`
+	tests := []struct {
+		src    string
+		limits Limits
+		limit  string
+		line   int
+	}{
+		{media, Limits{MaxSegments: 3}, "", 0},
+		{media, Limits{MaxSegments: 2}, "MaxSegments", 10},
+		{media, Limits{MaxDateRanges: 1}, "MaxDateRanges", 6},
+		{media, Limits{MaxLineLength: 40}, "MaxLineLength", 3},
+		{media, Limits{MaxSize: 50}, "MaxSize", 0},
+		{master, Limits{MaxVariants: 2, MaxSegments: 1}, "", 0},
+		{master, Limits{MaxVariants: 1}, "MaxVariants", 5},
+	}
+	for i, tt := range tests {
+		_, _, err := DecodeWithOptions(strings.NewReader(tt.src), DecodeOptions{Limits: tt.limits})
+		if tt.limit == "" {
+			if err != nil {
+				t.Errorf("case %d: unexpected error: %v", i, err)
+			}
+			continue
+		}
+		le, ok := err.(*LimitError)
+		if !ok {
+			t.Errorf("case %d: expected LimitError, got %v", i, err)
+			continue
+		}
+		if le.Limit != tt.limit || le.Line != tt.line {
+			t.Errorf("case %d: expected %s at line %d, got %v", i, tt.limit, tt.line, le)
+		}
+	}
+
+	p, err := NewMediaPlaylist(3, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = p.DecodeWithOptions(strings.NewReader(media), DecodeOptions{Limits: Limits{MaxSegments: 1}})
+	if le, ok := err.(*LimitError); !ok || le.Limit != "MaxSegments" {
+		t.Errorf("expected MaxSegments error, got %v", err)
+	}
+}
+
+func TestDecodeLimitsCustomTags(t *testing.T) {
+	src := "#EXTM3U\n#CUSTOM-PLAYLIST-TAG:1\n#EXT-X-TARGETDURATION:10\n#CUSTOM-PLAYLIST-TAG:2\n#EXTINF:10,\na.ts\n"
+	opts := DecodeOptions{CustomDecoders: []CustomDecoder{&MockCustomTag{name: "#CUSTOM-PLAYLIST-TAG:"}}, Limits: Limits{MaxCustomTags: 1}}
+	_, _, err := DecodeWithOptions(strings.NewReader(src), opts)
+	if le, ok := err.(*LimitError); !ok || le.Limit != "MaxCustomTags" || le.Line != 4 {
+		t.Errorf("expected MaxCustomTags error at line 4, got %v", err)
+	}
+}
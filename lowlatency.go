@@ -0,0 +1,171 @@
+package m3u8
+
+/*
+ Part of M3U8 parser & generator library.
+ This file defines server control settings of low-latency playlists.
+
+ Copyright 2013-2019 The Project Developers.
+ See the AUTHORS and LICENSE files at the top-level directory of this distribution
+ and at https://github.com/grafov/m3u8/
+
+ ॐ तारे तुत्तारे तुरे स्व
+*/
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ServerControl represents EXT-X-SERVER-CONTROL tag which declares
+// delivery directives supported by the server (section 4.4.3.8 of
+// rfc8216bis). Zero values are not written.
+type ServerControl struct {
+	CanSkipUntil      float64 // CAN-SKIP-UNTIL in seconds, delta updates are supported
+	CanSkipDateRanges bool    // CAN-SKIP-DATERANGES, requires CAN-SKIP-UNTIL
+	HoldBack          float64 // HOLD-BACK in seconds
+	PartHoldBack      float64 // PART-HOLD-BACK in seconds, required for playlists with parts
+	CanBlockReload    bool    // CAN-BLOCK-RELOAD, blocking playlist reload is supported
+}
+
+// MinHoldBack returns the minimal HOLD-BACK allowed for the target
+// duration, three target durations. It is also the default hold back
+// of players for playlists without HOLD-BACK.
+func MinHoldBack(targetDuration float64) float64 {
+	return 3 * targetDuration
+}
+
+// MinPartHoldBack returns the minimal PART-HOLD-BACK allowed for the
+// part target duration, twice the part target.
+func MinPartHoldBack(partTarget float64) float64 {
+	return 2 * partTarget
+}
+
+// RecommendedPartHoldBack returns PART-HOLD-BACK recommended for the
+// part target duration, three part targets.
+func RecommendedPartHoldBack(partTarget float64) float64 {
+	return 3 * partTarget
+}
+
+// MinCanSkipUntil returns the minimal CAN-SKIP-UNTIL allowed for the
+// target duration, six target durations.
+func MinCanSkipUntil(targetDuration float64) float64 {
+	return 6 * targetDuration
+}
+
+// NewServerControl returns spec-compliant server control settings for
+// the target duration and the part target duration (zero for
+// playlists without parts): the minimal HOLD-BACK and, for playlists
+// with parts, the recommended PART-HOLD-BACK. Other directives are up
+// to the server.
+func NewServerControl(targetDuration, partTarget float64) *ServerControl {
+	sc := &ServerControl{HoldBack: MinHoldBack(targetDuration)}
+	if partTarget > 0 {
+		sc.PartHoldBack = RecommendedPartHoldBack(partTarget)
+	}
+	return sc
+}
+
+// Validate returns error if the settings break the rules for the
+// target duration and the part target duration (zero for playlists
+// without parts): HOLD-BACK must be at least three target durations,
+// PART-HOLD-BACK at least twice the part target and it is required
+// for playlists with parts, CAN-SKIP-UNTIL must be at least six
+// target durations and CAN-SKIP-DATERANGES requires CAN-SKIP-UNTIL.
+func (sc *ServerControl) Validate(targetDuration, partTarget float64) error {
+	if sc.HoldBack < 0 || sc.PartHoldBack < 0 || sc.CanSkipUntil < 0 {
+		return fmt.Errorf("negative EXT-X-SERVER-CONTROL values are not allowed")
+	}
+	if min := MinHoldBack(targetDuration); sc.HoldBack > 0 && sc.HoldBack < min {
+		return fmt.Errorf("HOLD-BACK %v is less than three target durations (%v)", sc.HoldBack, min)
+	}
+	if partTarget > 0 && sc.PartHoldBack == 0 {
+		return fmt.Errorf("PART-HOLD-BACK is required for playlists with parts")
+	}
+	if min := MinPartHoldBack(partTarget); sc.PartHoldBack > 0 && sc.PartHoldBack < min {
+		return fmt.Errorf("PART-HOLD-BACK %v is less than twice the part target (%v)", sc.PartHoldBack, min)
+	}
+	if min := MinCanSkipUntil(targetDuration); sc.CanSkipUntil > 0 && sc.CanSkipUntil < min {
+		return fmt.Errorf("CAN-SKIP-UNTIL %v is less than six target durations (%v)", sc.CanSkipUntil, min)
+	}
+	if sc.CanSkipDateRanges && sc.CanSkipUntil == 0 {
+		return fmt.Errorf("CAN-SKIP-DATERANGES requires CAN-SKIP-UNTIL")
+	}
+	return nil
+}
+
+// CheckServerControl validates EXT-X-SERVER-CONTROL of the playlist
+// against its target duration and part target duration, see ServerControl.Validate. Playlists
+// without the tag are valid.
+func (p *MediaPlaylist) CheckServerControl() error {
+	if p.ServerControl == nil {
+		return nil
+	}
+	return p.ServerControl.Validate(p.TargetDuration, p.PartTargetDuration)
+}
+
+// decodeServerControl parses attributes of EXT-X-SERVER-CONTROL tag.
+func decodeServerControl(line string, strict bool) (*ServerControl, error) {
+	sc := new(ServerControl)
+	for k, v := range decodeParamsLine(line) {
+		var err error
+		switch k {
+		case "CAN-SKIP-UNTIL":
+			sc.CanSkipUntil, err = strconv.ParseFloat(v, 64)
+		case "CAN-SKIP-DATERANGES":
+			sc.CanSkipDateRanges, err = decodeYesNo(v, strict)
+		case "HOLD-BACK":
+			sc.HoldBack, err = strconv.ParseFloat(v, 64)
+		case "PART-HOLD-BACK":
+			sc.PartHoldBack, err = strconv.ParseFloat(v, 64)
+		case "CAN-BLOCK-RELOAD":
+			sc.CanBlockReload, err = decodeYesNo(v, strict)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %s: %v", k, v, err)
+		}
+	}
+	return sc, nil
+}
+
+// decodeYesNo parses enumerated YES/NO value. Only strict mode
+// rejects other values, lenient mode treats them as NO.
+func decodeYesNo(v string, strict bool) (bool, error) {
+	switch strings.ToUpper(v) {
+	case "YES":
+		return true, nil
+	case "NO":
+		return false, nil
+	}
+	if strict {
+		return false, fmt.Errorf("value must be YES or NO")
+	}
+	return false, nil
+}
+
+// writeServerControl writes EXT-X-SERVER-CONTROL tag.
+func writeServerControl(buf *bytes.Buffer, sc *ServerControl) {
+	var attrs attrList
+	if sc.CanSkipUntil > 0 {
+		attrs.add("CAN-SKIP-UNTIL", strconv.FormatFloat(sc.CanSkipUntil, 'f', -1, 64))
+	}
+	if sc.CanSkipDateRanges {
+		attrs.add("CAN-SKIP-DATERANGES", "YES")
+	}
+	if sc.HoldBack > 0 {
+		attrs.add("HOLD-BACK", strconv.FormatFloat(sc.HoldBack, 'f', -1, 64))
+	}
+	if sc.PartHoldBack > 0 {
+		attrs.add("PART-HOLD-BACK", strconv.FormatFloat(sc.PartHoldBack, 'f', -1, 64))
+	}
+	if sc.CanBlockReload {
+		attrs.add("CAN-BLOCK-RELOAD", "YES")
+	}
+	if len(attrs) == 0 {
+		return
+	}
+	buf.WriteString("#EXT-X-SERVER-CONTROL:")
+	attrs.writeTo(buf, nil)
+	buf.WriteRune('\n')
+}
@@ -0,0 +1,100 @@
+/*
+Low-latency server control tests.
+
+Copyright 2013-2019 The Project Developers.
+See the AUTHORS and LICENSE files at the top-level directory of this distribution
+and at https://github.com/grafov/m3u8/
+
+ॐ तारे तुत्तारे तुरे स्व
+*/
+package m3u8
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHoldBackCalculators(t *testing.T) {
+	if v := MinHoldBack(4); v != 12 {
+		t.Errorf("expected HOLD-BACK 12, got %v", v)
+	}
+	if v := MinPartHoldBack(1.002); v != 2.004 {
+		t.Errorf("expected PART-HOLD-BACK 2.004, got %v", v)
+	}
+	if v := RecommendedPartHoldBack(0.5); v != 1.5 {
+		t.Errorf("expected PART-HOLD-BACK 1.5, got %v", v)
+	}
+	if v := MinCanSkipUntil(6); v != 36 {
+		t.Errorf("expected CAN-SKIP-UNTIL 36, got %v", v)
+	}
+	sc := NewServerControl(4, 1)
+	if sc.HoldBack != 12 || sc.PartHoldBack != 3 {
+		t.Errorf("unexpected server control %+v", sc)
+	}
+	if err := sc.Validate(4, 1); err != nil {
+		t.Errorf("default server control must be valid: %s", err)
+	}
+	if sc = NewServerControl(6, 0); sc.PartHoldBack != 0 {
+		t.Errorf("PART-HOLD-BACK without parts: %v", sc.PartHoldBack)
+	}
+}
+
+func TestServerControlValidate(t *testing.T) {
+	for i, c := range []struct {
+		sc    ServerControl
+		valid bool
+	}{
+		{ServerControl{}, true},
+		{ServerControl{HoldBack: 12}, true},
+		{ServerControl{HoldBack: 11.9}, false},
+		{ServerControl{PartHoldBack: 2}, true},
+		{ServerControl{PartHoldBack: 1.9}, false},
+		{ServerControl{CanSkipUntil: 24, CanSkipDateRanges: true}, true},
+		{ServerControl{CanSkipUntil: 23}, false},
+		{ServerControl{CanSkipDateRanges: true}, false},
+		{ServerControl{HoldBack: -1}, false},
+	} {
+		err := c.sc.Validate(4, 1)
+		if c.sc.PartHoldBack == 0 {
+			// parts require PART-HOLD-BACK, check without parts
+			err = c.sc.Validate(4, 0)
+		}
+		if (err == nil) != c.valid {
+			t.Errorf("case %d: expected valid %v, got %v", i, c.valid, err)
+		}
+	}
+	if err := (&ServerControl{HoldBack: 12}).Validate(4, 1); err == nil {
+		t.Error("expected error for missing PART-HOLD-BACK")
+	}
+}
+
+func TestDecodeServerControl(t *testing.T) {
+	src := "#EXTM3U\n#EXT-X-VERSION:3\n#EXT-X-MEDIA-SEQUENCE:0\n#EXT-X-TARGETDURATION:4\n#EXT-X-SERVER-CONTROL:CAN-SKIP-UNTIL=24,CAN-SKIP-DATERANGES=YES,HOLD-BACK=12,PART-HOLD-BACK=3.012,CAN-BLOCK-RELOAD=YES\n#EXTINF:4.000,\na.ts\n"
+	pl, _, err := DecodeFrom(strings.NewReader(src), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := pl.(*MediaPlaylist)
+	expected := ServerControl{CanSkipUntil: 24, CanSkipDateRanges: true, HoldBack: 12, PartHoldBack: 3.012, CanBlockReload: true}
+	if p.ServerControl == nil || *p.ServerControl != expected {
+		t.Fatalf("unexpected server control %+v", p.ServerControl)
+	}
+	if p.String() != src {
+		t.Errorf("server control is not written back:\n%s", p)
+	}
+	if err = p.CheckServerControl(); err != nil {
+		t.Error(err)
+	}
+	p.ServerControl.HoldBack = 8
+	if err = p.CheckServerControl(); err == nil {
+		t.Error("expected error for HOLD-BACK of two target durations")
+	}
+
+	bad := strings.Replace(src, "CAN-BLOCK-RELOAD=YES", "CAN-BLOCK-RELOAD=1", 1)
+	if _, _, err = DecodeFrom(strings.NewReader(bad), true); err == nil {
+		t.Error("expected error for invalid CAN-BLOCK-RELOAD in strict mode")
+	}
+	if _, _, err = DecodeFrom(strings.NewReader(bad), false); err != nil {
+		t.Errorf("unexpected error in lenient mode: %s", err)
+	}
+}
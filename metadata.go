@@ -0,0 +1,61 @@
+package m3u8
+
+/*
+ Part of M3U8 parser & generator library.
+ This file defines user metadata of playlists, variants and segments.
+
+ Copyright 2013-2019 The Project Developers.
+ See the AUTHORS and LICENSE files at the top-level directory of this distribution
+ and at https://github.com/grafov/m3u8/
+
+ ॐ तारे तुत्तारे तुरे स्व
+*/
+
+// Metadata holds arbitrary annotations of the application (origin
+// shard, storage key, QC flags and so on) attached to media
+// playlists, variants and segments, so they may be passed through the
+// library APIs without abusing custom tags. Metadata is never written
+// to playlists, journals and binary snapshots. Copies of playlists and
+// segments made by the library share the metadata with the originals.
+type Metadata map[string]interface{}
+
+// Get returns the value of the key, nil for missing keys and nil
+// metadata.
+func (m Metadata) Get(key string) interface{} {
+	return m[key]
+}
+
+// setMetadata sets the key of the metadata creating the map if
+// required.
+func setMetadata(m *Metadata, key string, value interface{}) {
+	if *m == nil {
+		*m = make(Metadata)
+	}
+	(*m)[key] = value
+}
+
+// SetMetadata sets the key of the playlist metadata.
+func (p *MediaPlaylist) SetMetadata(key string, value interface{}) {
+	setMetadata(&p.Metadata, key, value)
+}
+
+// SetMetadata sets the key of the variant metadata.
+func (v *Variant) SetMetadata(key string, value interface{}) {
+	setMetadata(&v.Metadata, key, value)
+}
+
+// SetMetadata sets the key of the segment metadata.
+func (s *MediaSegment) SetMetadata(key string, value interface{}) {
+	setMetadata(&s.Metadata, key, value)
+}
+
+// GobEncode implements gob.GobEncoder, metadata is not saved in binary
+// snapshots as its values may have unregistered types.
+func (m Metadata) GobEncode() ([]byte, error) {
+	return nil, nil
+}
+
+// GobDecode implements gob.GobDecoder.
+func (m *Metadata) GobDecode([]byte) error {
+	return nil
+}
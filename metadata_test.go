@@ -0,0 +1,90 @@
+/*
+User metadata tests.
+
+Copyright 2013-2019 The Project Developers.
+See the AUTHORS and LICENSE files at the top-level directory of this distribution
+and at https://github.com/grafov/m3u8/
+
+ॐ तारे तुत्तारे तुरे स्व
+*/
+package m3u8
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+type qcResult struct {
+	Passed bool
+}
+
+func TestMetadataNotWritten(t *testing.T) {
+	p, err := NewMediaPlaylist(3, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.SetMetadata("shard", "origin-7")
+	var journal bytes.Buffer
+	j, err := NewJournal(p, &journal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	seg := &MediaSegment{URI: "seg0.ts", Duration: 4}
+	seg.SetMetadata("s3-key", "bucket/seg0.ts")
+	seg.SetMetadata("qc", qcResult{true})
+	if err = j.AppendSegment(seg); err != nil {
+		t.Fatal(err)
+	}
+	if got := p.Segments[0].Metadata.Get("qc"); got != (qcResult{true}) {
+		t.Errorf("unexpected metadata: %v", got)
+	}
+	if (Metadata)(nil).Get("missing") != nil {
+		t.Error("nil metadata must return nil")
+	}
+
+	for name, out := range map[string]string{"playlist": p.Encode().String(), "journal": journal.String()} {
+		if strings.Contains(out, "origin-7") || strings.Contains(out, "bucket") {
+			t.Errorf("metadata written to %s:\n%s", name, out)
+		}
+	}
+
+	// values of unregistered types don't break snapshots
+	data, err := p.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := new(MediaPlaylist)
+	if err = q.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if q.Segments[0].Metadata != nil || q.Metadata != nil {
+		t.Error("metadata restored from snapshot")
+	}
+
+	m := NewMasterPlaylist()
+	m.Append("low.m3u8", nil, VariantParams{Bandwidth: 800000})
+	m.Variants[0].SetMetadata("qc", qcResult{false})
+	if strings.Contains(m.Encode().String(), "qc") {
+		t.Error("metadata written to master playlist")
+	}
+	if _, err = m.MarshalBinary(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMetadataNotMarshaled(t *testing.T) {
+	p, _ := NewMediaPlaylist(1, 1)
+	p.Append("a.ts", 5, "")
+	p.SetMetadata("shard", "origin-7")
+	p.Segments[0].Metadata = Metadata{"qc": qcResult{Passed: true}}
+	v := &Variant{URI: "a.m3u8", Chunklist: p, Metadata: Metadata{"origin": "7"}}
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(data, []byte("Metadata")) {
+		t.Errorf("metadata is marshaled: %s", data)
+	}
+}
@@ -0,0 +1,89 @@
+package m3u8
+
+/*
+ Part of M3U8 parser & generator library.
+ This file defines metrics hooks of decoding and encoding.
+
+ Copyright 2013-2019 The Project Developers.
+ See the AUTHORS and LICENSE files at the top-level directory of this distribution
+ and at https://github.com/grafov/m3u8/
+
+ ॐ तारे तुत्तारे तुरे स्व
+*/
+
+import (
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Metrics receives measurements of decoding and encoding operations
+// of the library, it may be bound to Prometheus, expvar or any other
+// metrics system. Methods are called synchronously from decoding and
+// encoding goroutines so they should be fast and safe for concurrent
+// use.
+type Metrics interface {
+	// Decoded is called when a playlist is decoded with the number
+	// of its items (segments of media playlists and variants of
+	// master playlists).
+	Decoded(listType ListType, items int)
+	// DecodeError is called for every line failed to decode with the
+	// name of the tag (e.g. "#EXT-X-KEY") or "URI" for URI lines.
+	// Errors are reported in non-strict mode too.
+	DecodeError(tag string, err error)
+	// Encoded is called when a playlist is encoded (cached encodings
+	// are not reported) with the number of items and the time spent.
+	Encoded(listType ListType, items int, elapsed time.Duration)
+}
+
+// metricsHolder wraps the metrics as atomic.Value can't hold nil.
+type metricsHolder struct{ m Metrics }
+
+var currentMetrics atomic.Value
+
+// SetMetrics sets the metrics receiver for all decoding and encoding
+// operations of the library. Nil disables metrics.
+func SetMetrics(m Metrics) {
+	currentMetrics.Store(metricsHolder{m})
+}
+
+// metrics returns the current metrics receiver or nil.
+func metrics() Metrics {
+	h, _ := currentMetrics.Load().(metricsHolder)
+	return h.m
+}
+
+// reportDecodeError reports the error of decoding of the line.
+func reportDecodeError(line string, err error) {
+	m := metrics()
+	if m == nil || err == nil {
+		return
+	}
+	m.DecodeError(lineTag(line), err)
+}
+
+// lineTag returns the name of the tag of the line or "URI".
+func lineTag(line string) string {
+	line = strings.TrimSpace(line)
+	if !strings.HasPrefix(line, "#") {
+		return "URI"
+	}
+	if i := strings.IndexByte(line, ':'); i >= 0 {
+		return line[:i]
+	}
+	return line
+}
+
+// reportDecoded reports the decoded playlist.
+func reportDecoded(listType ListType, items int) {
+	if m := metrics(); m != nil {
+		m.Decoded(listType, items)
+	}
+}
+
+// reportEncoded reports the encoded playlist started at the time.
+func reportEncoded(listType ListType, items int, start time.Time) {
+	if m := metrics(); m != nil {
+		m.Encoded(listType, items, time.Since(start))
+	}
+}
@@ -0,0 +1,80 @@
+/*
+Metrics hooks tests.
+
+Copyright 2013-2019 The Project Developers.
+See the AUTHORS and LICENSE files at the top-level directory of this distribution
+and at https://github.com/grafov/m3u8/
+
+ॐ तारे तुत्तारे तुरे स्व
+*/
+package m3u8
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type mockMetrics struct {
+	sync.Mutex
+	decoded map[ListType][]int
+	errors  []string
+	encoded map[ListType][]int
+}
+
+func (m *mockMetrics) Decoded(listType ListType, items int) {
+	m.Lock()
+	m.decoded[listType] = append(m.decoded[listType], items)
+	m.Unlock()
+}
+
+func (m *mockMetrics) DecodeError(tag string, err error) {
+	m.Lock()
+	m.errors = append(m.errors, tag)
+	m.Unlock()
+}
+
+func (m *mockMetrics) Encoded(listType ListType, items int, elapsed time.Duration) {
+	m.Lock()
+	m.encoded[listType] = append(m.encoded[listType], items)
+	m.Unlock()
+}
+
+func TestMetrics(t *testing.T) {
+	m := &mockMetrics{decoded: make(map[ListType][]int), encoded: make(map[ListType][]int)}
+	SetMetrics(m)
+	defer SetMetrics(nil)
+
+	src := "#EXTM3U\n#EXT-X-TARGETDURATION:10\n#EXT-X-MEDIA-SEQUENCE:x\n#EXTINF:10,\na.ts\n#EXTINF:10,\nb.ts\n#EXT-X-ENDLIST\n"
+	p, _, err := DecodeFrom(strings.NewReader(src), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.decoded[MEDIA]) != 1 || m.decoded[MEDIA][0] != 2 {
+		t.Errorf("unexpected decoded metrics: %v", m.decoded)
+	}
+	if len(m.errors) != 1 || m.errors[0] != "#EXT-X-MEDIA-SEQUENCE" {
+		t.Errorf("unexpected decode errors: %v", m.errors)
+	}
+	p.Encode()
+	p.Encode() // cached
+	p.(*MediaPlaylist).EncodeWindow(1)
+	if got := m.encoded[MEDIA]; len(got) != 2 || got[0] != 2 || got[1] != 1 {
+		t.Errorf("unexpected encoded metrics: %v", got)
+	}
+
+	master := NewMasterPlaylist()
+	master.Append("low.m3u8", nil, VariantParams{Bandwidth: 1})
+	master.Encode()
+	if got := m.encoded[MASTER]; len(got) != 1 || got[0] != 1 {
+		t.Errorf("unexpected encoded metrics: %v", got)
+	}
+
+	SetMetrics(nil)
+	master.ResetCache()
+	master.Encode()
+	if len(m.encoded[MASTER]) != 1 {
+		t.Error("metrics are reported after disabling")
+	}
+}
@@ -0,0 +1,153 @@
+package m3u8
+
+/*
+ Part of M3U8 parser & generator library.
+ This file defines the monitor of live media playlists.
+
+ Copyright 2013-2019 The Project Developers.
+ See the AUTHORS and LICENSE files at the top-level directory of this distribution
+ and at https://github.com/grafov/m3u8/
+
+ ॐ तारे तुत्तारे तुरे स्व
+*/
+
+import (
+	"errors"
+	"time"
+)
+
+// MonitorEventType is the type of events reported by LiveMonitor.
+type MonitorEventType uint
+
+const (
+	// use 0 for not defined type
+	EventStalled                MonitorEventType = iota + 1 // no new segments for too long
+	EventSequenceBackwards                                  // EXT-X-MEDIA-SEQUENCE decreased
+	EventTargetDurationChanged                              // EXT-X-TARGETDURATION changed
+	EventDiscontinuitySeqJumped                             // EXT-X-DISCONTINUITY-SEQUENCE changed unexpectedly
+	EventEndlist                                            // EXT-X-ENDLIST appeared
+)
+
+// DefaultStallFactor is the number of target durations without new
+// segments after which a live playlist considered stalled.
+const DefaultStallFactor = 3
+
+// MonitorEvent describes a change detected between successive
+// snapshots of a live playlist. Previous and Current hold the old and
+// the new values of the changed property (sequence numbers or target
+// duration). For EventStalled Previous is the last media sequence
+// number of the segment and Current is the stall time in seconds.
+type MonitorEvent struct {
+	Type     MonitorEventType
+	Time     time.Time
+	Previous float64
+	Current  float64
+}
+
+// LiveMonitor consumes successive snapshots of the same live media
+// playlist and reports health events. It is not safe for concurrent
+// use.
+type LiveMonitor struct {
+	StallFactor float64 // playlist stalled after StallFactor × target duration without new segments
+
+	started        bool
+	seqNo          uint64
+	lastSeqID      uint64
+	targetDuration float64
+	dseq           uint64
+	closed         bool
+	lastProgress   time.Time
+	stalled        bool
+}
+
+// NewLiveMonitor creates a monitor with the stall factor. Zero factor
+// means DefaultStallFactor.
+func NewLiveMonitor(stallFactor float64) *LiveMonitor {
+	if stallFactor <= 0 {
+		stallFactor = DefaultStallFactor
+	}
+	return &LiveMonitor{StallFactor: stallFactor}
+}
+
+// Update compares the playlist snapshot received at the time now with
+// the previous one and returns detected events. The first snapshot
+// only initializes the monitor.
+func (m *LiveMonitor) Update(p *MediaPlaylist, now time.Time) []MonitorEvent {
+	lastSeqID := p.SeqNo
+	if p.count > 0 {
+		lastSeqID += uint64(p.count) - 1
+	}
+	if !m.started {
+		m.started = true
+		m.seqNo, m.lastSeqID = p.SeqNo, lastSeqID
+		m.targetDuration, m.dseq, m.closed = p.TargetDuration, p.DiscontinuitySeq, p.Closed
+		m.lastProgress = now
+		return nil
+	}
+
+	var events []MonitorEvent
+	add := func(t MonitorEventType, prev, cur float64) {
+		events = append(events, MonitorEvent{Type: t, Time: now, Previous: prev, Current: cur})
+	}
+	if p.SeqNo < m.seqNo {
+		add(EventSequenceBackwards, float64(m.seqNo), float64(p.SeqNo))
+	}
+	if p.TargetDuration != m.targetDuration {
+		add(EventTargetDurationChanged, m.targetDuration, p.TargetDuration)
+	}
+	// Discontinuity sequence may grow only when segments slide out of
+	// the window and at most by the number of removed segments.
+	if p.DiscontinuitySeq != m.dseq {
+		if p.DiscontinuitySeq < m.dseq || p.SeqNo < m.seqNo || p.DiscontinuitySeq-m.dseq > p.SeqNo-m.seqNo {
+			add(EventDiscontinuitySeqJumped, float64(m.dseq), float64(p.DiscontinuitySeq))
+		}
+	}
+	if p.Closed && !m.closed {
+		add(EventEndlist, float64(m.lastSeqID), float64(lastSeqID))
+	}
+
+	if lastSeqID != m.lastSeqID || p.SeqNo < m.seqNo {
+		m.lastProgress = now
+		m.stalled = false
+	} else if !p.Closed && !m.stalled && p.TargetDuration > 0 {
+		idle := now.Sub(m.lastProgress).Seconds()
+		if idle > m.StallFactor*p.TargetDuration {
+			m.stalled = true
+			add(EventStalled, float64(lastSeqID), idle)
+		}
+	}
+
+	m.seqNo, m.lastSeqID = p.SeqNo, lastSeqID
+	m.targetDuration, m.dseq, m.closed = p.TargetDuration, p.DiscontinuitySeq, p.Closed
+	return events
+}
+
+// ErrNoProgramDateTime returned when latency can't be estimated
+// because the playlist has no segments with EXT-X-PROGRAM-DATE-TIME.
+var ErrNoProgramDateTime = errors.New("playlist has no segments with program date time")
+
+// EstimateLatency estimates end-to-end latency of the live playlist
+// at the time now. The live edge is the end of the newest segment
+// computed from the nearest EXT-X-PROGRAM-DATE-TIME plus durations of
+// the following segments. The latency is the age of the live edge
+// plus the hold back a player keeps from the edge. Zero holdBack
+// means the default of three target durations (section 4.4.3.8), pass
+// PART-HOLD-BACK for low-latency playlists.
+func (p *MediaPlaylist) EstimateLatency(now time.Time, holdBack time.Duration) (time.Duration, error) {
+	if holdBack == 0 {
+		holdBack = time.Duration(3 * p.TargetDuration * float64(time.Second))
+	}
+	var duration float64
+	for i := uint(0); i < p.count; i++ {
+		seg := p.Segments[(p.head+p.count-1-i)%p.capacity]
+		if seg == nil {
+			continue
+		}
+		duration += seg.Duration
+		if !seg.ProgramDateTime.IsZero() {
+			edge := seg.ProgramDateTime.Add(time.Duration(duration * float64(time.Second)))
+			return now.Sub(edge) + holdBack, nil
+		}
+	}
+	return 0, ErrNoProgramDateTime
+}
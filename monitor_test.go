@@ -0,0 +1,92 @@
+/*
+Live monitor tests.
+
+Copyright 2013-2019 The Project Developers.
+See the AUTHORS and LICENSE files at the top-level directory of this distribution
+and at https://github.com/grafov/m3u8/
+
+ॐ तारे तुत्तारे तुरे स्व
+*/
+package m3u8
+
+import (
+	"testing"
+	"time"
+)
+
+func monitorSnapshot(seqNo uint64, n int, target float64) *MediaPlaylist {
+	p, _ := NewMediaPlaylist(uint(n), uint(n))
+	p.SeqNo = seqNo
+	for i := 0; i < n; i++ {
+		_ = p.Append("seg.ts", target, "")
+	}
+	p.TargetDuration = target
+	return p
+}
+
+func TestLiveMonitor(t *testing.T) {
+	m := NewLiveMonitor(0)
+	start := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	if events := m.Update(monitorSnapshot(10, 3, 6), start); len(events) != 0 {
+		t.Fatalf("First snapshot must not produce events: %v", events)
+	}
+	if events := m.Update(monitorSnapshot(10, 3, 6), start.Add(12*time.Second)); len(events) != 0 {
+		t.Errorf("Unexpected events: %v", events)
+	}
+	events := m.Update(monitorSnapshot(10, 3, 6), start.Add(20*time.Second))
+	if len(events) != 1 || events[0].Type != EventStalled {
+		t.Errorf("Expected stall event, got %v", events)
+	}
+	if events = m.Update(monitorSnapshot(10, 3, 6), start.Add(30*time.Second)); len(events) != 0 {
+		t.Errorf("Stall must be reported once, got %v", events)
+	}
+
+	p := monitorSnapshot(11, 3, 8)
+	p.DiscontinuitySeq = 5
+	events = m.Update(p, start.Add(36*time.Second))
+	if len(events) != 2 || events[0].Type != EventTargetDurationChanged || events[1].Type != EventDiscontinuitySeqJumped {
+		t.Errorf("Expected target duration and discontinuity events, got %v", events)
+	}
+
+	p = monitorSnapshot(9, 3, 8)
+	p.DiscontinuitySeq = 5
+	events = m.Update(p, start.Add(40*time.Second))
+	if len(events) != 1 || events[0].Type != EventSequenceBackwards || events[0].Previous != 11 || events[0].Current != 9 {
+		t.Errorf("Expected sequence backwards event, got %v", events)
+	}
+
+	p = monitorSnapshot(9, 3, 8)
+	p.DiscontinuitySeq = 5
+	p.Close()
+	events = m.Update(p, start.Add(100*time.Second))
+	if len(events) != 1 || events[0].Type != EventEndlist {
+		t.Errorf("Expected endlist event, got %v", events)
+	}
+}
+
+func TestEstimateLatency(t *testing.T) {
+	p, _ := NewMediaPlaylist(5, 5)
+	pdt := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	if _, err := p.EstimateLatency(pdt, 0); err != ErrNoProgramDateTime {
+		t.Errorf("Expected ErrNoProgramDateTime, got %v", err)
+	}
+	_ = p.Append("seg0.ts", 6, "")
+	_ = p.SetProgramDateTime(pdt)
+	_ = p.Append("seg1.ts", 6, "")
+	_ = p.Append("seg2.ts", 4, "")
+	p.TargetDuration = 6
+
+	// live edge at pdt+16s
+	now := pdt.Add(20 * time.Second)
+	latency, err := p.EstimateLatency(now, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if latency != 22*time.Second {
+		t.Errorf("Latency = %s, want 22s", latency)
+	}
+	latency, _ = p.EstimateLatency(now, time.Second)
+	if latency != 5*time.Second {
+		t.Errorf("Latency = %s, want 5s", latency)
+	}
+}
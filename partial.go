@@ -0,0 +1,153 @@
+package m3u8
+
+/*
+ Part of M3U8 parser & generator library.
+ This file defines partial segments of low-latency playlists.
+
+ Copyright 2013-2019 The Project Developers.
+ See the AUTHORS and LICENSE files at the top-level directory of this distribution
+ and at https://github.com/grafov/m3u8/
+
+ ॐ तारे तुत्तारे तुरे स्व
+*/
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PartialSegment represents EXT-X-PART tag of the partial segment
+// (section 4.4.4.9 of rfc8216bis). Parts of a segment precede its
+// EXTINF, parts of the segment in progress follow the last segment of
+// the playlist. The first part of the segment starts with it, so it is
+// decoded with the EXT-X-PROGRAM-DATE-TIME of the segment.
+type PartialSegment struct {
+	URI             string
+	Duration        float64   // DURATION in seconds, must not exceed PART-TARGET
+	Independent     bool      // INDEPENDENT=YES, the part contains an independent frame
+	Limit           int64     // BYTERANGE length, zero for the whole resource
+	Offset          int64     // BYTERANGE offset
+	Gap             bool      // GAP=YES, the part is unavailable
+	ProgramDateTime time.Time // EXT-X-PROGRAM-DATE-TIME written before the part
+}
+
+// AppendPartial appends the partial segment to the segment in
+// progress (PendingPartials). Pending parts are moved to the next
+// segment appended with AppendSegment unless it has own parts, so the
+// complete segment is published after its parts. PartTargetDuration
+// follows the longest part appended unless it is fixed with
+// SetPartTargetDuration or decoded from EXT-X-PART-INF, then it
+// returns error if the duration of the part exceeds it. It returns
+// error for the part without URI too. This operation does reset
+// playlist cache.
+func (p *MediaPlaylist) AppendPartial(part *PartialSegment) error {
+	if part.URI == "" {
+		return errors.New("partial segment without URI")
+	}
+	if p.partTargetSet && part.Duration > p.PartTargetDuration {
+		return fmt.Errorf("partial segment duration %v exceeds PART-TARGET %v", part.Duration, p.PartTargetDuration)
+	}
+	p.fitPartTarget(part)
+	p.PendingPartials = append(p.PendingPartials, part)
+	p.buf.Reset()
+	return nil
+}
+
+// SetPartTargetDuration fixes EXT-X-PART-INF:PART-TARGET of the
+// playlist, so it doesn't follow durations of appended parts and
+// longer parts are rejected by AppendPartial. Zero restores the
+// automatic part target. This operation does reset playlist cache.
+func (p *MediaPlaylist) SetPartTargetDuration(partTarget float64) {
+	p.PartTargetDuration = partTarget
+	p.partTargetSet = partTarget > 0
+	p.buf.Reset()
+}
+
+// fitPartTarget raises the automatic part target to the duration of
+// the part.
+func (p *MediaPlaylist) fitPartTarget(part *PartialSegment) {
+	if !p.partTargetSet && p.PartTargetDuration < part.Duration {
+		p.PartTargetDuration = part.Duration
+	}
+}
+
+// decodePartial parses attributes of EXT-X-PART tag. BYTERANGE without
+// offset continues the previous part of the same resource.
+func decodePartial(line string, prev *PartialSegment, strict bool) (*PartialSegment, error) {
+	part := new(PartialSegment)
+	var continued bool
+	for k, v := range decodeParamsLine(line) {
+		var err error
+		switch k {
+		case "URI":
+			part.URI = v
+		case "DURATION":
+			part.Duration, err = strconv.ParseFloat(v, 64)
+		case "INDEPENDENT":
+			part.Independent, err = decodeYesNo(v, strict)
+		case "GAP":
+			part.Gap, err = decodeYesNo(v, strict)
+		case "BYTERANGE":
+			params := strings.SplitN(v, "@", 2)
+			if part.Limit, err = strconv.ParseInt(params[0], 10, 64); err != nil {
+				break
+			}
+			if len(params) > 1 {
+				part.Offset, err = strconv.ParseInt(params[1], 10, 64)
+			} else {
+				continued = true
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %s: %v", k, v, err)
+		}
+	}
+	if continued && prev != nil && prev.URI == part.URI {
+		part.Offset = prev.Offset + prev.Limit
+	}
+	if strict && (part.URI == "" || part.Duration == 0) {
+		return nil, errors.New("EXT-X-PART without URI or DURATION")
+	}
+	return part, nil
+}
+
+// writePartial writes EXT-X-PART tag preceded by
+// EXT-X-PROGRAM-DATE-TIME of the part when it is set and withTime.
+func writePartial(buf *bytes.Buffer, part *PartialSegment, withTime bool) {
+	if withTime && !part.ProgramDateTime.IsZero() {
+		buf.WriteString("#EXT-X-PROGRAM-DATE-TIME:")
+		buf.WriteString(part.ProgramDateTime.Format(DATETIME))
+		buf.WriteRune('\n')
+	}
+	buf.WriteString("#EXT-X-PART:")
+	partialAttrs(part).writeTo(buf, nil)
+	buf.WriteRune('\n')
+}
+
+// partialAttrs returns attributes of EXT-X-PART tag.
+func partialAttrs(part *PartialSegment) attrList {
+	var attrs attrList
+	attrs.add("DURATION", strconv.FormatFloat(part.Duration, 'f', -1, 64))
+	attrs.quoted("URI", part.URI)
+	if part.Independent {
+		attrs.add("INDEPENDENT", "YES")
+	}
+	if part.Limit > 0 {
+		attrs.quoted("BYTERANGE", strconv.FormatInt(part.Limit, 10)+"@"+strconv.FormatInt(part.Offset, 10))
+	}
+	if part.Gap {
+		attrs.add("GAP", "YES")
+	}
+	return attrs
+}
+
+// writePartInf writes EXT-X-PART-INF tag.
+func writePartInf(buf *bytes.Buffer, partTarget float64) {
+	buf.WriteString("#EXT-X-PART-INF:PART-TARGET=")
+	buf.WriteString(strconv.FormatFloat(partTarget, 'f', -1, 64))
+	buf.WriteRune('\n')
+}
@@ -0,0 +1,187 @@
+/*
+Partial segments tests.
+
+Copyright 2013-2019 The Project Developers.
+See the AUTHORS and LICENSE files at the top-level directory of this distribution
+and at https://github.com/grafov/m3u8/
+
+ॐ तारे तुत्तारे तुरे स्व
+*/
+package m3u8
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAppendPartial(t *testing.T) {
+	p, err := NewMediaPlaylist(3, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.SetPartTargetDuration(1)
+	for _, part := range []*PartialSegment{
+		{URI: "seg0.part0.mp4", Duration: 1, Independent: true},
+		{URI: "seg0.part1.mp4", Duration: 1},
+	} {
+		if err = p.AppendPartial(part); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = p.Append("seg0.mp4", 2, ""); err != nil {
+		t.Fatal(err)
+	}
+	if err = p.AppendPartial(&PartialSegment{URI: "seg1.part0.mp4", Duration: 1, Independent: true, Limit: 1000, Offset: 0}); err != nil {
+		t.Fatal(err)
+	}
+	if err = p.AppendPartial(&PartialSegment{URI: "seg1.part1.mp4", Duration: 1.5}); err == nil {
+		t.Error("expected error for part longer than PART-TARGET")
+	}
+	if err = p.AppendPartial(&PartialSegment{Duration: 1}); err == nil {
+		t.Error("expected error for part without URI")
+	}
+	if len(p.Segments[0].Partials) != 2 || len(p.PendingPartials) != 1 {
+		t.Fatalf("unexpected parts: %d of the segment, %d pending", len(p.Segments[0].Partials), len(p.PendingPartials))
+	}
+
+	expected := `#EXT-X-PART-INF:PART-TARGET=1
+#EXT-X-PART:DURATION=1,URI="seg0.part0.mp4",INDEPENDENT=YES
+#EXT-X-PART:DURATION=1,URI="seg0.part1.mp4"
+#EXTINF:2.000,
+seg0.mp4
+#EXT-X-PART:DURATION=1,URI="seg1.part0.mp4",INDEPENDENT=YES,BYTERANGE="1000@0"
+`
+	out := p.String()
+	if !strings.Contains(out, expected[:strings.Index(expected, "\n")+1]) ||
+		!strings.HasSuffix(out, expected[strings.Index(expected, "\n")+1:]) {
+		t.Errorf("unexpected output:\n%s\nexpected:\n%s", out, expected)
+	}
+}
+
+func TestDecodePartials(t *testing.T) {
+	src := `#EXTM3U
+#EXT-X-TARGETDURATION:4
+#EXT-X-PART-INF:PART-TARGET=1.004
+#EXT-X-MEDIA-SEQUENCE:266
+#EXT-X-PART:DURATION=1.004,URI="seg266.mp4",INDEPENDENT=YES,BYTERANGE="20000@0"
+#EXT-X-PART:DURATION=1.004,URI="seg266.mp4",BYTERANGE="18000"
+#EXT-X-PART:DURATION=1.004,URI="seg266.mp4",GAP=YES,BYTERANGE="19000"
+#EXTINF:3.012,
+seg266.mp4
+#EXT-X-PART:DURATION=1.004,URI="seg267.part0.mp4",INDEPENDENT=YES
+`
+	p, err := NewMediaPlaylist(0, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = p.DecodeFrom(bytes.NewBufferString(src), true); err != nil {
+		t.Fatal(err)
+	}
+	if p.PartTargetDuration != 1.004 {
+		t.Errorf("unexpected PART-TARGET: %v", p.PartTargetDuration)
+	}
+	parts := p.Segments[0].Partials
+	if len(parts) != 3 {
+		t.Fatalf("expected 3 parts of the segment, got %d", len(parts))
+	}
+	if !parts[0].Independent || parts[1].Offset != 20000 || parts[2].Offset != 38000 || !parts[2].Gap {
+		t.Errorf("unexpected parts: %+v %+v %+v", parts[0], parts[1], parts[2])
+	}
+	if len(p.PendingPartials) != 1 || p.PendingPartials[0].URI != "seg267.part0.mp4" {
+		t.Errorf("unexpected pending parts: %v", p.PendingPartials)
+	}
+	out := p.String()
+	for _, line := range []string{
+		`#EXT-X-PART:DURATION=1.004,URI="seg266.mp4",BYTERANGE="18000@20000"`,
+		`#EXT-X-PART:DURATION=1.004,URI="seg266.mp4",BYTERANGE="19000@38000",GAP=YES`,
+	} {
+		if !strings.Contains(out, line+"\n") {
+			t.Errorf("expected %s in output:\n%s", line, out)
+		}
+	}
+
+	q, _ := NewMediaPlaylist(0, 1)
+	if err = q.DecodeFrom(strings.NewReader("#EXTM3U\n#EXT-X-TARGETDURATION:4\n#EXT-X-PART:DURATION=1\n"), true); err == nil {
+		t.Error("expected error for EXT-X-PART without URI in strict mode")
+	}
+}
+
+func TestAutomaticPartTarget(t *testing.T) {
+	p, _ := NewMediaPlaylist(3, 3)
+	for _, d := range []float64{0.9, 1.002, 0.95} {
+		if err := p.AppendPartial(&PartialSegment{URI: "part.mp4", Duration: d}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if p.PartTargetDuration != 1.002 {
+		t.Errorf("unexpected PART-TARGET: %v", p.PartTargetDuration)
+	}
+	_ = p.AppendSegment(&MediaSegment{URI: "seg.mp4", Duration: 2, Partials: []*PartialSegment{{URI: "seg.mp4", Duration: 1.1}}})
+	if p.PartTargetDuration != 1.1 {
+		t.Errorf("unexpected PART-TARGET: %v", p.PartTargetDuration)
+	}
+	if !strings.Contains(p.String(), "#EXT-X-PART-INF:PART-TARGET=1.1\n") {
+		t.Errorf("expected PART-INF in output:\n%s", p.String())
+	}
+	p.ServerControl = NewServerControl(p.TargetDuration, 0)
+	if err := p.CheckServerControl(); err == nil {
+		t.Error("expected error for missing PART-HOLD-BACK")
+	}
+
+	p.SetPartTargetDuration(1)
+	if err := p.AppendPartial(&PartialSegment{URI: "part.mp4", Duration: 1.2}); err == nil {
+		t.Error("expected error for part longer than fixed PART-TARGET")
+	}
+	p.SetPartTargetDuration(0)
+	if err := p.AppendPartial(&PartialSegment{URI: "part.mp4", Duration: 1.2}); err != nil || p.PartTargetDuration != 1.2 {
+		t.Errorf("unexpected automatic PART-TARGET %v: %v", p.PartTargetDuration, err)
+	}
+}
+
+func TestPartialProgramDateTime(t *testing.T) {
+	src := `#EXTM3U
+#EXT-X-TARGETDURATION:2
+#EXT-X-PART-INF:PART-TARGET=1
+#EXT-X-PROGRAM-DATE-TIME:2020-01-01T00:00:00Z
+#EXT-X-PART:DURATION=1,URI="seg0.part0.mp4",INDEPENDENT=YES
+#EXT-X-PROGRAM-DATE-TIME:2020-01-01T00:00:01Z
+#EXT-X-PART:DURATION=1,URI="seg0.part1.mp4"
+#EXTINF:2.000,
+seg0.mp4
+#EXT-X-PROGRAM-DATE-TIME:2020-01-01T00:00:02Z
+#EXT-X-PART:DURATION=1,URI="seg1.part0.mp4",INDEPENDENT=YES
+`
+	decoded, _, err := DecodeFrom(bytes.NewBufferString(src), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := decoded.(*MediaPlaylist)
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	seg := p.Segments[0]
+	if !seg.ProgramDateTime.Equal(start) {
+		t.Errorf("unexpected time of the segment: %v", seg.ProgramDateTime)
+	}
+	for i, expected := range []time.Time{start, start.Add(time.Second)} {
+		if pdt := seg.Partials[i].ProgramDateTime; !pdt.Equal(expected) {
+			t.Errorf("part %d: expected time %v, got %v", i, expected, pdt)
+		}
+	}
+	if len(p.PendingPartials) != 1 || !p.PendingPartials[0].ProgramDateTime.Equal(start.Add(2*time.Second)) {
+		t.Errorf("unexpected pending parts: %+v", p.PendingPartials)
+	}
+	if out := p.String(); strings.Count(out, "#EXT-X-PROGRAM-DATE-TIME:") != 3 {
+		t.Errorf("the time of the first part must be written once:\n%s", out)
+	}
+
+	// the time following the parts belongs to the segment
+	q, _ := NewMediaPlaylist(0, 1)
+	src = "#EXTM3U\n#EXT-X-TARGETDURATION:2\n#EXT-X-PART:DURATION=1,URI=\"a.mp4\"\n#EXT-X-PROGRAM-DATE-TIME:2020-01-01T00:00:00Z\n#EXTINF:2,\nseg.mp4\n"
+	if err = q.DecodeFrom(strings.NewReader(src), true); err != nil {
+		t.Fatal(err)
+	}
+	if seg := q.Segments[0]; !seg.ProgramDateTime.Equal(start) || !seg.Partials[0].ProgramDateTime.IsZero() {
+		t.Errorf("unexpected times of the segment %v and its part %v", seg.ProgramDateTime, seg.Partials[0].ProgramDateTime)
+	}
+}
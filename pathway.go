@@ -0,0 +1,120 @@
+package m3u8
+
+/*
+ Part of M3U8 parser & generator library.
+ This file defines pathways of content steering.
+
+ Copyright 2013-2019 The Project Developers.
+ See the AUTHORS and LICENSE files at the top-level directory of this distribution
+ and at https://github.com/grafov/m3u8/
+
+ ॐ तारे तुत्तारे तुरे स्व
+*/
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// DefaultPathwayId is the pathway of variants without PATHWAY-ID.
+const DefaultPathwayId = "."
+
+// pathwayOf returns the pathway of the variant.
+func pathwayOf(v *Variant) string {
+	if v.PathwayId == "" {
+		return DefaultPathwayId
+	}
+	return v.PathwayId
+}
+
+// Pathways returns sorted distinct pathways of the variants. Variants
+// without PATHWAY-ID belong to DefaultPathwayId.
+func (p *MasterPlaylist) Pathways() []string {
+	seen := make(map[string]bool)
+	var ids []string
+	for _, v := range p.Variants {
+		if v == nil || seen[pathwayOf(v)] {
+			continue
+		}
+		seen[pathwayOf(v)] = true
+		ids = append(ids, pathwayOf(v))
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// DuplicatePathway clones the variants of the pathway from (use
+// DefaultPathwayId for variants without PATHWAY-ID) onto the new
+// pathway to, so a multivariant playlist for content steering may be
+// published from the definition of a single CDN. URIs of the cloned
+// variants and renditions are replaced with the results of the
+// function (for example, with other hostnames or prefixes). The cloned
+// renditions form own groups named as the original groups with the
+// "-" and the pathway suffix, and the cloned variants refer to them.
+// Other attributes of the variants and renditions, including their
+// identifiers, are preserved; chunklists are shared with the original
+// variants. The clones are appended after the existing variants. It
+// returns error if the pathway to already exists or the pathway from
+// has no variants. This operation does reset playlist cache.
+func (p *MasterPlaylist) DuplicatePathway(from, to string, rewrite func(uri string) string) error {
+	if to == "" {
+		return errors.New("empty pathway ID")
+	}
+	var source []*Variant
+	for _, v := range p.Variants {
+		if v == nil {
+			continue
+		}
+		switch pathwayOf(v) {
+		case to:
+			return fmt.Errorf("pathway %q already exists", to)
+		case from:
+			source = append(source, v)
+		}
+	}
+	if len(source) == 0 {
+		return fmt.Errorf("pathway %q has no variants", from)
+	}
+	if rewrite == nil {
+		rewrite = func(uri string) string { return uri }
+	}
+
+	group := func(id string) string {
+		if id == "" || id == "NONE" {
+			return id
+		}
+		return id + "-" + to
+	}
+	alts := make(map[*Alternative]*Alternative)
+	for _, v := range source {
+		clone := new(Variant)
+		*clone = *v
+		clone.URI = rewrite(v.URI)
+		clone.PathwayId = to
+		clone.Audio = group(v.Audio)
+		clone.Video = group(v.Video)
+		clone.Subtitles = group(v.Subtitles)
+		clone.Captions = group(v.Captions)
+		clone.Alternatives = nil
+		for _, alt := range v.Alternatives {
+			if alt == nil {
+				continue
+			}
+			a, ok := alts[alt]
+			if !ok {
+				a = new(Alternative)
+				*a = *alt
+				a.GroupId = group(alt.GroupId)
+				if alt.URI != "" {
+					a.URI = rewrite(alt.URI)
+				}
+				alts[alt] = a
+			}
+			clone.Alternatives = append(clone.Alternatives, a)
+		}
+		p.Variants = append(p.Variants, clone)
+	}
+	p.buf.Reset()
+	return nil
+}
@@ -0,0 +1,76 @@
+/*
+Content steering pathways tests.
+
+Copyright 2013-2019 The Project Developers.
+See the AUTHORS and LICENSE files at the top-level directory of this distribution
+and at https://github.com/grafov/m3u8/
+
+ॐ तारे तुत्तारे तुरे स्व
+*/
+package m3u8
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestDuplicatePathway(t *testing.T) {
+	audio := &Alternative{GroupId: "aac", Type: "AUDIO", Name: "English", Language: "en", URI: "https://cdn-a.example.com/audio.m3u8"}
+	m := NewMasterPlaylist()
+	m.Append("https://cdn-a.example.com/low.m3u8", nil, VariantParams{Bandwidth: 800000, Audio: "aac", Captions: "NONE", Alternatives: []*Alternative{audio}})
+	m.Append("https://cdn-a.example.com/high.m3u8", nil, VariantParams{Bandwidth: 2500000, Audio: "aac", Captions: "NONE", Alternatives: []*Alternative{audio}})
+	rewrite := func(uri string) string {
+		return strings.Replace(uri, "cdn-a", "cdn-b", 1)
+	}
+	if err := m.DuplicatePathway(DefaultPathwayId, "CDN-B", rewrite); err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Variants) != 4 {
+		t.Fatalf("expected 4 variants, got %d", len(m.Variants))
+	}
+	clone := m.Variants[2]
+	if clone.URI != "https://cdn-b.example.com/low.m3u8" || clone.PathwayId != "CDN-B" || clone.Bandwidth != 800000 {
+		t.Errorf("unexpected clone: %+v", clone)
+	}
+	if clone.Audio != "aac-CDN-B" || clone.Captions != "NONE" {
+		t.Errorf("unexpected groups of the clone: audio %q, captions %q", clone.Audio, clone.Captions)
+	}
+	if clone.Alternatives[0] != m.Variants[3].Alternatives[0] {
+		t.Error("shared rendition cloned twice")
+	}
+	if alt := clone.Alternatives[0]; alt.GroupId != "aac-CDN-B" || alt.URI != "https://cdn-b.example.com/audio.m3u8" || alt.Name != "English" {
+		t.Errorf("unexpected cloned rendition: %+v", alt)
+	}
+	if audio.GroupId != "aac" || m.Variants[0].PathwayId != "" {
+		t.Error("original pathway changed")
+	}
+	if got := m.Pathways(); len(got) != 2 || got[0] != "." || got[1] != "CDN-B" {
+		t.Errorf("unexpected pathways: %v", got)
+	}
+
+	out := m.Encode().String()
+	for _, line := range []string{
+		`#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID="aac-CDN-B",NAME="English",DEFAULT=NO,LANGUAGE="en",URI="https://cdn-b.example.com/audio.m3u8"`,
+		`#EXT-X-STREAM-INF:BANDWIDTH=800000,AUDIO="aac-CDN-B",CLOSED-CAPTIONS=NONE,PATHWAY-ID="CDN-B"`,
+	} {
+		if !strings.Contains(out, line+"\n") {
+			t.Errorf("expected %s in output:\n%s", line, out)
+		}
+	}
+
+	p := NewMasterPlaylist()
+	if err := p.DecodeFrom(bytes.NewBufferString(out), true); err != nil {
+		t.Fatal(err)
+	}
+	if p.Variants[3].PathwayId != "CDN-B" {
+		t.Errorf("PATHWAY-ID not decoded: %q", p.Variants[3].PathwayId)
+	}
+
+	if err := m.DuplicatePathway(DefaultPathwayId, "CDN-B", rewrite); err == nil {
+		t.Error("expected error for existing pathway")
+	}
+	if err := m.DuplicatePathway("CDN-C", "CDN-D", rewrite); err == nil {
+		t.Error("expected error for pathway without variants")
+	}
+}
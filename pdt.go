@@ -0,0 +1,54 @@
+package m3u8
+
+/*
+ Part of M3U8 parser & generator library.
+ This file defines propagation of program date time to appended segments.
+
+ Copyright 2013-2019 The Project Developers.
+ See the AUTHORS and LICENSE files at the top-level directory of this distribution
+ and at https://github.com/grafov/m3u8/
+
+ ॐ तारे तुत्तारे तुरे स्व
+*/
+
+import "time"
+
+// AutoProgramDateTime enables propagation of program date time to
+// appended segments. Once a segment gets EXT-X-PROGRAM-DATE-TIME
+// (appended with it or set by SetProgramDateTime), the wall-clock
+// time of the following segments is extended by durations of the
+// preceding ones. The time is written for every `every` segment and
+// for segments with EXT-X-DISCONTINUITY (appended with it or set by
+// SetDiscontinuity), so one means every segment. Segments appended
+// with own program date time keep it and restart the counting. Zero
+// disables the propagation.
+func (p *MediaPlaylist) AutoProgramDateTime(every uint) {
+	p.pdtEvery = every
+	p.pdtSince = 0
+	p.pdtLast = time.Time{}
+	if every > 0 && p.count > 0 {
+		// continue from the program time of the last segment
+		if times := programTimes(p.segments()); times != nil {
+			p.pdtLast = times[len(times)-1]
+		}
+	}
+}
+
+// propagateProgramDateTime sets program date time of the appended
+// segment following the previous one when it is due.
+func (p *MediaPlaylist) propagateProgramDateTime(seg, prev *MediaSegment) {
+	if !seg.ProgramDateTime.IsZero() {
+		p.pdtLast = seg.ProgramDateTime
+		p.pdtSince = 0
+		return
+	}
+	if p.pdtLast.IsZero() || prev == nil {
+		return
+	}
+	p.pdtLast = p.pdtLast.Add(seconds(prev.Duration))
+	p.pdtSince++
+	if p.pdtSince >= p.pdtEvery || seg.Discontinuity {
+		seg.ProgramDateTime = p.pdtLast
+		p.pdtSince = 0
+	}
+}
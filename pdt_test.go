@@ -0,0 +1,67 @@
+/*
+Program date time propagation tests.
+
+Copyright 2013-2019 The Project Developers.
+See the AUTHORS and LICENSE files at the top-level directory of this distribution
+and at https://github.com/grafov/m3u8/
+
+ॐ तारे तुत्तारे तुरे स्व
+*/
+package m3u8
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestAutoProgramDateTime(t *testing.T) {
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	p, _ := NewMediaPlaylist(0, 0)
+	p.AutoProgramDateTime(3)
+	_ = p.Append("t00.ts", 6, "") // no time known yet
+	_ = p.Append("t01.ts", 6, "")
+	_ = p.SetProgramDateTime(start)
+	for i := 2; i < 9; i++ {
+		_ = p.Append(fmt.Sprintf("t%02d.ts", i), 4, "")
+		if i == 6 {
+			_ = p.SetDiscontinuity()
+		}
+	}
+	want := map[int]time.Time{
+		1: start,
+		4: start.Add(14 * time.Second),
+		6: start.Add(22 * time.Second), // discontinuity restarts the counting
+	}
+	for i, seg := range p.LiveSegments() {
+		if seg.ProgramDateTime != want[i] {
+			t.Errorf("Segment %d has program date time %v, want %v", i, seg.ProgramDateTime, want[i])
+		}
+	}
+
+	// own time of the appended segment restarts the counting
+	own := start.Add(time.Hour)
+	_ = p.AppendSegment(&MediaSegment{URI: "t09.ts", Duration: 4, ProgramDateTime: own})
+	_ = p.Append("t10.ts", 4, "")
+	_ = p.Append("t11.ts", 4, "")
+	_ = p.Append("t12.ts", 4, "")
+	if got := p.At(12).ProgramDateTime; got != own.Add(12*time.Second) {
+		t.Errorf("Expected program date time %v, got %v", own.Add(12*time.Second), got)
+	}
+	if !p.At(10).ProgramDateTime.IsZero() || !p.At(11).ProgramDateTime.IsZero() {
+		t.Error("Expected program date time every 3 segments")
+	}
+}
+
+func TestAutoProgramDateTimeContinuesPlaylist(t *testing.T) {
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	p, _ := NewMediaPlaylist(0, 3)
+	_ = p.Append("t00.ts", 6, "")
+	_ = p.SetProgramDateTime(start)
+	_ = p.Append("t01.ts", 6, "")
+	p.AutoProgramDateTime(1)
+	_ = p.Append("t02.ts", 6, "")
+	if got := p.At(2).ProgramDateTime; got != start.Add(12*time.Second) {
+		t.Errorf("Expected program date time %v, got %v", start.Add(12*time.Second), got)
+	}
+}
@@ -0,0 +1,151 @@
+package m3u8
+
+/*
+ Part of M3U8 parser & generator library.
+ This file defines the poller of live media playlists.
+
+ Copyright 2013-2019 The Project Developers.
+ See the AUTHORS and LICENSE files at the top-level directory of this distribution
+ and at https://github.com/grafov/m3u8/
+
+ ॐ तारे तुत्तारे तुरे स्व
+*/
+
+import (
+	"context"
+	"time"
+)
+
+// DefaultPollInterval is the reload interval of the poller used when
+// the playlist has no target duration or failed to load the first
+// time.
+const DefaultPollInterval = time.Second
+
+// PollUpdate is the result of one reload of the polled playlist.
+// Segments holds segments appeared since the previous reload (all
+// segments on the first reload or after restart of the media
+// sequence). Err is set when the playlist failed to load, Playlist is
+// nil then.
+type PollUpdate struct {
+	Playlist *MediaPlaylist
+	Segments []*MediaSegment
+	Time     time.Time
+	Err      error
+}
+
+// Poller reloads the live media playlist from the URI and tracks its
+// new segments by the media sequence numbers. Reloads follow section
+// 6.3.4: after the playlist changed the next reload is in the target
+// duration and in the half of it otherwise (failed reloads as well). Polling ends with
+// EXT-X-ENDLIST. Use RetryFetcher for retries of failed reloads. It is
+// not safe for concurrent use.
+type Poller struct {
+	Fetcher Fetcher
+	URI     string
+	Strict  bool
+	// MinInterval is the lower bound of the reload interval, zero
+	// means no bound.
+	MinInterval time.Duration
+
+	started   bool
+	lastSeqID uint64
+	seqNo     uint64
+	target    time.Duration
+	interval  time.Duration
+}
+
+// NewPoller returns the poller of the media playlist at the URI. Nil
+// fetcher means HTTPFetcher with http.DefaultClient.
+func NewPoller(f Fetcher, uri string) *Poller {
+	if f == nil {
+		f = &HTTPFetcher{}
+	}
+	return &Poller{Fetcher: f, URI: uri}
+}
+
+// Poll reloads the playlist once and returns the update. Error of
+// the update is returned as well.
+func (pl *Poller) Poll(ctx context.Context) (PollUpdate, error) {
+	p, err := fetchMediaPlaylist(ctx, pl.Fetcher, pl.URI, pl.Strict)
+	u := PollUpdate{Time: time.Now(), Err: err}
+	if err != nil {
+		// retry in the half of the target duration as for unchanged
+		// playlist
+		if pl.target > 0 {
+			pl.interval = pl.target / 2
+		} else {
+			pl.interval = DefaultPollInterval
+		}
+		return u, err
+	}
+	u.Playlist = p
+	restarted := pl.started && p.SeqNo < pl.seqNo
+	p.eachSegment(func(seg *MediaSegment) {
+		if !pl.started || restarted || seg.SeqId > pl.lastSeqID {
+			u.Segments = append(u.Segments, seg)
+		}
+	})
+	pl.target = seconds(p.TargetDuration)
+	pl.interval = pl.target
+	if pl.started && len(u.Segments) == 0 {
+		pl.interval /= 2
+	}
+	if pl.interval <= 0 {
+		pl.interval = DefaultPollInterval
+	}
+	if n := len(u.Segments); n > 0 {
+		pl.lastSeqID = u.Segments[n-1].SeqId
+	}
+	pl.seqNo = p.SeqNo
+	pl.started = true
+	return u, nil
+}
+
+// Next returns the interval before the next reload.
+func (pl *Poller) Next() time.Duration {
+	if pl.interval < pl.MinInterval {
+		return pl.MinInterval
+	}
+	return pl.interval
+}
+
+// Run reloads the playlist and passes each update to fn until the
+// playlist gets EXT-X-ENDLIST or the context is done. Failed reloads
+// are passed to fn as well and polling continues. It returns nil
+// after the closed playlist and the context error otherwise.
+func (pl *Poller) Run(ctx context.Context, fn func(PollUpdate)) error {
+	for {
+		u, _ := pl.Poll(ctx)
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		fn(u)
+		if u.Playlist != nil && u.Playlist.Closed {
+			return nil
+		}
+		timer := time.NewTimer(pl.Next())
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// Updates runs the poller in a goroutine and delivers updates over
+// the returned channel. The channel is closed when polling ends, see
+// Run.
+func (pl *Poller) Updates(ctx context.Context) <-chan PollUpdate {
+	ch := make(chan PollUpdate)
+	go func() {
+		defer close(ch)
+		pl.Run(ctx, func(u PollUpdate) {
+			select {
+			case ch <- u:
+			case <-ctx.Done():
+			}
+		})
+	}()
+	return ch
+}
@@ -0,0 +1,104 @@
+/*
+Poller tests.
+
+Copyright 2013-2019 The Project Developers.
+See the AUTHORS and LICENSE files at the top-level directory of this distribution
+and at https://github.com/grafov/m3u8/
+
+ॐ तारे तुत्तारे तुरे स्व
+*/
+package m3u8
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+)
+
+// pollerFetcher returns the playlists in turn, nil one means failure.
+func pollerFetcher(playlists ...string) Fetcher {
+	return FetcherFunc(func(ctx context.Context, uri string) (io.ReadCloser, error) {
+		if len(playlists) == 0 {
+			return nil, errors.New("no more playlists")
+		}
+		pl := playlists[0]
+		playlists = playlists[1:]
+		if pl == "" {
+			return nil, errors.New("origin failed")
+		}
+		return ioutil.NopCloser(strings.NewReader(pl)), nil
+	})
+}
+
+const (
+	pollerFirst = "#EXTM3U\n#EXT-X-TARGETDURATION:4\n#EXT-X-MEDIA-SEQUENCE:10\n" +
+		"#EXTINF:4,\na.ts\n#EXTINF:4,\nb.ts\n"
+	pollerSecond = "#EXTM3U\n#EXT-X-TARGETDURATION:4\n#EXT-X-MEDIA-SEQUENCE:11\n" +
+		"#EXTINF:4,\nb.ts\n#EXTINF:4,\nc.ts\n#EXTINF:4,\nd.ts\n"
+	pollerRestart = "#EXTM3U\n#EXT-X-TARGETDURATION:4\n" +
+		"#EXTINF:4,\nx.ts\n#EXT-X-ENDLIST\n"
+)
+
+func segmentURIs(segs []*MediaSegment) string {
+	var uris []string
+	for _, seg := range segs {
+		uris = append(uris, seg.URI)
+	}
+	return strings.Join(uris, " ")
+}
+
+func TestPollerPoll(t *testing.T) {
+	ctx := context.Background()
+	pl := NewPoller(pollerFetcher(pollerFirst, pollerFirst, pollerSecond, "", pollerRestart), "live.m3u8")
+	for i, expected := range []struct {
+		uris     string
+		interval time.Duration
+		err      bool
+	}{
+		{"a.ts b.ts", 4 * time.Second, false},
+		{"", 2 * time.Second, false},
+		{"c.ts d.ts", 4 * time.Second, false},
+		{"", 2 * time.Second, true},
+		{"x.ts", 4 * time.Second, false},
+	} {
+		u, err := pl.Poll(ctx)
+		if (err != nil) != expected.err || (u.Err != nil) != expected.err {
+			t.Fatalf("poll %d: unexpected error %v", i, err)
+		}
+		if uris := segmentURIs(u.Segments); uris != expected.uris {
+			t.Errorf("poll %d: new segments %q, expected %q", i, uris, expected.uris)
+		}
+		if next := pl.Next(); next != expected.interval {
+			t.Errorf("poll %d: next reload in %v, expected %v", i, next, expected.interval)
+		}
+	}
+	pl.MinInterval = 10 * time.Second
+	if next := pl.Next(); next != pl.MinInterval {
+		t.Errorf("next reload in %v, expected MinInterval", next)
+	}
+}
+
+func TestPollerUpdates(t *testing.T) {
+	closed := "#EXTM3U\n#EXT-X-TARGETDURATION:1\n#EXTINF:1,\na.ts\n#EXT-X-ENDLIST\n"
+	live := strings.Replace(closed, "#EXT-X-ENDLIST\n", "", 1)
+	pl := NewPoller(pollerFetcher(live, "", closed), "live.m3u8")
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	var updates []PollUpdate
+	for u := range pl.Updates(ctx) {
+		updates = append(updates, u)
+	}
+	if ctx.Err() != nil {
+		t.Fatal("polling didn't stop on EXT-X-ENDLIST")
+	}
+	if len(updates) != 3 || updates[1].Err == nil || !updates[2].Playlist.Closed {
+		t.Fatalf("unexpected updates %+v", updates)
+	}
+	if len(updates[2].Segments) != 0 {
+		t.Errorf("unexpected new segments %s", segmentURIs(updates[2].Segments))
+	}
+}
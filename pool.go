@@ -0,0 +1,67 @@
+package m3u8
+
+/*
+ Part of M3U8 parser & generator library.
+ This file defines pooling of media segments for long-running live playlists.
+
+ Copyright 2013-2019 The Project Developers.
+ See the AUTHORS and LICENSE files at the top-level directory of this distribution
+ and at https://github.com/grafov/m3u8/
+
+ ॐ तारे तुत्तारे तुरे स्व
+*/
+
+import (
+	"sync"
+)
+
+// SegmentPool keeps MediaSegment objects evicted from sliding
+// playlists for reuse. Live playlists which slide for weeks then
+// don't allocate a new segment for each appended chunk. A pool is
+// safe for concurrent use and may be shared by many playlists.
+type SegmentPool struct {
+	pool sync.Pool
+}
+
+// NewSegmentPool creates a new empty pool of media segments.
+func NewSegmentPool() *SegmentPool {
+	sp := new(SegmentPool)
+	sp.pool.New = func() interface{} {
+		return new(MediaSegment)
+	}
+	return sp
+}
+
+// Get returns a zeroed segment from the pool or allocates a new one.
+func (sp *SegmentPool) Get() *MediaSegment {
+	return sp.pool.Get().(*MediaSegment)
+}
+
+// Put resets the segment and returns it to the pool. The segment
+// must not be used by the caller after that.
+func (sp *SegmentPool) Put(seg *MediaSegment) {
+	if seg == nil {
+		return
+	}
+	*seg = MediaSegment{}
+	sp.pool.Put(seg)
+}
+
+// SetSegmentPool makes the playlist take new segments from the pool
+// in Append and Slide and return segments removed by Remove and Slide
+// back to the pool. Pass nil to disable pooling. Callers must not
+// keep references to removed segments when pooling is enabled.
+// Segments passed to the callback of SetOnEvict are not returned to
+// the pool as the callback may keep them.
+func (p *MediaPlaylist) SetSegmentPool(sp *SegmentPool) {
+	p.pool = sp
+}
+
+// newSegment returns a new segment taken from the pool when the
+// playlist has one.
+func (p *MediaPlaylist) newSegment() *MediaSegment {
+	if p.pool != nil {
+		return p.pool.Get()
+	}
+	return new(MediaSegment)
+}
@@ -0,0 +1,83 @@
+/*
+Segment pool tests.
+
+Copyright 2013-2019 The Project Developers.
+See the AUTHORS and LICENSE files at the top-level directory of this distribution
+and at https://github.com/grafov/m3u8/
+
+ॐ तारे तुत्तारे तुरे स्व
+*/
+package m3u8
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestSegmentPoolReset(t *testing.T) {
+	sp := NewSegmentPool()
+	seg := sp.Get()
+	seg.URI = "test.ts"
+	seg.Key = &Key{Method: "AES-128", URI: "key"}
+	sp.Put(seg)
+	if seg.URI != "" || seg.Key != nil {
+		t.Errorf("Segment returned to the pool must be reset: %+v", seg)
+	}
+}
+
+func TestSlideWithSegmentPool(t *testing.T) {
+	p, e := NewMediaPlaylist(3, 3)
+	if e != nil {
+		t.Fatalf("Create media playlist failed: %s", e)
+	}
+	p.SetSegmentPool(NewSegmentPool())
+	for i := 0; i < 10; i++ {
+		p.Slide(fmt.Sprintf("test%d.ts", i), 5.0, "")
+	}
+	if p.Count() != 3 {
+		t.Fatalf("Count = %d, want 3", p.Count())
+	}
+	expected := `#EXTM3U
+#EXT-X-VERSION:3
+#EXT-X-MEDIA-SEQUENCE:7
+#EXT-X-TARGETDURATION:5
+#EXTINF:5.000,
+test7.ts
+#EXTINF:5.000,
+test8.ts
+#EXTINF:5.000,
+test9.ts
+`
+	if p.String() != expected {
+		t.Errorf("Unexpected playlist:\n%s\nwant:\n%s", p, expected)
+	}
+}
+
+func TestSegmentPoolWithOnEvict(t *testing.T) {
+	p, _ := NewMediaPlaylist(2, 4)
+	p.SetSegmentPool(NewSegmentPool())
+	var evicted []*MediaSegment
+	p.SetOnEvict(func(seg *MediaSegment) { evicted = append(evicted, seg) })
+	for i := 0; i < 4; i++ {
+		p.Slide(fmt.Sprintf("test%d.ts", i), 5.0, "")
+	}
+	if err := p.RemoveAt(1); err != nil {
+		t.Fatal(err)
+	}
+	if len(evicted) != 3 {
+		t.Fatalf("expected 3 evicted segments, got %d", len(evicted))
+	}
+	for i, uri := range []string{"test0.ts", "test1.ts", "test3.ts"} {
+		if evicted[i].URI != uri {
+			t.Errorf("evicted segment %d is reset or reused: %+v", i, evicted[i])
+		}
+	}
+}
+
+func BenchmarkSlideWithSegmentPool(b *testing.B) {
+	p, _ := NewMediaPlaylist(6, 6)
+	p.SetSegmentPool(NewSegmentPool())
+	for i := 0; i < b.N; i++ {
+		p.Slide("test.ts", 6.0, "")
+	}
+}
@@ -0,0 +1,110 @@
+package m3u8
+
+/*
+ Part of M3U8 parser & generator library.
+ This file defines preload hints of low-latency playlists.
+
+ Copyright 2013-2019 The Project Developers.
+ See the AUTHORS and LICENSE files at the top-level directory of this distribution
+ and at https://github.com/grafov/m3u8/
+
+ ॐ तारे तुत्तारे तुरे स्व
+*/
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// Types of preload hints.
+const (
+	PreloadHintPart = "PART" // the next partial segment
+	PreloadHintMap  = "MAP"  // the next media initialization section
+)
+
+// PreloadHint represents EXT-X-PRELOAD-HINT tag which allows clients
+// to request the resource before it is available (section 4.4.5.3 of
+// rfc8216bis).
+type PreloadHint struct {
+	Type   string // PreloadHintPart or PreloadHintMap
+	URI    string
+	Start  int64 // BYTERANGE-START, zero if not set
+	Length int64 // BYTERANGE-LENGTH, zero for the rest of the resource
+}
+
+// SetPreloadHint sets the preload hint of the playlist replacing the
+// hint of the same type, as the playlist may contain only one hint of
+// each type. It returns error for unknown types and hints without
+// URI. This operation does reset playlist cache.
+func (p *MediaPlaylist) SetPreloadHint(hint *PreloadHint) error {
+	if hint.Type != PreloadHintPart && hint.Type != PreloadHintMap {
+		return fmt.Errorf("unknown preload hint type %q", hint.Type)
+	}
+	if hint.URI == "" {
+		return errors.New("preload hint without URI")
+	}
+	p.RemovePreloadHint(hint.Type)
+	p.PreloadHints = append(p.PreloadHints, hint)
+	return nil
+}
+
+// RemovePreloadHint removes the preload hint of the type. This
+// operation does reset playlist cache.
+func (p *MediaPlaylist) RemovePreloadHint(hintType string) {
+	hints := p.PreloadHints[:0]
+	for _, h := range p.PreloadHints {
+		if h.Type != hintType {
+			hints = append(hints, h)
+		}
+	}
+	p.PreloadHints = hints
+	p.buf.Reset()
+}
+
+// decodePreloadHint parses attributes of EXT-X-PRELOAD-HINT tag.
+func decodePreloadHint(line string, strict bool) (*PreloadHint, error) {
+	hint := new(PreloadHint)
+	for k, v := range decodeParamsLine(line) {
+		var err error
+		switch k {
+		case "TYPE":
+			hint.Type = v
+		case "URI":
+			hint.URI = v
+		case "BYTERANGE-START":
+			hint.Start, err = strconv.ParseInt(v, 10, 64)
+		case "BYTERANGE-LENGTH":
+			hint.Length, err = strconv.ParseInt(v, 10, 64)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %s: %v", k, v, err)
+		}
+	}
+	if strict && (hint.Type == "" || hint.URI == "") {
+		return nil, errors.New("EXT-X-PRELOAD-HINT without TYPE or URI")
+	}
+	return hint, nil
+}
+
+// writePreloadHint writes EXT-X-PRELOAD-HINT tag.
+func writePreloadHint(buf *bytes.Buffer, hint *PreloadHint) {
+	buf.WriteString("#EXT-X-PRELOAD-HINT:")
+	preloadHintAttrs(hint).writeTo(buf, nil)
+	buf.WriteRune('\n')
+}
+
+// preloadHintAttrs returns attributes of EXT-X-PRELOAD-HINT tag.
+func preloadHintAttrs(hint *PreloadHint) attrList {
+	var attrs attrList
+	attrs.add("TYPE", hint.Type)
+	attrs.quoted("URI", hint.URI)
+	if hint.Start > 0 {
+		attrs.add("BYTERANGE-START", strconv.FormatInt(hint.Start, 10))
+	}
+	if hint.Length > 0 {
+		attrs.add("BYTERANGE-LENGTH", strconv.FormatInt(hint.Length, 10))
+	}
+	return attrs
+}
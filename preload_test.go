@@ -0,0 +1,61 @@
+/*
+Preload hints tests.
+
+Copyright 2013-2019 The Project Developers.
+See the AUTHORS and LICENSE files at the top-level directory of this distribution
+and at https://github.com/grafov/m3u8/
+
+ॐ तारे तुत्तारे तुरे स्व
+*/
+package m3u8
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSetPreloadHint(t *testing.T) {
+	p, err := NewMediaPlaylist(3, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.PartTargetDuration = 1
+	_ = p.Append("seg0.mp4", 2, "")
+	_ = p.AppendPartial(&PartialSegment{URI: "seg1.part0.mp4", Duration: 1, Independent: true})
+	if err = p.SetPreloadHint(&PreloadHint{Type: PreloadHintPart, URI: "seg1.part0.mp4"}); err != nil {
+		t.Fatal(err)
+	}
+	if err = p.SetPreloadHint(&PreloadHint{Type: PreloadHintPart, URI: "seg1.mp4", Start: 1000}); err != nil {
+		t.Fatal(err)
+	}
+	if err = p.SetPreloadHint(&PreloadHint{Type: PreloadHintMap, URI: "init1.mp4", Length: 720}); err != nil {
+		t.Fatal(err)
+	}
+	if err = p.SetPreloadHint(&PreloadHint{Type: "SEGMENT", URI: "seg1.mp4"}); err == nil {
+		t.Error("expected error for unknown type")
+	}
+	if err = p.SetPreloadHint(&PreloadHint{Type: PreloadHintMap}); err == nil {
+		t.Error("expected error for hint without URI")
+	}
+	expected := `#EXT-X-PART:DURATION=1,URI="seg1.part0.mp4",INDEPENDENT=YES
+#EXT-X-PRELOAD-HINT:TYPE=PART,URI="seg1.mp4",BYTERANGE-START=1000
+#EXT-X-PRELOAD-HINT:TYPE=MAP,URI="init1.mp4",BYTERANGE-LENGTH=720
+`
+	out := p.String()
+	if !strings.HasSuffix(out, expected) {
+		t.Fatalf("unexpected output:\n%s", out)
+	}
+
+	q, _ := NewMediaPlaylist(0, 3)
+	if err = q.DecodeFrom(strings.NewReader(out), true); err != nil {
+		t.Fatal(err)
+	}
+	if len(q.PreloadHints) != 2 || *q.PreloadHints[0] != *p.PreloadHints[0] || *q.PreloadHints[1] != *p.PreloadHints[1] {
+		t.Errorf("unexpected decoded hints: %+v", q.PreloadHints)
+	}
+
+	p.RemovePreloadHint(PreloadHintMap)
+	if strings.Contains(p.String(), "TYPE=MAP") {
+		t.Error("MAP hint not removed")
+	}
+}
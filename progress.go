@@ -0,0 +1,64 @@
+package m3u8
+
+/*
+ Part of M3U8 parser & generator library.
+ This file defines progress reporting and cancellation of decoding.
+
+ Copyright 2013-2019 The Project Developers.
+ See the AUTHORS and LICENSE files at the top-level directory of this distribution
+ and at https://github.com/grafov/m3u8/
+
+ ॐ तारे तुत्तारे तुरे स्व
+*/
+
+import (
+	"context"
+	"strings"
+)
+
+// DefaultProgressInterval is the number of decoded segments (or
+// variants) between calls of DecodeOptions.Progress when
+// ProgressInterval is not set.
+const DefaultProgressInterval = 1000
+
+// progress calls the progress callback of decoding and checks the
+// context of DecodeContext after each decoded segment or variant.
+type progress struct {
+	ctx      context.Context
+	fn       func(decoded int) error
+	interval int
+	decoded  int
+}
+
+func newProgress(opts DecodeOptions) *progress {
+	if opts.Progress == nil && opts.ctx == nil {
+		return nil
+	}
+	interval := opts.ProgressInterval
+	if interval <= 0 {
+		interval = DefaultProgressInterval
+	}
+	return &progress{ctx: opts.ctx, fn: opts.Progress, interval: interval}
+}
+
+// check counts decoded URI lines and returns the context error or the error
+// of the callback to abort decoding.
+func (d *progress) check(line string) error {
+	if d == nil {
+		return nil
+	}
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return nil
+	}
+	d.decoded++
+	if d.ctx != nil {
+		if err := d.ctx.Err(); err != nil {
+			return err
+		}
+	}
+	if d.fn != nil && d.decoded%d.interval == 0 {
+		return d.fn(d.decoded)
+	}
+	return nil
+}
@@ -0,0 +1,229 @@
+package m3u8
+
+/*
+ Part of M3U8 parser & generator library.
+ This file defines checking of values which can't be written.
+
+ Copyright 2013-2019 The Project Developers.
+ See the AUTHORS and LICENSE files at the top-level directory of this distribution
+ and at https://github.com/grafov/m3u8/
+
+ ॐ तारे तुत्तारे तुरे स्व
+*/
+
+import (
+	"fmt"
+	"strings"
+)
+
+// check returns error for the first attribute which can't be written
+// to the attribute list: unquoted values (enumerated strings, numbers
+// etc.) must not be empty or contain commas, double quotes or
+// whitespace, quoted strings must not contain double quotes or line
+// breaks as quoted-string has no escaping (section 4.2).
+func (l attrList) check() error {
+	for _, a := range l {
+		if strings.HasPrefix(a.value, `"`) {
+			if strings.ContainsAny(a.value[1:len(a.value)-1], "\"\r\n") {
+				return fmt.Errorf("attribute %s: quoted string %s must not contain double quote or line break", a.name, a.value)
+			}
+			continue
+		}
+		if a.value == "" || strings.ContainsAny(a.value, "\", \t\r\n") {
+			return fmt.Errorf("attribute %s: value %q can't be written unquoted", a.name, a.value)
+		}
+	}
+	return nil
+}
+
+// checkURILine returns error if the URI written on its own line
+// contains line breaks.
+func checkURILine(uri string) error {
+	if strings.ContainsAny(uri, "\r\n") {
+		return fmt.Errorf("URI %q contains line break", uri)
+	}
+	return nil
+}
+
+// checkTitle returns error if the title of EXTINF contains line
+// breaks.
+func checkTitle(title string) error {
+	if strings.ContainsAny(title, "\r\n") {
+		return fmt.Errorf("title %q contains line break", title)
+	}
+	return nil
+}
+
+// checkTag returns error of the attributes of the tag which can't be
+// written, see attrList.check.
+func checkTag(tag string, attrs attrList) error {
+	if err := attrs.check(); err != nil {
+		return fmt.Errorf("%s: %s", tag, err)
+	}
+	return nil
+}
+
+// checkDefines returns error for the first EXT-X-DEFINE which can't
+// be written.
+func checkDefines(defines []*Define) error {
+	for _, d := range defines {
+		if d == nil {
+			continue
+		}
+		if err := checkTag("EXT-X-DEFINE", defineAttrs(d)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CheckValues returns error for the first value of the media playlist
+// which can't be written correctly: unquoted attribute values of the
+// tags, quoted strings with double quotes or line breaks and URIs and
+// titles of the segments with line breaks. Values are written as is,
+// e.g. percent-encoded URIs are kept unchanged. EncodeStrict returns
+// the same error.
+func (p *MediaPlaylist) CheckValues() error {
+	if err := checkDefines(p.Defines); err != nil {
+		return err
+	}
+	if p.Key != nil {
+		if err := checkTag("EXT-X-KEY", keyAttrs(p.Key)); err != nil {
+			return err
+		}
+	}
+	if p.Map != nil {
+		if err := checkTag("EXT-X-MAP", mapAttrs(p.Map)); err != nil {
+			return err
+		}
+	}
+	if p.Skip != nil {
+		if err := checkTag("EXT-X-SKIP", skipAttrs(p.Skip)); err != nil {
+			return err
+		}
+	}
+	var err error
+	p.eachSegment(func(seg *MediaSegment) {
+		if err != nil {
+			return
+		}
+		err = checkSegmentValues(seg)
+		if err != nil {
+			err = fmt.Errorf("segment %d: %s", seg.SeqId, err)
+		}
+	})
+	if err != nil {
+		return err
+	}
+	for _, part := range p.PendingPartials {
+		if err := checkTag("EXT-X-PART", partialAttrs(part)); err != nil {
+			return err
+		}
+	}
+	for _, hint := range p.PreloadHints {
+		if err := checkTag("EXT-X-PRELOAD-HINT", preloadHintAttrs(hint)); err != nil {
+			return err
+		}
+	}
+	for _, r := range p.RenditionReports {
+		if err := checkTag("EXT-X-RENDITION-REPORT", renditionReportAttrs(r)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkSegmentValues returns error for the first value of the segment
+// which can't be written, see MediaPlaylist.CheckValues.
+func checkSegmentValues(seg *MediaSegment) error {
+	if err := checkURILine(seg.URI); err != nil {
+		return err
+	}
+	if err := checkTitle(seg.Title); err != nil {
+		return err
+	}
+	if seg.SCTE != nil {
+		switch seg.SCTE.Syntax {
+		case SCTE35_67_2014:
+			if strings.ContainsAny(seg.SCTE.Cue+seg.SCTE.ID, "\"\r\n") {
+				return fmt.Errorf("EXT-SCTE35: CUE or ID must not contain double quote or line break")
+			}
+		case SCTE35_ADOBE:
+			if err := checkTag("EXT-X-CUE", adobeCueAttrs(seg.SCTE)); err != nil {
+				return err
+			}
+		}
+	}
+	if seg.Key != nil {
+		if err := checkTag("EXT-X-KEY", keyAttrs(seg.Key)); err != nil {
+			return err
+		}
+	}
+	for _, dr := range seg.DateRange {
+		if err := checkTag(fmt.Sprintf("EXT-X-DATERANGE %q", dr.ID), dateRangeAttrs(dr)); err != nil {
+			return err
+		}
+	}
+	if seg.Map != nil {
+		if err := checkTag("EXT-X-MAP", mapAttrs(seg.Map)); err != nil {
+			return err
+		}
+	}
+	for _, part := range seg.Partials {
+		if err := checkTag("EXT-X-PART", partialAttrs(part)); err != nil {
+			return err
+		}
+	}
+	if seg.Tiles != nil {
+		if err := checkTag("EXT-X-TILES", tilesAttrs(seg.Tiles)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CheckValues returns error for the first value of the master
+// playlist which can't be written correctly: unquoted attribute
+// values of the tags, quoted strings with double quotes or line
+// breaks and URIs of the variants with line breaks. EncodeStrict
+// returns the same error.
+func (p *MasterPlaylist) CheckValues() error {
+	if err := checkDefines(p.Defines); err != nil {
+		return err
+	}
+	if p.ContentSteering != nil {
+		if err := checkTag("EXT-X-CONTENT-STEERING", contentSteeringAttrs(p.ContentSteering)); err != nil {
+			return err
+		}
+	}
+	for _, sd := range p.SessionData {
+		if sd == nil {
+			continue
+		}
+		if err := checkTag(fmt.Sprintf("EXT-X-SESSION-DATA %q", sd.DataID), sessionDataAttrs(sd)); err != nil {
+			return err
+		}
+	}
+	for _, v := range p.Variants {
+		if v == nil {
+			continue
+		}
+		if !v.Iframe && !v.Images {
+			if err := checkURILine(v.URI); err != nil {
+				return fmt.Errorf("variant %q: %s", v.URI, err)
+			}
+		}
+		if err := variantAttrs(v).check(); err != nil {
+			return fmt.Errorf("variant %q: %s", v.URI, err)
+		}
+		for _, alt := range v.Alternatives {
+			if alt == nil {
+				continue
+			}
+			if err := alternativeAttrs(alt).check(); err != nil {
+				return fmt.Errorf("rendition %q: %s", alt.Name, err)
+			}
+		}
+	}
+	return nil
+}
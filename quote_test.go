@@ -0,0 +1,141 @@
+/*
+Quoting tests.
+
+Copyright 2013-2019 The Project Developers.
+See the AUTHORS and LICENSE files at the top-level directory of this distribution
+and at https://github.com/grafov/m3u8/
+
+ॐ तारे तुत्तारे तुरे स्व
+*/
+package m3u8
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestPercentEncodedValuesKept(t *testing.T) {
+	const uri = "https://example.com/key?d=%7B%22a%22%3A1%7D&x=%0a"
+	p, _ := NewMediaPlaylist(0, 2)
+	p.Append("a.ts", 5, "100%25")
+	p.SetKey("AES-128", uri, "", "", "")
+	p.Close()
+	if err := p.CheckValues(); err != nil {
+		t.Fatal(err)
+	}
+	out := p.String()
+	if !strings.Contains(out, `URI="`+uri+`"`) || !strings.Contains(out, "#EXTINF:5.000,100%25\n") {
+		t.Fatalf("values are changed in\n%s", out)
+	}
+	decoded, _, err := DecodeFrom(bytes.NewBufferString(out), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	seg := decoded.(*MediaPlaylist).At(0)
+	if seg.Key == nil || seg.Key.URI != uri {
+		t.Errorf("decoded key %+v", seg.Key)
+	}
+	if seg.Title != "100%25" {
+		t.Errorf("decoded title %q", seg.Title)
+	}
+	if v := DecodeAttributeList(`URI="a%22b%0A"`)["URI"]; v != "a%22b%0A" {
+		t.Errorf("decoded attribute %q", v)
+	}
+}
+
+func TestCheckValues(t *testing.T) {
+	m := NewMasterPlaylist()
+	m.Append("a.m3u8", nil, VariantParams{Bandwidth: 1000, Alternatives: []*Alternative{{GroupId: "aac", Type: "AUDIO, VIDEO", Name: "x"}}})
+	if err := m.CheckValues(); err == nil {
+		t.Error("expected error for TYPE with comma")
+	}
+	m = NewMasterPlaylist()
+	m.Append("a.m3u8\nb.m3u8", nil, VariantParams{Bandwidth: 1000})
+	if err := m.CheckValues(); err == nil {
+		t.Error("expected error for variant URI with line break")
+	}
+	m = NewMasterPlaylist()
+	m.Append("a.m3u8", nil, VariantParams{Bandwidth: 1000, Alternatives: []*Alternative{{GroupId: "aac", Type: "AUDIO", Name: `Director's "cut"`}}})
+	if err := m.CheckValues(); err == nil {
+		t.Error("expected error for NAME with double quotes")
+	}
+
+	p, _ := NewMediaPlaylist(0, 2)
+	p.Append("a.ts", 5, "first\nline")
+	if err := p.CheckValues(); err == nil {
+		t.Error("expected error for title with line break")
+	}
+	p, _ = NewMediaPlaylist(0, 2)
+	p.Append("a.ts", 5, "")
+	p.SetKey("AES-128", "key\n", "", "", "")
+	if err := p.CheckValues(); err == nil {
+		t.Error("expected error for key URI with line break")
+	}
+	p, _ = NewMediaPlaylist(0, 2)
+	p.Append("a.ts", 5, "")
+	p.SetKey("AES 128", "key", "", "", "")
+	if err := p.CheckValues(); err == nil {
+		t.Error("expected error for METHOD with whitespace")
+	}
+	found := false
+	for _, v := range p.Validate() {
+		if v.Rule == "values" {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("expected values violation")
+	}
+}
+
+func TestCheckValuesAllTags(t *testing.T) {
+	newMedia := func() *MediaPlaylist {
+		p, _ := NewMediaPlaylist(0, 2)
+		p.Append("a.ts", 5, "")
+		return p
+	}
+	media := map[string]func(p *MediaPlaylist){
+		"EXT-X-MAP":    func(p *MediaPlaylist) { p.Map = &Map{URI: `init".mp4`} },
+		"segment map":  func(p *MediaPlaylist) { p.SetMap("init\n.mp4", 0, 0) },
+		"EXT-X-PART":   func(p *MediaPlaylist) { p.At(0).Partials = []*PartialSegment{{URI: `p".mp4`, Duration: 1}} },
+		"pending part": func(p *MediaPlaylist) { p.PendingPartials = []*PartialSegment{{URI: "p\n.mp4", Duration: 1}} },
+		"report":       func(p *MediaPlaylist) { p.RenditionReports = []*RenditionReport{{URI: `r".m3u8`, LastPart: -1}} },
+		"EXT-X-DEFINE": func(p *MediaPlaylist) { p.Defines = []*Define{{Name: "a", Value: `x"y`}} },
+		"EXT-X-CUE":    func(p *MediaPlaylist) { p.At(0).SCTE = &SCTE{Syntax: SCTE35_ADOBE, ID: `a"b`} },
+	}
+	for name, set := range media {
+		p := newMedia()
+		set(p)
+		if err := p.CheckValues(); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+		if _, err := p.EncodeStrict(); err == nil {
+			t.Errorf("%s: expected error of EncodeStrict", name)
+		}
+	}
+
+	master := map[string]func(m *MasterPlaylist){
+		"EXT-X-SESSION-DATA": func(m *MasterPlaylist) {
+			m.SessionData = []*SessionData{{DataID: "com.example.title", Value: `say "hi"`}}
+		},
+		"EXT-X-CONTENT-STEERING": func(m *MasterPlaylist) { m.ContentSteering = &ContentSteering{ServerURI: "steer\n.json"} },
+	}
+	for name, set := range master {
+		m := NewMasterPlaylist()
+		m.Append("a.m3u8", nil, VariantParams{Bandwidth: 1000})
+		set(m)
+		if err := m.CheckValues(); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+		if _, err := m.EncodeStrict(); err == nil {
+			t.Errorf("%s: expected error of EncodeStrict", name)
+		}
+	}
+
+	p := newMedia()
+	p.SetMap("init.mp4", 0, 0)
+	if _, err := p.EncodeStrict(); err != nil {
+		t.Errorf("unexpected error %s", err)
+	}
+}
@@ -13,58 +13,133 @@ package m3u8
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"io"
-	"regexp"
 	"strconv"
 	"strings"
 	"time"
 )
 
-var reKeyValue = regexp.MustCompile(`([a-zA-Z0-9_-]+)=("[^"]+"|[^",]+)`)
-
 // TimeParse allows globally apply and/or override Time Parser function.
 // Available variants:
 //   - FullTimeParse - implements full featured ISO/IEC 8601:2004
 //   - StrictTimeParse - implements only RFC3339 Nanoseconds format
 var TimeParse func(value string) (time.Time, error) = FullTimeParse
 
+// DecodeOptions controls decoding of playlists.
+type DecodeOptions struct {
+	// Strict makes decoder to return the first syntax error.
+	Strict bool
+	// CustomDecoders used for decoding of custom and unsupported tags.
+	CustomDecoders []CustomDecoder
+	// PreserveAttributeOrder makes decoder to record the source order
+	// of attributes for each decoded tag. Encoder reproduces the
+	// recorded order, attributes added after decoding follow the
+	// recorded ones.
+	PreserveAttributeOrder bool
+	// SourceMap makes decoder to record line numbers of segments,
+	// variants and header tags, see SourceMap type.
+	SourceMap bool
+	// LinkSCTE35DateRanges makes decoder to set SCTE field of the
+	// segment from the first EXT-X-DATERANGE of the segment carrying
+	// SCTE35-OUT, SCTE35-IN or SCTE35-CMD attribute (with syntax
+	// SCTE35_DATERANGE) unless the segment has other SCTE-35 tags.
+	LinkSCTE35DateRanges bool
+	// Limits restricts resources consumed by decoding of untrusted
+	// input, see Limits type.
+	Limits Limits
+	// Progress is called every ProgressInterval decoded segments of
+	// the media playlist (variants of the master playlist) with the
+	// number of segments (variants) decoded so far. Error returned by
+	// the callback aborts decoding and is returned by the decoder.
+	Progress func(decoded int) error
+	// ProgressInterval is the number of segments (variants) between
+	// calls of Progress, DefaultProgressInterval if not set.
+	ProgressInterval int
+	// PreserveUnknownTags makes decoder to keep tags (and comments)
+	// which it doesn't support verbatim in UnknownTags fields of the
+	// playlist, its segments and variants, so encoder writes them back
+	// at the same positions. Tags handled by custom decoders are not
+	// affected.
+	PreserveUnknownTags bool
+	// Fidelity makes decoder to record everything required to
+	// reproduce the source: it implies PreserveAttributeOrder and
+	// PreserveUnknownTags, keeps comments, blank lines and the source
+	// text of EXTINF durations. Encoder writes the source byte for
+	// byte while the playlist is unchanged and keeps the rest of the
+	// source formatting after changes. Streaming decoders ignore it.
+	Fidelity bool
+	// OnWarning is called in lenient mode for each violation of the
+	// specification which the strict decoder would return as the
+	// error, see DecodeWithWarnings.
+	OnWarning func(w Warning)
+
+	ctx context.Context // set by DecodeContext to abort parsing
+}
+
 // Decode parses a master playlist passed from the buffer. If `strict`
 // parameter is true then it returns first syntax error.
 func (p *MasterPlaylist) Decode(data bytes.Buffer, strict bool) error {
-	return p.decode(&data, strict)
+	return p.decode(&data, DecodeOptions{Strict: strict})
 }
 
 // DecodeFrom parses a master playlist passed from the io.Reader
 // stream.  If `strict` parameter is true then it returns first syntax
 // error.
 func (p *MasterPlaylist) DecodeFrom(reader io.Reader, strict bool) error {
-	buf := new(bytes.Buffer)
-	_, err := buf.ReadFrom(reader)
+	return p.DecodeWithOptions(reader, DecodeOptions{Strict: strict})
+}
+
+// DecodeWithOptions parses a master playlist passed from the io.Reader
+// stream accordingly with the options.
+func (p *MasterPlaylist) DecodeWithOptions(reader io.Reader, opts DecodeOptions) error {
+	buf, err := readInput(reader, opts.Limits)
 	if err != nil {
 		return err
 	}
-	return p.decode(buf, strict)
+	if opts.CustomDecoders != nil {
+		p.WithCustomDecoders(opts.CustomDecoders)
+	}
+	return p.decode(buf, opts)
+}
+
+// DecodeContext is the same as DecodeWithOptions but reading of the
+// stream is stopped with the context error when the context is done.
+// The context is checked between reads and after each decoded
+// segment or variant, a reader blocked in a read (e.g. a network
+// connection) should be bound to the context itself.
+func (p *MasterPlaylist) DecodeContext(ctx context.Context, reader io.Reader, opts DecodeOptions) error {
+	opts.ctx = ctx
+	return p.DecodeWithOptions(&contextReader{ctx: ctx, r: reader}, opts)
 }
 
 // WithCustomDecoders adds custom tag decoders to the master playlist for decoding
 func (p *MasterPlaylist) WithCustomDecoders(customDecoders []CustomDecoder) Playlist {
-	// Create the map if it doesn't already exist
-	if p.Custom == nil {
-		p.Custom = make(map[string]CustomTag)
-	}
-
 	p.customDecoders = customDecoders
 
 	return p
 }
 
 // Parse master playlist. Internal function.
-func (p *MasterPlaylist) decode(buf *bytes.Buffer, strict bool) error {
+func (p *MasterPlaylist) decode(buf *bytes.Buffer, opts DecodeOptions) error {
 	var eof bool
 
+	opts = opts.withFidelity()
+	source := opts.source(buf)
+	strict := opts.Strict
 	state := new(decodingState)
+	state.attrOrder = opts.PreserveAttributeOrder
+	if opts.SourceMap {
+		state.sourceMap = newSourceMap()
+	}
+	state.linkSCTE35 = opts.LinkSCTE35DateRanges
+	state.onWarning = opts.OnWarning
+	state.keepUnknown = opts.PreserveUnknownTags
+	state.fidelity = opts.Fidelity
+	limits := newLimiter(opts.Limits, p.customDecoders)
+	prog := newProgress(opts)
 
 	for !eof {
 		line, err := buf.ReadString('\n')
@@ -73,53 +148,90 @@ func (p *MasterPlaylist) decode(buf *bytes.Buffer, strict bool) error {
 		} else if err != nil {
 			break
 		}
+		state.beginLine(line)
+		if err = limits.check(state, line); err != nil {
+			return err
+		}
 		err = decodeLineOfMasterPlaylist(p, state, line, strict)
-		if strict && err != nil {
+		reportDecodeError(line, err)
+		if state.violation(err, strict) {
+			return state.parseError(line, err)
+		}
+		if err = prog.check(line); err != nil {
 			return err
 		}
 	}
-	if strict && !state.m3u {
-		return errors.New("#EXTM3U absent")
+	p.sourceMap = state.sourceMap
+	if err := state.checkHeader(strict); err != nil {
+		return err
 	}
+	p.keepSource(source)
+	reportDecoded(MASTER, len(p.Variants))
 	return nil
 }
 
 // Decode parses a media playlist passed from the buffer. If `strict`
 // parameter is true then return first syntax error.
 func (p *MediaPlaylist) Decode(data bytes.Buffer, strict bool) error {
-	return p.decode(&data, strict)
+	return p.decode(&data, DecodeOptions{Strict: strict})
 }
 
 // DecodeFrom parses a media playlist passed from the io.Reader
 // stream. If `strict` parameter is true then it returns first syntax
 // error.
 func (p *MediaPlaylist) DecodeFrom(reader io.Reader, strict bool) error {
-	buf := new(bytes.Buffer)
-	_, err := buf.ReadFrom(reader)
+	return p.DecodeWithOptions(reader, DecodeOptions{Strict: strict})
+}
+
+// DecodeWithOptions parses a media playlist passed from the io.Reader
+// stream accordingly with the options.
+func (p *MediaPlaylist) DecodeWithOptions(reader io.Reader, opts DecodeOptions) error {
+	buf, err := readInput(reader, opts.Limits)
 	if err != nil {
 		return err
 	}
-	return p.decode(buf, strict)
+	if opts.CustomDecoders != nil {
+		p.WithCustomDecoders(opts.CustomDecoders)
+	}
+	return p.decode(buf, opts)
+}
+
+// DecodeContext is the same as DecodeWithOptions but reading of the
+// stream is stopped with the context error when the context is done.
+// The context is checked between reads and after each decoded
+// segment or variant, a reader blocked in a read (e.g. a network
+// connection) should be bound to the context itself.
+func (p *MediaPlaylist) DecodeContext(ctx context.Context, reader io.Reader, opts DecodeOptions) error {
+	opts.ctx = ctx
+	return p.DecodeWithOptions(&contextReader{ctx: ctx, r: reader}, opts)
 }
 
 // WithCustomDecoders adds custom tag decoders to the media playlist for decoding
 func (p *MediaPlaylist) WithCustomDecoders(customDecoders []CustomDecoder) Playlist {
-	// Create the map if it doesn't already exist
-	if p.Custom == nil {
-		p.Custom = make(map[string]CustomTag)
-	}
-
 	p.customDecoders = customDecoders
 
 	return p
 }
 
-func (p *MediaPlaylist) decode(buf *bytes.Buffer, strict bool) error {
+func (p *MediaPlaylist) decode(buf *bytes.Buffer, opts DecodeOptions) error {
 	var eof bool
 	var line string
 	var err error
 
+	opts = opts.withFidelity()
+	source := opts.source(buf)
+	strict := opts.Strict
 	state := new(decodingState)
+	state.attrOrder = opts.PreserveAttributeOrder
+	if opts.SourceMap {
+		state.sourceMap = newSourceMap()
+	}
+	state.linkSCTE35 = opts.LinkSCTE35DateRanges
+	state.onWarning = opts.OnWarning
+	state.keepUnknown = opts.PreserveUnknownTags
+	state.fidelity = opts.Fidelity
+	limits := newLimiter(opts.Limits, p.customDecoders)
+	prog := newProgress(opts)
 	wv := new(WV)
 
 	for !eof {
@@ -128,9 +240,17 @@ func (p *MediaPlaylist) decode(buf *bytes.Buffer, strict bool) error {
 		} else if err != nil {
 			break
 		}
+		state.beginLine(line)
+		if err = limits.check(state, line); err != nil {
+			return err
+		}
 
 		err = decodeLineOfMediaPlaylist(p, wv, state, line, strict)
-		if strict && err != nil {
+		reportDecodeError(line, err)
+		if state.violation(err, strict) {
+			return state.parseError(line, err)
+		}
+		if err = prog.check(line); err != nil {
 			return err
 		}
 
@@ -138,16 +258,19 @@ func (p *MediaPlaylist) decode(buf *bytes.Buffer, strict bool) error {
 	if state.tagWV {
 		p.WV = wv
 	}
-	if strict && !state.m3u {
-		return errors.New("#EXTM3U absent")
+	p.sourceMap = state.sourceMap
+	if err := state.checkHeader(strict); err != nil {
+		return err
 	}
+	p.keepSource(source)
+	reportDecoded(MEDIA, int(p.Count()))
 	return nil
 }
 
 // Decode detects type of playlist and decodes it. It accepts bytes
 // buffer as input.
 func Decode(data bytes.Buffer, strict bool) (Playlist, ListType, error) {
-	return decode(&data, strict, nil)
+	return decode(&data, DecodeOptions{Strict: strict})
 }
 
 // DecodeFrom detects type of playlist and decodes it. It accepts data
@@ -158,7 +281,7 @@ func DecodeFrom(reader io.Reader, strict bool) (Playlist, ListType, error) {
 	if err != nil {
 		return nil, 0, err
 	}
-	return decode(buf, strict, nil)
+	return decode(buf, DecodeOptions{Strict: strict})
 }
 
 // DecodeWith detects the type of playlist and decodes it. It accepts either bytes.Buffer
@@ -166,22 +289,57 @@ func DecodeFrom(reader io.Reader, strict bool) (Playlist, ListType, error) {
 func DecodeWith(input interface{}, strict bool, customDecoders []CustomDecoder) (Playlist, ListType, error) {
 	switch v := input.(type) {
 	case bytes.Buffer:
-		return decode(&v, strict, customDecoders)
+		return decode(&v, DecodeOptions{Strict: strict, CustomDecoders: customDecoders})
 	case io.Reader:
 		buf := new(bytes.Buffer)
 		_, err := buf.ReadFrom(v)
 		if err != nil {
 			return nil, 0, err
 		}
-		return decode(buf, strict, customDecoders)
+		return decode(buf, DecodeOptions{Strict: strict, CustomDecoders: customDecoders})
 	default:
 		return nil, 0, errors.New("input must be bytes.Buffer or io.Reader type")
 	}
 }
 
+// DecodeWithOptions detects type of playlist and decodes it
+// accordingly with the options. It accepts data conformed with
+// io.Reader.
+func DecodeWithOptions(reader io.Reader, opts DecodeOptions) (Playlist, ListType, error) {
+	buf, err := readInput(reader, opts.Limits)
+	if err != nil {
+		return nil, 0, err
+	}
+	return decode(buf, opts)
+}
+
+// DecodeContext detects type of playlist and decodes it the same way
+// as DecodeWithOptions but reading of the stream is stopped with the
+// context error when the context is done. The context is checked
+// between reads and after each decoded segment or variant, a reader
+// blocked in a read (e.g. a network connection) should be bound to
+// the context itself.
+func DecodeContext(ctx context.Context, reader io.Reader, opts DecodeOptions) (Playlist, ListType, error) {
+	opts.ctx = ctx
+	return DecodeWithOptions(&contextReader{ctx: ctx, r: reader}, opts)
+}
+
+// contextReader stops reading when the context is done.
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (r *contextReader) Read(b []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.r.Read(b)
+}
+
 // Detect playlist type and decode it. May be used as decoder for both
 // master and media playlists.
-func decode(buf *bytes.Buffer, strict bool, customDecoders []CustomDecoder) (Playlist, ListType, error) {
+func decode(buf *bytes.Buffer, opts DecodeOptions) (Playlist, ListType, error) {
 	var eof bool
 	var line string
 	var master *MasterPlaylist
@@ -189,7 +347,19 @@ func decode(buf *bytes.Buffer, strict bool, customDecoders []CustomDecoder) (Pla
 	var listType ListType
 	var err error
 
+	opts = opts.withFidelity()
+	source := opts.source(buf)
+	strict := opts.Strict
+	customDecoders := opts.CustomDecoders
 	state := new(decodingState)
+	state.attrOrder = opts.PreserveAttributeOrder
+	if opts.SourceMap {
+		state.sourceMap = newSourceMap()
+	}
+	state.linkSCTE35 = opts.LinkSCTE35DateRanges
+	state.onWarning = opts.OnWarning
+	state.keepUnknown = opts.PreserveUnknownTags
+	state.fidelity = opts.Fidelity
 	wv := new(WV)
 
 	master = NewMasterPlaylist()
@@ -202,8 +372,9 @@ func decode(buf *bytes.Buffer, strict bool, customDecoders []CustomDecoder) (Pla
 	if customDecoders != nil {
 		media = media.WithCustomDecoders(customDecoders).(*MediaPlaylist)
 		master = master.WithCustomDecoders(customDecoders).(*MasterPlaylist)
-		state.custom = make(map[string]CustomTag)
 	}
+	limits := newLimiter(opts.Limits, customDecoders)
+	prog := newProgress(opts)
 
 	for !eof {
 		if line, err = buf.ReadString('\n'); err == io.EOF {
@@ -211,6 +382,10 @@ func decode(buf *bytes.Buffer, strict bool, customDecoders []CustomDecoder) (Pla
 		} else if err != nil {
 			break
 		}
+		state.beginLine(line)
+		if err = limits.check(state, line); err != nil {
+			return nil, state.listType, err
+		}
 
 		// fixes the issues https://github.com/grafov/m3u8/issues/25
 		// TODO: the same should be done in decode functions of both Master- and MediaPlaylists
@@ -220,32 +395,43 @@ func decode(buf *bytes.Buffer, strict bool, customDecoders []CustomDecoder) (Pla
 		}
 
 		err = decodeLineOfMasterPlaylist(master, state, line, strict)
-		if strict && err != nil {
-			return master, state.listType, err
+		reportDecodeError(line, err)
+		if state.violation(err, strict) {
+			return master, state.listType, state.parseError(line, err)
 		}
 
 		err = decodeLineOfMediaPlaylist(media, wv, state, line, strict)
-		if strict && err != nil {
-			return media, state.listType, err
+		reportDecodeError(line, err)
+		if state.violation(err, strict) {
+			return media, state.listType, state.parseError(line, err)
+		}
+		if err = prog.check(line); err != nil {
+			return nil, state.listType, err
 		}
 
 	}
 	if state.listType == MEDIA && state.tagWV {
 		media.WV = wv
 	}
+	master.sourceMap = state.sourceMap
+	media.sourceMap = state.sourceMap
 
-	if strict && !state.m3u {
-		return nil, listType, errors.New("#EXTM3U absent")
+	if err := state.checkHeader(strict); err != nil {
+		return nil, listType, err
 	}
 
 	switch state.listType {
 	case MASTER:
+		master.keepSource(source)
+		reportDecoded(MASTER, len(master.Variants))
 		return master, MASTER, nil
 	case MEDIA:
 		if media.Closed || media.MediaType == EVENT {
 			// VoD and Event's should show the entire playlist
 			media.SetWinSize(0)
 		}
+		media.keepSource(source)
+		reportDecoded(MEDIA, int(media.Count()))
 		return media, MEDIA, nil
 	}
 	return nil, state.listType, errors.New("can't detect playlist type")
@@ -253,39 +439,115 @@ func decode(buf *bytes.Buffer, strict bool, customDecoders []CustomDecoder) (Pla
 
 // DecodeAttributeList turns an attribute list into a key, value map. You should trim
 // any characters not part of the attribute list, such as the tag and ':'.
+// See ParseAttributeList to keep the order and the types of the values.
 func DecodeAttributeList(line string) map[string]string {
 	return decodeParamsLine(line)
 }
 
 func decodeParamsLine(line string) map[string]string {
 	out := make(map[string]string)
-	for _, kv := range reKeyValue.FindAllStringSubmatch(line, -1) {
-		k, v := kv[1], kv[2]
+	scanAttributes(line, func(k, v string) {
 		out[k] = strings.Trim(v, ` "`)
-	}
+	})
 	return out
 }
 
+// scanAttributes calls the function for each NAME=VALUE attribute of
+// the attribute list in the source order. Raw values are passed, so
+// quoted strings keep their quotes. Values are either quoted strings
+// or run up to the next comma or quote. Malformed attributes (without
+// value or with empty or unterminated quoted string) are skipped.
+func scanAttributes(line string, fn func(name, value string)) {
+	i := 0
+	for i < len(line) {
+		if !isAttributeNameChar(line[i]) {
+			i++
+			continue
+		}
+		start := i
+		for i < len(line) && isAttributeNameChar(line[i]) {
+			i++
+		}
+		if i+1 >= len(line) || line[i] != '=' {
+			continue
+		}
+		name := line[start:i]
+		i++
+		if line[i] == '"' {
+			end := strings.IndexByte(line[i+1:], '"')
+			if end <= 0 {
+				continue
+			}
+			fn(name, line[i:i+end+2])
+			i += end + 2
+			continue
+		}
+		end := i
+		for end < len(line) && line[end] != '"' && line[end] != ',' {
+			end++
+		}
+		if end > i {
+			fn(name, line[i:end])
+			i = end
+		}
+	}
+}
+
+// isAttributeNameChar reports whether the character is allowed in
+// attribute names (which are upper case by the spec, lower case is
+// accepted for compatibility).
+func isAttributeNameChar(c byte) bool {
+	return 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' || c == '-' || c == '_'
+}
+
+// record stores the source order of attribute names of the tag value
+// decoded from the attribute list.
+func (o *attrOrders) record(value interface{}, line string) {
+	if *o == nil {
+		*o = make(attrOrders)
+	}
+	var names []string
+	scanAttributes(line, func(name, _ string) {
+		names = append(names, name)
+	})
+	(*o)[value] = names
+}
+
+// copy assigns the recorded order of the src value to the dst value.
+func (o attrOrders) copy(dst, src interface{}) {
+	if order, ok := o[src]; ok {
+		o[dst] = order
+	}
+}
+
 // Parse one line of master playlist.
 func decodeLineOfMasterPlaylist(p *MasterPlaylist, state *decodingState, line string, strict bool) error {
 	var err error
 
+	blank := state.fidelity && strings.HasSuffix(line, "\n") && strings.TrimSpace(line) == ""
 	line = strings.TrimSpace(line)
+	if state.sourceMap != nil && strings.HasPrefix(line, "#EXT") {
+		state.sourceMap.recordTag(line, state.lineNo)
+	}
 
 	// check for custom tags first to allow custom parsing of existing tags
-	if p.Custom != nil {
+	if len(p.customDecoders) > 0 {
 		for _, v := range p.customDecoders {
 			if strings.HasPrefix(line, v.TagName()) {
 				t, err := v.Decode(line)
 
-				if strict && err != nil {
+				if state.violation(err, strict) {
 					return err
 				}
 
-				p.Custom[t.TagName()] = t
+				p.Custom = append(p.Custom, t)
 			}
 		}
 	}
+	if state.keepUnknown && (blank || isUnknownTag(line, p.customDecoders)) {
+		p.keepUnknownTag(state, line)
+		return nil
+	}
 
 	switch {
 	case line == "#EXTM3U": // start tag first
@@ -306,29 +568,53 @@ func decodeLineOfMasterPlaylist(p *MasterPlaylist, state *decodingState, line st
 			}
 		}
 		// EXT-X-SESSION-DATA tag MUST contain either a VALUE or URI attribute, but not both.
-		if (sessionData.Value == "" && sessionData.URI == "") || (sessionData.Value != "" && sessionData.URI != "") {
-			if strict {
-				return errors.New("either VALUE or URI must be present, but not both")
+		if (sessionData.Value == "") == (sessionData.URI == "") {
+			if state.violation(ErrSessionDataValueURI, strict) {
+				return ErrSessionDataValueURI
 			}
 		}
 		// A Playlist MUST NOT contain more than one EXT-X-SESSION-DATA tag with the
 		// same DATA-ID attribute and the same LANGUAGE attribute.
-		for _, sd := range p.SessionData {
-			if sd.DataID == sessionData.DataID && sd.Language == sessionData.Language {
-				if strict {
-					return errors.New("duplicate EXT-X-SESSION-DATA tag with the same DATA-ID and LANGUAGE")
-				}
-			}
+		if _, ok := p.LookupSessionData(sessionData.DataID, sessionData.Language); ok && strict {
+			return ErrSessionDataDuplicate
+		}
+		if state.attrOrder {
+			p.attrOrder.record(sessionData, line[20:])
 		}
 		p.SessionData = append(p.SessionData, sessionData)
 	case strings.HasPrefix(line, "#EXT-X-VERSION:"): // version tag
 		state.listType = MASTER
 		_, err = fmt.Sscanf(line, "#EXT-X-VERSION:%d", &p.ver)
-		if strict && err != nil {
+		if state.violation(err, strict) {
 			return err
 		}
 	case line == "#EXT-X-INDEPENDENT-SEGMENTS":
 		p.SetIndependentSegments(true)
+	case strings.HasPrefix(line, "#EXT-X-DEFINE:"):
+		d, err := decodeDefine(line[14:])
+		if err != nil {
+			return err
+		}
+		p.Defines = append(p.Defines, d)
+	case strings.HasPrefix(line, "#EXT-X-START:"):
+		for k, v := range decodeParamsLine(line[13:]) {
+			switch k {
+			case "TIME-OFFSET":
+				st, err := strconv.ParseFloat(v, 64)
+				if err != nil {
+					return fmt.Errorf("invalid TIME-OFFSET: %s: %v", v, err)
+				}
+				p.StartTime = st
+				p.startSet = true
+			case "PRECISE":
+				p.StartTimePrecise = v == "YES"
+			}
+		}
+	case strings.HasPrefix(line, "#EXT-X-CONTENT-STEERING:"):
+		state.listType = MASTER
+		if p.ContentSteering, err = decodeContentSteering(line[24:], strict); err != nil {
+			return err
+		}
 	case strings.HasPrefix(line, "#EXT-X-MEDIA:"):
 		var alt Alternative
 		state.listType = MASTER
@@ -340,6 +626,8 @@ func decodeLineOfMasterPlaylist(p *MasterPlaylist, state *decodingState, line st
 				alt.GroupId = v
 			case "LANGUAGE":
 				alt.Language = v
+			case "ASSOC-LANGUAGE":
+				alt.AssocLanguage = v
 			case "NAME":
 				alt.Name = v
 			case "DEFAULT":
@@ -347,8 +635,8 @@ func decodeLineOfMasterPlaylist(p *MasterPlaylist, state *decodingState, line st
 					alt.Default = true
 				} else if strings.ToUpper(v) == "NO" {
 					alt.Default = false
-				} else if strict {
-					return errors.New("value must be YES or NO")
+				} else if err = errors.New("value must be YES or NO"); state.violation(err, strict) {
+					return err
 				}
 			case "AUTOSELECT":
 				alt.Autoselect = v
@@ -364,8 +652,26 @@ func decodeLineOfMasterPlaylist(p *MasterPlaylist, state *decodingState, line st
 				alt.InstreamId = v
 			case "CHANNELS":
 				alt.Channels = v
+			case "BIT-DEPTH":
+				if alt.BitDepth, err = strconv.Atoi(v); state.violation(err, strict) {
+					return err
+				}
+			case "SAMPLE-RATE":
+				if alt.SampleRate, err = strconv.Atoi(v); state.violation(err, strict) {
+					return err
+				}
+			case "STABLE-RENDITION-ID":
+				if !validStableId(v) {
+					if err = fmt.Errorf("invalid STABLE-RENDITION-ID: %s", v); state.violation(err, strict) {
+						return err
+					}
+				}
+				alt.StableRenditionId = v
 			}
 		}
+		if state.attrOrder {
+			p.attrOrder.record(&alt, line[13:])
+		}
 		state.alternatives = append(state.alternatives, &alt)
 	case !state.tagStreamInf && strings.HasPrefix(line, "#EXT-X-STREAM-INF:"):
 		state.tagStreamInf = true
@@ -375,27 +681,52 @@ func decodeLineOfMasterPlaylist(p *MasterPlaylist, state *decodingState, line st
 			state.variant.Alternatives = state.alternatives
 			state.alternatives = nil
 		}
+		if len(p.TrailingUnknownTags) > 0 {
+			state.variant.UnknownTags = p.TrailingUnknownTags
+			p.TrailingUnknownTags = nil
+		}
 		p.Variants = append(p.Variants, state.variant)
+		if state.attrOrder {
+			p.attrOrder.record(state.variant, line[18:])
+		}
+		if state.sourceMap != nil {
+			state.sourceMap.Variants[state.variant] = state.lineNo
+		}
 		for k, v := range decodeParamsLine(line[18:]) {
 			switch k {
 			case "PROGRAM-ID":
 				var val int
 				val, err = strconv.Atoi(v)
-				if strict && err != nil {
+				if state.violation(err, strict) {
 					return err
 				}
-				state.variant.ProgramId = uint32(val)
+				state.variant.SetProgramId(uint32(val))
 			case "BANDWIDTH":
 				var val int
 				val, err = strconv.Atoi(v)
-				if strict && err != nil {
+				if state.violation(err, strict) {
 					return err
 				}
 				state.variant.Bandwidth = uint32(val)
 			case "CODECS":
 				state.variant.Codecs = v
+			case "SUPPLEMENTAL-CODECS":
+				state.variant.Supplemental = v
+			case "SCORE":
+				if state.variant.Score, err = strconv.ParseFloat(v, 64); state.violation(err, strict) {
+					return err
+				}
+			case "ALLOWED-CPC":
+				state.variant.AllowedCPC = v
+			case "REQ-VIDEO-LAYOUT":
+				state.variant.ReqVideoLayout = v
 			case "RESOLUTION":
 				state.variant.Resolution = v
+				// Width and Height are left to the caller, so changes
+				// of the decoded Resolution are written
+				if _, _, err = parseResolution(v); state.violation(err, strict) {
+					return err
+				}
 			case "AUDIO":
 				state.variant.Audio = v
 			case "VIDEO":
@@ -409,54 +740,98 @@ func decodeLineOfMasterPlaylist(p *MasterPlaylist, state *decodingState, line st
 			case "AVERAGE-BANDWIDTH":
 				var val int
 				val, err = strconv.Atoi(v)
-				if strict && err != nil {
+				if state.violation(err, strict) {
 					return err
 				}
 				state.variant.AverageBandwidth = uint32(val)
 			case "FRAME-RATE":
-				if state.variant.FrameRate, err = strconv.ParseFloat(v, 64); strict && err != nil {
+				if state.variant.FrameRate, err = strconv.ParseFloat(v, 64); state.violation(err, strict) {
 					return err
 				}
 			case "VIDEO-RANGE":
-				state.variant.VideoRange = v
+				state.variant.VideoRange = VideoRange(v)
+				if !state.variant.VideoRange.Valid() {
+					if err = fmt.Errorf("invalid VIDEO-RANGE: %s", v); state.violation(err, strict) {
+						return err
+					}
+				}
 			case "HDCP-LEVEL":
 				state.variant.HDCPLevel = v
+			case "PATHWAY-ID":
+				state.variant.PathwayId = v
+			case "STABLE-VARIANT-ID":
+				if !validStableId(v) {
+					if err = fmt.Errorf("invalid STABLE-VARIANT-ID: %s", v); state.violation(err, strict) {
+						return err
+					}
+				}
+				state.variant.StableVariantId = v
 			}
 		}
 	case state.tagStreamInf && !strings.HasPrefix(line, "#"):
 		state.tagStreamInf = false
 		state.variant.URI = line
-	case strings.HasPrefix(line, "#EXT-X-I-FRAME-STREAM-INF:"):
+	case strings.HasPrefix(line, "#EXT-X-I-FRAME-STREAM-INF:") || strings.HasPrefix(line, "#EXT-X-IMAGE-STREAM-INF:"):
 		state.listType = MASTER
 		state.variant = new(Variant)
-		state.variant.Iframe = true
+		if strings.HasPrefix(line, "#EXT-X-IMAGE-STREAM-INF:") {
+			state.variant.Images = true
+		} else {
+			state.variant.Iframe = true
+		}
+		attrs := line[strings.IndexByte(line, ':')+1:]
 		if len(state.alternatives) > 0 {
 			state.variant.Alternatives = state.alternatives
 			state.alternatives = nil
 		}
+		if len(p.TrailingUnknownTags) > 0 {
+			state.variant.UnknownTags = p.TrailingUnknownTags
+			p.TrailingUnknownTags = nil
+		}
 		p.Variants = append(p.Variants, state.variant)
-		for k, v := range decodeParamsLine(line[26:]) {
+		if state.attrOrder {
+			p.attrOrder.record(state.variant, attrs)
+		}
+		if state.sourceMap != nil {
+			state.sourceMap.Variants[state.variant] = state.lineNo
+		}
+		for k, v := range decodeParamsLine(attrs) {
 			switch k {
 			case "URI":
 				state.variant.URI = v
 			case "PROGRAM-ID":
 				var val int
 				val, err = strconv.Atoi(v)
-				if strict && err != nil {
+				if state.violation(err, strict) {
 					return err
 				}
-				state.variant.ProgramId = uint32(val)
+				state.variant.SetProgramId(uint32(val))
 			case "BANDWIDTH":
 				var val int
 				val, err = strconv.Atoi(v)
-				if strict && err != nil {
+				if state.violation(err, strict) {
 					return err
 				}
 				state.variant.Bandwidth = uint32(val)
 			case "CODECS":
 				state.variant.Codecs = v
+			case "SUPPLEMENTAL-CODECS":
+				state.variant.Supplemental = v
+			case "SCORE":
+				if state.variant.Score, err = strconv.ParseFloat(v, 64); state.violation(err, strict) {
+					return err
+				}
+			case "ALLOWED-CPC":
+				state.variant.AllowedCPC = v
+			case "REQ-VIDEO-LAYOUT":
+				state.variant.ReqVideoLayout = v
 			case "RESOLUTION":
 				state.variant.Resolution = v
+				// Width and Height are left to the caller, so changes
+				// of the decoded Resolution are written
+				if _, _, err = parseResolution(v); state.violation(err, strict) {
+					return err
+				}
 			case "AUDIO":
 				state.variant.Audio = v
 			case "VIDEO":
@@ -464,14 +839,28 @@ func decodeLineOfMasterPlaylist(p *MasterPlaylist, state *decodingState, line st
 			case "AVERAGE-BANDWIDTH":
 				var val int
 				val, err = strconv.Atoi(v)
-				if strict && err != nil {
+				if state.violation(err, strict) {
 					return err
 				}
 				state.variant.AverageBandwidth = uint32(val)
 			case "VIDEO-RANGE":
-				state.variant.VideoRange = v
+				state.variant.VideoRange = VideoRange(v)
+				if !state.variant.VideoRange.Valid() {
+					if err = fmt.Errorf("invalid VIDEO-RANGE: %s", v); state.violation(err, strict) {
+						return err
+					}
+				}
 			case "HDCP-LEVEL":
 				state.variant.HDCPLevel = v
+			case "PATHWAY-ID":
+				state.variant.PathwayId = v
+			case "STABLE-VARIANT-ID":
+				if !validStableId(v) {
+					if err = fmt.Errorf("invalid STABLE-VARIANT-ID: %s", v); state.violation(err, strict) {
+						return err
+					}
+				}
+				state.variant.StableVariantId = v
 			}
 		}
 	}
@@ -482,42 +871,52 @@ func decodeLineOfMasterPlaylist(p *MasterPlaylist, state *decodingState, line st
 func decodeLineOfMediaPlaylist(p *MediaPlaylist, wv *WV, state *decodingState, line string, strict bool) error {
 	var err error
 
+	blank := state.fidelity && strings.HasSuffix(line, "\n") && strings.TrimSpace(line) == ""
 	line = strings.TrimSpace(line)
+	if state.sourceMap != nil && strings.HasPrefix(line, "#EXT") {
+		state.sourceMap.recordTag(line, state.lineNo)
+	}
 
 	// check for custom tags first to allow custom parsing of existing tags
-	if p.Custom != nil {
+	if len(p.customDecoders) > 0 {
 		for _, v := range p.customDecoders {
 			if strings.HasPrefix(line, v.TagName()) {
 				t, err := v.Decode(line)
 
-				if strict && err != nil {
+				if state.violation(err, strict) {
 					return err
 				}
 
 				if v.SegmentTag() {
 					state.tagCustom = true
-					state.custom[v.TagName()] = t
+					state.custom = append(state.custom, t)
 				} else {
-					p.Custom[v.TagName()] = t
+					p.Custom = append(p.Custom, t)
 				}
 			}
 		}
 	}
+	if state.keepUnknown && (blank || isUnknownTag(line, p.customDecoders)) {
+		p.keepUnknownTag(state, line)
+		return nil
+	}
 
 	switch {
 	case !state.tagInf && strings.HasPrefix(line, "#EXTINF:"):
 		state.tagInf = true
 		state.listType = MEDIA
+		state.segmentLine = state.lineNo
 		sepIndex := strings.Index(line, ",")
 		if sepIndex == -1 {
-			if strict {
-				return fmt.Errorf("could not parse: %q", line)
+			if err = fmt.Errorf("could not parse: %q", line); state.violation(err, strict) {
+				return err
 			}
 			sepIndex = len(line)
 		}
 		duration := line[8:sepIndex]
+		state.durationText = duration
 		if len(duration) > 0 {
-			if state.duration, err = strconv.ParseFloat(duration, 64); strict && err != nil {
+			if state.duration, err = strconv.ParseFloat(duration, 64); state.violation(err, strict) {
 				return fmt.Errorf("duration parsing error: %s", err)
 			}
 		}
@@ -528,68 +927,89 @@ func decodeLineOfMediaPlaylist(p *MediaPlaylist, wv *WV, state *decodingState, l
 		if state.tagInf {
 			err := p.Append(line, state.duration, state.title)
 			if err == ErrPlaylistFull {
-				// Extend playlist by doubling size, reset internal state, try again.
+				// Extend playlist by doubling size and try again.
 				// If the second Append fails, the if err block will handle it.
 				// Retrying instead of being recursive was chosen as the state maybe
 				// modified non-idempotently.
-				p.Segments = append(p.Segments, make([]*MediaSegment, p.Count())...)
-				p.capacity = uint(len(p.Segments))
-				p.tail = p.count
+				p.grow()
 				err = p.Append(line, state.duration, state.title)
 			}
 			// Check err for first or subsequent Append()
 			if err != nil {
 				return err
 			}
+			if state.sourceMap != nil {
+				state.sourceMap.Segments[p.Segments[p.last()]] = state.segmentLine
+			}
+			p.Segments[p.last()].Bitrate = state.bitrate
+			if state.fidelity {
+				p.keepDurationText(p.Segments[p.last()], state.durationText)
+			}
 			state.tagInf = false
 		}
 		if state.tagRange {
-			if err = p.SetRange(state.limit, state.offset); strict && err != nil {
+			if err = p.SetRange(state.limit, state.offset); state.violation(err, strict) {
 				return err
 			}
+			if err == nil {
+				p.Segments[p.last()].offsetOmitted = state.offsetOmitted
+			}
 			state.tagRange = false
 		}
 		if state.tagSCTE35 {
 			state.tagSCTE35 = false
-			if err = p.SetSCTE35(state.scte); strict && err != nil {
+			if err = p.SetSCTE35(state.scte); state.violation(err, strict) {
 				return err
 			}
 		}
 		if state.tagDiscontinuity {
 			state.tagDiscontinuity = false
-			if err = p.SetDiscontinuity(); strict && err != nil {
+			if err = p.SetDiscontinuity(); state.violation(err, strict) {
 				return err
 			}
 		}
 		if state.tagGap {
 			state.tagGap = false
-			if err = p.SetGap(); strict && err != nil {
+			if err = p.SetGap(); state.violation(err, strict) {
 				return err
 			}
 		}
+		if !state.partDateTime.IsZero() && !state.tagProgramDateTime {
+			// the time following the parts of the segment is its own
+			state.tagProgramDateTime = true
+			state.programDateTime = state.partDateTime
+		}
+		state.partDateTime = time.Time{}
 		if state.tagProgramDateTime && p.Count() > 0 {
 			state.tagProgramDateTime = false
-			if err = p.SetProgramDateTime(state.programDateTime); strict && err != nil {
+			if err = p.SetProgramDateTime(state.programDateTime); state.violation(err, strict) {
 				return err
 			}
 		}
 		// If EXT-X-KEY appeared before reference to segment (EXTINF) then it linked to this segment
 		if state.tagKey {
 			p.Segments[p.last()].Key = &Key{state.xkey.Method, state.xkey.URI, state.xkey.IV, state.xkey.Keyformat, state.xkey.Keyformatversions}
+			p.attrOrder.copy(p.Segments[p.last()].Key, state.xkey)
 			// First EXT-X-KEY may appeared in the header of the playlist and linked to first segment
 			// but for convenient playlist generation it also linked as default playlist key
 			if p.Key == nil {
 				p.Key = state.xkey
+			} else if p.Key != state.xkey {
+				// only the order of the copy is used
+				delete(p.attrOrder, state.xkey)
 			}
 			state.tagKey = false
 		}
 		// If EXT-X-MAP appeared before reference to segment (EXTINF) then it linked to this segment
 		if state.tagMap {
 			p.Segments[p.last()].Map = &Map{state.xmap.URI, state.xmap.Limit, state.xmap.Offset}
+			p.attrOrder.copy(p.Segments[p.last()].Map, state.xmap)
 			// First EXT-X-MAP may appeared in the header of the playlist and linked to first segment
 			// but for convenient playlist generation it also linked as default playlist map
 			if p.Map == nil {
 				p.Map = state.xmap
+			} else if p.Map != state.xmap {
+				delete(p.attrOrder, state.xmap)
 			}
 			state.tagMap = false
 		}
@@ -597,13 +1017,28 @@ func decodeLineOfMediaPlaylist(p *MediaPlaylist, wv *WV, state *decodingState, l
 		// if segment custom tag appeared before EXTINF then it links to this segment
 		if state.tagCustom {
 			p.Segments[p.last()].Custom = state.custom
-			state.custom = make(map[string]CustomTag)
+			state.custom = nil
 			state.tagCustom = false
 		}
 		if len(state.daterange) > 0 {
 			p.SetDateRange(state.daterange)
+			if seg := p.Segments[p.last()]; state.linkSCTE35 && seg != nil && seg.SCTE == nil {
+				for _, dr := range state.daterange {
+					if seg.SCTE = dr.SCTE(); seg.SCTE != nil {
+						break
+					}
+				}
+			}
 			state.daterange = []*DateRange{}
 		}
+		if state.tiles != nil {
+			p.Segments[p.last()].Tiles = state.tiles
+			state.tiles = nil
+		}
+		if len(p.TrailingUnknownTags) > 0 {
+			p.Segments[p.last()].UnknownTags = p.TrailingUnknownTags
+			p.TrailingUnknownTags = nil
+		}
 	// start tag first
 	case line == "#EXTM3U":
 		state.m3u = true
@@ -612,19 +1047,34 @@ func decodeLineOfMediaPlaylist(p *MediaPlaylist, wv *WV, state *decodingState, l
 		p.Closed = true
 	case line == "#EXT-X-INDEPENDENT-SEGMENTS":
 		p.SetIndependentSegments(true)
+	case strings.HasPrefix(line, "#EXT-X-DEFINE:"):
+		d, err := decodeDefine(line[14:])
+		if err != nil {
+			return err
+		}
+		p.Defines = append(p.Defines, d)
+	case strings.HasPrefix(line, "#EXT-X-ALLOW-CACHE:"):
+		state.listType = MEDIA
+		p.AllowCache = line[19:]
+		if p.AllowCache != "YES" && p.AllowCache != "NO" {
+			if err = fmt.Errorf("invalid EXT-X-ALLOW-CACHE value: %q", p.AllowCache); state.violation(err, strict) {
+				return err
+			}
+		}
 	case strings.HasPrefix(line, "#EXT-X-VERSION:"):
 		state.listType = MEDIA
-		if _, err = fmt.Sscanf(line, "#EXT-X-VERSION:%d", &p.ver); strict && err != nil {
+		if _, err = fmt.Sscanf(line, "#EXT-X-VERSION:%d", &p.ver); state.violation(err, strict) {
 			return err
 		}
 	case strings.HasPrefix(line, "#EXT-X-TARGETDURATION:"):
 		state.listType = MEDIA
-		if _, err = fmt.Sscanf(line, "#EXT-X-TARGETDURATION:%f", &p.TargetDuration); strict && err != nil {
+		if _, err = fmt.Sscanf(line, "#EXT-X-TARGETDURATION:%f", &p.TargetDuration); state.violation(err, strict) {
 			return err
 		}
+		p.decodedTarget = p.TargetDuration
 	case strings.HasPrefix(line, "#EXT-X-MEDIA-SEQUENCE:"):
 		state.listType = MEDIA
-		if _, err = fmt.Sscanf(line, "#EXT-X-MEDIA-SEQUENCE:%d", &p.SeqNo); strict && err != nil {
+		if _, err = fmt.Sscanf(line, "#EXT-X-MEDIA-SEQUENCE:%d", &p.SeqNo); state.violation(err, strict) {
 			return err
 		}
 	case strings.HasPrefix(line, "#EXT-X-PLAYLIST-TYPE:"):
@@ -632,7 +1082,7 @@ func decodeLineOfMediaPlaylist(p *MediaPlaylist, wv *WV, state *decodingState, l
 		var playlistType string
 		_, err = fmt.Sscanf(line, "#EXT-X-PLAYLIST-TYPE:%s", &playlistType)
 		if err != nil {
-			if strict {
+			if state.violation(err, strict) {
 				return err
 			}
 		} else {
@@ -645,11 +1095,69 @@ func decodeLineOfMediaPlaylist(p *MediaPlaylist, wv *WV, state *decodingState, l
 		}
 	case strings.HasPrefix(line, "#EXT-X-DISCONTINUITY-SEQUENCE:"):
 		state.listType = MEDIA
-		if _, err = fmt.Sscanf(line, "#EXT-X-DISCONTINUITY-SEQUENCE:%d", &p.DiscontinuitySeq); strict && err != nil {
+		if _, err = fmt.Sscanf(line, "#EXT-X-DISCONTINUITY-SEQUENCE:%d", &p.DiscontinuitySeq); state.violation(err, strict) {
 			return err
 		}
-	case strings.HasPrefix(line, "#EXT-X-START:"):
+		p.dseqSet = err == nil
+	case strings.HasPrefix(line, "#EXT-X-SERVER-CONTROL:"):
+		state.listType = MEDIA
+		if p.ServerControl, err = decodeServerControl(line[22:], strict); err != nil {
+			return err
+		}
+	case strings.HasPrefix(line, "#EXT-X-PART-INF:"):
+		state.listType = MEDIA
+		if v, ok := decodeParamsLine(line[16:])["PART-TARGET"]; ok {
+			if p.PartTargetDuration, err = strconv.ParseFloat(v, 64); state.violation(err, strict) {
+				return fmt.Errorf("invalid PART-TARGET: %s: %v", v, err)
+			}
+			p.partTargetSet = p.PartTargetDuration > 0
+		} else if err = errors.New("EXT-X-PART-INF without PART-TARGET"); state.violation(err, strict) {
+			return err
+		}
+	case strings.HasPrefix(line, "#EXT-X-PART:"):
+		state.listType = MEDIA
+		var prev *PartialSegment
+		if n := len(p.PendingPartials); n > 0 {
+			prev = p.PendingPartials[n-1]
+		}
+		part, err := decodePartial(line[12:], prev, strict)
+		if err != nil {
+			if state.violation(err, strict) {
+				return err
+			}
+			break
+		}
+		part.ProgramDateTime = state.partDateTime
+		state.partDateTime = time.Time{}
+		p.PendingPartials = append(p.PendingPartials, part)
+	case strings.HasPrefix(line, "#EXT-X-PRELOAD-HINT:"):
+		state.listType = MEDIA
+		hint, err := decodePreloadHint(line[20:], strict)
+		if err != nil {
+			if state.violation(err, strict) {
+				return err
+			}
+			break
+		}
+		p.PreloadHints = append(p.PreloadHints, hint)
+	case strings.HasPrefix(line, "#EXT-X-RENDITION-REPORT:"):
 		state.listType = MEDIA
+		r, err := decodeRenditionReport(line[24:], strict)
+		if err != nil {
+			if state.violation(err, strict) {
+				return err
+			}
+			break
+		}
+		p.RenditionReports = append(p.RenditionReports, r)
+	case strings.HasPrefix(line, "#EXT-X-SKIP:"):
+		state.listType = MEDIA
+		if p.Skip, err = decodeSkip(line[12:]); err != nil {
+			return err
+		}
+	case strings.HasPrefix(line, "#EXT-X-START:"):
+		// the tag is allowed in both playlist types so it doesn't
+		// define the type
 		for k, v := range decodeParamsLine(line[13:]) {
 			switch k {
 			case "TIME-OFFSET":
@@ -658,6 +1166,7 @@ func decodeLineOfMediaPlaylist(p *MediaPlaylist, wv *WV, state *decodingState, l
 					return fmt.Errorf("invalid TIME-OFFSET: %s: %v", v, err)
 				}
 				p.StartTime = st
+				p.startSet = true
 			case "PRECISE":
 				p.StartTimePrecise = v == "YES"
 			}
@@ -665,6 +1174,9 @@ func decodeLineOfMediaPlaylist(p *MediaPlaylist, wv *WV, state *decodingState, l
 	case strings.HasPrefix(line, "#EXT-X-KEY:"):
 		state.listType = MEDIA
 		state.xkey = new(Key)
+		if state.attrOrder {
+			p.attrOrder.record(state.xkey, line[11:])
+		}
 		for k, v := range decodeParamsLine(line[11:]) {
 			switch k {
 			case "METHOD":
@@ -683,25 +1195,45 @@ func decodeLineOfMediaPlaylist(p *MediaPlaylist, wv *WV, state *decodingState, l
 	case strings.HasPrefix(line, "#EXT-X-MAP:"):
 		state.listType = MEDIA
 		state.xmap = new(Map)
+		if state.attrOrder {
+			p.attrOrder.record(state.xmap, line[11:])
+		}
 		for k, v := range decodeParamsLine(line[11:]) {
 			switch k {
 			case "URI":
 				state.xmap.URI = v
 			case "BYTERANGE":
-				if _, err = fmt.Sscanf(v, "%d@%d", &state.xmap.Limit, &state.xmap.Offset); strict && err != nil {
+				if _, err = fmt.Sscanf(v, "%d@%d", &state.xmap.Limit, &state.xmap.Offset); state.violation(err, strict) {
 					return fmt.Errorf("byterange sub-range length value parsing error: %s", err)
 				}
 			}
 		}
 		state.tagMap = true
-	case !state.tagProgramDateTime && strings.HasPrefix(line, "#EXT-X-PROGRAM-DATE-TIME:"):
+	case (state.tagProgramDateTime || len(p.PendingPartials) > 0) && strings.HasPrefix(line, "#EXT-X-PROGRAM-DATE-TIME:"):
+		// the time following parts of the segment or its own time
+		// belongs to the next part
+		state.listType = MEDIA
+		if state.partDateTime, err = TimeParse(line[25:]); state.violation(err, strict) {
+			return err
+		}
+	case strings.HasPrefix(line, "#EXT-X-PROGRAM-DATE-TIME:"):
 		state.tagProgramDateTime = true
 		state.listType = MEDIA
-		if state.programDateTime, err = TimeParse(line[25:]); strict && err != nil {
+		if state.programDateTime, err = TimeParse(line[25:]); state.violation(err, strict) {
+			return err
+		}
+		state.partDateTime = state.programDateTime
+	case strings.HasPrefix(line, "#EXT-X-BITRATE:"):
+		state.listType = MEDIA
+		if state.bitrate, err = strconv.ParseInt(line[15:], 10, 64); state.violation(err, strict) {
 			return err
 		}
 	case strings.HasPrefix(line, "#EXT-X-DATERANGE:"):
 		dr := new(DateRange)
+		if state.attrOrder {
+			p.attrOrder.record(dr, line[17:])
+		}
+		client := make(map[string]bool)
 		for k, v := range decodeParamsLine(line[17:]) {
 			switch k {
 			case "ID":
@@ -710,6 +1242,11 @@ func decodeLineOfMediaPlaylist(p *MediaPlaylist, wv *WV, state *decodingState, l
 				dr.Class = v
 			case "START-DATE":
 				dr.StartDate, _ = time.Parse(DATETIME, v)
+			case "CUE":
+				dr.Cue = v
+				if err = checkCue(splitEnumList(v)); state.violation(err, strict) {
+					return err
+				}
 			case "END-DATE":
 				dr.EndDate, _ = time.Parse(DATETIME, v)
 			case "DURATION":
@@ -730,23 +1267,53 @@ func decodeLineOfMediaPlaylist(p *MediaPlaylist, wv *WV, state *decodingState, l
 				dr.XPlayoutLimit, _ = strconv.ParseFloat(v, 64)
 			case "X-SNAP":
 				dr.XSnap = v
+				if err = checkEnumList(k, splitEnumList(v), SnapIn, SnapOut); state.violation(err, strict) {
+					return err
+				}
 			case "X-RESTRICT":
 				dr.XRestrict = v
+				if err = checkEnumList(k, splitEnumList(v), RestrictSkip, RestrictJump); state.violation(err, strict) {
+					return err
+				}
 			case "X-ASSET-URI":
 				dr.XAssetURI = v
 			case "X-ASSET-LIST":
 				dr.XAssetList = v
+			case "X-TIMELINE-OCCUPIES":
+				dr.XTimelineOccupies = v
+			case "X-TIMELINE-STYLE":
+				dr.XTimelineStyle = v
+			case "X-CONTENT-MAY-VARY":
+				dr.XContentMayVary = v
 			default:
 				if strings.HasPrefix(k, "X-") {
-					if dr.X == nil {
-						dr.X = make(map[string]string)
-					}
-					dr.X[k] = v
-				} else {
-					if strict {
-						return fmt.Errorf("unrecognized EXT-X-DATERANGE attribte: %s", k)
+					client[k] = true
+				} else if err = fmt.Errorf("unrecognized EXT-X-DATERANGE attribte: %s", k); state.violation(err, strict) {
+					return err
+				}
+			}
+		}
+		if len(client) > 0 {
+			// client-defined attributes are kept in source order with
+			// types derived from their syntax, the values must be
+			// quoted strings, hexadecimal sequences or decimal
+			// floating points (section 4.4.5.1)
+			var xerr error // the first violation aborting the decoding
+			scanAttributes(line[17:], func(name, raw string) {
+				if !client[name] || xerr != nil {
+					return
+				}
+				a := parseAttribute(name, raw)
+				if a.Type == AttrEnum || a.Type == AttrResolution {
+					if err := fmt.Errorf("invalid %s: %s", a.Name, a.Value); state.violation(err, strict) {
+						xerr = err
+						return
 					}
 				}
+				dr.X = append(dr.X, a)
+			})
+			if xerr != nil {
+				return xerr
 			}
 		}
 		state.daterange = append(state.daterange, dr)
@@ -755,11 +1322,12 @@ func decodeLineOfMediaPlaylist(p *MediaPlaylist, wv *WV, state *decodingState, l
 		state.listType = MEDIA
 		state.offset = 0
 		params := strings.SplitN(line[17:], "@", 2)
-		if state.limit, err = strconv.ParseInt(params[0], 10, 64); strict && err != nil {
+		state.offsetOmitted = len(params) < 2
+		if state.limit, err = strconv.ParseInt(params[0], 10, 64); state.violation(err, strict) {
 			return fmt.Errorf("byterange sub-range length value parsing error: %s", err)
 		}
 		if len(params) > 1 {
-			if state.offset, err = strconv.ParseInt(params[1], 10, 64); strict && err != nil {
+			if state.offset, err = strconv.ParseInt(params[1], 10, 64); state.violation(err, strict) {
 				return fmt.Errorf("byterange sub-range offset value parsing error: %s", err)
 			}
 		}
@@ -791,11 +1359,12 @@ func decodeLineOfMediaPlaylist(p *MediaPlaylist, wv *WV, state *decodingState, l
 	case !state.tagSCTE35 && strings.HasPrefix(line, "#EXT-X-CUE-OUT-CONT:"):
 		state.tagSCTE35 = true
 		state.scte = new(SCTE)
-		state.scte.Syntax = SCTE35_OATCLS
+		state.scte.Syntax = SCTE35_ELEMENTAL
 		state.scte.CueType = SCTE35Cue_Mid
 		for attribute, value := range decodeParamsLine(line[20:]) {
 			switch attribute {
 			case "SCTE35":
+				state.scte.Syntax = SCTE35_OATCLS
 				state.scte.Cue = value
 			case "Duration":
 				state.scte.Time, _ = strconv.ParseFloat(value, 64)
@@ -806,12 +1375,43 @@ func decodeLineOfMediaPlaylist(p *MediaPlaylist, wv *WV, state *decodingState, l
 	case !state.tagSCTE35 && strings.HasPrefix(line, "#EXT-X-CUE-OUT"):
 		state.tagSCTE35 = true
 		state.scte = new(SCTE)
-		state.scte.Syntax = SCTE35_OATCLS
+		state.scte.Syntax = SCTE35_ELEMENTAL
 		state.scte.CueType = SCTE35Cue_Start
-		lenLine := len(line)
-		if lenLine > 14 {
+		switch {
+		case len(line) <= 15:
+			// no duration
+		case strings.Contains(line[15:], "="):
+			if v, ok := decodeParamsLine(line[15:])["DURATION"]; ok {
+				state.scte.Time, _ = strconv.ParseFloat(v, 64)
+			}
+		default:
+			state.scte.Syntax = SCTE35_OATCLS
 			state.scte.Time, _ = strconv.ParseFloat(line[15:], 64)
 		}
+	case !state.tagSCTE35 && strings.HasPrefix(line, "#EXT-X-CUE:"):
+		state.tagSCTE35 = true
+		state.listType = MEDIA
+		state.scte = new(SCTE)
+		state.scte.Syntax = SCTE35_ADOBE
+		state.scte.CueType = SCTE35Cue_Mid
+		for attribute, value := range decodeParamsLine(line[11:]) {
+			switch attribute {
+			case "TYPE":
+				if value == AdobeCueSpliceOut {
+					state.scte.CueType = SCTE35Cue_Start
+				} else if value == AdobeCueSpliceIn {
+					state.scte.CueType = SCTE35Cue_End
+				}
+			case "ID":
+				state.scte.ID = value
+			case "DURATION":
+				state.scte.Time, _ = strconv.ParseFloat(value, 64)
+			case "TIME":
+				state.scte.Start, _ = strconv.ParseFloat(value, 64)
+			case "CUE":
+				state.scte.Cue = value
+			}
+		}
 	case !state.tagSCTE35 && line == "#EXT-X-CUE-IN":
 		state.tagSCTE35 = true
 		state.scte = new(SCTE)
@@ -826,9 +1426,17 @@ func decodeLineOfMediaPlaylist(p *MediaPlaylist, wv *WV, state *decodingState, l
 	case strings.HasPrefix(line, "#EXT-X-I-FRAMES-ONLY"):
 		state.listType = MEDIA
 		p.Iframe = true
+	case strings.HasPrefix(line, "#EXT-X-IMAGES-ONLY"):
+		state.listType = MEDIA
+		p.ImagesOnly = true
+	case strings.HasPrefix(line, "#EXT-X-TILES:"):
+		state.listType = MEDIA
+		if state.tiles, err = decodeTiles(line[13:], strict); state.violation(err, strict) {
+			return err
+		}
 	case strings.HasPrefix(line, "#WV-AUDIO-CHANNELS"):
 		state.listType = MEDIA
-		if _, err = fmt.Sscanf(line, "#WV-AUDIO-CHANNELS %d", &wv.AudioChannels); strict && err != nil {
+		if _, err = fmt.Sscanf(line, "#WV-AUDIO-CHANNELS %d", &wv.AudioChannels); state.violation(err, strict) {
 			return err
 		}
 		if err == nil {
@@ -836,7 +1444,7 @@ func decodeLineOfMediaPlaylist(p *MediaPlaylist, wv *WV, state *decodingState, l
 		}
 	case strings.HasPrefix(line, "#WV-AUDIO-FORMAT"):
 		state.listType = MEDIA
-		if _, err = fmt.Sscanf(line, "#WV-AUDIO-FORMAT %d", &wv.AudioFormat); strict && err != nil {
+		if _, err = fmt.Sscanf(line, "#WV-AUDIO-FORMAT %d", &wv.AudioFormat); state.violation(err, strict) {
 			return err
 		}
 		if err == nil {
@@ -844,7 +1452,7 @@ func decodeLineOfMediaPlaylist(p *MediaPlaylist, wv *WV, state *decodingState, l
 		}
 	case strings.HasPrefix(line, "#WV-AUDIO-PROFILE-IDC"):
 		state.listType = MEDIA
-		if _, err = fmt.Sscanf(line, "#WV-AUDIO-PROFILE-IDC %d", &wv.AudioProfileIDC); strict && err != nil {
+		if _, err = fmt.Sscanf(line, "#WV-AUDIO-PROFILE-IDC %d", &wv.AudioProfileIDC); state.violation(err, strict) {
 			return err
 		}
 		if err == nil {
@@ -852,7 +1460,7 @@ func decodeLineOfMediaPlaylist(p *MediaPlaylist, wv *WV, state *decodingState, l
 		}
 	case strings.HasPrefix(line, "#WV-AUDIO-SAMPLE-SIZE"):
 		state.listType = MEDIA
-		if _, err = fmt.Sscanf(line, "#WV-AUDIO-SAMPLE-SIZE %d", &wv.AudioSampleSize); strict && err != nil {
+		if _, err = fmt.Sscanf(line, "#WV-AUDIO-SAMPLE-SIZE %d", &wv.AudioSampleSize); state.violation(err, strict) {
 			return err
 		}
 		if err == nil {
@@ -860,7 +1468,7 @@ func decodeLineOfMediaPlaylist(p *MediaPlaylist, wv *WV, state *decodingState, l
 		}
 	case strings.HasPrefix(line, "#WV-AUDIO-SAMPLING-FREQUENCY"):
 		state.listType = MEDIA
-		if _, err = fmt.Sscanf(line, "#WV-AUDIO-SAMPLING-FREQUENCY %d", &wv.AudioSamplingFrequency); strict && err != nil {
+		if _, err = fmt.Sscanf(line, "#WV-AUDIO-SAMPLING-FREQUENCY %d", &wv.AudioSamplingFrequency); state.violation(err, strict) {
 			return err
 		}
 		if err == nil {
@@ -872,7 +1480,7 @@ func decodeLineOfMediaPlaylist(p *MediaPlaylist, wv *WV, state *decodingState, l
 		state.tagWV = true
 	case strings.HasPrefix(line, "#WV-ECM"):
 		state.listType = MEDIA
-		if _, err = fmt.Sscanf(line, "#WV-ECM %s", &wv.ECM); strict && err != nil {
+		if _, err = fmt.Sscanf(line, "#WV-ECM %s", &wv.ECM); state.violation(err, strict) {
 			return err
 		}
 		if err == nil {
@@ -880,7 +1488,7 @@ func decodeLineOfMediaPlaylist(p *MediaPlaylist, wv *WV, state *decodingState, l
 		}
 	case strings.HasPrefix(line, "#WV-VIDEO-FORMAT"):
 		state.listType = MEDIA
-		if _, err = fmt.Sscanf(line, "#WV-VIDEO-FORMAT %d", &wv.VideoFormat); strict && err != nil {
+		if _, err = fmt.Sscanf(line, "#WV-VIDEO-FORMAT %d", &wv.VideoFormat); state.violation(err, strict) {
 			return err
 		}
 		if err == nil {
@@ -888,7 +1496,7 @@ func decodeLineOfMediaPlaylist(p *MediaPlaylist, wv *WV, state *decodingState, l
 		}
 	case strings.HasPrefix(line, "#WV-VIDEO-FRAME-RATE"):
 		state.listType = MEDIA
-		if _, err = fmt.Sscanf(line, "#WV-VIDEO-FRAME-RATE %d", &wv.VideoFrameRate); strict && err != nil {
+		if _, err = fmt.Sscanf(line, "#WV-VIDEO-FRAME-RATE %d", &wv.VideoFrameRate); state.violation(err, strict) {
 			return err
 		}
 		if err == nil {
@@ -896,7 +1504,7 @@ func decodeLineOfMediaPlaylist(p *MediaPlaylist, wv *WV, state *decodingState, l
 		}
 	case strings.HasPrefix(line, "#WV-VIDEO-LEVEL-IDC"):
 		state.listType = MEDIA
-		if _, err = fmt.Sscanf(line, "#WV-VIDEO-LEVEL-IDC %d", &wv.VideoLevelIDC); strict && err != nil {
+		if _, err = fmt.Sscanf(line, "#WV-VIDEO-LEVEL-IDC %d", &wv.VideoLevelIDC); state.violation(err, strict) {
 			return err
 		}
 		if err == nil {
@@ -904,7 +1512,7 @@ func decodeLineOfMediaPlaylist(p *MediaPlaylist, wv *WV, state *decodingState, l
 		}
 	case strings.HasPrefix(line, "#WV-VIDEO-PROFILE-IDC"):
 		state.listType = MEDIA
-		if _, err = fmt.Sscanf(line, "#WV-VIDEO-PROFILE-IDC %d", &wv.VideoProfileIDC); strict && err != nil {
+		if _, err = fmt.Sscanf(line, "#WV-VIDEO-PROFILE-IDC %d", &wv.VideoProfileIDC); state.violation(err, strict) {
 			return err
 		}
 		if err == nil {
@@ -916,7 +1524,7 @@ func decodeLineOfMediaPlaylist(p *MediaPlaylist, wv *WV, state *decodingState, l
 		state.tagWV = true
 	case strings.HasPrefix(line, "#WV-VIDEO-SAR"):
 		state.listType = MEDIA
-		if _, err = fmt.Sscanf(line, "#WV-VIDEO-SAR %s", &wv.VideoSAR); strict && err != nil {
+		if _, err = fmt.Sscanf(line, "#WV-VIDEO-SAR %s", &wv.VideoSAR); state.violation(err, strict) {
 			return err
 		}
 		if err == nil {
@@ -12,10 +12,14 @@ package m3u8
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"reflect"
+	"regexp"
+	"strings"
 	"testing"
 	"time"
 )
@@ -228,7 +232,7 @@ func TestDecodeMediaPlaylistByteRange(t *testing.T) {
 	expected := []*MediaSegment{
 		{URI: "video.ts", Duration: 10, Limit: 75232, SeqId: 0},
 		{URI: "video.ts", Duration: 10, Limit: 82112, Offset: 752321, SeqId: 1},
-		{URI: "video.ts", Duration: 10, Limit: 69864, SeqId: 2},
+		{URI: "video.ts", Duration: 10, Limit: 69864, SeqId: 2, offsetOmitted: true},
 	}
 	for i, seg := range p.Segments {
 		if !reflect.DeepEqual(*seg, *expected[i]) {
@@ -249,9 +253,9 @@ func TestDecodeMasterPlaylistWithIFrameStreamInf(t *testing.T) {
 		t.Fatal(err)
 	}
 	expected := map[int]*Variant{
-		86000:  {URI: "low/iframe.m3u8", VariantParams: VariantParams{Bandwidth: 86000, ProgramId: 1, Codecs: "c1", Resolution: "1x1", Video: "1", Iframe: true}},
-		150000: {URI: "mid/iframe.m3u8", VariantParams: VariantParams{Bandwidth: 150000, ProgramId: 1, Codecs: "c2", Resolution: "2x2", Video: "2", Iframe: true}},
-		550000: {URI: "hi/iframe.m3u8", VariantParams: VariantParams{Bandwidth: 550000, ProgramId: 1, Codecs: "c2", Resolution: "2x2", Video: "2", Iframe: true}},
+		86000:  {URI: "low/iframe.m3u8", VariantParams: VariantParams{Bandwidth: 86000, ProgramId: 1, programIdSet: true, Codecs: "c1", Resolution: "1x1", Video: "1", Iframe: true}},
+		150000: {URI: "mid/iframe.m3u8", VariantParams: VariantParams{Bandwidth: 150000, ProgramId: 1, programIdSet: true, Codecs: "c2", Resolution: "2x2", Video: "2", Iframe: true}},
+		550000: {URI: "hi/iframe.m3u8", VariantParams: VariantParams{Bandwidth: 550000, ProgramId: 1, programIdSet: true, Codecs: "c2", Resolution: "2x2", Video: "2", Iframe: true}},
 	}
 	for _, variant := range p.Variants {
 		for k, expect := range expected {
@@ -840,6 +844,9 @@ func TestDecodeMasterPlaylistWithCustomTags(t *testing.T) {
 		}
 
 		p, listType, err := DecodeWith(bufio.NewReader(f), true, testCase.customDecoders)
+		if perr, ok := err.(*ParseError); ok {
+			err = perr.Err
+		}
 
 		if !reflect.DeepEqual(err, testCase.expectedError) {
 			t.Fatal(err)
@@ -863,7 +870,7 @@ func TestDecodeMasterPlaylistWithCustomTags(t *testing.T) {
 		} else {
 			// we have the same count, lets confirm its the right tags
 			for _, expectedTag := range testCase.expectedPlaylistTags {
-				if _, ok := pp.Custom[expectedTag]; !ok {
+				if pp.Custom.Get(expectedTag) == nil {
 					t.Errorf("Did not parse custom tag %s", expectedTag)
 				}
 			}
@@ -947,6 +954,9 @@ func TestDecodeMediaPlaylistWithCustomTags(t *testing.T) {
 		}
 
 		p, listType, err := DecodeWith(bufio.NewReader(f), true, testCase.customDecoders)
+		if perr, ok := err.(*ParseError); ok {
+			err = perr.Err
+		}
 
 		if !reflect.DeepEqual(err, testCase.expectedError) {
 			t.Fatal(err)
@@ -970,7 +980,7 @@ func TestDecodeMediaPlaylistWithCustomTags(t *testing.T) {
 		} else {
 			// we have the same count, lets confirm its the right tags
 			for _, expectedTag := range testCase.expectedPlaylistTags {
-				if _, ok := pp.Custom[expectedTag]; !ok {
+				if pp.Custom.Get(expectedTag) == nil {
 					t.Errorf("Did not parse custom tag %s", expectedTag)
 				}
 			}
@@ -1009,7 +1019,7 @@ func TestDecodeMediaPlaylistWithCustomTags(t *testing.T) {
 			} else {
 				// we have the same count, lets confirm its the right tags
 				for _, expectedTag := range expectedSegmentTag.names {
-					if _, ok := seg.Custom[expectedTag]; !ok {
+					if seg.Custom.Get(expectedTag) == nil {
 						t.Errorf("Did not parse customTag %s on Segment %d", expectedTag, i)
 					}
 				}
@@ -1258,6 +1268,45 @@ func BenchmarkDecodeMediaPlaylist(b *testing.B) {
 	}
 }
 
+// The attribute scanner replaced the regular expression, it must
+// match the same attributes.
+func TestScanAttributesMatchesPattern(t *testing.T) {
+	pattern := regexp.MustCompile(`([a-zA-Z0-9_-]+)=("[^"]+"|[^",]+)`)
+	for _, line := range []string{
+		`BANDWIDTH=1280000,CODECS="avc1.4d401f,mp4a.40.2",RESOLUTION=640x360`,
+		`METHOD=AES-128,URI="https://example.com/key?a=1,b=2",IV=0x0102`,
+		`ID="ad",START-DATE="2020-01-01T00:00:00Z",X-COM-VALUE=-1.5`,
+		`A="",B=1`,
+		`A="unterminated,B=2`,
+		`A=,B=2`,
+		`A=1"B=2",C`,
+		`lower_case=yes, SPACED = 1 ,X=`,
+		`=1,==,A==2,B=3=4`,
+		`NAME="quoted"tail,NEXT=1`,
+		``,
+		`NOVALUE`,
+	} {
+		var want, got []string
+		for _, kv := range pattern.FindAllStringSubmatch(line, -1) {
+			want = append(want, kv[1]+"|"+kv[2])
+		}
+		scanAttributes(line, func(name, value string) {
+			got = append(got, name+"|"+value)
+		})
+		if strings.Join(got, " ") != strings.Join(want, " ") {
+			t.Errorf("Attributes of %q are %q, want %q", line, got, want)
+		}
+	}
+}
+
+func BenchmarkDecodeAttributeList(b *testing.B) {
+	line := `BANDWIDTH=1280000,AVERAGE-BANDWIDTH=1000000,CODECS="avc1.4d401f,mp4a.40.2",RESOLUTION=640x360,FRAME-RATE=29.970,AUDIO="aac"`
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		DecodeAttributeList(line)
+	}
+}
+
 func TestDecodeMediaPlaylistWithIndependentSegments(t *testing.T) {
 	f, err := os.Open("sample-playlists/media-playlist-with-independent-segments.m3u8")
 	if err != nil {
@@ -1329,3 +1378,331 @@ func TestWriteMediaPlaylistWithoutIndependentSegments(t *testing.T) {
 		t.Error("Expected independentsegments to be false")
 	}
 }
+
+func TestDecodeMasterPlaylistWithInvalidVideoRange(t *testing.T) {
+	playlist := `#EXTM3U
+#EXT-X-STREAM-INF:BANDWIDTH=1280000,VIDEO-RANGE=HDR
+chunklist.m3u8
+`
+	p := NewMasterPlaylist()
+	if err := p.DecodeFrom(bytes.NewBufferString(playlist), true); err == nil {
+		t.Error("Expected error for invalid VIDEO-RANGE in strict mode")
+	}
+	p = NewMasterPlaylist()
+	if err := p.DecodeFrom(bytes.NewBufferString(playlist), false); err != nil {
+		t.Fatal(err)
+	}
+	if p.Variants[0].VideoRange != "HDR" {
+		t.Errorf("VideoRange = %s, want HDR", p.Variants[0].VideoRange)
+	}
+}
+
+func TestDecodeWithPreserveAttributeOrder(t *testing.T) {
+	master := `#EXTM3U
+#EXT-X-VERSION:4
+#EXT-X-SESSION-DATA:LANGUAGE="en",DATA-ID="com.example.title",VALUE="Title"
+#EXT-X-MEDIA:NAME="English",TYPE=AUDIO,GROUP-ID="aud",DEFAULT=YES,LANGUAGE="en"
+#EXT-X-STREAM-INF:BANDWIDTH=1000,AUDIO="aud",CODECS="avc1.4d401f,mp4a.40.2",PROGRAM-ID=0
+low.m3u8
+#EXT-X-I-FRAME-STREAM-INF:URI="iframe.m3u8",BANDWIDTH=100,PROGRAM-ID=0
+`
+	p, listType, err := DecodeWithOptions(strings.NewReader(master), DecodeOptions{Strict: true, PreserveAttributeOrder: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if listType != MASTER {
+		t.Fatalf("Expected master playlist, got %v", listType)
+	}
+	if out := p.String(); out != master {
+		t.Errorf("Attribute order not preserved:\n%s\nwant:\n%s", out, master)
+	}
+
+	media := `#EXTM3U
+#EXT-X-VERSION:3
+#EXT-X-KEY:URI="key1",METHOD=AES-128
+#EXT-X-MEDIA-SEQUENCE:0
+#EXT-X-TARGETDURATION:10
+#EXT-X-DATERANGE:START-DATE="2019-01-01T00:00:00Z",ID="ad",DURATION=10
+#EXTINF:10.000,
+seg0.ts
+#EXT-X-KEY:IV=0x10,URI="key2",METHOD=AES-128
+#EXT-X-MAP:BYTERANGE=100@0,URI="init.mp4"
+#EXTINF:10.000,
+seg1.ts
+`
+	pp, _ := NewMediaPlaylist(0, 4)
+	if err = pp.DecodeWithOptions(strings.NewReader(media), DecodeOptions{Strict: true, PreserveAttributeOrder: true}); err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{
+		`#EXT-X-KEY:URI="key1",METHOD=AES-128`,
+		`#EXT-X-KEY:IV=0x10,URI="key2",METHOD=AES-128`,
+		`#EXT-X-MAP:BYTERANGE=100@0,URI="init.mp4"`,
+		`#EXT-X-DATERANGE:START-DATE="2019-01-01T00:00:00Z",ID="ad",DURATION=10`,
+	} {
+		if !strings.Contains(pp.String(), line+"\n") {
+			t.Errorf("Encoded playlist lacks %s:\n%s", line, pp.String())
+		}
+	}
+
+	// without the option the default order is used
+	p, _, _ = DecodeWithOptions(strings.NewReader(master), DecodeOptions{})
+	if !strings.Contains(p.String(), "#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=1000,") {
+		t.Errorf("Unexpected attribute order:\n%s", p.String())
+	}
+}
+
+func TestAttributeOrderPrunedOnRemove(t *testing.T) {
+	src := new(bytes.Buffer)
+	src.WriteString("#EXTM3U\n#EXT-X-VERSION:3\n#EXT-X-TARGETDURATION:10\n")
+	for i := 0; i < 6; i++ {
+		fmt.Fprintf(src, "#EXT-X-KEY:URI=\"key%d\",METHOD=AES-128\n", i)
+		fmt.Fprintf(src, "#EXT-X-DATERANGE:START-DATE=\"2019-01-01T00:00:00Z\",ID=\"ad%d\"\n", i)
+		fmt.Fprintf(src, "#EXTINF:10.000,\nseg%d.ts\n", i)
+	}
+	p, _ := NewMediaPlaylist(6, 6)
+	if err := p.DecodeWithOptions(src, DecodeOptions{Strict: true, PreserveAttributeOrder: true}); err != nil {
+		t.Fatal(err)
+	}
+	// the playlist key and a key and a daterange of each segment
+	if n := len(p.attrOrder); n != 13 {
+		t.Fatalf("expected 13 recorded orders, got %d", n)
+	}
+	for i := 0; i < 3; i++ {
+		if err := p.Remove(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := p.RemoveAt(1); err != nil {
+		t.Fatal(err)
+	}
+	// the daterange of the removed seg4 is moved to seg5
+	if n := len(p.attrOrder); n != 1+2*2+1 {
+		t.Errorf("expected 6 recorded orders, got %d", n)
+	}
+	if out := p.String(); !strings.Contains(out, "#EXT-X-KEY:URI=\"key5\",METHOD=AES-128\n") ||
+		!strings.Contains(out, "#EXT-X-DATERANGE:START-DATE=\"2019-01-01T00:00:00Z\",ID=\"ad4\"\n") {
+		t.Errorf("attribute order is lost:\n%s", out)
+	}
+}
+
+func TestDecodeMediaPlaylistDateRanges(t *testing.T) {
+	playlist := `#EXTM3U
+#EXT-X-TARGETDURATION:10
+#EXT-X-DATERANGE:ID="first",START-DATE="2019-01-01T00:00:00Z"
+#EXTINF:10.000,
+seg0.ts
+#EXTINF:10.000,
+seg1.ts
+#EXT-X-DATERANGE:ID="second",START-DATE="2019-01-01T00:00:20Z"
+#EXT-X-DATERANGE:ID="third",START-DATE="2019-01-01T00:00:20Z"
+#EXTINF:10.000,
+seg2.ts
+`
+	p, _, err := DecodeFrom(strings.NewReader(playlist), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	refs := p.(*MediaPlaylist).DateRanges()
+	if len(refs) != 3 {
+		t.Fatalf("Expected 3 dateranges, got %d", len(refs))
+	}
+	for i, want := range []struct{ id, uri string }{{"first", "seg0.ts"}, {"second", "seg2.ts"}, {"third", "seg2.ts"}} {
+		if refs[i].DateRange.ID != want.id || refs[i].Segment.URI != want.uri {
+			t.Errorf("Daterange %d: got %s before %s, want %s before %s", i, refs[i].DateRange.ID, refs[i].Segment.URI, want.id, want.uri)
+		}
+	}
+}
+
+func TestDecodeMediaPlaylistAllowCache(t *testing.T) {
+	src := "#EXTM3U\n#EXT-X-VERSION:3\n#EXT-X-ALLOW-CACHE:YES\n#EXT-X-MEDIA-SEQUENCE:0\n#EXT-X-TARGETDURATION:10\n#EXTINF:10.000,\na.ts\n#EXT-X-ENDLIST\n"
+	p, listType, err := DecodeFrom(strings.NewReader(src), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if listType != MEDIA {
+		t.Fatal("Sample not recognized as media playlist.")
+	}
+	pp := p.(*MediaPlaylist)
+	if pp.AllowCache != "YES" {
+		t.Errorf("Expected AllowCache YES, got %q", pp.AllowCache)
+	}
+	if !strings.Contains(pp.String(), "#EXT-X-ALLOW-CACHE:YES\n") {
+		t.Errorf("EXT-X-ALLOW-CACHE is lost on encoding:\n%s", pp.String())
+	}
+	if _, _, err = DecodeFrom(strings.NewReader(strings.Replace(src, ":YES", ":MAYBE", 1)), true); err == nil {
+		t.Error("Expected error on invalid EXT-X-ALLOW-CACHE value in strict mode")
+	}
+}
+
+type slowReader struct {
+	data   []byte
+	cancel func()
+}
+
+// Read returns one byte per call and cancels the context after the
+// first read.
+func (r *slowReader) Read(b []byte) (int, error) {
+	if len(r.data) == 0 {
+		return 0, io.EOF
+	}
+	b[0] = r.data[0]
+	r.data = r.data[1:]
+	r.cancel()
+	return 1, nil
+}
+
+func TestDecodeContext(t *testing.T) {
+	src := "#EXTM3U\n#EXT-X-TARGETDURATION:10\n#EXTINF:10,\na.ts\n"
+	p, listType, err := DecodeContext(context.Background(), strings.NewReader(src), DecodeOptions{Strict: true})
+	if err != nil || listType != MEDIA || p.(*MediaPlaylist).Count() != 1 {
+		t.Fatalf("unexpected result: %v %v", listType, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	_, _, err = DecodeContext(ctx, &slowReader{data: []byte(src), cancel: cancel}, DecodeOptions{})
+	if err != context.Canceled {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+	media, _ := NewMediaPlaylist(1, 1)
+	if err = media.DecodeContext(ctx, strings.NewReader(src), DecodeOptions{}); err != context.Canceled {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+	if err = NewMasterPlaylist().DecodeContext(ctx, strings.NewReader(src), DecodeOptions{}); err != context.Canceled {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestDecodeProgress(t *testing.T) {
+	var b strings.Builder
+	b.WriteString("#EXTM3U\n#EXT-X-TARGETDURATION:10\n")
+	for i := 0; i < 25; i++ {
+		fmt.Fprintf(&b, "#EXTINF:10,\n%d.ts\n", i)
+	}
+	src := b.String()
+
+	var calls []int
+	opts := DecodeOptions{ProgressInterval: 10, Progress: func(decoded int) error {
+		calls = append(calls, decoded)
+		return nil
+	}}
+	p, _, err := DecodeWithOptions(strings.NewReader(src), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.(*MediaPlaylist).Count() != 25 || fmt.Sprint(calls) != "[10 20]" {
+		t.Errorf("unexpected progress calls: %v", calls)
+	}
+
+	errStop := errors.New("stop")
+	opts.Progress = func(decoded int) error { return errStop }
+	media, _ := NewMediaPlaylist(0, 25)
+	if err = media.DecodeWithOptions(strings.NewReader(src), opts); err != errStop {
+		t.Errorf("expected error of the callback, got %v", err)
+	}
+	if media.Count() != 10 {
+		t.Errorf("expected 10 decoded segments before abort, got %d", media.Count())
+	}
+
+	// cancellation while parsing the read input
+	ctx, cancel := context.WithCancel(context.Background())
+	opts.Progress = func(decoded int) error {
+		cancel()
+		return nil
+	}
+	if _, _, err = DecodeContext(ctx, strings.NewReader(src), opts); err != context.Canceled {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestDecodeMasterPlaylistWithAssocLanguage(t *testing.T) {
+	src := `#EXTM3U
+#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID="aud",NAME="English",DEFAULT=YES,LANGUAGE="en",ASSOC-LANGUAGE="en-GB",URI="en.m3u8"
+#EXT-X-STREAM-INF:BANDWIDTH=1000,AUDIO="aud"
+low.m3u8
+`
+	p := NewMasterPlaylist()
+	if err := p.DecodeFrom(strings.NewReader(src), true); err != nil {
+		t.Fatal(err)
+	}
+	alt := p.Variants[0].Alternatives[0]
+	if alt.AssocLanguage != "en-GB" {
+		t.Errorf("expected ASSOC-LANGUAGE en-GB, got %q", alt.AssocLanguage)
+	}
+	if !strings.Contains(p.String(), `LANGUAGE="en",ASSOC-LANGUAGE="en-GB"`) {
+		t.Errorf("ASSOC-LANGUAGE is not written:\n%s", p)
+	}
+}
+
+func TestDecodeMasterPlaylistWithAudioSampleAttributes(t *testing.T) {
+	src := `#EXTM3U
+#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID="alac",NAME="English",LANGUAGE="en",ASSOC-LANGUAGE="en-US",CHANNELS="2",BIT-DEPTH=24,SAMPLE-RATE=48000,URI="alac/en.m3u8"
+#EXT-X-STREAM-INF:BANDWIDTH=3000000,CODECS="avc1.640028,alac",AUDIO="alac"
+video.m3u8
+`
+	p := NewMasterPlaylist()
+	if err := p.DecodeFrom(strings.NewReader(src), true); err != nil {
+		t.Fatal(err)
+	}
+	alt := p.Variants[0].Alternatives[0]
+	if alt.BitDepth != 24 || alt.SampleRate != 48000 || alt.AssocLanguage != "en-US" {
+		t.Errorf("unexpected rendition: %+v", alt)
+	}
+	expected := `#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID="alac",NAME="English",DEFAULT=NO,LANGUAGE="en",ASSOC-LANGUAGE="en-US",URI="alac/en.m3u8",CHANNELS="2",BIT-DEPTH=24,SAMPLE-RATE=48000` + "\n"
+	if out := p.String(); !strings.Contains(out, expected) {
+		t.Errorf("expected %s in output:\n%s", expected, out)
+	}
+	if err := p.DecodeFrom(strings.NewReader(strings.Replace(src, "SAMPLE-RATE=48000", "SAMPLE-RATE=48kHz", 1)), true); err == nil {
+		t.Error("expected error for invalid SAMPLE-RATE")
+	}
+}
+
+func TestDecodeMediaPlaylistWithElementalAndAdobeCues(t *testing.T) {
+	src := `#EXTM3U
+#EXT-X-VERSION:3
+#EXT-X-TARGETDURATION:10
+#EXT-X-MEDIA-SEQUENCE:0
+#EXT-X-CUE-OUT:DURATION=30
+#EXTINF:10.000,
+ad0.ts
+#EXT-X-CUE-OUT-CONT:ElapsedTime=10,Duration=30
+#EXTINF:10.000,
+ad1.ts
+#EXT-X-CUE-IN
+#EXTINF:10.000,
+main0.ts
+#EXT-X-CUE-OUT
+#EXTINF:10.000,
+ad2.ts
+#EXT-X-CUE:TYPE="SpliceOut",ID="break-1",DURATION=20,TIME=1432.5,CUE="/DAlAAAAAAAAAP/wFAUAAAABf+/+AAAAAH4AKTLgAAEAAAAAuVx0Kw=="
+#EXTINF:10.000,
+ad3.ts
+#EXT-X-CUE:TYPE="SpliceIn",ID="break-1"
+#EXTINF:10.000,
+main1.ts
+`
+	p, err := NewMediaPlaylist(0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = p.DecodeFrom(strings.NewReader(src), true); err != nil {
+		t.Fatal(err)
+	}
+	expected := []*SCTE{
+		{Syntax: SCTE35_ELEMENTAL, CueType: SCTE35Cue_Start, Time: 30},
+		{Syntax: SCTE35_ELEMENTAL, CueType: SCTE35Cue_Mid, Time: 30, Elapsed: 10},
+		{Syntax: SCTE35_OATCLS, CueType: SCTE35Cue_End},
+		{Syntax: SCTE35_ELEMENTAL, CueType: SCTE35Cue_Start},
+		{Syntax: SCTE35_ADOBE, CueType: SCTE35Cue_Start, ID: "break-1", Time: 20, Start: 1432.5, Cue: "/DAlAAAAAAAAAP/wFAUAAAABf+/+AAAAAH4AKTLgAAEAAAAAuVx0Kw=="},
+		{Syntax: SCTE35_ADOBE, CueType: SCTE35Cue_End, ID: "break-1"},
+	}
+	for i, scte := range expected {
+		if got := p.Segments[i].SCTE; got == nil || *got != *scte {
+			t.Errorf("segment %d: expected %+v, got %+v", i, scte, got)
+		}
+	}
+	out := p.String()
+	if !strings.HasSuffix(src, out[strings.Index(out, "#EXT-X-CUE-OUT:DURATION"):]) {
+		t.Errorf("cues are not round-tripped:\n%s", out)
+	}
+}
@@ -0,0 +1,107 @@
+package m3u8
+
+/*
+ Part of M3U8 parser & generator library.
+ This file defines redaction of sensitive data in playlists.
+
+ Copyright 2013-2019 The Project Developers.
+ See the AUTHORS and LICENSE files at the top-level directory of this distribution
+ and at https://github.com/grafov/m3u8/
+
+ ॐ तारे तुत्तारे तुरे स्व
+*/
+
+import "strings"
+
+// Redacted replaces sensitive values in redacted playlists.
+const Redacted = "REDACTED"
+
+// Redact masks sensitive data of the media playlist keeping its
+// structure intact, so real-world playlists may be attached to bug
+// reports and used as test fixtures: URIs of the keys are replaced
+// with Redacted and values of query parameters of all other URIs and
+// of Args are replaced with Redacted (names of the parameters are
+// kept). Custom tags are left intact. This operation changes the
+// playlist and does reset playlist cache.
+func (p *MediaPlaylist) Redact() {
+	p.RewriteURIs(redactQuery)
+	p.eachSegment(func(seg *MediaSegment) {
+		seg.Args = redactParams(seg.Args)
+	})
+	for _, key := range p.keys() {
+		if key.URI != "" {
+			key.URI = Redacted
+		}
+	}
+	p.Args = redactParams(p.Args)
+}
+
+// Redact masks sensitive data of the master playlist keeping its
+// structure intact: values of the session data are replaced with
+// Redacted and values of query parameters of all URIs and of Args are
+// replaced with Redacted (names of the parameters are kept). Media
+// playlists of the variants (Chunklist) are redacted too. Custom tags
+// are left intact. This operation changes the playlist and does reset
+// playlist cache.
+func (p *MasterPlaylist) Redact() {
+	p.RewriteURIs(redactQuery)
+	for _, sd := range p.SessionData {
+		if sd.Value != "" {
+			sd.Value = Redacted
+		}
+	}
+	p.Args = redactParams(p.Args)
+	for _, v := range p.Variants {
+		if v == nil {
+			continue
+		}
+		v.Args = redactParams(v.Args)
+		if v.Chunklist != nil {
+			v.Chunklist.Redact()
+		}
+	}
+}
+
+// keys returns distinct keys of the playlist and its segments.
+func (p *MediaPlaylist) keys() []*Key {
+	var keys []*Key
+	seen := make(map[*Key]bool)
+	add := func(key *Key) {
+		if key != nil && !seen[key] {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	}
+	add(p.Key)
+	p.eachSegment(func(seg *MediaSegment) {
+		add(seg.Key)
+	})
+	return keys
+}
+
+// redactQuery replaces values of query parameters of the URI with
+// Redacted.
+func redactQuery(uri string) string {
+	i := strings.IndexByte(uri, '?')
+	if i < 0 {
+		return uri
+	}
+	return uri[:i+1] + redactParams(uri[i+1:])
+}
+
+// redactParams replaces values of URL-encoded parameters with
+// Redacted. Parameters without names are replaced completely.
+func redactParams(params string) string {
+	if params == "" {
+		return params
+	}
+	list := strings.Split(params, "&")
+	for i, param := range list {
+		if eq := strings.IndexByte(param, '='); eq >= 0 {
+			list[i] = param[:eq+1] + Redacted
+		} else if param != "" {
+			list[i] = Redacted
+		}
+	}
+	return strings.Join(list, "&")
+}
@@ -0,0 +1,63 @@
+/*
+Playlist redaction tests.
+
+Copyright 2013-2019 The Project Developers.
+See the AUTHORS and LICENSE files at the top-level directory of this distribution
+and at https://github.com/grafov/m3u8/
+
+ॐ तारे तुत्तारे तुरे स्व
+*/
+package m3u8
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMediaPlaylistRedact(t *testing.T) {
+	p, err := NewMediaPlaylist(3, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.SetDefaultKey("AES-128", "https://keys.example.com/k?session=secret", "", "", "")
+	p.Append("a.ts?token=abc&exp=123", 10, "")
+	p.SetKey("AES-128", "https://keys.example.com/k2", "", "", "")
+	p.Append("b.ts", 10, "")
+	p.SetMap("init.mp4?sig", 0, 0)
+	p.Args = "auth=xyz"
+	before := p.String()
+	p.Redact()
+	out := p.String()
+	if out == before {
+		t.Fatal("cache is not reset")
+	}
+	for _, secret := range []string{"secret", "abc", "123", "k2", "sig", "xyz"} {
+		if strings.Contains(out, secret) {
+			t.Errorf("%q is not redacted:\n%s", secret, out)
+		}
+	}
+	for _, e := range []string{`URI="REDACTED"`, "a.ts?token=REDACTED&exp=REDACTED&auth=REDACTED\n", "b.ts?auth=REDACTED\n", `URI="init.mp4?REDACTED"`} {
+		if !strings.Contains(out, e) {
+			t.Errorf("expected %s in:\n%s", e, out)
+		}
+	}
+}
+
+func TestMasterPlaylistRedact(t *testing.T) {
+	m := NewMasterPlaylist()
+	alt := &Alternative{GroupId: "aac", Type: "AUDIO", Name: "en", URI: "en.m3u8?t=1"}
+	m.Append("low.m3u8?t=1", nil, VariantParams{Bandwidth: 1, Audio: "aac", Alternatives: []*Alternative{alt}})
+	m.Append("high.m3u8?t=1", nil, VariantParams{Bandwidth: 2, Audio: "aac", Alternatives: []*Alternative{alt}})
+	m.SessionData = []*SessionData{{DataID: "com.example.user", Value: "john"}}
+	m.Redact()
+	out := m.String()
+	if strings.Contains(out, "t=1") || strings.Contains(out, "john") {
+		t.Errorf("master playlist is not redacted:\n%s", out)
+	}
+	if alt.URI != "en.m3u8?t=REDACTED" {
+		t.Errorf("shared rendition is rewritten incorrectly: %s", alt.URI)
+	}
+	if !strings.Contains(out, `DATA-ID="com.example.user",VALUE="REDACTED"`) {
+		t.Errorf("unexpected session data:\n%s", out)
+	}
+}
@@ -0,0 +1,152 @@
+package m3u8
+
+/*
+ Part of M3U8 parser & generator library.
+ This file defines delivery directives of blocking playlist reload.
+
+ Copyright 2013-2019 The Project Developers.
+ See the AUTHORS and LICENSE files at the top-level directory of this distribution
+ and at https://github.com/grafov/m3u8/
+
+ ॐ तारे तुत्तारे तुरे स्व
+*/
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Names of the delivery directives query parameters (section 6.2.5 of
+// rfc8216bis).
+const (
+	DirectiveMSN  = "_HLS_msn"
+	DirectivePart = "_HLS_part"
+	DirectiveSkip = "_HLS_skip"
+)
+
+// Values of _HLS_skip directive.
+const (
+	SkipSegments   = "YES" // skip segments, see MediaPlaylist.Delta
+	SkipDateRanges = "v2"  // skip segments and dateranges
+)
+
+// ErrDirectiveTooFar returned by MediaPlaylist.Ready when the
+// requested media sequence number is more than two segments ahead of
+// the playlist, the server should respond with 400 then.
+var ErrDirectiveTooFar = errors.New("requested segment is too far ahead of the playlist")
+
+// DeliveryDirectives are the query parameters of the playlist request
+// of low-latency clients: blocking reload until the segment MSN (and
+// its part Part) is available and the delta update with Skip.
+type DeliveryDirectives struct {
+	MSN     uint64 // _HLS_msn
+	HasMSN  bool
+	Part    uint64 // _HLS_part, requires _HLS_msn
+	HasPart bool
+	Skip    string // _HLS_skip, SkipSegments or SkipDateRanges
+}
+
+// ParseDeliveryDirectives returns delivery directives of the query.
+// It returns error for invalid values and for _HLS_part without
+// _HLS_msn, the server should respond with 400 then.
+func ParseDeliveryDirectives(query url.Values) (DeliveryDirectives, error) {
+	var (
+		d   DeliveryDirectives
+		err error
+	)
+	if v := query.Get(DirectiveMSN); v != "" {
+		if d.MSN, err = strconv.ParseUint(v, 10, 64); err != nil {
+			return d, fmt.Errorf("invalid %s %q", DirectiveMSN, v)
+		}
+		d.HasMSN = true
+	}
+	if v := query.Get(DirectivePart); v != "" {
+		if !d.HasMSN {
+			return d, fmt.Errorf("%s without %s", DirectivePart, DirectiveMSN)
+		}
+		if d.Part, err = strconv.ParseUint(v, 10, 64); err != nil {
+			return d, fmt.Errorf("invalid %s %q", DirectivePart, v)
+		}
+		d.HasPart = true
+	}
+	switch v := query.Get(DirectiveSkip); v {
+	case "", SkipSegments, SkipDateRanges:
+		d.Skip = v
+	default:
+		return d, fmt.Errorf("invalid %s %q", DirectiveSkip, v)
+	}
+	return d, nil
+}
+
+// Blocking reports whether the directives request blocking reload.
+func (d DeliveryDirectives) Blocking() bool {
+	return d.HasMSN
+}
+
+// Values returns the directives as query parameters.
+func (d DeliveryDirectives) Values() url.Values {
+	q := make(url.Values)
+	if d.HasMSN {
+		q.Set(DirectiveMSN, strconv.FormatUint(d.MSN, 10))
+		if d.HasPart {
+			q.Set(DirectivePart, strconv.FormatUint(d.Part, 10))
+		}
+	}
+	if d.Skip != "" {
+		q.Set(DirectiveSkip, d.Skip)
+	}
+	return q
+}
+
+// ApplyTo returns the playlist URI with the directives. Directives
+// already present in the URI are replaced, the directives follow
+// other query parameters sorted by name as required for caching.
+func (d DeliveryDirectives) ApplyTo(uri string) (string, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return "", err
+	}
+	var params []string
+	if u.RawQuery != "" {
+		for _, param := range strings.Split(u.RawQuery, "&") {
+			if !strings.HasPrefix(param, "_HLS_") {
+				params = append(params, param)
+			}
+		}
+	}
+	if directives := d.Values().Encode(); directives != "" {
+		params = append(params, directives)
+	}
+	u.RawQuery = strings.Join(params, "&")
+	return u.String(), nil
+}
+
+// Ready reports whether the playlist satisfies blocking reload with
+// the directives: the segment MSN is in the playlist or, with Part,
+// the part of the segment is in the playlist (parts of the complete
+// segment or pending parts of the segment in progress). The closed
+// playlist and the directives without _HLS_msn are always ready. It
+// returns ErrDirectiveTooFar when MSN is more than two segments after
+// the next segment of the playlist.
+func (p *MediaPlaylist) Ready(d DeliveryDirectives) (bool, error) {
+	if !d.HasMSN || p.Closed {
+		return true, nil
+	}
+	next := p.SeqNo + uint64(p.count)
+	if p.count > 0 {
+		next = p.Segments[p.last()].SeqId + 1
+	}
+	if d.MSN > next+2 {
+		return false, ErrDirectiveTooFar
+	}
+	if d.MSN < next {
+		return true, nil
+	}
+	if d.MSN == next && d.HasPart {
+		return d.Part < uint64(len(p.PendingPartials)), nil
+	}
+	return false, nil
+}
@@ -0,0 +1,78 @@
+/*
+Blocking reload tests.
+
+Copyright 2013-2019 The Project Developers.
+See the AUTHORS and LICENSE files at the top-level directory of this distribution
+and at https://github.com/grafov/m3u8/
+
+ॐ तारे तुत्तारे तुरे स्व
+*/
+package m3u8
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestParseDeliveryDirectives(t *testing.T) {
+	q, _ := url.ParseQuery("_HLS_msn=273&_HLS_part=2&_HLS_skip=v2&token=x")
+	d, err := ParseDeliveryDirectives(q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := DeliveryDirectives{MSN: 273, HasMSN: true, Part: 2, HasPart: true, Skip: SkipDateRanges}
+	if d != expected {
+		t.Errorf("parsed %+v, expected %+v", d, expected)
+	}
+	for _, query := range []string{"_HLS_part=1", "_HLS_msn=-1", "_HLS_msn=1&_HLS_part=x", "_HLS_skip=NO"} {
+		q, _ := url.ParseQuery(query)
+		if _, err := ParseDeliveryDirectives(q); err == nil {
+			t.Errorf("expected error for %s", query)
+		}
+	}
+}
+
+func TestDeliveryDirectivesApplyTo(t *testing.T) {
+	d := DeliveryDirectives{MSN: 12, HasMSN: true, Part: 0, HasPart: true, Skip: SkipSegments}
+	uri, err := d.ApplyTo("https://example.com/live.m3u8?token=x&_HLS_msn=5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if uri != "https://example.com/live.m3u8?token=x&_HLS_msn=12&_HLS_part=0&_HLS_skip=YES" {
+		t.Errorf("unexpected URI %s", uri)
+	}
+	if uri, _ = (DeliveryDirectives{}).ApplyTo("live.m3u8?_HLS_skip=YES"); uri != "live.m3u8" {
+		t.Errorf("unexpected URI %s", uri)
+	}
+}
+
+func TestMediaPlaylistReady(t *testing.T) {
+	p, _ := NewMediaPlaylist(0, 5)
+	p.SeqNo = 10
+	p.Append("a.ts", 4, "")
+	p.Append("b.ts", 4, "")
+	p.AppendPartial(&PartialSegment{URI: "c.0.mp4", Duration: 1})
+	for _, c := range []struct {
+		d     DeliveryDirectives
+		ready bool
+		err   error
+	}{
+		{DeliveryDirectives{}, true, nil},
+		{DeliveryDirectives{MSN: 11, HasMSN: true}, true, nil},
+		{DeliveryDirectives{MSN: 11, HasMSN: true, Part: 3, HasPart: true}, true, nil},
+		{DeliveryDirectives{MSN: 12, HasMSN: true}, false, nil},
+		{DeliveryDirectives{MSN: 12, HasMSN: true, Part: 0, HasPart: true}, true, nil},
+		{DeliveryDirectives{MSN: 12, HasMSN: true, Part: 1, HasPart: true}, false, nil},
+		{DeliveryDirectives{MSN: 14, HasMSN: true}, false, nil},
+		{DeliveryDirectives{MSN: 15, HasMSN: true}, false, ErrDirectiveTooFar},
+	} {
+		ready, err := p.Ready(c.d)
+		if ready != c.ready || err != c.err {
+			t.Errorf("Ready(%+v) = %v, %v, expected %v, %v", c.d, ready, err, c.ready, c.err)
+		}
+	}
+	p.Close()
+	if ready, _ := p.Ready(DeliveryDirectives{MSN: 20, HasMSN: true}); !ready {
+		t.Error("closed playlist must be ready")
+	}
+}
@@ -0,0 +1,151 @@
+package m3u8
+
+/*
+ Part of M3U8 parser & generator library.
+ This file defines replacement of segments covered by dateranges.
+
+ Copyright 2013-2019 The Project Developers.
+ See the AUTHORS and LICENSE files at the top-level directory of this distribution
+ and at https://github.com/grafov/m3u8/
+
+ ॐ तारे तुत्तारे तुरे स्व
+*/
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrDateRangeNotFound returned when the playlist has no daterange
+// with the requested ID.
+var ErrDateRangeNotFound = errors.New("daterange not found")
+
+// ReplaceRange replaces all segments covered by the daterange with
+// the ID by the replacement segments, for example to replace an ad
+// avail with the alternate content. The covered segments start with
+// the segment preceded by EXT-X-DATERANGE and last for DURATION
+// (PLANNED-DURATION or END-DATE minus START-DATE when DURATION is
+// absent) of the daterange. The first replacement segment takes the
+// dateranges, the SCTE-35 cue and EXT-X-PROGRAM-DATE-TIME of the first
+// covered segment, the replacement and the segment following it get
+// EXT-X-DISCONTINUITY, and the following segment gets its program date
+// time and the key and map effective before the replacement, so the
+// content after the range is unaffected. Sequence IDs of the
+// replacement and following segments are renumbered. The replacement
+// segments are copied and the target duration grows if required by
+// them. This operation does reset playlist cache.
+func (p *MediaPlaylist) ReplaceRange(id string, replacement []*MediaSegment) error {
+	if len(replacement) == 0 {
+		return ErrPlaylistEmpty
+	}
+	segs := p.segments()
+	start := -1
+	var dr *DateRange
+	for i, seg := range segs {
+		for _, v := range seg.DateRange {
+			if v != nil && v.ID == id {
+				start, dr = i, v
+				break
+			}
+		}
+		if dr != nil {
+			break
+		}
+	}
+	if dr == nil {
+		return ErrDateRangeNotFound
+	}
+	duration := dr.Duration
+	if duration == 0 {
+		duration = dr.PlannedDuration
+	}
+	if duration == 0 && !dr.StartDate.IsZero() && !dr.EndDate.IsZero() {
+		duration = dr.EndDate.Sub(dr.StartDate).Seconds()
+	}
+	if duration <= 0 {
+		return fmt.Errorf("daterange %s has no duration", id)
+	}
+
+	// covered segments are segs[start:end]; key, m and pdt are the key,
+	// map and program date time effective at the end of the range
+	var (
+		offset float64
+		pdt    time.Time
+		end    = start
+	)
+	key, m := p.Key, p.Map
+	for i, seg := range segs {
+		if i >= start && offset >= duration {
+			break
+		}
+		if !seg.ProgramDateTime.IsZero() {
+			pdt = seg.ProgramDateTime
+		} else if !pdt.IsZero() && i > 0 {
+			pdt = pdt.Add(seconds(segs[i-1].Duration))
+		}
+		if seg.Key != nil {
+			key = seg.Key
+		}
+		if seg.Map != nil {
+			m = seg.Map
+		}
+		if i >= start {
+			offset += seg.Duration
+			end = i + 1
+		}
+	}
+	if !pdt.IsZero() {
+		pdt = pdt.Add(seconds(segs[end-1].Duration))
+	}
+
+	first := segs[start]
+	spliced := make([]*MediaSegment, 0, len(segs)-(end-start)+len(replacement))
+	spliced = append(spliced, segs[:start]...)
+	var adKey *Key
+	var remap bool
+	for i, seg := range replacement {
+		s := *seg
+		if i == 0 {
+			s.Discontinuity = true
+			s.DateRange = first.DateRange
+			s.SCTE = first.SCTE
+			s.ProgramDateTime = first.ProgramDateTime
+		}
+		p.fitTargetDuration(s.Duration)
+		if s.Key != nil {
+			adKey = s.Key
+		}
+		remap = remap || s.Map != nil
+		spliced = append(spliced, &s)
+	}
+	if end < len(segs) {
+		next := segs[end]
+		next.Discontinuity = true
+		if !pdt.IsZero() && next.ProgramDateTime.IsZero() {
+			next.ProgramDateTime = pdt
+		}
+		// the key in effect after the replacement must be restored
+		// unless the replacement leaves the content as it was
+		rekey := adKey != nil && !(key == nil && adKey.Method == "NONE")
+		if rekey && next.Key == nil {
+			next.Key = key
+			if key == nil {
+				// the content is clear
+				next.Key = &Key{Method: "NONE"}
+			}
+		}
+		if remap && next.Map == nil && m != nil {
+			next.Map = m
+		}
+	}
+	spliced = append(spliced, segs[end:]...)
+	seqID := first.SeqId
+	for _, seg := range spliced[start:] {
+		seg.SeqId = seqID
+		seqID++
+	}
+
+	p.resetSegments(spliced)
+	return nil
+}
@@ -0,0 +1,114 @@
+/*
+Daterange replacement tests.
+
+Copyright 2013-2019 The Project Developers.
+See the AUTHORS and LICENSE files at the top-level directory of this distribution
+and at https://github.com/grafov/m3u8/
+
+ॐ तारे तुत्तारे तुरे स्व
+*/
+package m3u8
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReplaceRange(t *testing.T) {
+	p, e := NewMediaPlaylist(0, 6)
+	if e != nil {
+		t.Fatalf("Create media playlist failed: %s", e)
+	}
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 6; i++ {
+		p.Append(fmt.Sprintf("s%d.ts", i), 4, "")
+		switch i {
+		case 0:
+			p.SetProgramDateTime(start)
+		case 1:
+			p.SetDateRange([]*DateRange{{ID: "ad1", StartDate: start.Add(4 * time.Second), PlannedDuration: 8}})
+		}
+	}
+	ad := []*MediaSegment{
+		{URI: "ad0.ts", Duration: 5},
+		{URI: "ad1.ts", Duration: 5, Key: &Key{Method: "NONE"}},
+		{URI: "ad2.ts", Duration: 2},
+	}
+	if err := p.ReplaceRange("ad1", ad); err != nil {
+		t.Fatal(err)
+	}
+	if p.Count() != 7 {
+		t.Fatalf("expected 7 segments, got %d", p.Count())
+	}
+	var uris []string
+	for i := uint(0); i < p.Count(); i++ {
+		seg := p.At(i)
+		if seg.SeqId != uint64(i) {
+			t.Errorf("segment %s: expected sequence ID %d, got %d", seg.URI, i, seg.SeqId)
+		}
+		uris = append(uris, seg.URI)
+	}
+	if strings.Join(uris, " ") != "s0.ts ad0.ts ad1.ts ad2.ts s3.ts s4.ts s5.ts" {
+		t.Errorf("unexpected segments %v", uris)
+	}
+	if ad[0].Discontinuity || ad[0].DateRange != nil {
+		t.Error("replacement segments must be copied")
+	}
+	text := p.String()
+	for _, expected := range []string{
+		"#EXT-X-TARGETDURATION:5\n",
+		"#EXT-X-DATERANGE:ID=\"ad1\",START-DATE=\"2020-01-01T00:00:04Z\",PLANNED-DURATION=8\n#EXT-X-DISCONTINUITY\n#EXTINF:5.000,\nad0.ts\n",
+		"#EXT-X-DISCONTINUITY\n#EXT-X-PROGRAM-DATE-TIME:2020-01-01T00:00:12Z\n#EXTINF:4.000,\ns3.ts\n",
+	} {
+		if !strings.Contains(text, expected) {
+			t.Errorf("expected %q in:\n%s", expected, text)
+		}
+	}
+	if p.At(4).Key != nil {
+		t.Error("unencrypted playlist must not get a key after the replacement")
+	}
+
+	if err := p.ReplaceRange("ad2", ad); err != ErrDateRangeNotFound {
+		t.Errorf("expected %v, got %v", ErrDateRangeNotFound, err)
+	}
+	p.At(5).DateRange = []*DateRange{{ID: "open"}}
+	if err := p.ReplaceRange("open", ad); err == nil {
+		t.Error("daterange without duration must not be replaced")
+	}
+}
+
+func TestReplaceRangeRestoresKey(t *testing.T) {
+	p, _ := NewMediaPlaylist(0, 3)
+	p.Append("s0.ts", 4, "")
+	p.SetKey("AES-128", "k1", "", "", "")
+	p.SetDateRange([]*DateRange{{ID: "ad", Duration: 4}})
+	p.Append("s1.ts", 4, "")
+	p.Append("s2.ts", 4, "")
+	err := p.ReplaceRange("ad", []*MediaSegment{{URI: "ad.ts", Duration: 4, Key: &Key{Method: "NONE"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next := p.At(1); next.URI != "s1.ts" || next.Key == nil || next.Key.URI != "k1" {
+		t.Errorf("key of the content must be restored after the replacement: %+v", next)
+	}
+}
+
+func TestReplaceRangeClearContent(t *testing.T) {
+	p, _ := NewMediaPlaylist(0, 3)
+	p.Append("s0.ts", 4, "")
+	p.SetDateRange([]*DateRange{{ID: "ad", Duration: 4}})
+	p.Append("s1.ts", 4, "")
+	p.Append("s2.ts", 4, "")
+	err := p.ReplaceRange("ad", []*MediaSegment{{URI: "ad.ts", Duration: 4, Key: &Key{Method: "AES-128", URI: "adkey"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next := p.At(1); next.URI != "s1.ts" || next.Key == nil || next.Key.Method != "NONE" {
+		t.Errorf("content after the encrypted replacement must be clear: %+v", next)
+	}
+	if out := p.String(); !strings.Contains(out, "#EXT-X-KEY:METHOD=NONE\n#EXT-X-DISCONTINUITY\n#EXTINF:4.000,\ns1.ts") {
+		t.Errorf("unexpected playlist:\n%s", out)
+	}
+}
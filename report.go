@@ -0,0 +1,112 @@
+package m3u8
+
+/*
+ Part of M3U8 parser & generator library.
+ This file defines rendition reports of low-latency playlists.
+
+ Copyright 2013-2019 The Project Developers.
+ See the AUTHORS and LICENSE files at the top-level directory of this distribution
+ and at https://github.com/grafov/m3u8/
+
+ ॐ तारे तुत्तारे तुरे स्व
+*/
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// RenditionReport represents EXT-X-RENDITION-REPORT tag which carries
+// the state of another rendition of the stream, so clients may switch
+// renditions without an extra playlist request (section 4.4.5.4 of
+// rfc8216bis).
+type RenditionReport struct {
+	URI      string
+	LastMSN  uint64 // LAST-MSN, media sequence number of the last segment
+	LastPart int    // LAST-PART, index of the last part of the segment, negative if not set
+}
+
+// NewRenditionReport returns the report of the rendition with the URI
+// relative to the playlist carrying the report. LAST-MSN is the media
+// sequence number of the last segment or of the segment in progress
+// when the rendition has pending parts, LAST-PART is the index of the
+// last part of that segment. LAST-PART isn't set for renditions
+// without parts.
+func NewRenditionReport(uri string, rendition *MediaPlaylist) *RenditionReport {
+	r := &RenditionReport{URI: uri, LastPart: -1}
+	next := rendition.SeqNo
+	if rendition.count > 0 {
+		last := rendition.Segments[rendition.last()]
+		r.LastMSN = last.SeqId
+		r.LastPart = len(last.Partials) - 1
+		next = last.SeqId + 1
+	}
+	if n := len(rendition.PendingPartials); n > 0 {
+		r.LastMSN = next
+		r.LastPart = n - 1
+	}
+	return r
+}
+
+// AddRenditionReport adds the report of the rendition to the playlist
+// replacing the report with the same URI. This operation does reset
+// playlist cache.
+func (p *MediaPlaylist) AddRenditionReport(r *RenditionReport) error {
+	if r.URI == "" {
+		return errors.New("rendition report without URI")
+	}
+	for i, report := range p.RenditionReports {
+		if report.URI == r.URI {
+			p.RenditionReports[i] = r
+			p.buf.Reset()
+			return nil
+		}
+	}
+	p.RenditionReports = append(p.RenditionReports, r)
+	p.buf.Reset()
+	return nil
+}
+
+// decodeRenditionReport parses attributes of EXT-X-RENDITION-REPORT
+// tag.
+func decodeRenditionReport(line string, strict bool) (*RenditionReport, error) {
+	r := &RenditionReport{LastPart: -1}
+	for k, v := range decodeParamsLine(line) {
+		var err error
+		switch k {
+		case "URI":
+			r.URI = v
+		case "LAST-MSN":
+			r.LastMSN, err = strconv.ParseUint(v, 10, 64)
+		case "LAST-PART":
+			r.LastPart, err = strconv.Atoi(v)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %s: %v", k, v, err)
+		}
+	}
+	if strict && r.URI == "" {
+		return nil, errors.New("EXT-X-RENDITION-REPORT without URI")
+	}
+	return r, nil
+}
+
+// writeRenditionReport writes EXT-X-RENDITION-REPORT tag.
+func writeRenditionReport(buf *bytes.Buffer, r *RenditionReport) {
+	buf.WriteString("#EXT-X-RENDITION-REPORT:")
+	renditionReportAttrs(r).writeTo(buf, nil)
+	buf.WriteRune('\n')
+}
+
+// renditionReportAttrs returns attributes of EXT-X-RENDITION-REPORT tag.
+func renditionReportAttrs(r *RenditionReport) attrList {
+	var attrs attrList
+	attrs.quoted("URI", r.URI)
+	attrs.add("LAST-MSN", strconv.FormatUint(r.LastMSN, 10))
+	if r.LastPart >= 0 {
+		attrs.add("LAST-PART", strconv.Itoa(r.LastPart))
+	}
+	return attrs
+}
@@ -0,0 +1,57 @@
+/*
+Rendition reports tests.
+
+Copyright 2013-2019 The Project Developers.
+See the AUTHORS and LICENSE files at the top-level directory of this distribution
+and at https://github.com/grafov/m3u8/
+
+ॐ तारे तुत्तारे तुरे स्व
+*/
+package m3u8
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenditionReports(t *testing.T) {
+	audio, _ := NewMediaPlaylist(3, 3)
+	_ = audio.Append("a0.mp4", 2, "")
+	_ = audio.Append("a1.mp4", 2, "")
+	_ = audio.AppendPartial(&PartialSegment{URI: "a2.part0.mp4", Duration: 1})
+	_ = audio.AppendPartial(&PartialSegment{URI: "a2.part1.mp4", Duration: 1})
+	plain, _ := NewMediaPlaylist(3, 3)
+	plain.SeqNo = 10
+	_ = plain.Append("b10.ts", 2, "")
+
+	p, _ := NewMediaPlaylist(3, 3)
+	_ = p.Append("v0.mp4", 2, "")
+	if err := p.AddRenditionReport(NewRenditionReport("../audio/index.m3u8", audio)); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.AddRenditionReport(NewRenditionReport("../plain/index.m3u8", plain)); err != nil {
+		t.Fatal(err)
+	}
+	_ = audio.AppendPartial(&PartialSegment{URI: "a2.part2.mp4", Duration: 1})
+	if err := p.AddRenditionReport(NewRenditionReport("../audio/index.m3u8", audio)); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.AddRenditionReport(&RenditionReport{}); err == nil {
+		t.Error("expected error for report without URI")
+	}
+	expected := `#EXT-X-RENDITION-REPORT:URI="../audio/index.m3u8",LAST-MSN=2,LAST-PART=2
+#EXT-X-RENDITION-REPORT:URI="../plain/index.m3u8",LAST-MSN=10
+`
+	out := p.String()
+	if !strings.HasSuffix(out, expected) {
+		t.Fatalf("unexpected output:\n%s", out)
+	}
+
+	q, _ := NewMediaPlaylist(0, 3)
+	if err := q.DecodeFrom(strings.NewReader(out), true); err != nil {
+		t.Fatal(err)
+	}
+	if len(q.RenditionReports) != 2 || *q.RenditionReports[0] != *p.RenditionReports[0] || *q.RenditionReports[1] != *p.RenditionReports[1] {
+		t.Errorf("unexpected decoded reports: %+v", q.RenditionReports)
+	}
+}
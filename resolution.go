@@ -0,0 +1,91 @@
+package m3u8
+
+/*
+ Part of M3U8 parser & generator library.
+ This file defines RESOLUTION attribute of variants.
+
+ Copyright 2013-2019 The Project Developers.
+ See the AUTHORS and LICENSE files at the top-level directory of this distribution
+ and at https://github.com/grafov/m3u8/
+
+ ॐ तारे तुत्तारे तुरे स्व
+*/
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// parseResolution parses the decimal resolution (e.g. "1280x720") of
+// RESOLUTION attribute.
+func parseResolution(s string) (width, height int, err error) {
+	i := strings.IndexByte(s, 'x')
+	if i < 0 {
+		return 0, 0, fmt.Errorf("invalid RESOLUTION %q", s)
+	}
+	width, err = strconv.Atoi(s[:i])
+	if err == nil {
+		height, err = strconv.Atoi(s[i+1:])
+	}
+	if err != nil || width <= 0 || height <= 0 {
+		return 0, 0, fmt.Errorf("invalid RESOLUTION %q", s)
+	}
+	return width, height, nil
+}
+
+// resolution returns RESOLUTION of the variant to be written: Width
+// and Height if both are set, Resolution otherwise.
+func (vp *VariantParams) resolution() string {
+	if vp.Width > 0 && vp.Height > 0 {
+		return strconv.Itoa(vp.Width) + "x" + strconv.Itoa(vp.Height)
+	}
+	return vp.Resolution
+}
+
+// Dimensions returns width and height of the variant: Width and
+// Height if both are set or the values parsed from Resolution.
+func (vp *VariantParams) Dimensions() (width, height int, ok bool) {
+	if vp.Width > 0 && vp.Height > 0 {
+		return vp.Width, vp.Height, true
+	}
+	width, height, err := parseResolution(vp.Resolution)
+	return width, height, err == nil
+}
+
+// Pixels returns the number of pixels of the frames of the variant
+// or zero if its resolution is unknown.
+func (vp *VariantParams) Pixels() int {
+	w, h, _ := vp.Dimensions()
+	return w * h
+}
+
+// AspectRatio returns the ratio of the width to the height of the
+// variant or zero if its resolution is unknown.
+func (vp *VariantParams) AspectRatio() float64 {
+	w, h, ok := vp.Dimensions()
+	if !ok {
+		return 0
+	}
+	return float64(w) / float64(h)
+}
+
+// CompareResolution compares resolutions of the variants by the
+// number of pixels and then by the height. It returns -1, 0 or 1 if
+// the resolution of a is lower, equal or higher than the resolution of
+// b. Unknown resolution is lower than any known one.
+func CompareResolution(a, b *VariantParams) int {
+	pa, pb := a.Pixels(), b.Pixels()
+	if pa == pb {
+		_, ha, _ := a.Dimensions()
+		_, hb, _ := b.Dimensions()
+		pa, pb = ha, hb
+	}
+	switch {
+	case pa < pb:
+		return -1
+	case pa > pb:
+		return 1
+	}
+	return 0
+}
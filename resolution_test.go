@@ -0,0 +1,75 @@
+/*
+RESOLUTION attribute tests.
+
+Copyright 2013-2019 The Project Developers.
+See the AUTHORS and LICENSE files at the top-level directory of this distribution
+and at https://github.com/grafov/m3u8/
+
+ॐ तारे तुत्तारे तुरे स्व
+*/
+package m3u8
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestDecodeResolution(t *testing.T) {
+	const playlist = "#EXTM3U\n#EXT-X-STREAM-INF:BANDWIDTH=1000000,RESOLUTION=%s\nhd.m3u8\n"
+	p, _, err := DecodeFrom(bytes.NewBufferString(strings.Replace(playlist, "%s", "1280x720", 1)), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := p.(*MasterPlaylist).Variants[0]
+	if w, h, ok := v.Dimensions(); !ok || w != 1280 || h != 720 || v.Resolution != "1280x720" {
+		t.Errorf("unexpected resolution %dx%d (%s)", w, h, v.Resolution)
+	}
+
+	// the decoded resolution changed with Resolution or with Width
+	// and Height is written
+	v.Resolution = "1920x1080"
+	if out := p.String(); !strings.Contains(out, "RESOLUTION=1920x1080\n") {
+		t.Errorf("changed Resolution is not written:\n%s", out)
+	}
+	v.Width, v.Height = 640, 360
+	p.(*MasterPlaylist).ResetCache()
+	if out := p.String(); !strings.Contains(out, "RESOLUTION=640x360\n") {
+		t.Errorf("changed Width and Height are not written:\n%s", out)
+	}
+	for _, res := range []string{"1280", "1280x", "0x720", "wide"} {
+		if _, _, err = DecodeFrom(bytes.NewBufferString(strings.Replace(playlist, "%s", res, 1)), true); err == nil {
+			t.Errorf("RESOLUTION=%s is expected to fail in strict mode", res)
+		}
+		p, _, err = DecodeFrom(bytes.NewBufferString(strings.Replace(playlist, "%s", res, 1)), false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if out := p.String(); !strings.Contains(out, "RESOLUTION="+res+"\n") {
+			t.Errorf("RESOLUTION=%s is not kept:\n%s", res, out)
+		}
+	}
+}
+
+func TestEncodeResolution(t *testing.T) {
+	m := NewMasterPlaylist()
+	m.Append("hd.m3u8", nil, VariantParams{Bandwidth: 3000000, Width: 1280, Height: 720})
+	m.Append("sd.m3u8", nil, VariantParams{Bandwidth: 800000, Resolution: "640x360"})
+	m.Append("sq.m3u8", nil, VariantParams{Bandwidth: 800000, Width: 480, Height: 480})
+	out := m.String()
+	for _, e := range []string{"RESOLUTION=1280x720\nhd.m3u8", "RESOLUTION=640x360\nsd.m3u8"} {
+		if !strings.Contains(out, e) {
+			t.Errorf("expected %q in:\n%s", e, out)
+		}
+	}
+	hd, sd, sq := &m.Variants[0].VariantParams, &m.Variants[1].VariantParams, &m.Variants[2].VariantParams
+	if hd.Pixels() != 921600 || sd.AspectRatio() != 16.0/9 || sq.AspectRatio() != 1 {
+		t.Errorf("unexpected pixels %d and aspect ratios %v, %v", hd.Pixels(), sd.AspectRatio(), sq.AspectRatio())
+	}
+	if CompareResolution(hd, sd) != 1 || CompareResolution(sd, hd) != -1 || CompareResolution(sd, sd) != 0 {
+		t.Error("unexpected order of HD and SD")
+	}
+	if CompareResolution(&VariantParams{}, sq) != -1 || (&VariantParams{}).AspectRatio() != 0 {
+		t.Error("unknown resolution is expected to be the lowest")
+	}
+}
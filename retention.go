@@ -0,0 +1,55 @@
+package m3u8
+
+/*
+ Part of M3U8 parser & generator library.
+ This file defines retention policies of sliding windows.
+
+ Copyright 2013-2019 The Project Developers.
+ See the AUTHORS and LICENSE files at the top-level directory of this distribution
+ and at https://github.com/grafov/m3u8/
+
+ ॐ तारे तुत्तारे तुरे स्व
+*/
+
+import "time"
+
+// RetentionPolicy reports whether the oldest segment of the live
+// playlist should be removed after a segment was appended.
+type RetentionPolicy func(p *MediaPlaylist, oldest *MediaSegment) bool
+
+// RetainDuration returns the policy keeping the newest segments with
+// the total duration of at least the window, older segments are
+// removed.
+func RetainDuration(window time.Duration) RetentionPolicy {
+	return func(p *MediaPlaylist, oldest *MediaSegment) bool {
+		return seconds(p.TotalDuration()-oldest.Duration) >= window
+	}
+}
+
+// SetRetention sets the policy trimming the head of the live playlist
+// after each segment appended with Append, AppendSegment, Slide or
+// SlideSegment. The oldest segments are removed with Remove while the
+// policy reports so, so EXT-X-MEDIA-SEQUENCE and
+// EXT-X-DISCONTINUITY-SEQUENCE advance as usual and the newest segment
+// is never removed. When the playlist is full the oldest segment is
+// removed to make room for the appended one. Use window size 0 to
+// write all retained segments. Closed playlists are not trimmed. Nil
+// removes the policy.
+func (p *MediaPlaylist) SetRetention(policy RetentionPolicy) {
+	p.retention = policy
+}
+
+// retain removes the oldest segments accordingly with the retention
+// policy.
+func (p *MediaPlaylist) retain() {
+	if p.retention == nil || p.Closed {
+		return
+	}
+	for p.count > 1 {
+		oldest := p.Segments[p.head]
+		if oldest != nil && !p.retention(p, oldest) {
+			return
+		}
+		p.Remove()
+	}
+}
@@ -0,0 +1,62 @@
+/*
+Retention tests.
+
+Copyright 2013-2019 The Project Developers.
+See the AUTHORS and LICENSE files at the top-level directory of this distribution
+and at https://github.com/grafov/m3u8/
+
+ॐ तारे तुत्तारे तुरे स्व
+*/
+package m3u8
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestRetainDuration(t *testing.T) {
+	p, err := NewMediaPlaylist(0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.SetRetention(RetainDuration(20 * time.Second))
+	for i := 0; i < 10; i++ {
+		if err = p.Append(fmt.Sprintf("s%d.ts", i), 6, ""); err != nil {
+			t.Fatal(err)
+		}
+		if i == 3 {
+			p.SetDiscontinuity()
+		}
+	}
+	// 6+6+6 < 20, so four segments are retained
+	if p.Count() != 4 || p.SeqNo != 6 || p.At(0).URI != "s6.ts" {
+		t.Errorf("unexpected window: %d segments from %d", p.Count(), p.SeqNo)
+	}
+	if p.DiscontinuitySeq != 1 {
+		t.Errorf("expected discontinuity sequence 1, got %d", p.DiscontinuitySeq)
+	}
+
+	// a custom policy with a fixed capacity
+	p, _ = NewMediaPlaylist(0, 3)
+	p.SetRetention(func(p *MediaPlaylist, oldest *MediaSegment) bool {
+		return oldest.Title == "expired"
+	})
+	p.Append("a.ts", 6, "expired")
+	p.Append("b.ts", 6, "")
+	p.Append("c.ts", 6, "expired")
+	p.Append("d.ts", 6, "")
+	if err = p.Append("e.ts", 6, ""); err != nil {
+		t.Fatalf("full playlist with retention must accept segments: %v", err)
+	}
+	if p.Count() != 2 || p.At(0).URI != "d.ts" || p.SeqNo != 3 {
+		t.Errorf("unexpected window: %d segments from %s", p.Count(), p.At(0).URI)
+	}
+
+	p.Close()
+	p.SetRetention(RetainDuration(0))
+	p.Append("f.ts", 6, "")
+	if p.Count() != 3 {
+		t.Error("closed playlist must not be trimmed")
+	}
+}
@@ -0,0 +1,135 @@
+package m3u8
+
+/*
+ Part of M3U8 parser & generator library.
+ This file defines retries of fetching of playlists.
+
+ Copyright 2013-2019 The Project Developers.
+ See the AUTHORS and LICENSE files at the top-level directory of this distribution
+ and at https://github.com/grafov/m3u8/
+
+ ॐ तारे तुत्तारे तुरे स्व
+*/
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// RetryPolicy defines retries of failed fetches with exponential
+// backoff.
+type RetryPolicy struct {
+	MaxRetries     int           // retries after the first attempt
+	InitialBackoff time.Duration // delay before the first retry
+	MaxBackoff     time.Duration // upper bound of the delay, zero means no bound
+	Multiplier     float64       // growth of the delay, values below 1 mean 2
+	Jitter         float64       // random part of the delay from 0 to 1
+	// StaleWhileError is the age of the last successfully fetched
+	// content of the URI which may be returned instead of the error
+	// when all retries failed. Zero disables stale content.
+	StaleWhileError time.Duration
+}
+
+// Backoff returns the delay before the retry with the number
+// (starting from 1).
+func (rp RetryPolicy) Backoff(retry int) time.Duration {
+	multiplier := rp.Multiplier
+	if multiplier < 1 {
+		multiplier = 2
+	}
+	backoff := float64(rp.InitialBackoff)
+	for i := 1; i < retry; i++ {
+		backoff *= multiplier
+		if rp.MaxBackoff > 0 && backoff > float64(rp.MaxBackoff) {
+			break
+		}
+	}
+	if rp.MaxBackoff > 0 && backoff > float64(rp.MaxBackoff) {
+		backoff = float64(rp.MaxBackoff)
+	}
+	if rp.Jitter > 0 {
+		backoff -= backoff * rp.Jitter * rand.Float64()
+	}
+	return time.Duration(backoff)
+}
+
+// RetryFetcher is a Fetcher retrying failed fetches of the underlying
+// fetcher accordingly with the policy, so transient origin errors of
+// live playlists don't surface as stream-down events. With
+// StaleWhileError policy contents are read into memory so it should
+// be used for playlists and not for media segments.
+type RetryFetcher struct {
+	Fetcher Fetcher
+	Policy  RetryPolicy
+
+	mu    sync.Mutex
+	stale map[string]staleContent
+}
+
+// staleContent is the last successfully fetched content of the URI.
+type staleContent struct {
+	data    []byte
+	fetched time.Time
+}
+
+// NewRetryFetcher returns the fetcher retrying fetches of f
+// accordingly with the policy.
+func NewRetryFetcher(f Fetcher, policy RetryPolicy) *RetryFetcher {
+	return &RetryFetcher{Fetcher: f, Policy: policy}
+}
+
+// Fetch implements Fetcher interface.
+func (f *RetryFetcher) Fetch(ctx context.Context, uri string) (io.ReadCloser, error) {
+	var (
+		body io.ReadCloser
+		err  error
+	)
+	for retry := 0; ; retry++ {
+		if body, err = f.fetch(ctx, uri); err == nil {
+			return body, nil
+		}
+		if retry >= f.Policy.MaxRetries || ctx.Err() != nil {
+			break
+		}
+		timer := time.NewTimer(f.Policy.Backoff(retry + 1))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+		case <-timer.C:
+		}
+	}
+	if f.Policy.StaleWhileError > 0 {
+		f.mu.Lock()
+		stale, ok := f.stale[uri]
+		f.mu.Unlock()
+		if ok && time.Since(stale.fetched) <= f.Policy.StaleWhileError {
+			return ioutil.NopCloser(bytes.NewReader(stale.data)), nil
+		}
+	}
+	return nil, err
+}
+
+// fetch makes one attempt and remembers the content for stale use.
+func (f *RetryFetcher) fetch(ctx context.Context, uri string) (io.ReadCloser, error) {
+	body, err := f.Fetcher.Fetch(ctx, uri)
+	if err != nil || f.Policy.StaleWhileError <= 0 {
+		return body, err
+	}
+	defer body.Close()
+	data, err := ioutil.ReadAll(body)
+	if err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	if f.stale == nil {
+		f.stale = make(map[string]staleContent)
+	}
+	f.stale[uri] = staleContent{data: data, fetched: time.Now()}
+	f.mu.Unlock()
+	return ioutil.NopCloser(bytes.NewReader(data)), nil
+}
@@ -0,0 +1,79 @@
+/*
+Fetch retries tests.
+
+Copyright 2013-2019 The Project Developers.
+See the AUTHORS and LICENSE files at the top-level directory of this distribution
+and at https://github.com/grafov/m3u8/
+
+ॐ तारे तुत्तारे तुरे स्व
+*/
+package m3u8
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	rp := RetryPolicy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+	expected := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond, time.Second, time.Second}
+	for i, e := range expected {
+		if b := rp.Backoff(i + 1); b != e {
+			t.Errorf("retry %d: expected %s, got %s", i+1, e, b)
+		}
+	}
+	rp.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		if b := rp.Backoff(1); b < 50*time.Millisecond || b > 100*time.Millisecond {
+			t.Fatalf("backoff with jitter out of range: %s", b)
+		}
+	}
+}
+
+func TestRetryFetcher(t *testing.T) {
+	var calls int
+	failures := 2
+	origin := FetcherFunc(func(ctx context.Context, uri string) (io.ReadCloser, error) {
+		calls++
+		if calls <= failures {
+			return nil, errors.New("origin error")
+		}
+		return ioutil.NopCloser(strings.NewReader("#EXTM3U\n")), nil
+	})
+	f := NewRetryFetcher(origin, RetryPolicy{MaxRetries: 2, InitialBackoff: time.Millisecond, StaleWhileError: time.Minute})
+	body, err := f.Fetch(context.Background(), "live.m3u8")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body.Close()
+	if calls != 3 {
+		t.Errorf("expected 3 attempts, got %d", calls)
+	}
+
+	// origin is down, stale content is returned
+	calls, failures = 0, 10
+	body, err = f.Fetch(context.Background(), "live.m3u8")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := ioutil.ReadAll(body)
+	if string(data) != "#EXTM3U\n" || calls != 3 {
+		t.Errorf("expected stale content after 3 attempts, got %q after %d", data, calls)
+	}
+	if _, err = f.Fetch(context.Background(), "other.m3u8"); err == nil {
+		t.Error("expected error without stale content")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	calls = 0
+	f.Policy.StaleWhileError = 0
+	if _, err = f.Fetch(ctx, "live.m3u8"); err == nil || calls != 1 {
+		t.Errorf("expected no retries with canceled context, got %d attempts", calls)
+	}
+}
@@ -0,0 +1,174 @@
+package m3u8
+
+/*
+ Part of M3U8 parser & generator library.
+ This file defines rewriting and resolution of URIs of playlists.
+
+ Copyright 2013-2019 The Project Developers.
+ See the AUTHORS and LICENSE files at the top-level directory of this distribution
+ and at https://github.com/grafov/m3u8/
+
+ ॐ तारे तुत्तारे तुरे स्व
+*/
+
+import "net/url"
+
+// URIKind is the kind of URI passed to the function of
+// RewriteURIsByKind, so keys or assets may be rewritten differently
+// from segments (e.g. signed with another token).
+type URIKind uint
+
+const (
+	// use 0 for not defined kind
+	URISegment         URIKind = iota + 1 // URI of the media segment
+	URIPart                               // URI of EXT-X-PART (parts of segments and pending parts)
+	URIKey                                // URI attribute of EXT-X-KEY
+	URIMap                                // URI attribute of EXT-X-MAP
+	URIPreloadHint                        // URI attribute of EXT-X-PRELOAD-HINT
+	URIRenditionReport                    // URI attribute of EXT-X-RENDITION-REPORT
+	URIAsset                              // X-ASSET-URI of EXT-X-DATERANGE
+	URIAssetList                          // X-ASSET-LIST of EXT-X-DATERANGE
+	URIVariant                            // URI of the variant (EXT-X-STREAM-INF, I-frame and image variants)
+	URIRendition                          // URI attribute of EXT-X-MEDIA
+	URISessionData                        // URI attribute of EXT-X-SESSION-DATA
+	URISteering                           // SERVER-URI of EXT-X-CONTENT-STEERING
+)
+
+// RewriteURIs replaces URIs of the media playlist with the results of
+// the function: URIs of the segments, partial segments, keys, maps,
+// preload hints, rendition reports and X-ASSET-URI and X-ASSET-LIST
+// of the dateranges. Keys, maps and dateranges shared by several
+// segments are rewritten once. Empty URIs are not passed to the
+// function. This operation does reset playlist cache.
+func (p *MediaPlaylist) RewriteURIs(fn func(uri string) string) {
+	p.RewriteURIsByKind(func(kind URIKind, uri string) string {
+		return fn(uri)
+	})
+}
+
+// RewriteURIsByKind replaces URIs of the media playlist as
+// RewriteURIs does passing the kind of each URI to the function. This
+// operation does reset playlist cache.
+func (p *MediaPlaylist) RewriteURIsByKind(fn func(kind URIKind, uri string) string) {
+	rewrite := func(kind URIKind, uri *string) {
+		if *uri != "" {
+			*uri = fn(kind, *uri)
+		}
+	}
+	maps := make(map[*Map]bool)
+	rewriteMap := func(m *Map) {
+		if m != nil && !maps[m] {
+			maps[m] = true
+			rewrite(URIMap, &m.URI)
+		}
+	}
+	dateRanges := make(map[*DateRange]bool)
+	rewriteMap(p.Map)
+	for _, seg := range p.segments() {
+		rewrite(URISegment, &seg.URI)
+		rewriteMap(seg.Map)
+		for _, part := range seg.Partials {
+			rewrite(URIPart, &part.URI)
+		}
+		for _, dr := range seg.DateRange {
+			if dr != nil && !dateRanges[dr] {
+				dateRanges[dr] = true
+				rewrite(URIAsset, &dr.XAssetURI)
+				rewrite(URIAssetList, &dr.XAssetList)
+			}
+		}
+	}
+	for _, key := range p.keys() {
+		rewrite(URIKey, &key.URI)
+	}
+	for _, part := range p.PendingPartials {
+		rewrite(URIPart, &part.URI)
+	}
+	for _, hint := range p.PreloadHints {
+		rewrite(URIPreloadHint, &hint.URI)
+	}
+	for _, r := range p.RenditionReports {
+		rewrite(URIRenditionReport, &r.URI)
+	}
+	p.buf.Reset()
+}
+
+// RewriteURIs replaces URIs of the master playlist with the results
+// of the function: URIs of the variants, renditions, session data and
+// SERVER-URI of the content steering. Renditions shared by several
+// variants are rewritten once. Media playlists of the variants
+// (Chunklist) are not changed. Empty URIs are not passed to the
+// function. This operation does reset playlist cache.
+func (p *MasterPlaylist) RewriteURIs(fn func(uri string) string) {
+	p.RewriteURIsByKind(func(kind URIKind, uri string) string {
+		return fn(uri)
+	})
+}
+
+// RewriteURIsByKind replaces URIs of the master playlist as
+// RewriteURIs does passing the kind of each URI to the function. This
+// operation does reset playlist cache.
+func (p *MasterPlaylist) RewriteURIsByKind(fn func(kind URIKind, uri string) string) {
+	rewrite := func(kind URIKind, uri *string) {
+		if *uri != "" {
+			*uri = fn(kind, *uri)
+		}
+	}
+	alts := make(map[*Alternative]bool)
+	for _, v := range p.Variants {
+		if v == nil {
+			continue
+		}
+		rewrite(URIVariant, &v.URI)
+		for _, alt := range v.Alternatives {
+			if alt != nil && !alts[alt] {
+				alts[alt] = true
+				rewrite(URIRendition, &alt.URI)
+			}
+		}
+	}
+	for _, sd := range p.SessionData {
+		rewrite(URISessionData, &sd.URI)
+	}
+	if p.ContentSteering != nil {
+		rewrite(URISteering, &p.ContentSteering.ServerURI)
+	}
+	p.buf.Reset()
+}
+
+// ResolveURIs resolves relative URIs of the media playlist (see
+// RewriteURIs) against the base URL, usually the URL the playlist was
+// fetched from. Invalid URIs are left intact. This operation does
+// reset playlist cache.
+func (p *MediaPlaylist) ResolveURIs(base *url.URL) {
+	p.RewriteURIs(resolveFunc(base))
+}
+
+// ResolveURIs resolves relative URIs of the master playlist (see
+// RewriteURIs) against the base URL, usually the URL the playlist was
+// fetched from. URIs of media playlists of the variants (Chunklist)
+// are resolved against the resolved URIs of the variants. Invalid
+// URIs are left intact. This operation does reset playlist cache.
+func (p *MasterPlaylist) ResolveURIs(base *url.URL) {
+	p.RewriteURIs(resolveFunc(base))
+	for _, v := range p.Variants {
+		if v == nil || v.Chunklist == nil {
+			continue
+		}
+		if u, err := url.Parse(v.URI); err == nil {
+			v.Chunklist.ResolveURIs(u)
+		}
+	}
+}
+
+// resolveFunc returns the function resolving URIs against the base
+// URL.
+func resolveFunc(base *url.URL) func(uri string) string {
+	return func(uri string) string {
+		ref, err := url.Parse(uri)
+		if err != nil {
+			return uri
+		}
+		return base.ResolveReference(ref).String()
+	}
+}
@@ -0,0 +1,126 @@
+/*
+URI rewriting tests.
+
+Copyright 2013-2019 The Project Developers.
+See the AUTHORS and LICENSE files at the top-level directory of this distribution
+and at https://github.com/grafov/m3u8/
+
+ॐ तारे तुत्तारे तुरे स्व
+*/
+package m3u8
+
+import (
+	"bytes"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestMediaPlaylistResolveURIs(t *testing.T) {
+	const playlist = `#EXTM3U
+#EXT-X-VERSION:7
+#EXT-X-TARGETDURATION:10
+#EXT-X-PART-INF:PART-TARGET=1
+#EXT-X-MAP:URI="init.mp4"
+#EXT-X-KEY:METHOD=AES-128,URI="/keys/1"
+#EXT-X-DATERANGE:ID="ad",START-DATE="2020-01-01T00:00:00Z",X-ASSET-URI="ads/1.m3u8"
+#EXTINF:10,
+a.ts
+#EXT-X-PART:DURATION=1,URI="b.0.ts"
+#EXT-X-PRELOAD-HINT:TYPE=PART,URI="b.1.ts"
+#EXT-X-RENDITION-REPORT:URI="../low/index.m3u8",LAST-MSN=1
+`
+	p, _, err := DecodeFrom(bytes.NewBufferString(playlist), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pp := p.(*MediaPlaylist)
+	base, _ := url.Parse("https://cdn.example.com/live/high/index.m3u8")
+	pp.ResolveURIs(base)
+	out := pp.String()
+	for _, e := range []string{
+		`URI="https://cdn.example.com/live/high/init.mp4"`,
+		`URI="https://cdn.example.com/keys/1"`,
+		`X-ASSET-URI="https://cdn.example.com/live/high/ads/1.m3u8"`,
+		"\nhttps://cdn.example.com/live/high/a.ts\n",
+		`URI="https://cdn.example.com/live/high/b.0.ts"`,
+		`URI="https://cdn.example.com/live/high/b.1.ts"`,
+		`URI="https://cdn.example.com/live/low/index.m3u8"`,
+	} {
+		if !strings.Contains(out, e) {
+			t.Errorf("%s is not found in:\n%s", e, out)
+		}
+	}
+}
+
+func TestMasterPlaylistRewriteURIs(t *testing.T) {
+	m := NewMasterPlaylist()
+	alt := &Alternative{GroupId: "aac", Type: "AUDIO", Name: "en", URI: "en.m3u8"}
+	m.Append("high.m3u8", nil, VariantParams{Bandwidth: 2000000, Audio: "aac", Alternatives: []*Alternative{alt}})
+	m.Append("low.m3u8", nil, VariantParams{Bandwidth: 500000, Audio: "aac", Alternatives: []*Alternative{alt}})
+	m.SessionData = append(m.SessionData, &SessionData{DataID: "com.example", URI: "data.json"})
+	m.ContentSteering = &ContentSteering{ServerURI: "steering.json"}
+	before := m.String()
+	calls := 0
+	m.RewriteURIs(func(uri string) string {
+		calls++
+		return "/v1/" + uri
+	})
+	if calls != 5 {
+		t.Errorf("function is called %d times, expected 5", calls)
+	}
+	out := m.String()
+	if out == before {
+		t.Fatal("cache is not reset")
+	}
+	for _, e := range []string{"\n/v1/high.m3u8\n", "\n/v1/low.m3u8\n", `URI="/v1/en.m3u8"`, `URI="/v1/data.json"`, `SERVER-URI="/v1/steering.json"`} {
+		if !strings.Contains(out, e) {
+			t.Errorf("%s is not found in:\n%s", e, out)
+		}
+	}
+}
+
+func TestRewriteURIsByKind(t *testing.T) {
+	const playlist = `#EXTM3U
+#EXT-X-VERSION:7
+#EXT-X-TARGETDURATION:10
+#EXT-X-MAP:URI="init.mp4"
+#EXT-X-KEY:METHOD=AES-128,URI="key"
+#EXTINF:10,
+a.ts
+#EXTINF:10,
+b.ts
+`
+	p, _, err := DecodeFrom(bytes.NewBufferString(playlist), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kinds := make(map[URIKind]int)
+	p.(*MediaPlaylist).RewriteURIsByKind(func(kind URIKind, uri string) string {
+		kinds[kind]++
+		if kind == URIKey {
+			return uri + "?key-token=1"
+		}
+		return uri + "?token=1"
+	})
+	if kinds[URISegment] != 2 || kinds[URIKey] == 0 || kinds[URIMap] == 0 || len(kinds) != 3 {
+		t.Errorf("unexpected kinds of URIs: %v", kinds)
+	}
+	out := p.String()
+	for _, e := range []string{`URI="key?key-token=1"`, `URI="init.mp4?token=1"`, "\na.ts?token=1\n"} {
+		if !strings.Contains(out, e) {
+			t.Errorf("%s is not found in:\n%s", e, out)
+		}
+	}
+
+	m := NewMasterPlaylist()
+	m.Append("high.m3u8", nil, VariantParams{Bandwidth: 2000000, Alternatives: []*Alternative{{GroupId: "aac", Type: "AUDIO", Name: "en", URI: "en.m3u8"}}})
+	kinds = make(map[URIKind]int)
+	m.RewriteURIsByKind(func(kind URIKind, uri string) string {
+		kinds[kind]++
+		return uri
+	})
+	if kinds[URIVariant] != 1 || kinds[URIRendition] != 1 || len(kinds) != 2 {
+		t.Errorf("unexpected kinds of URIs: %v", kinds)
+	}
+}
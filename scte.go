@@ -0,0 +1,233 @@
+package m3u8
+
+/*
+ Part of M3U8 parser & generator library.
+ This file defines conversion of SCTE-35 cue tags to EXT-X-DATERANGE
+ tags and back.
+
+ Copyright 2013-2019 The Project Developers.
+ See the AUTHORS and LICENSE files at the top-level directory of this distribution
+ and at https://github.com/grafov/m3u8/
+
+ ॐ तारे तुत्तारे तुरे स्व
+*/
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrCueTimeUnknown is returned by SCTEToDateRanges for the cue of a
+// segment without known wall-clock time as START-DATE of the daterange
+// can't be derived.
+var ErrCueTimeUnknown = errors.New("wall-clock time of the cue is unknown, EXT-X-PROGRAM-DATE-TIME required")
+
+// cueHex returns the splice info section of the cue as the hexadecimal
+// sequence used by SCTE35-OUT, SCTE35-IN and SCTE35-CMD attributes.
+// Cues of SCTE-35 tags are base64 encoded, hexadecimal sequences are
+// returned as is.
+func cueHex(cue string) (string, error) {
+	if cue == "" || isHexSequence(cue) {
+		return cue, nil
+	}
+	b, err := base64.StdEncoding.DecodeString(cue)
+	if err != nil {
+		return "", fmt.Errorf("invalid SCTE-35 cue %q: %v", cue, err)
+	}
+	return "0x" + strings.ToUpper(hex.EncodeToString(b)), nil
+}
+
+// cueBase64 returns the hexadecimal sequence of the daterange as
+// base64 encoded cue of SCTE-35 tags.
+func cueBase64(seq string) (string, error) {
+	if !isHexSequence(seq) {
+		return seq, nil
+	}
+	digits := seq[2:]
+	if len(digits)%2 == 1 {
+		digits = "0" + digits
+	}
+	b, err := hex.DecodeString(digits)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(b), nil
+}
+
+// DateRange converts the cue to EXT-X-DATERANGE with the ID (ID of the
+// cue if it has one) starting at the time. A start cue is converted
+// to SCTE35-OUT with PLANNED-DURATION of the break, an end cue to
+// SCTE35-IN and SCTE35_67_2014 cues to SCTE35-CMD. The splice info is
+// converted from base64 to the hexadecimal sequence. Cues without
+// splice info produce dateranges without SCTE35 attributes. Mid cues
+// have no daterange, nil is returned for them.
+func (s *SCTE) DateRange(id string, start time.Time) (*DateRange, error) {
+	if s.CueType == SCTE35Cue_Mid {
+		return nil, nil
+	}
+	cue, err := cueHex(s.Cue)
+	if err != nil {
+		return nil, err
+	}
+	if s.ID != "" {
+		id = s.ID
+	}
+	dr := &DateRange{ID: id, StartDate: start}
+	switch {
+	case s.Syntax == SCTE35_67_2014:
+		dr.SCTE35Cmd = cue
+	case s.CueType == SCTE35Cue_Start:
+		dr.SCTE35Out = cue
+		dr.PlannedDuration = s.Time
+	default:
+		dr.SCTE35In = cue
+	}
+	return dr, nil
+}
+
+// SCTEToDateRanges replaces SCTE-35 cue tags of the segments (any
+// syntax) with EXT-X-DATERANGE tags, so heterogeneous ad markers are
+// normalized to the representation defined by the HLS specification.
+// The dateranges start at the wall-clock time of the segments derived
+// from EXT-X-PROGRAM-DATE-TIME. The end cue of the break gets the
+// daterange with the ID and START-DATE of the start cue and the
+// DURATION of the break (section 4.4.5.1: a later daterange with the
+// same ID adds attributes). Mid cues are dropped, cues linked from
+// dateranges (SCTE35_DATERANGE) are unlinked. IDs of cues without ID
+// are "splice-" with the sequence number of the segment. It returns
+// ErrCueTimeUnknown and leaves the playlist intact if the wall-clock
+// time of a cue is unknown. This operation does reset playlist cache.
+func (p *MediaPlaylist) SCTEToDateRanges() error {
+	type conversion struct {
+		seg *MediaSegment
+		dr  *DateRange
+	}
+	var (
+		list   []conversion
+		base   time.Time
+		offset float64
+		baseAt float64
+		open   *DateRange
+		err    error
+	)
+	p.eachSegment(func(seg *MediaSegment) {
+		if !seg.ProgramDateTime.IsZero() {
+			base, baseAt = seg.ProgramDateTime, offset
+		}
+		at := base.Add(time.Duration((offset - baseAt) * float64(time.Second)))
+		offset += seg.Duration
+		if err != nil || seg.SCTE == nil {
+			return
+		}
+		c := conversion{seg: seg}
+		list = append(list, c)
+		if seg.SCTE.Syntax == SCTE35_DATERANGE || seg.SCTE.CueType == SCTE35Cue_Mid {
+			return
+		}
+		if base.IsZero() {
+			err = ErrCueTimeUnknown
+			return
+		}
+		var dr *DateRange
+		if dr, err = seg.SCTE.DateRange(fmt.Sprintf("splice-%d", seg.SeqId), at); err != nil {
+			return
+		}
+		switch {
+		case seg.SCTE.Syntax == SCTE35_67_2014:
+		case seg.SCTE.CueType == SCTE35Cue_Start:
+			open = dr
+		case open != nil:
+			dr.ID, dr.StartDate = open.ID, open.StartDate
+			dr.Duration = at.Sub(open.StartDate).Seconds()
+			open = nil
+		case dr.SCTE35In == "":
+			// the end of unknown break without splice info
+			dr = nil
+		}
+		list[len(list)-1].dr = dr
+	})
+	if err != nil {
+		return err
+	}
+	for _, c := range list {
+		c.seg.SCTE = nil
+		if c.dr != nil {
+			c.seg.DateRange = append(c.seg.DateRange, c.dr)
+		}
+	}
+	p.buf.Reset()
+	return nil
+}
+
+// DateRangesToSCTE replaces EXT-X-DATERANGE tags carrying SCTE35-OUT,
+// SCTE35-IN or SCTE35-CMD with SCTE-35 cue tags of the syntax
+// (SCTE35_67_2014, SCTE35_OATCLS, SCTE35_ELEMENTAL or SCTE35_ADOBE)
+// for downstream systems which don't support dateranges. The splice
+// info is converted to base64 (values which aren't hexadecimal
+// sequences are kept as is). Segments of the break started by
+// SCTE35-OUT get mid cues (except SCTE35_67_2014 and SCTE35_ADOBE)
+// until the DURATION or PLANNED-DURATION of the break is elapsed, the
+// next segment gets the end cue unless the break is ended with
+// SCTE35-IN before. Only the first daterange with SCTE35 attributes
+// of a segment is converted, other dateranges are kept. This
+// operation does reset playlist cache.
+func (p *MediaPlaylist) DateRangesToSCTE(syntax SCTE35Syntax) error {
+	switch syntax {
+	case SCTE35_67_2014, SCTE35_OATCLS, SCTE35_ELEMENTAL, SCTE35_ADOBE:
+	default:
+		return fmt.Errorf("unsupported SCTE-35 syntax %d", syntax)
+	}
+	var (
+		err     error
+		open    *SCTE
+		elapsed float64
+	)
+	p.eachSegment(func(seg *MediaSegment) {
+		if err != nil {
+			return
+		}
+		var cue *SCTE
+		var out bool
+		for i, dr := range seg.DateRange {
+			if cue = dr.SCTE(); cue == nil {
+				continue
+			}
+			if cue.Cue, err = cueBase64(cue.Cue); err != nil {
+				return
+			}
+			cue.Syntax = syntax
+			out = dr.SCTE35Out != ""
+			if syntax == SCTE35_ADOBE {
+				cue.Start = float64(dr.StartDate.UnixNano()) / float64(time.Second)
+			} else {
+				cue.ID = ""
+			}
+			if syntax == SCTE35_67_2014 {
+				cue.Time = 0
+			}
+			seg.DateRange = append(seg.DateRange[:i:i], seg.DateRange[i+1:]...)
+			break
+		}
+		switch {
+		case out && cue.Time > 0 && syntax != SCTE35_67_2014:
+			open, elapsed = cue, 0
+		case cue != nil:
+			open = nil
+		case open != nil && elapsed >= open.Time:
+			cue = &SCTE{Syntax: syntax, CueType: SCTE35Cue_End, ID: open.ID}
+			open = nil
+		case open != nil && syntax != SCTE35_ADOBE:
+			cue = &SCTE{Syntax: syntax, CueType: SCTE35Cue_Mid, Cue: open.Cue, Time: open.Time, Elapsed: elapsed}
+		}
+		if cue != nil {
+			seg.SCTE = cue
+		}
+		elapsed += seg.Duration
+	})
+	p.buf.Reset()
+	return err
+}
@@ -0,0 +1,87 @@
+/*
+SCTE-35 conversion tests.
+
+Copyright 2013-2019 The Project Developers.
+See the AUTHORS and LICENSE files at the top-level directory of this distribution
+and at https://github.com/grafov/m3u8/
+
+ॐ तारे तुत्तारे तुरे स्व
+*/
+package m3u8
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSCTEToDateRanges(t *testing.T) {
+	src := `#EXTM3U
+#EXT-X-VERSION:3
+#EXT-X-TARGETDURATION:10
+#EXT-X-MEDIA-SEQUENCE:100
+#EXT-X-PROGRAM-DATE-TIME:2020-01-01T00:00:00Z
+#EXTINF:10.000,
+main0.ts
+#EXT-OATCLS-SCTE35:/DAlAAAAAAAAAP/wFAUAAAABf+/+AAAAAH4AKTLgAAEAAAAAuVx0Kw==
+#EXT-X-CUE-OUT:20
+#EXTINF:10.000,
+ad0.ts
+#EXT-X-CUE-OUT-CONT:ElapsedTime=10,Duration=20,SCTE35=/DAlAAAAAAAAAP/wFAUAAAABf+/+AAAAAH4AKTLgAAEAAAAAuVx0Kw==
+#EXTINF:10.000,
+ad1.ts
+#EXT-X-CUE-IN
+#EXTINF:10.000,
+main1.ts
+`
+	p, err := NewMediaPlaylist(0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = p.DecodeFrom(strings.NewReader(src), true); err != nil {
+		t.Fatal(err)
+	}
+	if err = p.SCTEToDateRanges(); err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2020, 1, 1, 0, 0, 10, 0, time.UTC)
+	out := p.Segments[1].DateRange
+	if len(out) != 1 || out[0].ID != "splice-101" || !out[0].StartDate.Equal(start) || out[0].PlannedDuration != 20 ||
+		out[0].SCTE35Out != "0xFC302500000000000000FFF01405000000017FEFFE000000007E002932E0000100000000B95C742B" {
+		t.Errorf("unexpected start daterange: %+v", out)
+	}
+	if len(p.Segments[2].DateRange) != 0 {
+		t.Errorf("unexpected daterange of the mid cue: %+v", p.Segments[2].DateRange)
+	}
+	in := p.Segments[3].DateRange
+	if len(in) != 1 || in[0].ID != "splice-101" || !in[0].StartDate.Equal(start) || in[0].Duration != 20 || in[0].SCTE35In != "" {
+		t.Errorf("unexpected end daterange: %+v", in)
+	}
+	for i := 0; i < 4; i++ {
+		if p.Segments[i].SCTE != nil {
+			t.Errorf("segment %d keeps the cue", i)
+		}
+	}
+
+	// and back
+	if err = p.DateRangesToSCTE(SCTE35_OATCLS); err != nil {
+		t.Fatal(err)
+	}
+	res := strings.Replace(p.String(), "#EXT-X-DATERANGE:ID=\"splice-101\",START-DATE=\"2020-01-01T00:00:10Z\",DURATION=20\n", "", 1)
+	if !strings.HasSuffix(res, src[strings.Index(src, "#EXT-OATCLS"):]) {
+		t.Errorf("unexpected cues:\n%s", res)
+	}
+	if len(p.Segments[3].DateRange) != 1 {
+		t.Error("the end daterange without SCTE35 attributes must be kept")
+	}
+
+	q, _ := NewMediaPlaylist(3, 3)
+	_ = q.Append("a.ts", 10, "")
+	q.Segments[0].SCTE = &SCTE{Syntax: SCTE35_ELEMENTAL, CueType: SCTE35Cue_Start, Time: 30}
+	if err = q.SCTEToDateRanges(); err != ErrCueTimeUnknown {
+		t.Errorf("expected ErrCueTimeUnknown, got %v", err)
+	}
+	if q.Segments[0].SCTE == nil {
+		t.Error("playlist modified on error")
+	}
+}
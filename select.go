@@ -0,0 +1,366 @@
+package m3u8
+
+/*
+ Part of M3U8 parser & generator library.
+ This file defines selection of variants of master playlists.
+
+ Copyright 2013-2019 The Project Developers.
+ See the AUTHORS and LICENSE files at the top-level directory of this distribution
+ and at https://github.com/grafov/m3u8/
+
+ ॐ तारे तुत्तारे तुरे स्व
+*/
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// VariantConstraints describes capabilities of a device or a network
+// for selection of variants. Zero values mean no constraint.
+type VariantConstraints struct {
+	MaxBandwidth uint32
+	MaxWidth     int
+	MaxHeight    int
+	// Codecs lists allowed codec prefixes (e.g. "avc1", "mp4a"), all
+	// codecs of a variant must match one of them.
+	Codecs []string
+	// MaxHDCPLevel is the highest supported HDCP-LEVEL: NONE, TYPE-0
+	// or TYPE-1. Empty value means no constraint.
+	MaxHDCPLevel string
+	// SDROnly excludes variants with PQ and HLG VIDEO-RANGE.
+	SDROnly bool
+}
+
+// Allows reports whether the variant satisfies the constraints.
+func (c VariantConstraints) Allows(v *Variant) bool {
+	if c.MaxBandwidth > 0 && v.Bandwidth > c.MaxBandwidth {
+		return false
+	}
+	if c.MaxWidth > 0 || c.MaxHeight > 0 {
+		if w, h, ok := v.Dimensions(); ok && (c.MaxWidth > 0 && w > c.MaxWidth || c.MaxHeight > 0 && h > c.MaxHeight) {
+			return false
+		}
+	}
+	if len(c.Codecs) > 0 && v.Codecs != "" {
+		for _, codec := range strings.Split(v.Codecs, ",") {
+			if !hasCodecPrefix(strings.TrimSpace(codec), c.Codecs) {
+				return false
+			}
+		}
+	}
+	if c.MaxHDCPLevel != "" && hdcpRank(v.HDCPLevel) > hdcpRank(c.MaxHDCPLevel) {
+		return false
+	}
+	if c.SDROnly && (v.VideoRange == VideoRangePQ || v.VideoRange == VideoRangeHLG) {
+		return false
+	}
+	return true
+}
+
+// FilterVariants returns EXT-X-STREAM-INF and EXT-X-I-FRAME-STREAM-INF
+// variants of the master playlist satisfying the constraints in
+// order of the playlist, e.g. for device-targeted trimming of master
+// playlists.
+func (p *MasterPlaylist) FilterVariants(c VariantConstraints) []*Variant {
+	var list []*Variant
+	for _, v := range p.Variants {
+		if v != nil && c.Allows(v) {
+			list = append(list, v)
+		}
+	}
+	return list
+}
+
+// SelectVariant returns the EXT-X-STREAM-INF variant of the master
+// playlist satisfying the constraints with the highest BANDWIDTH (the
+// highest AVERAGE-BANDWIDTH and then the first one in the playlist
+// among variants with equal BANDWIDTH). It returns nil if no variant
+// satisfies the constraints.
+func (p *MasterPlaylist) SelectVariant(c VariantConstraints) *Variant {
+	var best *Variant
+	for _, v := range p.FilterVariants(c) {
+		if v.Iframe || v.Images {
+			continue
+		}
+		if best == nil || v.Bandwidth > best.Bandwidth ||
+			v.Bandwidth == best.Bandwidth && v.AverageBandwidth > best.AverageBandwidth {
+			best = v
+		}
+	}
+	return best
+}
+
+// SortVariantsByBandwidth sorts the variants of the master playlist
+// by BANDWIDTH and then by AVERAGE-BANDWIDTH in ascending or, if
+// descending is true, in descending order. Variants with equal
+// bandwidth keep their order. This operation does reset playlist
+// cache.
+func (p *MasterPlaylist) SortVariantsByBandwidth(descending bool) {
+	sort.SliceStable(p.Variants, func(i, j int) bool {
+		a, b := p.Variants[i], p.Variants[j]
+		if a == nil || b == nil {
+			return b == nil && a != nil
+		}
+		if descending {
+			a, b = b, a
+		}
+		return a.Bandwidth < b.Bandwidth ||
+			a.Bandwidth == b.Bandwidth && a.AverageBandwidth < b.AverageBandwidth
+	})
+	p.buf.Reset()
+}
+
+// RemoveVariants removes the variants of the master playlist for
+// which the function returns true and returns the number of removed
+// variants. Renditions (EXT-X-MEDIA) are written for the remaining
+// variants only. This operation does reset playlist cache.
+func (p *MasterPlaylist) RemoveVariants(fn func(v *Variant) bool) int {
+	kept := p.Variants[:0]
+	for _, v := range p.Variants {
+		if v == nil || !fn(v) {
+			kept = append(kept, v)
+		}
+	}
+	removed := len(p.Variants) - len(kept)
+	for i := len(kept); i < len(p.Variants); i++ {
+		p.Variants[i] = nil
+	}
+	p.Variants = kept
+	p.buf.Reset()
+	return removed
+}
+
+// TrimVariants removes the variants of the master playlist not
+// satisfying the constraints (see FilterVariants) and returns the
+// number of removed variants. This operation does reset playlist
+// cache.
+func (p *MasterPlaylist) TrimVariants(c VariantConstraints) int {
+	return p.RemoveVariants(func(v *Variant) bool {
+		return !c.Allows(v)
+	})
+}
+
+// hasCodecPrefix reports whether the codec starts with one of the
+// prefixes.
+func hasCodecPrefix(codec string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(codec, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// hdcpRank orders HDCP-LEVEL values, the absent level is NONE.
+func hdcpRank(level string) int {
+	switch level {
+	case "TYPE-0":
+		return 1
+	case "TYPE-1":
+		return 2
+	}
+	return 0
+}
+
+// RenditionPreferences describes preferences for selection of
+// renditions (EXT-X-MEDIA) of a master playlist.
+type RenditionPreferences struct {
+	// Languages lists preferred BCP-47 language tags in order of
+	// preference.
+	Languages []string
+	// Characteristics lists UTIs of CHARACTERISTICS attribute the
+	// selected rendition must have (e.g. CharacteristicDescribesVideo).
+	Characteristics []string
+}
+
+// RenditionMatch is the rendition selected by SelectRendition with its
+// group context.
+type RenditionMatch struct {
+	Alternative *Alternative
+	Group       []*Alternative // renditions of the same TYPE and GROUP-ID in order of the playlist
+}
+
+// SelectRendition returns the rendition of the type (AUDIO, VIDEO,
+// SUBTITLES or CLOSED-CAPTIONS) with all the required characteristics
+// best matching the preferred languages. Languages are tried in order
+// of preference, an exact match (case-insensitive) of the language
+// tag wins over a match of the primary language subtag (e.g. "en-US"
+// matches "en" and "en-GB"). When no rendition matches the languages
+// the default rendition (DEFAULT=YES) is selected, then the
+// autoselected one and then the first one. It returns false if there
+// are no renditions of the type with the characteristics.
+func (p *MasterPlaylist) SelectRendition(mediaType string, pref RenditionPreferences) (RenditionMatch, bool) {
+	var candidates, all []*Alternative
+	seen := make(map[*Alternative]bool)
+	for _, v := range p.Variants {
+		if v == nil {
+			continue
+		}
+		for _, alt := range v.Alternatives {
+			if alt == nil || seen[alt] || alt.Type != mediaType {
+				continue
+			}
+			seen[alt] = true
+			all = append(all, alt)
+			if hasCharacteristics(alt, pref.Characteristics) {
+				candidates = append(candidates, alt)
+			}
+		}
+	}
+	if len(candidates) == 0 {
+		return RenditionMatch{}, false
+	}
+	selected := selectByLanguage(candidates, pref.Languages)
+	if selected == nil {
+		selected = candidates[0]
+		for _, alt := range candidates {
+			if alt.Default {
+				selected = alt
+				break
+			}
+			if alt.Autoselect == "YES" && selected.Autoselect != "YES" {
+				selected = alt
+			}
+		}
+	}
+	match := RenditionMatch{Alternative: selected}
+	for _, alt := range all {
+		if alt.GroupId == selected.GroupId {
+			match.Group = append(match.Group, alt)
+		}
+	}
+	return match, true
+}
+
+// selectByLanguage returns the rendition best matching the preferred
+// languages or nil.
+func selectByLanguage(alts []*Alternative, languages []string) *Alternative {
+	for _, lang := range languages {
+		var partial *Alternative
+		for _, alt := range alts {
+			switch matchLanguage(lang, alt.Language) {
+			case 2:
+				return alt
+			case 1:
+				if partial == nil {
+					partial = alt
+				}
+			}
+		}
+		if partial != nil {
+			return partial
+		}
+	}
+	return nil
+}
+
+// matchLanguage returns 2 for equal language tags, 1 for tags with
+// equal primary language subtags and 0 otherwise.
+func matchLanguage(a, b string) int {
+	if a == "" || b == "" {
+		return 0
+	}
+	if strings.EqualFold(a, b) {
+		return 2
+	}
+	primary := func(tag string) string {
+		if i := strings.IndexAny(tag, "-_"); i >= 0 {
+			return tag[:i]
+		}
+		return tag
+	}
+	if strings.EqualFold(primary(a), primary(b)) {
+		return 1
+	}
+	return 0
+}
+
+// hasCharacteristics reports whether the rendition has all the UTIs.
+func hasCharacteristics(alt *Alternative, utis []string) bool {
+	for _, uti := range utis {
+		if !alt.HasCharacteristic(uti) {
+			return false
+		}
+	}
+	return true
+}
+
+// renditionGroups returns distinct renditions with the GROUP-ID by
+// their TYPE in order of the playlist.
+func (p *MasterPlaylist) renditionGroups(groupID string) map[string][]*Alternative {
+	groups := make(map[string][]*Alternative)
+	seen := make(map[*Alternative]bool)
+	for _, v := range p.Variants {
+		if v == nil {
+			continue
+		}
+		for _, alt := range v.Alternatives {
+			if alt == nil || seen[alt] || alt.GroupId != groupID {
+				continue
+			}
+			seen[alt] = true
+			groups[alt.Type] = append(groups[alt.Type], alt)
+		}
+	}
+	return groups
+}
+
+// EnsureSingleDefault makes exactly one rendition of the group
+// DEFAULT=YES (and AUTOSELECT=YES as required for the default). The
+// default is the rendition best matching the preferred languages (see
+// SelectRendition), then the current default (the first one if there
+// are several), then the autoselected one and then the first one.
+// Groups with the same GROUP-ID and different TYPE are resolved
+// separately. It returns error if the playlist has no renditions in
+// the group. This operation does reset playlist cache.
+func (p *MasterPlaylist) EnsureSingleDefault(groupID string, languages ...string) error {
+	groups := p.renditionGroups(groupID)
+	if len(groups) == 0 {
+		return fmt.Errorf("rendition group %q not found", groupID)
+	}
+	for _, group := range groups {
+		selected := selectByLanguage(group, languages)
+		if selected == nil {
+			selected = group[0]
+			for _, alt := range group {
+				if alt.Default {
+					selected = alt
+					break
+				}
+				if alt.Autoselect == "YES" && selected.Autoselect != "YES" {
+					selected = alt
+				}
+			}
+		}
+		setDefault(group, selected)
+	}
+	p.buf.Reset()
+	return nil
+}
+
+// SetDefaultRendition makes the rendition with the NAME the only
+// DEFAULT=YES rendition of the group, see EnsureSingleDefault. It
+// returns error if there is no such rendition. This operation does
+// reset playlist cache.
+func (p *MasterPlaylist) SetDefaultRendition(groupID, name string) error {
+	for _, group := range p.renditionGroups(groupID) {
+		for _, alt := range group {
+			if alt.Name == name {
+				setDefault(group, alt)
+				p.buf.Reset()
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("rendition %q not found in group %q", name, groupID)
+}
+
+// setDefault makes the rendition the only default of the group.
+func setDefault(group []*Alternative, def *Alternative) {
+	for _, alt := range group {
+		alt.Default = alt == def
+	}
+	def.Autoselect = "YES"
+}
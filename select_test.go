@@ -0,0 +1,168 @@
+/*
+Variant selection tests.
+
+Copyright 2013-2019 The Project Developers.
+See the AUTHORS and LICENSE files at the top-level directory of this distribution
+and at https://github.com/grafov/m3u8/
+
+ॐ तारे तुत्तारे तुरे स्व
+*/
+package m3u8
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSelectVariant(t *testing.T) {
+	m := NewMasterPlaylist()
+	m.Append("sd.m3u8", nil, VariantParams{Bandwidth: 800000, Codecs: "avc1.4d401e,mp4a.40.2", Resolution: "640x360"})
+	m.Append("hd.m3u8", nil, VariantParams{Bandwidth: 3000000, Codecs: "avc1.640028,mp4a.40.2", Resolution: "1280x720", HDCPLevel: "NONE"})
+	m.Append("fhd.m3u8", nil, VariantParams{Bandwidth: 6000000, Codecs: "avc1.640028,mp4a.40.2", Resolution: "1920x1080", HDCPLevel: "TYPE-0"})
+	m.Append("hdr.m3u8", nil, VariantParams{Bandwidth: 8000000, Codecs: "hvc1.2.4.L123.B0,mp4a.40.2", Resolution: "1920x1080", VideoRange: VideoRangePQ, HDCPLevel: "TYPE-1"})
+	m.Append("iframe.m3u8", nil, VariantParams{Bandwidth: 9000000, Codecs: "avc1.640028", Resolution: "1920x1080", Iframe: true})
+
+	tests := []struct {
+		c   VariantConstraints
+		uri string
+	}{
+		{VariantConstraints{}, "hdr.m3u8"},
+		{VariantConstraints{MaxBandwidth: 5000000}, "hd.m3u8"},
+		{VariantConstraints{MaxHeight: 720}, "hd.m3u8"},
+		{VariantConstraints{MaxWidth: 700}, "sd.m3u8"},
+		{VariantConstraints{Codecs: []string{"avc1", "mp4a"}}, "fhd.m3u8"},
+		{VariantConstraints{MaxHDCPLevel: "NONE"}, "hd.m3u8"},
+		{VariantConstraints{MaxHDCPLevel: "TYPE-0"}, "fhd.m3u8"},
+		{VariantConstraints{SDROnly: true}, "fhd.m3u8"},
+		{VariantConstraints{MaxBandwidth: 100}, ""},
+	}
+	for i, tt := range tests {
+		v := m.SelectVariant(tt.c)
+		switch {
+		case v == nil && tt.uri != "":
+			t.Errorf("case %d: expected %s, got nothing", i, tt.uri)
+		case v != nil && v.URI != tt.uri:
+			t.Errorf("case %d: expected %s, got %s", i, tt.uri, v.URI)
+		}
+	}
+	if list := m.FilterVariants(VariantConstraints{MaxHeight: 1080, SDROnly: true}); len(list) != 4 {
+		t.Errorf("expected 4 variants including I-frame one, got %d", len(list))
+	}
+}
+
+func TestSortAndTrimVariants(t *testing.T) {
+	m := NewMasterPlaylist()
+	alt := &Alternative{GroupId: "aac", Type: "AUDIO", Name: "en", URI: "en.m3u8"}
+	m.Append("hd.m3u8", nil, VariantParams{Bandwidth: 3000000, Codecs: "avc1.640028,mp4a.40.2", Audio: "aac", Alternatives: []*Alternative{alt}})
+	m.Append("sd.m3u8", nil, VariantParams{Bandwidth: 800000, Codecs: "avc1.4d401e,mp4a.40.2"})
+	m.Append("hdr.m3u8", nil, VariantParams{Bandwidth: 8000000, Codecs: "hvc1.2.4.L123.B0,mp4a.40.2"})
+	m.Append("sd2.m3u8", nil, VariantParams{Bandwidth: 800000, AverageBandwidth: 700000, Codecs: "avc1.4d401e,mp4a.40.2"})
+	uris := func() string {
+		var list []string
+		for _, v := range m.Variants {
+			list = append(list, v.URI)
+		}
+		return strings.Join(list, " ")
+	}
+	m.SortVariantsByBandwidth(false)
+	if s := uris(); s != "sd.m3u8 sd2.m3u8 hd.m3u8 hdr.m3u8" {
+		t.Errorf("ascending order: %s", s)
+	}
+	m.SortVariantsByBandwidth(true)
+	if s := uris(); s != "hdr.m3u8 hd.m3u8 sd2.m3u8 sd.m3u8" {
+		t.Errorf("descending order: %s", s)
+	}
+	before := m.String()
+	if n := m.TrimVariants(VariantConstraints{Codecs: []string{"avc1", "mp4a"}}); n != 1 {
+		t.Errorf("expected 1 removed variant, got %d", n)
+	}
+	if n := m.RemoveVariants(func(v *Variant) bool { return v.AverageBandwidth > 0 }); n != 1 {
+		t.Errorf("expected 1 removed variant, got %d", n)
+	}
+	if s := uris(); s != "hd.m3u8 sd.m3u8" {
+		t.Errorf("remaining variants: %s", s)
+	}
+	out := m.String()
+	if out == before || strings.Contains(out, "hdr.m3u8") || !strings.Contains(out, `URI="en.m3u8"`) {
+		t.Errorf("unexpected playlist:\n%s", out)
+	}
+}
+
+func TestSelectRendition(t *testing.T) {
+	en := &Alternative{Type: "AUDIO", GroupId: "aac", Name: "English", Language: "en", Autoselect: "YES"}
+	enAD := &Alternative{Type: "AUDIO", GroupId: "aac", Name: "English AD", Language: "en", Characteristics: CharacteristicDescribesVideo}
+	de := &Alternative{Type: "AUDIO", GroupId: "aac", Name: "Deutsch", Language: "de-DE", Default: true}
+	subs := &Alternative{Type: "SUBTITLES", GroupId: "subs", Name: "English", Language: "en-GB"}
+	m := NewMasterPlaylist()
+	alts := []*Alternative{en, enAD, de, subs}
+	m.Append("low.m3u8", nil, VariantParams{Bandwidth: 1, Audio: "aac", Subtitles: "subs", Alternatives: alts})
+	m.Append("high.m3u8", nil, VariantParams{Bandwidth: 2, Audio: "aac", Subtitles: "subs", Alternatives: alts})
+
+	tests := []struct {
+		mediaType string
+		pref      RenditionPreferences
+		expected  *Alternative
+	}{
+		{"AUDIO", RenditionPreferences{Languages: []string{"EN"}}, en},
+		{"AUDIO", RenditionPreferences{Languages: []string{"fr", "de"}}, de},
+		{"AUDIO", RenditionPreferences{Languages: []string{"fr"}}, de},
+		{"AUDIO", RenditionPreferences{Languages: []string{"en"}, Characteristics: []string{CharacteristicDescribesVideo}}, enAD},
+		{"SUBTITLES", RenditionPreferences{Languages: []string{"en-US"}}, subs},
+	}
+	for i, tt := range tests {
+		match, ok := m.SelectRendition(tt.mediaType, tt.pref)
+		if !ok || match.Alternative != tt.expected {
+			t.Errorf("case %d: expected %s, got %+v", i, tt.expected.Name, match.Alternative)
+		}
+	}
+	match, _ := m.SelectRendition("AUDIO", RenditionPreferences{})
+	if len(match.Group) != 3 || match.Alternative != de {
+		t.Errorf("unexpected match: %+v", match)
+	}
+	if _, ok := m.SelectRendition("SUBTITLES", RenditionPreferences{Characteristics: []string{CharacteristicDescribesVideo}}); ok {
+		t.Error("expected no rendition with the characteristic")
+	}
+}
+
+func TestEnsureSingleDefault(t *testing.T) {
+	en := &Alternative{Type: "AUDIO", GroupId: "aac", Name: "English", Language: "en", Default: true, Autoselect: "YES"}
+	de := &Alternative{Type: "AUDIO", GroupId: "aac", Name: "Deutsch", Language: "de", Default: true}
+	fr := &Alternative{Type: "AUDIO", GroupId: "aac", Name: "Français", Language: "fr"}
+	subs := &Alternative{Type: "SUBTITLES", GroupId: "aac", Name: "English", Language: "en"}
+	m := NewMasterPlaylist()
+	m.Append("low.m3u8", nil, VariantParams{Bandwidth: 800000, Audio: "aac", Alternatives: []*Alternative{en, de, fr, subs}})
+	m.Append("high.m3u8", nil, VariantParams{Bandwidth: 2500000, Audio: "aac", Alternatives: []*Alternative{en, de, fr, subs}})
+
+	if err := m.EnsureSingleDefault("aac"); err != nil {
+		t.Fatal(err)
+	}
+	if !en.Default || de.Default || fr.Default {
+		t.Errorf("expected the first default to stay: en %v, de %v, fr %v", en.Default, de.Default, fr.Default)
+	}
+	if !subs.Default || subs.Autoselect != "YES" {
+		t.Error("expected the only subtitles rendition to become default and autoselected")
+	}
+
+	if err := m.EnsureSingleDefault("aac", "fr-CA", "de"); err != nil {
+		t.Fatal(err)
+	}
+	if en.Default || de.Default || !fr.Default || fr.Autoselect != "YES" {
+		t.Errorf("expected preferred language to become default: en %v, de %v, fr %v", en.Default, de.Default, fr.Default)
+	}
+	if n := strings.Count(m.String(), "DEFAULT=YES"); n != 2 {
+		t.Errorf("expected 2 defaults in output, got %d", n)
+	}
+
+	if err := m.SetDefaultRendition("aac", "Deutsch"); err != nil {
+		t.Fatal(err)
+	}
+	if en.Default || !de.Default || fr.Default {
+		t.Errorf("expected explicit pick to become default: en %v, de %v, fr %v", en.Default, de.Default, fr.Default)
+	}
+	if err := m.SetDefaultRendition("aac", "Italiano"); err == nil {
+		t.Error("expected error for unknown rendition")
+	}
+	if err := m.EnsureSingleDefault("ac3"); err == nil {
+		t.Error("expected error for unknown group")
+	}
+}
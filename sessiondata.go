@@ -0,0 +1,98 @@
+package m3u8
+
+/*
+ Part of M3U8 parser & generator library.
+ This file defines operations with session data of master playlists.
+
+ Copyright 2013-2019 The Project Developers.
+ See the AUTHORS and LICENSE files at the top-level directory of this distribution
+ and at https://github.com/grafov/m3u8/
+
+ ॐ तारे तुत्तारे तुरे स्व
+*/
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrSessionDataValueURI is returned for EXT-X-SESSION-DATA with
+	// both or none of VALUE and URI attributes.
+	ErrSessionDataValueURI = errors.New("either VALUE or URI must be present, but not both")
+	// ErrSessionDataDuplicate is returned for EXT-X-SESSION-DATA with
+	// the same DATA-ID and LANGUAGE as another one of the playlist.
+	ErrSessionDataDuplicate = errors.New("duplicate EXT-X-SESSION-DATA tag with the same DATA-ID and LANGUAGE")
+)
+
+// Validate returns error if the session data has no DATA-ID or has
+// both or none of VALUE and URI attributes. Encoder writes only VALUE
+// of the session data having both of them.
+func (sd *SessionData) Validate() error {
+	if sd.DataID == "" {
+		return errors.New("DATA-ID of EXT-X-SESSION-DATA is empty")
+	}
+	if (sd.Value == "") == (sd.URI == "") {
+		return ErrSessionDataValueURI
+	}
+	return nil
+}
+
+// AppendSessionData validates the session data and appends it to the
+// master playlist. It returns ErrSessionDataDuplicate if the playlist
+// already has session data with the same DATA-ID and LANGUAGE. This
+// operation does reset playlist cache.
+func (p *MasterPlaylist) AppendSessionData(sd *SessionData) error {
+	if err := sd.Validate(); err != nil {
+		return err
+	}
+	if _, ok := p.LookupSessionData(sd.DataID, sd.Language); ok {
+		return ErrSessionDataDuplicate
+	}
+	p.SessionData = append(p.SessionData, sd)
+	p.buf.Reset()
+	return nil
+}
+
+// LookupSessionData returns the session data with the DATA-ID and
+// LANGUAGE. Empty language matches the session data without LANGUAGE
+// attribute only.
+func (p *MasterPlaylist) LookupSessionData(dataID, language string) (*SessionData, bool) {
+	for _, sd := range p.SessionData {
+		if sd != nil && sd.DataID == dataID && sd.Language == language {
+			return sd, true
+		}
+	}
+	return nil, false
+}
+
+// SessionDataByID returns the session data with the DATA-ID in all
+// languages in order of the playlist.
+func (p *MasterPlaylist) SessionDataByID(dataID string) []*SessionData {
+	var list []*SessionData
+	for _, sd := range p.SessionData {
+		if sd != nil && sd.DataID == dataID {
+			list = append(list, sd)
+		}
+	}
+	return list
+}
+
+// CheckSessionData returns error if session data of the playlist is
+// invalid (see SessionData.Validate) or duplicated.
+func (p *MasterPlaylist) CheckSessionData() error {
+	for i, sd := range p.SessionData {
+		if sd == nil {
+			continue
+		}
+		if err := sd.Validate(); err != nil {
+			return fmt.Errorf("session data %q: %s", sd.DataID, err)
+		}
+		for _, prev := range p.SessionData[:i] {
+			if prev != nil && prev.DataID == sd.DataID && prev.Language == sd.Language {
+				return fmt.Errorf("session data %q: %s", sd.DataID, ErrSessionDataDuplicate)
+			}
+		}
+	}
+	return nil
+}
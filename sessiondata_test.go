@@ -0,0 +1,62 @@
+/*
+Session data tests.
+
+Copyright 2013-2019 The Project Developers.
+See the AUTHORS and LICENSE files at the top-level directory of this distribution
+and at https://github.com/grafov/m3u8/
+
+ॐ तारे तुत्तारे तुरे स्व
+*/
+package m3u8
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSessionDataLookup(t *testing.T) {
+	src := `#EXTM3U
+#EXT-X-SESSION-DATA:DATA-ID="com.example.title",VALUE="Title",LANGUAGE="en"
+#EXT-X-SESSION-DATA:DATA-ID="com.example.title",VALUE="Titel",LANGUAGE="de"
+#EXT-X-SESSION-DATA:DATA-ID="com.example.lyrics",URI="lyrics.json"
+#EXT-X-STREAM-INF:BANDWIDTH=100
+low.m3u8
+`
+	pl, _, err := DecodeFrom(strings.NewReader(src), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := pl.(*MasterPlaylist)
+	if sd, ok := m.LookupSessionData("com.example.title", "de"); !ok || sd.Value != "Titel" {
+		t.Errorf("unexpected session data: %+v", sd)
+	}
+	if sd, ok := m.LookupSessionData("com.example.lyrics", ""); !ok || sd.URI != "lyrics.json" {
+		t.Errorf("unexpected session data: %+v", sd)
+	}
+	if _, ok := m.LookupSessionData("com.example.title", "fr"); ok {
+		t.Error("unexpected session data for missing language")
+	}
+	if list := m.SessionDataByID("com.example.title"); len(list) != 2 || list[0].Language != "en" {
+		t.Errorf("unexpected session data list: %v", list)
+	}
+	if err = m.CheckSessionData(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if err = m.AppendSessionData(&SessionData{DataID: "com.example.title", Value: "Title", Language: "en"}); err != ErrSessionDataDuplicate {
+		t.Errorf("expected duplicate error, got %v", err)
+	}
+	if err = m.AppendSessionData(&SessionData{DataID: "com.example.both", Value: "v", URI: "u"}); err != ErrSessionDataValueURI {
+		t.Errorf("expected VALUE/URI error, got %v", err)
+	}
+	if err = m.AppendSessionData(&SessionData{DataID: "com.example.title", Value: "Titre", Language: "fr"}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(m.String(), `DATA-ID="com.example.title",VALUE="Titre",LANGUAGE="fr"`) {
+		t.Errorf("appended session data is not written:\n%s", m.String())
+	}
+	m.SessionData = append(m.SessionData, &SessionData{DataID: "com.example.none"})
+	if err = m.CheckSessionData(); err == nil {
+		t.Error("expected error for session data without VALUE and URI")
+	}
+}
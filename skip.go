@@ -0,0 +1,361 @@
+package m3u8
+
+/*
+ Part of M3U8 parser & generator library.
+ This file defines playlist delta updates (EXT-X-SKIP).
+
+ Copyright 2013-2019 The Project Developers.
+ See the AUTHORS and LICENSE files at the top-level directory of this distribution
+ and at https://github.com/grafov/m3u8/
+
+ ॐ तारे तुत्तारे तुरे स्व
+*/
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Skip represents EXT-X-SKIP tag of playlist delta updates (section
+// 4.4.5.2 of rfc8216bis). The tag replaces SkippedSegments segments
+// following EXT-X-MEDIA-SEQUENCE of the playlist.
+type Skip struct {
+	SkippedSegments           uint64
+	RecentlyRemovedDateRanges []string // IDs of dateranges removed from the playlist, written for delta updates skipping dateranges
+}
+
+// ErrDeltaMismatch returned by MergeDelta when the full playlist
+// doesn't hold the segments skipped by the delta update.
+var ErrDeltaMismatch = errors.New("full playlist doesn't contain segments skipped by the delta update")
+
+// removedDateRange is a daterange removed with the segment. The at is
+// the total duration of removed segments at the removal.
+type removedDateRange struct {
+	id string
+	at float64
+}
+
+// trackRemovedDateRanges remembers IDs of dateranges of the segment
+// removed from the playlist which allows skipping dateranges
+// (CAN-SKIP-DATERANGES). IDs are kept while they were removed less
+// than CAN-SKIP-UNTIL seconds of removed segments ago.
+func (p *MediaPlaylist) trackRemovedDateRanges(seg *MediaSegment) {
+	sc := p.ServerControl
+	if sc == nil || !sc.CanSkipDateRanges || sc.CanSkipUntil <= 0 {
+		p.removedDateRanges = nil
+		return
+	}
+	p.removedDuration += seg.Duration
+	for _, dr := range seg.DateRange {
+		if dr != nil && dr.ID != "" {
+			p.removedDateRanges = append(p.removedDateRanges, removedDateRange{dr.ID, p.removedDuration})
+		}
+	}
+	var i int
+	for i < len(p.removedDateRanges) && p.removedDuration-p.removedDateRanges[i].at >= sc.CanSkipUntil {
+		i++
+	}
+	p.removedDateRanges = p.removedDateRanges[i:]
+}
+
+// recentlyRemovedDateRanges returns IDs of recently removed dateranges
+// which are not present in the playlist anymore.
+func (p *MediaPlaylist) recentlyRemovedDateRanges() []string {
+	present := make(map[string]bool)
+	p.eachSegment(func(seg *MediaSegment) {
+		for _, dr := range seg.DateRange {
+			if dr != nil {
+				present[dr.ID] = true
+			}
+		}
+	})
+	var ids []string
+	for _, r := range p.removedDateRanges {
+		if !present[r.id] {
+			present[r.id] = true
+			ids = append(ids, r.id)
+		}
+	}
+	return ids
+}
+
+// Delta returns the delta update of the playlist (section 6.2.5.1 of
+// rfc8216bis): segments older than CAN-SKIP-UNTIL seconds from the end
+// of the playlist are replaced with EXT-X-SKIP. Dateranges of the
+// skipped segments are moved to the first remaining segment unless
+// skipDateRanges is set (_HLS_skip=v2), then they are omitted and IDs
+// of dateranges recently removed from the playlist are listed in
+// RECENTLY-REMOVED-DATERANGES, so the client may drop them from its
+// retained state. The playlist requires EXT-X-SERVER-CONTROL with
+// CAN-SKIP-UNTIL (and CAN-SKIP-DATERANGES for skipDateRanges).
+// Transforms registered for the playlist are applied to the delta.
+func (p *MediaPlaylist) Delta(skipDateRanges bool) (*MediaPlaylist, error) {
+	sc := p.ServerControl
+	if sc == nil || sc.CanSkipUntil <= 0 {
+		return nil, errors.New("playlist doesn't allow delta updates without CAN-SKIP-UNTIL")
+	}
+	if skipDateRanges && !sc.CanSkipDateRanges {
+		return nil, errors.New("playlist doesn't allow skipping dateranges without CAN-SKIP-DATERANGES")
+	}
+	q := p.transformed()
+	if q == p {
+		q = p.transformCopy()
+	}
+
+	// skip segments ending at least CAN-SKIP-UNTIL before the end
+	segs := q.segments()
+	var total float64
+	for _, seg := range segs {
+		total += seg.Duration
+	}
+	var (
+		skipped uint64
+		end     float64
+		drs     []*DateRange
+	)
+	key, m := q.Key, q.Map
+	for _, seg := range segs {
+		if total-(end+seg.Duration) < sc.CanSkipUntil {
+			break
+		}
+		end += seg.Duration
+		skipped++
+		if seg.Key != nil {
+			key = seg.Key
+		}
+		if seg.Map != nil {
+			m = seg.Map
+		}
+		drs = append(drs, seg.DateRange...)
+	}
+	version(&q.ver, featureVersions[FeatureSkip])
+	skip := &Skip{SkippedSegments: skipped}
+	if skipDateRanges {
+		skip.RecentlyRemovedDateRanges = p.recentlyRemovedDateRanges()
+		if len(skip.RecentlyRemovedDateRanges) > 0 {
+			version(&q.ver, featureVersions[FeatureSkipDateRanges])
+		}
+	}
+	if skipped == 0 {
+		q.Skip = skip
+		return q, nil
+	}
+
+	q.head = (q.head + uint(skipped)) % q.capacity
+	q.count -= uint(skipped)
+	if q.count > 0 {
+		first := q.Segments[q.head]
+		if key != nil && first.Key == nil && (q.Key == nil || !sameKey(key, q.Key)) {
+			first.Key = key
+		}
+		if m != nil && first.Map == nil && m != q.Map {
+			first.Map = m
+		}
+		if !skipDateRanges && len(drs) > 0 {
+			first.DateRange = append(drs, first.DateRange...)
+		}
+	}
+	q.Skip = skip
+	return q, nil
+}
+
+// EncodeDelta generates the delta update of the playlist in M3U8
+// format, see Delta. It neither uses nor resets the playlist cache.
+func (p *MediaPlaylist) EncodeDelta(skipDateRanges bool) (*bytes.Buffer, error) {
+	q, err := p.Delta(skipDateRanges)
+	if err != nil {
+		return nil, err
+	}
+	buf := new(bytes.Buffer)
+	q.encode(buf, 0, 0)
+	return buf, nil
+}
+
+// MergeDelta reconstructs the complete playlist from the full playlist
+// previously received by the client and the delta update with
+// EXT-X-SKIP. Skipped segments are taken from the full playlist with
+// the key, map and discontinuity sequence in effect for them there,
+// the rest of the playlist is taken from the delta. Dateranges listed
+// in RECENTLY-REMOVED-DATERANGES are dropped from the retained
+// segments and dateranges present in the delta replace the retained
+// ones with the same IDs. The delta without EXT-X-SKIP is returned as
+// is. Neither of the playlists is changed.
+func MergeDelta(full, delta *MediaPlaylist) (*MediaPlaylist, error) {
+	if delta.Skip == nil {
+		return delta, nil
+	}
+	skipped := delta.Skip.SkippedSegments
+	fresh := make(map[string]bool)
+	delta.eachSegment(func(seg *MediaSegment) {
+		for _, dr := range seg.DateRange {
+			if dr != nil {
+				fresh[dr.ID] = true
+			}
+		}
+	})
+	for _, id := range delta.Skip.RecentlyRemovedDateRanges {
+		fresh[id] = true
+	}
+
+	// the skipped segments must be consecutive in the full playlist
+	fullSegs := full.segments()
+	from := -1
+	for i, seg := range fullSegs {
+		if seg.SeqId == delta.SeqNo {
+			from = i
+			break
+		}
+	}
+	if from < 0 || uint64(len(fullSegs)-from) < skipped {
+		return nil, ErrDeltaMismatch
+	}
+	for i := uint64(0); i < skipped; i++ {
+		if fullSegs[from+int(i)].SeqId != delta.SeqNo+i {
+			return nil, ErrDeltaMismatch
+		}
+	}
+	retained := &MediaPlaylist{}
+	if skipped > 0 {
+		var err error
+		if retained, err = full.excerpt(fullSegs, from, from+int(skipped), 0); err != nil {
+			return nil, err
+		}
+	}
+
+	out, err := NewMediaPlaylist(0, uint(skipped)+delta.count+1)
+	if err != nil {
+		return nil, err
+	}
+	// the header key and map are the ones of the delta, the first
+	// retained segment gets the key and the map in effect for it in
+	// the full playlist unless they are the same
+	key, m := delta.Key, delta.Map
+	if retained.count > 0 {
+		effKey, effMap := full.Key, full.Map
+		for _, seg := range fullSegs[:from+1] {
+			if seg.Key != nil {
+				effKey = seg.Key
+			}
+			if seg.Map != nil {
+				effMap = seg.Map
+			}
+		}
+		first := retained.Segments[0]
+		if first.Key == nil && effKey != nil && (key == nil || !sameKey(effKey, key)) {
+			first.Key = effKey
+		}
+		if first.Map == nil && effMap != nil && effMap != m {
+			first.Map = effMap
+		}
+	}
+	add := func(s *MediaSegment) {
+		if s.Key != nil {
+			key = s.Key
+		}
+		if s.Map != nil {
+			m = s.Map
+		}
+		out.Segments[out.tail] = s
+		out.tail++
+		out.count++
+	}
+	for i := uint(0); i < retained.count; i++ {
+		s := retained.Segments[i]
+		drs := s.DateRange
+		s.DateRange = nil
+		for _, dr := range drs {
+			if dr != nil && !fresh[dr.ID] {
+				s.DateRange = append(s.DateRange, dr)
+			}
+		}
+		add(s)
+	}
+	first := true
+	delta.eachSegment(func(seg *MediaSegment) {
+		s := *seg
+		if first {
+			// the first segment of the delta is under the key and the
+			// map of the delta header unless it has own ones
+			if s.Key == nil && delta.Key != nil && (key == nil || !sameKey(key, delta.Key)) {
+				s.Key = delta.Key
+			}
+			if s.Map == nil && delta.Map != nil && m != delta.Map {
+				s.Map = delta.Map
+			}
+			first = false
+		}
+		add(&s)
+	})
+	out.TargetDuration = delta.TargetDuration
+	out.SeqNo = delta.SeqNo
+	out.Args = delta.Args
+	out.Iframe = delta.Iframe
+	out.ImagesOnly = delta.ImagesOnly
+	out.Closed = delta.Closed
+	out.MediaType = delta.MediaType
+	out.DiscontinuitySeq = delta.DiscontinuitySeq
+	out.dseqSet = delta.dseqSet
+	if skipped > 0 {
+		out.DiscontinuitySeq = retained.DiscontinuitySeq
+		out.dseqSet = retained.dseqSet || delta.dseqSet
+	}
+	out.StartTime = delta.StartTime
+	out.StartTimePrecise = delta.StartTimePrecise
+	out.startSet = delta.startSet
+	out.AllowCache = delta.AllowCache
+	out.Key = delta.Key
+	out.Map = delta.Map
+	out.WV = delta.WV
+	out.Custom = delta.Custom
+	out.Defines = delta.Defines
+	out.ServerControl = delta.ServerControl
+	out.PartTargetDuration = delta.PartTargetDuration
+	out.partTargetSet = delta.partTargetSet
+	out.PendingPartials = delta.PendingPartials
+	out.PreloadHints = delta.PreloadHints
+	out.RenditionReports = delta.RenditionReports
+	out.UnknownTags = delta.UnknownTags
+	out.TrailingUnknownTags = delta.TrailingUnknownTags
+	out.ver = full.ver
+	out.independentSegments = delta.independentSegments
+	return out, nil
+}
+
+// decodeSkip parses attributes of EXT-X-SKIP tag.
+func decodeSkip(line string) (*Skip, error) {
+	skip := new(Skip)
+	params := decodeParamsLine(line)
+	v, ok := params["SKIPPED-SEGMENTS"]
+	if !ok {
+		return nil, errors.New("EXT-X-SKIP without SKIPPED-SEGMENTS")
+	}
+	n, err := strconv.ParseUint(v, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SKIPPED-SEGMENTS: %s: %v", v, err)
+	}
+	skip.SkippedSegments = n
+	if v := params["RECENTLY-REMOVED-DATERANGES"]; v != "" {
+		skip.RecentlyRemovedDateRanges = strings.Split(v, "\t")
+	}
+	return skip, nil
+}
+
+// writeSkip writes EXT-X-SKIP tag.
+func writeSkip(buf *bytes.Buffer, skip *Skip) {
+	buf.WriteString("#EXT-X-SKIP:")
+	skipAttrs(skip).writeTo(buf, nil)
+	buf.WriteRune('\n')
+}
+
+// skipAttrs returns attributes of EXT-X-SKIP tag.
+func skipAttrs(skip *Skip) attrList {
+	var attrs attrList
+	attrs.add("SKIPPED-SEGMENTS", strconv.FormatUint(skip.SkippedSegments, 10))
+	if len(skip.RecentlyRemovedDateRanges) > 0 {
+		attrs.quoted("RECENTLY-REMOVED-DATERANGES", strings.Join(skip.RecentlyRemovedDateRanges, "\t"))
+	}
+	return attrs
+}
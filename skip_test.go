@@ -0,0 +1,207 @@
+/*
+Playlist delta updates tests.
+
+Copyright 2013-2019 The Project Developers.
+See the AUTHORS and LICENSE files at the top-level directory of this distribution
+and at https://github.com/grafov/m3u8/
+
+ॐ तारे तुत्तारे तुरे स्व
+*/
+package m3u8
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// deltaPlaylist returns a live playlist of 8 segments of 4 seconds
+// allowing to skip segments older than 12 seconds and dateranges.
+// Segments s0 and s1 were removed, s0 had daterange "gone", s3 has
+// daterange "ad" and the key changes on s2.
+func deltaPlaylist(t *testing.T) *MediaPlaylist {
+	p, e := NewMediaPlaylist(8, 8)
+	if e != nil {
+		t.Fatalf("Create media playlist failed: %s", e)
+	}
+	p.ServerControl = &ServerControl{CanSkipUntil: 12, CanSkipDateRanges: true, HoldBack: 12}
+	for i := 0; i < 10; i++ {
+		p.Slide(fmt.Sprintf("s%d.ts", i), 4, "")
+		switch i {
+		case 0:
+			p.SetDateRange([]*DateRange{{ID: "gone", Class: "x"}})
+		case 2:
+			p.SetKey("AES-128", "k1", "", "", "")
+		case 3:
+			p.SetDateRange([]*DateRange{{ID: "ad", Class: "x"}})
+		}
+	}
+	return p
+}
+
+func TestDelta(t *testing.T) {
+	p := deltaPlaylist(t)
+	full := p.String()
+	buf, err := p.EncodeDelta(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	text := buf.String()
+	for _, expected := range []string{
+		"#EXT-X-VERSION:9\n",
+		"#EXT-X-MEDIA-SEQUENCE:2\n",
+		"#EXT-X-SKIP:SKIPPED-SEGMENTS=5\n",
+		"#EXT-X-KEY:METHOD=AES-128,URI=\"k1\"\n#EXT-X-DATERANGE:ID=\"ad\",CLASS=\"x\"",
+	} {
+		if !strings.Contains(text, expected) {
+			t.Errorf("expected %q in:\n%s", expected, text)
+		}
+	}
+	if strings.Contains(text, "s6.ts") || !strings.Contains(text, "s7.ts") {
+		t.Errorf("segments older than CAN-SKIP-UNTIL must be skipped:\n%s", text)
+	}
+	if p.String() != full {
+		t.Error("the playlist must not be changed by delta encoding")
+	}
+
+	buf, err = p.EncodeDelta(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	text = buf.String()
+	if !strings.Contains(text, "#EXT-X-SKIP:SKIPPED-SEGMENTS=5,RECENTLY-REMOVED-DATERANGES=\"gone\"\n") || strings.Contains(text, "\"ad\"") {
+		t.Errorf("unexpected delta skipping dateranges:\n%s", text)
+	}
+
+	p.ServerControl.CanSkipDateRanges = false
+	if _, err = p.Delta(true); err == nil {
+		t.Error("expected error for skipping dateranges without CAN-SKIP-DATERANGES")
+	}
+	p.ServerControl = nil
+	if _, err = p.Delta(false); err == nil {
+		t.Error("expected error for delta without CAN-SKIP-UNTIL")
+	}
+}
+
+func TestRecentlyRemovedDateRangesExpire(t *testing.T) {
+	p := deltaPlaylist(t)
+	// removal of s2, s3 and s4 expires "gone" removed with s0 more
+	// than 12 seconds ago and adds "ad" removed with s3
+	for i := 10; i < 13; i++ {
+		p.Slide(fmt.Sprintf("s%d.ts", i), 4, "")
+	}
+	if ids := p.recentlyRemovedDateRanges(); len(ids) != 1 || ids[0] != "ad" {
+		t.Errorf("expected recently removed daterange ad, got %v", ids)
+	}
+	for i := 13; i < 16; i++ {
+		p.Slide(fmt.Sprintf("s%d.ts", i), 4, "")
+	}
+	if ids := p.recentlyRemovedDateRanges(); len(ids) != 0 {
+		t.Errorf("expected no recently removed dateranges, got %v", ids)
+	}
+}
+
+func TestMergeDelta(t *testing.T) {
+	p := deltaPlaylist(t)
+	client, _, err := DecodeFrom(strings.NewReader(p.String()), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	full := client.(*MediaPlaylist)
+
+	// the server slides two segments removing daterange "ad" and
+	// shrinks the window to s6-s11, so s6-s8 are skipped
+	p.Slide("s10.ts", 4, "")
+	p.Slide("s11.ts", 4, "")
+	p.Remove()
+	p.Remove()
+	buf, err := p.EncodeDelta(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, _, err := DecodeFrom(buf, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	delta := decoded.(*MediaPlaylist)
+	if delta.Skip == nil || delta.Skip.SkippedSegments != 3 || strings.Join(delta.Skip.RecentlyRemovedDateRanges, ",") != "ad" {
+		t.Fatalf("unexpected skip %+v", delta.Skip)
+	}
+	if seg := delta.At(0); seg.SeqId != 9 {
+		t.Errorf("first segment after skip must have sequence 9, got %d", seg.SeqId)
+	}
+
+	merged, err := MergeDelta(full, delta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// s6 is still under key k1 set on s2 in the full playlist, the
+	// server dropped it together with s2
+	if k := merged.At(0).Key; k == nil || k.URI != "k1" {
+		t.Errorf("first retained segment must carry key k1, got %+v", k)
+	}
+	merged.At(0).Key = nil
+	p.Skip = nil
+	if merged.String() != p.String() {
+		t.Errorf("merged playlist differs:\n%s\nexpected:\n%s", merged, p)
+	}
+
+	// retained dateranges listed as removed are dropped
+	full.At(4).DateRange = []*DateRange{{ID: "ad", Class: "x"}, {ID: "kept", Class: "x"}}
+	if merged, err = MergeDelta(full, delta); err != nil {
+		t.Fatal(err)
+	}
+	if drs := merged.At(0).DateRange; len(drs) != 1 || drs[0].ID != "kept" {
+		t.Errorf("unexpected dateranges of the retained segment: %v", drs)
+	}
+	if len(full.At(4).DateRange) != 2 {
+		t.Error("the full playlist must not be changed")
+	}
+
+	stale, _ := NewMediaPlaylist(1, 1)
+	stale.Append("x.ts", 4, "")
+	if _, err = MergeDelta(stale, delta); err != ErrDeltaMismatch {
+		t.Errorf("expected %v, got %v", ErrDeltaMismatch, err)
+	}
+}
+
+func TestMergeDeltaKeyRotation(t *testing.T) {
+	full, _ := NewMediaPlaylist(6, 6)
+	for i := 0; i < 6; i++ {
+		full.Append(fmt.Sprintf("s%d.ts", i), 4, "")
+	}
+	full.At(0).Map = &Map{URI: "init.mp4"}
+	full.At(2).Key = &Key{Method: "AES-128", URI: "k2"}
+	full.At(4).Key = &Key{Method: "AES-128", URI: "k3"}
+	full.At(3).Discontinuity = true
+
+	// the delta skips s3 and s4
+	delta, _ := NewMediaPlaylist(1, 1)
+	delta.SeqNo = 3
+	delta.Skip = &Skip{SkippedSegments: 2}
+	delta.Key = &Key{Method: "AES-128", URI: "k3"}
+	delta.Map = &Map{URI: "init.mp4"}
+	delta.Append("s5.ts", 4, "")
+	delta.Segments[0].SeqId = 5
+
+	merged, err := MergeDelta(full, delta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if k := merged.At(0).Key; k == nil || k.URI != "k2" {
+		t.Errorf("s3 must stay under key k2, got %+v", k)
+	}
+	if k := merged.At(1).Key; k == nil || k.URI != "k3" {
+		t.Errorf("s4 must be under key k3, got %+v", k)
+	}
+	if merged.At(0).Map == nil || merged.At(0).Map.URI != "init.mp4" {
+		t.Errorf("s3 must carry the map in effect, got %+v", merged.At(0).Map)
+	}
+	out := merged.String()
+	if i, j := strings.Index(out, `URI="k2"`), strings.Index(out, "s3.ts"); i < 0 || i > j {
+		t.Errorf("key k2 must be written before s3:\n%s", out)
+	}
+	if full.At(3).Key != nil {
+		t.Error("the full playlist must not be changed")
+	}
+}
@@ -0,0 +1,315 @@
+package m3u8
+
+/*
+ Part of M3U8 parser & generator library.
+ This file defines binary snapshots of playlists.
+
+ Copyright 2013-2019 The Project Developers.
+ See the AUTHORS and LICENSE files at the top-level directory of this distribution
+ and at https://github.com/grafov/m3u8/
+
+ ॐ तारे तुत्तारे तुरे स्व
+*/
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"time"
+)
+
+// snapshotVersion is the version of the snapshot format. Snapshots of
+// other versions are rejected by UnmarshalBinary.
+const snapshotVersion = 1
+
+// ErrSnapshotVersion is returned on unmarshaling of a snapshot made by
+// an incompatible version of the library.
+var ErrSnapshotVersion = errors.New("unsupported snapshot version")
+
+func init() {
+	gob.Register(&PlacedTag{})
+}
+
+// mediaSnapshot is the complete state of the media playlist. Segments
+// are stored in playlist order so the ring buffer is restored compact.
+type mediaSnapshot struct {
+	Version             int
+	TargetDuration      float64
+	SeqNo               uint64
+	Segments            []*MediaSegment
+	Args                string
+	Iframe              bool
+	ImagesOnly          bool
+	Closed              bool
+	MediaType           MediaType
+	DiscontinuitySeq    uint64
+	StartTime           float64
+	StartTimePrecise    bool
+	AllowCache          string
+	Key                 *Key
+	Map                 *Map
+	WV                  *WV
+	Custom              CustomTags
+	Defines             []*Define
+	ServerControl       *ServerControl
+	PartTargetDuration  float64
+	PendingPartials     []*PartialSegment
+	PreloadHints        []*PreloadHint
+	RenditionReports    []*RenditionReport
+	Skip                *Skip
+	UnknownTags         []string
+	TrailingUnknownTags []string
+	DurationAsInt       bool
+	DurationPrec        int
+	DurationPrecSet     bool
+	ManualDSeq          bool
+	DSeqSet             bool
+	StartSet            bool
+	PartTargetSet       bool
+	TargetSet           bool
+	PDTEvery            uint
+	PDTSince            uint
+	PDTLast             time.Time
+	TargetRounding      TargetDurationRounding
+	Winsize             uint
+	Capacity            uint
+	Unbounded           bool
+	Ver                 uint8
+	PinnedVer           uint8
+	OmitVer             bool
+	IndependentSegments bool
+}
+
+// masterSnapshot is the complete state of the master playlist.
+// ProgramIdSet holds the flags set by SetProgramId for each variant.
+type masterSnapshot struct {
+	Version             int
+	Variants            []*Variant
+	ProgramIdSet        []bool
+	SessionData         []*SessionData
+	Args                string
+	CypherVersion       string
+	StartTime           float64
+	StartTimePrecise    bool
+	StartSet            bool
+	Custom              CustomTags
+	Defines             []*Define
+	ContentSteering     *ContentSteering
+	UnknownTags         []string
+	TrailingUnknownTags []string
+	Ver                 uint8
+	PinnedVer           uint8
+	OmitVer             bool
+	IndependentSegments bool
+	AltPlacement        AlternativesPlacement
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler. It saves the
+// complete state of the media playlist (including the sliding window,
+// the capacity and the settings which are lost on encoding to M3U8) so
+// it may be stored between requests and restored with UnmarshalBinary
+// without decoding. Concrete types of custom tags must be registered
+// with gob.Register. Custom decoders, callbacks, segment pool,
+// transforms, source map and metadata are not saved.
+func (p *MediaPlaylist) MarshalBinary() ([]byte, error) {
+	s := mediaSnapshot{
+		Version:             snapshotVersion,
+		TargetDuration:      p.TargetDuration,
+		SeqNo:               p.SeqNo,
+		Segments:            p.segments(),
+		Args:                p.Args,
+		Iframe:              p.Iframe,
+		ImagesOnly:          p.ImagesOnly,
+		Closed:              p.Closed,
+		MediaType:           p.MediaType,
+		DiscontinuitySeq:    p.DiscontinuitySeq,
+		StartTime:           p.StartTime,
+		StartTimePrecise:    p.StartTimePrecise,
+		AllowCache:          p.AllowCache,
+		Key:                 p.Key,
+		Map:                 p.Map,
+		WV:                  p.WV,
+		Custom:              p.Custom,
+		Defines:             p.Defines,
+		ServerControl:       p.ServerControl,
+		PartTargetDuration:  p.PartTargetDuration,
+		PendingPartials:     p.PendingPartials,
+		PreloadHints:        p.PreloadHints,
+		RenditionReports:    p.RenditionReports,
+		Skip:                p.Skip,
+		UnknownTags:         p.UnknownTags,
+		TrailingUnknownTags: p.TrailingUnknownTags,
+		DurationAsInt:       p.durationAsInt,
+		DurationPrec:        p.durationPrec,
+		DurationPrecSet:     p.durationPrecSet,
+		ManualDSeq:          p.manualDSeq,
+		DSeqSet:             p.dseqSet,
+		StartSet:            p.startSet,
+		TargetRounding:      p.targetRounding,
+		PartTargetSet:       p.partTargetSet,
+		TargetSet:           p.targetSet,
+		PDTEvery:            p.pdtEvery,
+		PDTSince:            p.pdtSince,
+		PDTLast:             p.pdtLast,
+		Winsize:             p.winsize,
+		Capacity:            p.capacity,
+		Unbounded:           p.unbounded,
+		Ver:                 p.ver,
+		PinnedVer:           p.pinnedVer,
+		OmitVer:             p.omitVer,
+		IndependentSegments: p.independentSegments,
+	}
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(&s); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler. It restores
+// the state of the media playlist saved with MarshalBinary replacing
+// the current content of the playlist. This operation does reset
+// playlist cache.
+func (p *MediaPlaylist) UnmarshalBinary(data []byte) error {
+	var s mediaSnapshot
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&s); err != nil {
+		return err
+	}
+	if s.Version != snapshotVersion {
+		return ErrSnapshotVersion
+	}
+	capacity := s.Capacity
+	if capacity < uint(len(s.Segments)) {
+		capacity = uint(len(s.Segments))
+	}
+	if capacity == 0 {
+		capacity = 1
+	}
+	p.TargetDuration = s.TargetDuration
+	p.SeqNo = s.SeqNo
+	p.Segments = make([]*MediaSegment, capacity)
+	copy(p.Segments, s.Segments)
+	p.Args = s.Args
+	p.Iframe = s.Iframe
+	p.ImagesOnly = s.ImagesOnly
+	p.Closed = s.Closed
+	p.MediaType = s.MediaType
+	p.DiscontinuitySeq = s.DiscontinuitySeq
+	p.StartTime = s.StartTime
+	p.StartTimePrecise = s.StartTimePrecise
+	p.AllowCache = s.AllowCache
+	p.Key = s.Key
+	p.Map = s.Map
+	p.WV = s.WV
+	p.Custom = s.Custom
+	p.Defines = s.Defines
+	p.ServerControl = s.ServerControl
+	p.PartTargetDuration = s.PartTargetDuration
+	p.PendingPartials = s.PendingPartials
+	p.PreloadHints = s.PreloadHints
+	p.RenditionReports = s.RenditionReports
+	p.Skip = s.Skip
+	p.UnknownTags = s.UnknownTags
+	p.TrailingUnknownTags = s.TrailingUnknownTags
+	p.durationAsInt = s.DurationAsInt
+	p.durationPrec = s.DurationPrec
+	p.durationPrecSet = s.DurationPrecSet
+	p.manualDSeq = s.ManualDSeq
+	p.dseqSet = s.DSeqSet
+	p.startSet = s.StartSet
+	p.targetRounding = s.TargetRounding
+	p.partTargetSet = s.PartTargetSet
+	p.targetSet = s.TargetSet
+	p.pdtEvery = s.PDTEvery
+	p.pdtSince = s.PDTSince
+	p.pdtLast = s.PDTLast
+	p.winsize = s.Winsize
+	p.capacity = capacity
+	p.unbounded = s.Unbounded
+	p.head = 0
+	p.count = uint(len(s.Segments))
+	p.tail = p.count % capacity
+	p.ver = s.Ver
+	p.pinnedVer = s.PinnedVer
+	p.omitVer = s.OmitVer
+	p.independentSegments = s.IndependentSegments
+	p.buf.Reset()
+	return nil
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler. It saves the
+// complete state of the master playlist including chunklists of the
+// variants. Concrete types of custom tags must be registered with
+// gob.Register. Custom decoders, source map and metadata are not
+// saved.
+func (p *MasterPlaylist) MarshalBinary() ([]byte, error) {
+	s := masterSnapshot{
+		Version:             snapshotVersion,
+		Variants:            p.Variants,
+		ProgramIdSet:        make([]bool, len(p.Variants)),
+		SessionData:         p.SessionData,
+		Args:                p.Args,
+		CypherVersion:       p.CypherVersion,
+		StartTime:           p.StartTime,
+		StartTimePrecise:    p.StartTimePrecise,
+		StartSet:            p.startSet,
+		Custom:              p.Custom,
+		Defines:             p.Defines,
+		ContentSteering:     p.ContentSteering,
+		UnknownTags:         p.UnknownTags,
+		TrailingUnknownTags: p.TrailingUnknownTags,
+		Ver:                 p.ver,
+		PinnedVer:           p.pinnedVer,
+		OmitVer:             p.omitVer,
+		IndependentSegments: p.independentSegments,
+		AltPlacement:        p.altPlacement,
+	}
+	for i, v := range p.Variants {
+		if v != nil {
+			s.ProgramIdSet[i] = v.programIdSet
+		}
+	}
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(&s); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler. It restores
+// the state of the master playlist saved with MarshalBinary replacing
+// the current content of the playlist. This operation does reset
+// playlist cache.
+func (p *MasterPlaylist) UnmarshalBinary(data []byte) error {
+	var s masterSnapshot
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&s); err != nil {
+		return err
+	}
+	if s.Version != snapshotVersion {
+		return ErrSnapshotVersion
+	}
+	for i, v := range s.Variants {
+		if i < len(s.ProgramIdSet) {
+			v.programIdSet = s.ProgramIdSet[i]
+		}
+	}
+	p.Variants = s.Variants
+	p.SessionData = s.SessionData
+	p.Args = s.Args
+	p.CypherVersion = s.CypherVersion
+	p.StartTime = s.StartTime
+	p.StartTimePrecise = s.StartTimePrecise
+	p.startSet = s.StartSet
+	p.Custom = s.Custom
+	p.Defines = s.Defines
+	p.ContentSteering = s.ContentSteering
+	p.UnknownTags = s.UnknownTags
+	p.TrailingUnknownTags = s.TrailingUnknownTags
+	p.ver = s.Ver
+	p.pinnedVer = s.PinnedVer
+	p.omitVer = s.OmitVer
+	p.independentSegments = s.IndependentSegments
+	p.altPlacement = s.AltPlacement
+	p.buf.Reset()
+	return nil
+}
@@ -0,0 +1,135 @@
+/*
+Playlist snapshots tests.
+
+Copyright 2013-2019 The Project Developers.
+See the AUTHORS and LICENSE files at the top-level directory of this distribution
+and at https://github.com/grafov/m3u8/
+
+ॐ तारे तुत्तारे तुरे स्व
+*/
+package m3u8
+
+import (
+	"bytes"
+	"encoding/gob"
+	"os"
+	"testing"
+)
+
+func TestMediaPlaylistSnapshot(t *testing.T) {
+	for _, name := range []string{
+		"sample-playlists/media-playlist-with-daterange.m3u8",
+		"sample-playlists/media-playlist-with-scte35.m3u8",
+		"sample-playlists/media-playlist-with-byterange.m3u8",
+		"sample-playlists/media-playlist-with-discontinuity-seq.m3u8",
+	} {
+		f, err := os.Open(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		p, err := NewMediaPlaylist(0, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = p.DecodeFrom(f, true)
+		f.Close()
+		if err != nil {
+			t.Fatalf("%s: %s", name, err)
+		}
+		data, err := p.MarshalBinary()
+		if err != nil {
+			t.Fatalf("%s: %s", name, err)
+		}
+		var q MediaPlaylist
+		if err = q.UnmarshalBinary(data); err != nil {
+			t.Fatalf("%s: %s", name, err)
+		}
+		if q.String() != p.String() {
+			t.Errorf("%s: restored playlist differs:\n%s\nexpected:\n%s", name, q.String(), p.String())
+		}
+	}
+}
+
+func TestMediaPlaylistSnapshotWindow(t *testing.T) {
+	p, e := NewMediaPlaylist(3, 5)
+	if e != nil {
+		t.Fatalf("Create media playlist failed: %s", e)
+	}
+	for i := 0; i < 5; i++ {
+		if e = p.Append("test.ts", 6, ""); e != nil {
+			t.Fatal(e)
+		}
+	}
+	p.Slide("test.ts", 6, "")
+	p.Slide("test.ts", 6, "")
+	p.SetDiscontinuitySequence(0)
+	p.PinVersion(4)
+	data, err := p.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var q MediaPlaylist
+	if err = q.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if q.String() != p.String() {
+		t.Errorf("restored playlist differs:\n%s\nexpected:\n%s", q.String(), p.String())
+	}
+	if q.Count() != p.Count() || q.WinSize() != p.WinSize() || q.capacity != p.capacity {
+		t.Errorf("restored state differs: count %d, winsize %d, capacity %d", q.Count(), q.WinSize(), q.capacity)
+	}
+	// the restored playlist keeps sliding
+	if err = q.Append("next.ts", 6, ""); err == nil {
+		t.Error("full playlist must reject append")
+	}
+	q.Slide("next.ts", 6, "")
+	if q.SeqNo != p.SeqNo+1 {
+		t.Errorf("expected sequence %d, got %d", p.SeqNo+1, q.SeqNo)
+	}
+}
+
+func TestMasterPlaylistSnapshot(t *testing.T) {
+	f, err := os.Open("sample-playlists/master-with-alternatives.m3u8")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := NewMasterPlaylist()
+	err = m.DecodeFrom(f, true)
+	f.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	chunk, _ := NewMediaPlaylist(0, 2)
+	chunk.Append("a.ts", 4, "")
+	chunk.Close()
+	m.Variants[0].Chunklist = chunk
+	m.Variants[0].SetProgramId(0)
+	data, err := m.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var q MasterPlaylist
+	if err = q.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if q.String() != m.String() {
+		t.Errorf("restored playlist differs:\n%s\nexpected:\n%s", q.String(), m.String())
+	}
+	if q.Variants[0].Chunklist == nil || q.Variants[0].Chunklist.String() != chunk.String() {
+		t.Error("chunklist of the variant is not restored")
+	}
+}
+
+func TestSnapshotVersion(t *testing.T) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(&mediaSnapshot{Version: snapshotVersion + 1}); err != nil {
+		t.Fatal(err)
+	}
+	var p MediaPlaylist
+	if err := p.UnmarshalBinary(buf.Bytes()); err != ErrSnapshotVersion {
+		t.Errorf("expected %v, got %v", ErrSnapshotVersion, err)
+	}
+	if err := p.UnmarshalBinary([]byte("#EXTM3U\n")); err == nil {
+		t.Error("text playlist must not be unmarshaled")
+	}
+}
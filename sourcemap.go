@@ -0,0 +1,80 @@
+package m3u8
+
+/*
+ Part of M3U8 parser & generator library.
+ This file defines the source map of decoded playlists.
+
+ Copyright 2013-2019 The Project Developers.
+ See the AUTHORS and LICENSE files at the top-level directory of this distribution
+ and at https://github.com/grafov/m3u8/
+
+ ॐ तारे तुत्तारे तुरे स्व
+*/
+
+import (
+	"strings"
+)
+
+// SourceMap maps items of a decoded playlist to line numbers (counted
+// from 1) in the source playlist. It is filled by decoder when
+// DecodeOptions.SourceMap is set. Items added or replaced after
+// decoding are not tracked.
+type SourceMap struct {
+	Segments map[*MediaSegment]int // line of EXTINF tag of the segment
+	Variants map[*Variant]int      // line of EXT-X-STREAM-INF or EXT-X-I-FRAME-STREAM-INF tag
+	Tags     map[string]int        // line of the first occurrence of a header tag by tag name, e.g. "#EXT-X-TARGETDURATION"
+}
+
+// headerTags lists playlist level tags tracked in the source map.
+var headerTags = []string{
+	"#EXTM3U",
+	"#EXT-X-VERSION",
+	"#EXT-X-INDEPENDENT-SEGMENTS",
+	"#EXT-X-START",
+	"#EXT-X-TARGETDURATION",
+	"#EXT-X-MEDIA-SEQUENCE",
+	"#EXT-X-DISCONTINUITY-SEQUENCE",
+	"#EXT-X-PLAYLIST-TYPE",
+	"#EXT-X-I-FRAMES-ONLY",
+	"#EXT-X-ALLOW-CACHE",
+	"#EXT-X-ENDLIST",
+}
+
+func newSourceMap() *SourceMap {
+	return &SourceMap{
+		Segments: make(map[*MediaSegment]int),
+		Variants: make(map[*Variant]int),
+		Tags:     make(map[string]int),
+	}
+}
+
+// recordTag records the line of the header tag unless the tag was
+// already seen.
+func (m *SourceMap) recordTag(line string, lineNo int) {
+	name := line
+	if i := strings.IndexByte(line, ':'); i >= 0 {
+		name = line[:i]
+	}
+	for _, tag := range headerTags {
+		if name == tag {
+			if _, ok := m.Tags[name]; !ok {
+				m.Tags[name] = lineNo
+			}
+			return
+		}
+	}
+}
+
+// SourceMap returns line numbers of playlist items in the decoded
+// source or nil if the playlist was decoded without
+// DecodeOptions.SourceMap.
+func (p *MasterPlaylist) SourceMap() *SourceMap {
+	return p.sourceMap
+}
+
+// SourceMap returns line numbers of playlist items in the decoded
+// source or nil if the playlist was decoded without
+// DecodeOptions.SourceMap.
+func (p *MediaPlaylist) SourceMap() *SourceMap {
+	return p.sourceMap
+}
@@ -0,0 +1,70 @@
+/*
+Source map tests.
+
+Copyright 2013-2019 The Project Developers.
+See the AUTHORS and LICENSE files at the top-level directory of this distribution
+and at https://github.com/grafov/m3u8/
+
+ॐ तारे तुत्तारे तुरे स्व
+*/
+package m3u8
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDecodeMediaPlaylistSourceMap(t *testing.T) {
+	playlist := `#EXTM3U
+#EXT-X-VERSION:3
+#EXT-X-TARGETDURATION:10
+
+#EXTINF:10.000,
+seg0.ts
+#EXT-X-DISCONTINUITY
+#EXTINF:10.000,
+seg1.ts
+#EXT-X-ENDLIST
+`
+	p, _, err := DecodeWithOptions(strings.NewReader(playlist), DecodeOptions{Strict: true, SourceMap: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pp := p.(*MediaPlaylist)
+	sm := pp.SourceMap()
+	if sm == nil {
+		t.Fatal("Source map is not set")
+	}
+	if line := sm.Segments[pp.Segments[0]]; line != 5 {
+		t.Errorf("First segment at line %d, want 5", line)
+	}
+	if line := sm.Segments[pp.Segments[1]]; line != 8 {
+		t.Errorf("Second segment at line %d, want 8", line)
+	}
+	for tag, want := range map[string]int{"#EXTM3U": 1, "#EXT-X-TARGETDURATION": 3, "#EXT-X-ENDLIST": 10} {
+		if line := sm.Tags[tag]; line != want {
+			t.Errorf("Tag %s at line %d, want %d", tag, line, want)
+		}
+	}
+
+	p, _, _ = DecodeFrom(strings.NewReader(playlist), true)
+	if p.(*MediaPlaylist).SourceMap() != nil {
+		t.Error("Source map must not be recorded by default")
+	}
+}
+
+func TestDecodeMasterPlaylistSourceMap(t *testing.T) {
+	playlist := `#EXTM3U
+#EXT-X-STREAM-INF:BANDWIDTH=1000
+low.m3u8
+#EXT-X-I-FRAME-STREAM-INF:BANDWIDTH=100,URI="iframe.m3u8"
+`
+	p := NewMasterPlaylist()
+	if err := p.DecodeWithOptions(strings.NewReader(playlist), DecodeOptions{Strict: true, SourceMap: true}); err != nil {
+		t.Fatal(err)
+	}
+	sm := p.SourceMap()
+	if sm.Variants[p.Variants[0]] != 2 || sm.Variants[p.Variants[1]] != 4 {
+		t.Errorf("Unexpected variant lines: %d, %d", sm.Variants[p.Variants[0]], sm.Variants[p.Variants[1]])
+	}
+}
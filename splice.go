@@ -0,0 +1,191 @@
+package m3u8
+
+/*
+ Part of M3U8 parser & generator library.
+ This file defines splicing of ad playlists into media playlists.
+
+ Copyright 2013-2019 The Project Developers.
+ See the AUTHORS and LICENSE files at the top-level directory of this distribution
+ and at https://github.com/grafov/m3u8/
+
+ ॐ तारे तुत्तारे तुरे स्व
+*/
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// SpliceOptions controls splicing of the ad playlist with Splice.
+type SpliceOptions struct {
+	// CarryKeys makes the ad segments use the keys of the ad playlist
+	// (its default EXT-X-KEY and keys of its segments) and restores
+	// the key of the content after the ad. Ad segments without key are
+	// marked unencrypted (METHOD=NONE) when the content is encrypted.
+	// Otherwise keys are left as is.
+	CarryKeys bool
+	// CarryMaps makes the ad segments use the media initialization
+	// sections of the ad playlist (its default EXT-X-MAP and maps of
+	// its segments) and restores the map of the content after the ad.
+	// Otherwise maps are left as is.
+	CarryMaps bool
+}
+
+// Splice inserts the segments of the ad playlist before the segment
+// with the sequence ID (after the last segment for the sequence ID
+// following it) for server-side ad insertion. The first ad segment and
+// the segment following the ad get EXT-X-DISCONTINUITY, so
+// EXT-X-DISCONTINUITY-SEQUENCE of the playlist stays valid and grows
+// when the ad is removed from the sliding window. If the content has
+// EXT-X-PROGRAM-DATE-TIME, the first ad segment starts at the time of
+// the splice point and the segment following the ad keeps its time.
+// Ad segments are copied and take sequence IDs starting at the
+// sequence ID, the following segments are renumbered. The target
+// duration grows if required by the ad. This operation does reset
+// playlist cache.
+func (p *MediaPlaylist) Splice(seqID uint64, ad *MediaPlaylist, opts SpliceOptions) error {
+	if ad == nil || ad.count == 0 {
+		return ErrPlaylistEmpty
+	}
+	segs := p.segments()
+	at := -1
+	for i, seg := range segs {
+		if seg.SeqId == seqID {
+			at = i
+			break
+		}
+	}
+	if at < 0 && len(segs) > 0 && seqID == segs[len(segs)-1].SeqId+1 {
+		at = len(segs)
+	}
+	if at < 0 {
+		return fmt.Errorf("no segment with sequence ID %d", seqID)
+	}
+
+	// key, m and pdt are the key, map and program date time effective
+	// at the splice point
+	var pdt time.Time
+	key, m := p.Key, p.Map
+	for i, seg := range segs[:at] {
+		if !seg.ProgramDateTime.IsZero() {
+			pdt = seg.ProgramDateTime
+		} else if !pdt.IsZero() && i > 0 {
+			pdt = pdt.Add(seconds(segs[i-1].Duration))
+		}
+		if seg.Key != nil {
+			key = seg.Key
+		}
+		if seg.Map != nil {
+			m = seg.Map
+		}
+	}
+	if !pdt.IsZero() {
+		pdt = pdt.Add(seconds(segs[at-1].Duration))
+	}
+	if at < len(segs) && !segs[at].ProgramDateTime.IsZero() {
+		pdt = segs[at].ProgramDateTime
+	}
+
+	adSegs := ad.segments()
+	spliced := make([]*MediaSegment, 0, len(segs)+len(adSegs))
+	spliced = append(spliced, segs[:at]...)
+	var rekey bool
+	for i, seg := range adSegs {
+		s := *seg
+		if i == 0 {
+			s.Discontinuity = at > 0
+			if !pdt.IsZero() && s.ProgramDateTime.IsZero() {
+				s.ProgramDateTime = pdt
+			}
+			if opts.CarryKeys && s.Key == nil {
+				switch {
+				case ad.Key != nil:
+					s.Key = ad.Key
+				case key != nil && key.Method != "NONE":
+					s.Key = &Key{Method: "NONE"}
+				}
+			}
+			if opts.CarryMaps && s.Map == nil && ad.Map != nil {
+				s.Map = ad.Map
+			}
+		}
+		p.fitTargetDuration(s.Duration)
+		rekey = rekey || s.Key != nil
+		spliced = append(spliced, &s)
+	}
+	if at < len(segs) {
+		next := segs[at]
+		next.Discontinuity = true
+		if !pdt.IsZero() && next.ProgramDateTime.IsZero() {
+			next.ProgramDateTime = pdt
+		}
+		if opts.CarryKeys && rekey && next.Key == nil {
+			next.Key = key
+			if key == nil {
+				next.Key = &Key{Method: "NONE"}
+			}
+		}
+		if opts.CarryMaps && next.Map == nil && m != nil {
+			next.Map = m
+		}
+	}
+	spliced = append(spliced, segs[at:]...)
+	for i, seg := range spliced[at:] {
+		seg.SeqId = seqID + uint64(i)
+	}
+	p.resetSegments(spliced)
+	return nil
+}
+
+// SpliceAt is the same as Splice but the splice point is the segment
+// playing at the time according to EXT-X-PROGRAM-DATE-TIME, the ad is
+// inserted before the first segment starting at or after the time. It
+// returns error if the playlist has no segments with
+// EXT-X-PROGRAM-DATE-TIME or the time is after the end of the
+// playlist.
+func (p *MediaPlaylist) SpliceAt(t time.Time, ad *MediaPlaylist, opts SpliceOptions) error {
+	var (
+		seqID uint64
+		found bool
+		known bool
+		pdt   time.Time
+		next  uint64
+	)
+	p.eachSegment(func(seg *MediaSegment) {
+		if !seg.ProgramDateTime.IsZero() {
+			pdt, known = seg.ProgramDateTime, true
+		}
+		if known && !found && !pdt.Before(t) {
+			seqID, found = seg.SeqId, true
+		}
+		pdt = pdt.Add(seconds(seg.Duration))
+		next = seg.SeqId + 1
+	})
+	if !known {
+		return errors.New("playlist has no segments with EXT-X-PROGRAM-DATE-TIME")
+	}
+	if !found {
+		if pdt.Before(t) {
+			return fmt.Errorf("time %s is after the end of the playlist", t.Format(DATETIME))
+		}
+		seqID = next
+	}
+	return p.Splice(seqID, ad, opts)
+}
+
+// resetSegments replaces segments of the playlist with the segments
+// in playlist order, the capacity grows if required.
+func (p *MediaPlaylist) resetSegments(segs []*MediaSegment) {
+	capacity := p.capacity
+	if capacity < uint(len(segs)) {
+		capacity = uint(len(segs))
+	}
+	p.Segments = make([]*MediaSegment, capacity)
+	copy(p.Segments, segs)
+	p.capacity = capacity
+	p.head = 0
+	p.count = uint(len(segs))
+	p.tail = p.count % capacity
+	p.buf.Reset()
+}
@@ -0,0 +1,88 @@
+/*
+Ad splicing tests.
+
+Copyright 2013-2019 The Project Developers.
+See the AUTHORS and LICENSE files at the top-level directory of this distribution
+and at https://github.com/grafov/m3u8/
+
+ॐ तारे तुत्तारे तुरे स्व
+*/
+package m3u8
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSplice(t *testing.T) {
+	p, _ := NewMediaPlaylist(0, 3)
+	p.SeqNo = 10
+	p.Key = &Key{Method: "AES-128", URI: "content.key"}
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, uri := range []string{"c0.ts", "c1.ts", "c2.ts"} {
+		p.Append(uri, 6, "")
+		if i == 0 {
+			p.SetProgramDateTime(start)
+		}
+	}
+	ad, _ := NewMediaPlaylist(0, 2)
+	ad.Append("ad0.ts", 5, "")
+	ad.Append("ad1.ts", 10, "")
+
+	if err := p.Splice(11, ad, SpliceOptions{CarryKeys: true}); err != nil {
+		t.Fatal(err)
+	}
+	const expected = `#EXTM3U
+#EXT-X-VERSION:3
+#EXT-X-KEY:METHOD=AES-128,URI="content.key"
+#EXT-X-MEDIA-SEQUENCE:10
+#EXT-X-TARGETDURATION:10
+#EXT-X-PROGRAM-DATE-TIME:2020-01-01T00:00:00Z
+#EXTINF:6.000,
+c0.ts
+#EXT-X-KEY:METHOD=NONE
+#EXT-X-DISCONTINUITY
+#EXT-X-PROGRAM-DATE-TIME:2020-01-01T00:00:06Z
+#EXTINF:5.000,
+ad0.ts
+#EXTINF:10.000,
+ad1.ts
+#EXT-X-KEY:METHOD=AES-128,URI="content.key"
+#EXT-X-DISCONTINUITY
+#EXT-X-PROGRAM-DATE-TIME:2020-01-01T00:00:06Z
+#EXTINF:6.000,
+c1.ts
+#EXTINF:6.000,
+c2.ts
+`
+	if out := p.String(); out != expected {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+	if seg, ok := p.GetSegment(14); !ok || seg.URI != "c2.ts" {
+		t.Errorf("unexpected segment 14: %+v", seg)
+	}
+	if err := p.Splice(100, ad, SpliceOptions{}); err == nil {
+		t.Error("expected error for unknown sequence ID")
+	}
+}
+
+func TestSpliceAt(t *testing.T) {
+	p, _ := NewMediaPlaylist(0, 3)
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	p.Append("c0.ts", 6, "")
+	p.SetProgramDateTime(start)
+	p.Append("c1.ts", 6, "")
+	ad, _ := NewMediaPlaylist(0, 1)
+	ad.Append("ad0.ts", 5, "")
+
+	if err := p.SpliceAt(start.Add(12*time.Second), ad, SpliceOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(p.String(), "#EXT-X-DISCONTINUITY\n#EXT-X-PROGRAM-DATE-TIME:2020-01-01T00:00:12Z\n#EXTINF:5.000,\nad0.ts\n") {
+		t.Errorf("unexpected output:\n%s", p)
+	}
+	if err := p.SpliceAt(start.Add(time.Hour), ad, SpliceOptions{}); err == nil {
+		t.Error("expected error for the time after the end")
+	}
+}
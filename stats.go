@@ -0,0 +1,108 @@
+package m3u8
+
+/*
+ Part of M3U8 parser & generator library.
+ This file defines statistics of media playlists.
+
+ Copyright 2013-2019 The Project Developers.
+ See the AUTHORS and LICENSE files at the top-level directory of this distribution
+ and at https://github.com/grafov/m3u8/
+
+ ॐ तारे तुत्तारे तुरे स्व
+*/
+
+import (
+	"math"
+	"time"
+)
+
+// TotalDuration returns the sum of durations of the segments in
+// seconds.
+func (p *MediaPlaylist) TotalDuration() float64 {
+	var total float64
+	p.eachSegment(func(seg *MediaSegment) {
+		total += seg.Duration
+	})
+	return total
+}
+
+// AverageSegmentDuration returns the average duration of the segments
+// in seconds or zero for the playlist without segments.
+func (p *MediaPlaylist) AverageSegmentDuration() float64 {
+	var total float64
+	var n int
+	p.eachSegment(func(seg *MediaSegment) {
+		total += seg.Duration
+		n++
+	})
+	if n == 0 {
+		return 0
+	}
+	return total / float64(n)
+}
+
+// MaxSegmentDuration returns the longest duration of the segments in
+// seconds.
+func (p *MediaPlaylist) MaxSegmentDuration() float64 {
+	var max float64
+	p.eachSegment(func(seg *MediaSegment) {
+		if seg.Duration > max {
+			max = seg.Duration
+		}
+	})
+	return max
+}
+
+// DiscontinuityRangeCounts returns the number of segments between
+// discontinuities. The element i holds the number of segments with
+// discontinuity sequence number DiscontinuitySeq+i. EXT-X-DISCONTINUITY
+// of the first segment is already counted by DiscontinuitySeq, so it
+// doesn't start a new range. Nil is returned for the playlist without
+// segments.
+func (p *MediaPlaylist) DiscontinuityRangeCounts() []uint {
+	var counts []uint
+	p.eachSegment(func(seg *MediaSegment) {
+		if counts == nil || seg.Discontinuity {
+			counts = append(counts, 0)
+		}
+		counts[len(counts)-1]++
+	})
+	return counts
+}
+
+// EstimatedSize returns the estimated size of the segments in bytes.
+// The size of a segment is taken from ByteSize or its byte range
+// (EXT-X-BYTERANGE) and otherwise computed from its duration and the
+// bitrate (EXT-X-BITRATE) applied to it. Segments of unknown size are
+// not counted.
+func (p *MediaPlaylist) EstimatedSize() int64 {
+	var size, bitrate int64
+	p.eachSegment(func(seg *MediaSegment) {
+		if seg.Bitrate > 0 {
+			bitrate = seg.Bitrate
+		}
+		if n := segmentSize(seg); n > 0 {
+			size += n
+		} else if bitrate > 0 {
+			size += int64(math.Ceil(float64(bitrate) * 1000 / 8 * seg.Duration))
+		}
+	})
+	return size
+}
+
+// ProgramTimeSpan returns the time between the first and the last
+// EXT-X-PROGRAM-DATE-TIME of the segments or zero if less than two
+// segments have it.
+func (p *MediaPlaylist) ProgramTimeSpan() time.Duration {
+	var first, last time.Time
+	p.eachSegment(func(seg *MediaSegment) {
+		if seg.ProgramDateTime.IsZero() {
+			return
+		}
+		if first.IsZero() {
+			first = seg.ProgramDateTime
+		}
+		last = seg.ProgramDateTime
+	})
+	return last.Sub(first)
+}
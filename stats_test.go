@@ -0,0 +1,62 @@
+/*
+Playlist statistics tests.
+
+Copyright 2013-2019 The Project Developers.
+See the AUTHORS and LICENSE files at the top-level directory of this distribution
+and at https://github.com/grafov/m3u8/
+
+ॐ तारे तुत्तारे तुरे स्व
+*/
+package m3u8
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestMediaPlaylistStats(t *testing.T) {
+	p, err := NewMediaPlaylist(0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.AverageSegmentDuration() != 0 || p.DiscontinuityRangeCounts() != nil || p.ProgramTimeSpan() != 0 {
+		t.Error("statistics of empty playlist are not zero")
+	}
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, d := range []float64{4, 6, 2, 4} {
+		if err = p.Append("seg.ts", d, ""); err != nil {
+			t.Fatal(err)
+		}
+		if i == 0 {
+			p.SetDiscontinuity()
+			p.SetProgramDateTime(start)
+		}
+		if i == 2 {
+			p.SetDiscontinuity()
+			p.SetProgramDateTime(start.Add(30 * time.Second))
+		}
+	}
+	p.Segments[0].Bitrate = 800
+	p.Segments[1].Limit = 1000
+	p.Segments[3].ByteSize = 500
+	if d := p.TotalDuration(); d != 16 {
+		t.Errorf("total duration %v, expected 16", d)
+	}
+	if d := p.AverageSegmentDuration(); d != 4 {
+		t.Errorf("average duration %v, expected 4", d)
+	}
+	if d := p.MaxSegmentDuration(); d != 6 {
+		t.Errorf("max duration %v, expected 6", d)
+	}
+	if c := p.DiscontinuityRangeCounts(); !reflect.DeepEqual(c, []uint{2, 2}) {
+		t.Errorf("discontinuity range counts %v, expected [2 2]", c)
+	}
+	// 4s and 2s at 800 kbps, 1000 bytes of the byte range and 500 bytes
+	if n := p.EstimatedSize(); n != 400000+1000+200000+500 {
+		t.Errorf("estimated size %d, expected 601500", n)
+	}
+	if d := p.ProgramTimeSpan(); d != 30*time.Second {
+		t.Errorf("program time span %v, expected 30s", d)
+	}
+}
@@ -0,0 +1,186 @@
+package m3u8
+
+/*
+ Part of M3U8 parser & generator library.
+ This file defines content steering of master playlists and steering
+ manifests.
+
+ Copyright 2013-2019 The Project Developers.
+ See the AUTHORS and LICENSE files at the top-level directory of this distribution
+ and at https://github.com/grafov/m3u8/
+
+ ॐ तारे तुत्तारे तुरे स्व
+*/
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// SteeringManifestVersion is the only version of steering manifests
+// defined.
+const SteeringManifestVersion = 1
+
+// ContentSteering represents EXT-X-CONTENT-STEERING tag of the master
+// playlist (section 4.4.6.6 of rfc8216bis).
+type ContentSteering struct {
+	ServerURI string // SERVER-URI of the steering manifest
+	PathwayId string // PATHWAY-ID chosen until the manifest is loaded, optional
+}
+
+// CheckContentSteering returns error if EXT-X-CONTENT-STEERING of the
+// playlist has no SERVER-URI or its PATHWAY-ID isn't the pathway of
+// any variant. Playlists without the tag are valid.
+func (p *MasterPlaylist) CheckContentSteering() error {
+	cs := p.ContentSteering
+	if cs == nil {
+		return nil
+	}
+	if cs.ServerURI == "" {
+		return errors.New("EXT-X-CONTENT-STEERING without SERVER-URI")
+	}
+	if cs.PathwayId == "" {
+		return nil
+	}
+	for _, id := range p.Pathways() {
+		if id == cs.PathwayId {
+			return nil
+		}
+	}
+	return fmt.Errorf("no variants of pathway %q of EXT-X-CONTENT-STEERING", cs.PathwayId)
+}
+
+// validStableId reports whether the STABLE-VARIANT-ID or
+// STABLE-RENDITION-ID value uses only the allowed characters
+// [a-z], [A-Z], [0-9], '+', '/', '=', '.', '-' and '_'.
+func validStableId(id string) bool {
+	if id == "" {
+		return false
+	}
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case strings.ContainsRune("+/=.-_", r):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// decodeContentSteering parses attributes of EXT-X-CONTENT-STEERING
+// tag.
+func decodeContentSteering(line string, strict bool) (*ContentSteering, error) {
+	cs := new(ContentSteering)
+	for k, v := range decodeParamsLine(line) {
+		switch k {
+		case "SERVER-URI":
+			cs.ServerURI = v
+		case "PATHWAY-ID":
+			cs.PathwayId = v
+		}
+	}
+	if strict && cs.ServerURI == "" {
+		return nil, errors.New("EXT-X-CONTENT-STEERING without SERVER-URI")
+	}
+	return cs, nil
+}
+
+// writeContentSteering writes EXT-X-CONTENT-STEERING tag.
+func writeContentSteering(buf *bytes.Buffer, cs *ContentSteering) {
+	buf.WriteString("#EXT-X-CONTENT-STEERING:")
+	contentSteeringAttrs(cs).writeTo(buf, nil)
+	buf.WriteRune('\n')
+}
+
+// contentSteeringAttrs returns attributes of EXT-X-CONTENT-STEERING tag.
+func contentSteeringAttrs(cs *ContentSteering) attrList {
+	var attrs attrList
+	attrs.quoted("SERVER-URI", cs.ServerURI)
+	if cs.PathwayId != "" {
+		attrs.quoted("PATHWAY-ID", cs.PathwayId)
+	}
+	return attrs
+}
+
+// SteeringManifest is the JSON document served by the steering server
+// of EXT-X-CONTENT-STEERING (section 7.1 of rfc8216bis). It orders the
+// pathways of the master playlist for the client and may define new
+// pathways as clones of the existing ones.
+type SteeringManifest struct {
+	Version         int             `json:"VERSION"`
+	TTL             int             `json:"TTL,omitempty"`        // seconds until the next reload
+	ReloadURI       string          `json:"RELOAD-URI,omitempty"` // URI of the next reload, relative to the manifest
+	PathwayPriority []string        `json:"PATHWAY-PRIORITY"`
+	PathwayClones   []*PathwayClone `json:"PATHWAY-CLONES,omitempty"`
+}
+
+// PathwayClone defines the pathway ID as a copy of the pathway BaseId
+// with URIs replaced (see also MasterPlaylist.DuplicatePathway which
+// writes such copies to the playlist).
+type PathwayClone struct {
+	BaseId         string         `json:"BASE-ID"`
+	ID             string         `json:"ID"`
+	URIReplacement URIReplacement `json:"URI-REPLACEMENT"`
+}
+
+// URIReplacement describes how URIs of the cloned pathway are derived
+// from URIs of the base pathway: the host is replaced with Host and
+// Params are added to the query. URIs of individual variants and
+// renditions may be replaced by their stable IDs.
+type URIReplacement struct {
+	Host             string            `json:"HOST,omitempty"`
+	Params           map[string]string `json:"PARAMS,omitempty"`
+	PerVariantURIs   map[string]string `json:"PER-VARIANT-URIS,omitempty"`
+	PerRenditionURIs map[string]string `json:"PER-RENDITION-URIS,omitempty"`
+}
+
+// NewSteeringManifest returns the manifest of the current version with
+// the pathways in order of priority.
+func NewSteeringManifest(ttl int, pathways ...string) *SteeringManifest {
+	return &SteeringManifest{Version: SteeringManifestVersion, TTL: ttl, PathwayPriority: pathways}
+}
+
+// Validate returns error if the manifest has an unsupported version,
+// empty PATHWAY-PRIORITY or clones without BASE-ID or ID.
+func (m *SteeringManifest) Validate() error {
+	if m.Version != SteeringManifestVersion {
+		return fmt.Errorf("unsupported steering manifest version %d", m.Version)
+	}
+	if len(m.PathwayPriority) == 0 {
+		return errors.New("steering manifest without PATHWAY-PRIORITY")
+	}
+	for _, c := range m.PathwayClones {
+		if c == nil || c.BaseId == "" || c.ID == "" {
+			return errors.New("pathway clone without BASE-ID or ID")
+		}
+	}
+	return nil
+}
+
+// Encode validates the manifest and writes it as JSON.
+func (m *SteeringManifest) Encode(w io.Writer) error {
+	if err := m.Validate(); err != nil {
+		return err
+	}
+	return json.NewEncoder(w).Encode(m)
+}
+
+// DecodeSteeringManifest reads the JSON steering manifest. Unknown
+// keys are ignored. In strict mode the manifest is validated.
+func DecodeSteeringManifest(r io.Reader, strict bool) (*SteeringManifest, error) {
+	m := new(SteeringManifest)
+	if err := json.NewDecoder(r).Decode(m); err != nil {
+		return nil, err
+	}
+	if strict {
+		if err := m.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
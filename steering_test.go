@@ -0,0 +1,109 @@
+/*
+Content steering tests.
+
+Copyright 2013-2019 The Project Developers.
+See the AUTHORS and LICENSE files at the top-level directory of this distribution
+and at https://github.com/grafov/m3u8/
+
+ॐ तारे तुत्तारे तुरे स्व
+*/
+package m3u8
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestContentSteering(t *testing.T) {
+	m := NewMasterPlaylist()
+	m.Append("cdn-a/low.m3u8", nil, VariantParams{Bandwidth: 1000000, PathwayId: "CDN-A"})
+	m.Append("cdn-b/low.m3u8", nil, VariantParams{Bandwidth: 1000000, PathwayId: "CDN-B"})
+	m.ContentSteering = &ContentSteering{ServerURI: "https://steering.example.com/manifest.json", PathwayId: "CDN-A"}
+	if err := m.CheckContentSteering(); err != nil {
+		t.Fatal(err)
+	}
+	out := m.String()
+	if !strings.Contains(out, "#EXT-X-CONTENT-STEERING:SERVER-URI=\"https://steering.example.com/manifest.json\",PATHWAY-ID=\"CDN-A\"\n") {
+		t.Fatalf("unexpected output:\n%s", out)
+	}
+
+	p, listType, err := DecodeFrom(strings.NewReader(out), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if listType != MASTER {
+		t.Fatal("expected master playlist")
+	}
+	if cs := p.(*MasterPlaylist).ContentSteering; cs == nil || *cs != *m.ContentSteering {
+		t.Errorf("unexpected decoded tag: %+v", cs)
+	}
+
+	m.ContentSteering.PathwayId = "CDN-C"
+	if err = m.CheckContentSteering(); err == nil {
+		t.Error("expected error for unknown pathway")
+	}
+	if _, _, err = DecodeFrom(strings.NewReader("#EXTM3U\n#EXT-X-CONTENT-STEERING:PATHWAY-ID=\"CDN-A\"\n"), true); err == nil {
+		t.Error("expected error for tag without SERVER-URI")
+	}
+}
+
+func TestSteeringManifest(t *testing.T) {
+	m := NewSteeringManifest(300, "CDN-A", "CDN-B", "CDN-C")
+	m.ReloadURI = "manifest.json?session=1"
+	m.PathwayClones = []*PathwayClone{{
+		BaseId:         "CDN-A",
+		ID:             "CDN-C",
+		URIReplacement: URIReplacement{Host: "cdn-c.example.com", Params: map[string]string{"token": "abc"}},
+	}}
+	var buf bytes.Buffer
+	if err := m.Encode(&buf); err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"VERSION":1,"TTL":300,"RELOAD-URI":"manifest.json?session=1","PATHWAY-PRIORITY":["CDN-A","CDN-B","CDN-C"],"PATHWAY-CLONES":[{"BASE-ID":"CDN-A","ID":"CDN-C","URI-REPLACEMENT":{"HOST":"cdn-c.example.com","PARAMS":{"token":"abc"}}}]}` + "\n"
+	if buf.String() != expected {
+		t.Fatalf("unexpected manifest:\n%s", buf.String())
+	}
+	d, err := DecodeSteeringManifest(&buf, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.TTL != 300 || len(d.PathwayPriority) != 3 || len(d.PathwayClones) != 1 || d.PathwayClones[0].URIReplacement.Params["token"] != "abc" {
+		t.Errorf("unexpected decoded manifest: %+v", d)
+	}
+	if _, err = DecodeSteeringManifest(strings.NewReader(`{"VERSION":2,"PATHWAY-PRIORITY":["A"]}`), true); err == nil {
+		t.Error("expected error for unsupported version")
+	}
+	if err = NewSteeringManifest(10).Encode(&buf); err == nil {
+		t.Error("expected error for manifest without pathways")
+	}
+}
+
+func TestStableIds(t *testing.T) {
+	m := NewMasterPlaylist()
+	alt := &Alternative{GroupId: "aac", Type: "AUDIO", Name: "English", URI: "audio/en.m3u8", StableRenditionId: "audio-en"}
+	m.Append("video/720p.m3u8", nil, VariantParams{Bandwidth: 3000000, Audio: "aac", StableVariantId: "720p", Alternatives: []*Alternative{alt}})
+	m.Append("video/720p-iframes.m3u8", nil, VariantParams{Bandwidth: 300000, Iframe: true, StableVariantId: "720p/iframes"})
+	out := m.String()
+	for _, line := range []string{
+		`#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID="aac",NAME="English",DEFAULT=NO,URI="audio/en.m3u8",STABLE-RENDITION-ID="audio-en"`,
+		`#EXT-X-STREAM-INF:BANDWIDTH=3000000,AUDIO="aac",STABLE-VARIANT-ID="720p"`,
+		`#EXT-X-I-FRAME-STREAM-INF:BANDWIDTH=300000,STABLE-VARIANT-ID="720p/iframes",URI="video/720p-iframes.m3u8"`,
+	} {
+		if !strings.Contains(out, line+"\n") {
+			t.Errorf("expected %s in output:\n%s", line, out)
+		}
+	}
+
+	p, _, err := DecodeFrom(strings.NewReader(out), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := p.(*MasterPlaylist)
+	if d.Variants[0].StableVariantId != "720p" || d.Variants[0].Alternatives[0].StableRenditionId != "audio-en" || d.Variants[1].StableVariantId != "720p/iframes" {
+		t.Errorf("unexpected decoded IDs: %+v", d.Variants)
+	}
+	if _, _, err = DecodeFrom(strings.NewReader("#EXTM3U\n#EXT-X-STREAM-INF:BANDWIDTH=1,STABLE-VARIANT-ID=\"a b\"\nv.m3u8\n"), true); err == nil {
+		t.Error("expected error for invalid STABLE-VARIANT-ID")
+	}
+}
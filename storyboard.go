@@ -0,0 +1,108 @@
+package m3u8
+
+/*
+ Part of M3U8 parser & generator library.
+ This file defines generation of WebVTT storyboards (thumbnail tracks).
+
+ Copyright 2013-2019 The Project Developers.
+ See the AUTHORS and LICENSE files at the top-level directory of this distribution
+ and at https://github.com/grafov/m3u8/
+
+ ॐ तारे तुत्तारे तुरे स्व
+*/
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Thumbnail is an image of the storyboard shown for the time interval
+// of the presentation. The image may be a region of a sprite.
+type Thumbnail struct {
+	URI      string  // image or sprite URI
+	Start    float64 // presentation time in seconds
+	Duration float64 // in seconds
+	// Region of the sprite, zero Width or Height means the whole image.
+	X, Y, Width, Height int
+}
+
+// StoryboardFile is a WebVTT file of the storyboard.
+type StoryboardFile struct {
+	URI  string
+	Data []byte
+}
+
+// NewStoryboard generates WebVTT files referencing the thumbnails
+// (with the #xywh media fragment for sprite regions) and the closed
+// media playlist of the files, so the storyboard for trick play is
+// delivered as a subtitles-like rendition. Thumbnails are split
+// between files by their start time, each file covers the segment
+// duration of the presentation. The uri function returns the URI of
+// the file by its number.
+func NewStoryboard(thumbs []Thumbnail, segmentDuration float64, uri func(i int) string) (*MediaPlaylist, []StoryboardFile, error) {
+	if len(thumbs) == 0 {
+		return nil, nil, errors.New("no thumbnails")
+	}
+	if segmentDuration <= 0 {
+		return nil, nil, errors.New("segment duration must be positive")
+	}
+	sorted := make([]Thumbnail, len(thumbs))
+	copy(sorted, thumbs)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Start < sorted[j].Start })
+
+	var end float64
+	for _, th := range sorted {
+		if th.Start < 0 || th.Duration <= 0 {
+			return nil, nil, fmt.Errorf("thumbnail %q: invalid timing", th.URI)
+		}
+		if th.Start+th.Duration > end {
+			end = th.Start + th.Duration
+		}
+	}
+	count := int(math.Ceil(end / segmentDuration))
+	p, err := NewMediaPlaylist(0, uint(count))
+	if err != nil {
+		return nil, nil, err
+	}
+	files := make([]StoryboardFile, count)
+	next := 0
+	for i := range files {
+		start := float64(i) * segmentDuration
+		duration := math.Min(segmentDuration, end-start)
+		var buf bytes.Buffer
+		buf.WriteString("WEBVTT\n")
+		for ; next < len(sorted) && (i == count-1 || sorted[next].Start < start+segmentDuration); next++ {
+			writeThumbnailCue(&buf, sorted[next])
+		}
+		files[i] = StoryboardFile{URI: uri(i), Data: buf.Bytes()}
+		if err = p.Append(files[i].URI, duration, ""); err != nil {
+			return nil, nil, err
+		}
+	}
+	p.MediaType = VOD
+	p.Close()
+	return p, files, nil
+}
+
+// writeThumbnailCue writes the WebVTT cue showing the thumbnail.
+func writeThumbnailCue(buf *bytes.Buffer, th Thumbnail) {
+	buf.WriteRune('\n')
+	buf.WriteString(vttTimestamp(th.Start))
+	buf.WriteString(" --> ")
+	buf.WriteString(vttTimestamp(th.Start + th.Duration))
+	buf.WriteRune('\n')
+	buf.WriteString(th.URI)
+	if th.Width > 0 && th.Height > 0 {
+		fmt.Fprintf(buf, "#xywh=%d,%d,%d,%d", th.X, th.Y, th.Width, th.Height)
+	}
+	buf.WriteRune('\n')
+}
+
+// vttTimestamp formats seconds as WebVTT timestamp hh:mm:ss.ttt.
+func vttTimestamp(seconds float64) string {
+	ms := int64(math.Round(seconds * 1000))
+	return fmt.Sprintf("%02d:%02d:%02d.%03d", ms/3600000, ms/60000%60, ms/1000%60, ms%1000)
+}
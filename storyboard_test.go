@@ -0,0 +1,53 @@
+/*
+Storyboard generation tests.
+
+Copyright 2013-2019 The Project Developers.
+See the AUTHORS and LICENSE files at the top-level directory of this distribution
+and at https://github.com/grafov/m3u8/
+
+ॐ तारे तुत्तारे तुरे स्व
+*/
+package m3u8
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestNewStoryboard(t *testing.T) {
+	var thumbs []Thumbnail
+	for i := 0; i < 5; i++ {
+		thumbs = append(thumbs, Thumbnail{URI: "sprite.jpg", Start: float64(i) * 5, Duration: 5, X: i * 160, Width: 160, Height: 90})
+	}
+	p, files, err := NewStoryboard(thumbs, 10, func(i int) string { return fmt.Sprintf("thumbs%d.vtt", i) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 3 || p.Count() != 3 {
+		t.Fatalf("expected 3 files, got %d", len(files))
+	}
+	expected := "WEBVTT\n\n00:00:10.000 --> 00:00:15.000\nsprite.jpg#xywh=320,0,160,90\n\n00:00:15.000 --> 00:00:20.000\nsprite.jpg#xywh=480,0,160,90\n"
+	if files[1].URI != "thumbs1.vtt" || string(files[1].Data) != expected {
+		t.Errorf("unexpected file %s:\n%s", files[1].URI, files[1].Data)
+	}
+	out := p.String()
+	for _, e := range []string{"#EXT-X-PLAYLIST-TYPE:VOD\n", "#EXTINF:10.000,\nthumbs0.vtt\n", "#EXTINF:5.000,\nthumbs2.vtt\n", "#EXT-X-ENDLIST\n"} {
+		if !strings.Contains(out, e) {
+			t.Errorf("expected %q in:\n%s", e, out)
+		}
+	}
+
+	if _, _, err = NewStoryboard(nil, 10, nil); err == nil {
+		t.Error("expected error for empty storyboard")
+	}
+	if _, _, err = NewStoryboard([]Thumbnail{{URI: "a.jpg"}}, 10, nil); err == nil {
+		t.Error("expected error for thumbnail without duration")
+	}
+}
+
+func TestVTTTimestamp(t *testing.T) {
+	if ts := vttTimestamp(3723.4567); ts != "01:02:03.457" {
+		t.Errorf("unexpected timestamp %s", ts)
+	}
+}
@@ -0,0 +1,198 @@
+package m3u8
+
+/*
+ Part of M3U8 parser & generator library.
+ This file defines streaming decoding of playlists.
+
+ Copyright 2013-2019 The Project Developers.
+ See the AUTHORS and LICENSE files at the top-level directory of this distribution
+ and at https://github.com/grafov/m3u8/
+
+ ॐ तारे तुत्तारे तुरे स्व
+*/
+
+import (
+	"bufio"
+	"io"
+)
+
+// DecodeStream parses a media playlist from the io.Reader stream and
+// calls the function for each decoded segment, see
+// MediaPlaylist.DecodeStream. It returns the playlist with header
+// tags only. If `strict` parameter is true then it returns first
+// syntax error.
+func DecodeStream(reader io.Reader, strict bool, fn func(seg *MediaSegment) error) (*MediaPlaylist, error) {
+	p, err := NewMediaPlaylist(0, 2)
+	if err != nil {
+		return nil, err
+	}
+	if err = p.DecodeStream(reader, DecodeOptions{Strict: strict}, fn); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// DecodeStream parses a media playlist from the io.Reader stream line
+// by line and calls the function for each decoded segment instead of
+// keeping the segments in the playlist, so memory consumed by
+// decoding doesn't depend on the size of the playlist. The segment
+// passed to the function is complete, tags preceding its URI are
+// applied to it and SeqId follows EXT-X-MEDIA-SEQUENCE. The playlist
+// gets header tags, pending partial segments, preload hints and
+// rendition reports but no segments. Error returned by the function
+// aborts decoding and is returned by DecodeStream. Options
+// PreserveAttributeOrder and SourceMap are ignored as they keep state
+// of every decoded segment.
+func (p *MediaPlaylist) DecodeStream(reader io.Reader, opts DecodeOptions, fn func(seg *MediaSegment) error) error {
+	if opts.CustomDecoders != nil {
+		p.WithCustomDecoders(opts.CustomDecoders)
+	}
+	strict := opts.Strict
+	state := new(decodingState)
+	state.linkSCTE35 = opts.LinkSCTE35DateRanges
+	state.onWarning = opts.OnWarning
+	state.keepUnknown = opts.PreserveUnknownTags
+	limits := newLimiter(opts.Limits, p.customDecoders)
+	prog := newProgress(opts)
+	wv := new(WV)
+	in := newStreamInput(reader, opts.Limits)
+
+	defer p.dropStreamed(0)
+	for !in.eof {
+		line, err := in.readLine()
+		if err != nil {
+			return err
+		}
+		state.beginLine(line)
+		if err = limits.check(state, line); err != nil {
+			return err
+		}
+		// empty lines are skipped as by Decode
+		if len(line) < 1 || line == "\r" {
+			continue
+		}
+		count := p.count
+		err = decodeLineOfMediaPlaylist(p, wv, state, line, strict)
+		reportDecodeError(line, err)
+		if state.violation(err, strict) {
+			return state.parseError(line, err)
+		}
+		if p.count > count {
+			if err = fn(p.Segments[p.last()]); err != nil {
+				return err
+			}
+			// the last segment is kept for SeqId of the next one
+			p.dropStreamed(1)
+		}
+		if err = prog.check(line); err != nil {
+			return err
+		}
+	}
+	if state.tagWV {
+		p.WV = wv
+	}
+	return state.checkHeader(strict)
+}
+
+// dropStreamed removes segments passed to the callback of
+// DecodeStream from the head of the playlist keeping the last ones.
+// Unlike Remove it doesn't touch sequence numbers of the playlist.
+func (p *MediaPlaylist) dropStreamed(keep uint) {
+	for p.count > keep {
+		p.Segments[p.head] = nil
+		p.head = (p.head + 1) % p.capacity
+		p.count--
+	}
+	if p.count == 0 {
+		p.head, p.tail = 0, 0
+	}
+	p.buf.Reset()
+}
+
+// DecodeStream parses a master playlist from the io.Reader stream
+// line by line and calls the function for each decoded variant
+// (EXT-X-STREAM-INF with its URI or EXT-X-I-FRAME-STREAM-INF) instead
+// of keeping the variants in the playlist. The playlist gets the rest
+// of tags. Error returned by the function aborts decoding and is
+// returned by DecodeStream. Options PreserveAttributeOrder and
+// SourceMap are ignored as they keep state of every decoded variant.
+func (p *MasterPlaylist) DecodeStream(reader io.Reader, opts DecodeOptions, fn func(v *Variant) error) error {
+	if opts.CustomDecoders != nil {
+		p.WithCustomDecoders(opts.CustomDecoders)
+	}
+	strict := opts.Strict
+	state := new(decodingState)
+	state.linkSCTE35 = opts.LinkSCTE35DateRanges
+	state.onWarning = opts.OnWarning
+	state.keepUnknown = opts.PreserveUnknownTags
+	limits := newLimiter(opts.Limits, p.customDecoders)
+	prog := newProgress(opts)
+	in := newStreamInput(reader, opts.Limits)
+
+	defer func() { p.Variants = nil }()
+	for !in.eof {
+		line, err := in.readLine()
+		if err != nil {
+			return err
+		}
+		state.beginLine(line)
+		if err = limits.check(state, line); err != nil {
+			return err
+		}
+		// empty lines are skipped as by Decode
+		if len(line) < 1 || line == "\r" {
+			continue
+		}
+		err = decodeLineOfMasterPlaylist(p, state, line, strict)
+		reportDecodeError(line, err)
+		if state.violation(err, strict) {
+			return state.parseError(line, err)
+		}
+		for len(p.Variants) > 0 {
+			v := p.Variants[0]
+			if v == state.variant && state.tagStreamInf {
+				break // waits for the URI
+			}
+			p.Variants[0] = nil
+			p.Variants = p.Variants[1:]
+			if err = fn(v); err != nil {
+				return err
+			}
+		}
+		if err = prog.check(line); err != nil {
+			return err
+		}
+	}
+	return state.checkHeader(strict)
+}
+
+// streamInput reads lines of the playlist stream accordingly with
+// MaxSize limit.
+type streamInput struct {
+	r     *bufio.Reader
+	max   int64
+	total int64
+	eof   bool
+}
+
+func newStreamInput(reader io.Reader, limits Limits) *streamInput {
+	if limits.MaxSize > 0 {
+		reader = io.LimitReader(reader, limits.MaxSize+1)
+	}
+	return &streamInput{r: bufio.NewReader(reader), max: limits.MaxSize}
+}
+
+// readLine returns the next line of the stream with its line feed.
+func (in *streamInput) readLine() (string, error) {
+	line, err := in.r.ReadString('\n')
+	if err == io.EOF {
+		in.eof = true
+	} else if err != nil {
+		return "", err
+	}
+	in.total += int64(len(line))
+	if in.max > 0 && in.total > in.max {
+		return "", &LimitError{Limit: "MaxSize", Max: in.max}
+	}
+	return line, nil
+}
@@ -0,0 +1,146 @@
+/*
+Streaming decoding tests.
+
+Copyright 2013-2019 The Project Developers.
+See the AUTHORS and LICENSE files at the top-level directory of this distribution
+and at https://github.com/grafov/m3u8/
+
+ॐ तारे तुत्तारे तुरे स्व
+*/
+package m3u8
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDecodeStreamMedia(t *testing.T) {
+	src := new(bytes.Buffer)
+	src.WriteString("#EXTM3U\n#EXT-X-VERSION:3\n#EXT-X-MEDIA-SEQUENCE:100\n#EXT-X-TARGETDURATION:6\n")
+	for i := 0; i < 5000; i++ {
+		if i == 10 {
+			src.WriteString("#EXT-X-DISCONTINUITY\n")
+		}
+		fmt.Fprintf(src, "#EXTINF:5.000,\nseg%d.ts\n", i)
+	}
+	src.WriteString("#EXT-X-ENDLIST\n")
+
+	var (
+		n     int
+		total float64
+		disc  uint64
+	)
+	p, err := DecodeStream(src, true, func(seg *MediaSegment) error {
+		if seg.SeqId != 100+uint64(n) || seg.URI != fmt.Sprintf("seg%d.ts", n) {
+			return fmt.Errorf("unexpected segment %d: %+v", n, seg)
+		}
+		if seg.Discontinuity {
+			disc = seg.SeqId
+		}
+		n++
+		total += seg.Duration
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 5000 || total != 25000 || disc != 110 {
+		t.Errorf("unexpected stats: %d segments, %v seconds, discontinuity at %d", n, total, disc)
+	}
+	if p.Count() != 0 || p.SeqNo != 100 || !p.Closed || p.TargetDuration != 6 {
+		t.Errorf("unexpected playlist: count %d, seqno %d, closed %t, target %v", p.Count(), p.SeqNo, p.Closed, p.TargetDuration)
+	}
+	if len(p.Segments) > 4 {
+		t.Errorf("segments are retained: capacity %d", len(p.Segments))
+	}
+}
+
+func TestDecodeStreamAbort(t *testing.T) {
+	src := "#EXTM3U\n#EXT-X-TARGETDURATION:6\n#EXTINF:5,\na.ts\n#EXTINF:5,\nb.ts\n#EXTINF:5,\nc.ts\n"
+	stop := errors.New("stop")
+	var n int
+	_, err := DecodeStream(strings.NewReader(src), true, func(seg *MediaSegment) error {
+		if n++; n == 2 {
+			return stop
+		}
+		return nil
+	})
+	if err != stop || n != 2 {
+		t.Errorf("expected abort after 2 segments, got %v after %d", err, n)
+	}
+
+	p, _ := NewMediaPlaylist(0, 2)
+	err = p.DecodeStream(strings.NewReader(src), DecodeOptions{Limits: Limits{MaxSize: 20}}, func(seg *MediaSegment) error { return nil })
+	if _, ok := err.(*LimitError); !ok {
+		t.Errorf("expected limit error, got %v", err)
+	}
+}
+
+func TestDecodeStreamMaster(t *testing.T) {
+	const src = `#EXTM3U
+#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID="aac",NAME="English",DEFAULT=YES,URI="en.m3u8"
+#EXT-X-STREAM-INF:BANDWIDTH=300000,AUDIO="aac"
+low.m3u8
+#EXT-X-STREAM-INF:BANDWIDTH=600000,AUDIO="aac"
+high.m3u8
+#EXT-X-I-FRAME-STREAM-INF:BANDWIDTH=80000,URI="iframe.m3u8"
+`
+	p := NewMasterPlaylist()
+	var uris []string
+	err := p.DecodeStream(strings.NewReader(src), DecodeOptions{Strict: true}, func(v *Variant) error {
+		uris = append(uris, v.URI)
+		if v.URI == "low.m3u8" && len(v.Alternatives) != 1 {
+			return fmt.Errorf("variant %s without alternatives", v.URI)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(uris, " ") != "low.m3u8 high.m3u8 iframe.m3u8" {
+		t.Errorf("unexpected variants: %v", uris)
+	}
+	if len(p.Variants) != 0 {
+		t.Errorf("variants are retained: %d", len(p.Variants))
+	}
+}
+
+func TestDecodeStreamSamples(t *testing.T) {
+	files, err := filepath.Glob("sample-playlists/*.m3u8")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range files {
+		data, err := ioutil.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		decoded, listType, err := DecodeFrom(bytes.NewReader(data), false)
+		if err != nil || listType != MEDIA {
+			continue
+		}
+		p := decoded.(*MediaPlaylist)
+		var uris []string
+		if _, err = DecodeStream(bytes.NewReader(data), false, func(seg *MediaSegment) error {
+			uris = append(uris, seg.URI)
+			return nil
+		}); err != nil {
+			t.Errorf("%s: %s", name, err)
+			continue
+		}
+		if len(uris) != int(p.Count()) {
+			t.Errorf("%s: %d streamed segments, %d decoded", name, len(uris), p.Count())
+			continue
+		}
+		for i, uri := range uris {
+			if seg := p.At(uint(i)); seg.URI != uri {
+				t.Errorf("%s: segment %d: streamed %q, decoded %q", name, i, uri, seg.URI)
+			}
+		}
+	}
+}
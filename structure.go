@@ -14,6 +14,7 @@ package m3u8
 import (
 	"bytes"
 	"io"
+	"strings"
 	"time"
 )
 
@@ -57,6 +58,79 @@ const (
 	VOD
 )
 
+// TargetDurationRounding defines how EXT-X-TARGETDURATION is derived
+// from segment durations.
+type TargetDurationRounding uint
+
+const (
+	// TargetDurationCeil rounds the maximum segment duration up
+	// (RFC 8216, the default for backwards compatibility).
+	TargetDurationCeil TargetDurationRounding = iota
+	// TargetDurationRound rounds the maximum segment duration to the
+	// nearest integer (rfc8216bis).
+	TargetDurationRound
+)
+
+// AlternativesPlacement defines placement of EXT-X-MEDIA tags in the
+// encoded master playlist.
+type AlternativesPlacement uint
+
+const (
+	// AlternativesInterleaved writes EXT-X-MEDIA tags right before the
+	// first variant referencing them (default).
+	AlternativesInterleaved AlternativesPlacement = iota
+	// AlternativesFirst writes all EXT-X-MEDIA tags before variants in
+	// order of appearance.
+	AlternativesFirst
+	// AlternativesGrouped writes all EXT-X-MEDIA tags before variants
+	// grouped by TYPE and GROUP-ID.
+	AlternativesGrouped
+)
+
+// VideoRange is the type for the VIDEO-RANGE attribute of
+// EXT-X-STREAM-INF and EXT-X-I-FRAME-STREAM-INF tags.
+type VideoRange string
+
+const (
+	VideoRangeSDR VideoRange = "SDR" // standard dynamic range
+	VideoRangeHLG VideoRange = "HLG" // hybrid log-gamma HDR
+	VideoRangePQ  VideoRange = "PQ"  // perceptual quantizer HDR (HDR10, Dolby Vision)
+)
+
+// Valid reports whether the video range is one of the values defined
+// by the HLS specification.
+func (r VideoRange) Valid() bool {
+	switch r {
+	case VideoRangeSDR, VideoRangeHLG, VideoRangePQ:
+		return true
+	}
+	return false
+}
+
+// HDR reports whether the video range requires HDR capable codecs.
+func (r VideoRange) HDR() bool {
+	return r == VideoRangeHLG || r == VideoRangePQ
+}
+
+// Video channel specifiers of the REQ-VIDEO-LAYOUT attribute. The
+// attribute lists the specifiers separated by "/" (e.g.
+// "CH-STEREO/CH-MONO"), the first one is the default layout.
+const (
+	VideoLayoutStereo = "CH-STEREO" // stereoscopic video, e.g. spatial video
+	VideoLayoutMono   = "CH-MONO"   // monoscopic video
+)
+
+// Uniform Type Identifiers of accessibility characteristics used in
+// the CHARACTERISTICS attribute of EXT-X-MEDIA tag.
+const (
+	CharacteristicDescribesVideo         = "public.accessibility.describes-video"
+	CharacteristicTranscribesDialog      = "public.accessibility.transcribes-spoken-dialog"
+	CharacteristicDescribesMusicAndSound = "public.accessibility.describes-music-and-sound"
+	CharacteristicEasyToRead             = "public.easy-to-read"
+	CharacteristicAuxiliaryContent       = "public.auxiliary-content"
+	CharacteristicMachineGenerated       = "public.machine-generated"
+)
+
 // SCTE35Syntax defines the format of the SCTE-35 cue points which do not use
 // the draft-pantos-http-live-streaming-19 EXT-X-DATERANGE tag and instead
 // have their own custom tags
@@ -64,8 +138,18 @@ type SCTE35Syntax uint
 
 const (
 	// SCTE35_67_2014 will be the default due to backwards compatibility reasons.
-	SCTE35_67_2014 SCTE35Syntax = iota // SCTE35_67_2014 defined in http://www.scte.org/documents/pdf/standards/SCTE%2067%202014.pdf
-	SCTE35_OATCLS                      // SCTE35_OATCLS is a non-standard but common format
+	SCTE35_67_2014   SCTE35Syntax = iota // SCTE35_67_2014 defined in http://www.scte.org/documents/pdf/standards/SCTE%2067%202014.pdf
+	SCTE35_OATCLS                        // SCTE35_OATCLS is a non-standard but common format
+	SCTE35_DATERANGE                     // SCTE35_DATERANGE is a cue linked from SCTE35-OUT/IN/CMD of EXT-X-DATERANGE, it is not written by encoder
+	SCTE35_ELEMENTAL                     // SCTE35_ELEMENTAL is EXT-X-CUE-OUT with DURATION attribute or without value, EXT-X-CUE-OUT-CONT without SCTE35 and EXT-X-CUE-IN
+	SCTE35_ADOBE                         // SCTE35_ADOBE is Adobe style EXT-X-CUE tag with TYPE, ID, DURATION, TIME and CUE attributes
+)
+
+// Values of TYPE attribute of Adobe style EXT-X-CUE tag
+// (SCTE35_ADOBE) for the start and the end cue points.
+const (
+	AdobeCueSpliceOut = "SpliceOut"
+	AdobeCueSpliceIn  = "SpliceIn"
 )
 
 // SCTE35CueType defines the type of cue point, used by readers and writers to
@@ -109,27 +193,69 @@ type MediaPlaylist struct {
 	TargetDuration      float64
 	SeqNo               uint64 // EXT-X-MEDIA-SEQUENCE
 	Segments            []*MediaSegment
-	Args                string // optional arguments placed after URIs (URI?Args)
+	Args                string // optional arguments placed after URIs of all segments (URI?Args), see MediaSegment.Args
 	Iframe              bool   // EXT-X-I-FRAMES-ONLY
+	ImagesOnly          bool   // EXT-X-IMAGES-ONLY, segments are images, see Tiles
 	Closed              bool   // is this VOD (closed) or Live (sliding) playlist?
 	MediaType           MediaType
 	DiscontinuitySeq    uint64 // EXT-X-DISCONTINUITY-SEQUENCE
 	StartTime           float64
 	StartTimePrecise    bool
-	durationAsInt       bool // output durations as integers of floats?
+	AllowCache          string    // EXT-X-ALLOW-CACHE: YES or NO, removed in protocol version 7
+	durationAsInt       bool      // output durations as integers of floats?
+	durationPrec        int       // decimals of durations, see SetDurationPrecision
+	durationPrecSet     bool      // durations are formatted with durationPrec
+	manualDSeq          bool      // don't increment DiscontinuitySeq on removal of discontinuity segments
+	dseqSet             bool      // write EXT-X-DISCONTINUITY-SEQUENCE even if zero, see SetDiscontinuitySequence
+	startSet            bool      // write EXT-X-START even if zero, see SetStartTime
+	targetSet           bool      // TargetDuration is fixed, see SetTargetDuration
+	pdtEvery            uint      // program date time is propagated to every pdtEvery segment, see AutoProgramDateTime
+	pdtSince            uint      // segments appended since the last one with program date time
+	pdtLast             time.Time // program date time of the last appended segment
+	partTargetSet       bool      // PartTargetDuration is fixed, see SetPartTargetDuration
+	targetRounding      TargetDurationRounding
 	winsize             uint // max number of segments displayed in an encoded playlist; need set to zero for VOD playlists
 	capacity            uint // total capacity of slice used for the playlist
+	unbounded           bool // capacity grows on demand, see NewMediaPlaylist
 	head                uint // head of FIFO, we add segments to head
 	tail                uint // tail of FIFO, we remove segments from tail
 	count               uint // number of segments added to the playlist
 	buf                 bytes.Buffer
 	ver                 uint8
+	pinnedVer           uint8 // version written regardless of features, see PinVersion
+	omitVer             bool  // don't write EXT-X-VERSION
 	independentSegments bool
 	Key                 *Key // EXT-X-KEY is optional encryption key displayed before any segments (default key for the playlist)
 	Map                 *Map // EXT-X-MAP is optional tag specifies how to obtain the Media Initialization Section (default map for the playlist)
 	WV                  *WV  // Widevine related tags outside of M3U8 specs
-	Custom              map[string]CustomTag
+	Custom              CustomTags
+	Defines             []*Define // EXT-X-DEFINE
+	ServerControl       *ServerControl
+	PartTargetDuration  float64            // EXT-X-PART-INF:PART-TARGET
+	PendingPartials     []*PartialSegment  // EXT-X-PART of the segment in progress, written after the last segment
+	PreloadHints        []*PreloadHint     // EXT-X-PRELOAD-HINT, written at the end of the playlist
+	RenditionReports    []*RenditionReport // EXT-X-RENDITION-REPORT, written at the end of the playlist
+	Skip                *Skip
+	UnknownTags         []string // unsupported tags of the header kept verbatim, see DecodeOptions
+	TrailingUnknownTags []string // unsupported tags following the last segment kept verbatim
+	Metadata            Metadata `json:"-"` // annotations of the application, never written
 	customDecoders      []CustomDecoder
+	pool                *SegmentPool // optional pool of segments, see SetSegmentPool
+	onFull              func(p *MediaPlaylist, seg *MediaSegment) error
+	onEvict             func(seg *MediaSegment)
+	retention           RetentionPolicy
+	beforeSegment       SegmentEncodeHook
+	afterSegment        SegmentEncodeHook
+	removedDateRanges   []removedDateRange // recently removed dateranges, see Delta
+	removedDuration     float64            // total duration of removed segments
+	transforms          []Transform
+	attrOrder           attrOrders // source order of tag attributes, see DecodeOptions
+	sourceMap           *SourceMap // line numbers of decoded items, see DecodeOptions
+	durationText        map[*MediaSegment]string
+	decodedTarget       float64 // EXT-X-TARGETDURATION of the source, see Validate
+	source              *fidelitySource
+	enc                 *segmentEncoder // state of the cached output for incremental encoding, see Encode
+	incremental         bool            // encode appended segments incrementally, see SetIncrementalEncoding
 }
 
 // MasterPlaylist structure represents a master playlist which
@@ -148,21 +274,37 @@ type MediaPlaylist struct {
 type MasterPlaylist struct {
 	Variants            []*Variant
 	SessionData         []*SessionData
-	Args                string // optional arguments placed after URI (URI?Args)
+	Args                string // optional arguments placed after URIs of all variants (URI?Args), see Variant.Args
 	CypherVersion       string // non-standard tag for Widevine (see also WV struct)
+	StartTime           float64
+	StartTimePrecise    bool
 	buf                 bytes.Buffer
 	ver                 uint8
+	pinnedVer           uint8 // version written regardless of features, see PinVersion
+	omitVer             bool  // don't write EXT-X-VERSION
+	startSet            bool  // write EXT-X-START even if zero, see SetStartTime
 	independentSegments bool
-	Custom              map[string]CustomTag
+	altPlacement        AlternativesPlacement
+	Custom              CustomTags
+	Defines             []*Define        // EXT-X-DEFINE
+	ContentSteering     *ContentSteering // EXT-X-CONTENT-STEERING
+	UnknownTags         []string         // unsupported tags of the header kept verbatim, see DecodeOptions
+	TrailingUnknownTags []string         // unsupported tags following the last variant kept verbatim
 	customDecoders      []CustomDecoder
+	attrOrder           attrOrders // source order of tag attributes, see DecodeOptions
+	sourceMap           *SourceMap // line numbers of decoded items, see DecodeOptions
+	source              *fidelitySource
 }
 
 // Variant structure represents variants for master playlist.
 // Variants included in a master playlist and point to media
 // playlists.
 type Variant struct {
-	URI       string
-	Chunklist *MediaPlaylist
+	URI         string
+	Args        string // optional arguments of the variant placed after URI before Args of the playlist
+	Chunklist   *MediaPlaylist
+	Metadata    Metadata `json:"-"` // annotations of the application, never written
+	UnknownTags []string // unsupported tags preceding the variant kept verbatim, see DecodeOptions
 	VariantParams
 }
 
@@ -181,33 +323,84 @@ type VariantParams struct {
 	Bandwidth        uint32
 	AverageBandwidth uint32 // EXT-X-STREAM-INF only
 	Codecs           string
-	Resolution       string
-	Audio            string // EXT-X-STREAM-INF only
+	Supplemental     string  // SUPPLEMENTAL-CODECS, e.g. Dolby Vision enhancement of the base codec
+	Score            float64 // SCORE, relative preference of the variant, not written if zero
+	Resolution       string  // RESOLUTION as written and decoded, see Width, Height and Dimensions
+	Width            int     // width of RESOLUTION, written instead of Resolution if set with Height, not set by decoding
+	Height           int     // height of RESOLUTION
+	Audio            string  // EXT-X-STREAM-INF only
 	Video            string
 	Subtitles        string // EXT-X-STREAM-INF only
 	Captions         string // EXT-X-STREAM-INF only
 	Name             string // EXT-X-STREAM-INF only (non standard Wowza/JWPlayer extension to name the variant/quality in UA)
 	Iframe           bool   // EXT-X-I-FRAME-STREAM-INF
-	VideoRange       string
+	Images           bool   // EXT-X-IMAGE-STREAM-INF of image media playlists
+	VideoRange       VideoRange
 	HDCPLevel        string
+	ReqVideoLayout   string         // REQ-VIDEO-LAYOUT, e.g. VideoLayoutStereo, requires protocol version 12
+	AllowedCPC       string         // ALLOWED-CPC, content protection configurations by KEYFORMAT
+	PathwayId        string         // PATHWAY-ID of content steering, see DefaultPathwayId
+	StableVariantId  string         // STABLE-VARIANT-ID, the same for the variant on every pathway
 	FrameRate        float64        // EXT-X-STREAM-INF
 	Alternatives     []*Alternative // EXT-X-MEDIA
+	programIdSet     bool           // PROGRAM-ID written even if zero, see SetProgramId
+}
+
+// SetProgramId sets PROGRAM-ID of the variant. Zero PROGRAM-ID is
+// written only when explicitly set or decoded from a playlist.
+func (vp *VariantParams) SetProgramId(id uint32) {
+	vp.ProgramId = id
+	vp.programIdSet = true
 }
 
 // Alternative structure represents EXT-X-MEDIA tag in variants.
 type Alternative struct {
-	GroupId         string
-	URI             string
-	Type            string
-	Language        string
-	Name            string
-	Default         bool
-	Autoselect      string
-	Forced          string
-	Characteristics string
-	Subtitles       string
-	InstreamId      string
-	Channels        string
+	GroupId           string
+	URI               string
+	Type              string
+	Language          string
+	AssocLanguage     string
+	Name              string
+	Default           bool
+	Autoselect        string
+	Forced            string
+	Characteristics   string
+	Subtitles         string
+	InstreamId        string
+	Channels          string
+	BitDepth          int    // BIT-DEPTH of audio samples, not written if zero
+	SampleRate        int    // SAMPLE-RATE of audio in Hz, not written if zero
+	StableRenditionId string // STABLE-RENDITION-ID, the same for the rendition on every pathway
+}
+
+// CharacteristicsList returns UTIs listed in the CHARACTERISTICS
+// attribute.
+func (a *Alternative) CharacteristicsList() []string {
+	if a.Characteristics == "" {
+		return nil
+	}
+	list := strings.Split(a.Characteristics, ",")
+	for i := range list {
+		list[i] = strings.TrimSpace(list[i])
+	}
+	return list
+}
+
+// SetCharacteristics sets the CHARACTERISTICS attribute from the list
+// of UTIs.
+func (a *Alternative) SetCharacteristics(utis ...string) {
+	a.Characteristics = strings.Join(utis, ",")
+}
+
+// HasCharacteristic reports whether the rendition declares the UTI in
+// the CHARACTERISTICS attribute.
+func (a *Alternative) HasCharacteristic(uti string) bool {
+	for _, c := range a.CharacteristicsList() {
+		if c == uti {
+			return true
+		}
+	}
+	return false
 }
 
 // MediaSegment structure represents a media segment included in a
@@ -217,6 +410,7 @@ type MediaSegment struct {
 	SeqId           uint64
 	Title           string // optional second parameter for EXTINF tag
 	URI             string
+	Args            string       // optional arguments of the segment placed after URI before Args of the playlist
 	Duration        float64      // first parameter for EXTINF tag; duration must be integers if protocol version is less than 3 but we are always keep them float
 	Limit           int64        // EXT-X-BYTERANGE <n> is length in bytes for the file under URI
 	Offset          int64        // EXT-X-BYTERANGE [@o] is offset from the start of the file under URI
@@ -227,7 +421,15 @@ type MediaSegment struct {
 	DateRange       []*DateRange // EXT-X-DATERANGE tags
 	SCTE            *SCTE        // SCTE-35 used for Ad signaling in HLS
 	ProgramDateTime time.Time    // EXT-X-PROGRAM-DATE-TIME tag associates the first sample of a media segment with an absolute date and/or time
-	Custom          map[string]CustomTag
+	Bitrate         int64        // EXT-X-BITRATE in kbps applies to the segment and the following ones until changed
+	ByteSize        int64        // size of the segment in bytes, it is not written to the playlist, see FillBitrates
+	Custom          CustomTags
+	Partials        []*PartialSegment // EXT-X-PART tags of the segment written before EXTINF
+	Tiles           *Tiles            // EXT-X-TILES of the image segment
+	UnknownTags     []string          // unsupported tags preceding the segment URI kept verbatim, see DecodeOptions
+	Metadata        Metadata          `json:"-"` // annotations of the application, never written
+
+	offsetOmitted bool // EXT-X-BYTERANGE was decoded without offset
 }
 
 // SCTE holds custom, non EXT-X-DATERANGE, SCTE-35 tags
@@ -238,27 +440,39 @@ type SCTE struct {
 	ID      string
 	Time    float64
 	Elapsed float64
+	Start   float64 // TIME of SCTE35_ADOBE cues, Time holds their DURATION
 }
 
 // DateRange holds the EXT-X-DATERANGE attributes specified in 4.3.2.7 https://datatracker.ietf.org/doc/html/draft-pantos-http-live-streaming
 type DateRange struct {
-	ID              string
-	Class           string
-	StartDate       time.Time
-	EndDate         time.Time
-	Duration        float64
-	PlannedDuration float64
-	X               map[string]string // X-" prefixed client-defined attributes
-	SCTE35Cmd       string
-	SCTE35In        string
-	SCTE35Out       string
-	EndOnNext       string
-	XAssetURI       string
-	XAssetList      string
-	XResumeOfsset   float64
-	XPlayoutLimit   float64
-	XSnap           string
-	XRestrict       string
+	ID                string
+	Class             string
+	StartDate         time.Time
+	Cue               string // CUE, enumerated-string-list of PRE, POST and ONCE
+	EndDate           time.Time
+	Duration          float64
+	PlannedDuration   float64
+	X                 AttributeList // X-" prefixed client-defined attributes in order of the tag
+	SCTE35Cmd         string
+	SCTE35In          string
+	SCTE35Out         string
+	EndOnNext         string
+	XAssetURI         string
+	XAssetList        string
+	XResumeOfsset     float64
+	XPlayoutLimit     float64
+	XSnap             string
+	XRestrict         string
+	XTimelineOccupies string // POINT (default) or RANGE
+	XTimelineStyle    string // HIGHLIGHT (default) or PRIMARY
+	XContentMayVary   string // YES (default) or NO
+}
+
+// DateRangeRef links EXT-X-DATERANGE to the media segment it
+// precedes in the playlist.
+type DateRangeRef struct {
+	DateRange *DateRange
+	Segment   *MediaSegment
 }
 
 // Key structure represents information about stream encryption.
@@ -356,16 +570,37 @@ type decodingState struct {
 	tagKey             bool
 	tagMap             bool
 	tagCustom          bool
+	onWarning          func(Warning)
+	keepUnknown        bool
+	fidelity           bool
+	tag                string // of the current line, for warnings
+	warned             int    // line of the last warning
+	lastWarning        string
+	tiles              *Tiles
 	programDateTime    time.Time
+	partDateTime       time.Time
 	limit              int64
 	offset             int64
+	offsetOmitted      bool
 	duration           float64
+	durationText       string // source text of EXTINF duration, see DecodeOptions.Fidelity
+	bitrate            int64
 	title              string
 	variant            *Variant
 	alternatives       []*Alternative
 	xkey               *Key
 	xmap               *Map
 	scte               *SCTE
-	custom             map[string]CustomTag
+	custom             CustomTags
 	daterange          []*DateRange
+	attrOrder          bool // record source order of tag attributes
+	sourceMap          *SourceMap
+	linkSCTE35         bool // link SCTE-35 dateranges to segment SCTE
+	lineNo             int
+	segmentLine        int // line of EXTINF of the current segment
 }
+
+// attrOrders keeps the source order of attribute names of decoded
+// tags. Keys are pointers to Variant, Alternative, SessionData, Key,
+// Map and DateRange values.
+type attrOrders map[interface{}][]string
@@ -0,0 +1,118 @@
+package m3u8
+
+/*
+ Part of M3U8 parser & generator library.
+ This file defines the media playlist safe for concurrent use.
+
+ Copyright 2013-2019 The Project Developers.
+ See the AUTHORS and LICENSE files at the top-level directory of this distribution
+ and at https://github.com/grafov/m3u8/
+
+ ॐ तारे तुत्तारे तुरे स्व
+*/
+
+import (
+	"bytes"
+	"io"
+	"sync"
+)
+
+// SyncMediaPlaylist wraps the media playlist making it safe for
+// concurrent use, e.g. a live origin appends segments from the ingest
+// goroutine while HTTP handlers encode the playlist. All the methods
+// are serialized with the internal lock as even encoding updates the
+// cache of the playlist. The wrapped playlist must not be used
+// directly while it is shared, use Do for operations not covered by
+// the wrapper.
+type SyncMediaPlaylist struct {
+	mu sync.Mutex
+	p  *MediaPlaylist
+}
+
+// NewSyncMediaPlaylist wraps the playlist for concurrent use.
+func NewSyncMediaPlaylist(p *MediaPlaylist) *SyncMediaPlaylist {
+	return &SyncMediaPlaylist{p: p}
+}
+
+// Do calls the function with the wrapped playlist holding the lock.
+// The function must not keep the playlist or its segments after it
+// returns.
+func (s *SyncMediaPlaylist) Do(fn func(p *MediaPlaylist) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return fn(s.p)
+}
+
+// Append appends the segment, see MediaPlaylist.Append.
+func (s *SyncMediaPlaylist) Append(uri string, duration float64, title string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.p.Append(uri, duration, title)
+}
+
+// AppendSegment appends the segment, see MediaPlaylist.AppendSegment.
+func (s *SyncMediaPlaylist) AppendSegment(seg *MediaSegment) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.p.AppendSegment(seg)
+}
+
+// Slide slides the playlist, see MediaPlaylist.Slide.
+func (s *SyncMediaPlaylist) Slide(uri string, duration float64, title string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.p.Slide(uri, duration, title)
+}
+
+// SlideSegment slides the playlist, see MediaPlaylist.SlideSegment.
+func (s *SyncMediaPlaylist) SlideSegment(seg *MediaSegment) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.p.SlideSegment(seg)
+}
+
+// Remove removes the oldest segment, see MediaPlaylist.Remove.
+func (s *SyncMediaPlaylist) Remove() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.p.Remove()
+}
+
+// Close closes the playlist, see MediaPlaylist.Close.
+func (s *SyncMediaPlaylist) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.p.Close()
+}
+
+// Count returns the number of segments in the playlist.
+func (s *SyncMediaPlaylist) Count() uint {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.p.Count()
+}
+
+// Encode returns the playlist in M3U8 format. Unlike
+// MediaPlaylist.Encode the buffer is a copy owned by the caller.
+func (s *SyncMediaPlaylist) Encode() *bytes.Buffer {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return bytes.NewBuffer(append([]byte(nil), s.p.Encode().Bytes()...))
+}
+
+// WriteTo writes the playlist in M3U8 format to the writer holding
+// the lock, so the encoded playlist isn't copied. It implements
+// io.WriterTo.
+func (s *SyncMediaPlaylist) WriteTo(w io.Writer) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n, err := w.Write(s.p.Encode().Bytes())
+	return int64(n), err
+}
+
+// String returns the playlist in M3U8 format.
+func (s *SyncMediaPlaylist) String() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.p.String()
+}
@@ -0,0 +1,64 @@
+/*
+Concurrent playlist tests.
+
+Copyright 2013-2019 The Project Developers.
+See the AUTHORS and LICENSE files at the top-level directory of this distribution
+and at https://github.com/grafov/m3u8/
+
+ॐ तारे तुत्तारे तुरे स्व
+*/
+package m3u8
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// Run with -race to check the locking.
+func TestSyncMediaPlaylist(t *testing.T) {
+	p, _ := NewMediaPlaylist(3, 5)
+	s := NewSyncMediaPlaylist(p)
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			s.Slide(fmt.Sprintf("t%03d.ts", i), 6, "")
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			out := s.Encode().String()
+			if !strings.HasPrefix(out, "#EXTM3U\n") {
+				t.Errorf("Unexpected playlist:\n%s", out)
+				return
+			}
+			var buf bytes.Buffer
+			if _, err := s.WriteTo(&buf); err != nil {
+				t.Error(err)
+				return
+			}
+		}
+	}()
+	wg.Wait()
+	if s.Count() != 3 {
+		t.Errorf("Expected 3 segments, got %d", s.Count())
+	}
+	err := s.Do(func(p *MediaPlaylist) error {
+		if p.SeqNo != 97 {
+			return fmt.Errorf("SeqNo is %d, expected 97", p.SeqNo)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Error(err)
+	}
+	s.Close()
+	if !strings.HasSuffix(s.String(), "t099.ts\n#EXT-X-ENDLIST\n") {
+		t.Errorf("Unexpected closed playlist:\n%s", s)
+	}
+}
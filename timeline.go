@@ -0,0 +1,115 @@
+package m3u8
+
+/*
+ Part of M3U8 parser & generator library.
+ This file defines the timeline view of media playlists.
+
+ Copyright 2013-2019 The Project Developers.
+ See the AUTHORS and LICENSE files at the top-level directory of this distribution
+ and at https://github.com/grafov/m3u8/
+
+ ॐ तारे तुत्तारे तुरे स्व
+*/
+
+import (
+	"sort"
+	"time"
+)
+
+// TimelineEventType is the type of the timeline event.
+type TimelineEventType uint
+
+const (
+	// TimelineSegment is the start of the media segment.
+	TimelineSegment TimelineEventType = iota + 1
+	// TimelineDiscontinuity is the discontinuity before the segment.
+	TimelineDiscontinuity
+	// TimelineKeyChange is the change of the effective encryption
+	// key (including the first key of the playlist).
+	TimelineKeyChange
+	// TimelineDateRange is the start of the daterange.
+	TimelineDateRange
+	// TimelineCue is the SCTE-35 cue (ad break start, mid or end) of
+	// the segment.
+	TimelineCue
+)
+
+// TimelineEvent is the event of the media playlist timeline.
+type TimelineEvent struct {
+	Type TimelineEventType
+	// Offset is the time of the event in seconds from the start of
+	// the first segment of the playlist.
+	Offset float64
+	// Time is the wall-clock time of the event derived from the
+	// nearest preceding EXT-X-PROGRAM-DATE-TIME (or the start date of
+	// the daterange), zero if it is unknown.
+	Time      time.Time
+	SeqId     uint64        // sequence number of the segment of the event
+	Segment   *MediaSegment // segment of the event
+	Key       *Key          // for TimelineKeyChange
+	DateRange *DateRange    // for TimelineDateRange
+	SCTE      *SCTE         // for TimelineCue
+}
+
+// Timeline returns the events of the media playlist (segments,
+// discontinuities, key changes, dateranges and SCTE-35 cues) merged
+// into a single timeline ordered by time. Events at the same time
+// keep the order of the playlist: discontinuity, key change,
+// dateranges, cue and then the segment. Dateranges with start dates
+// are placed at their start dates when the wall-clock time of the
+// segment is known.
+func (p *MediaPlaylist) Timeline() []TimelineEvent {
+	var (
+		events []TimelineEvent
+		offset float64
+		base   time.Time // wall-clock time at baseOffset
+		baseAt float64
+		key    *Key
+	)
+	for _, seg := range p.segments() {
+		if !seg.ProgramDateTime.IsZero() {
+			base, baseAt = seg.ProgramDateTime, offset
+		}
+		var at time.Time
+		if !base.IsZero() {
+			at = base.Add(time.Duration((offset - baseAt) * float64(time.Second)))
+		}
+		event := TimelineEvent{Offset: offset, Time: at, SeqId: seg.SeqId, Segment: seg}
+		add := func(t TimelineEventType) *TimelineEvent {
+			e := event
+			e.Type = t
+			events = append(events, e)
+			return &events[len(events)-1]
+		}
+		if seg.Discontinuity {
+			add(TimelineDiscontinuity)
+		}
+		effective := key
+		if seg.Key != nil {
+			effective = seg.Key
+		} else if key == nil {
+			effective = p.Key
+		}
+		if effective != nil && (key == nil || !sameKey(key, effective)) {
+			add(TimelineKeyChange).Key = effective
+		}
+		key = effective
+		for _, dr := range seg.DateRange {
+			e := add(TimelineDateRange)
+			e.DateRange = dr
+			if !dr.StartDate.IsZero() {
+				if !at.IsZero() {
+					e.Offset += dr.StartDate.Sub(at).Seconds()
+				}
+				e.Time = dr.StartDate
+			}
+		}
+		if seg.SCTE != nil {
+			add(TimelineCue).SCTE = seg.SCTE
+		}
+		add(TimelineSegment)
+		offset += seg.Duration
+	}
+	sort.SliceStable(events, func(i, j int) bool { return events[i].Offset < events[j].Offset })
+	return events
+}
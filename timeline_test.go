@@ -0,0 +1,71 @@
+/*
+Timeline tests.
+
+Copyright 2013-2019 The Project Developers.
+See the AUTHORS and LICENSE files at the top-level directory of this distribution
+and at https://github.com/grafov/m3u8/
+
+ॐ तारे तुत्तारे तुरे स्व
+*/
+package m3u8
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTimeline(t *testing.T) {
+	src := `#EXTM3U
+#EXT-X-VERSION:3
+#EXT-X-TARGETDURATION:10
+#EXT-X-MEDIA-SEQUENCE:5
+#EXT-X-KEY:METHOD=AES-128,URI="k1"
+#EXT-X-PROGRAM-DATE-TIME:2020-01-01T00:00:00Z
+#EXTINF:10,
+a.ts
+#EXT-X-DATERANGE:ID="ad",START-DATE="2020-01-01T00:00:12Z",PLANNED-DURATION=30
+#EXT-X-CUE-OUT:30
+#EXTINF:10,
+b.ts
+#EXT-X-DISCONTINUITY
+#EXT-X-KEY:METHOD=AES-128,URI="k2"
+#EXTINF:10,
+c.ts
+`
+	pl, _, err := DecodeFrom(strings.NewReader(src), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	events := pl.(*MediaPlaylist).Timeline()
+	expected := []struct {
+		typ    TimelineEventType
+		offset float64
+		seqID  uint64
+	}{
+		{TimelineKeyChange, 0, 5},
+		{TimelineSegment, 0, 5},
+		{TimelineCue, 10, 6},
+		{TimelineSegment, 10, 6},
+		{TimelineDateRange, 12, 6},
+		{TimelineDiscontinuity, 20, 7},
+		{TimelineKeyChange, 20, 7},
+		{TimelineSegment, 20, 7},
+	}
+	if len(events) != len(expected) {
+		t.Fatalf("expected %d events, got %d: %+v", len(expected), len(events), events)
+	}
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, e := range expected {
+		ev := events[i]
+		if ev.Type != e.typ || ev.Offset != e.offset || ev.SeqId != e.seqID {
+			t.Errorf("event %d: expected %v at %g of %d, got %v at %g of %d", i, e.typ, e.offset, e.seqID, ev.Type, ev.Offset, ev.SeqId)
+		}
+		if !ev.Time.Equal(start.Add(time.Duration(e.offset) * time.Second)) {
+			t.Errorf("event %d: unexpected time %s", i, ev.Time)
+		}
+	}
+	if events[6].Key.URI != "k2" || events[2].SCTE == nil || events[4].DateRange.ID != "ad" {
+		t.Error("events miss their subjects")
+	}
+}
@@ -0,0 +1,105 @@
+package m3u8
+
+/*
+ Part of M3U8 parser & generator library.
+ This file defines transformations of media playlists applied on encoding.
+
+ Copyright 2013-2019 The Project Developers.
+ See the AUTHORS and LICENSE files at the top-level directory of this distribution
+ and at https://github.com/grafov/m3u8/
+
+ ॐ तारे तुत्तारे तुरे स्व
+*/
+
+import (
+	"bytes"
+	"time"
+)
+
+// Transform is a function changing the media playlist before it is
+// encoded, for example trimming the window, signing segment URIs or
+// filtering tags. It may change and return the playlist it is given
+// or return another playlist. Returning nil leaves the playlist as it
+// was passed to the transform.
+type Transform func(p *MediaPlaylist) *MediaPlaylist
+
+// RegisterTransform appends the transform to the chain applied by
+// Encode, EncodeWindow and String. Transforms are applied in order of
+// registration to a copy of the playlist, so the playlist itself and
+// its segments are left intact. Nested values (keys, maps, dateranges
+// and custom tags) are shared with the playlist and should be
+// replaced rather than changed by transforms. As the encoded playlist
+// is cached, call ResetCache if the result of a transform depends on
+// anything else than the playlist (e.g. the time for URI signing).
+func (p *MediaPlaylist) RegisterTransform(fn Transform) {
+	p.transforms = append(p.transforms, fn)
+	p.buf.Reset()
+}
+
+// ClearTransforms removes all transforms registered for the playlist.
+func (p *MediaPlaylist) ClearTransforms() {
+	p.transforms = nil
+	p.buf.Reset()
+}
+
+// EncodeWith generates output in M3U8 format as Encode does with the
+// transforms applied after the registered ones. It neither uses nor
+// resets the playlist cache so the playlist may be encoded
+// differently for each request, for example with per-session segment
+// URIs.
+func (p *MediaPlaylist) EncodeWith(fns ...Transform) *bytes.Buffer {
+	start := time.Now()
+	q := p.transformed()
+	if len(fns) > 0 && q == p {
+		q = p.transformCopy()
+	}
+	for _, fn := range fns {
+		if r := fn(q); r != nil {
+			q = r
+		}
+	}
+	buf := new(bytes.Buffer)
+	q.encode(buf, 0, q.winsize)
+	reportEncoded(MEDIA, int(q.windowCount(0, q.winsize)), start)
+	return buf
+}
+
+// transformed returns the result of the transform chain applied to a
+// copy of the playlist or the playlist itself without transforms.
+func (p *MediaPlaylist) transformed() *MediaPlaylist {
+	if len(p.transforms) == 0 {
+		return p
+	}
+	q := p.transformCopy()
+	for _, fn := range p.transforms {
+		if r := fn(q); r != nil {
+			q = r
+		}
+	}
+	return q
+}
+
+// transformCopy returns a copy of the playlist with own copies of the
+// segments. The copy has no transforms, cache and callbacks, so
+// changing it doesn't affect the playlist.
+func (p *MediaPlaylist) transformCopy() *MediaPlaylist {
+	q := new(MediaPlaylist)
+	*q = *p
+	q.buf = bytes.Buffer{}
+	q.transforms = nil
+	q.pool = nil
+	q.onFull = nil
+	q.onEvict = nil
+	q.retention = nil
+	q.Segments = make([]*MediaSegment, len(p.Segments))
+	for i, seg := range p.Segments {
+		if seg != nil {
+			s := *seg
+			q.Segments[i] = &s
+		}
+	}
+	if p.Custom != nil {
+		q.Custom = append(CustomTags(nil), p.Custom...)
+	}
+	return q
+}
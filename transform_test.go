@@ -0,0 +1,90 @@
+/*
+Playlist transforms tests.
+
+Copyright 2013-2019 The Project Developers.
+See the AUTHORS and LICENSE files at the top-level directory of this distribution
+and at https://github.com/grafov/m3u8/
+
+ॐ तारे तुत्तारे तुरे स्व
+*/
+package m3u8
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRegisterTransform(t *testing.T) {
+	p, err := NewMediaPlaylist(5, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, uri := range []string{"a.ts", "b.ts", "c.ts"} {
+		if err = p.Append(uri, 10, ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	plain := p.String()
+
+	// sign URIs
+	p.RegisterTransform(func(q *MediaPlaylist) *MediaPlaylist {
+		head := q.head
+		for count := q.count; count > 0; count-- {
+			seg := q.Segments[head]
+			head = (head + 1) % q.capacity
+			seg.URI += "?token=x"
+		}
+		return q
+	})
+	// trim window to the last two segments
+	p.RegisterTransform(func(q *MediaPlaylist) *MediaPlaylist {
+		q.SetWinSize(2)
+		for q.Count() > 2 {
+			q.Remove()
+		}
+		return nil
+	})
+	out := p.String()
+	if strings.Contains(out, "a.ts") || !strings.Contains(out, "b.ts?token=x\n") ||
+		!strings.Contains(out, "c.ts?token=x\n") || !strings.Contains(out, "#EXT-X-MEDIA-SEQUENCE:1\n") {
+		t.Errorf("transforms are not applied:\n%s", out)
+	}
+	if p.Count() != 3 || p.Segments[0].URI != "a.ts" || p.WinSize() != 5 {
+		t.Errorf("transforms changed the playlist")
+	}
+	if w := p.EncodeWindow(1).String(); !strings.Contains(w, "c.ts?token=x\n") || strings.Contains(w, "b.ts") {
+		t.Errorf("transforms are not applied to window:\n%s", w)
+	}
+
+	p.ClearTransforms()
+	if p.String() != plain {
+		t.Errorf("expected plain playlist after clearing transforms:\n%s", p.String())
+	}
+}
+
+func TestEncodeWith(t *testing.T) {
+	p, e := NewMediaPlaylist(0, 2)
+	if e != nil {
+		t.Fatalf("Create media playlist failed: %s", e)
+	}
+	p.Append("a.ts", 4, "")
+	p.Append("b.ts", 4, "")
+	p.RegisterTransform(func(q *MediaPlaylist) *MediaPlaylist {
+		q.Args = "token=1"
+		return q
+	})
+	cached := p.String()
+	out := p.EncodeWith(func(q *MediaPlaylist) *MediaPlaylist {
+		q.Segments[q.head].URI = "session/a.ts"
+		return q
+	}).String()
+	if !strings.Contains(out, "\nsession/a.ts?token=1\n") {
+		t.Errorf("transforms must be applied in order:\n%s", out)
+	}
+	if p.String() != cached || strings.Contains(cached, "session") {
+		t.Error("cache must not be used by EncodeWith")
+	}
+	if p.EncodeWith().String() != cached {
+		t.Error("EncodeWith without transforms must match Encode")
+	}
+}
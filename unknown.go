@@ -0,0 +1,110 @@
+package m3u8
+
+/*
+ Part of M3U8 parser & generator library.
+ This file defines preservation of unknown tags.
+
+ Copyright 2013-2019 The Project Developers.
+ See the AUTHORS and LICENSE files at the top-level directory of this distribution
+ and at https://github.com/grafov/m3u8/
+
+ ॐ तारे तुत्तारे तुरे स्व
+*/
+
+import (
+	"bytes"
+	"strings"
+)
+
+// knownTags are tags handled by the decoder.
+var knownTags = map[string]bool{
+	"#EXTM3U":                       true,
+	"#EXTINF":                       true,
+	"#EXT-X-VERSION":                true,
+	"#EXT-X-INDEPENDENT-SEGMENTS":   true,
+	"#EXT-X-START":                  true,
+	"#EXT-X-DEFINE":                 true,
+	"#EXT-X-CONTENT-STEERING":       true,
+	"#EXT-X-SESSION-DATA":           true,
+	"#EXT-X-MEDIA":                  true,
+	"#EXT-X-STREAM-INF":             true,
+	"#EXT-X-I-FRAME-STREAM-INF":     true,
+	"#EXT-X-IMAGE-STREAM-INF":       true,
+	"#EXT-X-TARGETDURATION":         true,
+	"#EXT-X-MEDIA-SEQUENCE":         true,
+	"#EXT-X-DISCONTINUITY-SEQUENCE": true,
+	"#EXT-X-PLAYLIST-TYPE":          true,
+	"#EXT-X-ALLOW-CACHE":            true,
+	"#EXT-X-I-FRAMES-ONLY":          true,
+	"#EXT-X-IMAGES-ONLY":            true,
+	"#EXT-X-ENDLIST":                true,
+	"#EXT-X-SERVER-CONTROL":         true,
+	"#EXT-X-PART-INF":               true,
+	"#EXT-X-PART":                   true,
+	"#EXT-X-PRELOAD-HINT":           true,
+	"#EXT-X-RENDITION-REPORT":       true,
+	"#EXT-X-SKIP":                   true,
+	"#EXT-X-KEY":                    true,
+	"#EXT-X-MAP":                    true,
+	"#EXT-X-BYTERANGE":              true,
+	"#EXT-X-DISCONTINUITY":          true,
+	"#EXT-X-GAP":                    true,
+	"#EXT-X-PROGRAM-DATE-TIME":      true,
+	"#EXT-X-BITRATE":                true,
+	"#EXT-X-DATERANGE":              true,
+	"#EXT-X-TILES":                  true,
+	"#EXT-SCTE35":                   true,
+	"#EXT-OATCLS-SCTE35":            true,
+	"#EXT-X-CUE":                    true,
+	"#EXT-X-CUE-OUT":                true,
+	"#EXT-X-CUE-OUT-CONT":           true,
+	"#EXT-X-CUE-IN":                 true,
+}
+
+// isUnknownTag returns true for the tag (or comment) line which is
+// handled neither by the decoder nor by the custom decoders.
+func isUnknownTag(line string, decoders []CustomDecoder) bool {
+	if !strings.HasPrefix(line, "#") || strings.HasPrefix(line, "#WV-") {
+		return false
+	}
+	for _, d := range decoders {
+		if strings.HasPrefix(line, d.TagName()) {
+			return false
+		}
+	}
+	name := line
+	if i := strings.IndexByte(line, ':'); i >= 0 {
+		name = line[:i]
+	}
+	return !knownTags[name]
+}
+
+// keepUnknownTag stores the unknown tag of the master playlist. Tags
+// preceding the first EXT-X-MEDIA or variant belong to the header,
+// the rest are kept in TrailingUnknownTags until the next variant.
+func (p *MasterPlaylist) keepUnknownTag(state *decodingState, line string) {
+	if len(p.Variants) == 0 && len(state.alternatives) == 0 && len(p.TrailingUnknownTags) == 0 {
+		p.UnknownTags = append(p.UnknownTags, line)
+		return
+	}
+	p.TrailingUnknownTags = append(p.TrailingUnknownTags, line)
+}
+
+// keepUnknownTag stores the unknown tag of the media playlist. Tags
+// preceding the first segment tags belong to the header, the rest are
+// kept in TrailingUnknownTags until the next segment URI.
+func (p *MediaPlaylist) keepUnknownTag(state *decodingState, line string) {
+	if p.count == 0 && !state.tagInf && len(p.TrailingUnknownTags) == 0 {
+		p.UnknownTags = append(p.UnknownTags, line)
+		return
+	}
+	p.TrailingUnknownTags = append(p.TrailingUnknownTags, line)
+}
+
+// writeUnknownTags writes preserved unknown tags verbatim.
+func writeUnknownTags(buf *bytes.Buffer, tags []string) {
+	for _, tag := range tags {
+		buf.WriteString(tag)
+		buf.WriteRune('\n')
+	}
+}
@@ -0,0 +1,86 @@
+/*
+Unknown tags preservation tests.
+
+Copyright 2013-2019 The Project Developers.
+See the AUTHORS and LICENSE files at the top-level directory of this distribution
+and at https://github.com/grafov/m3u8/
+
+ॐ तारे तुत्तारे तुरे स्व
+*/
+package m3u8
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPreserveUnknownTagsMedia(t *testing.T) {
+	const src = `#EXTM3U
+#EXT-X-VERSION:3
+#EXT-X-MEDIA-SEQUENCE:0
+#EXT-X-TARGETDURATION:6
+#EXT-X-VENDOR-HEADER:foo=bar
+#EXTINF:5.000,
+#UPLYNK-SEGMENT:abc,0,segment
+a.ts
+#EXT-X-VENDOR-MARK
+#EXTINF:5.000,
+b.ts
+#EXT-X-VENDOR-TRAILER
+#EXT-X-ENDLIST
+`
+	p, listType, err := DecodeWithOptions(strings.NewReader(src), DecodeOptions{Strict: true, PreserveUnknownTags: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if listType != MEDIA {
+		t.Fatalf("unexpected playlist type %v", listType)
+	}
+	pp := p.(*MediaPlaylist)
+	if len(pp.UnknownTags) != 1 || pp.UnknownTags[0] != "#EXT-X-VENDOR-HEADER:foo=bar" {
+		t.Errorf("unexpected header tags: %q", pp.UnknownTags)
+	}
+	if tags := pp.Segments[0].UnknownTags; len(tags) != 1 || tags[0] != "#UPLYNK-SEGMENT:abc,0,segment" {
+		t.Errorf("unexpected tags of the first segment: %q", tags)
+	}
+	if len(pp.TrailingUnknownTags) != 1 {
+		t.Errorf("unexpected trailing tags: %q", pp.TrailingUnknownTags)
+	}
+	// the tag between EXTINF and URI is moved before EXTINF
+	expected := strings.Replace(src, "#EXTINF:5.000,\n#UPLYNK-SEGMENT:abc,0,segment\n", "#UPLYNK-SEGMENT:abc,0,segment\n#EXTINF:5.000,\n", 1)
+	if out := pp.String(); out != expected {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+
+	// unknown tags are dropped by default
+	p, _, err = DecodeFrom(strings.NewReader(src), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(p.String(), "VENDOR") {
+		t.Errorf("unknown tags must be dropped:\n%s", p)
+	}
+}
+
+func TestPreserveUnknownTagsMaster(t *testing.T) {
+	const src = `#EXTM3U
+#EXT-X-VERSION:3
+#EXT-X-VENDOR-HEADER
+#EXT-X-STREAM-INF:BANDWIDTH=300000
+low.m3u8
+# high quality
+#EXT-X-STREAM-INF:BANDWIDTH=600000
+high.m3u8
+#EXT-X-VENDOR-TRAILER
+`
+	p := NewMasterPlaylist()
+	if err := p.DecodeWithOptions(strings.NewReader(src), DecodeOptions{Strict: true, PreserveUnknownTags: true}); err != nil {
+		t.Fatal(err)
+	}
+	if tags := p.Variants[1].UnknownTags; len(tags) != 1 || tags[0] != "# high quality" {
+		t.Errorf("unexpected tags of the variant: %q", tags)
+	}
+	if out := p.String(); out != src {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+}
@@ -0,0 +1,225 @@
+package m3u8
+
+/*
+ Part of M3U8 parser & generator library.
+ This file defines templates of variant and segment URIs.
+
+ Copyright 2013-2019 The Project Developers.
+ See the AUTHORS and LICENSE files at the top-level directory of this distribution
+ and at https://github.com/grafov/m3u8/
+
+ ॐ तारे तुत्तारे तुरे स्व
+*/
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Placeholders of URI templates.
+const (
+	PlaceholderBandwidth        = "Bandwidth"        // BANDWIDTH of the variant
+	PlaceholderIndex            = "Index"            // zero-based position of the variant or the segment
+	PlaceholderSequence         = "Sequence"         // media sequence number of the segment
+	PlaceholderRepresentationID = "RepresentationID" // identifier of the rendition chosen by the caller
+)
+
+// ErrUnresolvedPlaceholder returned when a placeholder of the URI
+// template has no value.
+var ErrUnresolvedPlaceholder = errors.New("unresolved URI template placeholder")
+
+// URITemplate is a template of URIs with placeholders in the format
+// of DASH segment templates: $Name$ or $Name%0Nd$ for zero padded
+// numbers, "$$" is the escaped dollar sign. Supported names are
+// Bandwidth, Index, Sequence and RepresentationID, for example
+// "video/$RepresentationID$/seg-$Sequence%05d$.ts".
+type URITemplate string
+
+// URIValues holds values of URI template placeholders. Zero Bandwidth
+// and empty RepresentationID are unresolved, Index and Sequence are
+// always resolved.
+type URIValues struct {
+	Bandwidth        uint32
+	Index            int
+	Sequence         uint64
+	RepresentationID string
+}
+
+// Validate returns error if the template has unknown placeholders,
+// invalid formats or unpaired dollar signs.
+func (t URITemplate) Validate() error {
+	_, err := t.expand(nil)
+	return err
+}
+
+// Placeholders returns the names of placeholders used in the
+// template.
+func (t URITemplate) Placeholders() ([]string, error) {
+	var names []string
+	_, err := t.expand(func(name string) (uint64, string, bool) {
+		names = append(names, name)
+		return 0, "", true
+	})
+	return names, err
+}
+
+// Expand returns the URI with placeholders replaced by the values. It
+// returns ErrUnresolvedPlaceholder if a used placeholder has no value.
+func (t URITemplate) Expand(values URIValues) (string, error) {
+	return t.expand(func(name string) (uint64, string, bool) {
+		switch name {
+		case PlaceholderBandwidth:
+			return uint64(values.Bandwidth), "", values.Bandwidth > 0
+		case PlaceholderIndex:
+			return uint64(values.Index), "", true
+		case PlaceholderSequence:
+			return values.Sequence, "", true
+		default:
+			return 0, values.RepresentationID, values.RepresentationID != ""
+		}
+	})
+}
+
+// expand parses the template and replaces placeholders with the
+// numeric or string values returned by the function. Nil function
+// only validates the template.
+func (t URITemplate) expand(value func(name string) (uint64, string, bool)) (string, error) {
+	var b strings.Builder
+	s := string(t)
+	for {
+		i := strings.IndexByte(s, '$')
+		if i < 0 {
+			b.WriteString(s)
+			return b.String(), nil
+		}
+		b.WriteString(s[:i])
+		s = s[i+1:]
+		j := strings.IndexByte(s, '$')
+		if j < 0 {
+			return "", fmt.Errorf("unpaired $ in URI template %q", string(t))
+		}
+		placeholder := s[:j]
+		s = s[j+1:]
+		if placeholder == "" {
+			b.WriteByte('$')
+			continue
+		}
+		name, width := placeholder, 0
+		if k := strings.IndexByte(placeholder, '%'); k >= 0 {
+			name = placeholder[:k]
+			format := placeholder[k+1:]
+			if !strings.HasPrefix(format, "0") || !strings.HasSuffix(format, "d") {
+				return "", fmt.Errorf("invalid format of placeholder %q", placeholder)
+			}
+			w, err := strconv.Atoi(format[1 : len(format)-1])
+			if err != nil || w <= 0 {
+				return "", fmt.Errorf("invalid format of placeholder %q", placeholder)
+			}
+			width = w
+		}
+		switch name {
+		case PlaceholderBandwidth, PlaceholderIndex, PlaceholderSequence:
+		case PlaceholderRepresentationID:
+			if width > 0 {
+				return "", fmt.Errorf("placeholder %s can't be formatted as number", name)
+			}
+		default:
+			return "", fmt.Errorf("unknown placeholder %q in URI template %q", name, string(t))
+		}
+		if value == nil {
+			continue
+		}
+		n, str, ok := value(name)
+		if !ok {
+			return "", ErrUnresolvedPlaceholder
+		}
+		if name != PlaceholderRepresentationID {
+			str = strconv.FormatUint(n, 10)
+			if pad := width - len(str); pad > 0 {
+				str = strings.Repeat("0", pad) + str
+			}
+		}
+		b.WriteString(str)
+	}
+}
+
+// hasPlaceholders reports whether the URI contains valid placeholders
+// of URI templates left unexpanded.
+func hasPlaceholders(uri string) bool {
+	if !strings.Contains(uri, "$") {
+		return false
+	}
+	names, err := URITemplate(uri).Placeholders()
+	return err == nil && len(names) > 0
+}
+
+// ExpandURITemplates expands URI templates in URIs of the variants
+// with BANDWIDTH of the variant, its index in the master playlist and
+// the representation ID returned by the function (optional). It
+// returns error if a placeholder is unresolved, variants expanded
+// before the error keep their expanded URIs. This operation does
+// reset playlist cache.
+func (p *MasterPlaylist) ExpandURITemplates(representationID func(i int, v *Variant) string) error {
+	p.buf.Reset()
+	for i, v := range p.Variants {
+		if v == nil {
+			continue
+		}
+		values := URIValues{Bandwidth: v.Bandwidth, Index: i}
+		if representationID != nil {
+			values.RepresentationID = representationID(i, v)
+		}
+		uri, err := URITemplate(v.URI).Expand(values)
+		if err != nil {
+			return err
+		}
+		v.URI = uri
+	}
+	return nil
+}
+
+// URITemplateTransform returns the transform which expands URI
+// templates in URIs of the segments with the media sequence number of
+// the segment, its index in the playlist, the bandwidth and the
+// representation ID of the rendition. Register it with
+// RegisterTransform to keep templates in the playlist and expand them
+// on encoding. Segments with unresolved placeholders keep their
+// templates, use EncodeStrict to reject such playlists.
+func URITemplateTransform(bandwidth uint32, representationID string) Transform {
+	return func(p *MediaPlaylist) *MediaPlaylist {
+		var i int
+		p.eachSegment(func(seg *MediaSegment) {
+			values := URIValues{Bandwidth: bandwidth, Index: i, Sequence: seg.SeqId, RepresentationID: representationID}
+			if uri, err := URITemplate(seg.URI).Expand(values); err == nil {
+				seg.URI = uri
+			}
+			i++
+		})
+		return p
+	}
+}
+
+// checkPlaceholders returns ErrUnresolvedPlaceholder if URIs of the
+// media playlist contain placeholders of URI templates.
+func (p *MediaPlaylist) checkPlaceholders() error {
+	var err error
+	p.eachSegment(func(seg *MediaSegment) {
+		if hasPlaceholders(seg.URI) {
+			err = ErrUnresolvedPlaceholder
+		}
+	})
+	return err
+}
+
+// checkPlaceholders returns ErrUnresolvedPlaceholder if URIs of the
+// variants contain placeholders of URI templates.
+func (p *MasterPlaylist) checkPlaceholders() error {
+	for _, v := range p.Variants {
+		if v != nil && hasPlaceholders(v.URI) {
+			return ErrUnresolvedPlaceholder
+		}
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+/*
+URI templates tests.
+
+Copyright 2013-2019 The Project Developers.
+See the AUTHORS and LICENSE files at the top-level directory of this distribution
+and at https://github.com/grafov/m3u8/
+
+ॐ तारे तुत्तारे तुरे स्व
+*/
+package m3u8
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestURITemplateExpand(t *testing.T) {
+	values := URIValues{Bandwidth: 1500000, Index: 2, Sequence: 42, RepresentationID: "720p"}
+	cases := []struct {
+		tmpl URITemplate
+		want string
+	}{
+		{"plain.ts", "plain.ts"},
+		{"$RepresentationID$/seg-$Sequence$.ts", "720p/seg-42.ts"},
+		{"seg-$Sequence%05d$.ts", "seg-00042.ts"},
+		{"v$Index$_$Bandwidth$.m3u8", "v2_1500000.m3u8"},
+		{"price$$$Index$.ts", "price$2.ts"},
+	}
+	for _, c := range cases {
+		got, err := c.tmpl.Expand(values)
+		if err != nil {
+			t.Errorf("%s: %v", c.tmpl, err)
+			continue
+		}
+		if got != c.want {
+			t.Errorf("%s: expected %q, got %q", c.tmpl, c.want, got)
+		}
+	}
+}
+
+func TestURITemplateUnresolved(t *testing.T) {
+	if _, err := URITemplate("$RepresentationID$.m3u8").Expand(URIValues{}); err != ErrUnresolvedPlaceholder {
+		t.Errorf("expected ErrUnresolvedPlaceholder for empty representation ID, got %v", err)
+	}
+	if _, err := URITemplate("$Bandwidth$.m3u8").Expand(URIValues{}); err != ErrUnresolvedPlaceholder {
+		t.Errorf("expected ErrUnresolvedPlaceholder for zero bandwidth, got %v", err)
+	}
+	if _, err := URITemplate("$Index$-$Sequence$.ts").Expand(URIValues{}); err != nil {
+		t.Errorf("zero index and sequence must resolve: %v", err)
+	}
+}
+
+func TestURITemplateValidate(t *testing.T) {
+	for _, tmpl := range []URITemplate{"$Number$.ts", "$Sequence.ts", "$Sequence%5d$.ts", "$Sequence%0xd$.ts", "$RepresentationID%03d$.ts"} {
+		if err := tmpl.Validate(); err == nil {
+			t.Errorf("%s: expected error", tmpl)
+		}
+	}
+	names, err := URITemplate("$RepresentationID$/$Sequence%03d$.ts").Placeholders()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(names, ",") != "RepresentationID,Sequence" {
+		t.Errorf("unexpected placeholders: %v", names)
+	}
+}
+
+func TestMasterExpandURITemplates(t *testing.T) {
+	m := NewMasterPlaylist()
+	m.Append("$RepresentationID$/$Bandwidth$.m3u8", nil, VariantParams{Bandwidth: 800000, Resolution: "640x360"})
+	m.Append("$RepresentationID$/$Bandwidth$.m3u8", nil, VariantParams{Bandwidth: 2500000, Resolution: "1280x720"})
+	if _, err := m.EncodeStrict(); err != ErrUnresolvedPlaceholder {
+		t.Fatalf("expected ErrUnresolvedPlaceholder, got %v", err)
+	}
+	err := m.ExpandURITemplates(func(i int, v *Variant) string {
+		return fmt.Sprintf("r%d", i)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Variants[0].URI != "r0/800000.m3u8" || m.Variants[1].URI != "r1/2500000.m3u8" {
+		t.Errorf("unexpected URIs: %s %s", m.Variants[0].URI, m.Variants[1].URI)
+	}
+	if _, err := m.EncodeStrict(); err != nil {
+		t.Error(err)
+	}
+
+	m = NewMasterPlaylist()
+	m.Append("$RepresentationID$.m3u8", nil, VariantParams{Bandwidth: 800000})
+	if err := m.ExpandURITemplates(nil); err != ErrUnresolvedPlaceholder {
+		t.Errorf("expected ErrUnresolvedPlaceholder, got %v", err)
+	}
+}
+
+func TestURITemplateTransform(t *testing.T) {
+	p, err := NewMediaPlaylist(3, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.SeqNo = 10
+	for i := 0; i < 3; i++ {
+		if err = p.Append("$RepresentationID$/seg-$Sequence%04d$.ts", 4, ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err = p.EncodeStrict(); err != ErrUnresolvedPlaceholder {
+		t.Fatalf("expected ErrUnresolvedPlaceholder, got %v", err)
+	}
+	p.RegisterTransform(URITemplateTransform(0, "audio"))
+	buf, err := p.EncodeStrict()
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, uri := range []string{"audio/seg-0010.ts", "audio/seg-0011.ts", "audio/seg-0012.ts"} {
+		if !strings.Contains(out, uri+"\n") {
+			t.Errorf("expected %s in output:\n%s", uri, out)
+		}
+	}
+	if p.Segments[0].URI != "$RepresentationID$/seg-$Sequence%04d$.ts" {
+		t.Errorf("template of the segment changed: %s", p.Segments[0].URI)
+	}
+}
@@ -0,0 +1,464 @@
+package m3u8
+
+/*
+ Part of M3U8 parser & generator library.
+ This file defines functions related to playlist validation.
+
+ Copyright 2013-2019 The Project Developers.
+ See the AUTHORS and LICENSE files at the top-level directory of this distribution
+ and at https://github.com/grafov/m3u8/
+
+ ॐ तारे तुत्तारे तुरे स्व
+*/
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"golang.org/x/text/language"
+)
+
+// CheckVideoRange verifies that VIDEO-RANGE attributes of all variants
+// hold values defined by the specs and are consistent with the
+// CODECS of the variant. HDR video ranges (HLG, PQ) require at least
+// one codec with a profile capable to carry HDR video (HEVC Main 10,
+// Dolby Vision, AV1 or VP9 with 10/12 bit depth etc.). Variants
+// without CODECS are not checked against codecs. Compatibility brands
+// of SUPPLEMENTAL-CODECS must match the video range too, e.g. db1p
+// requires PQ and db4h requires HLG.
+func (p *MasterPlaylist) CheckVideoRange() error {
+	for _, v := range p.Variants {
+		if v.VideoRange == "" {
+			continue
+		}
+		if !v.VideoRange.Valid() {
+			return fmt.Errorf("variant %q: invalid VIDEO-RANGE %s", v.URI, v.VideoRange)
+		}
+		for _, codec := range strings.Split(v.Supplemental, ",") {
+			brands := strings.Split(strings.TrimSpace(codec), "/")
+			for _, brand := range brands[1:] {
+				if r, ok := brandVideoRanges[brand]; ok && r != v.VideoRange {
+					return fmt.Errorf("variant %q: SUPPLEMENTAL-CODECS brand %s requires VIDEO-RANGE %s, got %s", v.URI, brand, r, v.VideoRange)
+				}
+			}
+		}
+		if !v.VideoRange.HDR() || v.Codecs == "" {
+			continue
+		}
+		var capable bool
+		for _, codec := range strings.Split(v.Codecs, ",") {
+			if hdrCapableCodec(strings.TrimSpace(codec)) {
+				capable = true
+				break
+			}
+		}
+		if !capable {
+			return fmt.Errorf("variant %q: VIDEO-RANGE %s requires HDR capable codec, got %q", v.URI, v.VideoRange, v.Codecs)
+		}
+	}
+	return nil
+}
+
+// brandVideoRanges maps the compatibility brands of
+// SUPPLEMENTAL-CODECS to the video range of the base layer they
+// require (Dolby Vision profiles 8.1, 8.2 and 8.4).
+var brandVideoRanges = map[string]VideoRange{
+	"db1p": VideoRangePQ,
+	"db2g": VideoRangeSDR,
+	"db4h": VideoRangeHLG,
+}
+
+// hdrCapableCodec reports whether the codec from CODECS attribute
+// (RFC 6381 format) declares a profile which can carry HDR video.
+func hdrCapableCodec(codec string) bool {
+	parts := strings.Split(codec, ".")
+	switch parts[0] {
+	case "dvh1", "dvhe", "dva1", "dvav", "dav1":
+		// Dolby Vision
+		return true
+	case "hvc1", "hev1":
+		// hvc1.[profile_space]profile_idc...; Main 10 and range extensions
+		if len(parts) < 2 {
+			return false
+		}
+		profile, err := strconv.Atoi(strings.TrimLeft(parts[1], "ABC"))
+		return err == nil && profile >= 2
+	case "av01":
+		// av01.profile.level_tier.bit_depth
+		if len(parts) < 4 {
+			return false
+		}
+		depth, err := strconv.Atoi(parts[3])
+		return err == nil && depth >= 10
+	case "vp09":
+		// vp09.profile.level.bit_depth
+		if len(parts) < 4 {
+			return false
+		}
+		depth, err := strconv.Atoi(parts[3])
+		return err == nil && depth >= 10
+	case "avc1", "avc3":
+		// High 10, High 4:2:2 and High 4:4:4 profiles
+		if len(parts) < 2 || len(parts[1]) < 2 {
+			return false
+		}
+		switch strings.ToUpper(parts[1][:2]) {
+		case "6E", "7A", "F4":
+			return true
+		}
+	}
+	return false
+}
+
+// CheckCharacteristics verifies CHARACTERISTICS attributes of all
+// renditions. Each characteristic must be a UTI in reverse DNS form
+// and the standard accessibility UTIs must be used with media types
+// they describe: describes-video with AUDIO or VIDEO renditions,
+// transcribes-spoken-dialog, describes-music-and-sound and
+// easy-to-read with SUBTITLES or CLOSED-CAPTIONS renditions.
+func (p *MasterPlaylist) CheckCharacteristics() error {
+	for _, v := range p.Variants {
+		if v == nil {
+			continue
+		}
+		for _, alt := range v.Alternatives {
+			if alt == nil {
+				continue
+			}
+			for _, uti := range alt.CharacteristicsList() {
+				if !validUTI(uti) {
+					return fmt.Errorf("rendition %q: invalid characteristic %q", alt.Name, uti)
+				}
+				var types []string
+				switch uti {
+				case CharacteristicDescribesVideo:
+					types = []string{"AUDIO", "VIDEO"}
+				case CharacteristicTranscribesDialog, CharacteristicDescribesMusicAndSound, CharacteristicEasyToRead:
+					types = []string{"SUBTITLES", "CLOSED-CAPTIONS"}
+				default:
+					continue
+				}
+				if alt.Type != types[0] && alt.Type != types[1] {
+					return fmt.Errorf("rendition %q: characteristic %s is not applicable to %s", alt.Name, uti, alt.Type)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// validUTI reports whether the string looks like a Uniform Type
+// Identifier in reverse DNS form.
+func validUTI(uti string) bool {
+	parts := strings.Split(uti, ".")
+	if len(parts) < 2 {
+		return false
+	}
+	for _, part := range parts {
+		if part == "" {
+			return false
+		}
+		for _, r := range part {
+			if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-') {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// CheckLanguages verifies that LANGUAGE and ASSOC-LANGUAGE attributes
+// of renditions and LANGUAGE attributes of session data are valid
+// BCP-47 language tags (RFC 5646). Malformed tags such as "en_US" or
+// "English" frequently break rendition selection on devices.
+func (p *MasterPlaylist) CheckLanguages() error {
+	for _, v := range p.Variants {
+		if v == nil {
+			continue
+		}
+		for _, alt := range v.Alternatives {
+			if alt == nil {
+				continue
+			}
+			if err := checkLanguage(alt.Language); err != nil {
+				return fmt.Errorf("rendition %q: LANGUAGE: %s", alt.Name, err)
+			}
+			if err := checkLanguage(alt.AssocLanguage); err != nil {
+				return fmt.Errorf("rendition %q: ASSOC-LANGUAGE: %s", alt.Name, err)
+			}
+		}
+	}
+	for _, sd := range p.SessionData {
+		if sd == nil {
+			continue
+		}
+		if err := checkLanguage(sd.Language); err != nil {
+			return fmt.Errorf("session data %q: LANGUAGE: %s", sd.DataID, err)
+		}
+	}
+	return nil
+}
+
+// checkLanguage returns error if the non-empty tag is not a valid
+// BCP-47 language tag. Parser of x/text accepts underscores as
+// separators so they are rejected explicitly.
+func checkLanguage(tag string) error {
+	if tag == "" {
+		return nil
+	}
+	for _, r := range tag {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-') {
+			return fmt.Errorf("invalid language tag %q: unexpected character %q", tag, r)
+		}
+	}
+	if _, err := language.Parse(tag); err != nil {
+		return fmt.Errorf("invalid language tag %q: %s", tag, err)
+	}
+	return nil
+}
+
+// CheckAllowCache returns error if EXT-X-ALLOW-CACHE is written to the
+// playlist (set with AllowCache or implied by EVENT playlist type)
+// while the protocol version of the playlist is 7 or higher. The tag
+// was removed from the protocol in version 7 but it is still emitted
+// by many legacy origins so it is preserved by the decoder.
+func (p *MediaPlaylist) CheckAllowCache() error {
+	if p.AllowCache == "" && p.MediaType != EVENT {
+		return nil
+	}
+	if ver := writtenVersion(p.ver, p.pinnedVer); ver >= 7 {
+		return fmt.Errorf("EXT-X-ALLOW-CACHE is not allowed in protocol version %d", ver)
+	}
+	return nil
+}
+
+// Violation is a violation of the specification found by Validate.
+type Violation struct {
+	Rule string // identifier of the violated rule, e.g. "target-duration"
+	Item string // the violating item, e.g. "segment 12", empty for the whole playlist
+	Err  error
+}
+
+func (v Violation) Error() string {
+	if v.Item == "" {
+		return v.Err.Error()
+	}
+	return v.Item + ": " + v.Err.Error()
+}
+
+// violations collects violations found by Validate.
+type violations []Violation
+
+func (vs *violations) add(rule, item string, err error) {
+	if err != nil {
+		*vs = append(*vs, Violation{Rule: rule, Item: item, Err: err})
+	}
+}
+
+// Validate lints the media playlist and returns all violations of the
+// specification found: the version lower than required by features
+// of the playlist, EXTINF durations exceeding EXT-X-TARGETDURATION,
+// VOD playlist without EXT-X-ENDLIST, dateranges without ID or
+// START-DATE or ending before the start, keys inconsistent with their
+// METHOD, parts exceeding PART-TARGET, EXT-X-BYTERANGE without offset
+// not following a sub-range of the same resource and the checks of
+// CheckServerControl, CheckAllowCache and CheckValues. It returns nil
+// for the valid playlist.
+func (p *MediaPlaylist) Validate() []Violation {
+	var vs violations
+	vs.add("version", "", checkVersion(writtenVersion(p.ver, p.pinnedVer), p.requiredVersion()))
+	vs.add("server-control", "", p.CheckServerControl())
+	vs.add("allow-cache", "", p.CheckAllowCache())
+	vs.add("values", "", p.CheckValues())
+	if p.MediaType == VOD && !p.Closed {
+		vs.add("endlist", "", errors.New("VOD playlist without EXT-X-ENDLIST"))
+	}
+	vs.add("key", "playlist key", checkKey(p.Key))
+
+	// the target duration grows with appended segments, the decoded
+	// one is checked
+	target := p.roundTargetDuration(p.TargetDuration)
+	if p.decodedTarget > 0 {
+		target = p.decodedTarget
+	}
+	var prev *MediaSegment
+	p.eachSegment(func(seg *MediaSegment) {
+		item := fmt.Sprintf("segment %d", seg.SeqId)
+		if seg.offsetOmitted && (prev == nil || prev.Limit == 0 || prev.URI != seg.URI) {
+			vs.add("byterange", item, fmt.Errorf("EXT-X-BYTERANGE without offset is not preceded by a sub-range of %s", seg.URI))
+		}
+		prev = seg
+		if d := math.Floor(seg.Duration + 0.5); d > target {
+			vs.add("target-duration", item, fmt.Errorf("EXTINF %v exceeds EXT-X-TARGETDURATION %v", seg.Duration, target))
+		}
+		if seg.Key != nil && (p.Key == nil || !sameKey(seg.Key, p.Key)) {
+			vs.add("key", item, checkKey(seg.Key))
+		}
+		for _, dr := range seg.DateRange {
+			if dr != nil {
+				vs.add("daterange", item, checkDateRange(dr))
+			}
+		}
+		for _, part := range seg.Partials {
+			vs.add("part-target", item, p.checkPartTarget(part))
+		}
+	})
+	for _, part := range p.PendingPartials {
+		vs.add("part-target", "pending parts", p.checkPartTarget(part))
+	}
+	return vs
+}
+
+// checkKey returns error if URI of the key is inconsistent with its
+// METHOD.
+func checkKey(key *Key) error {
+	switch {
+	case key == nil:
+	case key.Method == "":
+		return errors.New("EXT-X-KEY without METHOD")
+	case key.Method == "NONE" && (key.URI != "" || key.IV != "" || key.Keyformat != "" || key.Keyformatversions != ""):
+		return errors.New("EXT-X-KEY with METHOD=NONE must have no other attributes")
+	case key.Method != "NONE" && key.URI == "":
+		return fmt.Errorf("EXT-X-KEY with METHOD=%s without URI", key.Method)
+	}
+	return nil
+}
+
+// checkDateRange returns error if the daterange misses required
+// attributes or its attributes are inconsistent.
+func checkDateRange(dr *DateRange) error {
+	switch {
+	case dr.ID == "":
+		return errors.New("EXT-X-DATERANGE without ID")
+	case dr.StartDate.IsZero():
+		return fmt.Errorf("EXT-X-DATERANGE %q without START-DATE", dr.ID)
+	case !dr.EndDate.IsZero() && dr.EndDate.Before(dr.StartDate):
+		return fmt.Errorf("EXT-X-DATERANGE %q ends before the start", dr.ID)
+	case dr.IsInterstitial():
+		if err := dr.ValidateInterstitial(); err != nil {
+			return fmt.Errorf("EXT-X-DATERANGE %q: %s", dr.ID, err)
+		}
+	case dr.Cue != "":
+		if err := checkCue(dr.CueList()); err != nil {
+			return fmt.Errorf("EXT-X-DATERANGE %q: %s", dr.ID, err)
+		}
+	}
+	return nil
+}
+
+// checkPartTarget returns error if the part is longer than PART-TARGET.
+func (p *MediaPlaylist) checkPartTarget(part *PartialSegment) error {
+	if part != nil && part.Duration > p.PartTargetDuration {
+		return fmt.Errorf("part %q duration %v exceeds PART-TARGET %v", part.URI, part.Duration, p.PartTargetDuration)
+	}
+	return nil
+}
+
+// Validate lints the master playlist and returns all violations of
+// the specification found: the version lower than required by
+// features of the playlist, variants without URI or BANDWIDTH and the
+// checks of CheckVideoRange, CheckCharacteristics, CheckLanguages,
+// CheckSessionData, CheckContentSteering and CheckValues. It returns
+// nil for the valid playlist.
+func (p *MasterPlaylist) Validate() []Violation {
+	var vs violations
+	vs.add("version", "", checkVersion(writtenVersion(p.ver, p.pinnedVer), p.requiredVersion()))
+	for i, v := range p.Variants {
+		if v == nil {
+			continue
+		}
+		item := fmt.Sprintf("variant %q", v.URI)
+		if v.URI == "" {
+			vs.add("uri", fmt.Sprintf("variant %d", i), errors.New("variant without URI"))
+		}
+		if v.Bandwidth == 0 {
+			vs.add("bandwidth", item, errors.New("variant without BANDWIDTH"))
+		}
+	}
+	vs.add("video-range", "", p.CheckVideoRange())
+	vs.add("characteristics", "", p.CheckCharacteristics())
+	vs.add("languages", "", p.CheckLanguages())
+	vs.add("session-data", "", p.CheckSessionData())
+	vs.add("content-steering", "", p.CheckContentSteering())
+	vs.add("values", "", p.CheckValues())
+	return vs
+}
+
+// ValidateReferences verifies references of the variants of the
+// master playlist and returns all violations found: AUDIO, VIDEO,
+// SUBTITLES and CLOSED-CAPTIONS groups without matching EXT-X-MEDIA
+// renditions (CLOSED-CAPTIONS=NONE is allowed), I-frame and image
+// variants without URI, and variants without CODECS or with malformed
+// CODECS. It returns nil if all references are valid.
+func (p *MasterPlaylist) ValidateReferences() []Violation {
+	groups := make(map[string]bool)
+	for _, v := range p.Variants {
+		if v == nil {
+			continue
+		}
+		for _, alt := range v.Alternatives {
+			if alt != nil {
+				groups[alt.Type+" "+alt.GroupId] = true
+			}
+		}
+	}
+
+	var vs violations
+	for i, v := range p.Variants {
+		if v == nil {
+			continue
+		}
+		item := fmt.Sprintf("variant %q", v.URI)
+		if v.URI == "" {
+			item = fmt.Sprintf("variant %d", i)
+			if v.Iframe || v.Images {
+				vs.add("uri", item, errors.New("I-frame variant without URI"))
+			}
+		}
+		refs := [][2]string{{"VIDEO", v.Video}}
+		if !v.Iframe && !v.Images {
+			refs = append(refs, [2]string{"AUDIO", v.Audio}, [2]string{"SUBTITLES", v.Subtitles})
+			if v.Captions != "NONE" {
+				refs = append(refs, [2]string{"CLOSED-CAPTIONS", v.Captions})
+			}
+		}
+		for _, ref := range refs {
+			if ref[1] != "" && !groups[ref[0]+" "+ref[1]] {
+				vs.add("group", item, fmt.Errorf("%s group %q has no renditions", ref[0], ref[1]))
+			}
+		}
+		if v.Codecs == "" {
+			vs.add("codecs", item, errors.New("variant without CODECS"))
+		} else {
+			vs.add("codecs", item, checkCodecs(v.Codecs))
+		}
+	}
+	return vs
+}
+
+// checkCodecs returns error if CODECS attribute is not a comma
+// separated list of RFC 6381 codecs, i.e. a sample entry code of up
+// to four characters optionally followed by dot separated parameters.
+func checkCodecs(codecs string) error {
+	for _, codec := range strings.Split(codecs, ",") {
+		codec = strings.TrimSpace(codec)
+		parts := strings.Split(codec, ".")
+		if len(parts[0]) < 3 || len(parts[0]) > 4 {
+			return fmt.Errorf("malformed codec %q in CODECS %q", codec, codecs)
+		}
+		for _, part := range parts {
+			if part == "" {
+				return fmt.Errorf("malformed codec %q in CODECS %q", codec, codecs)
+			}
+			for _, r := range part {
+				if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' || r == '+') {
+					return fmt.Errorf("malformed codec %q in CODECS %q", codec, codecs)
+				}
+			}
+		}
+	}
+	return nil
+}
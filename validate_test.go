@@ -0,0 +1,243 @@
+/*
+Playlist validation tests.
+
+Copyright 2013-2019 The Project Developers.
+See the AUTHORS and LICENSE files at the top-level directory of this distribution
+and at https://github.com/grafov/m3u8/
+
+ॐ तारे तुत्तारे तुरे स्व
+*/
+package m3u8
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckVideoRange(t *testing.T) {
+	cases := []struct {
+		params VariantParams
+		valid  bool
+	}{
+		{VariantParams{VideoRange: VideoRangeSDR, Codecs: "avc1.640028,mp4a.40.2"}, true},
+		{VariantParams{VideoRange: VideoRangePQ, Codecs: "hvc1.2.4.L123.B0"}, true},
+		{VariantParams{VideoRange: VideoRangePQ, Codecs: "dvh1.05.06,ec-3"}, true},
+		{VariantParams{VideoRange: VideoRangeHLG, Codecs: "av01.0.08M.10"}, true},
+		{VariantParams{VideoRange: VideoRangePQ}, true},
+		{VariantParams{VideoRange: VideoRangePQ, Codecs: "avc1.640028,mp4a.40.2"}, false},
+		{VariantParams{VideoRange: VideoRangeHLG, Codecs: "hvc1.1.6.L93.B0"}, false},
+		{VariantParams{VideoRange: "HDR10", Codecs: "hvc1.2.4.L123.B0"}, false},
+		{VariantParams{VideoRange: VideoRangePQ, Codecs: "hvc1.2.4.L153.B0", Supplemental: "dvh1.08.07/db1p"}, true},
+		{VariantParams{VideoRange: VideoRangeHLG, Codecs: "hvc1.2.4.L153.B0", Supplemental: "dvh1.08.07/db4h"}, true},
+		{VariantParams{VideoRange: VideoRangeSDR, Codecs: "avc1.640028", Supplemental: "dvav.09.05/db2g"}, true},
+		{VariantParams{VideoRange: VideoRangePQ, Codecs: "hvc1.2.4.L153.B0", Supplemental: "dvh1.08.07/unknown"}, true},
+		{VariantParams{VideoRange: VideoRangeHLG, Codecs: "hvc1.2.4.L153.B0", Supplemental: "dvh1.08.07/db1p"}, false},
+		{VariantParams{VideoRange: VideoRangePQ, Codecs: "hvc1.2.4.L153.B0", Supplemental: "dvh1.08.07/db4h"}, false},
+		{VariantParams{VideoRange: VideoRangeSDR, Codecs: "hvc1.2.4.L153.B0", Supplemental: "dvh1.08.07/db1p"}, false},
+	}
+	for i, c := range cases {
+		m := NewMasterPlaylist()
+		m.Append("chunklist.m3u8", nil, c.params)
+		err := m.CheckVideoRange()
+		if c.valid && err != nil {
+			t.Errorf("case %d: unexpected error: %s", i, err)
+		}
+		if !c.valid && err == nil {
+			t.Errorf("case %d: expected error for %+v", i, c.params)
+		}
+	}
+}
+
+func TestCheckCharacteristics(t *testing.T) {
+	sub := &Alternative{Type: "SUBTITLES", GroupId: "subs", Name: "English CC"}
+	sub.SetCharacteristics(CharacteristicTranscribesDialog, CharacteristicDescribesMusicAndSound)
+	if sub.Characteristics != "public.accessibility.transcribes-spoken-dialog,public.accessibility.describes-music-and-sound" {
+		t.Errorf("Unexpected characteristics: %s", sub.Characteristics)
+	}
+	if !sub.HasCharacteristic(CharacteristicDescribesMusicAndSound) || sub.HasCharacteristic(CharacteristicDescribesVideo) {
+		t.Errorf("Unexpected characteristics list: %v", sub.CharacteristicsList())
+	}
+	audio := &Alternative{Type: "AUDIO", GroupId: "aud", Name: "Described", Characteristics: CharacteristicDescribesVideo + ", com.example.custom"}
+	p := NewMasterPlaylist()
+	p.Append("low.m3u8", nil, VariantParams{Bandwidth: 1000, Alternatives: []*Alternative{sub, audio}})
+	if err := p.CheckCharacteristics(); err != nil {
+		t.Errorf("Unexpected error: %s", err)
+	}
+	audio.SetCharacteristics(CharacteristicTranscribesDialog)
+	if err := p.CheckCharacteristics(); err == nil {
+		t.Error("Expected error for transcribes-spoken-dialog on audio")
+	}
+	audio.SetCharacteristics("not a uti")
+	if err := p.CheckCharacteristics(); err == nil {
+		t.Error("Expected error for invalid UTI")
+	}
+}
+
+func TestCheckLanguages(t *testing.T) {
+	audio := &Alternative{Type: "AUDIO", GroupId: "aud", Name: "English", Language: "en-US", AssocLanguage: "zh-Hant"}
+	p := NewMasterPlaylist()
+	p.Append("low.m3u8", nil, VariantParams{Bandwidth: 1000, Alternatives: []*Alternative{audio}})
+	p.SessionData = append(p.SessionData, &SessionData{DataID: "com.example.title", Value: "Title", Language: "sr-Latn-RS"})
+	if err := p.CheckLanguages(); err != nil {
+		t.Errorf("Unexpected error: %s", err)
+	}
+	for _, tag := range []string{"en_US", "English", "e", "en-"} {
+		audio.Language = tag
+		if err := p.CheckLanguages(); err == nil {
+			t.Errorf("Expected error for LANGUAGE %q", tag)
+		}
+	}
+	audio.Language = "en"
+	audio.AssocLanguage = "zh_TW"
+	if err := p.CheckLanguages(); err == nil || !strings.Contains(err.Error(), "ASSOC-LANGUAGE") {
+		t.Errorf("Expected ASSOC-LANGUAGE error, got %v", err)
+	}
+	audio.AssocLanguage = ""
+	p.SessionData[0].Language = "en US"
+	if err := p.CheckLanguages(); err == nil {
+		t.Error("Expected error for session data LANGUAGE")
+	}
+}
+
+func TestCheckAllowCache(t *testing.T) {
+	p, err := NewMediaPlaylist(1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = p.CheckAllowCache(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	p.AllowCache = "NO"
+	if err = p.CheckAllowCache(); err != nil {
+		t.Errorf("unexpected error for version %d: %v", p.Version(), err)
+	}
+	p.PinVersion(7)
+	if err = p.CheckAllowCache(); err == nil {
+		t.Error("expected error for version 7")
+	}
+	p.AllowCache = ""
+	p.MediaType = EVENT
+	if err = p.CheckAllowCache(); err == nil {
+		t.Error("expected error for EVENT playlist of version 7")
+	}
+}
+
+func TestValidateMedia(t *testing.T) {
+	const src = `#EXTM3U
+#EXT-X-VERSION:3
+#EXT-X-PLAYLIST-TYPE:VOD
+#EXT-X-TARGETDURATION:6
+#EXT-X-KEY:METHOD=AES-128
+#EXTINF:5.000,
+a.ts
+#EXT-X-DATERANGE:ID="ad",DURATION=10
+#EXTINF:7.200,
+b.ts
+`
+	p, err := NewMediaPlaylist(0, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = p.DecodeFrom(strings.NewReader(src), false); err != nil {
+		t.Fatal(err)
+	}
+	var rules []string
+	for _, v := range p.Validate() {
+		rules = append(rules, v.Rule)
+	}
+	if strings.Join(rules, " ") != "endlist key target-duration daterange" {
+		t.Errorf("unexpected violations: %v", p.Validate())
+	}
+	if vs := p.Validate(); vs[2].Error() != "segment 1: EXTINF 7.2 exceeds EXT-X-TARGETDURATION 6" {
+		t.Errorf("unexpected message: %s", vs[2])
+	}
+
+	q, _ := NewMediaPlaylist(0, 2)
+	q.Append("a.ts", 5, "")
+	q.Close()
+	if vs := q.Validate(); vs != nil {
+		t.Errorf("unexpected violations of the valid playlist: %v", vs)
+	}
+}
+
+func TestValidateByteRangeWithoutOffset(t *testing.T) {
+	const src = `#EXTM3U
+#EXT-X-VERSION:4
+#EXT-X-TARGETDURATION:6
+#EXTINF:5.000,
+#EXT-X-BYTERANGE:1000
+a.ts
+#EXTINF:5.000,
+#EXT-X-BYTERANGE:1000
+a.ts
+#EXTINF:5.000,
+#EXT-X-BYTERANGE:1000
+b.ts
+#EXTINF:5.000,
+#EXT-X-BYTERANGE:1000@0
+c.ts
+#EXTINF:5.000,
+#EXT-X-BYTERANGE:1000
+c.ts
+#EXT-X-ENDLIST
+`
+	p, err := NewMediaPlaylist(0, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = p.DecodeFrom(strings.NewReader(src), true); err != nil {
+		t.Fatal(err)
+	}
+	var items []string
+	for _, v := range p.Validate() {
+		if v.Rule == "byterange" {
+			items = append(items, v.Item)
+		}
+	}
+	// the first range of a.ts and the range of b.ts following a.ts
+	if strings.Join(items, ",") != "segment 0,segment 2" {
+		t.Errorf("unexpected byterange violations: %v", p.Validate())
+	}
+}
+
+func TestValidateMaster(t *testing.T) {
+	p := NewMasterPlaylist()
+	p.Append("low.m3u8", nil, VariantParams{Bandwidth: 100000})
+	p.Append("high.m3u8", nil, VariantParams{VideoRange: VideoRangePQ, Codecs: "avc1.64001f"})
+	var rules []string
+	for _, v := range p.Validate() {
+		rules = append(rules, v.Rule)
+	}
+	if strings.Join(rules, " ") != "bandwidth video-range" {
+		t.Errorf("unexpected violations: %v", p.Validate())
+	}
+}
+
+func TestValidateReferences(t *testing.T) {
+	const src = `#EXTM3U
+#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID="aac",NAME="en",URI="en.m3u8"
+#EXT-X-STREAM-INF:BANDWIDTH=300000,CODECS="avc1.4d401e,mp4a.40.2",AUDIO="aac",CLOSED-CAPTIONS=NONE
+low.m3u8
+#EXT-X-STREAM-INF:BANDWIDTH=600000,CODECS="avc1.4d401f,,mp4a.40.2",AUDIO="ac3",SUBTITLES="subs"
+high.m3u8
+#EXT-X-I-FRAME-STREAM-INF:BANDWIDTH=80000
+`
+	p := NewMasterPlaylist()
+	if err := p.DecodeFrom(strings.NewReader(src), false); err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, v := range p.ValidateReferences() {
+		got = append(got, v.Rule+" "+v.Item)
+	}
+	expected := []string{
+		`group variant "high.m3u8"`,
+		`group variant "high.m3u8"`,
+		`codecs variant "high.m3u8"`,
+		`uri variant 2`,
+		`codecs variant 2`,
+	}
+	if strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("unexpected violations:\n%s", strings.Join(got, "\n"))
+	}
+}
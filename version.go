@@ -0,0 +1,433 @@
+package m3u8
+
+/*
+ Part of M3U8 parser & generator library.
+ This file defines functions related to the protocol version of playlists.
+
+ Copyright 2013-2019 The Project Developers.
+ See the AUTHORS and LICENSE files at the top-level directory of this distribution
+ and at https://github.com/grafov/m3u8/
+
+ ॐ तारे तुत्तारे तुरे स्व
+*/
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Feature is a feature of the protocol which requires a minimal
+// protocol version (section 7 "Protocol Version Compatibility").
+type Feature string
+
+const (
+	FeatureIV                   Feature = "IV attribute of EXT-X-KEY"
+	FeatureFloatDuration        Feature = "floating-point EXTINF durations"
+	FeatureByteRange            Feature = "EXT-X-BYTERANGE"
+	FeatureIframesOnly          Feature = "EXT-X-I-FRAMES-ONLY"
+	FeatureKeyFormat            Feature = "KEYFORMAT and KEYFORMATVERSIONS attributes of EXT-X-KEY"
+	FeatureMap                  Feature = "EXT-X-MAP"
+	FeatureMapWithoutIframes    Feature = "EXT-X-MAP in playlist without EXT-X-I-FRAMES-ONLY"
+	FeatureInstreamIDService    Feature = "SERVICE values of INSTREAM-ID"
+	FeatureVariableSubstitution Feature = "variable substitution"
+	FeatureSkip                 Feature = "EXT-X-SKIP"
+	FeatureSkipDateRanges       Feature = "EXT-X-SKIP replacing EXT-X-DATERANGE tags"
+	FeatureDefineQueryParam     Feature = "QUERYPARAM attribute of EXT-X-DEFINE"
+	FeatureReqVideoLayout       Feature = "REQ-VIDEO-LAYOUT attribute of EXT-X-STREAM-INF"
+)
+
+// featureVersions maps features to their minimal protocol versions.
+var featureVersions = map[Feature]uint8{
+	FeatureIV:                   2,
+	FeatureFloatDuration:        3,
+	FeatureByteRange:            4,
+	FeatureIframesOnly:          4,
+	FeatureKeyFormat:            5,
+	FeatureMap:                  5,
+	FeatureMapWithoutIframes:    6,
+	FeatureInstreamIDService:    7,
+	FeatureVariableSubstitution: 8,
+	FeatureSkip:                 9,
+	FeatureSkipDateRanges:       10,
+	FeatureDefineQueryParam:     11,
+	FeatureReqVideoLayout:       12,
+}
+
+// FeatureVersion returns the minimal protocol version required by the
+// feature or zero for unknown features.
+func FeatureVersion(f Feature) uint8 {
+	return featureVersions[f]
+}
+
+// Features returns all known version-dependent features ordered by
+// their minimal protocol versions.
+func Features() []Feature {
+	list := make([]Feature, 0, len(featureVersions))
+	for f := range featureVersions {
+		list = append(list, f)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		vi, vj := featureVersions[list[i]], featureVersions[list[j]]
+		return vi < vj || vi == vj && list[i] < list[j]
+	})
+	return list
+}
+
+// features is a list of used features without duplicates.
+type features []Feature
+
+func (fs *features) add(f Feature) {
+	for _, v := range *fs {
+		if v == f {
+			return
+		}
+	}
+	*fs = append(*fs, f)
+}
+
+// minVersion returns the minimal protocol version supporting all the
+// features.
+func (fs features) minVersion() uint8 {
+	ver := uint8(1)
+	for _, f := range fs {
+		version(&ver, featureVersions[f])
+	}
+	return ver
+}
+
+// OmitVersion excludes EXT-X-VERSION tag from the encoded playlist.
+// Some downstream systems require playlists without the tag. This
+// operation does reset playlist cache.
+func (p *MasterPlaylist) OmitVersion(yes bool) {
+	p.omitVer = yes
+	p.buf.Reset()
+}
+
+// PinVersion sets the version written to EXT-X-VERSION regardless of
+// features used in the playlist. Use CheckVersion to verify that the
+// pinned version is enough for the playlist. Zero value unpins the
+// version. This operation does reset playlist cache.
+func (p *MasterPlaylist) PinVersion(ver uint8) {
+	p.pinnedVer = ver
+	p.buf.Reset()
+}
+
+// CheckVersion returns error if the version pinned with PinVersion is
+// lower than the version required by features of the playlist.
+func (p *MasterPlaylist) CheckVersion() error {
+	return checkVersion(p.pinnedVer, p.requiredVersion())
+}
+
+// EncodeStrict generates output in M3U8 format as Encode does but
+// returns error instead of the non-compliant playlist when the version
+// written to EXT-X-VERSION (set with SetVersion or pinned with
+// PinVersion) is lower than the version required by features of the
+// playlist. Playlists without EXT-X-VERSION (see OmitVersion) are not
+// checked as they declare no version.
+// It also returns ErrUnresolvedPlaceholder when URIs of the variants
+// contain URI templates left unexpanded and the error of CheckValues
+// when values of the playlist can't be written.
+func (p *MasterPlaylist) EncodeStrict() (*bytes.Buffer, error) {
+	if !p.omitVer {
+		if err := checkVersion(writtenVersion(p.ver, p.pinnedVer), p.requiredVersion()); err != nil {
+			return nil, err
+		}
+	}
+	if err := p.checkPlaceholders(); err != nil {
+		return nil, err
+	}
+	if err := p.CheckValues(); err != nil {
+		return nil, err
+	}
+	return p.Encode(), nil
+}
+
+// ComputeMinVersion returns the minimal protocol version required by
+// features used in the master playlist, see UsedFeatures.
+func (p *MasterPlaylist) ComputeMinVersion() uint8 {
+	return p.requiredVersion()
+}
+
+// UsedFeatures returns the version-dependent features used in the
+// master playlist, so tools may explain why the playlist requires
+// its protocol version.
+func (p *MasterPlaylist) UsedFeatures() []Feature {
+	var used features
+	p.walkFeatures(func(f Feature, item string) {
+		used.add(f)
+	})
+	return used
+}
+
+// walkFeatures calls fn for each use of version-dependent features in
+// the master playlist with the item using the feature.
+func (p *MasterPlaylist) walkFeatures(fn func(f Feature, item string)) {
+	walkDefineFeatures(p.Defines, fn)
+	for _, v := range p.Variants {
+		if v == nil {
+			continue
+		}
+		if v.ReqVideoLayout != "" {
+			fn(FeatureReqVideoLayout, fmt.Sprintf("variant %q", v.URI))
+		}
+		for _, alt := range v.Alternatives {
+			if alt != nil && strings.HasPrefix(alt.InstreamId, "SERVICE") {
+				fn(FeatureInstreamIDService, fmt.Sprintf("rendition %q", alt.Name))
+			}
+		}
+	}
+}
+
+// walkDefineFeatures calls fn for features used by EXT-X-DEFINE tags.
+func walkDefineFeatures(defines []*Define, fn func(f Feature, item string)) {
+	for _, d := range defines {
+		if d == nil {
+			continue
+		}
+		item := fmt.Sprintf("EXT-X-DEFINE %q", d.Name)
+		fn(FeatureVariableSubstitution, item)
+		if d.Type == DefineQueryParam {
+			fn(FeatureDefineQueryParam, item)
+		}
+	}
+}
+
+// requiredVersion returns the minimal protocol version required by
+// features used in the master playlist.
+func (p *MasterPlaylist) requiredVersion() uint8 {
+	return features(p.UsedFeatures()).minVersion()
+}
+
+// OmitVersion excludes EXT-X-VERSION tag from the encoded playlist.
+// Some downstream systems require playlists without the tag. This
+// operation does reset playlist cache.
+func (p *MediaPlaylist) OmitVersion(yes bool) {
+	p.omitVer = yes
+	p.buf.Reset()
+}
+
+// PinVersion sets the version written to EXT-X-VERSION regardless of
+// features used in the playlist. Use CheckVersion to verify that the
+// pinned version is enough for the playlist. Zero value unpins the
+// version. This operation does reset playlist cache.
+func (p *MediaPlaylist) PinVersion(ver uint8) {
+	p.pinnedVer = ver
+	p.buf.Reset()
+}
+
+// CheckVersion returns error if the version pinned with PinVersion is
+// lower than the version required by features of the playlist.
+func (p *MediaPlaylist) CheckVersion() error {
+	return checkVersion(p.pinnedVer, p.requiredVersion())
+}
+
+// EncodeStrict generates output in M3U8 format as Encode does but
+// returns error instead of the non-compliant playlist when the version
+// written to EXT-X-VERSION (set with SetVersion or pinned with
+// PinVersion) is lower than the version required by features of the
+// playlist. Playlists without EXT-X-VERSION (see OmitVersion) are not
+// checked as they declare no version.
+// It also returns ErrUnresolvedPlaceholder when URIs of the segments
+// contain URI templates left unexpanded by transforms and the error of
+// CheckValues when values of the playlist can't be written.
+func (p *MediaPlaylist) EncodeStrict() (*bytes.Buffer, error) {
+	if !p.omitVer {
+		if err := checkVersion(writtenVersion(p.ver, p.pinnedVer), p.requiredVersion()); err != nil {
+			return nil, err
+		}
+	}
+	if err := p.transformed().checkPlaceholders(); err != nil {
+		return nil, err
+	}
+	if err := p.CheckValues(); err != nil {
+		return nil, err
+	}
+	return p.Encode(), nil
+}
+
+// ComputeMinVersion returns the minimal protocol version required by
+// features used in the media playlist, see UsedFeatures.
+func (p *MediaPlaylist) ComputeMinVersion() uint8 {
+	return p.requiredVersion()
+}
+
+// UsedFeatures returns the version-dependent features used in the
+// media playlist, so tools may explain why the playlist requires its
+// protocol version.
+func (p *MediaPlaylist) UsedFeatures() []Feature {
+	var used features
+	p.walkFeatures(func(f Feature, item string) {
+		used.add(f)
+	})
+	return used
+}
+
+// walkFeatures calls fn for each use of version-dependent features in
+// the media playlist with the item using the feature.
+func (p *MediaPlaylist) walkFeatures(fn func(f Feature, item string)) {
+	if !p.durationAsInt {
+		fn(FeatureFloatDuration, "EXTINF")
+	}
+	if p.Iframe {
+		fn(FeatureIframesOnly, "playlist")
+	}
+	walkDefineFeatures(p.Defines, fn)
+	if p.Skip != nil {
+		fn(FeatureSkip, "EXT-X-SKIP")
+		if len(p.Skip.RecentlyRemovedDateRanges) > 0 {
+			fn(FeatureSkipDateRanges, "EXT-X-SKIP")
+		}
+	}
+	keyFeatures := func(key *Key, item string) {
+		if key == nil {
+			return
+		}
+		if key.IV != "" {
+			fn(FeatureIV, item)
+		}
+		if key.Keyformat != "" || key.Keyformatversions != "" {
+			fn(FeatureKeyFormat, item)
+		}
+	}
+	mapFeature := func(m *Map, item string) {
+		if m == nil {
+			return
+		}
+		if p.Iframe {
+			fn(FeatureMap, item)
+		} else {
+			fn(FeatureMapWithoutIframes, item)
+		}
+	}
+	keyFeatures(p.Key, "playlist key")
+	mapFeature(p.Map, "playlist map")
+	for _, seg := range p.segments() {
+		item := fmt.Sprintf("segment %d", seg.SeqId)
+		keyFeatures(seg.Key, item)
+		if seg.Limit > 0 {
+			fn(FeatureByteRange, item)
+		}
+		mapFeature(seg.Map, item)
+	}
+}
+
+// requiredVersion returns the minimal protocol version required by
+// features used in the media playlist (section 7).
+func (p *MediaPlaylist) requiredVersion() uint8 {
+	return features(p.UsedFeatures()).minVersion()
+}
+
+func checkVersion(pinned, required uint8) error {
+	if pinned > 0 && pinned < required {
+		return fmt.Errorf("version %d is lower than required version %d", pinned, required)
+	}
+	return nil
+}
+
+// writtenVersion returns the version written to EXT-X-VERSION.
+func writtenVersion(ver, pinned uint8) uint8 {
+	if pinned > 0 {
+		return pinned
+	}
+	return ver
+}
+
+// writeVersion writes EXT-X-VERSION tag unless it omitted. The pinned
+// version overrides the playlist version.
+func writeVersion(buf *bytes.Buffer, ver, pinned uint8, omit bool) {
+	if omit {
+		return
+	}
+	buf.WriteString("#EXT-X-VERSION:")
+	buf.WriteString(strver(writtenVersion(ver, pinned)))
+	buf.WriteRune('\n')
+}
+
+// VersionRequirement is the use of the version-dependent feature by
+// the item of the playlist (segment, variant etc.).
+type VersionRequirement struct {
+	Feature Feature
+	Version uint8
+	Item    string
+}
+
+// VersionAudit explains the protocol version of the playlist.
+// Required is the minimal version required by the features used,
+// Declared is the version written to EXT-X-VERSION (zero when the
+// tag is omitted). Requirements lists each feature used with the
+// first item using it in order of the playlist.
+type VersionAudit struct {
+	Required     uint8
+	Declared     uint8
+	Requirements []VersionRequirement
+}
+
+// Forcing returns the requirements of the features forcing the
+// required version.
+func (a VersionAudit) Forcing() []VersionRequirement {
+	var forcing []VersionRequirement
+	for _, r := range a.Requirements {
+		if r.Version == a.Required {
+			forcing = append(forcing, r)
+		}
+	}
+	return forcing
+}
+
+// Check returns error if the declared version is lower than the
+// required one.
+func (a VersionAudit) Check() error {
+	if a.Declared == 0 {
+		return nil
+	}
+	return checkVersion(a.Declared, a.Required)
+}
+
+// String returns the report of the audit, one line per requirement.
+func (a VersionAudit) String() string {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "required version %d, declared %d\n", a.Required, a.Declared)
+	for _, r := range a.Requirements {
+		fmt.Fprintf(&buf, "version %d: %s (%s)\n", r.Version, r.Feature, r.Item)
+	}
+	return buf.String()
+}
+
+// auditVersion builds the audit from the walk of the features.
+func auditVersion(walk func(fn func(f Feature, item string)), declared uint8) VersionAudit {
+	a := VersionAudit{Required: 1, Declared: declared}
+	seen := make(map[Feature]bool)
+	walk(func(f Feature, item string) {
+		if seen[f] {
+			return
+		}
+		seen[f] = true
+		a.Requirements = append(a.Requirements, VersionRequirement{Feature: f, Version: featureVersions[f], Item: item})
+		version(&a.Required, featureVersions[f])
+	})
+	return a
+}
+
+// AuditVersion computes the minimal protocol version required by the
+// features of the media playlist and explains which feature forces
+// which version, see VersionAudit.
+func (p *MediaPlaylist) AuditVersion() VersionAudit {
+	declared := writtenVersion(p.ver, p.pinnedVer)
+	if p.omitVer {
+		declared = 0
+	}
+	return auditVersion(p.walkFeatures, declared)
+}
+
+// AuditVersion computes the minimal protocol version required by the
+// features of the master playlist and explains which feature forces
+// which version, see VersionAudit.
+func (p *MasterPlaylist) AuditVersion() VersionAudit {
+	declared := writtenVersion(p.ver, p.pinnedVer)
+	if p.omitVer {
+		declared = 0
+	}
+	return auditVersion(p.walkFeatures, declared)
+}
@@ -0,0 +1,231 @@
+/*
+Protocol version tests.
+
+Copyright 2013-2019 The Project Developers.
+See the AUTHORS and LICENSE files at the top-level directory of this distribution
+and at https://github.com/grafov/m3u8/
+
+ॐ तारे तुत्तारे तुरे स्व
+*/
+package m3u8
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestOmitVersion(t *testing.T) {
+	p, _ := NewMediaPlaylist(3, 3)
+	_ = p.Append("seg0.ts", 6, "")
+	p.OmitVersion(true)
+	if strings.Contains(p.String(), "#EXT-X-VERSION") {
+		t.Errorf("EXT-X-VERSION must be omitted:\n%s", p.String())
+	}
+	m := NewMasterPlaylist()
+	m.Append("low.m3u8", nil, VariantParams{Bandwidth: 1000})
+	m.OmitVersion(true)
+	if !strings.HasPrefix(m.String(), "#EXTM3U\n#EXT-X-STREAM-INF:") {
+		t.Errorf("EXT-X-VERSION must be omitted:\n%s", m.String())
+	}
+}
+
+func TestPinVersion(t *testing.T) {
+	p, _ := NewMediaPlaylist(3, 3)
+	_ = p.Append("seg0.ts", 6, "")
+	p.PinVersion(3)
+	if err := p.CheckVersion(); err != nil {
+		t.Errorf("Unexpected error: %s", err)
+	}
+	_ = p.SetRange(100, 0)
+	if !strings.Contains(p.String(), "#EXT-X-VERSION:3\n") {
+		t.Errorf("Pinned version must be written:\n%s", p.String())
+	}
+	if err := p.CheckVersion(); err == nil {
+		t.Error("Expected error for byterange in pinned version 3")
+	}
+	p.PinVersion(0)
+	if err := p.CheckVersion(); err != nil {
+		t.Errorf("Unpinned version must not be checked: %s", err)
+	}
+	if !strings.Contains(p.String(), "#EXT-X-VERSION:4\n") {
+		t.Errorf("Expected version 4:\n%s", p.String())
+	}
+
+	m := NewMasterPlaylist()
+	m.Append("low.m3u8", nil, VariantParams{Bandwidth: 1000, Alternatives: []*Alternative{{Type: "CLOSED-CAPTIONS", GroupId: "cc", Name: "cc1", InstreamId: "SERVICE1"}}})
+	m.PinVersion(6)
+	if err := m.CheckVersion(); err == nil {
+		t.Error("Expected error for INSTREAM-ID SERVICE in pinned version 6")
+	}
+}
+
+func TestUsedFeatures(t *testing.T) {
+	p, err := NewMediaPlaylist(3, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.DurationAsInt(true)
+	if v := p.ComputeMinVersion(); v != 1 {
+		t.Errorf("expected version 1, got %d", v)
+	}
+	p.Append("a.ts", 10, "")
+	p.SetRange(100, 0)
+	p.SetMap("init.mp4", 0, 0)
+	features := p.UsedFeatures()
+	if len(features) != 2 || features[0] != FeatureByteRange || features[1] != FeatureMapWithoutIframes {
+		t.Errorf("unexpected features: %v", features)
+	}
+	if v := p.ComputeMinVersion(); v != 6 {
+		t.Errorf("expected version 6, got %d", v)
+	}
+	p.Iframe = true
+	if v := p.ComputeMinVersion(); v != 5 {
+		t.Errorf("expected version 5 for I-frame playlist, got %d", v)
+	}
+
+	m := NewMasterPlaylist()
+	m.Append("low.m3u8", nil, VariantParams{Alternatives: []*Alternative{{Type: "CLOSED-CAPTIONS", InstreamId: "SERVICE1"}}})
+	if features = m.UsedFeatures(); len(features) != 1 || features[0] != FeatureInstreamIDService || m.ComputeMinVersion() != 7 {
+		t.Errorf("unexpected features: %v", features)
+	}
+}
+
+func TestFeatureVersions(t *testing.T) {
+	all := Features()
+	if len(all) != len(featureVersions) {
+		t.Fatalf("expected %d features, got %d", len(featureVersions), len(all))
+	}
+	for i := 1; i < len(all); i++ {
+		if FeatureVersion(all[i-1]) > FeatureVersion(all[i]) {
+			t.Errorf("features are not ordered by version: %v", all)
+		}
+	}
+	if FeatureVersion(FeatureVariableSubstitution) != 8 || FeatureVersion("unknown") != 0 {
+		t.Error("unexpected feature versions")
+	}
+}
+
+// Playlists with EXT-X-MAP built with the library must pass
+// EncodeStrict and Validate.
+func TestEncodeStrictWithMap(t *testing.T) {
+	p, _ := NewMediaPlaylist(0, 3)
+	p.SetDefaultMap("init.mp4", 0, 0)
+	_ = p.Append("seg0.mp4", 6, "")
+	_ = p.SetMap("init2.mp4", 0, 0)
+	p.Close()
+	buf, err := p.EncodeStrict()
+	if err != nil || !strings.Contains(buf.String(), "#EXT-X-VERSION:6\n") {
+		t.Fatalf("Unexpected result: %v\n%v", err, buf)
+	}
+	for _, v := range p.Validate() {
+		if v.Rule == "version" {
+			t.Errorf("Unexpected violation: %s", v)
+		}
+	}
+
+	iframes, _ := NewMediaPlaylist(0, 3)
+	iframes.SetIframeOnly()
+	iframes.SetDefaultMap("init.mp4", 0, 0)
+	if v := iframes.Version(); v != 5 {
+		t.Errorf("Expected version 5 for I-frame playlist with map, got %d", v)
+	}
+}
+
+func TestEncodeStrict(t *testing.T) {
+	p, _ := NewMediaPlaylist(3, 3)
+	_ = p.Append("seg0.ts", 6.5, "")
+	buf, err := p.EncodeStrict()
+	if err != nil || !strings.Contains(buf.String(), "#EXT-X-VERSION:3\n") {
+		t.Fatalf("Unexpected result: %v\n%v", err, buf)
+	}
+	p.SetVersion(2)
+	if buf, err = p.EncodeStrict(); err == nil || buf != nil {
+		t.Error("Expected error for float durations in version 2")
+	}
+	p.SetVersion(3)
+	p.PinVersion(2)
+	if _, err = p.EncodeStrict(); err == nil {
+		t.Error("Expected error for float durations in pinned version 2")
+	}
+	p.OmitVersion(true)
+	if _, err = p.EncodeStrict(); err != nil {
+		t.Errorf("Playlist without version must not be checked: %s", err)
+	}
+
+	m := NewMasterPlaylist()
+	m.Append("low.m3u8", nil, VariantParams{Bandwidth: 1000, Alternatives: []*Alternative{{Type: "CLOSED-CAPTIONS", GroupId: "cc", Name: "cc1", InstreamId: "SERVICE1"}}})
+	if _, err = m.EncodeStrict(); err == nil {
+		t.Error("Expected error for INSTREAM-ID SERVICE in version 3")
+	}
+	m.SetVersion(7)
+	if _, err = m.EncodeStrict(); err != nil {
+		t.Errorf("Unexpected error: %s", err)
+	}
+}
+
+func TestReqVideoLayout(t *testing.T) {
+	m := NewMasterPlaylist()
+	m.Append("spatial.m3u8", nil, VariantParams{Bandwidth: 20000000, Codecs: "hvc1.2.20000000.L123.B0,mp4a.40.2", ReqVideoLayout: VideoLayoutStereo + "/" + VideoLayoutMono})
+	if features := m.UsedFeatures(); len(features) != 1 || features[0] != FeatureReqVideoLayout || m.ComputeMinVersion() != 12 {
+		t.Errorf("unexpected features: %v", features)
+	}
+	out := m.String()
+	for _, line := range []string{
+		"#EXT-X-VERSION:12",
+		`#EXT-X-STREAM-INF:BANDWIDTH=20000000,CODECS="hvc1.2.20000000.L123.B0,mp4a.40.2",REQ-VIDEO-LAYOUT="CH-STEREO/CH-MONO"`,
+	} {
+		if !strings.Contains(out, line+"\n") {
+			t.Errorf("expected %s in output:\n%s", line, out)
+		}
+	}
+	p := NewMasterPlaylist()
+	if err := p.DecodeFrom(strings.NewReader(out), true); err != nil {
+		t.Fatal(err)
+	}
+	if p.Variants[0].ReqVideoLayout != "CH-STEREO/CH-MONO" || p.Version() != 12 {
+		t.Errorf("unexpected decoded variant: %+v", p.Variants[0].VariantParams)
+	}
+}
+
+func TestAuditVersion(t *testing.T) {
+	p, err := NewMediaPlaylist(3, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.Append("a.ts", 10, "")
+	p.Append("b.ts", 10, "")
+	p.SetRange(100, 0)
+	p.SetKey("AES-128", "key", "0x1", "com.example", "1")
+	p.PinVersion(4)
+	audit := p.AuditVersion()
+	if audit.Required != 5 || audit.Declared != 4 {
+		t.Errorf("unexpected versions: %+v", audit)
+	}
+	if audit.Check() == nil {
+		t.Error("expected error for declared version lower than required")
+	}
+	forcing := audit.Forcing()
+	if len(forcing) != 1 || forcing[0].Feature != FeatureKeyFormat || forcing[0].Item != "segment 1" {
+		t.Errorf("unexpected forcing requirements: %+v", forcing)
+	}
+	expected := `required version 5, declared 4
+version 3: floating-point EXTINF durations (EXTINF)
+version 2: IV attribute of EXT-X-KEY (segment 1)
+version 5: KEYFORMAT and KEYFORMATVERSIONS attributes of EXT-X-KEY (segment 1)
+version 4: EXT-X-BYTERANGE (segment 1)
+`
+	if s := audit.String(); s != expected {
+		t.Errorf("unexpected report:\n%s", s)
+	}
+
+	m := NewMasterPlaylist()
+	m.AppendDefine(&Define{Name: "token", Type: DefineQueryParam})
+	m.OmitVersion(true)
+	audit = m.AuditVersion()
+	if audit.Required != 11 || audit.Declared != 0 || audit.Check() != nil {
+		t.Errorf("unexpected master audit: %+v", audit)
+	}
+	if forcing = audit.Forcing(); len(forcing) != 1 || forcing[0].Item != `EXT-X-DEFINE "token"` {
+		t.Errorf("unexpected forcing requirements: %+v", forcing)
+	}
+}
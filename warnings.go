@@ -0,0 +1,105 @@
+package m3u8
+
+/*
+ Part of M3U8 parser & generator library.
+ This file defines warnings of lenient decoding.
+
+ Copyright 2013-2019 The Project Developers.
+ See the AUTHORS and LICENSE files at the top-level directory of this distribution
+ and at https://github.com/grafov/m3u8/
+
+ ॐ तारे तुत्तारे तुरे स्व
+*/
+
+import (
+	"fmt"
+	"io"
+)
+
+// Warning is a recoverable violation of the specification found by
+// the lenient decoder (e.g. a malformed attribute value, an invalid
+// date or a missing required attribute). The strict decoder returns
+// the same violation as ParseError.
+type Warning struct {
+	Line int    // number of the line, zero for the whole playlist
+	Tag  string // name of the tag of the line or "URI"
+	Err  error
+}
+
+func (w Warning) Error() string {
+	if w.Line == 0 {
+		return w.Err.Error()
+	}
+	return fmt.Sprintf("line %d: %s: %s", w.Line, w.Tag, w.Err)
+}
+
+// DecodeWithWarnings detects type of playlist and decodes it in
+// lenient mode accordingly with the rest of options. It returns
+// violations of the specification which the strict decoder would
+// fail on along with the playlist. The error is returned for failures
+// which don't allow decoding of the playlist, such as read errors,
+// exceeded limits or unknown type of the playlist.
+func DecodeWithWarnings(reader io.Reader, opts DecodeOptions) (Playlist, ListType, []Warning, error) {
+	var warnings []Warning
+	opts.Strict = false
+	onWarning := opts.OnWarning
+	opts.OnWarning = func(w Warning) {
+		warnings = append(warnings, w)
+		if onWarning != nil {
+			onWarning(w)
+		}
+	}
+	p, listType, err := DecodeWithOptions(reader, opts)
+	return p, listType, warnings, err
+}
+
+// violation handles the error of the decoded line. It returns true if
+// the decoder must stop with the error (strict mode), otherwise the
+// error is reported as the warning.
+func (s *decodingState) violation(err error, strict bool) bool {
+	if err == nil {
+		return false
+	}
+	if strict {
+		return true
+	}
+	s.warn(err)
+	return false
+}
+
+// warn reports the error of the current line as the warning once.
+func (s *decodingState) warn(err error) {
+	if s.onWarning == nil || err == nil {
+		return
+	}
+	if s.warned == s.lineNo && s.lastWarning == err.Error() {
+		return
+	}
+	s.warned, s.lastWarning = s.lineNo, err.Error()
+	w := Warning{Line: s.lineNo, Tag: s.tag, Err: err}
+	if s.lineNo == 0 {
+		w.Tag = ""
+	}
+	s.onWarning(w)
+}
+
+// beginLine prepares reporting of warnings for the line.
+func (s *decodingState) beginLine(line string) {
+	s.lineNo++
+	if s.onWarning != nil {
+		s.tag = lineTag(line)
+	}
+}
+
+// checkHeader checks #EXTM3U of the decoded playlist.
+func (s *decodingState) checkHeader(strict bool) error {
+	if s.m3u {
+		return nil
+	}
+	if strict {
+		return ErrNoHeader
+	}
+	s.lineNo = 0
+	s.warn(ErrNoHeader)
+	return nil
+}
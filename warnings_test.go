@@ -0,0 +1,67 @@
+/*
+Lenient decoding warnings tests.
+
+Copyright 2013-2019 The Project Developers.
+See the AUTHORS and LICENSE files at the top-level directory of this distribution
+and at https://github.com/grafov/m3u8/
+
+ॐ तारे तुत्तारे तुरे स्व
+*/
+package m3u8
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDecodeWithWarnings(t *testing.T) {
+	const src = `#EXTM3U
+#EXT-X-TARGETDURATION:6
+#EXT-X-ALLOW-CACHE:MAYBE
+#EXT-X-PROGRAM-DATE-TIME:yesterday
+#EXTINF:5,
+a.ts
+#EXT-X-DATERANGE:ID="ad",START-DATE="2020-01-01T00:00:00Z",BOGUS=1
+#EXTINF:abc,
+b.ts
+#EXTINF:5,
+c.ts
+`
+	p, listType, warnings, err := DecodeWithWarnings(strings.NewReader(src), DecodeOptions{Strict: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if listType != MEDIA || p.(*MediaPlaylist).Count() != 3 {
+		t.Fatalf("unexpected playlist: %v", p)
+	}
+	var lines []int
+	for _, w := range warnings {
+		lines = append(lines, w.Line)
+	}
+	if len(lines) != 4 || lines[0] != 3 || lines[1] != 4 || lines[2] != 7 || lines[3] != 8 {
+		t.Fatalf("unexpected warnings: %v", warnings)
+	}
+	if w := warnings[1]; w.Tag != "#EXT-X-PROGRAM-DATE-TIME" || !strings.HasPrefix(w.Error(), "line 4: #EXT-X-PROGRAM-DATE-TIME: ") {
+		t.Errorf("unexpected warning: %v", w)
+	}
+
+	// the strict decoder fails on the first warning
+	_, _, err = DecodeFrom(strings.NewReader(src), true)
+	if perr, ok := err.(*ParseError); !ok || perr.Line != 3 || perr.Err.Error() != warnings[0].Err.Error() {
+		t.Errorf("expected %v, got %v", warnings[0], err)
+	}
+}
+
+func TestDecodeWithWarningsHeader(t *testing.T) {
+	var warnings []Warning
+	p := NewMasterPlaylist()
+	err := p.DecodeWithOptions(strings.NewReader("#EXT-X-STREAM-INF:BANDWIDTH=1000\nlow.m3u8\n"), DecodeOptions{
+		OnWarning: func(w Warning) { warnings = append(warnings, w) },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(warnings) != 1 || warnings[0].Line != 0 || warnings[0].Error() != "#EXTM3U absent" {
+		t.Errorf("unexpected warnings: %v", warnings)
+	}
+}
@@ -0,0 +1,83 @@
+package m3u8
+
+/*
+ Part of M3U8 parser & generator library.
+ This file defines per-session variation of segment URIs for
+ forensic watermarking.
+
+ Copyright 2013-2019 The Project Developers.
+ See the AUTHORS and LICENSE files at the top-level directory of this distribution
+ and at https://github.com/grafov/m3u8/
+
+ ॐ तारे तुत्तारे तुरे स्व
+*/
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+)
+
+// ABPattern is a pattern of A/B variants of segments used for
+// forensic (A/B) watermarking: each segment is prepared in two
+// differently watermarked variants and each session gets its own
+// sequence of them. Elements are variant numbers 0 (A) or 1 (B).
+type ABPattern []uint8
+
+// NewABPattern derives the pattern of n variants from the session ID.
+// The pattern is HMAC-SHA256 of the session ID keyed by the secret,
+// so it is the same for the same session and can't be predicted
+// without the secret.
+func NewABPattern(secret []byte, sessionID string, n int) ABPattern {
+	pattern := make(ABPattern, 0, n)
+	var counter [8]byte
+	for block := uint64(0); len(pattern) < n; block++ {
+		mac := hmac.New(sha256.New, secret)
+		binary.BigEndian.PutUint64(counter[:], block)
+		mac.Write(counter[:])
+		mac.Write([]byte(sessionID))
+		for _, b := range mac.Sum(nil) {
+			for bit := uint(0); bit < 8 && len(pattern) < n; bit++ {
+				pattern = append(pattern, b>>(7-bit)&1)
+			}
+		}
+	}
+	return pattern
+}
+
+// Variant returns the variant of the segment with the sequence ID.
+// The pattern repeats for sequence IDs beyond its length. Empty
+// pattern always gives variant 0.
+func (ab ABPattern) Variant(seqID uint64) uint8 {
+	if len(ab) == 0 {
+		return 0
+	}
+	return ab[seqID%uint64(len(ab))]
+}
+
+// String returns the pattern as a string of A and B letters.
+func (ab ABPattern) String() string {
+	s := make([]byte, len(ab))
+	for i, v := range ab {
+		s[i] = 'A' + v
+	}
+	return string(s)
+}
+
+// ABTransform returns the transform which replaces URIs of segments
+// with the result of uri called with the URI of the segment and its
+// variant in the pattern. Pass it to EncodeWith to encode the playlist
+// for a session:
+//
+//	pattern := m3u8.NewABPattern(secret, sessionID, 64)
+//	buf := p.EncodeWith(m3u8.ABTransform(pattern, func(uri string, variant uint8) string {
+//		return "ab" + string('a'+variant) + "/" + uri
+//	}))
+func ABTransform(pattern ABPattern, uri func(uri string, variant uint8) string) Transform {
+	return func(p *MediaPlaylist) *MediaPlaylist {
+		p.eachSegment(func(seg *MediaSegment) {
+			seg.URI = uri(seg.URI, pattern.Variant(seg.SeqId))
+		})
+		return p
+	}
+}
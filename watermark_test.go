@@ -0,0 +1,75 @@
+/*
+Forensic watermarking tests.
+
+Copyright 2013-2019 The Project Developers.
+See the AUTHORS and LICENSE files at the top-level directory of this distribution
+and at https://github.com/grafov/m3u8/
+
+ॐ तारे तुत्तारे तुरे स्व
+*/
+package m3u8
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestNewABPattern(t *testing.T) {
+	secret := []byte("secret")
+	a := NewABPattern(secret, "session-1", 300)
+	if len(a) != 300 {
+		t.Fatalf("expected 300 variants, got %d", len(a))
+	}
+	if a.String() != NewABPattern(secret, "session-1", 300).String() {
+		t.Error("pattern must be deterministic")
+	}
+	if a.String() == NewABPattern(secret, "session-2", 300).String() {
+		t.Error("patterns of different sessions must differ")
+	}
+	if a.String() == NewABPattern([]byte("other"), "session-1", 300).String() {
+		t.Error("patterns with different secrets must differ")
+	}
+	if !strings.HasPrefix(a.String(), NewABPattern(secret, "session-1", 10).String()) {
+		t.Error("shorter pattern must be a prefix of the longer one")
+	}
+	var b int
+	for i, v := range a {
+		if v > 1 {
+			t.Fatalf("variant %d at %d", v, i)
+		}
+		b += int(v)
+	}
+	if b == 0 || b == len(a) {
+		t.Error("pattern must mix variants")
+	}
+	if a.Variant(300) != a[0] || a.Variant(301) != a[1] {
+		t.Error("pattern must repeat")
+	}
+	if ABPattern(nil).Variant(5) != 0 {
+		t.Error("empty pattern must give variant 0")
+	}
+}
+
+func TestABTransform(t *testing.T) {
+	p, e := NewMediaPlaylist(0, 4)
+	if e != nil {
+		t.Fatalf("Create media playlist failed: %s", e)
+	}
+	for i := 0; i < 4; i++ {
+		p.Append(fmt.Sprintf("s%d.ts", i), 4, "")
+	}
+	plain := p.String()
+	pattern := ABPattern{0, 1, 1, 0}
+	out := p.EncodeWith(ABTransform(pattern, func(uri string, variant uint8) string {
+		return string('a'+variant) + "/" + uri
+	})).String()
+	for _, expected := range []string{"\na/s0.ts\n", "\nb/s1.ts\n", "\nb/s2.ts\n", "\na/s3.ts\n"} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("expected %q in:\n%s", expected, out)
+		}
+	}
+	if p.String() != plain || p.At(1).URI != "s1.ts" {
+		t.Error("the playlist must not be changed by the session encoding")
+	}
+}
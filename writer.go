@@ -64,6 +64,9 @@ func (p *MasterPlaylist) Append(uri string, chunklist *MediaPlaylist, params Var
 		version(&p.ver, 4) // so it is optional and in theory may be set to ver.1
 		// but more tests required
 	}
+	if v.ReqVideoLayout != "" {
+		version(&p.ver, featureVersions[FeatureReqVideoLayout])
+	}
 	p.buf.Reset()
 }
 
@@ -77,24 +80,31 @@ func (p *MasterPlaylist) Encode() *bytes.Buffer {
 	if p.buf.Len() > 0 {
 		return &p.buf
 	}
+	defer reportEncoded(MASTER, len(p.Variants), time.Now())
+	p.buf.Grow(p.encodedSize())
+	p.encode(&p.buf)
+	return &p.buf
+}
 
-	p.buf.WriteString("#EXTM3U\n#EXT-X-VERSION:")
-	p.buf.WriteString(strver(p.ver))
-	p.buf.WriteRune('\n')
+// encode writes the playlist to the buffer.
+func (p *MasterPlaylist) encode(buf *bytes.Buffer) {
+	buf.WriteString("#EXTM3U\n")
+	writeCustomTags(buf, p.Custom, CustomTagBeforeHeader)
+	writeVersion(buf, p.ver, p.pinnedVer, p.omitVer)
 
 	if p.IndependentSegments() {
-		p.buf.WriteString("#EXT-X-INDEPENDENT-SEGMENTS\n")
+		buf.WriteString("#EXT-X-INDEPENDENT-SEGMENTS\n")
+	}
+	if p.StartTime != 0 || p.startSet {
+		writeStart(buf, p.StartTime, p.StartTimePrecise)
+	}
+	writeDefines(buf, p.Defines)
+	if p.ContentSteering != nil {
+		writeContentSteering(buf, p.ContentSteering)
 	}
 
 	// Write any custom master tags
-	if p.Custom != nil {
-		for _, v := range p.Custom {
-			if customBuf := v.Encode(); customBuf != nil {
-				p.buf.WriteString(customBuf.String())
-				p.buf.WriteRune('\n')
-			}
-		}
-	}
+	writeCustomTags(buf, p.Custom, CustomTagDefault)
 
 	var languageWritten = make(map[string]bool)
 	if p.SessionData != nil {
@@ -108,227 +118,102 @@ func (p *MasterPlaylist) Encode() *bytes.Buffer {
 				languageWritten[languageWrittenKey] = true
 			}
 
-			p.buf.WriteString("#EXT-X-SESSION-DATA:")
-			p.buf.WriteString("DATA-ID=\"")
-			p.buf.WriteString(sessionData.DataID)
-			p.buf.WriteRune('"')
-			if sessionData.Value != "" {
-				p.buf.WriteString(",VALUE=\"")
-				p.buf.WriteString(sessionData.Value)
-				p.buf.WriteRune('"')
-			}
-			// Each EXT-X-SESSION-DATA tag MUST contain either a VALUE or URI attribute, but not both.
-			// In case both are present, default to writing only the VALUE attribute.
-			if sessionData.URI != "" && sessionData.Value == "" {
-				p.buf.WriteString(",URI=\"")
-				p.buf.WriteString(sessionData.URI)
-				p.buf.WriteRune('"')
-			}
-			if sessionData.Language != "" {
-				p.buf.WriteString(",LANGUAGE=\"")
-				p.buf.WriteString(sessionData.Language)
-				p.buf.WriteRune('"')
-			}
-			p.buf.WriteRune('\n')
+			writeSessionData(buf, sessionData, p.attrOrder[sessionData])
 		}
 	}
+	writeUnknownTags(buf, p.UnknownTags)
+	writeCustomTags(buf, p.Custom, CustomTagBeforeSegments)
 
 	var altsWritten = make(map[string]bool)
+	// unwritten filters out alternatives already written so we only
+	// write out an alternative once
+	unwritten := func(alts []*Alternative) []*Alternative {
+		var out []*Alternative
+		for _, alt := range alts {
+			altKey := fmt.Sprintf("%s-%s-%s-%s", alt.Type, alt.GroupId, alt.Name, alt.Language)
+			if altsWritten[altKey] {
+				continue
+			}
+			altsWritten[altKey] = true
+			out = append(out, alt)
+		}
+		return out
+	}
 
-	for _, pl := range p.Variants {
-		if pl.Alternatives != nil {
-			for _, alt := range pl.Alternatives {
-				// Make sure that we only write out an alternative once
-				altKey := fmt.Sprintf("%s-%s-%s-%s", alt.Type, alt.GroupId, alt.Name, alt.Language)
-				if altsWritten[altKey] {
-					continue
-				}
-				altsWritten[altKey] = true
+	if p.altPlacement != AlternativesInterleaved {
+		var alts []*Alternative
+		for _, pl := range p.Variants {
+			alts = append(alts, unwritten(pl.Alternatives)...)
+		}
+		if p.altPlacement == AlternativesGrouped {
+			alts = groupAlternatives(alts)
+		}
+		for _, alt := range alts {
+			writeAlternative(buf, alt, p.attrOrder[alt])
+		}
+	}
 
-				p.buf.WriteString("#EXT-X-MEDIA:")
-				if alt.Type != "" {
-					p.buf.WriteString("TYPE=") // Type should not be quoted
-					p.buf.WriteString(alt.Type)
-				}
-				if alt.GroupId != "" {
-					p.buf.WriteString(",GROUP-ID=\"")
-					p.buf.WriteString(alt.GroupId)
-					p.buf.WriteRune('"')
-				}
-				if alt.Name != "" {
-					p.buf.WriteString(",NAME=\"")
-					p.buf.WriteString(alt.Name)
-					p.buf.WriteRune('"')
-				}
-				p.buf.WriteString(",DEFAULT=")
-				if alt.Default {
-					p.buf.WriteString("YES")
-				} else {
-					p.buf.WriteString("NO")
-				}
-				if alt.Autoselect != "" {
-					p.buf.WriteString(",AUTOSELECT=")
-					p.buf.WriteString(alt.Autoselect)
-				}
-				if alt.Language != "" {
-					p.buf.WriteString(",LANGUAGE=\"")
-					p.buf.WriteString(alt.Language)
-					p.buf.WriteRune('"')
-				}
-				if alt.Forced != "" {
-					p.buf.WriteString(",FORCED=")
-					p.buf.WriteString(alt.Forced)
-				}
-				if alt.Characteristics != "" {
-					p.buf.WriteString(",CHARACTERISTICS=\"")
-					p.buf.WriteString(alt.Characteristics)
-					p.buf.WriteRune('"')
-				}
-				if alt.Subtitles != "" {
-					p.buf.WriteString(",SUBTITLES=\"")
-					p.buf.WriteString(alt.Subtitles)
-					p.buf.WriteRune('"')
-				}
-				if alt.URI != "" {
-					p.buf.WriteString(",URI=\"")
-					p.buf.WriteString(alt.URI)
-					p.buf.WriteRune('"')
-				}
-				if alt.InstreamId != "" {
-					p.buf.WriteString(",INSTREAM-ID=\"")
-					p.buf.WriteString(alt.InstreamId)
-					p.buf.WriteRune('"')
-				}
-				if alt.Channels != "" {
-					p.buf.WriteString(",CHANNELS=\"")
-					p.buf.WriteString(alt.Channels)
-					p.buf.WriteRune('"')
-				}
-				p.buf.WriteRune('\n')
-			}
+	for _, pl := range p.Variants {
+		for _, alt := range unwritten(pl.Alternatives) {
+			writeAlternative(buf, alt, p.attrOrder[alt])
 		}
-		if pl.Iframe {
-			p.buf.WriteString("#EXT-X-I-FRAME-STREAM-INF:PROGRAM-ID=")
-			p.buf.WriteString(strconv.FormatUint(uint64(pl.ProgramId), 10))
-			p.buf.WriteString(",BANDWIDTH=")
-			p.buf.WriteString(strconv.FormatUint(uint64(pl.Bandwidth), 10))
-			if pl.AverageBandwidth != 0 {
-				p.buf.WriteString(",AVERAGE-BANDWIDTH=")
-				p.buf.WriteString(strconv.FormatUint(uint64(pl.AverageBandwidth), 10))
-			}
-			if pl.Codecs != "" {
-				p.buf.WriteString(",CODECS=\"")
-				p.buf.WriteString(pl.Codecs)
-				p.buf.WriteRune('"')
-			}
-			if pl.Resolution != "" {
-				p.buf.WriteString(",RESOLUTION=") // Resolution should not be quoted
-				p.buf.WriteString(pl.Resolution)
-			}
-			if pl.Video != "" {
-				p.buf.WriteString(",VIDEO=\"")
-				p.buf.WriteString(pl.Video)
-				p.buf.WriteRune('"')
-			}
-			if pl.VideoRange != "" {
-				p.buf.WriteString(",VIDEO-RANGE=")
-				p.buf.WriteString(pl.VideoRange)
-			}
-			if pl.HDCPLevel != "" {
-				p.buf.WriteString(",HDCP-LEVEL=")
-				p.buf.WriteString(pl.HDCPLevel)
-			}
-			if pl.URI != "" {
-				p.buf.WriteString(",URI=\"")
-				p.buf.WriteString(pl.URI)
-				p.buf.WriteRune('"')
-			}
-			p.buf.WriteRune('\n')
-		} else {
-			p.buf.WriteString("#EXT-X-STREAM-INF:PROGRAM-ID=")
-			p.buf.WriteString(strconv.FormatUint(uint64(pl.ProgramId), 10))
-			p.buf.WriteString(",BANDWIDTH=")
-			p.buf.WriteString(strconv.FormatUint(uint64(pl.Bandwidth), 10))
-			if pl.AverageBandwidth != 0 {
-				p.buf.WriteString(",AVERAGE-BANDWIDTH=")
-				p.buf.WriteString(strconv.FormatUint(uint64(pl.AverageBandwidth), 10))
-			}
-			if pl.Codecs != "" {
-				p.buf.WriteString(",CODECS=\"")
-				p.buf.WriteString(pl.Codecs)
-				p.buf.WriteRune('"')
-			}
-			if pl.Resolution != "" {
-				p.buf.WriteString(",RESOLUTION=") // Resolution should not be quoted
-				p.buf.WriteString(pl.Resolution)
-			}
-			if pl.Audio != "" {
-				p.buf.WriteString(",AUDIO=\"")
-				p.buf.WriteString(pl.Audio)
-				p.buf.WriteRune('"')
-			}
-			if pl.Video != "" {
-				p.buf.WriteString(",VIDEO=\"")
-				p.buf.WriteString(pl.Video)
-				p.buf.WriteRune('"')
-			}
-			if pl.Captions != "" {
-				p.buf.WriteString(",CLOSED-CAPTIONS=")
-				if pl.Captions == "NONE" {
-					p.buf.WriteString(pl.Captions) // CC should not be quoted when eq NONE
-				} else {
-					p.buf.WriteRune('"')
-					p.buf.WriteString(pl.Captions)
-					p.buf.WriteRune('"')
-				}
-			}
-			if pl.Subtitles != "" {
-				p.buf.WriteString(",SUBTITLES=\"")
-				p.buf.WriteString(pl.Subtitles)
-				p.buf.WriteRune('"')
-			}
-			if pl.Name != "" {
-				p.buf.WriteString(",NAME=\"")
-				p.buf.WriteString(pl.Name)
-				p.buf.WriteRune('"')
-			}
-			if pl.FrameRate != 0 {
-				p.buf.WriteString(",FRAME-RATE=")
-				p.buf.WriteString(strconv.FormatFloat(pl.FrameRate, 'f', 3, 64))
-			}
-			if pl.VideoRange != "" {
-				p.buf.WriteString(",VIDEO-RANGE=")
-				p.buf.WriteString(pl.VideoRange)
-			}
-			if pl.HDCPLevel != "" {
-				p.buf.WriteString(",HDCP-LEVEL=")
-				p.buf.WriteString(pl.HDCPLevel)
-			}
+		writeUnknownTags(buf, pl.UnknownTags)
+		writeVariant(buf, pl, p.Args, p.attrOrder[pl])
+	}
+	writeCustomTags(buf, p.Custom, CustomTagAfterSegments)
+	writeUnknownTags(buf, p.TrailingUnknownTags)
+	p.source.restore(buf)
+}
 
-			p.buf.WriteRune('\n')
-			p.buf.WriteString(pl.URI)
-			if p.Args != "" {
-				if strings.Contains(pl.URI, "?") {
-					p.buf.WriteRune('&')
-				} else {
-					p.buf.WriteRune('?')
-				}
-				p.buf.WriteString(p.Args)
-			}
-			p.buf.WriteRune('\n')
+// SetAlternativesPlacement sets the placement of EXT-X-MEDIA tags in
+// the encoded playlist. This operation does reset playlist cache.
+func (p *MasterPlaylist) SetAlternativesPlacement(placement AlternativesPlacement) {
+	p.altPlacement = placement
+	p.buf.Reset()
+}
+
+// groupAlternatives orders alternatives by groups (TYPE and GROUP-ID)
+// in order of the first appearance of each group keeping order of
+// alternatives inside the group.
+func groupAlternatives(alts []*Alternative) []*Alternative {
+	var groups []string
+	byGroup := make(map[string][]*Alternative)
+	for _, alt := range alts {
+		key := alt.Type + "-" + alt.GroupId
+		if _, ok := byGroup[key]; !ok {
+			groups = append(groups, key)
 		}
+		byGroup[key] = append(byGroup[key], alt)
 	}
+	out := make([]*Alternative, 0, len(alts))
+	for _, key := range groups {
+		out = append(out, byGroup[key]...)
+	}
+	return out
+}
 
-	return &p.buf
+// SetStartTime sets TIME-OFFSET and PRECISE attributes of EXT-X-START
+// tag of the master playlist, the preferred point to start playing
+// any variant. Negative offset is counted from the end of the
+// playlist. Zero offset is written only when explicitly set or decoded
+// from a playlist. This operation does reset playlist cache.
+func (p *MasterPlaylist) SetStartTime(offset float64, precise bool) {
+	p.StartTime = offset
+	p.StartTimePrecise = precise
+	p.startSet = true
+	p.buf.Reset()
 }
 
-// SetCustomTag sets the provided tag on the master playlist for its TagName
+// SetCustomTag sets the provided tag on the master playlist for its
+// TagName replacing the tags with the same name, see CustomTags.Set.
 func (p *MasterPlaylist) SetCustomTag(tag CustomTag) {
-	if p.Custom == nil {
-		p.Custom = make(map[string]CustomTag)
-	}
+	p.Custom.Set(tag)
+}
 
-	p.Custom[tag.TagName()] = tag
+// AddCustomTag appends the provided tag to the custom tags of the
+// master playlist, tags with the same name are kept.
+func (p *MasterPlaylist) AddCustomTag(tag CustomTag) {
+	p.Custom.Add(tag)
 }
 
 // Version returns the current playlist version number
@@ -363,11 +248,15 @@ func (p *MasterPlaylist) String() string {
 
 // NewMediaPlaylist creates a new media playlist structure. Winsize
 // defines how much items will displayed on playlist generation.
-// Capacity is total size of a playlist.
+// Capacity is total size of a playlist. Zero capacity makes the
+// playlist unbounded, its capacity grows on demand so AppendSegment
+// never returns ErrPlaylistFull, e.g. for VOD playlists with the
+// number of segments unknown up front.
 func NewMediaPlaylist(winsize uint, capacity uint) (*MediaPlaylist, error) {
 	p := new(MediaPlaylist)
 	p.ver = minver
 	p.capacity = capacity
+	p.unbounded = capacity == 0
 	if err := p.SetWinSize(winsize); err != nil {
 		return nil, err
 	}
@@ -456,6 +345,23 @@ func (p *MediaPlaylist) Remove() (err error) {
 	if p.count == 0 {
 		return errors.New("playlist is empty")
 	}
+	if seg := p.Segments[p.head]; seg != nil {
+		// Section 6.2.2: EXT-X-DISCONTINUITY-SEQUENCE must be incremented
+		// when a segment with EXT-X-DISCONTINUITY removed from the playlist.
+		if seg.Discontinuity && !p.Closed && !p.manualDSeq {
+			p.DiscontinuitySeq++
+		}
+		p.trackRemovedDateRanges(seg)
+		delete(p.durationText, seg)
+		p.forgetAttrOrder(seg)
+		if p.onEvict != nil {
+			p.onEvict(seg)
+		}
+	}
+	if p.pool != nil {
+		p.release(p.Segments[p.head])
+		p.Segments[p.head] = nil
+	}
 	p.head = (p.head + 1) % p.capacity
 	p.count--
 	if !p.Closed {
@@ -465,36 +371,213 @@ func (p *MediaPlaylist) Remove() (err error) {
 	return nil
 }
 
+// RemoveAt removes the i-th segment of the playlist counting from the
+// oldest one, e.g. to drop a corrupted segment from the middle of the
+// live window. Removal of the oldest segment is the same as Remove,
+// sequence IDs of the rest segments are preserved. Otherwise the
+// following segments are renumbered as media sequence numbers of the
+// playlist are consecutive, and the segment next to the removed one
+// takes its EXT-X-DISCONTINUITY, dateranges, key and map (when it has
+// own ones they are kept) and gets program date time continuing the
+// removed one. This operation does reset playlist cache.
+func (p *MediaPlaylist) RemoveAt(i uint) error {
+	if i >= p.count {
+		return errors.New("segment index out of range")
+	}
+	if i == 0 {
+		return p.Remove()
+	}
+	seg := p.At(i)
+	if next := p.At(i + 1); next != nil {
+		next.Discontinuity = next.Discontinuity || seg.Discontinuity
+		if len(seg.DateRange) > 0 {
+			next.DateRange = append(append([]*DateRange(nil), seg.DateRange...), next.DateRange...)
+		}
+		if next.Key == nil {
+			next.Key = seg.Key
+		}
+		if next.Map == nil {
+			next.Map = seg.Map
+		}
+		if next.ProgramDateTime.IsZero() && !seg.ProgramDateTime.IsZero() {
+			next.ProgramDateTime = seg.ProgramDateTime.Add(seconds(seg.Duration))
+		}
+	}
+	for ; i+1 < p.count; i++ {
+		moved := p.Segments[(p.head+i+1)%p.capacity]
+		moved.SeqId--
+		p.Segments[(p.head+i)%p.capacity] = moved
+	}
+	p.tail = p.last()
+	p.Segments[p.tail] = nil
+	p.count--
+	delete(p.durationText, seg)
+	p.forgetAttrOrder(seg)
+	if p.onEvict != nil {
+		p.onEvict(seg)
+	}
+	p.release(seg)
+	p.buf.Reset()
+	return nil
+}
+
+// forgetAttrOrder drops the recorded source order of attributes of
+// the key, the map and the dateranges of the removed segment unless
+// they are still used by the playlist.
+func (p *MediaPlaylist) forgetAttrOrder(seg *MediaSegment) {
+	if len(p.attrOrder) == 0 {
+		return
+	}
+	var values []interface{}
+	add := func(v interface{}) {
+		if _, ok := p.attrOrder[v]; ok {
+			values = append(values, v)
+		}
+	}
+	if seg.Key != nil && seg.Key != p.Key {
+		add(seg.Key)
+	}
+	if seg.Map != nil && seg.Map != p.Map {
+		add(seg.Map)
+	}
+	for _, dr := range seg.DateRange {
+		add(dr)
+	}
+	if len(values) == 0 {
+		return
+	}
+	used := func(v interface{}) {
+		for i := range values {
+			if values[i] == v {
+				values[i] = nil
+			}
+		}
+	}
+	p.eachSegment(func(s *MediaSegment) {
+		if s == seg {
+			return
+		}
+		used(s.Key)
+		used(s.Map)
+		for _, dr := range s.DateRange {
+			used(dr)
+		}
+	})
+	for _, v := range values {
+		if v != nil {
+			delete(p.attrOrder, v)
+		}
+	}
+}
+
+// release returns the removed segment to the segment pool unless the
+// evict callback is set as the callback may keep the segment.
+func (p *MediaPlaylist) release(seg *MediaSegment) {
+	if p.pool != nil && p.onEvict == nil {
+		p.pool.Put(seg)
+	}
+}
+
+// RemoveBySeqId removes the segment with the media sequence number
+// seqID, see RemoveAt. This operation does reset playlist cache.
+func (p *MediaPlaylist) RemoveBySeqId(seqID uint64) error {
+	for i := uint(0); i < p.count; i++ {
+		if p.At(i).SeqId == seqID {
+			return p.RemoveAt(i)
+		}
+	}
+	return fmt.Errorf("no segment with sequence ID %d", seqID)
+}
+
 // Append general chunk to the tail of chunk slice for a media playlist.
 // This operation does reset playlist cache.
 func (p *MediaPlaylist) Append(uri string, duration float64, title string) error {
-	seg := new(MediaSegment)
+	seg := p.newSegment()
 	seg.URI = uri
 	seg.Duration = duration
 	seg.Title = title
-	return p.AppendSegment(seg)
+	if err := p.AppendSegment(seg); err != nil {
+		if p.pool != nil {
+			p.pool.Put(seg)
+		}
+		return err
+	}
+	return nil
 }
 
 // AppendSegment appends a MediaSegment to the tail of chunk slice for
-// a media playlist.  This operation does reset playlist cache.
+// a media playlist. Partial segments appended with AppendPartial
+// become parts of the segment unless it has own parts. This operation
+// does reset playlist cache unless the segment is encoded
+// incrementally, see SetIncrementalEncoding.
 func (p *MediaPlaylist) AppendSegment(seg *MediaSegment) error {
+	target, partTarget := p.TargetDuration, p.PartTargetDuration
+	if p.unbounded && p.count == p.capacity {
+		p.grow()
+	}
+	if p.head == p.tail && p.count > 0 && p.retention != nil && !p.Closed {
+		p.Remove()
+	}
 	if p.head == p.tail && p.count > 0 {
-		return ErrPlaylistFull
+		if p.onFull == nil {
+			return ErrPlaylistFull
+		}
+		if err := p.onFull(p, seg); err != nil {
+			return err
+		}
+		if p.head == p.tail && p.count > 0 {
+			return ErrPlaylistFull
+		}
 	}
 	seg.SeqId = p.SeqNo
+	if p.Skip != nil {
+		seg.SeqId += p.Skip.SkippedSegments
+	}
+	var prev *MediaSegment
 	if p.count > 0 {
-		seg.SeqId = p.Segments[(p.capacity+p.tail-1)%p.capacity].SeqId + 1
+		prev = p.Segments[(p.capacity+p.tail-1)%p.capacity]
+		seg.SeqId = prev.SeqId + 1
+	}
+	if p.pdtEvery > 0 {
+		p.propagateProgramDateTime(seg, prev)
 	}
 	p.Segments[p.tail] = seg
 	p.tail = (p.tail + 1) % p.capacity
 	p.count++
-	if p.TargetDuration < seg.Duration {
-		p.TargetDuration = math.Ceil(seg.Duration)
+	if len(seg.Partials) == 0 && len(p.PendingPartials) > 0 {
+		seg.Partials = p.PendingPartials
+		p.PendingPartials = nil
 	}
-	p.buf.Reset()
+	for _, part := range seg.Partials {
+		p.fitPartTarget(part)
+	}
+	p.fitTargetDuration(seg.Duration)
+	// the cached output is kept for incremental encoding of the
+	// segment (see Encode) unless the header changes
+	if p.enc == nil || p.TargetDuration != target || p.PartTargetDuration != partTarget {
+		p.buf.Reset()
+	}
+	p.retain()
 	return nil
 }
 
+// grow doubles the capacity of the playlist keeping its segments in
+// order.
+func (p *MediaPlaylist) grow() {
+	capacity := 2 * p.capacity
+	if capacity == 0 {
+		capacity = 8
+	}
+	segs := make([]*MediaSegment, capacity)
+	for i := uint(0); i < p.count; i++ {
+		segs[i] = p.Segments[(p.head+i)%p.capacity]
+	}
+	p.Segments = segs
+	p.capacity = capacity
+	p.head = 0
+	p.tail = p.count
+}
+
 // Slide combines two operations: firstly it removes one chunk from
 // the head of chunk slice and move pointer to next chunk. Secondly it
 // appends one chunk to the tail of chunk slice. Useful for sliding
@@ -506,6 +589,16 @@ func (p *MediaPlaylist) Slide(uri string, duration float64, title string) {
 	p.Append(uri, duration, title)
 }
 
+// SlideSegment is the same as Slide but appends the complete media
+// segment so keys, program date time, SCTE cues, byte ranges etc. of
+// the segment are kept. This operation does reset cache.
+func (p *MediaPlaylist) SlideSegment(seg *MediaSegment) error {
+	if !p.Closed && p.count >= p.winsize {
+		p.Remove()
+	}
+	return p.AppendSegment(seg)
+}
+
 // ResetCache resets playlist cache. Next called Encode() will
 // regenerate playlist from the chunk slice.
 func (p *MediaPlaylist) ResetCache() {
@@ -525,409 +618,579 @@ func (p *MediaPlaylist) SetIndependentSegments(b bool) {
 }
 
 // Encode generates output in M3U8 format. Marshal `winsize` elements
-// from bottom of the `segments` queue.
+// from bottom of the `segments` queue. Segments may be encoded
+// incrementally, see SetIncrementalEncoding.
 func (p *MediaPlaylist) Encode() *bytes.Buffer {
+	if p.buf.Len() > 0 && p.enc != nil && p.enc.header != p.header() {
+		// the header changed after the cached output was written
+		// (e.g. the version was raised by SetRange or SetKey)
+		p.buf.Reset()
+	}
 	if p.buf.Len() > 0 {
+		if p.enc != nil && p.enc.count < p.count && p.winsize == 0 {
+			p.encodeAppended()
+		}
 		return &p.buf
 	}
+	start := time.Now()
+	q := p.transformed()
+	p.buf.Grow(p.encodedSize())
+	enc := q.encode(&p.buf, 0, q.winsize)
+	p.enc = nil
+	if q == p && p.incremental && p.winsize == 0 && p.source == nil {
+		p.enc = enc
+	}
+	p.source.restore(&p.buf)
+	reportEncoded(MEDIA, int(q.windowCount(0, q.winsize)), start)
+	return &p.buf
+}
 
-	p.buf.WriteString("#EXTM3U\n#EXT-X-VERSION:")
-	p.buf.WriteString(strver(p.ver))
-	p.buf.WriteRune('\n')
+// SetIncrementalEncoding enables incremental encoding of segments
+// appended to playlists without window (VOD and EVENT ones): Encode
+// appends them to the cached output instead of encoding the whole
+// playlist again when nothing but appends happened since the last
+// encoding. Setters of the playlist and of its current segment reset
+// the cache, but fields of the playlist and of already encoded
+// segments changed directly are not tracked, so ResetCache must be
+// called after such changes. The incremental encoding isn't used for
+// playlists with transforms and playlists decoded in the fidelity
+// mode. This operation does reset playlist cache.
+func (p *MediaPlaylist) SetIncrementalEncoding(yes bool) {
+	p.incremental = yes
+	p.buf.Reset()
+}
 
-	if p.IndependentSegments() {
-		p.buf.WriteString("#EXT-X-INDEPENDENT-SEGMENTS\n")
+// resetEncoded resets the cache after the change of the current
+// segment unless the segment is still to be encoded incrementally.
+func (p *MediaPlaylist) resetEncoded() {
+	if p.enc == nil || p.enc.count >= p.count {
+		p.buf.Reset()
 	}
+}
 
-	// Write any custom master tags
-	if p.Custom != nil {
-		for _, v := range p.Custom {
-			if customBuf := v.Encode(); customBuf != nil {
-				p.buf.WriteString(customBuf.String())
-				p.buf.WriteRune('\n')
-			}
+// encodeAppended writes segments appended since the last encoding to
+// the cached output replacing its trailer.
+func (p *MediaPlaylist) encodeAppended() {
+	start := time.Now()
+	enc := p.enc
+	n := p.count - enc.count
+	p.buf.Truncate(enc.end)
+	for ; enc.count < p.count; enc.count++ {
+		if seg := p.Segments[(p.head+enc.count)%p.capacity]; seg != nil {
+			p.encodeSegment(&p.buf, seg, enc)
 		}
 	}
+	enc.end = p.buf.Len()
+	p.encodeTrailer(&p.buf)
+	reportEncoded(MEDIA, int(n), start)
+}
+
+// EncodeWindow generates output in M3U8 format for the last n
+// segments of the playlist. Zero n means all segments. It neither
+// changes the window size of the playlist nor uses or resets the
+// playlist cache so the same playlist may be served with differently
+// sized windows (for example a full DVR window and a short live one).
+// The key, map and discontinuity sequence effective at the first
+// segment of the window are written as by EncodeRange.
+func (p *MediaPlaylist) EncodeWindow(n uint) *bytes.Buffer {
+	start := time.Now()
+	q := p.transformed()
+	segs := q.segments()
+	if len(segs) == 0 {
+		buf := new(bytes.Buffer)
+		q.encode(buf, 0, 0)
+		reportEncoded(MEDIA, 0, start)
+		return buf
+	}
+	from := 0
+	if n > 0 && len(segs) > int(n) {
+		from = len(segs) - int(n)
+	}
+	// the window is not empty, so the excerpt can't fail
+	buf, _ := q.encodeExcerpt(segs, from, len(segs))
+	reportEncoded(MEDIA, len(segs)-from, start)
+	return buf
+}
+
+// EncodeRange generates output in M3U8 format for the window of n
+// segments starting from the segment with the media sequence number
+// seqID, zero n means all the following segments. Like EncodeWindow
+// it neither changes the playlist nor uses its cache, so different
+// clients may be served with different windows of the same playlist.
+// The key, map and discontinuity sequence effective at the first
+// segment of the window are written. EXT-X-ENDLIST, pending parts,
+// preload hints and rendition reports are written only if the window
+// ends with the last segment of the playlist.
+func (p *MediaPlaylist) EncodeRange(seqID uint64, n uint) (*bytes.Buffer, error) {
+	start := time.Now()
+	q := p.transformed()
+	segs := q.segments()
+	from := -1
+	for i, seg := range segs {
+		if seg.SeqId == seqID {
+			from = i
+			break
+		}
+	}
+	if from < 0 {
+		return nil, fmt.Errorf("no segment with sequence ID %d", seqID)
+	}
+	end := len(segs)
+	if n > 0 && from+int(n) < end {
+		end = from + int(n)
+	}
+	buf, err := q.encodeExcerpt(segs, from, end)
+	if err != nil {
+		return nil, err
+	}
+	reportEncoded(MEDIA, end-from, start)
+	return buf, nil
+}
+
+// encodeExcerpt encodes the segments from the start index to the end
+// one (exclusive) with the key, map and discontinuity sequence
+// effective at the start, see EncodeRange.
+func (p *MediaPlaylist) encodeExcerpt(segs []*MediaSegment, from, end int) (*bytes.Buffer, error) {
+	out, err := p.excerpt(segs, from, end, 0)
+	if err != nil {
+		return nil, err
+	}
+	out.Custom = p.Custom
+	out.StartTime = p.StartTime
+	out.StartTimePrecise = p.StartTimePrecise
+	out.startSet = p.startSet
+	out.PartTargetDuration = p.PartTargetDuration
+	out.pinnedVer = p.pinnedVer
+	out.omitVer = p.omitVer
+	out.attrOrder = p.attrOrder
+	out.beforeSegment = p.beforeSegment
+	out.afterSegment = p.afterSegment
+	if end == len(segs) {
+		out.Closed = p.Closed
+		out.TrailingUnknownTags = p.TrailingUnknownTags
+		out.PendingPartials = p.PendingPartials
+		out.PreloadHints = p.PreloadHints
+		out.RenditionReports = p.RenditionReports
+	}
+	buf := new(bytes.Buffer)
+	out.encode(buf, 0, 0)
+	return buf, nil
+}
+
+// windowCount returns the number of segments written by encode.
+func (p *MediaPlaylist) windowCount(skip, winsize uint) uint {
+	count := p.count - skip
+	if winsize > 0 && count > winsize {
+		count = winsize
+	}
+	return count
+}
+
+// encode writes up to winsize segments (all of them for zero
+// winsize) to the buffer skipping first skip segments of the
+// playlist. Media sequence number is shifted accordingly. It returns
+// the state of encoding after the last written segment.
+func (p *MediaPlaylist) encode(buf *bytes.Buffer, skip, winsize uint) *segmentEncoder {
+	buf.WriteString("#EXTM3U\n")
+	writeCustomTags(buf, p.Custom, CustomTagBeforeHeader)
+	writeVersion(buf, p.ver, p.pinnedVer, p.omitVer)
+
+	if p.IndependentSegments() {
+		buf.WriteString("#EXT-X-INDEPENDENT-SEGMENTS\n")
+	}
+	writeDefines(buf, p.Defines)
+
+	// Write any custom master tags
+	writeCustomTags(buf, p.Custom, CustomTagDefault)
 
 	// default key (workaround for Widevine)
 	if p.Key != nil {
-		p.buf.WriteString("#EXT-X-KEY:")
-		p.buf.WriteString("METHOD=")
-		p.buf.WriteString(p.Key.Method)
-		if p.Key.Method != "NONE" {
-			p.buf.WriteString(",URI=\"")
-			p.buf.WriteString(p.Key.URI)
-			p.buf.WriteRune('"')
-			if p.Key.IV != "" {
-				p.buf.WriteString(",IV=")
-				p.buf.WriteString(p.Key.IV)
-			}
-			if p.Key.Keyformat != "" {
-				p.buf.WriteString(",KEYFORMAT=\"")
-				p.buf.WriteString(p.Key.Keyformat)
-				p.buf.WriteRune('"')
-			}
-			if p.Key.Keyformatversions != "" {
-				p.buf.WriteString(",KEYFORMATVERSIONS=\"")
-				p.buf.WriteString(p.Key.Keyformatversions)
-				p.buf.WriteRune('"')
-			}
-		}
-		p.buf.WriteRune('\n')
+		writeKey(buf, p.Key, p.attrOrder[p.Key])
 	}
 	if p.Map != nil {
-		p.buf.WriteString("#EXT-X-MAP:")
-		p.buf.WriteString("URI=\"")
-		p.buf.WriteString(p.Map.URI)
-		p.buf.WriteRune('"')
-		if p.Map.Limit > 0 {
-			p.buf.WriteString(",BYTERANGE=")
-			p.buf.WriteString(strconv.FormatInt(p.Map.Limit, 10))
-			p.buf.WriteRune('@')
-			p.buf.WriteString(strconv.FormatInt(p.Map.Offset, 10))
-		}
-		p.buf.WriteRune('\n')
+		writeMap(buf, p.Map, p.attrOrder[p.Map])
 	}
 	if p.MediaType > 0 {
-		p.buf.WriteString("#EXT-X-PLAYLIST-TYPE:")
+		buf.WriteString("#EXT-X-PLAYLIST-TYPE:")
 		switch p.MediaType {
 		case EVENT:
-			p.buf.WriteString("EVENT\n")
-			p.buf.WriteString("#EXT-X-ALLOW-CACHE:NO\n")
+			buf.WriteString("EVENT\n")
 		case VOD:
-			p.buf.WriteString("VOD\n")
+			buf.WriteString("VOD\n")
 		}
 	}
-	p.buf.WriteString("#EXT-X-MEDIA-SEQUENCE:")
-	p.buf.WriteString(strconv.FormatUint(p.SeqNo, 10))
-	p.buf.WriteRune('\n')
-	p.buf.WriteString("#EXT-X-TARGETDURATION:")
-	p.buf.WriteString(strconv.FormatInt(int64(math.Ceil(p.TargetDuration)), 10)) // due section 3.4.2 of M3U8 specs EXT-X-TARGETDURATION must be integer
-	p.buf.WriteRune('\n')
-	if p.StartTime > 0.0 {
-		p.buf.WriteString("#EXT-X-START:TIME-OFFSET=")
-		p.buf.WriteString(strconv.FormatFloat(p.StartTime, 'f', -1, 64))
-		if p.StartTimePrecise {
-			p.buf.WriteString(",PRECISE=YES")
-		}
-		p.buf.WriteRune('\n')
+	if p.AllowCache != "" {
+		buf.WriteString("#EXT-X-ALLOW-CACHE:")
+		buf.WriteString(p.AllowCache)
+		buf.WriteRune('\n')
+	} else if p.MediaType == EVENT {
+		buf.WriteString("#EXT-X-ALLOW-CACHE:NO\n")
+	}
+	buf.WriteString("#EXT-X-MEDIA-SEQUENCE:")
+	buf.WriteString(strconv.FormatUint(p.SeqNo+uint64(skip), 10))
+	buf.WriteRune('\n')
+	buf.WriteString("#EXT-X-TARGETDURATION:")
+	buf.WriteString(strconv.FormatInt(int64(p.roundTargetDuration(p.TargetDuration)), 10)) // due section 3.4.2 of M3U8 specs EXT-X-TARGETDURATION must be integer
+	buf.WriteRune('\n')
+	if p.ServerControl != nil {
+		writeServerControl(buf, p.ServerControl)
 	}
-	if p.DiscontinuitySeq != 0 {
-		p.buf.WriteString("#EXT-X-DISCONTINUITY-SEQUENCE:")
-		p.buf.WriteString(strconv.FormatUint(uint64(p.DiscontinuitySeq), 10))
-		p.buf.WriteRune('\n')
+	if p.PartTargetDuration > 0 {
+		writePartInf(buf, p.PartTargetDuration)
+	}
+	if p.StartTime > 0.0 || p.startSet {
+		writeStart(buf, p.StartTime, p.StartTimePrecise)
+	}
+	if p.DiscontinuitySeq != 0 || p.dseqSet {
+		buf.WriteString("#EXT-X-DISCONTINUITY-SEQUENCE:")
+		buf.WriteString(strconv.FormatUint(uint64(p.DiscontinuitySeq), 10))
+		buf.WriteRune('\n')
 	}
 	if p.Iframe {
-		p.buf.WriteString("#EXT-X-I-FRAMES-ONLY\n")
+		buf.WriteString("#EXT-X-I-FRAMES-ONLY\n")
+	}
+	if p.ImagesOnly {
+		buf.WriteString("#EXT-X-IMAGES-ONLY\n")
 	}
 	// Widevine tags
 	if p.WV != nil {
 		if p.WV.AudioChannels != 0 {
-			p.buf.WriteString("#WV-AUDIO-CHANNELS ")
-			p.buf.WriteString(strconv.FormatUint(uint64(p.WV.AudioChannels), 10))
-			p.buf.WriteRune('\n')
+			buf.WriteString("#WV-AUDIO-CHANNELS ")
+			buf.WriteString(strconv.FormatUint(uint64(p.WV.AudioChannels), 10))
+			buf.WriteRune('\n')
 		}
 		if p.WV.AudioFormat != 0 {
-			p.buf.WriteString("#WV-AUDIO-FORMAT ")
-			p.buf.WriteString(strconv.FormatUint(uint64(p.WV.AudioFormat), 10))
-			p.buf.WriteRune('\n')
+			buf.WriteString("#WV-AUDIO-FORMAT ")
+			buf.WriteString(strconv.FormatUint(uint64(p.WV.AudioFormat), 10))
+			buf.WriteRune('\n')
 		}
 		if p.WV.AudioProfileIDC != 0 {
-			p.buf.WriteString("#WV-AUDIO-PROFILE-IDC ")
-			p.buf.WriteString(strconv.FormatUint(uint64(p.WV.AudioProfileIDC), 10))
-			p.buf.WriteRune('\n')
+			buf.WriteString("#WV-AUDIO-PROFILE-IDC ")
+			buf.WriteString(strconv.FormatUint(uint64(p.WV.AudioProfileIDC), 10))
+			buf.WriteRune('\n')
 		}
 		if p.WV.AudioSampleSize != 0 {
-			p.buf.WriteString("#WV-AUDIO-SAMPLE-SIZE ")
-			p.buf.WriteString(strconv.FormatUint(uint64(p.WV.AudioSampleSize), 10))
-			p.buf.WriteRune('\n')
+			buf.WriteString("#WV-AUDIO-SAMPLE-SIZE ")
+			buf.WriteString(strconv.FormatUint(uint64(p.WV.AudioSampleSize), 10))
+			buf.WriteRune('\n')
 		}
 		if p.WV.AudioSamplingFrequency != 0 {
-			p.buf.WriteString("#WV-AUDIO-SAMPLING-FREQUENCY ")
-			p.buf.WriteString(strconv.FormatUint(uint64(p.WV.AudioSamplingFrequency), 10))
-			p.buf.WriteRune('\n')
+			buf.WriteString("#WV-AUDIO-SAMPLING-FREQUENCY ")
+			buf.WriteString(strconv.FormatUint(uint64(p.WV.AudioSamplingFrequency), 10))
+			buf.WriteRune('\n')
 		}
 		if p.WV.CypherVersion != "" {
-			p.buf.WriteString("#WV-CYPHER-VERSION ")
-			p.buf.WriteString(p.WV.CypherVersion)
-			p.buf.WriteRune('\n')
+			buf.WriteString("#WV-CYPHER-VERSION ")
+			buf.WriteString(p.WV.CypherVersion)
+			buf.WriteRune('\n')
 		}
 		if p.WV.ECM != "" {
-			p.buf.WriteString("#WV-ECM ")
-			p.buf.WriteString(p.WV.ECM)
-			p.buf.WriteRune('\n')
+			buf.WriteString("#WV-ECM ")
+			buf.WriteString(p.WV.ECM)
+			buf.WriteRune('\n')
 		}
 		if p.WV.VideoFormat != 0 {
-			p.buf.WriteString("#WV-VIDEO-FORMAT ")
-			p.buf.WriteString(strconv.FormatUint(uint64(p.WV.VideoFormat), 10))
-			p.buf.WriteRune('\n')
+			buf.WriteString("#WV-VIDEO-FORMAT ")
+			buf.WriteString(strconv.FormatUint(uint64(p.WV.VideoFormat), 10))
+			buf.WriteRune('\n')
 		}
 		if p.WV.VideoFrameRate != 0 {
-			p.buf.WriteString("#WV-VIDEO-FRAME-RATE ")
-			p.buf.WriteString(strconv.FormatUint(uint64(p.WV.VideoFrameRate), 10))
-			p.buf.WriteRune('\n')
+			buf.WriteString("#WV-VIDEO-FRAME-RATE ")
+			buf.WriteString(strconv.FormatUint(uint64(p.WV.VideoFrameRate), 10))
+			buf.WriteRune('\n')
 		}
 		if p.WV.VideoLevelIDC != 0 {
-			p.buf.WriteString("#WV-VIDEO-LEVEL-IDC")
-			p.buf.WriteString(strconv.FormatUint(uint64(p.WV.VideoLevelIDC), 10))
-			p.buf.WriteRune('\n')
+			buf.WriteString("#WV-VIDEO-LEVEL-IDC")
+			buf.WriteString(strconv.FormatUint(uint64(p.WV.VideoLevelIDC), 10))
+			buf.WriteRune('\n')
 		}
 		if p.WV.VideoProfileIDC != 0 {
-			p.buf.WriteString("#WV-VIDEO-PROFILE-IDC ")
-			p.buf.WriteString(strconv.FormatUint(uint64(p.WV.VideoProfileIDC), 10))
-			p.buf.WriteRune('\n')
+			buf.WriteString("#WV-VIDEO-PROFILE-IDC ")
+			buf.WriteString(strconv.FormatUint(uint64(p.WV.VideoProfileIDC), 10))
+			buf.WriteRune('\n')
 		}
 		if p.WV.VideoResolution != "" {
-			p.buf.WriteString("#WV-VIDEO-RESOLUTION ")
-			p.buf.WriteString(p.WV.VideoResolution)
-			p.buf.WriteRune('\n')
+			buf.WriteString("#WV-VIDEO-RESOLUTION ")
+			buf.WriteString(p.WV.VideoResolution)
+			buf.WriteRune('\n')
 		}
 		if p.WV.VideoSAR != "" {
-			p.buf.WriteString("#WV-VIDEO-SAR ")
-			p.buf.WriteString(p.WV.VideoSAR)
-			p.buf.WriteRune('\n')
+			buf.WriteString("#WV-VIDEO-SAR ")
+			buf.WriteString(p.WV.VideoSAR)
+			buf.WriteRune('\n')
 		}
 	}
 
-	var (
-		seg           *MediaSegment
-		durationCache = make(map[float64]string)
-	)
+	writeUnknownTags(buf, p.UnknownTags)
+	if p.Skip != nil {
+		writeSkip(buf, p.Skip)
+	}
+	writeCustomTags(buf, p.Custom, CustomTagBeforeSegments)
 
+	enc := &segmentEncoder{key: p.Key, durations: make(map[float64]string), header: p.header()}
 	head := p.head
-	count := p.count
-	for i := uint(0); (i < p.winsize || p.winsize == 0) && count > 0; count-- {
-		seg = p.Segments[head]
+	if skip > 0 {
+		head = (head + skip) % p.capacity
+	}
+	count := p.count - skip
+	for i := uint(0); (i < winsize || winsize == 0) && count > 0; count-- {
+		seg := p.Segments[head]
 		head = (head + 1) % p.capacity
+		enc.count++
 		if seg == nil { // protection from badly filled chunklists
 			continue
 		}
-		if p.winsize > 0 { // skip for VOD playlists, where winsize = 0
+		if winsize > 0 { // skip for VOD playlists, where winsize = 0
 			i++
 		}
-		if seg.SCTE != nil {
-			switch seg.SCTE.Syntax {
-			case SCTE35_67_2014:
-				p.buf.WriteString("#EXT-SCTE35:")
-				p.buf.WriteString("CUE=\"")
-				p.buf.WriteString(seg.SCTE.Cue)
-				p.buf.WriteRune('"')
-				if seg.SCTE.ID != "" {
-					p.buf.WriteString(",ID=\"")
-					p.buf.WriteString(seg.SCTE.ID)
-					p.buf.WriteRune('"')
-				}
-				if seg.SCTE.Time != 0 {
-					p.buf.WriteString(",TIME=")
-					p.buf.WriteString(strconv.FormatFloat(seg.SCTE.Time, 'f', -1, 64))
-				}
-				p.buf.WriteRune('\n')
-			case SCTE35_OATCLS:
-				switch seg.SCTE.CueType {
-				case SCTE35Cue_Start:
-					if seg.SCTE.Cue != "" {
-						p.buf.WriteString("#EXT-OATCLS-SCTE35:")
-						p.buf.WriteString(seg.SCTE.Cue)
-						p.buf.WriteRune('\n')
-					}
-					p.buf.WriteString("#EXT-X-CUE-OUT:")
-					p.buf.WriteString(strconv.FormatFloat(seg.SCTE.Time, 'f', -1, 64))
-					p.buf.WriteRune('\n')
-				case SCTE35Cue_Mid:
-					p.buf.WriteString("#EXT-X-CUE-OUT-CONT:")
-					p.buf.WriteString("ElapsedTime=")
-					p.buf.WriteString(strconv.FormatFloat(seg.SCTE.Elapsed, 'f', -1, 64))
-					p.buf.WriteString(",Duration=")
-					p.buf.WriteString(strconv.FormatFloat(seg.SCTE.Time, 'f', -1, 64))
-					p.buf.WriteString(",SCTE35=")
-					p.buf.WriteString(seg.SCTE.Cue)
-					p.buf.WriteRune('\n')
-				case SCTE35Cue_End:
-					p.buf.WriteString("#EXT-X-CUE-IN")
-					p.buf.WriteRune('\n')
-				}
-			}
+		p.encodeSegment(buf, seg, enc)
+	}
+	enc.end = buf.Len()
+	p.encodeTrailer(buf)
+	return enc
+}
+
+// segmentEncoder is the state of encoding carried from a segment to
+// the following ones. It is kept with the cache of the playlist for
+// incremental encoding of appended segments.
+type segmentEncoder struct {
+	key       *Key  // effective key of the current segment
+	bitrate   int64 // effective bitrate of the current segment
+	durations map[float64]string
+	count     uint // number of encoded segments from the head
+	end       int  // length of the output up to the end of the last segment
+	header    headerState
+}
+
+// headerState is the state of the playlist written to the header of
+// the cached output. Incremental encoding rewrites the whole output
+// when the state changes.
+type headerState struct {
+	ver            uint8
+	omitVer        bool
+	independent    bool
+	key            *Key
+	m              *Map
+	mediaType      MediaType
+	allowCache     string
+	seqNo          uint64
+	targetDuration float64
+	partTarget     float64
+	serverControl  *ServerControl
+	startTime      float64
+	dseq           uint64
+	iframe         bool
+	imagesOnly     bool
+	durationAsInt  bool
+	args           string
+	custom         string // encoded custom tags of the playlist
+}
+
+// header returns the current state of the header of the playlist.
+func (p *MediaPlaylist) header() headerState {
+	return headerState{
+		ver:            writtenVersion(p.ver, p.pinnedVer),
+		omitVer:        p.omitVer,
+		independent:    p.independentSegments,
+		key:            p.Key,
+		m:              p.Map,
+		mediaType:      p.MediaType,
+		allowCache:     p.AllowCache,
+		seqNo:          p.SeqNo,
+		targetDuration: p.TargetDuration,
+		partTarget:     p.PartTargetDuration,
+		serverControl:  p.ServerControl,
+		startTime:      p.StartTime,
+		dseq:           p.DiscontinuitySeq,
+		iframe:         p.Iframe,
+		imagesOnly:     p.ImagesOnly,
+		durationAsInt:  p.durationAsInt,
+		args:           p.Args,
+		custom:         customTagsText(p.Custom),
+	}
+}
+
+// customTagsText returns the custom tags as they are written.
+func customTagsText(tags CustomTags) string {
+	if len(tags) == 0 {
+		return ""
+	}
+	var buf bytes.Buffer
+	for _, tag := range tags {
+		if b := tag.Encode(); b != nil {
+			buf.Write(b.Bytes())
+			buf.WriteRune('\n')
 		}
-		// check for key change
-		if seg.Key != nil && p.Key != seg.Key {
-			p.buf.WriteString("#EXT-X-KEY:")
-			p.buf.WriteString("METHOD=")
-			p.buf.WriteString(seg.Key.Method)
-			if seg.Key.Method != "NONE" {
-				p.buf.WriteString(",URI=\"")
-				p.buf.WriteString(seg.Key.URI)
-				p.buf.WriteRune('"')
-				if seg.Key.IV != "" {
-					p.buf.WriteString(",IV=")
-					p.buf.WriteString(seg.Key.IV)
-				}
-				if seg.Key.Keyformat != "" {
-					p.buf.WriteString(",KEYFORMAT=\"")
-					p.buf.WriteString(seg.Key.Keyformat)
-					p.buf.WriteRune('"')
-				}
-				if seg.Key.Keyformatversions != "" {
-					p.buf.WriteString(",KEYFORMATVERSIONS=\"")
-					p.buf.WriteString(seg.Key.Keyformatversions)
-					p.buf.WriteRune('"')
-				}
+	}
+	return buf.String()
+}
+
+// encodeSegment writes the segment with its tags.
+func (p *MediaPlaylist) encodeSegment(buf *bytes.Buffer, seg *MediaSegment, enc *segmentEncoder) {
+	if p.beforeSegment != nil {
+		p.beforeSegment(buf, seg)
+	}
+	writeCustomTags(buf, seg.Custom, CustomTagBeforeHeader)
+	if seg.SCTE != nil {
+		switch seg.SCTE.Syntax {
+		case SCTE35_67_2014:
+			buf.WriteString("#EXT-SCTE35:")
+			buf.WriteString("CUE=\"")
+			buf.WriteString(seg.SCTE.Cue)
+			buf.WriteRune('"')
+			if seg.SCTE.ID != "" {
+				buf.WriteString(",ID=\"")
+				buf.WriteString(seg.SCTE.ID)
+				buf.WriteRune('"')
 			}
-			p.buf.WriteRune('\n')
-		}
-		if len(seg.DateRange) > 0 {
-			for _, dr := range seg.DateRange {
-				p.buf.WriteString("#EXT-X-DATERANGE:")
-				p.buf.WriteString("ID=\"")
-				p.buf.WriteString(dr.ID)
-				p.buf.WriteRune('"')
-				if dr.Class != "" {
-					p.buf.WriteString(",CLASS=\"")
-					p.buf.WriteString(dr.Class)
-					p.buf.WriteRune('"')
-				}
-				if !dr.StartDate.IsZero() {
-					p.buf.WriteString(",START-DATE=\"")
-					p.buf.WriteString(dr.StartDate.Format(DATETIME))
-					p.buf.WriteRune('"')
-				}
-				if !dr.EndDate.IsZero() {
-					p.buf.WriteString(",END-DATE=\"")
-					p.buf.WriteString(dr.EndDate.Format(DATETIME))
-					p.buf.WriteRune('"')
-				}
-				if dr.Duration > 0 {
-					p.buf.WriteString(",DURATION=")
-					p.buf.WriteString(strconv.FormatFloat(dr.Duration, 'f', -1, 64))
-				}
-				if dr.PlannedDuration > 0 {
-					p.buf.WriteString(",PLANNED-DURATION=")
-					p.buf.WriteString(strconv.FormatFloat(dr.PlannedDuration, 'f', -1, 64))
-				}
-				if dr.SCTE35Cmd != "" {
-					p.buf.WriteString(",SCTE35-CMD=")
-					p.buf.WriteString(dr.SCTE35Cmd)
-				}
-				if dr.SCTE35In != "" {
-					p.buf.WriteString(",SCTE35-IN=")
-					p.buf.WriteString(dr.SCTE35In)
-				}
-				if dr.SCTE35Out != "" {
-					p.buf.WriteString(",SCTE35-OUT=")
-					p.buf.WriteString(dr.SCTE35Out)
-				}
-				if dr.EndOnNext != "" {
-					p.buf.WriteString(",END-ON-NEXT=\"")
-					p.buf.WriteString(dr.EndOnNext)
-					p.buf.WriteRune('"')
-				}
-				if dr.XResumeOfsset > 0 {
-					p.buf.WriteString(",X-RESUME-OFFSET=")
-					p.buf.WriteString(strconv.FormatFloat(dr.Duration, 'f', -1, 64))
-				}
-				if dr.XPlayoutLimit > 0 {
-					p.buf.WriteString(",X-PLAYOUT-LIMIT=")
-					p.buf.WriteString(strconv.FormatFloat(dr.Duration, 'f', -1, 64))
-				}
-				if dr.XSnap != "" {
-					p.buf.WriteString(",X-SNAP=\"")
-					p.buf.WriteString(dr.XSnap)
-					p.buf.WriteRune('"')
-				}
-				if dr.XRestrict != "" {
-					p.buf.WriteString(",X-RESTRICT=\"")
-					p.buf.WriteString(dr.XRestrict)
-					p.buf.WriteRune('"')
-				}
-				if dr.XAssetURI != "" {
-					p.buf.WriteString(",X-ASSET-URI=\"")
-					p.buf.WriteString(dr.XAssetURI)
-					p.buf.WriteRune('"')
-				}
-				if dr.XAssetList != "" {
-					p.buf.WriteString(",X-ASSET-LIST=\"")
-					p.buf.WriteString(dr.XAssetList)
-					p.buf.WriteRune('"')
-				}
-				for k, v := range dr.X {
-					p.buf.WriteString(",")
-					p.buf.WriteString(k)
-					p.buf.WriteString("=\"")
-					p.buf.WriteString(v)
-					p.buf.WriteRune('"')
-				}
-				p.buf.WriteString("\n")
+			if seg.SCTE.Time != 0 {
+				buf.WriteString(",TIME=")
+				buf.WriteString(strconv.FormatFloat(seg.SCTE.Time, 'f', -1, 64))
 			}
-		}
-		if seg.Discontinuity {
-			p.buf.WriteString("#EXT-X-DISCONTINUITY\n")
-		}
-		if seg.Gap {
-			p.buf.WriteString("#EXT-X-GAP\n")
-		}
-		// ignore segment Map if default playlist Map is present
-		if p.Map == nil && seg.Map != nil {
-			p.buf.WriteString("#EXT-X-MAP:")
-			p.buf.WriteString("URI=\"")
-			p.buf.WriteString(seg.Map.URI)
-			p.buf.WriteRune('"')
-			if seg.Map.Limit > 0 {
-				p.buf.WriteString(",BYTERANGE=")
-				p.buf.WriteString(strconv.FormatInt(seg.Map.Limit, 10))
-				p.buf.WriteRune('@')
-				p.buf.WriteString(strconv.FormatInt(seg.Map.Offset, 10))
+			buf.WriteRune('\n')
+		case SCTE35_OATCLS:
+			switch seg.SCTE.CueType {
+			case SCTE35Cue_Start:
+				if seg.SCTE.Cue != "" {
+					buf.WriteString("#EXT-OATCLS-SCTE35:")
+					buf.WriteString(seg.SCTE.Cue)
+					buf.WriteRune('\n')
+				}
+				buf.WriteString("#EXT-X-CUE-OUT:")
+				buf.WriteString(strconv.FormatFloat(seg.SCTE.Time, 'f', -1, 64))
+				buf.WriteRune('\n')
+			case SCTE35Cue_Mid:
+				buf.WriteString("#EXT-X-CUE-OUT-CONT:")
+				buf.WriteString("ElapsedTime=")
+				buf.WriteString(strconv.FormatFloat(seg.SCTE.Elapsed, 'f', -1, 64))
+				buf.WriteString(",Duration=")
+				buf.WriteString(strconv.FormatFloat(seg.SCTE.Time, 'f', -1, 64))
+				buf.WriteString(",SCTE35=")
+				buf.WriteString(seg.SCTE.Cue)
+				buf.WriteRune('\n')
+			case SCTE35Cue_End:
+				buf.WriteString("#EXT-X-CUE-IN")
+				buf.WriteRune('\n')
 			}
-			p.buf.WriteRune('\n')
-		}
-		if !seg.ProgramDateTime.IsZero() {
-			p.buf.WriteString("#EXT-X-PROGRAM-DATE-TIME:")
-			p.buf.WriteString(seg.ProgramDateTime.Format(DATETIME))
-			p.buf.WriteRune('\n')
-		}
-		if seg.Limit > 0 {
-			p.buf.WriteString("#EXT-X-BYTERANGE:")
-			p.buf.WriteString(strconv.FormatInt(seg.Limit, 10))
-			p.buf.WriteRune('@')
-			p.buf.WriteString(strconv.FormatInt(seg.Offset, 10))
-			p.buf.WriteRune('\n')
-		}
-
-		// Add Custom Segment Tags here
-		if seg.Custom != nil {
-			for _, v := range seg.Custom {
-				if customBuf := v.Encode(); customBuf != nil {
-					p.buf.WriteString(customBuf.String())
-					p.buf.WriteRune('\n')
+		case SCTE35_ELEMENTAL:
+			switch seg.SCTE.CueType {
+			case SCTE35Cue_Start:
+				buf.WriteString("#EXT-X-CUE-OUT")
+				if seg.SCTE.Time != 0 {
+					buf.WriteString(":DURATION=")
+					buf.WriteString(strconv.FormatFloat(seg.SCTE.Time, 'f', -1, 64))
 				}
+				buf.WriteRune('\n')
+			case SCTE35Cue_Mid:
+				buf.WriteString("#EXT-X-CUE-OUT-CONT:")
+				buf.WriteString("ElapsedTime=")
+				buf.WriteString(strconv.FormatFloat(seg.SCTE.Elapsed, 'f', -1, 64))
+				buf.WriteString(",Duration=")
+				buf.WriteString(strconv.FormatFloat(seg.SCTE.Time, 'f', -1, 64))
+				buf.WriteRune('\n')
+			case SCTE35Cue_End:
+				buf.WriteString("#EXT-X-CUE-IN")
+				buf.WriteRune('\n')
 			}
+		case SCTE35_ADOBE:
+			writeAdobeCue(buf, seg.SCTE)
 		}
+	}
+	// check for key change
+	if seg.Key != nil && !sameKey(seg.Key, enc.key) {
+		writeKey(buf, seg.Key, p.attrOrder[seg.Key])
+		enc.key = seg.Key
+	}
+	if len(seg.DateRange) > 0 {
+		for _, dr := range seg.DateRange {
+			writeDateRange(buf, dr, p.attrOrder[dr])
+		}
+	}
+	if seg.Discontinuity {
+		buf.WriteString("#EXT-X-DISCONTINUITY\n")
+	}
+	if seg.Gap {
+		buf.WriteString("#EXT-X-GAP\n")
+	}
+	// ignore segment Map if default playlist Map is present
+	if p.Map == nil && seg.Map != nil {
+		writeMap(buf, seg.Map, p.attrOrder[seg.Map])
+	}
+	if !seg.ProgramDateTime.IsZero() {
+		buf.WriteString("#EXT-X-PROGRAM-DATE-TIME:")
+		buf.WriteString(seg.ProgramDateTime.Format(DATETIME))
+		buf.WriteRune('\n')
+	}
+	if seg.Bitrate > 0 && seg.Bitrate != enc.bitrate {
+		buf.WriteString("#EXT-X-BITRATE:")
+		buf.WriteString(strconv.FormatInt(seg.Bitrate, 10))
+		buf.WriteRune('\n')
+		enc.bitrate = seg.Bitrate
+	}
+	if seg.Limit > 0 {
+		buf.WriteString("#EXT-X-BYTERANGE:")
+		buf.WriteString(strconv.FormatInt(seg.Limit, 10))
+		buf.WriteRune('@')
+		buf.WriteString(strconv.FormatInt(seg.Offset, 10))
+		buf.WriteRune('\n')
+	}
+
+	// Add Custom Segment Tags here
+	writeCustomTags(buf, seg.Custom, CustomTagDefault, CustomTagBeforeSegments)
+	writeUnknownTags(buf, seg.UnknownTags)
 
-		p.buf.WriteString("#EXTINF:")
-		if str, ok := durationCache[seg.Duration]; ok {
-			p.buf.WriteString(str)
+	for i, part := range seg.Partials {
+		// the first part starts with the segment, the same time is
+		// written once
+		writePartial(buf, part, i > 0 || !part.ProgramDateTime.Equal(seg.ProgramDateTime))
+	}
+	if seg.Tiles != nil {
+		writeTiles(buf, seg.Tiles)
+	}
+	buf.WriteString("#EXTINF:")
+	if str, ok := p.sourceDuration(seg); ok {
+		buf.WriteString(str)
+	} else if str, ok := enc.durations[seg.Duration]; ok {
+		buf.WriteString(str)
+	} else {
+		if p.durationAsInt {
+			// Old Android players has problems with non integer Duration.
+			enc.durations[seg.Duration] = strconv.FormatInt(int64(math.Ceil(seg.Duration)), 10)
+		} else if p.durationPrecSet {
+			enc.durations[seg.Duration] = strconv.FormatFloat(seg.Duration, 'f', p.durationPrec, 64)
 		} else {
-			if p.durationAsInt {
-				// Old Android players has problems with non integer Duration.
-				durationCache[seg.Duration] = strconv.FormatInt(int64(math.Ceil(seg.Duration)), 10)
-			} else {
-				// Wowza Mediaserver and some others prefer floats.
-				durationCache[seg.Duration] = strconv.FormatFloat(seg.Duration, 'f', 3, 32)
-			}
-			p.buf.WriteString(durationCache[seg.Duration])
+			// Wowza Mediaserver and some others prefer floats.
+			enc.durations[seg.Duration] = strconv.FormatFloat(seg.Duration, 'f', 3, 32)
 		}
-		p.buf.WriteRune(',')
-		p.buf.WriteString(seg.Title)
-		p.buf.WriteRune('\n')
-		p.buf.WriteString(seg.URI)
-		if p.Args != "" {
-			p.buf.WriteRune('?')
-			p.buf.WriteString(p.Args)
-		}
-		p.buf.WriteRune('\n')
+		buf.WriteString(enc.durations[seg.Duration])
+	}
+	buf.WriteRune(',')
+	buf.WriteString(seg.Title)
+	buf.WriteRune('\n')
+	writeURI(buf, seg.URI, seg.Args, p.Args)
+	buf.WriteRune('\n')
+	writeCustomTags(buf, seg.Custom, CustomTagAfterSegments)
+	if p.afterSegment != nil {
+		p.afterSegment(buf, seg)
+	}
+}
+
+// encodeTrailer writes tags following the last segment.
+func (p *MediaPlaylist) encodeTrailer(buf *bytes.Buffer) {
+	writeCustomTags(buf, p.Custom, CustomTagAfterSegments)
+	writeUnknownTags(buf, p.TrailingUnknownTags)
+	for _, part := range p.PendingPartials {
+		writePartial(buf, part, true)
+	}
+	for _, hint := range p.PreloadHints {
+		writePreloadHint(buf, hint)
+	}
+	for _, r := range p.RenditionReports {
+		writeRenditionReport(buf, r)
 	}
 	if p.Closed {
-		p.buf.WriteString("#EXT-X-ENDLIST\n")
+		buf.WriteString("#EXT-X-ENDLIST\n")
 	}
-	return &p.buf
 }
 
 // String here for compatibility with Stringer interface For example
@@ -944,6 +1207,148 @@ func (p *MediaPlaylist) DurationAsInt(yes bool) {
 		version(&p.ver, 3)
 	}
 	p.durationAsInt = yes
+	p.buf.Reset()
+}
+
+// DurationShortest passed to SetDurationPrecision writes durations
+// with the minimal number of decimals representing them exactly.
+const DurationShortest = -1
+
+// SetDurationPrecision sets the number of decimals of EXTINF
+// durations in encoded playlist, DurationShortest writes the shortest
+// representation of the duration, e.g. "6" or "5.005". Durations are
+// formatted as float64 values unlike the default formatting with 3
+// decimals of float32 values. DurationAsInt takes precedence over the
+// precision. This operation does reset playlist cache.
+func (p *MediaPlaylist) SetDurationPrecision(prec int) {
+	if prec < 0 {
+		prec = DurationShortest
+	}
+	p.durationPrec = prec
+	p.durationPrecSet = true
+	p.buf.Reset()
+}
+
+// SetTargetDuration fixes EXT-X-TARGETDURATION of the playlist, so
+// it doesn't grow with durations of appended segments. Segments longer
+// than the target duration violate the spec and are reported by
+// Validate. Zero restores the automatic target duration following
+// appended segments, see also RecomputeTargetDuration. This operation
+// does reset playlist cache.
+func (p *MediaPlaylist) SetTargetDuration(target float64) {
+	p.TargetDuration = target
+	p.targetSet = target > 0
+	p.buf.Reset()
+}
+
+// fitTargetDuration raises the automatic target duration to the
+// rounded duration of the segment.
+func (p *MediaPlaylist) fitTargetDuration(duration float64) {
+	if target := p.roundTargetDuration(duration); !p.targetSet && p.TargetDuration < target {
+		p.TargetDuration = target
+	}
+}
+
+// SetTargetDurationRounding sets the rounding of segment durations
+// applied to EXT-X-TARGETDURATION. This operation does reset
+// playlist cache.
+func (p *MediaPlaylist) SetTargetDurationRounding(mode TargetDurationRounding) {
+	p.targetRounding = mode
+	p.buf.Reset()
+}
+
+// RecomputeTargetDuration sets TargetDuration to the maximum duration
+// of segments in the playlist rounded accordingly with the rounding
+// mode. Unlike Append it may decrease the target duration, e.g.
+// after long segments slid out of the window. It restores the
+// automatic target duration fixed by SetTargetDuration. This
+// operation does reset playlist cache.
+func (p *MediaPlaylist) RecomputeTargetDuration() {
+	var target float64
+	p.eachSegment(func(seg *MediaSegment) {
+		if d := p.roundTargetDuration(seg.Duration); d > target {
+			target = d
+		}
+	})
+	p.TargetDuration = target
+	p.targetSet = false
+	p.buf.Reset()
+}
+
+func (p *MediaPlaylist) roundTargetDuration(duration float64) float64 {
+	if p.targetRounding == TargetDurationRound {
+		return math.Round(duration)
+	}
+	return math.Ceil(duration)
+}
+
+// SetDiscontinuitySequence sets EXT-X-DISCONTINUITY-SEQUENCE of the
+// playlist. Zero sequence is written only when explicitly set or
+// decoded from a playlist. This operation does reset playlist cache.
+func (p *MediaPlaylist) SetDiscontinuitySequence(seq uint64) {
+	p.DiscontinuitySeq = seq
+	p.dseqSet = true
+	p.buf.Reset()
+}
+
+// SetStartTime sets TIME-OFFSET and PRECISE attributes of EXT-X-START
+// tag of the playlist. Zero offset is written only when explicitly
+// set or decoded from a playlist. This operation does reset playlist
+// cache.
+func (p *MediaPlaylist) SetStartTime(offset float64, precise bool) {
+	p.StartTime = offset
+	p.StartTimePrecise = precise
+	p.startSet = true
+	p.buf.Reset()
+}
+
+// writeAdobeCue writes Adobe style EXT-X-CUE tag.
+func writeAdobeCue(buf *bytes.Buffer, scte *SCTE) {
+	buf.WriteString("#EXT-X-CUE:")
+	adobeCueAttrs(scte).writeTo(buf, nil)
+	buf.WriteRune('\n')
+}
+
+// adobeCueAttrs returns attributes of Adobe style EXT-X-CUE tag.
+func adobeCueAttrs(scte *SCTE) attrList {
+	var attrs attrList
+	switch scte.CueType {
+	case SCTE35Cue_Start:
+		attrs.quoted("TYPE", AdobeCueSpliceOut)
+	case SCTE35Cue_End:
+		attrs.quoted("TYPE", AdobeCueSpliceIn)
+	}
+	if scte.ID != "" {
+		attrs.quoted("ID", scte.ID)
+	}
+	if scte.Time != 0 {
+		attrs.add("DURATION", strconv.FormatFloat(scte.Time, 'f', -1, 64))
+	}
+	if scte.Start != 0 {
+		attrs.add("TIME", strconv.FormatFloat(scte.Start, 'f', -1, 64))
+	}
+	if scte.Cue != "" {
+		attrs.quoted("CUE", scte.Cue)
+	}
+	return attrs
+}
+
+// writeStart writes EXT-X-START tag.
+func writeStart(buf *bytes.Buffer, offset float64, precise bool) {
+	buf.WriteString("#EXT-X-START:TIME-OFFSET=")
+	buf.WriteString(strconv.FormatFloat(offset, 'f', -1, 64))
+	if precise {
+		buf.WriteString(",PRECISE=YES")
+	}
+	buf.WriteRune('\n')
+}
+
+// AutoDiscontinuitySeq controls automatic increment of
+// DiscontinuitySeq when Remove or Slide evicts a segment with
+// EXT-X-DISCONTINUITY from a live playlist. It is enabled by default,
+// disable it when the sequence is maintained manually.
+func (p *MediaPlaylist) AutoDiscontinuitySeq(yes bool) {
+	p.manualDSeq = !yes
 }
 
 // Count tells us the number of items that are currently in the media
@@ -952,6 +1357,105 @@ func (p *MediaPlaylist) Count() uint {
 	return p.count
 }
 
+// At returns the i-th segment of the playlist counting from the
+// oldest one or nil if the index is out of range.
+func (p *MediaPlaylist) At(i uint) *MediaSegment {
+	if i >= p.count {
+		return nil
+	}
+	return p.Segments[(p.head+i)%p.capacity]
+}
+
+// eachSegment calls the function for segments of the playlist in
+// order from the oldest to the newest one skipping empty slots.
+func (p *MediaPlaylist) eachSegment(fn func(seg *MediaSegment)) {
+	head := p.head
+	for count := p.count; count > 0; count-- {
+		seg := p.Segments[head]
+		head = (head + 1) % p.capacity
+		if seg != nil {
+			fn(seg)
+		}
+	}
+}
+
+// segments returns the segments of the playlist in order skipping
+// empty slots.
+func (p *MediaPlaylist) segments() []*MediaSegment {
+	segs := make([]*MediaSegment, 0, p.count)
+	p.eachSegment(func(seg *MediaSegment) {
+		segs = append(segs, seg)
+	})
+	return segs
+}
+
+// LiveSegments returns segments of the playlist in order from the
+// oldest to the newest one. Unlike the Segments field which is the
+// ring buffer of the playlist it has neither empty slots nor wrapped
+// order of sliding playlists. The slice is a new one but the segments
+// are shared with the playlist.
+func (p *MediaPlaylist) LiveSegments() []*MediaSegment {
+	return p.segments()
+}
+
+// GetSegment returns the segment with the media sequence number
+// seqID if it is present in the playlist.
+func (p *MediaPlaylist) GetSegment(seqID uint64) (*MediaSegment, bool) {
+	if p.count == 0 {
+		return nil, false
+	}
+	// sequence numbers are usually consecutive so try the direct
+	// position first
+	if first := p.At(0); first != nil && seqID >= first.SeqId && seqID-first.SeqId < uint64(p.count) {
+		if seg := p.At(uint(seqID - first.SeqId)); seg != nil && seg.SeqId == seqID {
+			return seg, true
+		}
+	}
+	for i := uint(0); i < p.count; i++ {
+		if seg := p.At(i); seg != nil && seg.SeqId == seqID {
+			return seg, true
+		}
+	}
+	return nil, false
+}
+
+// GetSegmentBySeqId returns the segment with the media sequence
+// number seqID if it is present in the playlist, the same as
+// GetSegment.
+func (p *MediaPlaylist) GetSegmentBySeqId(seqID uint64) (*MediaSegment, bool) {
+	return p.GetSegment(seqID)
+}
+
+// GetSegmentByTime returns the segment containing the program time t,
+// i.e. t is within the duration of the segment since its program date
+// time. Program times of segments without EXT-X-PROGRAM-DATE-TIME
+// follow durations of the preceding (or the following) segments. It
+// returns false when no segment contains t or the playlist has no
+// program date time.
+func (p *MediaPlaylist) GetSegmentByTime(t time.Time) (*MediaSegment, bool) {
+	segs := p.segments()
+	times := programTimes(segs)
+	if times == nil {
+		return nil, false
+	}
+	if i := segmentAtTime(segs, times, t); i >= 0 {
+		return segs[i], true
+	}
+	return nil, false
+}
+
+// DateRanges returns all EXT-X-DATERANGE tags of the playlist in the
+// playlist order with references to the segments they precede.
+func (p *MediaPlaylist) DateRanges() []DateRangeRef {
+	var refs []DateRangeRef
+	p.eachSegment(func(seg *MediaSegment) {
+		for _, dr := range seg.DateRange {
+			refs = append(refs, DateRangeRef{dr, seg})
+		}
+	})
+	return refs
+}
+
 // Close sliding playlist and make them fixed.
 func (p *MediaPlaylist) Close() {
 	if p.buf.Len() > 0 {
@@ -971,7 +1475,7 @@ func (p *MediaPlaylist) SetDefaultKey(method, uri, iv, keyformat, keyformatversi
 		version(&p.ver, 5)
 	}
 	p.Key = &Key{method, uri, iv, keyformat, keyformatversions}
-
+	p.buf.Reset()
 	return nil
 }
 
@@ -979,8 +1483,18 @@ func (p *MediaPlaylist) SetDefaultKey(method, uri, iv, keyformat, keyformatversi
 // playlist (pointer to MediaPlaylist.Map). Set EXT-X-MAP tag for the
 // whole playlist.
 func (p *MediaPlaylist) SetDefaultMap(uri string, limit, offset int64) {
-	version(&p.ver, 5) // due section 4
+	version(&p.ver, p.mapVersion())
 	p.Map = &Map{uri, limit, offset}
+	p.buf.Reset()
+}
+
+// mapVersion returns the version required by EXT-X-MAP: 5 for
+// I-frame playlists and 6 for others (section 7).
+func (p *MediaPlaylist) mapVersion() uint8 {
+	if p.Iframe {
+		return featureVersions[FeatureMap]
+	}
+	return featureVersions[FeatureMapWithoutIframes]
 }
 
 // SetIframeOnly marks medialist as consists of only I-frames (Intra
@@ -988,6 +1502,7 @@ func (p *MediaPlaylist) SetDefaultMap(uri string, limit, offset int64) {
 func (p *MediaPlaylist) SetIframeOnly() {
 	version(&p.ver, 4) // due section 4.3.3
 	p.Iframe = true
+	p.buf.Reset()
 }
 
 // SetKey sets encryption key for the current segment of media playlist
@@ -1005,6 +1520,7 @@ func (p *MediaPlaylist) SetKey(method, uri, iv, keyformat, keyformatversions str
 	}
 
 	p.Segments[p.last()].Key = &Key{method, uri, iv, keyformat, keyformatversions}
+	p.resetEncoded()
 	return nil
 }
 
@@ -1014,8 +1530,9 @@ func (p *MediaPlaylist) SetMap(uri string, limit, offset int64) error {
 	if p.count == 0 {
 		return errors.New("playlist is empty")
 	}
-	version(&p.ver, 5) // due section 4
+	version(&p.ver, p.mapVersion())
 	p.Segments[p.last()].Map = &Map{uri, limit, offset}
+	p.resetEncoded()
 	return nil
 }
 
@@ -1028,6 +1545,7 @@ func (p *MediaPlaylist) SetRange(limit, offset int64) error {
 	version(&p.ver, 4) // due section 3.4.1
 	p.Segments[p.last()].Limit = limit
 	p.Segments[p.last()].Offset = offset
+	p.resetEncoded()
 	return nil
 }
 
@@ -1044,6 +1562,7 @@ func (p *MediaPlaylist) SetSCTE35(scte35 *SCTE) error {
 		return errors.New("playlist is empty")
 	}
 	p.Segments[p.last()].SCTE = scte35
+	p.resetEncoded()
 	return nil
 }
 
@@ -1058,6 +1577,7 @@ func (p *MediaPlaylist) SetDateRange(drs []*DateRange) error {
 		}
 	}
 	p.Segments[p.last()].DateRange = drs
+	p.resetEncoded()
 	return nil
 }
 
@@ -1070,6 +1590,7 @@ func (p *MediaPlaylist) AppendDateRange(dr *DateRange) error {
 		return errors.New("DateRange ID")
 	}
 	p.Segments[p.last()].DateRange = append(p.Segments[p.last()].DateRange, dr)
+	p.resetEncoded()
 	return nil
 }
 
@@ -1082,7 +1603,14 @@ func (p *MediaPlaylist) SetDiscontinuity() error {
 	if p.count == 0 {
 		return errors.New("playlist is empty")
 	}
-	p.Segments[p.last()].Discontinuity = true
+	seg := p.Segments[p.last()]
+	seg.Discontinuity = true
+	if p.pdtEvery > 0 && seg.ProgramDateTime.IsZero() && !p.pdtLast.IsZero() {
+		// time of segments after discontinuities is written, see AutoProgramDateTime
+		seg.ProgramDateTime = p.pdtLast
+		p.pdtSince = 0
+	}
+	p.resetEncoded()
 	return nil
 }
 
@@ -1094,6 +1622,7 @@ func (p *MediaPlaylist) SetGap() error {
 		return errors.New("playlist is empty")
 	}
 	p.Segments[p.last()].Gap = true
+	p.resetEncoded()
 	return nil
 }
 
@@ -1107,34 +1636,49 @@ func (p *MediaPlaylist) SetProgramDateTime(value time.Time) error {
 		return errors.New("playlist is empty")
 	}
 	p.Segments[p.last()].ProgramDateTime = value
+	if p.pdtEvery > 0 {
+		p.pdtLast = value
+		p.pdtSince = 0
+	}
+	p.resetEncoded()
 	return nil
 }
 
 // SetCustomTag sets the provided tag on the media playlist for its
-// TagName.
+// TagName replacing the tags with the same name, see CustomTags.Set.
 func (p *MediaPlaylist) SetCustomTag(tag CustomTag) {
-	if p.Custom == nil {
-		p.Custom = make(map[string]CustomTag)
-	}
+	p.Custom.Set(tag)
+	p.buf.Reset()
+}
 
-	p.Custom[tag.TagName()] = tag
+// AddCustomTag appends the provided tag to the custom tags of the
+// media playlist, tags with the same name are kept.
+func (p *MediaPlaylist) AddCustomTag(tag CustomTag) {
+	p.Custom.Add(tag)
+	p.buf.Reset()
 }
 
 // SetCustomSegmentTag sets the provided tag on the current media
-// segment for its TagName.
+// segment for its TagName replacing the tags with the same name.
 func (p *MediaPlaylist) SetCustomSegmentTag(tag CustomTag) error {
 	if p.count == 0 {
 		return errors.New("playlist is empty")
 	}
 
-	last := p.Segments[p.last()]
+	p.Segments[p.last()].Custom.Set(tag)
+	p.resetEncoded()
+	return nil
+}
 
-	if last.Custom == nil {
-		last.Custom = make(map[string]CustomTag)
+// AddCustomSegmentTag appends the provided tag to the custom tags of
+// the current media segment, tags with the same name are kept.
+func (p *MediaPlaylist) AddCustomSegmentTag(tag CustomTag) error {
+	if p.count == 0 {
+		return errors.New("playlist is empty")
 	}
 
-	last.Custom[tag.TagName()] = tag
-
+	p.Segments[p.last()].Custom.Add(tag)
+	p.resetEncoded()
 	return nil
 }
 
@@ -1154,11 +1698,448 @@ func (p *MediaPlaylist) WinSize() uint {
 	return p.winsize
 }
 
+// SetOnFull sets the callback invoked when AppendSegment finds the
+// playlist full. The callback may free space (e.g. Remove segments
+// after archiving them) and return nil to let the segment be
+// appended, or return an error to be passed to the caller, allowing
+// to apply backpressure. AppendSegment returns ErrPlaylistFull if the
+// playlist is still full after the callback. Nil removes the callback.
+func (p *MediaPlaylist) SetOnFull(fn func(p *MediaPlaylist, seg *MediaSegment) error) {
+	p.onFull = fn
+}
+
+// SetOnEvict sets the callback invoked with the segment removed from
+// the playlist by Remove, RemoveAt or Slide. The callback owns the
+// segment, so removed segments are not returned to the segment pool
+// while the callback is set. Nil removes the callback.
+func (p *MediaPlaylist) SetOnEvict(fn func(seg *MediaSegment)) {
+	p.onEvict = fn
+}
+
+// SegmentEncodeHook is called by the encoder of the media playlist
+// for each written segment. It may write extra lines (vendor tags,
+// comments) to the buffer, each line must end with the newline.
+type SegmentEncodeHook func(buf *bytes.Buffer, seg *MediaSegment)
+
+// SetBeforeSegmentEncode sets the hook invoked before the tags of each
+// written segment. Output of the hook is cached with the rest of the
+// playlist until the cache is reset. Nil removes the hook. This
+// operation does reset playlist cache.
+func (p *MediaPlaylist) SetBeforeSegmentEncode(fn SegmentEncodeHook) {
+	p.beforeSegment = fn
+	p.buf.Reset()
+}
+
+// SetAfterSegmentEncode sets the hook invoked after the URI of each
+// written segment. Output of the hook is cached with the rest of the
+// playlist until the cache is reset. Nil removes the hook. This
+// operation does reset playlist cache.
+func (p *MediaPlaylist) SetAfterSegmentEncode(fn SegmentEncodeHook) {
+	p.afterSegment = fn
+	p.buf.Reset()
+}
+
 // SetWinSize overwrites the playlist's window size.
 func (p *MediaPlaylist) SetWinSize(winsize uint) error {
-	if winsize > p.capacity {
+	if !p.unbounded && winsize > p.capacity {
 		return errors.New("capacity must be greater than winsize or equal")
 	}
 	p.winsize = winsize
 	return nil
 }
+
+// attr is an attribute of the tag attribute list with the value
+// already formatted for the output.
+type attr struct {
+	name  string
+	value string
+}
+
+// attrList collects attributes of a tag for writing.
+type attrList []attr
+
+func (l *attrList) add(name, value string) {
+	*l = append(*l, attr{name, value})
+}
+
+func (l *attrList) quoted(name, value string) {
+	l.add(name, `"`+value+`"`)
+}
+
+// writeTo writes comma separated attributes to the buffer. When the
+// order is set, attributes listed in it are written first in that
+// order and the rest follow in the list order.
+func (l attrList) writeTo(buf *bytes.Buffer, order []string) {
+	written := make([]bool, len(l))
+	first := true
+	write := func(i int) {
+		if !first {
+			buf.WriteRune(',')
+		}
+		first = false
+		written[i] = true
+		buf.WriteString(l[i].name)
+		buf.WriteRune('=')
+		buf.WriteString(l[i].value)
+	}
+	for _, name := range order {
+		for i := range l {
+			if !written[i] && l[i].name == name {
+				write(i)
+				break
+			}
+		}
+	}
+	for i := range l {
+		if !written[i] {
+			write(i)
+		}
+	}
+}
+
+func writeSessionData(buf *bytes.Buffer, sd *SessionData, order []string) {
+	buf.WriteString("#EXT-X-SESSION-DATA:")
+	sessionDataAttrs(sd).writeTo(buf, order)
+	buf.WriteRune('\n')
+}
+
+// sessionDataAttrs returns attributes of EXT-X-SESSION-DATA tag.
+func sessionDataAttrs(sd *SessionData) attrList {
+	var attrs attrList
+	attrs.quoted("DATA-ID", sd.DataID)
+	if sd.Value != "" {
+		attrs.quoted("VALUE", sd.Value)
+	}
+	// Each EXT-X-SESSION-DATA tag MUST contain either a VALUE or URI attribute, but not both.
+	// In case both are present, default to writing only the VALUE attribute
+	// (see SessionData.Validate and MasterPlaylist.CheckSessionData).
+	if sd.URI != "" && sd.Value == "" {
+		attrs.quoted("URI", sd.URI)
+	}
+	if sd.Language != "" {
+		attrs.quoted("LANGUAGE", sd.Language)
+	}
+	return attrs
+}
+
+func writeAlternative(buf *bytes.Buffer, alt *Alternative, order []string) {
+	buf.WriteString("#EXT-X-MEDIA:")
+	alternativeAttrs(alt).writeTo(buf, order)
+	buf.WriteRune('\n')
+}
+
+// alternativeAttrs returns attributes of EXT-X-MEDIA tag of the
+// rendition.
+func alternativeAttrs(alt *Alternative) attrList {
+	var attrs attrList
+	if alt.Type != "" {
+		attrs.add("TYPE", alt.Type) // Type should not be quoted
+	}
+	if alt.GroupId != "" {
+		attrs.quoted("GROUP-ID", alt.GroupId)
+	}
+	if alt.Name != "" {
+		attrs.quoted("NAME", alt.Name)
+	}
+	if alt.Default {
+		attrs.add("DEFAULT", "YES")
+	} else {
+		attrs.add("DEFAULT", "NO")
+	}
+	if alt.Autoselect != "" {
+		attrs.add("AUTOSELECT", alt.Autoselect)
+	}
+	if alt.Language != "" {
+		attrs.quoted("LANGUAGE", alt.Language)
+	}
+	if alt.AssocLanguage != "" {
+		attrs.quoted("ASSOC-LANGUAGE", alt.AssocLanguage)
+	}
+	if alt.Forced != "" {
+		attrs.add("FORCED", alt.Forced)
+	}
+	if alt.Characteristics != "" {
+		attrs.quoted("CHARACTERISTICS", alt.Characteristics)
+	}
+	if alt.Subtitles != "" {
+		attrs.quoted("SUBTITLES", alt.Subtitles)
+	}
+	if alt.URI != "" {
+		attrs.quoted("URI", alt.URI)
+	}
+	if alt.InstreamId != "" {
+		attrs.quoted("INSTREAM-ID", alt.InstreamId)
+	}
+	if alt.Channels != "" {
+		attrs.quoted("CHANNELS", alt.Channels)
+	}
+	if alt.BitDepth != 0 {
+		attrs.add("BIT-DEPTH", strconv.Itoa(alt.BitDepth))
+	}
+	if alt.SampleRate != 0 {
+		attrs.add("SAMPLE-RATE", strconv.Itoa(alt.SampleRate))
+	}
+	if alt.StableRenditionId != "" {
+		attrs.quoted("STABLE-RENDITION-ID", alt.StableRenditionId)
+	}
+	return attrs
+}
+
+func writeVariant(buf *bytes.Buffer, pl *Variant, args string, order []string) {
+	if pl.Iframe || pl.Images {
+		if pl.Images {
+			buf.WriteString("#EXT-X-IMAGE-STREAM-INF:")
+		} else {
+			buf.WriteString("#EXT-X-I-FRAME-STREAM-INF:")
+		}
+		variantAttrs(pl).writeTo(buf, order)
+		buf.WriteRune('\n')
+		return
+	}
+	buf.WriteString("#EXT-X-STREAM-INF:")
+	variantAttrs(pl).writeTo(buf, order)
+	buf.WriteRune('\n')
+	writeURI(buf, pl.URI, pl.Args, args)
+	buf.WriteRune('\n')
+}
+
+// writeURI writes the URI followed by the non-empty query arguments
+// joined with '?' or '&' whether the URI already has a query.
+func writeURI(buf *bytes.Buffer, uri string, args ...string) {
+	buf.WriteString(uri)
+	query := strings.IndexByte(uri, '?') >= 0
+	for _, a := range args {
+		if a == "" {
+			continue
+		}
+		if query {
+			buf.WriteRune('&')
+		} else {
+			buf.WriteRune('?')
+		}
+		buf.WriteString(a)
+		query = true
+	}
+}
+
+// joinURI returns the URI followed by the non-empty query arguments,
+// see writeURI.
+func joinURI(uri string, args ...string) string {
+	var buf bytes.Buffer
+	writeURI(&buf, uri, args...)
+	return buf.String()
+}
+
+// variantAttrs returns attributes of EXT-X-STREAM-INF tag of the
+// variant or EXT-X-I-FRAME-STREAM-INF (EXT-X-IMAGE-STREAM-INF) tag
+// (with URI) of the I-frame (image) variant.
+func variantAttrs(pl *Variant) attrList {
+	var attrs attrList
+	if pl.ProgramId != 0 || pl.programIdSet {
+		attrs.add("PROGRAM-ID", strconv.FormatUint(uint64(pl.ProgramId), 10))
+	}
+	attrs.add("BANDWIDTH", strconv.FormatUint(uint64(pl.Bandwidth), 10))
+	if pl.AverageBandwidth != 0 {
+		attrs.add("AVERAGE-BANDWIDTH", strconv.FormatUint(uint64(pl.AverageBandwidth), 10))
+	}
+	if pl.Codecs != "" {
+		attrs.quoted("CODECS", pl.Codecs)
+	}
+	if pl.Supplemental != "" {
+		attrs.quoted("SUPPLEMENTAL-CODECS", pl.Supplemental)
+	}
+	if pl.Score != 0 {
+		attrs.add("SCORE", strconv.FormatFloat(pl.Score, 'f', -1, 64))
+	}
+	if res := pl.resolution(); res != "" {
+		attrs.add("RESOLUTION", res) // Resolution should not be quoted
+	}
+	if pl.Iframe || pl.Images {
+		if pl.Video != "" {
+			attrs.quoted("VIDEO", pl.Video)
+		}
+		if pl.VideoRange != "" {
+			attrs.add("VIDEO-RANGE", string(pl.VideoRange))
+		}
+		if pl.HDCPLevel != "" {
+			attrs.add("HDCP-LEVEL", pl.HDCPLevel)
+		}
+		if pl.ReqVideoLayout != "" {
+			attrs.quoted("REQ-VIDEO-LAYOUT", pl.ReqVideoLayout)
+		}
+		if pl.AllowedCPC != "" {
+			attrs.quoted("ALLOWED-CPC", pl.AllowedCPC)
+		}
+		if pl.PathwayId != "" {
+			attrs.quoted("PATHWAY-ID", pl.PathwayId)
+		}
+		if pl.StableVariantId != "" {
+			attrs.quoted("STABLE-VARIANT-ID", pl.StableVariantId)
+		}
+		if pl.URI != "" {
+			attrs.quoted("URI", joinURI(pl.URI, pl.Args))
+		}
+		return attrs
+	}
+	if pl.Audio != "" {
+		attrs.quoted("AUDIO", pl.Audio)
+	}
+	if pl.Video != "" {
+		attrs.quoted("VIDEO", pl.Video)
+	}
+	if pl.Captions != "" {
+		if pl.Captions == "NONE" {
+			attrs.add("CLOSED-CAPTIONS", pl.Captions) // CC should not be quoted when eq NONE
+		} else {
+			attrs.quoted("CLOSED-CAPTIONS", pl.Captions)
+		}
+	}
+	if pl.Subtitles != "" {
+		attrs.quoted("SUBTITLES", pl.Subtitles)
+	}
+	if pl.Name != "" {
+		attrs.quoted("NAME", pl.Name)
+	}
+	if pl.FrameRate != 0 {
+		attrs.add("FRAME-RATE", strconv.FormatFloat(pl.FrameRate, 'f', 3, 64))
+	}
+	if pl.VideoRange != "" {
+		attrs.add("VIDEO-RANGE", string(pl.VideoRange))
+	}
+	if pl.HDCPLevel != "" {
+		attrs.add("HDCP-LEVEL", pl.HDCPLevel)
+	}
+	if pl.ReqVideoLayout != "" {
+		attrs.quoted("REQ-VIDEO-LAYOUT", pl.ReqVideoLayout)
+	}
+	if pl.AllowedCPC != "" {
+		attrs.quoted("ALLOWED-CPC", pl.AllowedCPC)
+	}
+	if pl.PathwayId != "" {
+		attrs.quoted("PATHWAY-ID", pl.PathwayId)
+	}
+	if pl.StableVariantId != "" {
+		attrs.quoted("STABLE-VARIANT-ID", pl.StableVariantId)
+	}
+	return attrs
+}
+
+// sameKey compares keys by value.
+func sameKey(a, b *Key) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Method == b.Method && a.URI == b.URI && a.IV == b.IV &&
+		a.Keyformat == b.Keyformat && a.Keyformatversions == b.Keyformatversions
+}
+
+func writeKey(buf *bytes.Buffer, key *Key, order []string) {
+	buf.WriteString("#EXT-X-KEY:")
+	keyAttrs(key).writeTo(buf, order)
+	buf.WriteRune('\n')
+}
+
+// keyAttrs returns attributes of EXT-X-KEY tag.
+func keyAttrs(key *Key) attrList {
+	var attrs attrList
+	attrs.add("METHOD", key.Method)
+	if key.Method != "NONE" {
+		attrs.quoted("URI", key.URI)
+		if key.IV != "" {
+			attrs.add("IV", key.IV)
+		}
+		if key.Keyformat != "" {
+			attrs.quoted("KEYFORMAT", key.Keyformat)
+		}
+		if key.Keyformatversions != "" {
+			attrs.quoted("KEYFORMATVERSIONS", key.Keyformatversions)
+		}
+	}
+	return attrs
+}
+
+func writeMap(buf *bytes.Buffer, m *Map, order []string) {
+	buf.WriteString("#EXT-X-MAP:")
+	mapAttrs(m).writeTo(buf, order)
+	buf.WriteRune('\n')
+}
+
+// mapAttrs returns attributes of EXT-X-MAP tag.
+func mapAttrs(m *Map) attrList {
+	var attrs attrList
+	attrs.quoted("URI", m.URI)
+	if m.Limit > 0 {
+		attrs.add("BYTERANGE", strconv.FormatInt(m.Limit, 10)+"@"+strconv.FormatInt(m.Offset, 10))
+	}
+	return attrs
+}
+
+func writeDateRange(buf *bytes.Buffer, dr *DateRange, order []string) {
+	buf.WriteString("#EXT-X-DATERANGE:")
+	dateRangeAttrs(dr).writeTo(buf, order)
+	buf.WriteRune('\n')
+}
+
+// dateRangeAttrs returns attributes of EXT-X-DATERANGE tag.
+func dateRangeAttrs(dr *DateRange) attrList {
+	var attrs attrList
+	attrs.quoted("ID", dr.ID)
+	if dr.Class != "" {
+		attrs.quoted("CLASS", dr.Class)
+	}
+	if !dr.StartDate.IsZero() {
+		attrs.quoted("START-DATE", dr.StartDate.Format(DATETIME))
+	}
+	if dr.Cue != "" {
+		attrs.quoted("CUE", dr.Cue)
+	}
+	if !dr.EndDate.IsZero() {
+		attrs.quoted("END-DATE", dr.EndDate.Format(DATETIME))
+	}
+	if dr.Duration > 0 {
+		attrs.add("DURATION", strconv.FormatFloat(dr.Duration, 'f', -1, 64))
+	}
+	if dr.PlannedDuration > 0 {
+		attrs.add("PLANNED-DURATION", strconv.FormatFloat(dr.PlannedDuration, 'f', -1, 64))
+	}
+	if dr.SCTE35Cmd != "" {
+		attrs.add("SCTE35-CMD", dr.SCTE35Cmd)
+	}
+	if dr.SCTE35In != "" {
+		attrs.add("SCTE35-IN", dr.SCTE35In)
+	}
+	if dr.SCTE35Out != "" {
+		attrs.add("SCTE35-OUT", dr.SCTE35Out)
+	}
+	if dr.EndOnNext != "" {
+		attrs.quoted("END-ON-NEXT", dr.EndOnNext)
+	}
+	if dr.XResumeOfsset > 0 {
+		attrs.add("X-RESUME-OFFSET", strconv.FormatFloat(dr.XResumeOfsset, 'f', -1, 64))
+	}
+	if dr.XPlayoutLimit > 0 {
+		attrs.add("X-PLAYOUT-LIMIT", strconv.FormatFloat(dr.XPlayoutLimit, 'f', -1, 64))
+	}
+	if dr.XSnap != "" {
+		attrs.quoted("X-SNAP", dr.XSnap)
+	}
+	if dr.XRestrict != "" {
+		attrs.quoted("X-RESTRICT", dr.XRestrict)
+	}
+	if dr.XAssetURI != "" {
+		attrs.quoted("X-ASSET-URI", dr.XAssetURI)
+	}
+	if dr.XAssetList != "" {
+		attrs.quoted("X-ASSET-LIST", dr.XAssetList)
+	}
+	if dr.XTimelineOccupies != "" {
+		attrs.quoted("X-TIMELINE-OCCUPIES", dr.XTimelineOccupies)
+	}
+	if dr.XTimelineStyle != "" {
+		attrs.quoted("X-TIMELINE-STYLE", dr.XTimelineStyle)
+	}
+	if dr.XContentMayVary != "" {
+		attrs.quoted("X-CONTENT-MAY-VARY", dr.XContentMayVary)
+	}
+	return append(attrs, dr.X.attrs()...)
+}
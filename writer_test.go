@@ -12,6 +12,7 @@ package m3u8
 import (
 	"bufio"
 	"bytes"
+	"errors"
 	"fmt"
 	"os"
 	"reflect"
@@ -106,6 +107,35 @@ func TestAppendSegmentToMediaPlaylist(t *testing.T) {
 	}
 }
 
+// Create new unbounded media playlist
+// Append segments over the initial capacity after removing from the head
+func TestAppendSegmentToUnboundedMediaPlaylist(t *testing.T) {
+	p, err := NewMediaPlaylist(3, 0)
+	if err != nil {
+		t.Fatalf("Create unbounded media playlist failed: %s", err)
+	}
+	for i := 0; i < 5; i++ {
+		if err = p.Append(fmt.Sprintf("t%02d.ts", i), 10, ""); err != nil {
+			t.Fatalf("Append to unbounded playlist failed: %s", err)
+		}
+	}
+	_ = p.Remove()
+	for i := 5; i < 100; i++ {
+		if err = p.Append(fmt.Sprintf("t%02d.ts", i), 10, ""); err != nil {
+			t.Fatalf("Append to unbounded playlist failed: %s", err)
+		}
+	}
+	if p.Count() != 99 {
+		t.Errorf("Expected 99 segments, got %d", p.Count())
+	}
+	for i := uint(0); i < p.Count(); i++ {
+		seg := p.At(i)
+		if want := fmt.Sprintf("t%02d.ts", i+1); seg.URI != want || seg.SeqId != uint64(i+1) {
+			t.Fatalf("Segment %d is %s (%d), expected %s (%d)", i, seg.URI, seg.SeqId, want, i+1)
+		}
+	}
+}
+
 // Create new media playlist
 // Add three segments to media playlist
 // Set discontinuity tag for the 2nd segment.
@@ -534,6 +564,34 @@ func TestMediaPlaylistWithIntegerDurations(t *testing.T) {
 	//	fmt.Println(p.Encode().String())
 }
 
+func TestMediaPlaylistDurationPrecision(t *testing.T) {
+	p, _ := NewMediaPlaylist(0, 3)
+	_ = p.Append("a.ts", 6, "")
+	_ = p.Append("b.ts", 5.005, "")
+	_ = p.Append("c.ts", 4.0000001, "")
+	for _, c := range []struct {
+		prec int
+		want []string
+	}{
+		{DurationShortest, []string{"#EXTINF:6,", "#EXTINF:5.005,", "#EXTINF:4.0000001,"}},
+		{2, []string{"#EXTINF:6.00,", "#EXTINF:5.00,", "#EXTINF:4.00,"}},
+		{7, []string{"#EXTINF:6.0000000,", "#EXTINF:5.0050000,", "#EXTINF:4.0000001,"}},
+	} {
+		p.SetDurationPrecision(c.prec)
+		out := p.String()
+		for _, line := range c.want {
+			if !strings.Contains(out, line+"\n") {
+				t.Errorf("Precision %d: %s not found in\n%s", c.prec, line, out)
+			}
+		}
+	}
+	p.DurationAsInt(true)
+	p.ResetCache()
+	if out := p.String(); !strings.Contains(out, "#EXTINF:5,\n") {
+		t.Errorf("DurationAsInt must take precedence over precision:\n%s", out)
+	}
+}
+
 // Create new media playlist
 // Add 9 segments to media playlist
 // 11 times encode structure to HLS with integer target durations
@@ -597,19 +655,22 @@ func TestLargeMediaPlaylistWithParallel(t *testing.T) {
 			defer wg.Done()
 			f, err := os.Open("sample-playlists/media-playlist-large.m3u8")
 			if err != nil {
-				t.Fatal(err)
+				t.Error(err)
+				return
 			}
 			p, err := NewMediaPlaylist(50000, 50000)
 			if err != nil {
-				t.Fatalf("Create media playlist failed: %s", err)
+				t.Errorf("Create media playlist failed: %s", err)
+				return
 			}
 			if err = p.DecodeFrom(bufio.NewReader(f), true); err != nil {
-				t.Fatal(err)
+				t.Error(err)
+				return
 			}
 
 			actual := p.Encode().Bytes() // disregard output
 			if !bytes.Equal(expect, actual) {
-				t.Fatal("not matched")
+				t.Error("not matched")
 			}
 		}()
 		wg.Wait()
@@ -749,6 +810,35 @@ func TestMediaPlaylist_Slide(t *testing.T) {
 	}
 }
 
+func TestMediaPlaylist_SlideSegment(t *testing.T) {
+	m, e := NewMediaPlaylist(2, 3)
+	if e != nil {
+		t.Fatalf("Failed to create media playlist: %v", e)
+	}
+	pdt := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 4; i++ {
+		seg := &MediaSegment{
+			URI:             fmt.Sprintf("t%02d.ts", i),
+			Duration:        10,
+			ProgramDateTime: pdt.Add(time.Duration(i*10) * time.Second),
+			Key:             &Key{Method: "AES-128", URI: fmt.Sprintf("key%d", i)},
+		}
+		if e = m.SlideSegment(seg); e != nil {
+			t.Fatalf("Slide segment %d failed: %v", i, e)
+		}
+	}
+	if m.Count() != 2 || m.SeqNo != 2 {
+		t.Fatalf("Excepted 2 segments from SeqNo 2, got: %v from %v", m.Count(), m.SeqNo)
+	}
+	seg := m.Segments[m.head]
+	if seg.URI != "t02.ts" || seg.SeqId != 2 || seg.Key.URI != "key2" || !seg.ProgramDateTime.Equal(pdt.Add(20*time.Second)) {
+		t.Errorf("Unexpected segment after slide: %+v", seg)
+	}
+	if !strings.Contains(m.String(), "#EXT-X-KEY:METHOD=AES-128,URI=\"key3\"\n#EXT-X-PROGRAM-DATE-TIME:2019-01-01T00:00:30Z\n") {
+		t.Errorf("Segment attributes are not encoded:\n%s", m.String())
+	}
+}
+
 // Create new master playlist without params
 // Add media playlist
 func TestNewMasterPlaylist(t *testing.T) {
@@ -929,6 +1019,48 @@ func TestEncodeMasterPlaylistWithExistingQuery(t *testing.T) {
 	}
 }
 
+// Create new master playlist
+// Add variants with own query params
+// Ensure params of variants precede params of the playlist
+func TestEncodeMasterPlaylistWithVariantArgs(t *testing.T) {
+	m := NewMasterPlaylist()
+	m.Append("high.m3u8", nil, VariantParams{Bandwidth: 1500000})
+	m.Append("low.m3u8?k1=v1", nil, VariantParams{Bandwidth: 500000})
+	m.Append("iframe.m3u8", nil, VariantParams{Bandwidth: 100000, Iframe: true})
+	m.Variants[0].Args = "token=high"
+	m.Variants[1].Args = "token=low"
+	m.Variants[2].Args = "token=iframe"
+	m.Args = "k3=v3"
+	out := m.String()
+	for _, e := range []string{"\nhigh.m3u8?token=high&k3=v3\n", "\nlow.m3u8?k1=v1&token=low&k3=v3\n", `URI="iframe.m3u8?token=iframe"`} {
+		if !strings.Contains(out, e) {
+			t.Errorf("expected %q in:\n%s", e, out)
+		}
+	}
+}
+
+// Create new media playlist
+// Add segments with own query params and existing query in URI
+// Ensure params are joined correctly
+func TestEncodeMediaPlaylistWithSegmentArgs(t *testing.T) {
+	p, e := NewMediaPlaylist(3, 3)
+	if e != nil {
+		t.Fatalf("Create media playlist failed: %s", e)
+	}
+	p.Append("a.ts", 5, "")
+	p.Append("b.ts?k1=v1", 5, "")
+	p.Append("c.ts", 5, "")
+	p.Segments[0].Args = "token=a"
+	p.Segments[1].Args = "token=b"
+	p.Args = "k2=v2"
+	out := p.String()
+	for _, e := range []string{"\na.ts?token=a&k2=v2\n", "\nb.ts?k1=v1&token=b&k2=v2\n", "\nc.ts?k2=v2\n"} {
+		if !strings.Contains(out, e) {
+			t.Errorf("expected %q in:\n%s", e, out)
+		}
+	}
+}
+
 // Create new master playlist
 // Add media playlist
 // Encode structures to HLS
@@ -1273,7 +1405,7 @@ func ExampleMediaPlaylist_String() {
 // Create new media playlist
 // Add two segments to media playlist
 // Print it
-func ExampleMediaPlaylist_String_Winsize0() {
+func ExampleMediaPlaylist_String_winsize0() {
 	p, _ := NewMediaPlaylist(0, 2)
 	p.Append("test01.ts", 5.0, "")
 	p.Append("test02.ts", 6.0, "")
@@ -1292,7 +1424,7 @@ func ExampleMediaPlaylist_String_Winsize0() {
 // Create new media playlist
 // Add two segments to media playlist
 // Print it
-func ExampleMediaPlaylist_String_Winsize0_VOD() {
+func ExampleMediaPlaylist_String_winsize0_vod() {
 	p, _ := NewMediaPlaylist(0, 2)
 	p.Append("test01.ts", 5.0, "")
 	p.Append("test02.ts", 6.0, "")
@@ -1343,9 +1475,9 @@ func ExampleMasterPlaylist_String_with_hlsv7() {
 	// #EXTM3U
 	// #EXT-X-VERSION:7
 	// #EXT-X-INDEPENDENT-SEGMENTS
-	// #EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=12886714,AVERAGE-BANDWIDTH=7964551,CODECS="hvc1.2.4.L123.B0",RESOLUTION=1920x1080,CLOSED-CAPTIONS=NONE,FRAME-RATE=23.976,VIDEO-RANGE=PQ,HDCP-LEVEL=TYPE-0
+	// #EXT-X-STREAM-INF:BANDWIDTH=12886714,AVERAGE-BANDWIDTH=7964551,CODECS="hvc1.2.4.L123.B0",RESOLUTION=1920x1080,CLOSED-CAPTIONS=NONE,FRAME-RATE=23.976,VIDEO-RANGE=PQ,HDCP-LEVEL=TYPE-0
 	// hdr10_1080/prog_index.m3u8
-	// #EXT-X-I-FRAME-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=905053,AVERAGE-BANDWIDTH=364552,CODECS="hvc1.2.4.L123.B0",RESOLUTION=1920x1080,VIDEO-RANGE=PQ,HDCP-LEVEL=TYPE-0,URI="hdr10_1080/iframe_index.m3u8"
+	// #EXT-X-I-FRAME-STREAM-INF:BANDWIDTH=905053,AVERAGE-BANDWIDTH=364552,CODECS="hvc1.2.4.L123.B0",RESOLUTION=1920x1080,VIDEO-RANGE=PQ,HDCP-LEVEL=TYPE-0,URI="hdr10_1080/iframe_index.m3u8"
 }
 
 func ExampleMediaPlaylist_Segments_scte35_oatcls() {
@@ -1484,3 +1616,624 @@ func TestMediaPlaylistWithoutIndependentSegments(t *testing.T) {
 	// #EXTINF:5.000,
 	// test2.ts
 }
+
+func TestEncodeWindow(t *testing.T) {
+	p, e := NewMediaPlaylist(0, 10)
+	if e != nil {
+		t.Fatalf("Create media playlist failed: %s", e)
+	}
+	for i := 0; i < 5; i++ {
+		if e = p.Append(fmt.Sprintf("test%d.ts", i), 5.0, ""); e != nil {
+			t.Fatalf("Add segment #%d to a media playlist failed: %s", i, e)
+		}
+	}
+	full := p.String()
+	expected := `#EXTM3U
+#EXT-X-VERSION:3
+#EXT-X-MEDIA-SEQUENCE:3
+#EXT-X-TARGETDURATION:5
+#EXTINF:5.000,
+test3.ts
+#EXTINF:5.000,
+test4.ts
+`
+	if out := p.EncodeWindow(2).String(); out != expected {
+		t.Errorf("Unexpected window:\n%s\nwant:\n%s", out, expected)
+	}
+	if p.WinSize() != 0 {
+		t.Errorf("EncodeWindow must not change winsize, got %d", p.WinSize())
+	}
+	if p.String() != full {
+		t.Error("EncodeWindow must not change the cached playlist")
+	}
+	if out := p.EncodeWindow(10).String(); out != full {
+		t.Errorf("Window larger than playlist must contain all segments:\n%s", out)
+	}
+}
+
+// The window must carry the key, map and discontinuity sequence in
+// effect at its first segment.
+func TestEncodeWindowCarriesState(t *testing.T) {
+	p, _ := NewMediaPlaylist(0, 10)
+	for i := 0; i < 5; i++ {
+		_ = p.Append(fmt.Sprintf("test%d.ts", i), 5.0, "")
+		switch i {
+		case 0:
+			_ = p.SetKey("AES-128", "key1", "", "", "")
+			_ = p.SetMap("init.mp4", 0, 0)
+		case 1:
+			_ = p.SetDiscontinuity()
+		}
+	}
+	out := p.EncodeWindow(2).String()
+	for _, e := range []string{
+		"#EXT-X-MEDIA-SEQUENCE:3\n",
+		"#EXT-X-DISCONTINUITY-SEQUENCE:1\n",
+		"#EXT-X-KEY:METHOD=AES-128,URI=\"key1\"\n",
+		"#EXT-X-MAP:URI=\"init.mp4\"\n",
+	} {
+		if !strings.Contains(out, e) {
+			t.Errorf("%q is not found in the window:\n%s", e, out)
+		}
+	}
+	if r, _ := p.EncodeRange(3, 2); r.String() != out {
+		t.Errorf("EncodeWindow differs from EncodeRange:\n%s\nwant:\n%s", out, r)
+	}
+}
+
+func TestEncodeRange(t *testing.T) {
+	p, _ := NewMediaPlaylist(0, 10)
+	p.SeqNo = 100
+	for i := 0; i < 5; i++ {
+		_ = p.Append(fmt.Sprintf("test%d.ts", i), 5.0, "")
+		if i == 1 {
+			_ = p.SetKey("AES-128", "key1", "", "", "")
+			_ = p.SetDiscontinuity()
+		}
+	}
+	p.Close()
+	full := p.String()
+	expected := `#EXTM3U
+#EXT-X-VERSION:3
+#EXT-X-MEDIA-SEQUENCE:102
+#EXT-X-TARGETDURATION:5
+#EXT-X-DISCONTINUITY-SEQUENCE:1
+#EXT-X-KEY:METHOD=AES-128,URI="key1"
+#EXTINF:5.000,
+test2.ts
+#EXTINF:5.000,
+test3.ts
+`
+	out, err := p.EncodeRange(102, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != expected {
+		t.Errorf("Unexpected range:\n%s\nwant:\n%s", out, expected)
+	}
+	if p.String() != full {
+		t.Error("EncodeRange must not change the playlist")
+	}
+	if out, _ = p.EncodeRange(103, 0); !strings.HasSuffix(out.String(), "test4.ts\n#EXT-X-ENDLIST\n") {
+		t.Errorf("Range up to the last segment must be closed:\n%s", out)
+	}
+	if _, err = p.EncodeRange(99, 2); err == nil {
+		t.Error("Expected error for missing sequence ID")
+	}
+}
+
+// PROGRAM-ID must be written only when set explicitly or decoded.
+func TestEncodeMasterPlaylistProgramId(t *testing.T) {
+	m := NewMasterPlaylist()
+	m.Append("unset.m3u8", nil, VariantParams{Bandwidth: 1000})
+	vp := VariantParams{Bandwidth: 2000}
+	vp.SetProgramId(0)
+	m.Append("zero.m3u8", nil, vp)
+	m.Append("one.m3u8", nil, VariantParams{ProgramId: 1, Bandwidth: 3000})
+	out := m.String()
+	for _, line := range []string{
+		"#EXT-X-STREAM-INF:BANDWIDTH=1000\n",
+		"#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=2000\n",
+		"#EXT-X-STREAM-INF:PROGRAM-ID=1,BANDWIDTH=3000\n",
+	} {
+		if !strings.Contains(out, line) {
+			t.Errorf("Master playlist did not contain: %s\nMaster Playlist:\n%v", line, out)
+		}
+	}
+
+	decoded := NewMasterPlaylist()
+	if err := decoded.DecodeFrom(strings.NewReader(out), true); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.String() != out {
+		t.Errorf("PROGRAM-ID not preserved:\n%s\nwant:\n%s", decoded.String(), out)
+	}
+}
+
+func TestMediaPlaylistHooks(t *testing.T) {
+	p, _ := NewMediaPlaylist(2, 2)
+	var evicted []string
+	p.SetOnEvict(func(seg *MediaSegment) {
+		evicted = append(evicted, seg.URI)
+	})
+	_ = p.Append("t00.ts", 10, "")
+	_ = p.Append("t01.ts", 10, "")
+	if err := p.Append("t02.ts", 10, ""); err != ErrPlaylistFull {
+		t.Errorf("Expected ErrPlaylistFull without OnFull callback, got %v", err)
+	}
+
+	// archive the oldest segment to free space
+	p.SetOnFull(func(p *MediaPlaylist, seg *MediaSegment) error {
+		return p.Remove()
+	})
+	if err := p.Append("t02.ts", 10, ""); err != nil {
+		t.Fatalf("Append failed: %v", err)
+	}
+	p.Slide("t03.ts", 10, "")
+	if strings.Join(evicted, ",") != "t00.ts,t01.ts" {
+		t.Errorf("Unexpected evicted segments: %v", evicted)
+	}
+
+	// backpressure
+	errBusy := errors.New("busy")
+	p.SetOnFull(func(p *MediaPlaylist, seg *MediaSegment) error {
+		return errBusy
+	})
+	if err := p.Append("t04.ts", 10, ""); err != errBusy {
+		t.Errorf("Expected error from OnFull, got %v", err)
+	}
+	p.SetOnFull(func(p *MediaPlaylist, seg *MediaSegment) error {
+		return nil
+	})
+	if err := p.Append("t04.ts", 10, ""); err != ErrPlaylistFull {
+		t.Errorf("Expected ErrPlaylistFull when OnFull frees no space, got %v", err)
+	}
+}
+
+func TestMediaPlaylistAutoDiscontinuitySeq(t *testing.T) {
+	p, _ := NewMediaPlaylist(2, 3)
+	_ = p.Append("t00.ts", 10, "")
+	_ = p.Append("t01.ts", 10, "")
+	_ = p.SetDiscontinuity()
+	p.Slide("t02.ts", 10, "")
+	if p.DiscontinuitySeq != 0 {
+		t.Errorf("Expected DiscontinuitySeq 0, got %d", p.DiscontinuitySeq)
+	}
+	p.Slide("t03.ts", 10, "")
+	if p.DiscontinuitySeq != 1 {
+		t.Errorf("Expected DiscontinuitySeq 1 after discontinuity evicted, got %d", p.DiscontinuitySeq)
+	}
+	if !strings.Contains(p.String(), "#EXT-X-DISCONTINUITY-SEQUENCE:1\n") {
+		t.Errorf("Discontinuity sequence not encoded:\n%s", p.String())
+	}
+
+	p.AutoDiscontinuitySeq(false)
+	_ = p.SetDiscontinuity()
+	p.Slide("t04.ts", 10, "")
+	p.Slide("t05.ts", 10, "")
+	if p.DiscontinuitySeq != 1 {
+		t.Errorf("Expected DiscontinuitySeq unchanged, got %d", p.DiscontinuitySeq)
+	}
+}
+
+func TestMediaPlaylistTargetDurationRounding(t *testing.T) {
+	p, _ := NewMediaPlaylist(3, 3)
+	_ = p.Append("t00.ts", 6.4, "")
+	if p.TargetDuration != 7 {
+		t.Errorf("Expected ceil target duration 7, got %v", p.TargetDuration)
+	}
+
+	p, _ = NewMediaPlaylist(3, 3)
+	p.SetTargetDurationRounding(TargetDurationRound)
+	_ = p.Append("t00.ts", 6.4, "")
+	_ = p.Append("t01.ts", 5.6, "")
+	if p.TargetDuration != 6 {
+		t.Errorf("Expected rounded target duration 6, got %v", p.TargetDuration)
+	}
+	_ = p.Append("t02.ts", 9.5, "")
+	if !strings.Contains(p.String(), "#EXT-X-TARGETDURATION:10\n") {
+		t.Errorf("Expected target duration 10:\n%s", p.String())
+	}
+	p.Slide("t03.ts", 4, "")
+	p.Slide("t04.ts", 4, "")
+	p.Slide("t05.ts", 4, "")
+	p.RecomputeTargetDuration()
+	if p.TargetDuration != 4 {
+		t.Errorf("Expected recomputed target duration 4, got %v", p.TargetDuration)
+	}
+}
+
+func TestMediaPlaylistSetTargetDuration(t *testing.T) {
+	p, _ := NewMediaPlaylist(3, 3)
+	p.SetTargetDuration(6)
+	_ = p.Append("t00.ts", 6, "")
+	_ = p.Append("t01.ts", 8, "")
+	if !strings.Contains(p.String(), "#EXT-X-TARGETDURATION:6\n") {
+		t.Errorf("Expected fixed target duration 6:\n%s", p)
+	}
+	if len(p.Validate()) == 0 {
+		t.Error("Expected violation for the segment longer than the target duration")
+	}
+	p.RecomputeTargetDuration()
+	_ = p.Append("t02.ts", 9, "")
+	if p.TargetDuration != 9 {
+		t.Errorf("Expected automatic target duration 9, got %v", p.TargetDuration)
+	}
+}
+
+func TestEncodeMasterPlaylistAlternativesPlacement(t *testing.T) {
+	audEn := &Alternative{Type: "AUDIO", GroupId: "aud", Name: "en"}
+	sub := &Alternative{Type: "SUBTITLES", GroupId: "subs", Name: "en"}
+	audFr := &Alternative{Type: "AUDIO", GroupId: "aud", Name: "fr"}
+	m := NewMasterPlaylist()
+	m.Append("low.m3u8", nil, VariantParams{Bandwidth: 1000, Alternatives: []*Alternative{audEn, sub}})
+	m.Append("high.m3u8", nil, VariantParams{Bandwidth: 2000, Alternatives: []*Alternative{audEn, sub, audFr}})
+
+	tags := func() string {
+		var out []string
+		for _, line := range strings.Split(m.String(), "\n") {
+			switch {
+			case strings.HasPrefix(line, "#EXT-X-MEDIA:"):
+				attrs := DecodeAttributeList(line[13:])
+				out = append(out, attrs["TYPE"]+"/"+attrs["NAME"])
+			case strings.HasPrefix(line, "#EXT-X-STREAM-INF:"):
+				out = append(out, "V")
+			}
+		}
+		return strings.Join(out, " ")
+	}
+	if got := tags(); got != "AUDIO/en SUBTITLES/en V AUDIO/fr V" {
+		t.Errorf("Unexpected interleaved layout: %s", got)
+	}
+	m.SetAlternativesPlacement(AlternativesFirst)
+	if got := tags(); got != "AUDIO/en SUBTITLES/en AUDIO/fr V V" {
+		t.Errorf("Unexpected layout: %s", got)
+	}
+	m.SetAlternativesPlacement(AlternativesGrouped)
+	if got := tags(); got != "AUDIO/en AUDIO/fr SUBTITLES/en V V" {
+		t.Errorf("Unexpected grouped layout: %s", got)
+	}
+}
+
+func TestMediaPlaylistSegmentAccessors(t *testing.T) {
+	p, _ := NewMediaPlaylist(3, 3)
+	if p.At(0) != nil {
+		t.Error("Expected nil segment in empty playlist")
+	}
+	if _, ok := p.GetSegment(0); ok {
+		t.Error("Expected no segments in empty playlist")
+	}
+	for i := 0; i < 5; i++ {
+		p.Slide(fmt.Sprintf("t%02d.ts", i), 10, "")
+	}
+	// ring buffer is rotated: segments 2, 3, 4
+	for i, want := range []string{"t02.ts", "t03.ts", "t04.ts"} {
+		if seg := p.At(uint(i)); seg == nil || seg.URI != want {
+			t.Errorf("At(%d) = %v, want %s", i, seg, want)
+		}
+	}
+	if p.At(3) != nil {
+		t.Error("Expected nil segment out of range")
+	}
+	var uris []string
+	for _, seg := range p.LiveSegments() {
+		uris = append(uris, seg.URI)
+	}
+	if got := strings.Join(uris, ","); got != "t02.ts,t03.ts,t04.ts" {
+		t.Errorf("LiveSegments() = %s, want t02.ts,t03.ts,t04.ts", got)
+	}
+	if seg, ok := p.GetSegment(3); !ok || seg.URI != "t03.ts" {
+		t.Errorf("GetSegment(3) = %v, %v", seg, ok)
+	}
+	if _, ok := p.GetSegment(1); ok {
+		t.Error("Expected evicted segment not found")
+	}
+	if _, ok := p.GetSegment(5); ok {
+		t.Error("Expected future segment not found")
+	}
+	if seg, ok := p.GetSegmentBySeqId(4); !ok || seg.URI != "t04.ts" {
+		t.Errorf("GetSegmentBySeqId(4) = %v, %v", seg, ok)
+	}
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	if _, ok := p.GetSegmentByTime(start); ok {
+		t.Error("Expected no segment in playlist without program date time")
+	}
+	// program date time of the middle segment, times of the rest follow durations
+	p.At(1).ProgramDateTime = start
+	for _, c := range []struct {
+		at   time.Duration
+		want string
+	}{
+		{-10 * time.Second, "t02.ts"},
+		{-time.Nanosecond, "t02.ts"},
+		{0, "t03.ts"},
+		{15 * time.Second, "t04.ts"},
+	} {
+		if seg, ok := p.GetSegmentByTime(start.Add(c.at)); !ok || seg.URI != c.want {
+			t.Errorf("GetSegmentByTime(%v) = %v, %v, want %s", c.at, seg, ok, c.want)
+		}
+	}
+	if _, ok := p.GetSegmentByTime(start.Add(20 * time.Second)); ok {
+		t.Error("Expected no segment after the end of the playlist")
+	}
+}
+
+// Keys equal by value must not be written again.
+func TestEncodeMediaPlaylistKeyDeduplication(t *testing.T) {
+	p, _ := NewMediaPlaylist(5, 5)
+	_ = p.SetDefaultKey("AES-128", "key1", "", "", "")
+	for i, uri := range []string{"key1", "key1", "key2", "key2", "key1"} {
+		_ = p.Append(fmt.Sprintf("t%02d.ts", i), 10, "")
+		_ = p.SetKey("AES-128", uri, "", "", "")
+	}
+	var keys []string
+	for _, line := range strings.Split(p.String(), "\n") {
+		if strings.HasPrefix(line, "#EXT-X-KEY:") {
+			keys = append(keys, DecodeAttributeList(line[11:])["URI"])
+		}
+	}
+	if got := strings.Join(keys, " "); got != "key1 key2 key1" {
+		t.Errorf("Unexpected keys written: %s\n%s", got, p.String())
+	}
+}
+
+func TestEncodeExplicitZeroHeaderTags(t *testing.T) {
+	p, e := NewMediaPlaylist(1, 1)
+	if e != nil {
+		t.Fatalf("Create media playlist failed: %s", e)
+	}
+	p.Append("a.ts", 10, "")
+	out := p.String()
+	if strings.Contains(out, "#EXT-X-DISCONTINUITY-SEQUENCE") || strings.Contains(out, "#EXT-X-START") {
+		t.Errorf("zero values must not be written by default:\n%s", out)
+	}
+	p.SetDiscontinuitySequence(0)
+	p.SetStartTime(0, true)
+	out = p.String()
+	if !strings.Contains(out, "#EXT-X-DISCONTINUITY-SEQUENCE:0\n") || !strings.Contains(out, "#EXT-X-START:TIME-OFFSET=0,PRECISE=YES\n") {
+		t.Errorf("explicitly set zero values must be written:\n%s", out)
+	}
+
+	src := "#EXTM3U\n#EXT-X-VERSION:3\n#EXT-X-MEDIA-SEQUENCE:0\n#EXT-X-TARGETDURATION:10\n#EXT-X-START:TIME-OFFSET=0\n#EXT-X-DISCONTINUITY-SEQUENCE:0\n#EXTINF:10.000,\na.ts\n#EXT-X-ENDLIST\n"
+	pl, _, err := DecodeFrom(strings.NewReader(src), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pl.String() != src {
+		t.Errorf("decoded zero values are not written:\n%s", pl.String())
+	}
+}
+
+func TestSegmentEncodeHooks(t *testing.T) {
+	p, _ := NewMediaPlaylist(3, 3)
+	_ = p.Append("t00.ts", 10, "")
+	_ = p.Append("t01.ts", 10, "")
+	p.SetBeforeSegmentEncode(func(buf *bytes.Buffer, seg *MediaSegment) {
+		fmt.Fprintf(buf, "#EXT-X-VENDOR-SEQ:%d\n", seg.SeqId)
+	})
+	p.SetAfterSegmentEncode(func(buf *bytes.Buffer, seg *MediaSegment) {
+		buf.WriteString("## end of " + seg.URI + "\n")
+	})
+	expected := `#EXT-X-VENDOR-SEQ:0
+#EXTINF:10.000,
+t00.ts
+## end of t00.ts
+#EXT-X-VENDOR-SEQ:1
+#EXTINF:10.000,
+t01.ts
+## end of t01.ts
+`
+	if out := p.String(); !strings.HasSuffix(out, expected) {
+		t.Errorf("Hooks output not found:\n%s", out)
+	}
+	p.SetBeforeSegmentEncode(nil)
+	p.SetAfterSegmentEncode(nil)
+	if out := p.String(); strings.Contains(out, "VENDOR") || strings.Contains(out, "end of") {
+		t.Errorf("Hooks not removed:\n%s", out)
+	}
+}
+
+func TestEncodeMasterPlaylistWithScoreAndSupplementalCodecs(t *testing.T) {
+	m := NewMasterPlaylist()
+	m.Append("dv/index.m3u8", nil, VariantParams{
+		Bandwidth:    8000000,
+		Codecs:       "hvc1.2.4.L153.b0",
+		Supplemental: "dvh1.08.07/db4h",
+		Score:        2.5,
+		HDCPLevel:    "TYPE-1",
+		AllowedCPC:   "com.apple.streamingkeydelivery:AppleMain/Main,com.widevine:HW",
+	})
+	m.Append("dv/iframes.m3u8", nil, VariantParams{Bandwidth: 800000, Supplemental: "dvh1.08.07/db4h", Score: 1, Iframe: true})
+	out := m.String()
+	for _, line := range []string{
+		`#EXT-X-STREAM-INF:BANDWIDTH=8000000,CODECS="hvc1.2.4.L153.b0",SUPPLEMENTAL-CODECS="dvh1.08.07/db4h",SCORE=2.5,HDCP-LEVEL=TYPE-1,ALLOWED-CPC="com.apple.streamingkeydelivery:AppleMain/Main,com.widevine:HW"`,
+		`#EXT-X-I-FRAME-STREAM-INF:BANDWIDTH=800000,SUPPLEMENTAL-CODECS="dvh1.08.07/db4h",SCORE=1,URI="dv/iframes.m3u8"`,
+	} {
+		if !strings.Contains(out, line+"\n") {
+			t.Errorf("expected %s in output:\n%s", line, out)
+		}
+	}
+	p := NewMasterPlaylist()
+	if err := p.DecodeFrom(strings.NewReader(out), true); err != nil {
+		t.Fatal(err)
+	}
+	for i, v := range p.Variants {
+		if v.Supplemental != m.Variants[i].Supplemental || v.Score != m.Variants[i].Score || v.AllowedCPC != m.Variants[i].AllowedCPC {
+			t.Errorf("unexpected decoded variant: %+v", v.VariantParams)
+		}
+	}
+}
+
+func TestEncodeMasterPlaylistWithStartTime(t *testing.T) {
+	m := NewMasterPlaylist()
+	m.Append("low.m3u8", nil, VariantParams{Bandwidth: 1000000})
+	m.SetStartTime(-12.5, true)
+	out := m.String()
+	if !strings.Contains(out, "#EXT-X-START:TIME-OFFSET=-12.5,PRECISE=YES\n") {
+		t.Fatalf("unexpected output:\n%s", out)
+	}
+	p, listType, err := DecodeFrom(strings.NewReader(out), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if listType != MASTER {
+		t.Fatal("expected master playlist")
+	}
+	if d := p.(*MasterPlaylist); d.StartTime != -12.5 || !d.StartTimePrecise {
+		t.Errorf("unexpected decoded start: %v %v", d.StartTime, d.StartTimePrecise)
+	}
+
+	m.SetStartTime(0, false)
+	if !strings.Contains(m.String(), "#EXT-X-START:TIME-OFFSET=0\n") {
+		t.Errorf("expected explicitly set zero offset in output:\n%s", m.String())
+	}
+}
+
+func TestRemoveAtMediaPlaylist(t *testing.T) {
+	p, _ := NewMediaPlaylist(0, 5)
+	p.SeqNo = 10
+	for i := 0; i < 5; i++ {
+		_ = p.Append(fmt.Sprintf("t%02d.ts", i), 6, "")
+	}
+	p.Segments[2].Key = &Key{Method: "AES-128", URI: "key2"}
+	p.Segments[2].Discontinuity = true
+	if err := p.RemoveAt(2); err != nil {
+		t.Fatal(err)
+	}
+	expected := `#EXTM3U
+#EXT-X-VERSION:3
+#EXT-X-MEDIA-SEQUENCE:10
+#EXT-X-TARGETDURATION:6
+#EXTINF:6.000,
+t00.ts
+#EXTINF:6.000,
+t01.ts
+#EXT-X-KEY:METHOD=AES-128,URI="key2"
+#EXT-X-DISCONTINUITY
+#EXTINF:6.000,
+t03.ts
+#EXTINF:6.000,
+t04.ts
+`
+	if p.String() != expected {
+		t.Errorf("Unexpected playlist:\n%s\nwant:\n%s", p, expected)
+	}
+	if seg := p.At(3); seg.SeqId != 13 {
+		t.Errorf("Last segment has SeqId %d, expected 13", seg.SeqId)
+	}
+	if err := p.RemoveBySeqId(10); err != nil {
+		t.Fatal(err)
+	}
+	if p.SeqNo != 11 || p.At(0).SeqId != 11 || p.Count() != 3 {
+		t.Errorf("Removal of the head: SeqNo %d, first SeqId %d, count %d", p.SeqNo, p.At(0).SeqId, p.Count())
+	}
+	if err := p.RemoveBySeqId(13); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.RemoveBySeqId(13); err == nil {
+		t.Error("Expected error for removed segment")
+	}
+	if err := p.RemoveAt(2); err == nil {
+		t.Error("Expected error for index out of range")
+	}
+	// appending continues sequence IDs after removal of the last segment
+	_ = p.Append("t05.ts", 6, "")
+	if seg := p.At(2); seg.URI != "t05.ts" || seg.SeqId != 13 {
+		t.Errorf("Appended segment %s has SeqId %d, expected 13", seg.URI, seg.SeqId)
+	}
+}
+
+// Incremental encoding of appended segments must match the full
+// encoding of the playlist.
+func TestEncodeMediaPlaylistIncrementally(t *testing.T) {
+	p, _ := NewMediaPlaylist(0, 0)
+	p.MediaType = EVENT
+	p.SetIncrementalEncoding(true)
+	full := func() string {
+		return p.Clone().Encode().String()
+	}
+	for i := 0; i < 20; i++ {
+		_ = p.Append(fmt.Sprintf("t%02d.ts", i), 4, "")
+		switch i {
+		case 3:
+			_ = p.SetKey("AES-128", "key1", "", "", "")
+		case 7:
+			p.Segments[p.last()].Bitrate = 1000
+		case 10:
+			_ = p.Append("long.ts", 9, "") // target duration grows
+		case 12:
+			p.PreloadHints = []*PreloadHint{{Type: PreloadHintPart, URI: "next.mp4"}}
+		}
+		if got, want := p.String(), full(); got != want {
+			t.Fatalf("Segment %d: incremental encoding differs:\n%s\nwant:\n%s", i, got, want)
+		}
+	}
+	if p.enc == nil || p.enc.count != p.Count() {
+		t.Error("Expected incremental encoding of appended segments")
+	}
+	p.Close()
+	if got, want := p.String(), full(); got != want {
+		t.Errorf("Closed playlist differs:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+// Incremental encoding must rewrite the header when the version is
+// raised after the output was cached.
+func TestEncodeIncrementallyVersionChange(t *testing.T) {
+	p, _ := NewMediaPlaylist(0, 0)
+	p.SetIncrementalEncoding(true)
+	_ = p.Append("a.ts", 4, "")
+	p.Encode()
+	_ = p.Append("b.ts", 4, "")
+	_ = p.SetRange(100, 0)
+	if out := p.String(); !strings.Contains(out, "#EXT-X-VERSION:4\n") {
+		t.Errorf("Expected version 4 for EXT-X-BYTERANGE:\n%s", out)
+	}
+	_ = p.Append("c.ts", 4, "")
+	_ = p.SetKey("AES-128", "key", "", "com.example", "1")
+	out := p.String()
+	if !strings.Contains(out, "#EXT-X-VERSION:5\n") {
+		t.Errorf("Expected version 5 for KEYFORMAT:\n%s", out)
+	}
+	if want := p.Clone().Encode().String(); out != want {
+		t.Errorf("Incremental encoding differs:\n%s\nwant:\n%s", out, want)
+	}
+}
+
+// Changes of the playlist between appends must be encoded as the full
+// encoding does, incrementally or not.
+func TestEncodeIncrementallyChanges(t *testing.T) {
+	for _, incremental := range []bool{false, true} {
+		for name, change := range map[string]func(p *MediaPlaylist){
+			"duration as int": func(p *MediaPlaylist) { p.DurationAsInt(true) },
+			"custom tag": func(p *MediaPlaylist) {
+				p.SetCustomTag(&MockCustomTag{name: "#CustomTag", encodedString: "#CustomTag"})
+			},
+			"args": func(p *MediaPlaylist) { p.Args = "tok" },
+			"custom segment tag": func(p *MediaPlaylist) {
+				_ = p.AddCustomSegmentTag(&MockCustomTag{name: "#CustomSegTag", encodedString: "#CustomSegTag"})
+			},
+		} {
+			p, _ := NewMediaPlaylist(0, 0)
+			p.SetIncrementalEncoding(incremental)
+			_ = p.Append("a.ts", 4.5, "")
+			p.Encode()
+			change(p)
+			_ = p.Append("b.ts", 4.5, "")
+			if got, want := p.String(), p.Clone().Encode().String(); got != want {
+				t.Errorf("%s (incremental %t): encoding differs:\n%s\nwant:\n%s", name, incremental, got, want)
+			}
+		}
+	}
+
+	// direct changes of encoded segments are written by the next
+	// encoding unless the incremental encoding is enabled
+	p, _ := NewMediaPlaylist(0, 0)
+	_ = p.Append("a.ts", 4, "")
+	p.Encode()
+	p.Segments[0].Title = "changed"
+	_ = p.Append("b.ts", 4, "")
+	if out := p.String(); !strings.Contains(out, "#EXTINF:4.000,changed\n") {
+		t.Errorf("Changed title is lost:\n%s", out)
+	}
+}